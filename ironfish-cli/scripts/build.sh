@@ -27,6 +27,12 @@ cat <<< "$(jq --arg gh "$GIT_HASH" '.gitHash = $gh' < ironfish-cli/package.json)
 echo "Inserting GIT hash into ironfish/package.json as gitHash"
 cat <<< "$(jq --arg gh "$GIT_HASH" '.gitHash = $gh' < ironfish/package.json)" > ironfish/package.json
 
+# The public key updates are verified with, builds without one can't update themselves
+if [ -n "${RELEASE_SIGNING_KEY:-}" ]; then
+    echo "Inserting release signing key into ironfish-cli/package.json as releaseSigningKey"
+    cat <<< "$(jq --arg key "$RELEASE_SIGNING_KEY" '.releaseSigningKey = $key' < ironfish-cli/package.json)" > ironfish-cli/package.json
+fi
+
 echo "Installing from lockfile"
 yarn --non-interactive --frozen-lockfile
 
@@ -9,12 +9,17 @@ import {
   Logger,
   RpcConnectionError,
 } from '@ironfish/sdk'
-import { Command, Config } from '@oclif/core'
+import { CliUx, Command, Config } from '@oclif/core'
+import { format } from 'util'
 import {
   ConfigFlagKey,
   DatabaseFlag,
   DatabaseFlagKey,
   DataDirFlagKey,
+  JsonFlagKey,
+  NetworkFlagKey,
+  ProfileFlagKey,
+  RpcAuthFlagKey,
   RpcTcpHostFlagKey,
   RpcTcpPortFlagKey,
   RpcTcpSecureFlag,
@@ -27,16 +32,25 @@ import {
   RpcUseTcpFlagKey,
   VerboseFlag,
   VerboseFlagKey,
+  YesFlagKey,
 } from './flags'
 import { IronfishCliPKG } from './package'
 import { hasUserResponseError } from './utils'
 
+// Put the name or alias of an account in this file to use it for commands run
+// in the directory
+export const ACCOUNT_FILE_NAME = '.ironfish-account'
+
 export type SIGNALS = 'SIGTERM' | 'SIGINT' | 'SIGUSR2'
 
 export type FLAGS =
   | typeof DataDirFlagKey
   | typeof DatabaseFlagKey
   | typeof ConfigFlagKey
+  | typeof JsonFlagKey
+  | typeof NetworkFlagKey
+  | typeof ProfileFlagKey
+  | typeof RpcAuthFlagKey
   | typeof RpcUseIpcFlagKey
   | typeof RpcUseTcpFlagKey
   | typeof RpcTcpHostFlagKey
@@ -44,6 +58,7 @@ export type FLAGS =
   | typeof RpcTcpSecureFlagKey
   | typeof RpcTcpTlsFlagKey
   | typeof VerboseFlagKey
+  | typeof YesFlagKey
 
 export abstract class IronfishCommand extends Command {
   // Yes, this is disabling the type system but any code
@@ -62,6 +77,23 @@ export abstract class IronfishCommand extends Command {
    */
   closing = false
 
+  /**
+   * How long closeFromSignal has to finish before the process is force closed
+   */
+  closeTimeoutMs = 3000
+
+  /**
+   * Set to true when the command was run with --json. Only commands that declare
+   * `JsonFlag` accept it, and they write their result with `logJson` while `log`
+   * writes to stderr
+   */
+  json = false
+
+  /**
+   * Set to true when the command was run with --yes or IRONFISH_ASSUME_YES
+   */
+  assumeYes = false
+
   constructor(argv: string[], config: Config) {
     super(argv, config)
     this.logger = createRootLogger().withTag(this.ctor.id)
@@ -94,6 +126,13 @@ export abstract class IronfishCommand extends Command {
     // Get the flags from the flag object which is unknown
     const dataDirFlag = getFlag(flags, DataDirFlagKey)
     const configFlag = getFlag(flags, ConfigFlagKey)
+    const profileFlag = getFlag(flags, ProfileFlagKey)
+
+    const jsonFlag = getFlag(flags, JsonFlagKey)
+    this.json = jsonFlag === true
+
+    const yesFlag = getFlag(flags, YesFlagKey)
+    this.assumeYes = yesFlag === true
 
     const configOverrides: Partial<ConfigOptions> = {}
 
@@ -102,6 +141,11 @@ export abstract class IronfishCommand extends Command {
       configOverrides.databaseName = databaseNameFlag
     }
 
+    const networkFlag = getFlag(flags, NetworkFlagKey)
+    if (typeof networkFlag === 'string') {
+      configOverrides.network = networkFlag
+    }
+
     const rpcConnectIpcFlag = getFlag(flags, RpcUseIpcFlagKey)
     if (typeof rpcConnectIpcFlag === 'boolean' && rpcConnectIpcFlag !== RpcUseIpcFlag.default) {
       configOverrides.enableRpcIpc = rpcConnectIpcFlag
@@ -135,6 +179,11 @@ export abstract class IronfishCommand extends Command {
       configOverrides.enableRpcTls = rpcTcpTlsFlag
     }
 
+    const rpcAuthFlag = getFlag(flags, RpcAuthFlagKey)
+    if (typeof rpcAuthFlag === 'string') {
+      configOverrides.rpcAuthToken = rpcAuthFlag
+    }
+
     const verboseFlag = getFlag(flags, VerboseFlagKey)
     if (typeof verboseFlag === 'boolean' && verboseFlag !== VerboseFlag.default) {
       configOverrides.logLevel = '*:verbose'
@@ -145,10 +194,93 @@ export abstract class IronfishCommand extends Command {
       configOverrides: configOverrides,
       configName: typeof configFlag === 'string' ? configFlag : undefined,
       dataDir: typeof dataDirFlag === 'string' ? dataDirFlag : undefined,
+      profile: typeof profileFlag === 'string' ? profileFlag : undefined,
       logger: this.logger,
     })
   }
 
+  log(message = '', ...args: unknown[]): void {
+    if (this.json) {
+      process.stderr.write(format(message, ...args) + '\n')
+      return
+    }
+
+    super.log(message, ...args)
+  }
+
+  /**
+   * Writes `data` to stdout as JSON. Bigints are written as strings so the
+   * output can be parsed without losing precision.
+   */
+  logJson(data: unknown): void {
+    const output = JSON.stringify(
+      data,
+      (_, value: unknown) => (typeof value === 'bigint' ? value.toString() : value),
+      2,
+    )
+
+    process.stdout.write(output + '\n')
+  }
+
+  /**
+   * The account a command should use when `account` was not passed. This is
+   * the first `.ironfish-account` file found in the working directory or its
+   * parents, then `defaultAccount` in the config. Returns undefined to use the
+   * wallet's default account. Aliases are resolved by the node.
+   */
+  async getAccountName(account?: string): Promise<string | undefined> {
+    if (account) {
+      return account
+    }
+
+    const files = this.sdk.fileSystem
+    let dir = files.resolve(process.cwd())
+
+    for (;;) {
+      const path = files.join(dir, ACCOUNT_FILE_NAME)
+      if (await files.exists(path)) {
+        const name = (await files.readFile(path)).trim()
+        if (name) {
+          return name
+        }
+      }
+
+      const parent = files.dirname(dir)
+      if (parent === dir) {
+        break
+      }
+      dir = parent
+    }
+
+    return this.sdk.config.get('defaultAccount') || undefined
+  }
+
+  /**
+   * Returns true if confirmation prompts should be skipped, either because the
+   * command was run with --yes or it's in the `skipConfirmations` config. A
+   * command in the `requireConfirmations` config always prompts.
+   */
+  shouldAssumeYes(): boolean {
+    const id = this.id ?? ''
+
+    if (this.sdk.config.get('requireConfirmations').includes(id)) {
+      return false
+    }
+
+    return this.assumeYes || this.sdk.config.get('skipConfirmations').includes(id)
+  }
+
+  /**
+   * Asks the user to confirm `message` unless confirmations are being skipped
+   */
+  async confirm(message: string): Promise<boolean> {
+    if (this.shouldAssumeYes()) {
+      return true
+    }
+
+    return CliUx.ux.confirm(message)
+  }
+
   listenForSignals(): void {
     const signals: SIGNALS[] = ['SIGINT', 'SIGTERM', 'SIGUSR2']
 
@@ -158,11 +290,10 @@ export abstract class IronfishCommand extends Command {
           return
         }
 
-        // Allow 3 seconds for graceful termination
         setTimeout(() => {
-          this.log('Force closing after 3 seconds')
+          this.log(`Force closing after ${this.closeTimeoutMs / 1000} seconds`)
           process.exit(1)
-        }, 3000).unref()
+        }, this.closeTimeoutMs).unref()
 
         this.closing = true
         const promise = this.closeFromSignal(signal).catch((err) => {
@@ -28,6 +28,14 @@ describe('accounts:address', () => {
           init: jest.fn().mockImplementation(() => ({
             client,
             connectRpc: jest.fn().mockResolvedValue(client),
+            // No .ironfish-account file and no defaultAccount in the config
+            fileSystem: {
+              resolve: jest.fn((path: string) => path),
+              join: jest.fn((...paths: string[]) => paths.join('/')),
+              dirname: jest.fn((path: string) => path),
+              exists: jest.fn().mockResolvedValue(false),
+            },
+            config: { get: jest.fn().mockReturnValue('') },
           })),
         },
       }
@@ -31,7 +31,7 @@ export class AddressCommand extends IronfishCommand {
 
   async start(): Promise<void> {
     const { args, flags } = await this.parse(AddressCommand)
-    const account = args.account as string | undefined
+    const account = await this.getAccountName(args.account as string | undefined)
 
     const client = await this.sdk.connectRpc()
 
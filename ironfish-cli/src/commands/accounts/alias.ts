@@ -0,0 +1,85 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { CliUx, Flags } from '@oclif/core'
+import { IronfishCommand } from '../../command'
+import { JsonFlag, JsonFlagKey, RemoteFlags } from '../../flags'
+
+export class AliasCommand extends IronfishCommand {
+  static aliases = ['wallet:alias']
+  static description = `Create, remove or list account aliases
+
+  An alias can be used anywhere an account name is accepted, by the CLI and the RPC.
+  To use an account for every command run in a directory, put its name or alias in
+  a .ironfish-account file there. To use one for a profile, set defaultAccount in
+  the profile's config.`
+
+  static examples = [
+    '$ ironfish accounts:alias',
+    '$ ironfish accounts:alias savings my-long-account-name',
+    '$ ironfish accounts:alias savings --remove',
+  ]
+
+  static flags = {
+    ...RemoteFlags,
+    [JsonFlagKey]: JsonFlag,
+    remove: Flags.boolean({
+      default: false,
+      description: 'remove the alias',
+    }),
+  }
+
+  static args = [
+    {
+      name: 'alias',
+      required: false,
+      description: 'the alias to create or remove, lists the aliases if not given',
+    },
+    {
+      name: 'account',
+      required: false,
+      description: 'the name of the account the alias is for',
+    },
+  ]
+
+  async start(): Promise<void> {
+    const { args, flags } = await this.parse(AliasCommand)
+    const alias = (args.alias as string | undefined)?.trim()
+    const account = (args.account as string | undefined)?.trim()
+
+    const client = await this.sdk.connectRpc()
+
+    if (!alias) {
+      const response = await client.getAliases()
+
+      if (this.json) {
+        this.logJson(response.content.aliases)
+        return
+      }
+
+      if (!response.content.aliases.length) {
+        this.log('There are no account aliases')
+        return
+      }
+
+      CliUx.ux.table(response.content.aliases, {
+        alias: { header: 'Alias' },
+        account: { header: 'Account' },
+      })
+      return
+    }
+
+    if (flags.remove) {
+      await client.removeAlias({ alias })
+      this.log(`Removed the alias ${alias}`)
+      return
+    }
+
+    if (!account) {
+      this.error('Pass the account the alias is for, or --remove to remove it')
+    }
+
+    const response = await client.setAlias({ alias, account })
+    this.log(`${response.content.alias} is now an alias for ${response.content.account}`)
+  }
+}
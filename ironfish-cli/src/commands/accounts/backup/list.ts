@@ -0,0 +1,52 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { FileUtils, openBackupLocation, sortBackups } from '@ironfish/sdk'
+import { CliUx, Flags } from '@oclif/core'
+import { IronfishCommand } from '../../../command'
+import { LocalFlags } from '../../../flags'
+
+export default class BackupListCommand extends IronfishCommand {
+  static description = 'List the automatic wallet backups, newest first'
+
+  static flags = {
+    ...LocalFlags,
+    path: Flags.string({
+      description: 'a directory or s3:// URL to list instead of walletBackupPath',
+    }),
+  }
+
+  async start(): Promise<void> {
+    const { flags } = await this.parse(BackupListCommand)
+
+    const path = flags.path ?? this.sdk.config.get('walletBackupPath')
+    if (!path) {
+      this.log('Wallet backups are not set up, set walletBackupPath or use --path')
+      this.exit(1)
+    }
+
+    const location = openBackupLocation(path)
+    const files = sortBackups(await location.list())
+
+    if (!files.length) {
+      this.log(`There are no wallet backups in ${location.description}`)
+      return
+    }
+
+    this.log(`Wallet backups in ${location.description}\n`)
+
+    CliUx.ux.table(files, {
+      name: {
+        header: 'Name',
+      },
+      size: {
+        header: 'Size',
+        get: (row) => FileUtils.formatFileSize(row.size),
+      },
+      createdAt: {
+        header: 'Created',
+        get: (row) => row.createdAt.toLocaleString(),
+      },
+    })
+  }
+}
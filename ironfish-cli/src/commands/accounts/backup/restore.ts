@@ -0,0 +1,95 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import {
+  decryptWalletBackup,
+  openBackupLocation,
+  readBackupPassphrase,
+  sortBackups,
+  WalletBackup,
+} from '@ironfish/sdk'
+import { CliUx, Flags } from '@oclif/core'
+import { IronfishCommand } from '../../../command'
+import { RemoteFlags } from '../../../flags'
+
+export default class BackupRestoreCommand extends IronfishCommand {
+  static description = 'Import the accounts from an automatic wallet backup'
+
+  static flags = {
+    ...RemoteFlags,
+    path: Flags.string({
+      description: 'a directory or s3:// URL to restore from instead of walletBackupPath',
+    }),
+    rescan: Flags.boolean({
+      allowNo: true,
+      default: true,
+      description: 'rescan the blockchain once the accounts are imported',
+    }),
+  }
+
+  static args = [
+    {
+      name: 'backup',
+      parse: (input: string): Promise<string> => Promise.resolve(input.trim()),
+      required: false,
+      description: 'the name of the backup to restore, defaults to the newest',
+    },
+  ]
+
+  async start(): Promise<void> {
+    const { flags, args } = await this.parse(BackupRestoreCommand)
+
+    const path = flags.path ?? this.sdk.config.get('walletBackupPath')
+    if (!path) {
+      this.log('Wallet backups are not set up, set walletBackupPath or use --path')
+      this.exit(1)
+    }
+
+    const location = openBackupLocation(path)
+
+    let name = args.backup as string | undefined
+    if (!name) {
+      const [newest] = sortBackups(await location.list())
+
+      if (!newest) {
+        this.log(`There are no wallet backups in ${location.description}`)
+        this.exit(1)
+      }
+
+      name = newest.name
+    }
+
+    const contents = await location.read(name)
+
+    const passphrase =
+      (await readBackupPassphrase(this.sdk.config.get('walletBackupPassphraseFile'))) ||
+      ((await CliUx.ux.prompt('Enter the backup passphrase', {
+        type: 'hide',
+        required: true,
+      })) as string)
+
+    let backup: WalletBackup
+    try {
+      backup = decryptWalletBackup(contents, passphrase)
+    } catch (e: unknown) {
+      this.log(e instanceof Error ? e.message : String(e))
+      this.exit(1)
+    }
+
+    const count = backup.accounts.length
+    this.log(`Restoring ${count} accounts from ${name}, made ${backup.createdAt}`)
+
+    const client = await this.sdk.connectRpc()
+    const existing = new Set((await client.getAccounts()).content.accounts)
+
+    for (const account of backup.accounts) {
+      if (existing.has(account.name)) {
+        this.log(`Skipped ${account.name}, an account with that name already exists`)
+        continue
+      }
+
+      await client.importAccount({ account, rescan: flags.rescan })
+      this.log(`Imported ${account.name}`)
+    }
+  }
+}
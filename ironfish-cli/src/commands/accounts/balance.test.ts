@@ -9,6 +9,23 @@ describe('accounts:balance', () => {
     account: 'default',
     confirmed: '5',
     unconfirmed: '10',
+    held: '2',
+    available: '3',
+    minConfirmations: 12,
+    buckets: [
+      { confirmations: 1, balance: '7' },
+      { confirmations: 12, balance: '5' },
+    ],
+    breakdown: [
+      {
+        asset: '$IRON',
+        available: '3',
+        immature: '2',
+        unconfirmed: '2',
+        pendingChange: '1',
+        locked: '2',
+      },
+    ],
   }
 
   beforeAll(() => {
@@ -52,6 +69,18 @@ describe('accounts:balance', () => {
         expectCli(ctx.stdout).include(
           displayIronAmountWithCurrency(oreToIron(Number(responseContent.confirmed)), true),
         )
+
+        expectCli(ctx.stdout).include(
+          `Held by pending transactions: ${displayIronAmountWithCurrency(oreToIron(2), true)}`,
+        )
+
+        expectCli(ctx.stdout).include(
+          `Amount available to spend: ${displayIronAmountWithCurrency(oreToIron(3), true)}`,
+        )
+
+        expectCli(ctx.stdout).include(
+          `with 1 confirmations: ${displayIronAmountWithCurrency(oreToIron(7), true)}`,
+        )
       })
   })
 })
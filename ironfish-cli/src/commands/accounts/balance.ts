@@ -2,6 +2,7 @@
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 import { displayIronAmountWithCurrency, oreToIron } from '@ironfish/sdk'
+import { Flags } from '@oclif/core'
 import { IronfishCommand } from '../../command'
 import { RemoteFlags } from '../../flags'
 
@@ -9,11 +10,16 @@ export class BalanceCommand extends IronfishCommand {
   static description =
     'Display the account balance\n\
   What is the difference between available to spend balance, and balance?\n\
-  Available to spend balance is your coins from transactions that have been mined on blocks on your main chain.\n\
-  Balance is your coins from all of your transactions, even if they are on forks or not yet included as part of a mined block.'
+  Available to spend balance is your coins from transactions that have been mined on blocks on your main chain,\n\
+  less the coins held by transactions you sent that have not been mined yet.\n\
+  Balance is your coins from all of your transactions, even if they are on forks or not yet included as part of a mined block.\n\
+  Coins with more confirmations are less likely to be reversed by a reorg.'
 
   static flags = {
     ...RemoteFlags,
+    confirmations: Flags.integer({
+      description: 'confirmations needed to spend coins, defaults to minimumBlockConfirmations',
+    }),
   }
 
   static args = [
@@ -26,26 +32,38 @@ export class BalanceCommand extends IronfishCommand {
   ]
 
   async start(): Promise<void> {
-    const { args } = await this.parse(BalanceCommand)
-    const account = args.account as string | undefined
+    const { args, flags } = await this.parse(BalanceCommand)
+    const account = await this.getAccountName(args.account as string | undefined)
 
     const client = await this.sdk.connectRpc()
 
     const response = await client.getAccountBalance({
       account: account,
+      minConfirmations: flags.confirmations,
     })
 
-    const { account: accountResponse, confirmed, unconfirmed } = response.content
+    const { account: accountResponse, unconfirmed, buckets, warning } = response.content
+    const { confirmed, held, available, minConfirmations } = response.content
+
+    const render = (ore: string) => displayIronAmountWithCurrency(oreToIron(Number(ore)), true)
 
     this.log(`Account - ${String(accountResponse)}\n`)
-    this.log(
-      `The balance is: ${displayIronAmountWithCurrency(oreToIron(Number(unconfirmed)), true)}`,
-    )
-    this.log(
-      `Amount available to spend: ${displayIronAmountWithCurrency(
-        oreToIron(Number(confirmed)),
-        true,
-      )}`,
-    )
+    this.log(`The balance is: ${render(unconfirmed)}`)
+    this.log(`Confirmed balance: ${render(confirmed)} (${minConfirmations} confirmations)`)
+
+    if (BigInt(held) > BigInt(0)) {
+      this.log(`Held by pending transactions: ${render(held)}`)
+    }
+
+    this.log(`Amount available to spend: ${render(available)}`)
+
+    for (const bucket of buckets) {
+      const balance = displayIronAmountWithCurrency(oreToIron(Number(bucket.balance)), true)
+      this.log(`  with ${bucket.confirmations} confirmations: ${balance}`)
+    }
+
+    if (warning) {
+      this.warn(warning)
+    }
   }
 }
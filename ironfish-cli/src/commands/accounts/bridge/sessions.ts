@@ -0,0 +1,68 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { BridgeSessionStore } from '@ironfish/sdk'
+import { CliUx, Flags } from '@oclif/core'
+import { IronfishCommand } from '../../../command'
+import { JsonFlag, JsonFlagKey, LocalFlags } from '../../../flags'
+
+export default class BridgeSessionsCommand extends IronfishCommand {
+  static description = 'List or revoke the apps paired with the signing bridge'
+
+  static flags = {
+    ...LocalFlags,
+    [JsonFlagKey]: JsonFlag,
+    revoke: Flags.string({
+      description: 'the id of a session to revoke, the app will have to pair again',
+    }),
+  }
+
+  async start(): Promise<void> {
+    const { flags } = await this.parse(BridgeSessionsCommand)
+
+    const sessions = new BridgeSessionStore(this.sdk.fileSystem, this.sdk.config.dataDir)
+    await sessions.load()
+
+    if (flags.revoke) {
+      if (!(await sessions.revoke(flags.revoke))) {
+        this.log(`No session with id ${flags.revoke}`)
+        this.exit(1)
+      }
+
+      this.log(`Revoked ${flags.revoke}`)
+      return
+    }
+
+    if (this.json) {
+      // The keys stay out of the output
+      this.logJson(sessions.list().map(({ key: _key, ...session }) => session))
+      return
+    }
+
+    if (!sessions.list().length) {
+      this.log('No apps are paired, use accounts:bridge:start to pair one')
+      return
+    }
+
+    CliUx.ux.table(sessions.list(), {
+      id: {
+        header: 'Session',
+      },
+      app: {
+        header: 'App',
+        get: (row) => row.app?.name ?? '',
+      },
+      account: {
+        header: 'Account',
+      },
+      createdAt: {
+        header: 'Paired',
+        get: (row) => new Date(row.createdAt).toLocaleString(),
+      },
+      lastUsedAt: {
+        header: 'Last Used',
+        get: (row) => (row.lastUsedAt ? new Date(row.lastUsedAt).toLocaleString() : ''),
+      },
+    })
+  }
+}
@@ -0,0 +1,222 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import {
+  BridgeApp,
+  BridgeError,
+  BridgeErrorCode,
+  BridgeMethods,
+  BridgeSendTransactionParams,
+  BridgeServer,
+  BridgeSession,
+  BridgeSessionStore,
+  displayIronAmountWithCurrency,
+  isValidPublicAddress,
+  oreToIron,
+  RpcClient,
+} from '@ironfish/sdk'
+import { CliUx, Flags } from '@oclif/core'
+import { IronfishCommand } from '../../../command'
+import { RemoteFlags } from '../../../flags'
+import { renderQrCode } from '../../../utils/qrcode'
+
+export default class BridgeStartCommand extends IronfishCommand {
+  static description = `Let other apps ask your wallet to send transactions
+
+Apps pair by scanning the QR code, or with the pairing URI, and you approve the
+pairing and every transaction here. Apps never see your keys, the transactions
+are made by the node.`
+
+  static flags = {
+    ...RemoteFlags,
+    account: Flags.string({
+      char: 'f',
+      description: 'the account a new app can send from, defaults to the default account',
+    }),
+    host: Flags.string({
+      default: 'localhost',
+      description: 'the host for apps to connect to',
+    }),
+    port: Flags.integer({
+      default: 8021,
+      description: 'the port for apps to connect to',
+    }),
+    pair: Flags.boolean({
+      default: true,
+      allowNo: true,
+      description: 'show a pairing code for a new app',
+    }),
+  }
+
+  server: BridgeServer | null = null
+
+  // Only one approval is asked for at a time
+  approvals: Promise<unknown> = Promise.resolve()
+
+  async start(): Promise<void> {
+    const { flags } = await this.parse(BridgeStartCommand)
+
+    const client = await this.sdk.connectRpc()
+
+    let account = await this.getAccountName(flags.account)
+    if (!account) {
+      const response = await client.getDefaultAccount()
+      if (!response.content.account) {
+        this.log('There is no default account, use --account to choose one')
+        this.exit(1)
+      }
+      account = response.content.account.name
+    }
+
+    const sessions = new BridgeSessionStore(this.sdk.fileSystem, this.sdk.config.dataDir)
+    await sessions.load()
+
+    const server = new BridgeServer({
+      host: flags.host,
+      port: flags.port,
+      network: this.sdk.config.get('network'),
+      sessions,
+      logger: this.logger,
+      onPair: (session, app) => this.approve(() => this.approvePairing(session, app)),
+      onRequest: (session, method, params) => this.onRequest(client, session, method, params),
+    })
+
+    this.server = server
+    await server.start()
+
+    this.log(`Signing bridge listening on ${flags.host}:${server.port}`)
+    this.log(`${sessions.list().length} paired apps can connect`)
+
+    if (flags.pair) {
+      const { uri } = server.createPairing(account)
+
+      this.log(`\nScan to pair an app with ${account}:\n`)
+      this.log(renderQrCode(uri))
+      this.log(`\nOr give the app this pairing URI:\n${uri}\n`)
+    }
+
+    await new Promise<void>((resolve) => server.server?.once('close', resolve))
+  }
+
+  async closeFromSignal(): Promise<void> {
+    await this.server?.stop()
+  }
+
+  approve(ask: () => Promise<boolean>): Promise<boolean> {
+    const result = this.approvals.then(ask)
+    this.approvals = result.catch(() => undefined)
+    return result
+  }
+
+  async approvePairing(session: BridgeSession, app: BridgeApp): Promise<boolean> {
+    this.log(`\n${app.name} wants to pair with ${session.account}`)
+    if (app.url) {
+      this.log(`URL: ${app.url}`)
+    }
+    if (app.description) {
+      this.log(app.description)
+    }
+
+    // Never skipped by --yes, every app has to be approved by a person
+    const approved = await CliUx.ux.confirm('Pair with this app (Y/N)?')
+    this.log(approved ? `Paired with ${app.name}` : `Rejected ${app.name}`)
+    return approved
+  }
+
+  async onRequest(
+    client: RpcClient,
+    session: BridgeSession,
+    method: string,
+    params: unknown,
+  ): Promise<unknown> {
+    switch (method) {
+      case BridgeMethods.GetAccount: {
+        const response = await client.getAccountPublicKey({ account: session.account })
+        return { account: session.account, publicAddress: response.content.publicKey }
+      }
+
+      case BridgeMethods.GetBalance: {
+        const response = await client.getAccountBalance({ account: session.account })
+        return response.content
+      }
+
+      case BridgeMethods.SendTransaction:
+        return this.sendTransaction(client, session, params)
+
+      default:
+        throw new BridgeError(BridgeErrorCode.UnknownMethod, `Unknown method ${method}`)
+    }
+  }
+
+  async sendTransaction(
+    client: RpcClient,
+    session: BridgeSession,
+    params: unknown,
+  ): Promise<{ hash: string }> {
+    const transaction = this.parseTransaction(params)
+    const appName = session.app?.name ?? 'An app'
+
+    const approved = await this.approve(async () => {
+      this.log(`\n${appName} wants to send from ${session.account}:`)
+      for (const receive of transaction.receives) {
+        const amount = displayIronAmountWithCurrency(oreToIron(Number(receive.amount)), true)
+        const memo = receive.memo ? ` with the memo "${receive.memo}"` : ''
+        this.log(`  ${amount} to ${receive.publicAddress}${memo}`)
+      }
+      const fee = displayIronAmountWithCurrency(oreToIron(Number(transaction.fee)), true)
+      this.log(`  plus a transaction fee of ${fee}`)
+      this.log('* This action is NOT reversible *')
+
+      return CliUx.ux.confirm('Send this transaction (Y/N)?')
+    })
+
+    if (!approved) {
+      this.log('Transaction rejected')
+      throw new BridgeError(BridgeErrorCode.Rejected, 'The transaction was rejected')
+    }
+
+    const response = await client.sendTransaction({
+      fromAccountName: session.account,
+      receives: transaction.receives,
+      fee: transaction.fee,
+      expirationSequence: transaction.expirationSequence,
+    })
+
+    this.log(`Sent transaction ${response.content.hash}`)
+    return { hash: response.content.hash }
+  }
+
+  parseTransaction(params: unknown): BridgeSendTransactionParams {
+    const invalid = (message: string) =>
+      new BridgeError(BridgeErrorCode.InvalidRequest, `Invalid transaction: ${message}`)
+
+    const transaction = params as Partial<BridgeSendTransactionParams> | undefined
+    const isOre = (value: unknown) => typeof value === 'string' && /^[0-9]+$/.test(value)
+
+    if (!Array.isArray(transaction?.receives) || !transaction?.receives.length) {
+      throw invalid('receives must be a list of receivers')
+    }
+
+    for (const receive of transaction.receives) {
+      if (!isValidPublicAddress(receive.publicAddress)) {
+        throw invalid(`${String(receive.publicAddress)} is not a public address`)
+      }
+      if (!isOre(receive.amount) || BigInt(receive.amount) <= BigInt(0)) {
+        throw invalid('amounts must be more than 0 ore')
+      }
+      if (typeof receive.memo !== 'string') {
+        throw invalid('memos must be strings')
+      }
+    }
+
+    if (!isOre(transaction.fee)) {
+      throw invalid('the fee must be in ore')
+    }
+
+    return {
+      receives: transaction.receives,
+      fee: transaction.fee as string,
+      expirationSequence: transaction.expirationSequence,
+    }
+  }
+}
@@ -55,4 +55,11 @@ describe('accounts:create command', () => {
       expectCli(ctx.stdout).include(`Creating account ${name}`)
       expect(createAccount).toHaveBeenCalledWith({ name })
     })
+
+  test
+    .command(['accounts:create', name, '--json'])
+    .catch((e) => expect(e.message).toContain('Nonexistent flag: --json'))
+    .it('does not accept --json because it has no JSON output', () => {
+      expect(createAccount).not.toHaveBeenCalled()
+    })
 })
@@ -0,0 +1,65 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { displayIronAmountWithCurrency, oreToIron } from '@ironfish/sdk'
+import { Flags } from '@oclif/core'
+import { IronfishCommand } from '../../command'
+import { JsonFlag, JsonFlagKey, RemoteFlags } from '../../flags'
+
+export class DiscloseCommand extends IronfishCommand {
+  static aliases = ['wallet:disclose']
+  static description = `Export a disclosure that proves a payment in a transaction
+
+  The disclosure reveals the owner, amount and memo of one note and can be checked
+  by anyone with accounts:verifyDisclosure. It doesn't reveal your view keys.`
+
+  static flags = {
+    ...RemoteFlags,
+    [JsonFlagKey]: JsonFlag,
+    account: Flags.string({
+      char: 'f',
+      description: 'the account that sent or received the payment',
+    }),
+    note: Flags.integer({
+      description: 'the index of the note to disclose, defaults to all of your notes',
+      min: 0,
+    }),
+  }
+
+  static args = [
+    {
+      name: 'hash',
+      required: true,
+      description: 'the hash of the transaction',
+    },
+  ]
+
+  async start(): Promise<void> {
+    const { args, flags } = await this.parse(DiscloseCommand)
+    const hash = (args.hash as string).trim()
+
+    const client = await this.sdk.connectRpc()
+
+    const response = await client.createDisclosure({
+      account: await this.getAccountName(flags.account),
+      transactionHash: hash,
+      noteIndex: flags.note,
+    })
+
+    if (this.json) {
+      this.logJson(response.content.disclosures)
+      return
+    }
+
+    for (const disclosure of response.content.disclosures) {
+      const amount = displayIronAmountWithCurrency(oreToIron(Number(disclosure.amount)), true)
+      this.log(`Note ${disclosure.noteIndex}: ${amount} to ${disclosure.owner}\n`)
+      this.log(JSON.stringify(disclosure))
+      this.log('')
+    }
+
+    if (!response.content.disclosures.some((d) => d.blockHash !== null)) {
+      this.warn('The transaction is not on the chain yet, check the disclosures once it is')
+    }
+  }
+}
@@ -2,7 +2,7 @@
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 import { oreToIron } from '@ironfish/sdk'
-import { CliUx } from '@oclif/core'
+import { CliUx, Flags } from '@oclif/core'
 import { IronfishCommand } from '../../command'
 import { RemoteFlags } from '../../flags'
 
@@ -11,6 +11,10 @@ export class NotesCommand extends IronfishCommand {
 
   static flags = {
     ...RemoteFlags,
+    dust: Flags.boolean({
+      default: false,
+      description: 'include notes worth less than the dustThreshold config',
+    }),
   }
 
   static args = [
@@ -23,12 +27,12 @@ export class NotesCommand extends IronfishCommand {
   ]
 
   async start(): Promise<void> {
-    const { args } = await this.parse(NotesCommand)
-    const account = args.account as string | undefined
+    const { args, flags } = await this.parse(NotesCommand)
+    const account = await this.getAccountName(args.account as string | undefined)
 
     const client = await this.sdk.connectRpc()
 
-    const response = await client.getAccountNotes({ account })
+    const response = await client.getAccountNotes({ account, includeDust: flags.dust })
 
     const { account: accountResponse, notes } = response.content
 
@@ -49,6 +53,14 @@ export class NotesCommand extends IronfishCommand {
       noteTxHash: {
         header: 'From Transaction',
       },
+      ...(flags.dust
+        ? {
+            dust: {
+              header: 'Dust',
+              get: (row: { dust: boolean }) => (row.dust ? `✔` : `x`),
+            },
+          }
+        : {}),
     })
 
     this.log(`\n`)
@@ -38,7 +38,9 @@ describe('accounts:pay command', () => {
     ironfishmodule.IronfishSdk.init = jest.fn().mockImplementation(() => {
       const client = {
         connect: jest.fn(),
-        getAccountBalance: jest.fn().mockResolvedValue({ content: { confirmed: 1000 } }),
+        getAccountBalance: jest.fn().mockResolvedValue({
+          content: { confirmed: 1000, available: 1000 },
+        }),
         status: jest.fn().mockResolvedValue({ content: { blockchain: { synced: true } } }),
         sendTransaction,
       }
@@ -4,17 +4,23 @@
 
 import {
   displayIronAmountWithCurrency,
+  FileUtils,
   ironToOre,
   isValidAmount,
   isValidPublicAddress,
   MINIMUM_IRON_AMOUNT,
   oreToIron,
+  RpcClient,
+  TimeUtils,
 } from '@ironfish/sdk'
 import { CliUx, Flags } from '@oclif/core'
 import { IronfishCommand } from '../../command'
 import { RemoteFlags } from '../../flags'
 import { ProgressBar } from '../../types'
 
+// The number of bytes a note memo can hold
+const MEMO_LENGTH = 32
+
 export class Pay extends IronfishCommand {
   static description = `Send coins to another account`
 
@@ -22,6 +28,7 @@ export class Pay extends IronfishCommand {
     '$ ironfish accounts:pay -a 2 -o 0.00000001 -t 997c586852d1b12da499bcff53595ba37d04e4909dbdb1a75f3bfd90dd7212217a1c2c0da652d187fc52ed',
     '$ ironfish accounts:pay -a 2 -o 0.00000001 -t 997c586852d1b12da499bcff53595ba37d04e4909dbdb1a75f3bfd90dd7212217a1c2c0da652d187fc52ed -f otheraccount',
     '$ ironfish accounts:pay -a 2 -o 0.00000001 -t 997c586852d1b12da499bcff53595ba37d04e4909dbdb1a75f3bfd90dd7212217a1c2c0da652d187fc52ed -f otheraccount -m my_message_for_the_transaction',
+    '$ ironfish accounts:pay --interactive',
   ]
 
   static flags = {
@@ -50,11 +57,27 @@ export class Pay extends IronfishCommand {
       default: false,
       description: 'confirm without asking',
     }),
+    interactive: Flags.boolean({
+      char: 'i',
+      default: false,
+      description: 'walk through each part of the transaction before sending it',
+    }),
     expirationSequence: Flags.integer({
       char: 'e',
       description:
         'The block sequence after which the transaction will be removed from the mempool. Set to 0 for no expiration.',
     }),
+    confirmations: Flags.integer({
+      description:
+        'only spend coins with this many confirmations, defaults to minimumBlockConfirmations',
+    }),
+    feePayer: Flags.string({
+      description: 'another account that pays the transaction fee',
+    }),
+    estimate: Flags.boolean({
+      default: false,
+      description: 'show how large the transaction would be and what it costs, without sending',
+    }),
   }
 
   async start(): Promise<void> {
@@ -62,9 +85,11 @@ export class Pay extends IronfishCommand {
     let amount = flags.amount ? Number(flags.amount) : undefined
     let fee = flags.fee ? Number(flags.fee) : undefined
     let to = flags.to?.trim()
-    let from = flags.account?.trim()
+    let from = await this.getAccountName(flags.account?.trim())
     const expirationSequence = flags.expirationSequence
-    const memo = flags.memo || ''
+    let memo = flags.memo || ''
+    const minConfirmations = flags.confirmations
+    const feePayer = flags.feePayer?.trim()
 
     const client = await this.sdk.connectRpc()
 
@@ -77,13 +102,22 @@ export class Pay extends IronfishCommand {
       this.exit(1)
     }
 
+    if (flags.interactive) {
+      const result = await this.promptTransaction(client, { from, to, memo, minConfirmations })
+      from = result.from
+      to = result.to
+      amount = result.amount
+      fee = result.fee
+      memo = result.memo
+    }
+
     if (amount == null || Number.isNaN(amount)) {
-      const response = await client.getAccountBalance({ account: from })
+      const response = await client.getAccountBalance({ account: from, minConfirmations })
 
       const input = Number(
         await CliUx.ux.prompt(
           `Enter the amount in $IRON (balance available: ${displayIronAmountWithCurrency(
-            oreToIron(Number(response.content.confirmed)),
+            oreToIron(Number(response.content.available)),
             false,
           )})`,
           {
@@ -99,6 +133,25 @@ export class Pay extends IronfishCommand {
       amount = input
     }
 
+    if (!from) {
+      const response = await client.getDefaultAccount()
+      const defaultAccount = response.content.account
+
+      if (!defaultAccount) {
+        this.error(
+          `No account is currently active.
+           Use ironfish accounts:create <name> to first create an account`,
+        )
+      }
+
+      from = defaultAccount.name
+    }
+
+    if (flags.estimate) {
+      await this.showEstimate(client, from, amount, fee, minConfirmations)
+      this.exit(0)
+    }
+
     if (fee == null || Number.isNaN(fee)) {
       const input = Number(
         await CliUx.ux.prompt('Enter the fee amount in $IRON', {
@@ -124,20 +177,6 @@ export class Pay extends IronfishCommand {
       }
     }
 
-    if (!from) {
-      const response = await client.getDefaultAccount()
-      const defaultAccount = response.content.account
-
-      if (!defaultAccount) {
-        this.error(
-          `No account is currently active.
-           Use ironfish accounts:create <name> to first create an account`,
-        )
-      }
-
-      from = defaultAccount.name
-    }
-
     if (!isValidAmount(amount)) {
       this.log(
         `The minimum transaction amount is ${displayIronAmountWithCurrency(
@@ -168,7 +207,9 @@ export class Pay extends IronfishCommand {
       this.exit(1)
     }
 
-    if (!flags.confirm) {
+    if (!flags.confirm && !flags.interactive) {
+      const paidBy = feePayer ? `, with the fee paid by ${feePayer}` : ''
+
       this.log(`
 You are about to send:
 ${displayIronAmountWithCurrency(
@@ -177,7 +218,7 @@ ${displayIronAmountWithCurrency(
 )} plus a transaction fee of ${displayIronAmountWithCurrency(
         fee,
         true,
-      )} to ${to} from the account ${from}
+      )} to ${to} from the account ${from}${paidBy}
 
 * This action is NOT reversible *
 `)
@@ -189,33 +230,17 @@ ${displayIronAmountWithCurrency(
       }
     }
 
-    // Run the progress bar for about 2 minutes
-    // Chances are that the transaction will finish faster (error or faster computer)
+    // The node streams progress as it builds each proof of the transaction
     const bar = CliUx.ux.progress({
       barCompleteChar: '\u2588',
       barIncompleteChar: '\u2591',
-      format: 'Creating the transaction: [{bar}] {percentage}% | ETA: {eta}s',
+      format: 'Generating proofs: [{bar}] {value}/{total} | ETA: {eta}s',
     }) as ProgressBar
 
-    bar.start()
-
-    let value = 0
-    const timer = setInterval(() => {
-      value++
-      bar.update(value)
-      if (value >= bar.getTotal()) {
-        bar.stop()
-      }
-    }, 1000)
-
-    const stopProgressBar = () => {
-      clearInterval(timer)
-      bar.update(100)
-      bar.stop()
-    }
+    bar.start(1, 0)
 
     try {
-      const result = await client.sendTransaction({
+      const response = client.sendTransactionStream({
         fromAccountName: from,
         receives: [
           {
@@ -226,9 +251,17 @@ ${displayIronAmountWithCurrency(
         ],
         fee: ironToOre(fee).toString(),
         expirationSequence,
+        minConfirmations,
+        feePayerAccountName: feePayer,
       })
 
-      stopProgressBar()
+      for await (const progress of response.contentStream()) {
+        bar.setTotal(progress.total)
+        bar.update(progress.done)
+      }
+
+      const result = await response.waitForEnd()
+      bar.stop()
 
       const transaction = result.content
       const recipients = transaction.receives.map((receive) => receive.publicAddress).join(', ')
@@ -237,13 +270,15 @@ Sending ${displayIronAmountWithCurrency(amount, true)} to ${recipients} from ${
         transaction.fromAccountName
       }
 Transaction Hash: ${transaction.hash}
-Transaction fee: ${displayIronAmountWithCurrency(fee, true)}
+Transaction fee: ${displayIronAmountWithCurrency(fee, true)}${
+        transaction.feePayerAccountName ? ` paid by ${transaction.feePayerAccountName}` : ''
+      }
 
 Find the transaction on https://explorer.ironfish.network/transaction/${
         transaction.hash
       } (it can take a few minutes before the transaction appears in the Explorer)`)
     } catch (error: unknown) {
-      stopProgressBar()
+      bar.stop()
       this.log(`An error occurred while sending the transaction.`)
       if (error instanceof Error) {
         this.error(error.message)
@@ -251,4 +286,164 @@ Find the transaction on https://explorer.ironfish.network/transaction/${
       this.exit(2)
     }
   }
+
+  async showEstimate(
+    client: RpcClient,
+    from: string,
+    amount: number,
+    fee: number | undefined,
+    minConfirmations: number | undefined,
+  ): Promise<void> {
+    const response = await client.estimateTransaction({
+      fromAccountName: from,
+      receives: [{ publicAddress: '', amount: ironToOre(amount).toString() }],
+      fee: fee !== undefined && !Number.isNaN(fee) ? ironToOre(fee).toString() : null,
+      minConfirmations,
+    })
+    const estimate = response.content
+
+    this.log(`Spends:     ${estimate.spends} notes`)
+    this.log(`Outputs:    ${estimate.notes} notes`)
+    this.log(`Size:       ${FileUtils.formatFileSize(estimate.size)}`)
+    this.log(`Proof time: about ${TimeUtils.renderSpan(estimate.proofTimeMs)}`)
+    this.log(`Fee estimates for a transaction of this size:`)
+    const render = (ore: string) => displayIronAmountWithCurrency(oreToIron(Number(ore)), true)
+    this.log(`  slow:    ${render(estimate.fees.slow)}`)
+    this.log(`  average: ${render(estimate.fees.average)}`)
+    this.log(`  fast:    ${render(estimate.fees.fast)}`)
+  }
+
+  async promptTransaction(
+    client: RpcClient,
+    defaults: { from?: string; to?: string; memo: string; minConfirmations?: number },
+  ): Promise<{ from: string; to: string; amount: number; fee: number; memo: string }> {
+    const accounts = (await client.getAccounts()).content.accounts
+    if (!accounts.length) {
+      this.error(`You have no accounts. Use ironfish accounts:create <name> to create one`)
+    }
+
+    const defaultAccount = (await client.getDefaultAccount()).content.account?.name
+
+    let from = ''
+    while (!accounts.includes(from)) {
+      const message = `Which account do you want to send from? (${accounts.join(', ')})`
+      from = (
+        (await CliUx.ux.prompt(message, {
+          default: defaults.from ?? defaultAccount,
+        })) as string
+      ).trim()
+    }
+
+    let to = defaults.to ?? ''
+    while (!isValidPublicAddress(to)) {
+      if (to) {
+        this.log('That is not a valid public address, try again.')
+      }
+      to = ((await CliUx.ux.prompt('Enter the public address of the recipient')) as string)
+        .trim()
+    }
+
+    // $IRON is the only asset that can be sent right now
+    this.log(`Asset: $IRON`)
+
+    const response = await client.getAccountBalance({
+      account: from,
+      minConfirmations: defaults.minConfirmations,
+    })
+    const balance = response.content
+    const available = Number(balance.available)
+    const availableIron = displayIronAmountWithCurrency(oreToIron(available), false)
+
+    let amount = NaN
+    for (;;) {
+      amount = Number(await CliUx.ux.prompt(`Enter the amount (available: ${availableIron})`))
+
+      if (!isValidAmount(amount)) {
+        const minimum = displayIronAmountWithCurrency(MINIMUM_IRON_AMOUNT, false)
+        this.log(`The amount must be a number of at least ${minimum}, try again.`)
+      } else if (ironToOre(amount) > available) {
+        this.log(`The amount is more than the available balance, try again.`)
+      } else {
+        break
+      }
+    }
+
+    const estimates = (await client.estimateFees()).content
+    const tiers = {
+      s: oreToIron(Number(estimates.slow)),
+      a: oreToIron(Number(estimates.average)),
+      f: oreToIron(Number(estimates.fast)),
+    }
+
+    this.log(`Fee estimates from recent transactions:`)
+    this.log(`  (s)low:    ${displayIronAmountWithCurrency(tiers.s, true)}`)
+    this.log(`  (a)verage: ${displayIronAmountWithCurrency(tiers.a, true)}`)
+    this.log(`  (f)ast:    ${displayIronAmountWithCurrency(tiers.f, true)}`)
+
+    let fee = NaN
+    for (;;) {
+      const choice = (
+        (await CliUx.ux.prompt('Choose a fee: (s)low, (a)verage, (f)ast, or (c)ustom', {
+          default: 'a',
+        })) as string
+      )
+        .trim()
+        .toLowerCase()
+        .charAt(0)
+
+      if (choice === 's' || choice === 'a' || choice === 'f') {
+        fee = tiers[choice]
+      } else if (choice === 'c') {
+        fee = Number(await CliUx.ux.prompt('Enter the fee amount in $IRON'))
+      } else {
+        continue
+      }
+
+      if (!isValidAmount(fee)) {
+        const minimum = displayIronAmountWithCurrency(MINIMUM_IRON_AMOUNT, false)
+        this.log(`The fee must be a number of at least ${minimum}, try again.`)
+      } else if (ironToOre(amount) + ironToOre(fee) > available) {
+        this.log(`The amount plus the fee is more than the available balance, try again.`)
+      } else {
+        break
+      }
+    }
+
+    let memo = defaults.memo
+    for (;;) {
+      memo = (await CliUx.ux.prompt('Enter a memo (optional)', {
+        default: memo,
+        required: false,
+      })) as string
+
+      if (Buffer.byteLength(memo, 'utf8') <= MEMO_LENGTH) {
+        break
+      }
+
+      this.log(`The memo can be at most ${MEMO_LENGTH} bytes, try again.`)
+    }
+
+    const total = oreToIron(ironToOre(amount) + ironToOre(fee))
+
+    this.log(`
+Summary
+  From:      ${from}
+  To:        ${to}
+  Asset:     $IRON
+  Amount:    ${displayIronAmountWithCurrency(amount, true)}
+  Fee:       ${displayIronAmountWithCurrency(fee, true)}
+  Total:     ${displayIronAmountWithCurrency(total, true)}
+  Memo:      ${memo || '-'}
+
+* This action is NOT reversible *
+`)
+
+    const confirmed = await CliUx.ux.confirm('Do you want to send this transaction (Y/N)?')
+    if (!confirmed) {
+      this.log('Transaction aborted.')
+      this.exit(0)
+    }
+
+    return { from, to, amount, fee, memo }
+  }
 }
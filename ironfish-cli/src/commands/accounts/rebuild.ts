@@ -0,0 +1,51 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { CliUx, Flags } from '@oclif/core'
+import { IronfishCommand } from '../../command'
+import { RemoteFlags } from '../../flags'
+import { hasUserResponseError } from '../../utils'
+
+export class RebuildCommand extends IronfishCommand {
+  static aliases = ['wallet:rebuild']
+  static description = `Rebuild the wallet's transactions and notes from its events
+
+  The wallet records every transaction it syncs as an event, and derives its
+  transactions, notes and balances from them. Rebuilding replays the events to
+  derive them again, which repairs them after a bug or corruption without
+  rescanning the chain.`
+
+  static flags = {
+    ...RemoteFlags,
+    local: Flags.boolean({
+      default: false,
+      description: 'Force the rebuild to not connect via RPC',
+    }),
+  }
+
+  async start(): Promise<void> {
+    const { flags } = await this.parse(RebuildCommand)
+
+    const client = await this.sdk.connectRpc(flags.local)
+
+    CliUx.ux.action.start('Rebuilding the wallet')
+
+    let response
+    try {
+      response = await client.rebuildAccounts()
+    } catch (error: unknown) {
+      CliUx.ux.action.stop('failed')
+
+      if (hasUserResponseError(error)) {
+        this.error(error.codeMessage)
+      }
+
+      throw error
+    }
+
+    CliUx.ux.action.stop()
+
+    const { events, transactions, notes } = response.content
+    this.log(`Replayed ${events} events into ${transactions} transactions and ${notes} notes`)
+  }
+}
@@ -0,0 +1,62 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { Flags } from '@oclif/core'
+import { IronfishCommand } from '../../command'
+import { JsonFlag, JsonFlagKey, RemoteFlags } from '../../flags'
+
+export class ReceiptCommand extends IronfishCommand {
+  static aliases = ['wallet:receipt']
+  static description = `Export a signed receipt for a transaction
+
+  The receipt lists the amounts, memos and block of the notes your account sent or
+  received. It is signed for your account address, so changes to it can be detected.`
+
+  static flags = {
+    ...RemoteFlags,
+    [JsonFlagKey]: JsonFlag,
+    account: Flags.string({
+      char: 'f',
+      description: 'the account that sent or received the transaction',
+    }),
+    output: Flags.string({
+      char: 'o',
+      description: 'write the JSON receipt to a file',
+    }),
+  }
+
+  static args = [
+    {
+      name: 'hash',
+      required: true,
+      description: 'the hash of the transaction',
+    },
+  ]
+
+  async start(): Promise<void> {
+    const { args, flags } = await this.parse(ReceiptCommand)
+    const hash = (args.hash as string).trim()
+
+    const client = await this.sdk.connectRpc()
+
+    const response = await client.getReceipt({
+      account: await this.getAccountName(flags.account),
+      hash,
+    })
+
+    const { receipt, text } = response.content
+
+    if (flags.output) {
+      const resolved = this.sdk.fileSystem.resolve(flags.output)
+      await this.sdk.fileSystem.writeFile(resolved, JSON.stringify(receipt, undefined, '  '))
+      this.log(`Wrote the receipt to ${resolved}`)
+    }
+
+    if (this.json) {
+      this.logJson(receipt)
+      return
+    }
+
+    this.log(text)
+  }
+}
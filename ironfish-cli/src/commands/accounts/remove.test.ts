@@ -11,8 +11,11 @@ describe('accounts:remove', () => {
     [params: ironfish.RemoveAccountRequest]
   >
   const name = 'default'
+  let skipConfirmations: string[] = []
 
   beforeEach(() => {
+    skipConfirmations = []
+
     removeAccount = jest.fn().mockImplementationOnce(() => ({
       content: { needsConfirm: true },
     }))
@@ -22,8 +25,13 @@ describe('accounts:remove', () => {
         removeAccount,
       }
 
+      const config = {
+        get: jest.fn((key: string) => (key === 'skipConfirmations' ? skipConfirmations : [])),
+      }
+
       return {
         client: client,
+        config: config,
         connectRpc: jest.fn().mockResolvedValue(client),
       }
     })
@@ -75,4 +83,40 @@ describe('accounts:remove', () => {
         expectCli(ctx.stdout).include(`Account '${name}' successfully removed.`)
       })
   })
+
+  describe('with the yes flag', () => {
+    beforeEach(() => {
+      removeAccount = jest.fn().mockImplementation(() => ({
+        content: {},
+      }))
+    })
+
+    test
+      .stdout()
+      .command(['accounts:remove', '--yes', name])
+      .exit(0)
+      .it('removes the account without prompting', (ctx) => {
+        expect(removeAccount).toHaveBeenCalledTimes(1)
+        expect(removeAccount.mock.calls[0][0]).toMatchObject({ name, confirm: true })
+        expectCli(ctx.stdout).include(`Account '${name}' successfully removed.`)
+      })
+  })
+
+  describe('with the command in skipConfirmations', () => {
+    beforeEach(() => {
+      skipConfirmations = ['accounts:remove']
+      removeAccount = jest.fn().mockImplementation(() => ({
+        content: {},
+      }))
+    })
+
+    test
+      .stdout()
+      .command(['accounts:remove', name])
+      .exit(0)
+      .it('removes the account without prompting', () => {
+        expect(removeAccount).toHaveBeenCalledTimes(1)
+        expect(removeAccount.mock.calls[0][0]).toMatchObject({ name, confirm: true })
+      })
+  })
 })
@@ -4,7 +4,7 @@
 
 import { CliUx, Flags } from '@oclif/core'
 import { IronfishCommand } from '../../command'
-import { RemoteFlags } from '../../flags'
+import { RemoteFlags, YesFlag, YesFlagKey } from '../../flags'
 
 export class RemoveCommand extends IronfishCommand {
   static description = `Permanently remove an account`
@@ -19,6 +19,7 @@ export class RemoveCommand extends IronfishCommand {
 
   static flags = {
     ...RemoteFlags,
+    [YesFlagKey]: YesFlag,
     confirm: Flags.boolean({
       description: 'suppress the confirmation prompt',
     }),
@@ -26,7 +27,7 @@ export class RemoveCommand extends IronfishCommand {
 
   async start(): Promise<void> {
     const { args, flags } = await this.parse(RemoveCommand)
-    const confirm = flags.confirm
+    const confirm = flags.confirm || this.shouldAssumeYes()
     const name = (args.name as string).trim()
 
     const client = await this.sdk.connectRpc()
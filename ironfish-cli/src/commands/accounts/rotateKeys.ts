@@ -0,0 +1,202 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import {
+  displayIronAmountWithCurrency,
+  ironToOre,
+  isValidAmount,
+  oreToIron,
+  PromiseUtils,
+} from '@ironfish/sdk'
+import { CliUx, Flags } from '@oclif/core'
+import { IronfishCommand } from '../../command'
+import { RemoteFlags } from '../../flags'
+
+// How long to wait for notes to confirm before sweeping again
+const POLL_INTERVAL_MS = 10 * 1000
+
+export class RotateKeysCommand extends IronfishCommand {
+  static description = `Move all funds to a new account, for when keys may be exposed
+
+A new account is created and every note of the old account is sent to it, a few
+notes per transaction so each one stays a reasonable size. Notes that are still
+being confirmed are waited for. The command runs until the new account holds
+all the funds, and can be run again with the same --to account to continue.`
+
+  static examples = [
+    '$ ironfish accounts:rotateKeys -f default',
+    '$ ironfish accounts:rotateKeys -f default --to default-2 --maxFee 0.001',
+  ]
+
+  static flags = {
+    ...RemoteFlags,
+    account: Flags.string({
+      char: 'f',
+      description: 'the account to move the funds from',
+    }),
+    to: Flags.string({
+      description: 'the name of the new account, or an account created by a previous run',
+    }),
+    fee: Flags.string({
+      char: 'o',
+      default: '0.00000001',
+      description: 'the fee of each transaction in IRON',
+    }),
+    maxFee: Flags.string({
+      description: 'stop once the fees of all transactions would go over this in IRON',
+    }),
+    maxNotes: Flags.integer({
+      default: 10,
+      description: 'the most notes to spend in each transaction',
+    }),
+    confirmations: Flags.integer({
+      description:
+        'only spend coins with this many confirmations, defaults to minimumBlockConfirmations',
+    }),
+    confirm: Flags.boolean({
+      default: false,
+      description: 'confirm without asking',
+    }),
+  }
+
+  async start(): Promise<void> {
+    const { flags } = await this.parse(RotateKeysCommand)
+
+    const client = await this.sdk.connectRpc()
+
+    const from =
+      (await this.getAccountName(flags.account?.trim())) ||
+      (await client.getDefaultAccount()).content.account?.name
+
+    if (!from) {
+      this.log('There is no default account, use --account to pick one')
+      this.exit(1)
+    }
+
+    const fee = Number(flags.fee)
+    if (!isValidAmount(fee)) {
+      this.error(`The fee ${flags.fee} is not a valid amount`)
+    }
+
+    const maxFee = flags.maxFee !== undefined ? ironToOre(Number(flags.maxFee)) : null
+    if (maxFee !== null && Number.isNaN(maxFee)) {
+      this.error(`The max fee ${String(flags.maxFee)} is not a valid amount`)
+    }
+
+    const to = flags.to?.trim() || `${from}-rotated`
+    const accounts = (await client.getAccounts()).content.accounts
+
+    if (to === from) {
+      this.error(`The new account must not be the account the funds are moved from`)
+    }
+
+    if (!flags.confirm) {
+      const action = accounts.includes(to)
+        ? `continue moving the funds of ${from} to ${to}`
+        : `create the account ${to} and move all the funds of ${from} to it`
+
+      const confirmed = await CliUx.ux.confirm(`This will ${action}. Continue (Y/N)?`)
+      if (!confirmed) {
+        this.exit(0)
+      }
+    }
+
+    if (accounts.includes(to)) {
+      this.log(`Continuing with the existing account ${to}`)
+    } else {
+      await client.createAccount({ name: to })
+      this.log(`Created the account ${to}`)
+    }
+
+    const publicAddress = (await client.getAccountPublicKey({ account: to })).content.publicKey
+
+    const feeOre = ironToOre(fee)
+    let feesSpent = 0
+    let sent = 0
+    let transactions = 0
+
+    for (;;) {
+      if (maxFee !== null && feesSpent + feeOre > maxFee) {
+        this.log(
+          `Stopped before going over the fee limit, run the command again with --to ${to}` +
+            ` to continue`,
+        )
+        this.exit(1)
+      }
+
+      const response = await client.sweepAccount({
+        fromAccountName: from,
+        toPublicAddress: publicAddress,
+        fee: feeOre.toString(),
+        maxNotes: flags.maxNotes,
+        memo: 'key rotation',
+        minConfirmations: flags.confirmations,
+      })
+
+      const { hash, amount, notes, remaining } = response.content
+
+      if (hash) {
+        feesSpent += feeOre
+        sent += Number(amount)
+        transactions++
+        this.log(`Sent ${this.renderOre(Number(amount))} from ${notes} notes in ${hash}`)
+        continue
+      }
+
+      if (BigInt(remaining) === BigInt(0)) {
+        break
+      }
+
+      // Nothing could be sent but funds are left, either they are still being
+      // confirmed or they are worth less than the fee
+      const balance = await client.getAccountBalance({
+        account: from,
+        minConfirmations: flags.confirmations,
+      })
+
+      if (BigInt(balance.content.available) > BigInt(0)) {
+        this.log(
+          `${this.renderOre(Number(remaining))} is left in ${from}, it is worth less than` +
+            ` the fee`,
+        )
+        break
+      }
+
+      CliUx.ux.action.start(`Waiting for ${this.renderOre(Number(remaining))} to confirm`)
+      await PromiseUtils.sleep(POLL_INTERVAL_MS)
+      CliUx.ux.action.stop()
+    }
+
+    this.log(
+      `Sent ${this.renderOre(sent)} in ${transactions} transactions for` +
+        ` ${this.renderOre(feesSpent)} in fees`,
+    )
+
+    CliUx.ux.action.start(`Waiting for the transactions to ${to} to confirm`)
+    for (;;) {
+      const balance = await client.getAccountBalance({
+        account: to,
+        minConfirmations: flags.confirmations,
+      })
+
+      if (balance.content.confirmed === balance.content.unconfirmed) {
+        CliUx.ux.action.stop(this.renderOre(Number(balance.content.confirmed)))
+        break
+      }
+
+      await PromiseUtils.sleep(POLL_INTERVAL_MS)
+    }
+
+    const defaultAccount = (await client.getDefaultAccount()).content.account?.name
+    if (defaultAccount === from) {
+      await client.useAccount({ name: to })
+      this.log(`The default account is now ${to}`)
+    }
+
+    this.log(`All funds are in ${to}. Remove ${from} once you no longer need its history.`)
+  }
+
+  renderOre(ore: number): string {
+    return displayIronAmountWithCurrency(oreToIron(ore), false)
+  }
+}
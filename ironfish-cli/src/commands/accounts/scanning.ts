@@ -0,0 +1,90 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { CliUx } from '@oclif/core'
+import { IronfishCommand } from '../../command'
+import { JsonFlag, JsonFlagKey, RemoteFlags } from '../../flags'
+
+export class ScanningCommand extends IronfishCommand {
+  static aliases = ['wallet:scanning']
+  static description = `Enable, disable or list scanning for accounts
+
+  The node decrypts the notes of every new transaction with every account it
+  scans for. Disable scanning for accounts you no longer use, like archived
+  ones, to save that work. Enabling scanning again rescans the chain for the
+  account, so it picks up the transactions it missed.`
+
+  static examples = [
+    '$ ironfish accounts:scanning',
+    '$ ironfish accounts:scanning disable archived',
+    '$ ironfish accounts:scanning enable archived',
+  ]
+
+  static flags = {
+    ...RemoteFlags,
+    [JsonFlagKey]: JsonFlag,
+  }
+
+  static args = [
+    {
+      name: 'action',
+      required: false,
+      options: ['enable', 'disable'],
+      description: 'enable or disable scanning, lists the accounts if not given',
+    },
+    {
+      name: 'account',
+      required: false,
+      description: 'the name of the account to enable or disable scanning for',
+    },
+  ]
+
+  async start(): Promise<void> {
+    const { args } = await this.parse(ScanningCommand)
+    const action = args.action as 'enable' | 'disable' | undefined
+    const account = (args.account as string | undefined)?.trim()
+
+    const client = await this.sdk.connectRpc()
+
+    if (!action) {
+      const response = await client.getScanning()
+
+      if (this.json) {
+        this.logJson(response.content.accounts)
+        return
+      }
+
+      if (!response.content.accounts.length) {
+        this.log('You have no accounts')
+        return
+      }
+
+      CliUx.ux.table(response.content.accounts, {
+        name: { header: 'Account' },
+        enabled: {
+          header: 'Scanning',
+          get: (row) => (row.enabled ? 'enabled' : 'disabled'),
+        },
+        sequence: { header: 'Scanned To' },
+        behind: { header: 'Blocks Behind' },
+        disabledSequence: {
+          header: 'Disabled At',
+          get: (row) => row.disabledSequence ?? '',
+        },
+      })
+      return
+    }
+
+    if (!account) {
+      this.error(`Pass the account to ${action} scanning for`)
+    }
+
+    const response = await client.setScanning({ account, enabled: action === 'enable' })
+
+    if (response.content.enabled) {
+      this.log(`Enabled scanning for ${response.content.account}, rescanning the chain for it`)
+    } else {
+      this.log(`Disabled scanning for ${response.content.account}`)
+    }
+  }
+}
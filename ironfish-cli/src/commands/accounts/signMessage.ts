@@ -0,0 +1,53 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { Flags } from '@oclif/core'
+import { IronfishCommand } from '../../command'
+import { JsonFlag, JsonFlagKey, RemoteFlags } from '../../flags'
+
+export class SignMessageCommand extends IronfishCommand {
+  static aliases = ['wallet:sign-message']
+  static description = `Sign a message to prove you own your account address
+
+  Anyone can check the signature with accounts:verifyMessage. It is signed with your spending
+  key, which it doesn't reveal, but anyone with the signature can see the notes you receive`
+
+  static flags = {
+    ...RemoteFlags,
+    [JsonFlagKey]: JsonFlag,
+    account: Flags.string({
+      char: 'f',
+      description: 'the account to sign with, defaults to the default account',
+    }),
+  }
+
+  static args = [
+    {
+      name: 'message',
+      required: true,
+      description: 'the message to sign',
+    },
+  ]
+
+  async start(): Promise<void> {
+    const { args, flags } = await this.parse(SignMessageCommand)
+    const message = args.message as string
+
+    const client = await this.sdk.connectRpc()
+
+    const response = await client.signMessage({
+      account: await this.getAccountName(flags.account),
+      message,
+    })
+
+    if (this.json) {
+      this.logJson(response.content)
+      return
+    }
+
+    this.log(`Account: ${response.content.account}`)
+    this.log(`Address: ${response.content.publicAddress}`)
+    this.log(`Message: ${response.content.message}`)
+    this.log(`Signature: ${response.content.signature}`)
+  }
+}
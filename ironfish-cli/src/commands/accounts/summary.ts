@@ -0,0 +1,141 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { displayIronAmountWithCurrency, oreToIron, TimeUtils } from '@ironfish/sdk'
+import { CliUx, Flags } from '@oclif/core'
+import { IronfishCommand } from '../../command'
+import { JsonFlag, JsonFlagKey, RemoteFlags } from '../../flags'
+
+export class SummaryCommand extends IronfishCommand {
+  static aliases = ['wallet:summary']
+  static description = `Summarize what an account sent and received
+
+  Totals only include transactions mined on the main chain.`
+
+  static examples = [
+    '$ ironfish accounts:summary',
+    '$ ironfish accounts:summary --range 30d',
+    '$ ironfish accounts:summary --since 2022-01-01 --until 2022-02-01',
+  ]
+
+  static flags = {
+    ...RemoteFlags,
+    [JsonFlagKey]: JsonFlag,
+    account: Flags.string({
+      char: 'f',
+      description: 'the account to summarize',
+    }),
+    range: Flags.string({
+      description: 'only include the last duration of transactions, like 24h or 7d',
+      exclusive: ['since'],
+    }),
+    since: Flags.string({
+      description: 'only include transactions mined on or after this date',
+    }),
+    until: Flags.string({
+      description: 'only include transactions mined before this date',
+    }),
+    limit: Flags.integer({
+      default: 5,
+      min: 0,
+      description: 'how many of the largest payments to show',
+    }),
+  }
+
+  async start(): Promise<void> {
+    const { flags } = await this.parse(SummaryCommand)
+
+    let start: number | undefined
+    let end: number | undefined
+
+    if (flags.range) {
+      try {
+        start = Date.now() - TimeUtils.parseDuration(flags.range)
+      } catch (e: unknown) {
+        this.error((e as Error).message)
+      }
+    }
+
+    if (flags.since) {
+      start = this.parseDate(flags.since, '--since')
+    }
+
+    if (flags.until) {
+      end = this.parseDate(flags.until, '--until')
+    }
+
+    const client = await this.sdk.connectRpc()
+
+    const response = await client.getSummary({
+      account: await this.getAccountName(flags.account),
+      start,
+      end,
+      limit: flags.limit,
+    })
+
+    const summary = response.content
+
+    if (this.json) {
+      this.logJson(summary)
+      return
+    }
+
+    const from = summary.start ? new Date(summary.start).toISOString() : 'the start'
+    const to = summary.end ? new Date(summary.end).toISOString() : 'now'
+    this.log(`Account: ${summary.account}`)
+    this.log(`From ${from} to ${to}\n`)
+
+    this.log(`Transactions: ${summary.transactions} (${summary.pending} pending)`)
+    for (const asset of summary.assets) {
+      this.log(`Received: ${displayOre(asset.received)}`)
+      this.log(`Sent:     ${displayOre(asset.sent)}`)
+    }
+    this.log(`Fees paid: ${displayOre(summary.feesPaid)}`)
+    this.log(`Mining rewards: ${displayOre(summary.minersFees)}`)
+
+    const confirmationTime =
+      summary.averageConfirmationTime === null
+        ? 'N/A'
+        : TimeUtils.renderSpan(summary.averageConfirmationTime)
+    this.log(`Average confirmation time: ${confirmationTime}`)
+
+    if (!summary.largest.length) {
+      return
+    }
+
+    this.log('\nLargest payments:\n')
+    CliUx.ux.table(summary.largest, {
+      direction: {
+        header: 'Direction',
+        get: (row) => (row.sent ? 'sent' : 'received'),
+      },
+      amount: {
+        header: 'Amount',
+        get: (row) => displayOre(row.amount),
+      },
+      memo: {
+        header: 'Memo',
+      },
+      counterparty: {
+        header: 'To',
+        get: (row) => row.counterparty ?? '',
+      },
+      timestamp: {
+        header: 'Mined',
+        get: (row) => new Date(row.timestamp).toISOString(),
+      },
+    })
+  }
+
+  parseDate(value: string, flag: string): number {
+    const date = Date.parse(value)
+    if (Number.isNaN(date)) {
+      this.error(`${flag} must be a date like 2022-01-31, got ${value}`)
+    }
+    return date
+  }
+}
+
+function displayOre(ore: string): string {
+  return displayIronAmountWithCurrency(oreToIron(Number(ore)), true)
+}
@@ -0,0 +1,67 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { encryptWalletData } from '@ironfish/sdk'
+import { CliUx } from '@oclif/core'
+import fsAsync from 'fs/promises'
+import { IronfishCommand } from '../../../command'
+import { RemoteFlags } from '../../../flags'
+
+export default class SyncExportCommand extends IronfishCommand {
+  static description = `Export an account with its wallet state to an encrypted sync file
+
+The sync file holds the account's keys with the transactions and notes the
+wallet found for it, encrypted with a passphrase. Importing it on another node
+with accounts:sync:import skips the rescan of the chain.`
+
+  static flags = {
+    ...RemoteFlags,
+  }
+
+  static args = [
+    {
+      name: 'path',
+      parse: (input: string): Promise<string> => Promise.resolve(input.trim()),
+      required: true,
+      description: 'the file to write the sync file to',
+    },
+    {
+      name: 'account',
+      parse: (input: string): Promise<string> => Promise.resolve(input.trim()),
+      required: false,
+      description: 'name of the account to export, defaults to the default account',
+    },
+  ]
+
+  async start(): Promise<void> {
+    const { args } = await this.parse(SyncExportCommand)
+    const path = this.sdk.fileSystem.resolve(args.path as string)
+
+    const client = await this.sdk.connectRpc()
+    const response = await client.exportAccountState({ account: args.account as string })
+    const { state } = response.content
+
+    const passphrase = (await CliUx.ux.prompt('Enter a passphrase for the sync file', {
+      type: 'hide',
+      required: true,
+    })) as string
+
+    const confirmed = (await CliUx.ux.prompt('Enter the passphrase again', {
+      type: 'hide',
+      required: true,
+    })) as string
+
+    if (passphrase !== confirmed) {
+      this.log('The passphrases do not match')
+      this.exit(1)
+    }
+
+    await fsAsync.writeFile(path, encryptWalletData(state, passphrase), { mode: 0o600 })
+
+    const head = state.head ? ` at block ${state.head.sequence}` : ''
+    this.log(
+      `Exported ${state.account.name} with ${state.transactions.length} transactions` +
+        `${head} to ${path}`,
+    )
+  }
+}
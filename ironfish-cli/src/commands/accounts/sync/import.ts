@@ -0,0 +1,71 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { decryptWalletData, WalletState } from '@ironfish/sdk'
+import { CliUx } from '@oclif/core'
+import fsAsync from 'fs/promises'
+import { IronfishCommand } from '../../../command'
+import { RemoteFlags } from '../../../flags'
+
+export default class SyncImportCommand extends IronfishCommand {
+  static description = `Import an account with its wallet state from a sync file
+
+The sync file is written by accounts:sync:export on another node. The blocks
+this node's wallet has scanned past the export are scanned for the account. If
+the node has not synced the block the state was exported at, the account is
+rescanned instead.`
+
+  static flags = {
+    ...RemoteFlags,
+  }
+
+  static args = [
+    {
+      name: 'path',
+      parse: (input: string): Promise<string> => Promise.resolve(input.trim()),
+      required: true,
+      description: 'the sync file written by accounts:sync:export',
+    },
+  ]
+
+  async start(): Promise<void> {
+    const { args } = await this.parse(SyncImportCommand)
+    const path = this.sdk.fileSystem.resolve(args.path as string)
+    const contents = await fsAsync.readFile(path, 'utf8')
+
+    const passphrase = (await CliUx.ux.prompt('Enter the passphrase of the sync file', {
+      type: 'hide',
+      required: true,
+    })) as string
+
+    let state: WalletState
+    try {
+      state = decryptWalletData(contents, passphrase, 'sync file') as WalletState
+    } catch (e: unknown) {
+      this.log(e instanceof Error ? e.message : String(e))
+      this.exit(1)
+    }
+
+    const client = await this.sdk.connectRpc()
+    const response = await client.importAccountState({ state })
+    const result = response.content
+
+    this.log(`Account ${result.name} imported.`)
+
+    if (result.rescan) {
+      this.log(
+        `This node has not synced the block the state was exported at, so the account` +
+          ` is being rescanned instead`,
+      )
+    } else {
+      this.log(
+        `Imported ${result.transactions} transactions and ${result.notes} notes, and` +
+          ` scanned ${result.scanned} newer blocks`,
+      )
+    }
+
+    if (result.isDefaultAccount) {
+      this.log(`The default account is now: ${result.name}`)
+    }
+  }
+}
@@ -20,6 +20,7 @@ describe('accounts:transactions', () => {
         notes: 1,
         spends: 1,
         expiration: 1,
+        dust: false,
       },
     ],
   }
@@ -61,6 +62,14 @@ describe('accounts:transactions', () => {
           init: jest.fn().mockImplementation(() => ({
             connectRpc: jest.fn().mockResolvedValue(client),
             client,
+            // No .ironfish-account file and no defaultAccount in the config
+            fileSystem: {
+              resolve: jest.fn((path: string) => path),
+              join: jest.fn((...paths: string[]) => paths.join('/')),
+              dirname: jest.fn((path: string) => path),
+              exists: jest.fn().mockResolvedValue(false),
+            },
+            config: { get: jest.fn().mockReturnValue('') },
           })),
         },
       }
@@ -4,13 +4,14 @@
 import { oreToIron } from '@ironfish/sdk'
 import { CliUx, Flags } from '@oclif/core'
 import { IronfishCommand } from '../../command'
-import { RemoteFlags } from '../../flags'
+import { JsonFlag, JsonFlagKey, RemoteFlags } from '../../flags'
 
 export class TransactionsCommand extends IronfishCommand {
   static description = `Display the account transactions`
 
   static flags = {
     ...RemoteFlags,
+    [JsonFlagKey]: JsonFlag,
     account: Flags.string({
       char: 'a',
       description: 'account transactions',
@@ -19,17 +20,21 @@ export class TransactionsCommand extends IronfishCommand {
       char: 't',
       description: 'details of transaction hash',
     }),
+    dust: Flags.boolean({
+      default: false,
+      description: 'include transactions that only sent the account dust',
+    }),
   }
 
   async start(): Promise<void> {
     const { flags } = await this.parse(TransactionsCommand)
-    const account = flags.account?.trim()
+    const account = await this.getAccountName(flags.account?.trim())
     const hash = flags.hash?.trim()
 
     if (hash) {
       await this.getTransaction(account, hash)
     } else {
-      await this.getTransactions(account)
+      await this.getTransactions(account, flags.dust)
     }
   }
 
@@ -38,6 +43,11 @@ export class TransactionsCommand extends IronfishCommand {
 
     const response = await client.getAccountTransaction({ account, hash })
 
+    if (this.json) {
+      this.logJson(response.content)
+      return
+    }
+
     const {
       account: accountResponse,
       transactionHash,
@@ -76,10 +86,15 @@ export class TransactionsCommand extends IronfishCommand {
     this.log(`\n`)
   }
 
-  async getTransactions(account: string | undefined): Promise<void> {
+  async getTransactions(account: string | undefined, includeDust: boolean): Promise<void> {
     const client = await this.sdk.connectRpc()
 
-    const response = await client.getAccountTransactions({ account })
+    const response = await client.getAccountTransactions({ account, includeDust })
+
+    if (this.json) {
+      this.logJson(response.content)
+      return
+    }
 
     const { account: accountResponse, transactions } = response.content
 
@@ -113,6 +128,14 @@ export class TransactionsCommand extends IronfishCommand {
       expiration: {
         header: 'Expiration',
       },
+      ...(includeDust
+        ? {
+            dust: {
+              header: 'Dust',
+              get: (row: { dust: boolean }) => (row.dust ? `✔` : `x`),
+            },
+          }
+        : {}),
     })
 
     this.log(`\n`)
@@ -0,0 +1,80 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import {
+  displayIronAmountWithCurrency,
+  JSONUtils,
+  oreToIron,
+  PaymentDisclosure,
+} from '@ironfish/sdk'
+import { Flags } from '@oclif/core'
+import { IronfishCommand } from '../../command'
+import { JsonFlag, JsonFlagKey, RemoteFlags } from '../../flags'
+
+export class VerifyDisclosureCommand extends IronfishCommand {
+  static aliases = ['wallet:verify-disclosure']
+  static description = `Check a payment disclosure from accounts:disclose against the chain`
+
+  static flags = {
+    ...RemoteFlags,
+    [JsonFlagKey]: JsonFlag,
+    file: Flags.string({
+      description: 'read the disclosure from a file',
+    }),
+  }
+
+  static args = [
+    {
+      name: 'disclosure',
+      required: false,
+      description: 'the disclosure JSON',
+    },
+  ]
+
+  async start(): Promise<void> {
+    const { args, flags } = await this.parse(VerifyDisclosureCommand)
+
+    let data = args.disclosure as string | undefined
+    if (flags.file) {
+      data = await this.sdk.fileSystem.readFile(this.sdk.fileSystem.resolve(flags.file))
+    }
+
+    if (!data) {
+      this.error('Pass the disclosure as an argument or with --file')
+    }
+
+    let disclosure: PaymentDisclosure
+    try {
+      disclosure = JSONUtils.parse<PaymentDisclosure>(data)
+    } catch {
+      this.error('The disclosure is not valid JSON')
+    }
+
+    const client = await this.sdk.connectRpc()
+    const response = await client.verifyDisclosure({ disclosure })
+    const result = response.content
+
+    if (this.json) {
+      this.logJson(result)
+    }
+
+    if (!result.valid) {
+      if (!this.json) {
+        this.log(`The disclosure is NOT valid: ${String(result.reason)}`)
+      }
+      this.exit(1)
+    }
+
+    if (this.json) {
+      return
+    }
+
+    const amount = displayIronAmountWithCurrency(oreToIron(Number(result.amount)), true)
+    this.log('The disclosure is valid')
+    this.log(`Owner: ${String(result.owner)}`)
+    this.log(`Amount: ${amount}`)
+    this.log(`Memo: ${String(result.memo)}`)
+    this.log(`Block: ${String(result.sequence)}`)
+    this.log(`Confirmations: ${String(result.confirmations)}`)
+  }
+}
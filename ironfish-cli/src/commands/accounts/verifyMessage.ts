@@ -0,0 +1,51 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { IronfishCommand } from '../../command'
+import { RemoteFlags } from '../../flags'
+
+export class VerifyMessageCommand extends IronfishCommand {
+  static aliases = ['wallet:verify-message']
+  static description = `Check that a message was signed by the owner of an address`
+
+  static flags = {
+    ...RemoteFlags,
+  }
+
+  static args = [
+    {
+      name: 'address',
+      required: true,
+      description: 'the public address that signed the message',
+    },
+    {
+      name: 'message',
+      required: true,
+      description: 'the message that was signed',
+    },
+    {
+      name: 'signature',
+      required: true,
+      description: 'the signature from accounts:signMessage',
+    },
+  ]
+
+  async start(): Promise<void> {
+    const { args } = await this.parse(VerifyMessageCommand)
+
+    const client = await this.sdk.connectRpc()
+
+    const response = await client.verifyMessage({
+      publicAddress: (args.address as string).trim(),
+      message: args.message as string,
+      signature: (args.signature as string).trim(),
+    })
+
+    if (!response.content.valid) {
+      this.log('The signature is NOT valid for this address and message')
+      this.exit(1)
+    }
+
+    this.log('The signature is valid, the message was signed by the owner of the address')
+  }
+}
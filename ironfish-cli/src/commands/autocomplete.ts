@@ -0,0 +1,81 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { Flags } from '@oclif/core'
+import path from 'path'
+import { IronfishCommand } from '../command'
+import { RemoteFlags } from '../flags'
+import {
+  COMPLETION_SHELLS,
+  CompletionShell,
+  getCompletionCommands,
+  renderCompletion,
+} from '../utils/completion'
+
+export default class Autocomplete extends IronfishCommand {
+  static description = 'Generate a shell completion script'
+
+  static args = [
+    {
+      name: 'shell',
+      required: false,
+      options: [...COMPLETION_SHELLS],
+      description: 'the shell to generate completions for, defaults to your current shell',
+    },
+  ]
+
+  static flags = {
+    ...RemoteFlags,
+    accounts: Flags.boolean({
+      default: false,
+      hidden: true,
+      description: 'print the account names on a running node, used by completion scripts',
+    }),
+  }
+
+  static examples = [
+    '$ ironfish autocomplete bash >> ~/.bashrc',
+    '$ ironfish autocomplete zsh > "${fpath[1]}/_ironfish"',
+    '$ ironfish autocomplete fish > ~/.config/fish/completions/ironfish.fish',
+    '$ ironfish autocomplete powershell >> $PROFILE',
+  ]
+
+  async start(): Promise<void> {
+    const { args, flags } = await this.parse(Autocomplete)
+
+    if (flags.accounts) {
+      await this.logAccounts()
+      this.exit(0)
+    }
+
+    const shell = (args.shell as CompletionShell | undefined) ?? detectShell()
+    const commands = getCompletionCommands(this.config)
+
+    this.log(renderCompletion(shell, this.config.bin, commands))
+    this.exit(0)
+  }
+
+  async logAccounts(): Promise<void> {
+    // Don't start a node just to complete account names
+    const connected = await this.sdk.client.tryConnect()
+    if (!connected) {
+      return
+    }
+
+    const response = await this.sdk.client.getAccounts()
+    for (const name of response.content.accounts) {
+      this.log(name)
+    }
+  }
+}
+
+function detectShell(): CompletionShell {
+  if (process.platform === 'win32') {
+    return 'powershell'
+  }
+
+  const shell = path.basename(process.env.SHELL ?? '')
+  return (COMPLETION_SHELLS as readonly string[]).includes(shell)
+    ? (shell as CompletionShell)
+    : 'bash'
+}
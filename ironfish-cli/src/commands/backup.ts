@@ -9,7 +9,14 @@ import os from 'os'
 import path from 'path'
 import { v4 as uuid } from 'uuid'
 import { IronfishCommand } from '../command'
-import { DataDirFlag, DataDirFlagKey, VerboseFlag, VerboseFlagKey } from '../flags'
+import {
+  DataDirFlag,
+  DataDirFlagKey,
+  ProfileFlag,
+  ProfileFlagKey,
+  VerboseFlag,
+  VerboseFlagKey,
+} from '../flags'
 
 export default class Backup extends IronfishCommand {
   static hidden = true
@@ -18,6 +25,7 @@ export default class Backup extends IronfishCommand {
   static flags = {
     [VerboseFlagKey]: VerboseFlag,
     [DataDirFlagKey]: DataDirFlag,
+    [ProfileFlagKey]: ProfileFlag,
     lock: Flags.boolean({
       default: true,
       allowNo: true,
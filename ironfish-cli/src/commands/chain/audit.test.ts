@@ -0,0 +1,239 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as ironfishmodule from '@ironfish/sdk'
+import { expect as expectCli, test } from '@oclif/test'
+import fs from 'fs'
+import os from 'os'
+import path from 'path'
+import tweetnacl from 'tweetnacl'
+import { v4 as uuid } from 'uuid'
+import { SignedAuditReport } from './audit'
+
+describe('chain:audit', () => {
+  const REWARD = 20
+
+  type FakeBlock = {
+    header: {
+      sequence: number
+      hash: Buffer
+      noteCommitment: { commitment: Buffer; size: number }
+      nullifierCommitment: { commitment: Buffer; size: number }
+    }
+    transactions: {
+      fee: () => bigint
+      notesLength: () => number
+      spends: () => Generator<{ nullifier: Buffer }>
+    }[]
+  }
+
+  // The fake trees have a root that is derived from their size
+  const root = (size: number) => Buffer.alloc(32, size)
+
+  let blocks: FakeBlock[] = []
+
+  const addBlock = (options: { reward?: number; nullifiers?: string[] } = {}) => {
+    const sequence = blocks.length + 1
+    const prev = blocks[blocks.length - 1]
+    const notes = (prev?.header.noteCommitment.size ?? 0) + 1
+    const spends = (options.nullifiers ?? []).map((n) => ({ nullifier: Buffer.from(n, 'hex') }))
+    const nullifiers = (prev?.header.nullifierCommitment.size ?? 0) + spends.length
+
+    blocks.push({
+      header: {
+        sequence,
+        hash: Buffer.alloc(32, 100 + sequence),
+        noteCommitment: { commitment: root(notes), size: notes },
+        nullifierCommitment: { commitment: root(nullifiers), size: nullifiers },
+      },
+      transactions: [
+        {
+          fee: () => BigInt(-(options.reward ?? REWARD)),
+          notesLength: () => 1,
+          spends: function* () {
+            yield* spends
+          },
+        },
+      ],
+    })
+  }
+
+  const ironFishSdkBackup = ironfishmodule.IronfishSdk.init
+  const dir = path.join(os.tmpdir(), uuid())
+
+  beforeAll(() => {
+    fs.mkdirSync(dir, { recursive: true })
+  })
+
+  beforeEach(() => {
+    blocks = []
+
+    const chain = {
+      get head() {
+        return blocks[blocks.length - 1].header
+      },
+      get isEmpty() {
+        return blocks.length === 0
+      },
+      network: { id: 0 },
+      open: jest.fn(),
+      getHeaderAtSequence: (sequence: number) =>
+        Promise.resolve(blocks[sequence - 1]?.header ?? null),
+      getBlock: (header: { sequence: number }) =>
+        Promise.resolve(blocks[header.sequence - 1] ?? null),
+      getNext: (header: { sequence: number }) =>
+        Promise.resolve(blocks[header.sequence]?.header ?? null),
+      notes: { pastRoot: (size: number) => Promise.resolve(root(size)) },
+      nullifiers: { pastRoot: (size: number) => Promise.resolve(root(size)) },
+    }
+
+    const node = {
+      openDB: jest.fn(),
+      chain,
+      strategy: { miningReward: () => REWARD },
+      pkg: { version: '0.0.0' },
+      config: { chainDatabasePath: '/chain' },
+    }
+
+    ironfishmodule.IronfishSdk.init = jest.fn().mockImplementation(() => ({
+      node: jest.fn().mockResolvedValue(node),
+      internal: { get: () => 'ab'.repeat(32) },
+    }))
+  })
+
+  afterEach(() => {
+    ironfishmodule.IronfishSdk.init = ironFishSdkBackup
+  })
+
+  const readReport = (name: string): SignedAuditReport =>
+    JSON.parse(fs.readFileSync(path.join(dir, name), 'utf8')) as SignedAuditReport
+
+  test
+    .do(() => {
+      addBlock({ reward: 1000 })
+      addBlock({ nullifiers: ['aa'.repeat(32)] })
+      addBlock({ nullifiers: ['bb'.repeat(32), 'cc'.repeat(32)] })
+    })
+    .stdout()
+    .command([
+      'chain:audit',
+      '--report',
+      path.join(dir, 'valid.json'),
+      '--nullifiers',
+      path.join(dir, 'nullifiers.txt'),
+    ])
+    .exit(0)
+    .it('passes a valid chain and exports its nullifiers', (ctx) => {
+      expectCli(ctx.stdout).include('The audit passed')
+
+      const { report } = readReport('valid.json')
+      expect(report).toMatchObject({
+        head: { sequence: 3 },
+        blocks: 3,
+        transactions: 3,
+        nullifiers: 3,
+        supply: { genesis: '1000', total: '1040', expected: '1040' },
+        failureCount: 0,
+        valid: true,
+      })
+
+      const nullifiers = fs.readFileSync(path.join(dir, 'nullifiers.txt'), 'utf8')
+      expect(nullifiers.trim().split('\n')).toEqual([
+        'aa'.repeat(32),
+        'bb'.repeat(32),
+        'cc'.repeat(32),
+      ])
+    })
+
+  test
+    .do(() => {
+      addBlock()
+      addBlock({ nullifiers: ['aa'.repeat(32)] })
+      addBlock({ nullifiers: ['aa'.repeat(32)] })
+    })
+    .stdout()
+    .command(['chain:audit', '--report', path.join(dir, 'doublespend.json')])
+    .exit(1)
+    .it('finds nullifiers that are spent twice', (ctx) => {
+      expectCli(ctx.stdout).include('The audit found 1 problems')
+
+      const { report } = readReport('doublespend.json')
+      expect(report.valid).toBe(false)
+      expect(report.failures).toEqual([
+        {
+          sequence: 3,
+          hash: Buffer.alloc(32, 103).toString('hex'),
+          reason: `Nullifier ${'aa'.repeat(32)} was already spent`,
+        },
+      ])
+    })
+
+  test
+    .do(() => {
+      addBlock()
+      addBlock({ reward: REWARD + 1 })
+    })
+    .stdout()
+    .command(['chain:audit', '--report', path.join(dir, 'supply.json')])
+    .exit(1)
+    .it('finds blocks that create more coins than the reward', () => {
+      const { report } = readReport('supply.json')
+      expect(report.supply).toEqual({ genesis: '20', total: '41', expected: '40' })
+      expect(report.failures).toMatchObject([
+        { sequence: 2, reason: `The block created 21 ore but the reward is ${REWARD}` },
+      ])
+    })
+
+  test
+    .do(() => {
+      addBlock()
+      addBlock()
+      blocks[1].header.noteCommitment.commitment = Buffer.alloc(32)
+    })
+    .stdout()
+    .command(['chain:audit', '--report', path.join(dir, 'roots.json')])
+    .exit(1)
+    .it('finds tree roots that do not match the headers', () => {
+      const { report } = readReport('roots.json')
+      expect(report.failures).toMatchObject([
+        { sequence: 2, reason: 'The note tree root does not match the header' },
+      ])
+    })
+
+  describe('--verify', () => {
+    const writeReport = (name: string, tamper: boolean) => {
+      const keyPair = tweetnacl.sign.keyPair()
+      const report = { blocks: 3, head: { sequence: 3, hash: 'ab' }, failureCount: 0 }
+      const signature = tweetnacl.sign.detached(
+        Buffer.from(JSON.stringify(report)),
+        keyPair.secretKey,
+      )
+
+      const signed = {
+        report: tamper ? { ...report, failureCount: 1 } : report,
+        publicKey: Buffer.from(keyPair.publicKey).toString('hex'),
+        signature: Buffer.from(signature).toString('hex'),
+      }
+
+      fs.writeFileSync(path.join(dir, name), JSON.stringify(signed))
+    }
+
+    test
+      .do(() => writeReport('signed.json', false))
+      .stdout()
+      .command(['chain:audit', '--verify', path.join(dir, 'signed.json')])
+      .exit(0)
+      .it('accepts a report with a valid signature', (ctx) => {
+        expectCli(ctx.stdout).include('The report was signed by')
+      })
+
+    test
+      .do(() => writeReport('tampered.json', true))
+      .stdout()
+      .command(['chain:audit', '--verify', path.join(dir, 'tampered.json')])
+      .exit(1)
+      .it('rejects a report that was changed after signing', (ctx) => {
+        expectCli(ctx.stdout).include('is not valid')
+      })
+  })
+})
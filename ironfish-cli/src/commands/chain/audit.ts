@@ -0,0 +1,350 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import {
+  Assert,
+  Block,
+  BlockHeader,
+  displayIronAmountWithCurrency,
+  GENESIS_BLOCK_SEQUENCE,
+  IronfishNode,
+  Meter,
+  oreToIron,
+  TimeUtils,
+} from '@ironfish/sdk'
+import { CliUx, Flags } from '@oclif/core'
+import fs from 'fs'
+import fsAsync from 'fs/promises'
+import tweetnacl from 'tweetnacl'
+import { IronfishCommand } from '../../command'
+import { LocalFlags } from '../../flags'
+import { ProgressBar } from '../../types'
+
+// How many failures to keep in the report, the count is always kept
+const MAX_REPORTED_FAILURES = 100
+
+export type AuditFailure = {
+  sequence: number
+  hash: string
+  reason: string
+}
+
+export type AuditReport = {
+  networkId: string
+  version: string
+  createdAt: string
+  head: { sequence: number; hash: string }
+  blocks: number
+  transactions: number
+  nullifiers: number
+  supply: {
+    /**
+     * Ore created by the genesis block
+     */
+    genesis: string
+    /**
+     * Ore created by every block including the genesis block
+     */
+    total: string
+    /**
+     * Ore the emission schedule says should exist at the head
+     */
+    expected: string
+  }
+  failureCount: number
+  failures: AuditFailure[]
+  valid: boolean
+}
+
+export type SignedAuditReport = {
+  report: AuditReport
+  publicKey: string
+  signature: string
+}
+
+export default class AuditChain extends IronfishCommand {
+  static description = `Audit the chain for double spends, corrupt trees and supply
+
+Every block from genesis to the head is checked that no nullifier is spent
+twice, the note and nullifier tree roots match the block headers, and the coins
+each block creates match the emission schedule.
+
+The report is signed with a key derived from this node's network identity so it
+can be published and checked later with --verify.`
+
+  static flags = {
+    ...LocalFlags,
+    report: Flags.string({
+      description: 'write the signed audit report to this path',
+    }),
+    nullifiers: Flags.string({
+      description: 'export every spent nullifier as hex, one per line, to this path',
+    }),
+    verify: Flags.string({
+      description: 'check the signature of a report at this path instead of auditing',
+      exclusive: ['report', 'nullifiers'],
+    }),
+  }
+
+  static examples = [
+    '$ ironfish chain:audit',
+    '$ ironfish chain:audit --report audit.json --nullifiers nullifiers.txt',
+    '$ ironfish chain:audit --verify audit.json',
+  ]
+
+  async start(): Promise<void> {
+    const { flags } = await this.parse(AuditChain)
+
+    if (flags.verify) {
+      return this.verifyReport(flags.verify)
+    }
+
+    CliUx.ux.action.start(`Opening node`)
+    const node = await this.sdk.node()
+    await node.openDB()
+    await node.chain.open()
+    CliUx.ux.action.stop('done.')
+
+    if (node.chain.isEmpty) {
+      this.log('The chain is empty, there is nothing to audit')
+      this.exit(0)
+    }
+
+    const report = await this.audit(node, flags.nullifiers)
+    const signed = this.signReport(report)
+
+    this.log(`\nBlocks        ${report.blocks}`)
+    this.log(`Transactions  ${report.transactions}`)
+    this.log(`Nullifiers    ${report.nullifiers}`)
+    this.log(`Supply        ${this.renderOre(report.supply.total)}`)
+    this.log(`Expected      ${this.renderOre(report.supply.expected)}`)
+    this.log(`Signed by     ${signed.publicKey}`)
+
+    if (flags.report) {
+      await fsAsync.writeFile(flags.report, JSON.stringify(signed, undefined, '  '))
+      this.log(`\nReport written to ${flags.report}`)
+    } else {
+      this.log(`\n${JSON.stringify(signed, undefined, '  ')}`)
+    }
+
+    if (!report.valid) {
+      this.log(`\n❗ The audit found ${report.failureCount} problems:`)
+
+      for (const failure of report.failures) {
+        this.log(`  ${failure.sequence} ${failure.hash}: ${failure.reason}`)
+      }
+
+      this.log(`\nRun ironfish chain:repair or delete ${node.config.chainDatabasePath}`)
+      this.exit(1)
+    }
+
+    this.log('\nThe audit passed')
+  }
+
+  async audit(node: IronfishNode, nullifiersPath?: string): Promise<AuditReport> {
+    Assert.isNotNull(node.chain.head)
+
+    const head = node.chain.head
+    const total = head.sequence
+    const spent = new Set<string>()
+    const failures: AuditFailure[] = []
+    let failureCount = 0
+    let transactions = 0
+    let genesisSupply = BigInt(0)
+    let totalSupply = BigInt(0)
+    let expectedSupply = BigInt(0)
+
+    const addFailure = (header: BlockHeader, reason: string) => {
+      failureCount++
+      if (failures.length < MAX_REPORTED_FAILURES) {
+        failures.push({ sequence: header.sequence, hash: header.hash.toString('hex'), reason })
+      }
+    }
+
+    const exported = nullifiersPath ? fs.createWriteStream(nullifiersPath) : null
+
+    const speed = new Meter()
+    const progress = CliUx.ux.progress({
+      format: 'Auditing blocks: [{bar}] {value}/{total} {percentage}% {speed}/sec | {estimate}',
+    }) as ProgressBar
+
+    speed.start()
+    progress.start(total, 0, { speed: '-', estimate: '-' })
+
+    let prev: BlockHeader | null = null
+    let header = await node.chain.getHeaderAtSequence(GENESIS_BLOCK_SEQUENCE)
+    let done = 0
+
+    while (header) {
+      const block: Block | null = await node.chain.getBlock(header)
+
+      if (!block) {
+        addFailure(header, 'The block body is missing')
+        break
+      }
+
+      let minted = BigInt(0)
+      let notes = 0
+      let nullifiers = 0
+
+      for (const transaction of block.transactions) {
+        transactions++
+
+        // Miner's fees are negative so this sums to the coins the block creates
+        minted -= transaction.fee()
+        notes += transaction.notesLength()
+
+        for (const spend of transaction.spends()) {
+          nullifiers++
+
+          const nullifier = spend.nullifier.toString('hex')
+          if (spent.has(nullifier)) {
+            addFailure(header, `Nullifier ${nullifier} was already spent`)
+            continue
+          }
+
+          spent.add(nullifier)
+          exported?.write(`${nullifier}\n`)
+        }
+      }
+
+      const prevNotes = prev ? prev.noteCommitment.size : 0
+      const prevNullifiers = prev ? prev.nullifierCommitment.size : 0
+
+      if (header.noteCommitment.size !== prevNotes + notes) {
+        addFailure(
+          header,
+          `Note tree size ${header.noteCommitment.size} expected ${prevNotes + notes}`,
+        )
+      }
+
+      if (header.nullifierCommitment.size !== prevNullifiers + nullifiers) {
+        addFailure(
+          header,
+          `Nullifier tree size ${header.nullifierCommitment.size}` +
+            ` expected ${prevNullifiers + nullifiers}`,
+        )
+      }
+
+      const noteRoot = await node.chain.notes.pastRoot(header.noteCommitment.size)
+      if (!noteRoot.equals(header.noteCommitment.commitment)) {
+        addFailure(header, 'The note tree root does not match the header')
+      }
+
+      const nullifierSize = header.nullifierCommitment.size
+      const nullifierRoot = await node.chain.nullifiers.pastRoot(nullifierSize)
+      if (!nullifierRoot.equals(header.nullifierCommitment.commitment)) {
+        addFailure(header, 'The nullifier tree root does not match the header')
+      }
+
+      if (header.sequence === GENESIS_BLOCK_SEQUENCE) {
+        genesisSupply = minted
+        expectedSupply += minted
+      } else {
+        const reward = BigInt(node.strategy.miningReward(header.sequence))
+        expectedSupply += reward
+
+        if (minted !== reward) {
+          addFailure(header, `The block created ${minted} ore but the reward is ${reward}`)
+        }
+      }
+
+      totalSupply += minted
+
+      if (header.hash.equals(head.hash)) {
+        break
+      }
+
+      prev = header
+      header = await node.chain.getNext(header)
+
+      done++
+      speed.add(1)
+      progress.increment()
+      progress.update({
+        estimate: TimeUtils.renderEstimate(done, total, speed.rate1m),
+        speed: speed.rate1s.toFixed(0),
+      })
+    }
+
+    progress.stop()
+    speed.stop()
+
+    if (exported) {
+      await new Promise((resolve) => exported.end(resolve))
+    }
+
+    if (!header || !header.hash.equals(head.hash)) {
+      failureCount++
+      failures.push({
+        sequence: prev ? prev.sequence : 0,
+        hash: prev ? prev.hash.toString('hex') : '',
+        reason: 'The main chain ends before the head, run chain:repair',
+      })
+    }
+
+    return {
+      networkId: node.chain.network.id,
+      version: node.pkg.version,
+      createdAt: new Date().toISOString(),
+      head: { sequence: head.sequence, hash: head.hash.toString('hex') },
+      blocks: done + 1,
+      transactions,
+      nullifiers: spent.size,
+      supply: {
+        genesis: genesisSupply.toString(),
+        total: totalSupply.toString(),
+        expected: expectedSupply.toString(),
+      },
+      failureCount,
+      failures,
+      valid: failureCount === 0,
+    }
+  }
+
+  /**
+   * The report is signed with an ed25519 key seeded from the network identity
+   * secret key, or a new key if this node has never started
+   */
+  signReport(report: AuditReport): SignedAuditReport {
+    const networkIdentity = this.sdk.internal.get('networkIdentity')
+
+    const keyPair =
+      networkIdentity.length > 31
+        ? tweetnacl.sign.keyPair.fromSeed(Buffer.from(networkIdentity, 'hex').slice(0, 32))
+        : tweetnacl.sign.keyPair()
+
+    const message = Buffer.from(JSON.stringify(report))
+    const signature = tweetnacl.sign.detached(message, keyPair.secretKey)
+
+    return {
+      report,
+      publicKey: Buffer.from(keyPair.publicKey).toString('hex'),
+      signature: Buffer.from(signature).toString('hex'),
+    }
+  }
+
+  async verifyReport(path: string): Promise<void> {
+    const signed = JSON.parse(await fsAsync.readFile(path, 'utf8')) as SignedAuditReport
+
+    const verified = tweetnacl.sign.detached.verify(
+      Buffer.from(JSON.stringify(signed.report)),
+      Buffer.from(signed.signature, 'hex'),
+      Buffer.from(signed.publicKey, 'hex'),
+    )
+
+    if (!verified) {
+      this.log(`The signature of ${path} is not valid`)
+      this.exit(1)
+    }
+
+    const { report } = signed
+    this.log(`The report was signed by ${signed.publicKey}`)
+    this.log(`Audited ${report.blocks} blocks to ${report.head.hash} (${report.head.sequence})`)
+    this.log(`Supply ${this.renderOre(report.supply.total)}, ${report.failureCount} problems`)
+  }
+
+  renderOre(ore: string): string {
+    return displayIronAmountWithCurrency(oreToIron(Number(ore)), true)
+  }
+}
@@ -0,0 +1,108 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as ironfishmodule from '@ironfish/sdk'
+import { GraffitiUtils } from '@ironfish/sdk'
+import { expect as expectCli, test } from '@oclif/test'
+
+describe('chain:block', () => {
+  const hash = Buffer.alloc(32, 1)
+
+  const transaction = (fee: bigint, minersFee: boolean) => ({
+    unsignedHash: () => Buffer.alloc(32, Number(fee)),
+    fee: () => fee,
+    spendsLength: () => (minersFee ? 0 : 1),
+    notesLength: () => 2,
+    expirationSequence: () => 0,
+    isMinersFee: () => minersFee,
+  })
+
+  const header = {
+    hash,
+    sequence: 3,
+    previousBlockHash: Buffer.alloc(32, 2),
+    timestamp: new Date(1652890982781),
+    target: { toDifficulty: () => BigInt(131072) },
+    work: BigInt(393216),
+    graffiti: GraffitiUtils.fromString('testGraffiti'),
+    minersFee: BigInt(-2000000005),
+    noteCommitment: { commitment: Buffer.alloc(32, 3), size: 7 },
+    nullifierCommitment: { commitment: Buffer.alloc(32, 4), size: 2 },
+  }
+
+  const block = {
+    header,
+    transactions: [
+      transaction(BigInt(-2000000005), true),
+      transaction(BigInt(3), false),
+      transaction(BigInt(2), false),
+    ],
+  }
+
+  const chain = {
+    head: { sequence: 3 },
+    getHeaderAtSequence: jest.fn(),
+    getHeader: jest.fn(),
+    getBlock: jest.fn(),
+    isHeadChain: jest.fn(),
+  }
+
+  const ironFishSdkBackup = ironfishmodule.IronfishSdk.init
+
+  beforeEach(() => {
+    chain.getHeaderAtSequence.mockClear().mockResolvedValue(header)
+    chain.getHeader.mockResolvedValue(null)
+    chain.getBlock.mockClear().mockResolvedValue(block)
+    chain.isHeadChain.mockResolvedValue(true)
+
+    ironfishmodule.IronfishSdk.init = jest.fn().mockImplementation(() => ({
+      node: jest.fn().mockResolvedValue({ openDB: jest.fn(), chain }),
+    }))
+  })
+
+  afterEach(() => {
+    ironfishmodule.IronfishSdk.init = ironFishSdkBackup
+  })
+
+  test
+    .stdout()
+    .command(['chain:block', '3', '--json'])
+    .exit(0)
+    .it('shows the block and its fees as JSON', (ctx) => {
+      expect(chain.getHeaderAtSequence).toHaveBeenCalledWith(3)
+
+      const output = JSON.parse(ctx.stdout) as Record<string, unknown>
+      expect(output).toMatchObject({
+        hash: hash.toString('hex'),
+        sequence: 3,
+        main: true,
+        graffiti: 'testGraffiti',
+        minersReward: '2000000005',
+        fees: '5',
+        noteCommitment: { size: 7 },
+        nullifierCommitment: { size: 2 },
+      })
+      expect(output.transactions).toHaveLength(3)
+    })
+
+  test
+    .do(() => {
+      chain.getHeader.mockResolvedValue(header)
+    })
+    .stdout()
+    .command(['chain:block', hash.toString('hex')])
+    .exit(0)
+    .it('shows the block by hash', (ctx) => {
+      expect(chain.getHeader).toHaveBeenCalledWith(hash)
+      expectCli(ctx.stdout).include('Block 3 (main chain)')
+      expectCli(ctx.stdout).include('Graffiti:       testGraffiti')
+      expectCli(ctx.stdout).include('Transactions:   3')
+    })
+
+  test
+    .command(['chain:block', 'ff'.repeat(32)])
+    .catch((e) => expect(e.message).toContain('No block found matching'))
+    .it('fails when the block is not in the chain', () => {
+      expect(chain.getBlock).not.toHaveBeenCalled()
+    })
+})
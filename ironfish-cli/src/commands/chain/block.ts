@@ -0,0 +1,140 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { displayIronAmountWithCurrency, GraffitiUtils, oreToIron } from '@ironfish/sdk'
+import { CliUx } from '@oclif/core'
+import { IronfishCommand } from '../../command'
+import { JsonFlag, JsonFlagKey, LocalFlags } from '../../flags'
+import { findBlockHeader } from '../../utils'
+
+export default class ChainBlock extends IronfishCommand {
+  static description = 'Show a block and its transactions from the local chain'
+
+  static flags = {
+    ...LocalFlags,
+    [JsonFlagKey]: JsonFlag,
+  }
+
+  static args = [
+    {
+      name: 'search',
+      parse: (input: string): Promise<string> => Promise.resolve(input.trim()),
+      required: true,
+      description:
+        'the hash or sequence of the block, negative sequences count back from the head',
+    },
+  ]
+
+  static examples = [
+    '$ ironfish chain:block 1000',
+    '$ ironfish chain:block -1',
+    '$ ironfish chain:block 000000000000123a7d4b6b8d09a1f4c6a9e4b7d0c2e8f1a3b5c7d9e0f2a4b6c8',
+  ]
+
+  async start(): Promise<void> {
+    const { args } = await this.parse(ChainBlock)
+    const search = args.search as string
+
+    CliUx.ux.action.start(`Opening node`)
+    const node = await this.sdk.node()
+    await node.openDB()
+    CliUx.ux.action.stop('done.')
+
+    const header = await findBlockHeader(node.chain, search)
+    if (!header) {
+      this.error(`No block found matching ${search}`)
+    }
+
+    const block = await node.chain.getBlock(header)
+    if (!block) {
+      this.error(`No transactions found for block ${header.hash.toString('hex')}`)
+    }
+
+    const main = await node.chain.isHeadChain(header)
+
+    const transactions = block.transactions.map((transaction) => ({
+      hash: transaction.unsignedHash().toString('hex'),
+      fee: transaction.fee(),
+      spends: transaction.spendsLength(),
+      outputs: transaction.notesLength(),
+      expirationSequence: transaction.expirationSequence(),
+      minersFee: transaction.isMinersFee(),
+    }))
+
+    const fees = transactions
+      .filter((transaction) => !transaction.minersFee)
+      .reduce((sum, transaction) => sum + transaction.fee, BigInt(0))
+
+    const info = {
+      hash: header.hash.toString('hex'),
+      sequence: header.sequence,
+      previousBlockHash: header.previousBlockHash.toString('hex'),
+      main,
+      timestamp: header.timestamp.getTime(),
+      difficulty: header.target.toDifficulty(),
+      work: header.work,
+      graffiti: GraffitiUtils.toHuman(header.graffiti),
+      minersReward: -header.minersFee,
+      fees,
+      noteCommitment: {
+        commitment: header.noteCommitment.commitment.toString('hex'),
+        size: header.noteCommitment.size,
+      },
+      nullifierCommitment: {
+        commitment: header.nullifierCommitment.commitment.toString('hex'),
+        size: header.nullifierCommitment.size,
+      },
+      transactions,
+    }
+
+    if (this.json) {
+      this.logJson(info)
+      return
+    }
+
+    const notes = info.noteCommitment
+    const nullifiers = info.nullifierCommitment
+
+    this.log(`Block ${info.sequence} ${main ? '(main chain)' : '(fork)'}`)
+    this.log(`  Hash:           ${info.hash}`)
+    this.log(`  Previous Hash:  ${info.previousBlockHash}`)
+    this.log(`  Timestamp:      ${header.timestamp.toISOString()}`)
+    this.log(`  Difficulty:     ${info.difficulty.toString()}`)
+    this.log(`  Work:           ${info.work.toString()}`)
+    this.log(`  Graffiti:       ${info.graffiti}`)
+    this.log(`  Miner Reward:   ${renderOre(info.minersReward)}`)
+    this.log(`  Fees:           ${renderOre(info.fees)}`)
+    this.log(`  Note Tree:      ${notes.commitment} (${notes.size})`)
+    this.log(`  Nullifier Set:  ${nullifiers.commitment} (${nullifiers.size})`)
+    this.log(`  Transactions:   ${transactions.length}`)
+    this.log('')
+
+    CliUx.ux.table(transactions, {
+      hash: {
+        header: 'Transaction Hash',
+      },
+      fee: {
+        header: 'Fee ($ORE)',
+        get: (row) => row.fee.toString(),
+      },
+      spends: {
+        header: 'Spends',
+      },
+      outputs: {
+        header: 'Outputs',
+      },
+      expirationSequence: {
+        header: 'Expiration',
+        get: (row) => (row.expirationSequence === 0 ? 'none' : row.expirationSequence),
+      },
+      minersFee: {
+        header: "Miner's Fee",
+        get: (row) => (row.minersFee ? '✔' : ''),
+      },
+    })
+  }
+}
+
+function renderOre(ore: bigint): string {
+  return displayIronAmountWithCurrency(oreToIron(Number(ore)), true)
+}
@@ -0,0 +1,64 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { checkChainIntegrity } from '@ironfish/sdk'
+import { CliUx, Flags } from '@oclif/core'
+import { IronfishCommand } from '../../command'
+import { JsonFlag, JsonFlagKey, LocalFlags } from '../../flags'
+
+export default class CheckCommand extends IronfishCommand {
+  static description = `Check the chain database for corruption
+
+By default only the last headers are checked, which takes a few seconds. Use
+--full to check every header back to the genesis block.`
+
+  static flags = {
+    ...LocalFlags,
+    [JsonFlagKey]: JsonFlag,
+    depth: Flags.integer({
+      description: 'how many headers behind the head to check, defaults to integrityCheckDepth',
+      min: 0,
+      exclusive: ['full'],
+    }),
+    full: Flags.boolean({
+      default: false,
+      description: 'check every header on the main chain',
+    }),
+  }
+
+  async start(): Promise<void> {
+    const { flags } = await this.parse(CheckCommand)
+
+    CliUx.ux.action.start(`Opening node`)
+    const node = await this.sdk.node()
+    await node.openDB()
+    CliUx.ux.action.stop('done.')
+
+    const depth = flags.full
+      ? node.chain.head.sequence
+      : flags.depth ?? node.config.get('integrityCheckDepth')
+
+    CliUx.ux.action.start(`Checking the chain database`)
+    const report = await checkChainIntegrity(node.chain, { depth })
+    CliUx.ux.action.stop(`checked ${report.checked} headers in ${report.durationMs}ms`)
+
+    if (this.json) {
+      this.logJson(report)
+    }
+
+    if (report.valid) {
+      this.log('No problems found.')
+      return
+    }
+
+    for (const problem of report.problems) {
+      this.log(`❗ ${problem}`)
+    }
+
+    this.log(
+      `\nRun "ironfish chain:repair" to rebuild the chain database, or restore a snapshot` +
+        ` with "ironfish chain:snapshot --download <url>".`,
+    )
+    this.exit(1)
+  }
+}
@@ -0,0 +1,55 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { CliUx } from '@oclif/core'
+import { IronfishCommand } from '../../command'
+import { RemoteFlags } from '../../flags'
+
+export default class ConfirmReorgCommand extends IronfishCommand {
+  static description = `Reorganize to a fork deeper than maxReorgDepth
+
+The node refuses to reorganize more blocks than maxReorgDepth by itself. Check
+that the fork is legitimate before confirming, anything mined after the fork on
+the current chain is undone.`
+
+  static flags = {
+    ...RemoteFlags,
+  }
+
+  static args = [
+    {
+      name: 'hash',
+      parse: (input: string): Promise<string> => Promise.resolve(input.trim()),
+      required: false,
+      description: 'the head of the fork to reorganize to, defaults to the refused fork',
+    },
+  ]
+
+  async start(): Promise<void> {
+    const { args } = await this.parse(ConfirmReorgCommand)
+    const hash = args.hash as string | undefined
+
+    const client = await this.sdk.connectRpc()
+
+    if (!hash) {
+      const status = await client.status()
+      const refused = status.content.blockchain.refusedReorg
+
+      if (!refused) {
+        this.log('No reorganization was refused')
+        this.exit(0)
+      }
+
+      this.log(`Head           ${status.content.blockchain.head}`)
+      this.log(`Refused Fork   ${refused}`)
+
+      const confirmed = await CliUx.ux.confirm('Reorganize to the refused fork? (y/n)')
+      if (!confirmed) {
+        this.exit(0)
+      }
+    }
+
+    const response = await client.confirmReorg({ hash })
+    this.log(`Reorganized to ${response.content.head} (${response.content.sequence})`)
+  }
+}
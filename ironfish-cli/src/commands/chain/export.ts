@@ -1,7 +1,7 @@
 /* This Source Code Form is subject to the terms of the Mozilla Public
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
-import { AsyncUtils, GENESIS_BLOCK_SEQUENCE } from '@ironfish/sdk'
+import { AsyncUtils, GENESIS_BLOCK_SEQUENCE, writeBlockArchive } from '@ironfish/sdk'
 import { CliUx, Flags } from '@oclif/core'
 import fs from 'fs'
 import { parseNumber } from '../../args'
@@ -10,7 +10,11 @@ import { RemoteFlags } from '../../flags'
 import { ProgressBar } from '../../types'
 
 export default class Export extends IronfishCommand {
-  static description = 'Export part of the chain database to JSON'
+  static description = `Export part of the chain database to JSON or a block archive
+
+With --format bin the blocks of the main chain are written to a versioned
+binary archive, described in blockchain/archive.ts of the SDK, which can be
+checked and added to another node with chain:import.`
 
   static flags = {
     ...RemoteFlags,
@@ -20,6 +24,21 @@ export default class Export extends IronfishCommand {
       required: false,
       description: 'a path to export the chain to',
     }),
+    serialized: Flags.boolean({
+      default: false,
+      description: 'include the full blocks so the export can be used with chain:replay',
+    }),
+    format: Flags.string({
+      options: ['json', 'bin'],
+      default: 'json',
+      description: 'export JSON, or a block archive for chain:import',
+    }),
+    start: Flags.integer({
+      description: 'the sequence to start at, instead of the start argument',
+    }),
+    end: Flags.integer({
+      description: 'the sequence to end at, instead of the stop argument',
+    }),
   }
 
   static args = [
@@ -38,20 +57,27 @@ export default class Export extends IronfishCommand {
     },
   ]
 
+  static examples = [
+    '$ ironfish chain:export --start 1 --end 1000 --format bin --path ./export',
+    '$ ironfish chain:import ./export/blocks.bin',
+  ]
+
   async start(): Promise<void> {
     const { flags, args } = await this.parse(Export)
+    const binary = flags.format === 'bin'
 
     const exportDir = flags.path
       ? this.sdk.fileSystem.resolve(flags.path)
       : this.sdk.config.dataDir
 
-    const exportPath = this.sdk.fileSystem.join(exportDir, 'data.json')
+    const exportPath = this.sdk.fileSystem.join(exportDir, binary ? 'blocks.bin' : 'data.json')
 
     const client = await this.sdk.connectRpc()
 
     const stream = client.exportChainStream({
-      start: args.start as number | null,
-      stop: args.stop as number | null,
+      start: flags.start ?? (args.start as number | null),
+      stop: flags.end ?? (args.stop as number | null),
+      serialized: flags.serialized || binary,
     })
 
     const { start, stop } = await AsyncUtils.first(stream.contentStream())
@@ -64,17 +90,31 @@ export default class Export extends IronfishCommand {
     progress.start(stop - start + 1, 0)
 
     const results: unknown[] = []
+    const blocks: Buffer[] = []
 
     for await (const result of stream.contentStream()) {
       results.push(result.block)
       progress.update((result.block?.seq || 0) - start + 1)
+
+      // Archives only hold the main chain, so they can be imported in order
+      if (result.block?.main && result.block.serialized) {
+        blocks.push(Buffer.from(result.block.serialized, 'hex'))
+      }
     }
 
     progress.stop()
 
     await this.sdk.fileSystem.mkdir(exportDir, { recursive: true })
 
-    await fs.promises.writeFile(exportPath, JSON.stringify(results, undefined, '  '))
+    if (binary) {
+      const info = await client.getChainInfo()
+      const genesis = Buffer.from(info.content.genesisBlockIdentifier.hash, 'hex')
+      const archive = writeBlockArchive({ genesis, start, stop }, blocks)
+      await fs.promises.writeFile(exportPath, archive)
+    } else {
+      await fs.promises.writeFile(exportPath, JSON.stringify(results, undefined, '  '))
+    }
+
     this.log('Export complete')
   }
 }
@@ -2,11 +2,13 @@
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 import {
+  DEFAULT_CONSENSUS_PARAMETERS,
   GENESIS_SUPPLY_IN_IRON,
   GenesisBlockInfo,
   IJSON,
   ironToOre,
   makeGenesisBlock,
+  NetworkDefinition,
   Target,
 } from '@ironfish/sdk'
 import { Flags } from '@oclif/core'
@@ -36,6 +38,10 @@ export default class GenesisBlockCommand extends IronfishCommand {
       default: 'Genesis Block',
       description: 'The memo of the block',
     }),
+    networkId: Flags.string({
+      required: false,
+      description: 'Output a network definition file for a network with this id',
+    }),
   }
 
   async start(): Promise<void> {
@@ -86,8 +92,23 @@ export default class GenesisBlockCommand extends IronfishCommand {
     this.log('\nBuilding a genesis block...')
     const { block } = await makeGenesisBlock(node.chain, info, account, this.logger)
 
-    this.log(`\nGenesis Block`)
     const serialized = node.strategy.blockSerde.serialize(block)
+
+    if (flags.networkId) {
+      const definition: NetworkDefinition = {
+        id: flags.networkId,
+        bootstrapNodes: [],
+        genesis: serialized,
+        consensus: DEFAULT_CONSENSUS_PARAMETERS,
+      }
+
+      this.log(`\nNetwork Definition`)
+      this.log(IJSON.stringify(definition, '  '))
+      this.log(`\nSave this to a file and start nodes with --network <file>`)
+      return
+    }
+
+    this.log(`\nGenesis Block`)
     this.log(IJSON.stringify(serialized, '  '))
   }
 }
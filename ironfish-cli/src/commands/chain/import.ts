@@ -0,0 +1,106 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { BlockArchiveError, readBlockArchive, readBlockFromBuffer } from '@ironfish/sdk'
+import { CliUx } from '@oclif/core'
+import fsAsync from 'fs/promises'
+import { IronfishCommand } from '../../command'
+import { LocalFlags } from '../../flags'
+import { ProgressBar } from '../../types'
+
+export default class ImportChain extends IronfishCommand {
+  static description = `Add the blocks of a block archive to the chain
+
+The archive must be written by chain:export --format bin from a node on the
+same network. Its checksum is checked before any block is added, and every
+block is verified the same way as blocks from peers. Blocks already on the
+chain are skipped, so an archive can be imported more than once.`
+
+  static flags = {
+    ...LocalFlags,
+  }
+
+  static args = [
+    {
+      name: 'file',
+      parse: (input: string): Promise<string> => Promise.resolve(input.trim()),
+      required: true,
+      description: 'the blocks.bin written by chain:export --format bin',
+    },
+  ]
+
+  static examples = ['$ ironfish chain:import ./export/blocks.bin']
+
+  async start(): Promise<void> {
+    const { args } = await this.parse(ImportChain)
+    const file = this.sdk.fileSystem.resolve(args.file as string)
+
+    let archive
+    try {
+      archive = readBlockArchive(await fsAsync.readFile(file))
+    } catch (error: unknown) {
+      if (error instanceof BlockArchiveError) {
+        this.log(`${file} is not a valid block archive: ${error.message}`)
+        this.exit(1)
+      }
+      throw error
+    }
+
+    const { header, blocks } = archive
+
+    CliUx.ux.action.start(`Opening node`)
+    const node = await this.sdk.node()
+    await node.openDB()
+    await node.chain.open()
+    CliUx.ux.action.stop('done.')
+
+    if (!header.genesis.equals(node.chain.genesis.hash)) {
+      const genesis = header.genesis.toString('hex')
+      this.log(`The archive is from another network, its genesis block is ${genesis}`)
+      await node.closeDB()
+      this.exit(1)
+    }
+
+    this.log(`Importing ${header.count} blocks from ${header.start} -> ${header.stop}`)
+
+    const progress = CliUx.ux.progress({
+      format: 'Importing blocks: [{bar}] {value}/{total} {percentage}% | ETA: {eta}s',
+    }) as ProgressBar
+
+    progress.start(blocks.length, 0)
+
+    let added = 0
+    let skipped = 0
+
+    for (const buffer of blocks) {
+      const block = node.strategy.blockSerde.deserialize(readBlockFromBuffer(buffer))
+
+      if (await node.chain.hasBlock(block.header.hash)) {
+        skipped++
+        progress.increment()
+        continue
+      }
+
+      const { isAdded, reason } = await node.chain.addBlock(block)
+
+      if (!isAdded) {
+        progress.stop()
+        const hash = block.header.hash.toString('hex')
+        this.log(`Could not add block ${hash} (${block.header.sequence}): ${String(reason)}`)
+        await node.closeDB()
+        this.exit(1)
+      }
+
+      added++
+      progress.increment()
+    }
+
+    progress.stop()
+
+    const head = node.chain.head
+    this.log(`Added ${added} blocks, ${skipped} were already on the chain`)
+    this.log(`The head is now ${head.hash.toString('hex')} (${head.sequence})`)
+
+    await node.closeDB()
+  }
+}
@@ -0,0 +1,57 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { CliUx, Flags } from '@oclif/core'
+import { IronfishCommand } from '../../command'
+import { JsonFlag, JsonFlagKey, RemoteFlags } from '../../flags'
+
+export default class MinersCommand extends IronfishCommand {
+  static description = 'Show who mined the recent blocks, grouped by graffiti'
+
+  static flags = {
+    ...RemoteFlags,
+    [JsonFlagKey]: JsonFlag,
+    since: Flags.integer({
+      default: -1000,
+      description: 'the first block to count, negative numbers count back from the head',
+    }),
+  }
+
+  static examples = [
+    '$ ironfish chain:miners --since 250000',
+    '$ ironfish chain:miners --since -100',
+  ]
+
+  async start(): Promise<void> {
+    const { flags } = await this.parse(MinersCommand)
+
+    const client = await this.sdk.connectRpc()
+    const response = await client.getMiners({ start: flags.since })
+    const { start, stop, blocks, miners } = response.content
+
+    if (this.json) {
+      this.logJson(response.content)
+      return
+    }
+
+    this.log(`${blocks} blocks from ${start} to ${stop}`)
+    this.log('')
+
+    CliUx.ux.table(miners, {
+      graffiti: {
+        header: 'Graffiti',
+        get: (row) => row.graffiti || '(none)',
+      },
+      blocks: {
+        header: 'Blocks',
+      },
+      percent: {
+        header: 'Percent',
+        get: (row) => `${((row.blocks / blocks) * 100).toFixed(2)}%`,
+      },
+      lastSequence: {
+        header: 'Last Block',
+      },
+    })
+  }
+}
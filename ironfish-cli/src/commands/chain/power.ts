@@ -0,0 +1,60 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { displayIronAmountWithCurrency, FileUtils, oreToIron } from '@ironfish/sdk'
+import { Flags } from '@oclif/core'
+import { IronfishCommand } from '../../command'
+import { RemoteFlags } from '../../flags'
+
+const DAY_MS = 24 * 60 * 60 * 1000
+
+export default class PowerCommand extends IronfishCommand {
+  static description = 'Show the estimated hashrate of the network'
+
+  static flags = {
+    ...RemoteFlags,
+    blocks: Flags.integer({
+      default: 120,
+      description: 'how many blocks to average the hashrate over',
+    }),
+    sequence: Flags.integer({
+      description: 'the block to estimate the hashrate at, defaults to the head',
+    }),
+    hashrate: Flags.integer({
+      description: 'your hashrate in H/s, to estimate how much you would mine a day',
+    }),
+  }
+
+  static examples = ['$ ironfish chain:power --blocks 1000 --hashrate 2500000']
+
+  async start(): Promise<void> {
+    const { flags } = await this.parse(PowerCommand)
+
+    const client = await this.sdk.connectRpc()
+    const response = await client.getNetworkHashrate({
+      blocks: flags.blocks,
+      sequence: flags.sequence,
+    })
+    const power = response.content
+
+    this.log(`Sequence        ${power.sequence}`)
+    this.log(`Blocks          ${power.blocks}`)
+    this.log(`Hashrate        ${FileUtils.formatHashRate(power.hashesPerSecond)}/s`)
+    this.log(`Difficulty      ${power.difficulty}`)
+    this.log(`Block Time      ${(power.averageBlockTime / 1000).toFixed(1)}s`)
+
+    if (flags.hashrate === undefined || !power.hashesPerSecond || !power.averageBlockTime) {
+      return
+    }
+
+    // Your share of the network hashrate is the share of blocks you'd mine
+    const share = flags.hashrate / power.hashesPerSecond
+    const blocksPerDay = (DAY_MS / power.averageBlockTime) * share
+    const orePerDay = Math.round(blocksPerDay * Number(power.blockReward))
+
+    this.log('')
+    this.log(`Network Share   ${(share * 100).toFixed(4)}%`)
+    this.log(`Blocks Per Day  ${blocksPerDay.toFixed(2)}`)
+    this.log(`Reward Per Day  ${displayIronAmountWithCurrency(oreToIron(orePerDay), false)}`)
+  }
+}
@@ -11,7 +11,7 @@ import {
 import { Meter } from '@ironfish/sdk'
 import { CliUx, Flags } from '@oclif/core'
 import { IronfishCommand } from '../../command'
-import { LocalFlags } from '../../flags'
+import { LocalFlags, YesFlag, YesFlagKey } from '../../flags'
 import { ProgressBar } from '../../types'
 
 const TREE_BATCH = 1000
@@ -26,6 +26,7 @@ export default class RepairChain extends IronfishCommand {
 
   static flags = {
     ...LocalFlags,
+    [YesFlagKey]: YesFlag,
     confirm: Flags.boolean({
       char: 'c',
       default: false,
@@ -63,7 +64,7 @@ export default class RepairChain extends IronfishCommand {
 
     const confirmed =
       flags.confirm ||
-      (await CliUx.ux.confirm(
+      (await this.confirm(
         `\n⚠️ If you start repairing your database, you MUST finish the\n` +
           `process or your database will be in a corrupt state. Repairing\n` +
           `may take ${estimate} or longer.\n\n` +
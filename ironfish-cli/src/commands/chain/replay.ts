@@ -0,0 +1,288 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import {
+  BenchUtils,
+  IronfishSdk,
+  MathUtils,
+  Meter,
+  readBlockFromBuffer,
+  TimeUtils,
+} from '@ironfish/sdk'
+import { CliUx, Flags } from '@oclif/core'
+import fsAsync from 'fs/promises'
+import os from 'os'
+import path from 'path'
+import { IronfishCommand } from '../../command'
+import { JsonFlag, JsonFlagKey, LocalFlags } from '../../flags'
+import { IronfishCliPKG } from '../../package'
+import { ProgressBar } from '../../types'
+
+type ExportedBlock = {
+  seq: number
+  hash: string
+  serialized?: string
+}
+
+export type ReplayStage = {
+  count: number
+  totalMs: number
+  meanMs: number
+  p50Ms: number
+  p95Ms: number
+  maxMs: number
+}
+
+export type ReplayProfile = {
+  version: string
+  createdAt: string
+  network: string
+  platform: string
+  cpu: string
+  cpus: number
+  memory: number
+  nodeVersion: string
+  workers: number
+  blocks: number
+  skipped: number
+  failed: number
+  elapsedMs: number
+  blocksPerSecond: number
+  stages: Record<string, ReplayStage>
+}
+
+export default class ReplayChain extends IronfishCommand {
+  static description = `Replay an exported block range onto a fresh database and profile it
+
+The export must be made with chain:export --serialized. Every block is added to
+an empty chain in a temporary data dir while each verification stage is timed,
+so the profile can be compared between machines or attached to bug reports.`
+
+  static flags = {
+    ...LocalFlags,
+    [JsonFlagKey]: JsonFlag,
+    report: Flags.string({
+      description: 'write the profile as JSON to this path',
+    }),
+  }
+
+  static args = [
+    {
+      name: 'file',
+      parse: (input: string): Promise<string> => Promise.resolve(input.trim()),
+      required: true,
+      description: 'the data.json written by chain:export --serialized',
+    },
+  ]
+
+  static examples = [
+    '$ ironfish chain:export 1 1000 --serialized --path ./export',
+    '$ ironfish chain:replay ./export/data.json --report profile.json',
+  ]
+
+  async start(): Promise<void> {
+    const { flags, args } = await this.parse(ReplayChain)
+    const file = this.sdk.fileSystem.resolve(args.file as string)
+
+    const exported = JSON.parse(await fsAsync.readFile(file, 'utf8')) as ExportedBlock[]
+    const blocks = exported.filter((b) => b.serialized)
+
+    if (!blocks.length) {
+      this.log(`${file} has no serialized blocks, export it with chain:export --serialized`)
+      this.exit(1)
+    }
+
+    const dataDir = await fsAsync.mkdtemp(path.join(os.tmpdir(), 'ironfish-replay-'))
+
+    try {
+      const profile = await this.replay(blocks, dataDir)
+
+      if (flags.report) {
+        await fsAsync.writeFile(flags.report, JSON.stringify(profile, undefined, '  '))
+        this.log(`Profile written to ${flags.report}`)
+      }
+
+      if (this.json) {
+        this.logJson(profile)
+      } else {
+        this.renderProfile(profile)
+      }
+
+      if (profile.failed) {
+        this.exit(1)
+      }
+    } finally {
+      await fsAsync.rm(dataDir, { recursive: true, force: true })
+    }
+  }
+
+  async replay(blocks: ExportedBlock[], dataDir: string): Promise<ReplayProfile> {
+    const sdk = await IronfishSdk.init({
+      pkg: IronfishCliPKG,
+      dataDir,
+      logger: this.logger,
+      configOverrides: {
+        network: this.sdk.config.get('network'),
+        nodeWorkers: this.sdk.config.get('nodeWorkers'),
+        nodeWorkersMax: this.sdk.config.get('nodeWorkersMax'),
+        bootstrapNodes: [''],
+        enableTelemetry: false,
+        ntpServer: '',
+      },
+    })
+
+    CliUx.ux.action.start(`Opening a fresh node in ${dataDir}`)
+    const node = await sdk.node()
+    await node.openDB()
+    await node.chain.open()
+    node.workerPool.start()
+    CliUx.ux.action.stop('done.')
+
+    const samples = new Map<string, number[]>()
+    const sample = (operation: string, elapsedMs: number) => {
+      const values = samples.get(operation) ?? []
+      values.push(elapsedMs)
+      samples.set(operation, values)
+    }
+
+    node.chain.tracer.onSpan.on(sample)
+
+    const speed = new Meter()
+    const progress = CliUx.ux.progress({
+      format: 'Replaying: [{bar}] {value}/{total} {percentage}% {speed}/sec | {estimate}',
+    }) as ProgressBar
+
+    let skipped = 0
+    let failed = 0
+    let done = 0
+
+    speed.start()
+    progress.start(blocks.length, 0, { speed: '-', estimate: '-' })
+    const start = BenchUtils.start()
+
+    for (const exported of blocks) {
+      if (!exported.serialized) {
+        continue
+      }
+
+      const deserializeStart = BenchUtils.start()
+      const buffer = Buffer.from(exported.serialized, 'hex')
+      const block = node.strategy.blockSerde.deserialize(readBlockFromBuffer(buffer))
+      sample('block.deserialize', BenchUtils.end(deserializeStart))
+
+      // The fresh chain already has the genesis block
+      if (await node.chain.hasBlock(block.header.hash)) {
+        skipped++
+      } else {
+        const addStart = BenchUtils.start()
+        const { isAdded, reason } = await node.chain.addBlock(block)
+        sample('block.add', BenchUtils.end(addStart))
+
+        if (!isAdded) {
+          failed++
+          this.logger.warn(
+            `Could not add block ${exported.hash} (${exported.seq}): ${String(reason)}`,
+          )
+        }
+      }
+
+      done++
+      speed.add(1)
+      progress.increment()
+      progress.update({
+        estimate: TimeUtils.renderEstimate(done, blocks.length, speed.rate1m),
+        speed: speed.rate1s.toFixed(0),
+      })
+    }
+
+    const elapsedMs = BenchUtils.end(start)
+    progress.stop()
+    speed.stop()
+
+    node.chain.tracer.onSpan.off(sample)
+    await node.workerPool.stop()
+    await node.closeDB()
+
+    const stages: Record<string, ReplayStage> = {}
+    for (const [operation, values] of samples) {
+      stages[operation] = this.summarize(values)
+    }
+
+    const cpus = os.cpus()
+
+    return {
+      version: node.pkg.version,
+      createdAt: new Date().toISOString(),
+      network: node.config.get('network'),
+      platform: `${os.platform()} ${os.arch()}`,
+      cpu: cpus.length ? cpus[0].model : 'unknown',
+      cpus: cpus.length,
+      memory: os.totalmem(),
+      nodeVersion: process.version,
+      workers: node.workerPool.numWorkers,
+      blocks: done - skipped,
+      skipped,
+      failed,
+      elapsedMs,
+      blocksPerSecond: elapsedMs > 0 ? ((done - skipped) * 1000) / elapsedMs : 0,
+      stages,
+    }
+  }
+
+  summarize(values: number[]): ReplayStage {
+    const sorted = [...values].sort((a, b) => a - b)
+    const percentile = (p: number) =>
+      sorted[Math.min(sorted.length - 1, Math.floor(p * sorted.length))]
+
+    return {
+      count: sorted.length,
+      totalMs: MathUtils.arraySum(sorted),
+      meanMs: MathUtils.arrayAverage(sorted),
+      p50Ms: percentile(0.5),
+      p95Ms: percentile(0.95),
+      maxMs: sorted[sorted.length - 1],
+    }
+  }
+
+  renderProfile(profile: ReplayProfile): void {
+    this.log(`\nReplayed      ${profile.blocks} blocks (${profile.skipped} skipped)`)
+    this.log(`Failed        ${profile.failed}`)
+    this.log(`Elapsed       ${(profile.elapsedMs / 1000).toFixed(1)}s`)
+    this.log(`Speed         ${profile.blocksPerSecond.toFixed(2)} blocks/sec`)
+    this.log(`Machine       ${profile.cpus}x ${profile.cpu}, ${profile.platform}`)
+    this.log(`Workers       ${profile.workers}`)
+    this.log('')
+
+    const rows = Object.entries(profile.stages).map(([stage, stats]) => ({ stage, ...stats }))
+    const ms = (value: number) => `${value.toFixed(2)}ms`
+
+    CliUx.ux.table(rows, {
+      stage: {
+        header: 'Stage',
+      },
+      count: {
+        header: 'Count',
+      },
+      totalMs: {
+        header: 'Total',
+        get: (row) => ms(row.totalMs),
+      },
+      meanMs: {
+        header: 'Mean',
+        get: (row) => ms(row.meanMs),
+      },
+      p50Ms: {
+        header: 'P50',
+        get: (row) => ms(row.p50Ms),
+      },
+      p95Ms: {
+        header: 'P95',
+        get: (row) => ms(row.p95Ms),
+      },
+      maxMs: {
+        header: 'Max',
+        get: (row) => ms(row.maxMs),
+      },
+    })
+  }
+}
@@ -0,0 +1,249 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { FileUtils, NodeUtils, S3Client } from '@ironfish/sdk'
+import { CliUx, Flags } from '@oclif/core'
+import { spawn } from 'child_process'
+import fsAsync from 'fs/promises'
+import os from 'os'
+import path from 'path'
+import { IronfishCommand } from '../../command'
+import { LocalFlags } from '../../flags'
+import { ProgressBar } from '../../types'
+import { hashFile } from '../../utils'
+
+const EXTENSION = '.tar.gz'
+const MANIFEST_EXTENSION = '.json'
+
+/**
+ * Uploaded next to every snapshot so downloads can be checked before they are
+ * unpacked over the chain database
+ */
+export type SnapshotManifest = {
+  version: number
+  network: string
+  sequence: number
+  hash: string
+  createdAt: string
+  file: string
+  size: number
+  sha256: string
+}
+
+export default class Snapshot extends IronfishCommand {
+  static description = `Create or restore a snapshot of the chain database
+
+Without --download a snapshot of the chain database is written to --path, and
+uploaded to S3 compatible storage with --upload. A manifest with the checksum
+of the snapshot is written next to it.
+
+With --download the snapshot is downloaded, checked against its manifest, and
+replaces the chain database. S3 credentials, the region, and a custom endpoint
+are read from the standard AWS environment variables.`
+
+  static flags = {
+    ...LocalFlags,
+    path: Flags.string({
+      description: 'the directory to write the snapshot to, defaults to the data dir',
+    }),
+    upload: Flags.string({
+      description: 'an s3:// URL of the bucket and prefix to upload the snapshot to',
+      exclusive: ['download'],
+    }),
+    download: Flags.string({
+      description: 'an s3:// URL of a snapshot to download and restore',
+      exclusive: ['upload', 'path'],
+    }),
+    partSize: Flags.integer({
+      default: 64,
+      description: 'megabytes to send in each part of an upload or download',
+    }),
+  }
+
+  static examples = [
+    '$ ironfish chain:snapshot --upload s3://my-bucket/snapshots',
+    '$ ironfish chain:snapshot --download s3://my-bucket/snapshots/ironfish.123.tar.gz',
+  ]
+
+  async start(): Promise<void> {
+    const { flags } = await this.parse(Snapshot)
+    const partSize = flags.partSize * 1024 * 1024
+
+    if (flags.download) {
+      return this.download(flags.download, partSize)
+    }
+
+    const snapshot = await this.create(flags.path)
+
+    if (flags.upload) {
+      await this.upload(flags.upload, snapshot, partSize)
+    }
+  }
+
+  async create(dir?: string): Promise<{ path: string; manifest: SnapshotManifest }> {
+    CliUx.ux.action.start(`Opening node`)
+    const node = await this.sdk.node()
+    await NodeUtils.waitForOpen(node)
+    await node.chain.open()
+    CliUx.ux.action.stop('done.')
+
+    if (node.chain.isEmpty) {
+      this.log('The chain is empty, there is nothing to snapshot')
+      this.exit(1)
+    }
+
+    const head = node.chain.head
+    const network = node.config.get('network')
+
+    // Close the database so it isn't written to while it's being zipped
+    await node.closeDB()
+
+    const outputDir = dir ? this.sdk.fileSystem.resolve(dir) : this.sdk.config.dataDir
+    await fsAsync.mkdir(outputDir, { recursive: true })
+
+    const name = `ironfish.${network}.${head.sequence}${EXTENSION}`
+    const output = path.join(outputDir, name)
+
+    CliUx.ux.action.start(`Zipping the chain at ${head.sequence} to ${output}`)
+    const source = this.sdk.config.chainDatabasePath
+    await this.tar(['-zcf', output, '-C', path.dirname(source), path.basename(source)])
+    CliUx.ux.action.stop('done.')
+
+    CliUx.ux.action.start('Computing the checksum')
+    const [stat, sha256] = await Promise.all([fsAsync.stat(output), hashFile(output)])
+    CliUx.ux.action.stop(sha256)
+
+    const manifest: SnapshotManifest = {
+      version: 1,
+      network,
+      sequence: head.sequence,
+      hash: head.hash.toString('hex'),
+      createdAt: new Date().toISOString(),
+      file: name,
+      size: stat.size,
+      sha256,
+    }
+
+    await fsAsync.writeFile(
+      `${output}${MANIFEST_EXTENSION}`,
+      JSON.stringify(manifest, undefined, '  '),
+    )
+
+    this.log(`Snapshot written to ${output} (${FileUtils.formatFileSize(stat.size)})`)
+    return { path: output, manifest }
+  }
+
+  async upload(
+    url: string,
+    snapshot: { path: string; manifest: SnapshotManifest },
+    partSize: number,
+  ): Promise<void> {
+    const { bucket, key: prefix } = S3Client.parseUrl(url)
+    const key = path.posix.join(prefix, snapshot.manifest.file)
+    const client = S3Client.fromEnvironment()
+
+    const progress = this.transferProgress('Uploading')
+
+    await client.uploadFile(bucket, key, snapshot.path, {
+      partSize,
+      onProgress: (uploaded, total) => progress.update(uploaded / total),
+    })
+
+    progress.stop()
+
+    await client.putObject(
+      bucket,
+      `${key}${MANIFEST_EXTENSION}`,
+      Buffer.from(JSON.stringify(snapshot.manifest, undefined, '  ')),
+    )
+
+    this.log(`Uploaded the snapshot to s3://${bucket}/${key}`)
+  }
+
+  async download(url: string, partSize: number): Promise<void> {
+    const { bucket, key } = S3Client.parseUrl(url)
+    const client = S3Client.fromEnvironment()
+
+    const manifest = JSON.parse(
+      (await client.getObject(bucket, `${key}${MANIFEST_EXTENSION}`)).toString('utf8'),
+    ) as SnapshotManifest
+
+    const network = this.sdk.config.get('network')
+    if (manifest.network !== network) {
+      this.log(`The snapshot is of ${manifest.network} but this node uses ${network}`)
+      this.exit(1)
+    }
+
+    this.log(
+      `Downloading the chain at ${manifest.sequence} ${manifest.hash}` +
+        ` (${FileUtils.formatFileSize(manifest.size)})`,
+    )
+
+    const workDir = await fsAsync.mkdtemp(path.join(os.tmpdir(), 'ironfish.snapshot'))
+
+    try {
+      const downloadTo = path.join(workDir, manifest.file)
+      const progress = this.transferProgress('Downloading')
+
+      await client.downloadFile(bucket, key, downloadTo, {
+        partSize,
+        onProgress: (downloaded, total) => progress.update(downloaded / total),
+      })
+
+      progress.stop()
+
+      CliUx.ux.action.start('Verifying the checksum')
+      const sha256 = await hashFile(downloadTo)
+      if (sha256 !== manifest.sha256) {
+        CliUx.ux.action.stop('failed')
+        this.log(`The snapshot is corrupt, expected ${manifest.sha256} got ${sha256}`)
+        this.exit(1)
+      }
+      CliUx.ux.action.stop('done.')
+
+      // Wait for any running node to let go of the database before replacing it
+      const node = await this.sdk.node()
+      await NodeUtils.waitForOpen(node)
+      await node.closeDB()
+
+      const destination = this.sdk.config.chainDatabasePath
+
+      CliUx.ux.action.start(`Unzipping to ${destination}`)
+
+      // Unzip next to the database so it can be moved into place without copying
+      const unzipTo = `${destination}.snapshot`
+      await fsAsync.rm(unzipTo, { recursive: true, force: true })
+      await fsAsync.mkdir(unzipTo, { recursive: true })
+      await this.tar(['-xzf', downloadTo, '-C', unzipTo])
+
+      const [unzipped] = await fsAsync.readdir(unzipTo)
+      await fsAsync.rm(destination, { recursive: true, force: true })
+      await fsAsync.rename(path.join(unzipTo, unzipped), destination)
+      await fsAsync.rm(unzipTo, { recursive: true, force: true })
+      CliUx.ux.action.stop('done.')
+
+      this.log(`Restored the chain at ${manifest.sequence}`)
+    } finally {
+      await fsAsync.rm(workDir, { recursive: true, force: true })
+    }
+  }
+
+  transferProgress(title: string): ProgressBar {
+    const progress = CliUx.ux.progress({
+      format: `${title} snapshot: [{bar}] {percentage}% | ETA: {eta}s`,
+    }) as ProgressBar
+
+    progress.start(1, 0)
+    return progress
+  }
+
+  tar(args: string[]): Promise<void> {
+    return new Promise<void>((resolve, reject) => {
+      const process = spawn('tar', args)
+      process.on('exit', (code) =>
+        code === 0 ? resolve() : reject(new Error(`tar exited with ${String(code)}`)),
+      )
+      process.on('error', (error) => reject(error))
+    })
+  }
+}
@@ -0,0 +1,58 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { displayIronAmountWithCurrency, oreToIron } from '@ironfish/sdk'
+import { CliUx, Flags } from '@oclif/core'
+import { IronfishCommand } from '../../command'
+import { RemoteFlags } from '../../flags'
+
+export default class SupplyCommand extends IronfishCommand {
+  static description = 'Show the circulating supply and the emission schedule'
+
+  static flags = {
+    ...RemoteFlags,
+    years: Flags.integer({
+      default: 10,
+      description: 'how many years of the emission schedule to show',
+    }),
+  }
+
+  async start(): Promise<void> {
+    const { flags } = await this.parse(SupplyCommand)
+
+    const client = await this.sdk.connectRpc()
+    const response = await client.getSupply({ years: flags.years })
+    const supply = response.content
+
+    const render = (ore: string) => displayIronAmountWithCurrency(oreToIron(Number(ore)), false)
+
+    this.log(`Sequence      ${supply.sequence}`)
+    this.log(`Genesis       ${render(supply.genesis)}`)
+    this.log(`Mined         ${render(supply.mined)}`)
+    this.log(`Burned        ${render(supply.burned)}`)
+    this.log(`Circulating   ${render(supply.circulating)}`)
+
+    if (!supply.emission.length) {
+      return
+    }
+
+    this.log('')
+
+    CliUx.ux.table(supply.emission, {
+      year: {
+        header: 'Year',
+      },
+      startSequence: {
+        header: 'Starts At',
+      },
+      blockReward: {
+        header: 'Block Reward',
+        get: (row) => render(row.blockReward),
+      },
+      supply: {
+        header: 'Supply At End',
+        get: (row) => render(row.supply),
+      },
+    })
+  }
+}
@@ -0,0 +1,83 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { PropagationPeerResponse } from '@ironfish/sdk'
+import { IronfishCommand } from '../../command'
+import { JsonFlag, JsonFlagKey, RemoteFlags } from '../../flags'
+
+export default class TraceCommand extends IronfishCommand {
+  static description = `Show how a block or transaction propagated through this node
+
+Shows which peer the node first heard it from, which other peers sent it, and
+which peers it was forwarded to. Only recent blocks and transactions are kept,
+and only while enablePropagationTracing is set in the config.`
+
+  static flags = {
+    ...RemoteFlags,
+    [JsonFlagKey]: JsonFlag,
+  }
+
+  static args = [
+    {
+      name: 'hash',
+      parse: (input: string): Promise<string> => Promise.resolve(input.trim().toLowerCase()),
+      required: true,
+      description: 'the hash of the block or transaction',
+    },
+  ]
+
+  static examples = [
+    '$ ironfish config:set enablePropagationTracing true',
+    '$ ironfish chain:trace <hash>',
+  ]
+
+  async start(): Promise<void> {
+    const { args } = await this.parse(TraceCommand)
+    const hash = args.hash as string
+
+    const client = await this.sdk.connectRpc()
+    const response = await client.getPropagationTrace({ hash })
+    const { enabled, trace } = response.content
+
+    if (this.json) {
+      this.logJson(response.content)
+      return
+    }
+
+    if (!enabled) {
+      this.error(
+        'Propagation tracing is off. Run "ironfish config:set enablePropagationTracing true"' +
+          ' and trace blocks and transactions the node sees after that.',
+      )
+    }
+
+    if (!trace) {
+      this.error(`No trace found for ${hash}, it may be older than the traces the node keeps`)
+    }
+
+    const source = trace.firstSeenFrom ? renderPeer(trace.firstSeenFrom) : 'this node'
+    this.log(`${trace.type === 'block' ? 'Block' : 'Transaction'} ${trace.hash}`)
+    this.log(`First seen at ${new Date(trace.firstSeenAt).toISOString()} from ${source}`)
+
+    this.log(`\nReceived from ${trace.received.length} peers:`)
+    for (const event of trace.received) {
+      this.log(`  +${event.elapsedMs}ms ${renderPeer(event)}`)
+    }
+
+    this.log(`\nForwarded to ${trace.forwarded.length} peers:`)
+    for (const event of trace.forwarded) {
+      this.log(`  +${event.elapsedMs}ms ${renderPeer(event)}`)
+    }
+
+    if (trace.type === 'block' && trace.forwarded.length === 0) {
+      this.log(
+        `\nThe block was not forwarded. Blocks are only forwarded if they are valid and` +
+          ` extend the chain, and while syncing only if relayWhileSyncing is set.`,
+      )
+    }
+  }
+}
+
+function renderPeer(peer: PropagationPeerResponse): string {
+  return peer.name ? `${peer.name} (${peer.identity})` : peer.identity
+}
@@ -0,0 +1,171 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import {
+  Block,
+  Blockchain,
+  BlockHeader,
+  displayIronAmountWithCurrency,
+  GENESIS_BLOCK_SEQUENCE,
+  oreToIron,
+  Transaction,
+} from '@ironfish/sdk'
+import { CliUx, Flags } from '@oclif/core'
+import { IronfishCommand } from '../../command'
+import { JsonFlag, JsonFlagKey, LocalFlags } from '../../flags'
+import { findBlockHeader } from '../../utils'
+
+export default class ChainTransaction extends IronfishCommand {
+  static description = 'Show a transaction from the local chain'
+
+  static flags = {
+    ...LocalFlags,
+    [JsonFlagKey]: JsonFlag,
+    block: Flags.string({
+      char: 'b',
+      parse: (input: string): Promise<string> => Promise.resolve(input.trim()),
+      description:
+        'the hash or sequence of the block to look in, otherwise the main chain is searched',
+    }),
+  }
+
+  static args = [
+    {
+      name: 'hash',
+      parse: (input: string): Promise<string> => Promise.resolve(input.trim().toLowerCase()),
+      required: true,
+      description: 'the hash of the transaction',
+    },
+  ]
+
+  static examples = [
+    '$ ironfish chain:transaction 9d25b6c74d4c8e1f3a2b5c6d7e8f9a0b1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e6f',
+    '$ ironfish chain:transaction 9d25b6c74d4c8e1f3a2b5c6d7e8f9a0b1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e6f --block 1000',
+  ]
+
+  async start(): Promise<void> {
+    const { args, flags } = await this.parse(ChainTransaction)
+    const hash = args.hash as string
+
+    CliUx.ux.action.start(`Opening node`)
+    const node = await this.sdk.node()
+    await node.openDB()
+    CliUx.ux.action.stop('done.')
+
+    let found: { block: Block; transaction: Transaction } | null = null
+
+    if (flags.block) {
+      const header = await findBlockHeader(node.chain, flags.block)
+      if (!header) {
+        this.error(`No block found matching ${flags.block}`)
+      }
+
+      found = await searchBlock(node.chain, header, hash)
+    } else {
+      CliUx.ux.action.start(`Searching the chain for ${hash}`)
+      found = await searchChain(node.chain, hash)
+      CliUx.ux.action.stop(found ? 'found.' : 'not found.')
+    }
+
+    if (!found) {
+      this.error(`No transaction found with hash ${hash}`)
+    }
+
+    const { block, transaction } = found
+    const main = await node.chain.isHeadChain(block.header)
+
+    const info = {
+      hash: transaction.unsignedHash().toString('hex'),
+      blockHash: block.header.hash.toString('hex'),
+      blockSequence: block.header.sequence,
+      main,
+      fee: transaction.fee(),
+      expirationSequence: transaction.expirationSequence(),
+      minersFee: transaction.isMinersFee(),
+      signature: transaction.transactionSignature().toString('hex'),
+      spends: [...transaction.spends()].map((spend) => ({
+        nullifier: spend.nullifier.toString('hex'),
+        commitment: spend.commitment.toString('hex'),
+        size: spend.size,
+      })),
+      outputs: [...transaction.notes()].map((note) => ({
+        commitment: note.merkleHash().toString('hex'),
+      })),
+    }
+
+    if (this.json) {
+      this.logJson(info)
+      return
+    }
+
+    const fee = displayIronAmountWithCurrency(oreToIron(Number(info.fee)), true)
+    const expiration = info.expirationSequence === 0 ? 'none' : info.expirationSequence
+
+    this.log(`Transaction ${info.hash}`)
+    this.log(`  Block:          ${info.blockSequence} ${info.blockHash}`)
+    this.log(`  Main Chain:     ${main ? 'yes' : 'no'}`)
+    this.log(`  Miner's Fee:    ${info.minersFee ? 'yes' : 'no'}`)
+    this.log(`  Fee:            ${fee}`)
+    this.log(`  Expiration:     ${expiration}`)
+    this.log(`  Signature:      ${info.signature}`)
+    this.log(`  Spends:         ${info.spends.length}`)
+    this.log(`  Outputs:        ${info.outputs.length}`)
+
+    if (info.spends.length) {
+      this.log('')
+      CliUx.ux.table(info.spends, {
+        nullifier: {
+          header: 'Spend Nullifier',
+        },
+        size: {
+          header: 'Tree Size',
+        },
+      })
+    }
+
+    if (info.outputs.length) {
+      this.log('')
+      CliUx.ux.table(info.outputs, {
+        commitment: {
+          header: 'Output Note Commitment',
+        },
+      })
+    }
+  }
+}
+
+async function searchBlock(
+  chain: Blockchain,
+  header: BlockHeader,
+  hash: string,
+): Promise<{ block: Block; transaction: Transaction } | null> {
+  const block = await chain.getBlock(header)
+  if (!block) {
+    return null
+  }
+
+  const transaction = block.transactions.find(
+    (transaction) => transaction.unsignedHash().toString('hex') === hash,
+  )
+
+  return transaction ? { block, transaction } : null
+}
+
+async function searchChain(
+  chain: Blockchain,
+  hash: string,
+): Promise<{ block: Block; transaction: Transaction } | null> {
+  for (let sequence = chain.head.sequence; sequence >= GENESIS_BLOCK_SEQUENCE; sequence--) {
+    const header = await chain.getHeaderAtSequence(sequence)
+    if (!header) {
+      continue
+    }
+
+    const found = await searchBlock(chain, header, hash)
+    if (found) {
+      return found
+    }
+  }
+
+  return null
+}
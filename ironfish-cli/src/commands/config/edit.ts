@@ -8,7 +8,14 @@ import os from 'os'
 import path from 'path'
 import { promisify } from 'util'
 import { IronfishCommand } from '../../command'
-import { ConfigFlag, ConfigFlagKey, DataDirFlag, DataDirFlagKey } from '../../flags'
+import {
+  ConfigFlag,
+  ConfigFlagKey,
+  DataDirFlag,
+  DataDirFlagKey,
+  ProfileFlag,
+  ProfileFlagKey,
+} from '../../flags'
 import { launchEditor } from '../../utils'
 
 const mkdtempAsync = promisify(mkdtemp)
@@ -23,6 +30,7 @@ export class EditCommand extends IronfishCommand {
   static flags = {
     [ConfigFlagKey]: ConfigFlag,
     [DataDirFlagKey]: DataDirFlag,
+    [ProfileFlagKey]: ProfileFlag,
     remote: Flags.boolean({
       default: false,
       description: 'connect to the node when editing the config',
@@ -0,0 +1,40 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { formatConfigIssue } from '@ironfish/sdk'
+import { IronfishCommand } from '../../command'
+import { LocalFlags } from '../../flags'
+
+export class LintCommand extends IronfishCommand {
+  static description = `Check the config file for unknown, mistyped, and deprecated options`
+
+  static flags = {
+    ...LocalFlags,
+  }
+
+  async start(): Promise<void> {
+    await this.parse(LintCommand)
+
+    const configPath = this.sdk.config.storage.configPath
+    const issues = this.sdk.config.issues
+
+    if (!issues.length) {
+      this.log(`No problems found in ${configPath}`)
+      this.exit(0)
+    }
+
+    const problems = issues.length === 1 ? 'problem' : 'problems'
+    this.log(`Found ${issues.length} ${problems} in ${configPath}\n`)
+
+    for (const issue of issues) {
+      this.log(formatConfigIssue(issue))
+    }
+
+    if (issues.some((i) => i.level === 'error')) {
+      this.log('\nThe node will not start until the errors are fixed')
+      this.exit(1)
+    }
+
+    this.exit(0)
+  }
+}
@@ -0,0 +1,36 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { IronfishCommand } from '../../command'
+import { RemoteFlags } from '../../flags'
+
+export class ReloadCommand extends IronfishCommand {
+  static description = `Reload the config file on a running node`
+
+  static flags = {
+    ...RemoteFlags,
+  }
+
+  async start(): Promise<void> {
+    await this.parse(ReloadCommand)
+
+    const client = await this.sdk.connectRpc()
+    const response = await client.reloadConfig()
+    const { applied, restartRequired } = response.content
+
+    if (!applied.length && !restartRequired.length) {
+      this.log('No config options changed')
+      this.exit(0)
+    }
+
+    if (applied.length) {
+      this.log(`Applied: ${applied.join(', ')}`)
+    }
+
+    if (restartRequired.length) {
+      this.log(`Restart the node to apply: ${restartRequired.join(', ')}`)
+    }
+
+    this.exit(0)
+  }
+}
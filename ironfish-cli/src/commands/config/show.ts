@@ -1,6 +1,7 @@
 /* This Source Code Form is subject to the terms of the Mozilla Public
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { Config } from '@ironfish/sdk'
 import { Flags } from '@oclif/core'
 import jsonColorizer from 'json-colorizer'
 import { IronfishCommand } from '../../command'
@@ -15,6 +16,11 @@ export class ShowCommand extends IronfishCommand {
     [ColorFlagKey]: ColorFlag,
     user: Flags.boolean({
       description: 'only show config from the users datadir and not overrides',
+      exclusive: ['defaults'],
+    }),
+    defaults: Flags.boolean({
+      default: false,
+      description: 'show the default value of every option, without connecting to the node',
     }),
     local: Flags.boolean({
       default: false,
@@ -25,10 +31,15 @@ export class ShowCommand extends IronfishCommand {
   async start(): Promise<void> {
     const { flags } = await this.parse(ShowCommand)
 
-    const client = await this.sdk.connectRpc(flags.local)
-    const response = await client.getConfig({ user: flags.user })
+    let content
+    if (flags.defaults) {
+      content = Config.GetDefaults(this.sdk.fileSystem, this.sdk.config.dataDir)
+    } else {
+      const client = await this.sdk.connectRpc(flags.local)
+      content = (await client.getConfig({ user: flags.user })).content
+    }
 
-    let output = JSON.stringify(response.content, undefined, '   ')
+    let output = JSON.stringify(content, undefined, '   ')
     if (flags.color) {
       output = jsonColorizer(output)
     }
@@ -59,7 +59,8 @@ export default class Bank extends IronfishCommand {
     const expirationSequenceDelta = flags.expirationSequenceDelta
 
     const accountName =
-      flags.account || (await this.client.getDefaultAccount()).content.account?.name
+      (await this.getAccountName(flags.account)) ||
+      (await this.client.getDefaultAccount()).content.account?.name
 
     if (!accountName) {
       this.log(
@@ -95,7 +96,7 @@ export default class Bank extends IronfishCommand {
     }
 
     const balanceResp = await this.client.getAccountBalance({ account: accountName })
-    const confirmedBalance = Number(balanceResp.content.confirmed)
+    const confirmedBalance = Number(balanceResp.content.available)
     if (confirmedBalance < ironToOre(IRON_TO_SEND) + fee) {
       const balance = oreToIron(confirmedBalance)
       const required = IRON_TO_SEND + feeInIron
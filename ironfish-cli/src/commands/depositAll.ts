@@ -65,7 +65,8 @@ export default class DepositAll extends IronfishCommand {
     const expirationSequenceDelta = flags.expirationSequenceDelta
 
     const accountName =
-      flags.account || (await this.client.getDefaultAccount()).content.account?.name
+      (await this.getAccountName(flags.account)) ||
+      (await this.client.getDefaultAccount()).content.account?.name
 
     if (!accountName) {
       this.log(
@@ -115,7 +116,7 @@ export default class DepositAll extends IronfishCommand {
     this.log('Fetching account balance...')
 
     let balanceResp = await this.client.getAccountBalance({ account: accountName })
-    let confirmedBalance = Number(balanceResp.content.confirmed)
+    let confirmedBalance = Number(balanceResp.content.available)
     let unconfirmedBalance = Number(balanceResp.content.unconfirmed)
 
     // Console log will create display issues with Blessed
@@ -175,7 +176,7 @@ export default class DepositAll extends IronfishCommand {
     // eslint-disable-next-line no-constant-condition
     while (true) {
       balanceResp = await this.client.getAccountBalance({ account: accountName })
-      confirmedBalance = Number(balanceResp.content.confirmed)
+      confirmedBalance = Number(balanceResp.content.available)
       unconfirmedBalance = Number(balanceResp.content.unconfirmed)
 
       // terminate condition
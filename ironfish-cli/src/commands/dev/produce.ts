@@ -0,0 +1,47 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { Flags } from '@oclif/core'
+import { parseNumber } from '../../args'
+import { IronfishCommand } from '../../command'
+import { ProfileFlag, ProfileFlagKey, RemoteFlags } from '../../flags'
+import { DEV_PROFILE } from './start'
+
+export default class DevProduce extends IronfishCommand {
+  static description = 'Produce blocks on a dev chain started with dev:start'
+
+  static args = [
+    {
+      name: 'count',
+      parse: (input: string): Promise<number | null> => Promise.resolve(parseNumber(input)),
+      required: false,
+      default: 1,
+      description: 'number of blocks to produce',
+    },
+  ]
+
+  static flags = {
+    ...RemoteFlags,
+    [ProfileFlagKey]: { ...ProfileFlag, default: DEV_PROFILE },
+    account: Flags.string({
+      char: 'a',
+      description: 'account to pay the block rewards to, defaults to the default account',
+    }),
+  }
+
+  async start(): Promise<void> {
+    const { args, flags } = await this.parse(DevProduce)
+    const count = args.count as number | null
+
+    if (count === null || count < 1) {
+      this.error('The number of blocks must be a positive number')
+    }
+
+    const client = await this.sdk.connectRpc()
+    const response = await client.produceBlocks({ count, account: flags.account })
+
+    for (const block of response.content.blocks) {
+      this.log(`Produced block ${block.sequence} ${block.hash} (${block.transactions} txs)`)
+    }
+  }
+}
@@ -0,0 +1,56 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { Profiles } from '@ironfish/sdk'
+import { Flags } from '@oclif/core'
+import fsAsync from 'fs/promises'
+import { IronfishCommand } from '../../command'
+import {
+  DataDirFlag,
+  DataDirFlagKey,
+  ProfileFlag,
+  ProfileFlagKey,
+  VerboseFlag,
+  VerboseFlagKey,
+  YesFlag,
+  YesFlagKey,
+} from '../../flags'
+import { DEV_PROFILE } from './start'
+
+export default class DevReset extends IronfishCommand {
+  static description = 'Delete a dev chain and its accounts so dev:start begins from genesis'
+
+  static flags = {
+    [VerboseFlagKey]: VerboseFlag,
+    [DataDirFlagKey]: DataDirFlag,
+    [ProfileFlagKey]: { ...ProfileFlag, default: DEV_PROFILE },
+    [YesFlagKey]: YesFlag,
+    confirm: Flags.boolean({
+      default: false,
+      description: 'confirm without asking',
+    }),
+  }
+
+  async start(): Promise<void> {
+    const { flags } = await this.parse(DevReset)
+
+    // Only ever delete a profile data dir, never the main data dir
+    if (!flags.profile) {
+      this.error('A dev chain profile is required')
+    }
+
+    const dataDir = Profiles.getDataDir(this.sdk.fileSystem, flags.datadir, flags.profile)
+
+    const confirmed =
+      flags.confirm ||
+      (await this.confirm(`Delete the dev chain at ${dataDir}? (y)es / (n)o`))
+
+    if (!confirmed) {
+      this.log('Reset aborted.')
+      this.exit(0)
+    }
+
+    await fsAsync.rm(dataDir, { recursive: true, force: true })
+    this.log(`Deleted the dev chain at ${dataDir}`)
+  }
+}
@@ -0,0 +1,147 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import {
+  Account,
+  DevBlockProducer,
+  GENESIS_BLOCK_SEQUENCE,
+  IronfishNode,
+  NodeUtils,
+  PromiseUtils,
+} from '@ironfish/sdk'
+import { CliUx, Flags } from '@oclif/core'
+import { IronfishCommand, SIGNALS } from '../../command'
+import {
+  DataDirFlag,
+  DataDirFlagKey,
+  ProfileFlag,
+  ProfileFlagKey,
+  VerboseFlag,
+  VerboseFlagKey,
+} from '../../flags'
+
+export const DEV_PROFILE = 'dev'
+
+export default class DevStart extends IronfishCommand {
+  static description = 'Start an isolated single node development chain'
+
+  static flags = {
+    [VerboseFlagKey]: VerboseFlag,
+    [DataDirFlagKey]: DataDirFlag,
+    [ProfileFlagKey]: { ...ProfileFlag, default: DEV_PROFILE },
+    interval: Flags.integer({
+      default: 0,
+      description: 'seconds between produced blocks, 0 only produces blocks with dev:produce',
+    }),
+    accounts: Flags.integer({
+      default: 3,
+      description: 'number of dev accounts to create',
+    }),
+    fund: Flags.integer({
+      default: 2,
+      description: 'number of blocks to produce for each dev account on a new chain',
+    }),
+  }
+
+  static examples = [
+    '$ ironfish dev:start',
+    '$ ironfish dev:start --interval 5',
+    '$ ironfish accounts:balance --profile dev',
+  ]
+
+  node: IronfishNode | null = null
+  startDonePromise: Promise<void> | null = null
+
+  async start(): Promise<void> {
+    const [startDonePromise, startDoneResolve] = PromiseUtils.split<void>()
+    this.startDonePromise = startDonePromise
+
+    const { flags } = await this.parse(DevStart)
+
+    // Keep the dev chain away from every other node
+    const config = this.sdk.config
+    config.setOverride('network', 'devnet')
+    config.setOverride('bootstrapNodes', [])
+    config.setOverride('enableListenP2P', false)
+    config.setOverride('enableSyncing', false)
+    config.setOverride('enableTelemetry', false)
+    config.setOverride('minPeers', 0)
+
+    const node = await this.sdk.node()
+    await NodeUtils.waitForOpen(node, () => this.closing)
+
+    if (this.closing) {
+      return startDoneResolve()
+    }
+
+    // Blocks are produced without any proof of work
+    node.chain.verifier.enableVerifyTarget = false
+
+    const accounts = await this.createAccounts(node, flags.accounts)
+
+    const producer = new DevBlockProducer({
+      node,
+      logger: this.logger,
+      interval: flags.interval * 1000,
+    })
+    node.devBlockProducer = producer
+
+    await node.start()
+    this.node = node
+
+    if (node.chain.head.sequence === GENESIS_BLOCK_SEQUENCE && flags.fund > 0) {
+      for (const account of accounts) {
+        await producer.produceBlocks(flags.fund, account)
+      }
+    }
+
+    producer.start()
+
+    this.log(`\nDev chain running in ${config.dataDir}`)
+    this.log(`Use --profile ${flags.profile} to run other commands against it\n`)
+
+    CliUx.ux.table(accounts, {
+      name: {
+        header: 'Account',
+      },
+      publicAddress: {
+        header: 'Public Address',
+      },
+    })
+
+    this.log('')
+    this.log(
+      flags.interval > 0
+        ? `Producing a block every ${flags.interval} seconds`
+        : `Produce blocks with ironfish dev:produce`,
+    )
+
+    startDoneResolve()
+    this.listenForSignals()
+    await node.waitForShutdown()
+  }
+
+  async createAccounts(node: IronfishNode, count: number): Promise<Account[]> {
+    const accounts = []
+
+    for (let i = 1; i <= count; i++) {
+      const name = `dev-${i}`
+
+      let account = node.accounts.getAccountByName(name)
+      if (!account) {
+        account = await node.accounts.createAccount(name, !node.accounts.getDefaultAccount())
+      }
+
+      accounts.push(account)
+    }
+
+    return accounts
+  }
+
+  async closeFromSignal(signal: SIGNALS): Promise<void> {
+    this.log(`Shutting down dev chain after ${signal}`)
+    await this.startDonePromise
+    await this.node?.shutdown()
+    await this.node?.closeDB()
+  }
+}
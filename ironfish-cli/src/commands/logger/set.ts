@@ -0,0 +1,44 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { IronfishCommand } from '../../command'
+import { RemoteFlags } from '../../flags'
+
+export class SetCommand extends IronfishCommand {
+  static description = `Change the log level of a module on the running node`
+
+  static args = [
+    {
+      name: 'module',
+      parse: (input: string): Promise<string> => Promise.resolve(input.trim()),
+      required: true,
+      description: 'tag of the module to change, or * for the default level',
+    },
+    {
+      name: 'level',
+      parse: (input: string): Promise<string> => Promise.resolve(input.trim()),
+      required: true,
+      description: 'log level to set, such as debug, info, or warn',
+    },
+  ]
+
+  static flags = {
+    ...RemoteFlags,
+  }
+
+  static examples = [
+    '$ ironfish logger:set syncer debug',
+    '$ ironfish logger:set peernetwork warn',
+  ]
+
+  async start(): Promise<void> {
+    const { args } = await this.parse(SetCommand)
+    const module = args.module as string
+    const level = args.level as string
+
+    await this.sdk.client.connect()
+    const response = await this.sdk.client.setLogLevel({ module, level })
+
+    this.log(`Log level for ${response.content.module} is now ${response.content.level}`)
+  }
+}
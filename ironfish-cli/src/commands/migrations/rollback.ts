@@ -0,0 +1,95 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { DatabaseIsLockedError, MigrationDatabase, Migrator } from '@ironfish/sdk'
+import { Flags } from '@oclif/core'
+import { IronfishCommand } from '../../command'
+import { LocalFlags, YesFlag, YesFlagKey } from '../../flags'
+
+export default class MigrationsRollback extends IronfishCommand {
+  static description = `Undo the last migration of the chain and wallet databases
+
+  Migrations that can't be reversed have to be undone by restoring the backup
+  in backups/migrations in the data dir instead.`
+
+  static flags = {
+    ...LocalFlags,
+    [YesFlagKey]: YesFlag,
+    'dry-run': Flags.boolean({
+      default: false,
+      description: 'roll back without saving the changes',
+    }),
+    backup: Flags.boolean({
+      default: true,
+      allowNo: true,
+      description: 'copy each database before rolling it back',
+    }),
+    database: Flags.string({
+      options: Object.values(MigrationDatabase),
+      required: false,
+      description: 'only roll back this database',
+    }),
+  }
+
+  async start(): Promise<void> {
+    const { flags } = await this.parse(MigrationsRollback)
+    const dryRun = flags['dry-run']
+
+    const node = await this.sdk.node({ autoSeed: false })
+    try {
+      await node.openDB({ upgrade: false, load: false })
+    } catch (e: unknown) {
+      if (e instanceof DatabaseIsLockedError) {
+        this.log('The node is running, stop it before rolling back its databases')
+        this.exit(1)
+      }
+
+      throw e
+    }
+
+    if (!dryRun) {
+      const confirmed = await this.confirm(
+        `Databases that are rolled back can only be opened by the version of ironfish` +
+          ` before the migration.\nAre you sure? (Y)es / (N)o`,
+      )
+
+      if (!confirmed) {
+        this.log('Rollback aborted.')
+        await node.closeDB()
+        this.exit(0)
+      }
+    }
+
+    const migrator = new Migrator({ node, logger: this.logger })
+
+    let results
+    try {
+      results = await migrator.rollback({
+        dryRun,
+        backup: flags.backup,
+        database: flags.database as MigrationDatabase | undefined,
+      })
+    } catch (e: unknown) {
+      await node.closeDB()
+      this.error(e instanceof Error ? e.message : String(e))
+    }
+
+    if (results.length === 0) {
+      this.log('There are no migrations to roll back')
+    }
+
+    for (const result of results) {
+      const [migration] = result.migrations
+      this.log(
+        `${dryRun ? 'Dry run of rolling back' : 'Rolled back'} ${migration.name},` +
+          ` the ${result.database} database is at version ${result.to}`,
+      )
+
+      if (result.backup) {
+        this.log(`  Backed up to ${result.backup}`)
+      }
+    }
+
+    await node.closeDB()
+  }
+}
@@ -0,0 +1,87 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { DatabaseIsLockedError, Migrator } from '@ironfish/sdk'
+import { Flags } from '@oclif/core'
+import { IronfishCommand } from '../../command'
+import { LocalFlags, YesFlag, YesFlagKey } from '../../flags'
+
+export default class MigrationsRun extends IronfishCommand {
+  static description = `Run the pending migrations of the chain and wallet databases
+
+  Each database is copied to backups/migrations in the data dir before it is
+  migrated, and each migration runs in a transaction so a failed migration
+  leaves the database at the version before it.`
+
+  static flags = {
+    ...LocalFlags,
+    [YesFlagKey]: YesFlag,
+    'dry-run': Flags.boolean({
+      default: false,
+      description: 'run the migrations without saving their changes',
+    }),
+    backup: Flags.boolean({
+      default: true,
+      allowNo: true,
+      description: 'copy each database before migrating it',
+    }),
+  }
+
+  async start(): Promise<void> {
+    const { flags } = await this.parse(MigrationsRun)
+    const dryRun = flags['dry-run']
+
+    const node = await this.sdk.node({ autoSeed: false })
+    try {
+      await node.openDB({ upgrade: false, load: false })
+    } catch (e: unknown) {
+      if (e instanceof DatabaseIsLockedError) {
+        this.log('The node is running, stop it before migrating its databases')
+        this.exit(1)
+      }
+
+      throw e
+    }
+
+    const migrator = new Migrator({ node, logger: this.logger })
+
+    const pending = (await migrator.status()).flatMap((s) => s.pending)
+    if (pending.length === 0) {
+      this.log('The databases are up to date')
+      await node.closeDB()
+      return
+    }
+
+    if (!dryRun && !flags.backup) {
+      const confirmed = await this.confirm(
+        `Run ${pending.length} migrations without a backup? Irreversible migrations` +
+          ` can't be undone.\nAre you sure? (Y)es / (N)o`,
+      )
+
+      if (!confirmed) {
+        this.log('Migration aborted.')
+        await node.closeDB()
+        this.exit(0)
+      }
+    }
+
+    const results = await migrator.migrate({ dryRun, backup: flags.backup })
+
+    for (const result of results) {
+      if (dryRun) {
+        this.log(
+          `${result.migrations.length} migrations of the ${result.database} database` +
+            ` ran without errors, no changes were saved`,
+        )
+      } else {
+        this.log(`Migrated the ${result.database} database from ${result.from} to ${result.to}`)
+      }
+
+      if (result.backup) {
+        this.log(`  Backed up to ${result.backup}`)
+      }
+    }
+
+    await node.closeDB()
+  }
+}
@@ -0,0 +1,57 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { DatabaseIsLockedError, Migrator } from '@ironfish/sdk'
+import { IronfishCommand } from '../../command'
+import { LocalFlags } from '../../flags'
+
+export default class MigrationsStatus extends IronfishCommand {
+  static description = 'Show the schema version and pending migrations of each database'
+
+  static flags = {
+    ...LocalFlags,
+  }
+
+  async start(): Promise<void> {
+    await this.parse(MigrationsStatus)
+
+    const node = await this.sdk.node({ autoSeed: false })
+    try {
+      await node.openDB({ upgrade: false, load: false })
+    } catch (e: unknown) {
+      if (e instanceof DatabaseIsLockedError) {
+        this.log('The node is running, stop it to check its migrations')
+        this.exit(1)
+      }
+
+      throw e
+    }
+
+    const migrator = new Migrator({ node, logger: this.logger })
+
+    for (const status of await migrator.status()) {
+      this.log(`${status.database} database at ${status.location}`)
+
+      if (status.version === null) {
+        this.log(`  Not created yet, it will be created at version ${status.latest}`)
+      } else if (status.version > status.latest) {
+        this.log(`  Version ${status.version} is newer than this version of ironfish`)
+      } else if (status.pending.length === 0 && status.version < status.latest) {
+        this.log(`  Version ${status.version} is too old to migrate to ${status.latest}`)
+      } else {
+        this.log(`  Version ${status.version} of ${status.latest}`)
+      }
+
+      for (const migration of status.applied) {
+        this.log(`  [x] ${migration.version} ${migration.name}`)
+      }
+
+      for (const migration of status.pending) {
+        const reversible = migration.reversible ? '' : ' (can only be undone from a backup)'
+        this.log(`  [ ] ${migration.version} ${migration.name}${reversible}`)
+      }
+    }
+
+    await node.closeDB()
+  }
+}
@@ -0,0 +1,36 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { PoolDatabase } from '@ironfish/sdk'
+import { Flags } from '@oclif/core'
+import { IronfishCommand } from '../../../command'
+import { LocalFlags } from '../../../flags'
+
+export class PoolMigrations extends IronfishCommand {
+  static description = `Show or revert the migrations of the mining pool database`
+
+  static flags = {
+    ...LocalFlags,
+    revert: Flags.boolean({
+      default: false,
+      description: 'undo the most recent migration, to run an older pool version',
+    }),
+  }
+
+  async start(): Promise<void> {
+    const { flags } = await this.parse(PoolMigrations)
+
+    const db = await PoolDatabase.init({ config: this.sdk.config, logger: this.logger })
+
+    if (flags.revert) {
+      const reverted = await db.migrations.revert()
+      this.log(reverted ? `Reverted ${reverted.name}` : 'No migrations to revert')
+    }
+
+    for (const migration of await db.migrations.status()) {
+      this.log(`${migration.applied ? 'APPLIED' : 'PENDING'}  ${migration.name}`)
+    }
+
+    await db.stop()
+  }
+}
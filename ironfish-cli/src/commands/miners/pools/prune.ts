@@ -0,0 +1,52 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { PoolDatabase } from '@ironfish/sdk'
+import { Flags } from '@oclif/core'
+import { IronfishCommand } from '../../../command'
+import { LocalFlags } from '../../../flags'
+
+export class PrunePool extends IronfishCommand {
+  static description = `Delete old paid shares and payouts from the mining pool database`
+
+  static flags = {
+    ...LocalFlags,
+    days: Flags.integer({
+      description: 'delete paid shares older than this many days, defaults to the config',
+    }),
+    vacuum: Flags.boolean({
+      default: false,
+      description: 'shrink the database file after pruning, the pool should be stopped',
+    }),
+  }
+
+  static examples = ['$ ironfish miners:pools:prune --days 7 --vacuum']
+
+  async start(): Promise<void> {
+    const { flags } = await this.parse(PrunePool)
+
+    const retention =
+      flags.days !== undefined
+        ? flags.days * 24 * 60 * 60
+        : this.sdk.config.get('poolShareRetention')
+
+    if (retention <= 0) {
+      this.log('poolShareRetention is 0, paid shares are kept forever')
+      this.exit(0)
+    }
+
+    const db = await PoolDatabase.init({ config: this.sdk.config, logger: this.logger })
+    await db.start()
+
+    const cutoff = Math.floor(Date.now() / 1000) - retention
+    const pruned = await db.prune(cutoff)
+    this.log(`Deleted ${pruned.shares} paid shares and ${pruned.payouts} payouts`)
+
+    if (flags.vacuum) {
+      this.log('Vacuuming the database...')
+      await db.vacuum()
+    }
+
+    await db.stop()
+  }
+}
@@ -15,11 +15,13 @@ import { Flags } from '@oclif/core'
 import blessed from 'blessed'
 import dns from 'dns'
 import { IronfishCommand } from '../../../command'
+import { JsonFlag, JsonFlagKey } from '../../../flags'
 
 export class PoolStatus extends IronfishCommand {
   static description = `Show the status of a mining pool`
 
   static flags = {
+    [JsonFlagKey]: JsonFlag,
     address: Flags.string({
       char: 'a',
       description: 'a public address for which to retrieve pool share data',
@@ -65,7 +67,13 @@ export class PoolStatus extends IronfishCommand {
 
     if (!flags.follow) {
       stratum.onConnected.on(() => stratum.getStatus(flags.address))
-      stratum.onStatus.on((status) => this.log(this.renderStatus(status)))
+      stratum.onStatus.on((status) => {
+        if (this.json) {
+          this.logJson(status)
+        } else {
+          this.log(this.renderStatus(status))
+        }
+      })
       stratum.start()
       await waitForEmit(stratum.onStatus)
       this.exit(0)
@@ -107,6 +115,22 @@ export class PoolStatus extends IronfishCommand {
     result += `Clients:               ${status.clients}\n`
     result += `Bans:                  ${status.bans}\n`
 
+    if (status.shares) {
+      result += `Shares:                ${renderShares(status.shares)}\n`
+    }
+
+    if (status.fees) {
+      const { poolFeePercent, feeAddress } = status.fees
+      result += feeAddress
+        ? `Pool fee:              ${poolFeePercent}% to ${feeAddress}\n`
+        : `Pool fee:              ${poolFeePercent}%\n`
+
+      if (status.fees.donationAddress) {
+        const { donationPercent, donationAddress } = status.fees
+        result += `Donation:              ${donationPercent}% to ${donationAddress}\n`
+      }
+    }
+
     if (status.addressStatus) {
       result += `\nMining status for address '${status.addressStatus.publicAddress}':\n`
       result += `Number of miners:      ${status.addressStatus.miners}\n`
@@ -115,7 +139,18 @@ export class PoolStatus extends IronfishCommand {
         status.addressStatus.hashRate,
       )}\n`
       result += `Shares pending payout: ${status.addressStatus.sharesPending}`
+
+      if (status.addressStatus.shares) {
+        result += `\nShares:                ${renderShares(status.addressStatus.shares)}`
+      }
     }
     return result
   }
 }
+
+function renderShares(shares: NonNullable<MiningStatusMessage['shares']>): string {
+  return (
+    `${shares.accepted} accepted, ${shares.stale} stale, ` +
+    `${shares.duplicate} duplicate, ${shares.invalid} invalid`
+  )
+}
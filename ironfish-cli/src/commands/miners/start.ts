@@ -2,6 +2,7 @@
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 import {
+  ErrorUtils,
   FileUtils,
   GraffitiUtils,
   isValidPublicAddress,
@@ -15,6 +16,7 @@ import dns from 'dns'
 import os from 'os'
 import { IronfishCommand } from '../../command'
 import { RemoteFlags } from '../../flags'
+import { parseCpuList, setCpuAffinity } from '../../utils/affinity'
 
 export class Miner extends IronfishCommand {
   static description = `Start a miner and subscribe to new blocks for the node`
@@ -41,6 +43,13 @@ export class Miner extends IronfishCommand {
       char: 'a',
       description: 'the public address to receive pool payouts',
     }),
+    affinity: Flags.string({
+      description: 'pin the mining threads to a list of CPUs such as 0,2-3 (Linux only)',
+    }),
+    'max-cpu': Flags.integer({
+      default: 100,
+      description: 'the percent of the time each thread may spend mining, from 1 to 100',
+    }),
     richOutput: Flags.boolean({
       default: true,
       allowNo: true,
@@ -55,6 +64,28 @@ export class Miner extends IronfishCommand {
       throw new Error('--threads must be a positive integer or -1.')
     }
 
+    const maxCpu = flags['max-cpu']
+    if (maxCpu < 1 || maxCpu > 100) {
+      this.error('--max-cpu must be between 1 and 100.')
+    }
+
+    if (flags.affinity) {
+      let cpus: number[]
+      try {
+        cpus = parseCpuList(flags.affinity)
+        await setCpuAffinity(flags.affinity)
+      } catch (e: unknown) {
+        this.error(ErrorUtils.renderError(e))
+      }
+
+      // Don't start more threads than the CPUs they can run on
+      if (flags.threads === -1) {
+        flags.threads = cpus.length
+      }
+
+      this.log(`Pinned mining threads to CPUs ${flags.affinity}`)
+    }
+
     if (flags.threads === -1) {
       flags.threads = os.cpus().length
     }
@@ -96,6 +127,7 @@ export class Miner extends IronfishCommand {
 
       const miner = new MiningPoolMiner({
         threadCount: flags.threads,
+        maxCpu,
         publicAddress: flags.address,
         logger: this.logger,
         batchSize,
@@ -124,6 +156,7 @@ export class Miner extends IronfishCommand {
 
       const miner = new MiningSoloMiner({
         threadCount: flags.threads,
+        maxCpu,
         graffiti: GraffitiUtils.fromString(graffiti),
         logger: this.logger,
         batchSize,
@@ -149,7 +182,13 @@ export class Miner extends IronfishCommand {
 
     const updateHashPower = () => {
       const rate = Math.max(0, Math.floor(miner.hashRate.rate5s))
-      const formatted = `${FileUtils.formatHashRate(rate)}/s (${rate})`
+      let formatted = `${FileUtils.formatHashRate(rate)}/s (${rate})`
+
+      if (miner instanceof MiningPoolMiner) {
+        const { submitted, stale } = miner.stratum.shareStats
+        formatted += `, shares ${submitted} submitted, ${stale} stale`
+      }
+
       CliUx.ux.action.status = formatted
     }
 
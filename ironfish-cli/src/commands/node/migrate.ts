@@ -0,0 +1,181 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { DatabaseIsLockedError, ErrorUtils, FileUtils } from '@ironfish/sdk'
+import { CliUx, Flags } from '@oclif/core'
+import fsAsync from 'fs/promises'
+import path from 'path'
+import { IronfishCommand } from '../../command'
+import {
+  ConfigFlag,
+  ConfigFlagKey,
+  DataDirFlag,
+  DataDirFlagKey,
+  ProfileFlag,
+  ProfileFlagKey,
+  VerboseFlag,
+  VerboseFlagKey,
+  YesFlag,
+  YesFlagKey,
+} from '../../flags'
+import { ProgressBar } from '../../types'
+import { copyFileVerified, isRemoteTarget, listDataDirFiles, runCommand } from '../../utils'
+
+export default class Migrate extends IronfishCommand {
+  static description = `Copy or move the data dir to another disk or machine
+
+Every file is verified after it's copied. Run the same command again to resume
+an interrupted migration, files that were already copied are skipped.
+
+Remote targets like user@host:/path are copied with rsync over ssh, which must
+be installed on both machines.`
+
+  static flags = {
+    [VerboseFlagKey]: VerboseFlag,
+    [ConfigFlagKey]: ConfigFlag,
+    [DataDirFlagKey]: DataDirFlag,
+    [ProfileFlagKey]: ProfileFlag,
+    [YesFlagKey]: YesFlag,
+    to: Flags.string({
+      required: true,
+      description: 'the path or user@host:/path to copy the data dir to',
+    }),
+    move: Flags.boolean({
+      default: false,
+      description: 'delete the data dir after it has been copied and verified',
+    }),
+    chain: Flags.boolean({
+      default: true,
+      allowNo: true,
+      description: 'copy the chain database, or leave it out and sync again at the target',
+    }),
+  }
+
+  static examples = [
+    '$ ironfish node:migrate --to /mnt/bigdisk/.ironfish',
+    '$ ironfish node:migrate --to /mnt/bigdisk/.ironfish --move',
+    '$ ironfish node:migrate --to user@host:~/.ironfish --no-chain',
+  ]
+
+  async start(): Promise<void> {
+    const { flags } = await this.parse(Migrate)
+
+    const source = this.sdk.fileSystem.resolve(this.sdk.dataDir)
+    const remote = isRemoteTarget(flags.to)
+    const target = remote ? flags.to : this.sdk.fileSystem.resolve(flags.to)
+
+    if (!remote && (target === source || target.startsWith(source + path.sep))) {
+      this.log(`Cannot migrate the data dir ${source} into itself`)
+      this.exit(1)
+    }
+
+    // Copying a database the node is writing to would copy it corrupt. Opening
+    // it only checks the lock, so it is copied without being upgraded
+    const node = await this.sdk.node()
+    try {
+      await node.openDB({ upgrade: false, load: false })
+      await node.closeDB()
+    } catch (e: unknown) {
+      if (e instanceof DatabaseIsLockedError) {
+        this.log('The node is running, stop it before migrating the data dir')
+        this.exit(1)
+      }
+
+      throw e
+    }
+
+    const exclude = flags.chain
+      ? []
+      : [
+          path.relative(source, node.config.chainDatabasePath),
+          path.relative(source, node.config.indexDatabasePath),
+        ]
+
+    const files = await listDataDirFiles(source, exclude)
+    const size = files.reduce((total, file) => total + file.size, 0)
+
+    this.log(`From    ${source}`)
+    this.log(`To      ${target}`)
+    this.log(`Files   ${files.length} (${FileUtils.formatFileSize(size)})`)
+    this.log(`Chain   ${flags.chain ? 'copied' : 'left out, the node will sync again'}`)
+    this.log(`Source  ${flags.move ? 'deleted after verifying' : 'kept'}`)
+
+    if (!(await this.confirm('\nContinue? (Y/N)'))) {
+      this.exit(0)
+    }
+
+    try {
+      if (remote) {
+        await this.migrateRemote(source, target, exclude)
+      } else {
+        await this.migrateLocal(source, target, files.map((f) => f.path))
+      }
+    } catch (e: unknown) {
+      this.log(`\nMigration failed: ${ErrorUtils.renderError(e)}`)
+      this.log('Run the same command again to resume it')
+      this.exit(1)
+    }
+
+    if (flags.move) {
+      CliUx.ux.action.start(`Deleting ${source}`)
+      await fsAsync.rm(source, { recursive: true, force: true })
+      CliUx.ux.action.stop('done')
+    }
+
+    this.log(`\nMigration complete, start the node with --datadir ${target}`)
+  }
+
+  async migrateLocal(source: string, target: string, files: string[]): Promise<void> {
+    const progress = CliUx.ux.progress({
+      format: 'Copying files: [{bar}] {value}/{total} {percentage}% | {skipped} already copied',
+    }) as ProgressBar
+
+    let skipped = 0
+    progress.start(files.length, 0, { skipped })
+
+    try {
+      for (const file of files) {
+        const copied = await copyFileVerified(path.join(source, file), path.join(target, file))
+
+        if (!copied) {
+          skipped++
+        }
+
+        progress.increment({ skipped })
+      }
+    } finally {
+      progress.stop()
+    }
+  }
+
+  async migrateRemote(source: string, target: string, exclude: string[]): Promise<void> {
+    const options = ['-a', '--partial', '--checksum', '-e', 'ssh']
+    const excludes = exclude.map((e) => `--exclude=/${e.split(path.sep).join('/')}`)
+    const args = [...options, ...excludes, `${source}/`, `${target}/`]
+
+    CliUx.ux.action.start('Copying files with rsync')
+
+    // --partial keeps partly sent files so running again resumes them
+    const copy = await runCommand('rsync', args).catch((e: NodeJS.ErrnoException) => {
+      throw e.code === 'ENOENT' ? new Error('rsync is not installed') : e
+    })
+
+    if (copy.code !== 0) {
+      CliUx.ux.action.stop('failed')
+      throw new Error(`rsync failed with code ${copy.code}: ${copy.stderr.trim()}`)
+    }
+
+    CliUx.ux.action.start('Verifying files')
+
+    // A dry run that would change nothing means every file matches by checksum
+    const verify = await runCommand('rsync', ['--dry-run', '--itemize-changes', ...args])
+    const changes = verify.stdout.trim()
+
+    if (verify.code !== 0 || changes) {
+      CliUx.ux.action.stop('failed')
+      throw new Error(`Files at ${target} do not match:\n${changes || verify.stderr.trim()}`)
+    }
+
+    CliUx.ux.action.stop('done')
+  }
+}
@@ -0,0 +1,104 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { FileUtils, MathUtils, MetricsHistorySample, TimeUtils } from '@ironfish/sdk'
+import { Flags } from '@oclif/core'
+import { IronfishCommand } from '../../command'
+import { RemoteFlags } from '../../flags'
+import { percentile, renderSparkline } from '../../utils'
+
+type StatDefinition = {
+  name: string
+  value: (sample: MetricsHistorySample) => number
+  format: (value: number) => string
+}
+
+const STATS: StatDefinition[] = [
+  {
+    name: 'Block Add Time',
+    value: (s) => s.blockAddTime,
+    format: (v) => `${MathUtils.round(v, 2)} ms`,
+  },
+  {
+    name: 'Peers',
+    value: (s) => s.peers,
+    format: (v) => `${v}`,
+  },
+  {
+    name: 'Mem Pool',
+    value: (s) => s.memPoolSize,
+    format: (v) => `${v} tx`,
+  },
+  {
+    name: 'Heap Used',
+    value: (s) => s.heapUsed,
+    format: (v) => FileUtils.formatMemorySize(v),
+  },
+  {
+    name: 'Pool Hashrate',
+    value: (s) => s.hashRate,
+    format: (v) => FileUtils.formatHashRate(v),
+  },
+]
+
+export class StatsCommand extends IronfishCommand {
+  static description = `Show the recent history of node performance metrics`
+
+  static flags = {
+    ...RemoteFlags,
+    since: Flags.string({
+      default: '24h',
+      description: 'how far back to show metrics, such as 30m, 24h, or 7d',
+    }),
+    width: Flags.integer({
+      default: 60,
+      description: 'the width of the graphs in characters',
+    }),
+  }
+
+  static examples = ['$ ironfish node:stats', '$ ironfish node:stats --since 7d']
+
+  async start(): Promise<void> {
+    const { flags } = await this.parse(StatsCommand)
+
+    let since: number
+    try {
+      since = Date.now() - TimeUtils.parseDuration(flags.since)
+    } catch (e: unknown) {
+      this.error(e instanceof Error ? e.message : String(e))
+    }
+
+    await this.sdk.client.connect()
+    const response = await this.sdk.client.getMetricsHistory({ since })
+    const { enabled, samples } = response.content
+
+    if (!enabled) {
+      this.log('Metrics history is disabled on this node. You can enable it with')
+      this.log('  ironfish config:set enableMetricsHistory true\n')
+    }
+
+    if (samples.length === 0) {
+      this.log(`No metrics have been recorded in the last ${flags.since}`)
+      this.exit(0)
+    }
+
+    const first = new Date(samples[0].timestamp).toLocaleString()
+    const last = new Date(samples[samples.length - 1].timestamp).toLocaleString()
+    this.log(`${samples.length} samples from ${first} to ${last}\n`)
+
+    for (const stat of STATS) {
+      const values = samples.map(stat.value)
+
+      this.log(stat.name)
+      this.log(`  ${renderSparkline(values, flags.width)}`)
+      this.log(
+        `  min ${stat.format(Math.min(...values))}` +
+          `, p50 ${stat.format(percentile(values, 50))}` +
+          `, p90 ${stat.format(percentile(values, 90))}` +
+          `, p99 ${stat.format(percentile(values, 99))}` +
+          `, max ${stat.format(Math.max(...values))}` +
+          `, now ${stat.format(values[values.length - 1])}\n`,
+      )
+    }
+  }
+}
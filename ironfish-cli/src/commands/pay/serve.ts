@@ -0,0 +1,107 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { InvoiceStore, PaymentProcessor, PaymentServer } from '@ironfish/sdk'
+import { Flags } from '@oclif/core'
+import { IronfishCommand } from '../../command'
+import { RemoteFlags } from '../../flags'
+
+export default class PayServeCommand extends IronfishCommand {
+  static description = `Take payments for invoices and call back merchants when they are paid
+
+Merchants create invoices over HTTP, and each invoice has a memo that payments
+to the account must be sent with. When payments with the memo reach the number
+of confirmations, the invoice's callback URL is sent the paid invoice. Set
+--callbackSecret to sign callbacks with an HMAC-SHA256 of the body in the
+X-Ironfish-Signature header.`
+
+  static flags = {
+    ...RemoteFlags,
+    account: Flags.string({
+      char: 'f',
+      description: 'the account to receive payments to, defaults to the default account',
+    }),
+    confirmations: Flags.integer({
+      description: 'blocks needed on top of a payment, defaults to minimumBlockConfirmations',
+    }),
+    host: Flags.string({
+      default: 'localhost',
+      description: 'the host to listen for merchants on',
+    }),
+    port: Flags.integer({
+      default: 8022,
+      description: 'the port to listen for merchants on',
+    }),
+    apiKey: Flags.string({
+      description: 'require merchants to send this key in an Authorization: Bearer header',
+    }),
+    callbackSecret: Flags.string({
+      description: 'the secret to sign callbacks with',
+    }),
+    expires: Flags.integer({
+      default: 60,
+      description: 'minutes until unpaid invoices expire, 0 to never expire them',
+    }),
+  }
+
+  server: PaymentServer | null = null
+  processor: PaymentProcessor | null = null
+
+  async start(): Promise<void> {
+    const { flags } = await this.parse(PayServeCommand)
+
+    const client = await this.sdk.connectRpc(false, true)
+
+    let account = await this.getAccountName(flags.account)
+    if (!account) {
+      const response = await client.getDefaultAccount()
+      if (!response.content.account) {
+        this.log('There is no default account, use --account to choose one')
+        this.exit(1)
+      }
+      account = response.content.account.name
+    }
+
+    const { publicKey } = (await client.getAccountPublicKey({ account })).content
+    const confirmations =
+      flags.confirmations ?? this.sdk.config.get('minimumBlockConfirmations')
+
+    const invoices = new InvoiceStore(this.sdk.fileSystem, this.sdk.config.dataDir)
+    await invoices.load()
+
+    const server = new PaymentServer({
+      invoices,
+      publicAddress: publicKey,
+      host: flags.host,
+      port: flags.port,
+      apiKey: flags.apiKey,
+      expiresInMinutes: flags.expires,
+      logger: this.logger,
+    })
+
+    const processor = new PaymentProcessor({
+      rpc: this.sdk.client,
+      invoices,
+      account,
+      confirmations,
+      callbackSecret: flags.callbackSecret,
+      logger: this.logger,
+    })
+
+    this.server = server
+    this.processor = processor
+
+    await server.start()
+    processor.start()
+
+    this.log(`Taking payments to ${account} with ${confirmations} confirmations`)
+    this.log(`Listening for merchants on http://${flags.host}:${server.port}/invoices`)
+
+    await new Promise<void>((resolve) => server.server?.once('close', resolve))
+  }
+
+  async closeFromSignal(): Promise<void> {
+    await this.server?.stop()
+    await this.processor?.stop()
+  }
+}
@@ -1,11 +1,11 @@
 /* This Source Code Form is subject to the terms of the Mozilla Public
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
-import { GetPeersResponse, PromiseUtils } from '@ironfish/sdk'
+import { GetPeersResponse, NEARBY_LATENCY_MS, PromiseUtils } from '@ironfish/sdk'
 import { CliUx, Flags } from '@oclif/core'
 import blessed from 'blessed'
 import { IronfishCommand } from '../../command'
-import { RemoteFlags } from '../../flags'
+import { JsonFlag, JsonFlagKey, RemoteFlags } from '../../flags'
 
 type GetPeerResponsePeer = GetPeersResponse['peers'][0]
 
@@ -16,6 +16,7 @@ export class ListCommand extends IronfishCommand {
 
   static flags = {
     ...RemoteFlags,
+    [JsonFlagKey]: JsonFlag,
     follow: Flags.boolean({
       char: 'f',
       default: false,
@@ -59,7 +60,14 @@ export class ListCommand extends IronfishCommand {
     if (!flags.follow) {
       await this.sdk.client.connect()
       const response = await this.sdk.client.getPeers()
-      this.log(renderTable(response.content, flags))
+
+      if (this.json) {
+        const peers = response.content.peers
+        this.logJson(flags.all ? peers : peers.filter((p) => p.state === 'CONNECTED'))
+      } else {
+        this.log(renderTable(response.content, flags))
+      }
+
       this.exit(0)
     }
 
@@ -165,6 +173,13 @@ function renderTable(
         return address
       },
     },
+    latency: {
+      header: 'LATENCY',
+      minWidth: 7,
+      get: (row: GetPeerResponsePeer) => {
+        return row.latency !== null ? `${row.latency}ms` : '-'
+      },
+    },
     connectionWebSocket: {
       header: 'SOCKET',
       minWidth: 4,
@@ -205,5 +220,26 @@ function renderTable(
     sort: flags.sort,
   })
 
+  result += renderLatency(peers)
+
   return result
 }
+
+/**
+ * Summarize the latency distribution of the peers, and how many are nearby
+ */
+function renderLatency(peers: GetPeerResponsePeer[]): string {
+  const latencies = peers.flatMap((p) => (p.latency !== null ? p.latency : []))
+  if (!latencies.length) {
+    return ''
+  }
+
+  latencies.sort((a, b) => a - b)
+  const percentile = (p: number) => latencies[Math.ceil((p / 100) * latencies.length) - 1]
+  const nearby = latencies.filter((l) => l <= NEARBY_LATENCY_MS).length
+
+  return (
+    `\nLatency: min ${latencies[0]}ms, p50 ${percentile(50)}ms, p90 ${percentile(90)}ms,` +
+    ` max ${latencies[latencies.length - 1]}ms, ${nearby}/${latencies.length} peers nearby\n`
+  )
+}
@@ -0,0 +1,98 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { isIdentity } from '@ironfish/sdk'
+import { CliUx, Flags } from '@oclif/core'
+import { IronfishCommand } from '../../command'
+import { JsonFlag, JsonFlagKey, RemoteFlags } from '../../flags'
+
+export class PreferCommand extends IronfishCommand {
+  static description = `Prefer syncing from a peer, or list the preferred peers
+
+  The node syncs from a connected preferred peer before any other peer, and falls
+  back to other peers if the preferred peer stalls. This updates
+  preferredSyncPeers in the node's config.`
+
+  static examples = [
+    '$ ironfish peers:prefer',
+    '$ ironfish peers:prefer <identity>',
+    '$ ironfish peers:prefer <identity> --remove',
+  ]
+
+  static flags = {
+    ...RemoteFlags,
+    [JsonFlagKey]: JsonFlag,
+    remove: Flags.boolean({
+      default: false,
+      description: 'stop preferring the peer',
+    }),
+  }
+
+  static args = [
+    {
+      name: 'identity',
+      required: false,
+      description: 'identity of the peer, lists the preferred peers if not given',
+    },
+  ]
+
+  async start(): Promise<void> {
+    const { args, flags } = await this.parse(PreferCommand)
+    const identity = (args.identity as string | undefined)?.trim()
+
+    const client = await this.sdk.connectRpc()
+
+    const config = await client.getConfig({ name: 'preferredSyncPeers' })
+    const preferred = config.content.preferredSyncPeers ?? []
+
+    if (!identity) {
+      const response = await client.getPeers()
+      const connected = new Set(
+        response.content.peers.filter((p) => p.state === 'CONNECTED').map((p) => p.identity),
+      )
+
+      const peers = preferred.map((identity) => ({
+        identity,
+        connected: connected.has(identity),
+      }))
+
+      if (this.json) {
+        this.logJson(peers)
+        return
+      }
+
+      if (!peers.length) {
+        this.log('There are no preferred sync peers')
+        return
+      }
+
+      CliUx.ux.table(peers, {
+        identity: { header: 'Identity' },
+        connected: { header: 'Connected', get: (row) => (row.connected ? 'yes' : 'no') },
+      })
+      return
+    }
+
+    if (flags.remove) {
+      if (!preferred.includes(identity)) {
+        this.error(`${identity} is not a preferred sync peer`)
+      }
+
+      const value = preferred.filter((i) => i !== identity)
+      await client.setConfig({ name: 'preferredSyncPeers', value: value.join(',') })
+      this.log(`No longer preferring ${identity} for syncing`)
+      return
+    }
+
+    if (!isIdentity(identity)) {
+      this.error(`${identity} is not a valid peer identity`)
+    }
+
+    if (!preferred.includes(identity)) {
+      const value = [...preferred, identity]
+      await client.setConfig({ name: 'preferredSyncPeers', value: value.join(',') })
+    }
+
+    this.log(`Preferring ${identity} for syncing`)
+  }
+}
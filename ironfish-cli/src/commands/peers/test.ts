@@ -0,0 +1,105 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { FileUtils, TestPeersResponse } from '@ironfish/sdk'
+import { CliUx, Flags } from '@oclif/core'
+import { IronfishCommand } from '../../command'
+import { JsonFlag, JsonFlagKey, RemoteFlags } from '../../flags'
+
+export class TestCommand extends IronfishCommand {
+  static description = `Test the connections to bootstrap nodes and connected peers`
+
+  static flags = {
+    ...RemoteFlags,
+    [JsonFlagKey]: JsonFlag,
+    blocks: Flags.integer({
+      char: 'b',
+      default: 10,
+      description: 'the number of blocks to download from each peer to measure throughput',
+    }),
+  }
+
+  static examples = ['$ ironfish peers:test', '$ ironfish peers:test --blocks 50']
+
+  async start(): Promise<void> {
+    const { flags } = await this.parse(TestCommand)
+
+    const client = await this.sdk.connectRpc()
+
+    if (!this.json) {
+      CliUx.ux.action.start('Testing connections')
+    }
+
+    const results: TestPeersResponse[] = []
+    const response = client.testPeersStream({ blocks: flags.blocks })
+
+    for await (const result of response.contentStream()) {
+      results.push(result)
+
+      if (!this.json) {
+        CliUx.ux.action.status = `${results.length} tested`
+      }
+    }
+
+    if (this.json) {
+      this.logJson(results)
+      return
+    }
+
+    CliUx.ux.action.stop('done.')
+    this.log('')
+
+    CliUx.ux.table(results, {
+      type: {
+        header: 'TYPE',
+      },
+      peer: {
+        header: 'PEER',
+        get: (row) => row.name || row.address || row.identity || '-',
+      },
+      handshake: {
+        header: 'HANDSHAKE',
+        get: (row) => (row.handshakeMs !== null ? `${row.handshakeMs}ms` : '-'),
+      },
+      latency: {
+        header: 'LATENCY',
+        get: (row) => (row.latencyMs !== null ? `${row.latencyMs}ms` : '-'),
+      },
+      throughput: {
+        header: 'THROUGHPUT',
+        get: (row) =>
+          row.throughput !== null ? `${FileUtils.formatFileSize(row.throughput)}/s` : '-',
+      },
+      blocks: {
+        header: 'BLOCKS',
+      },
+      status: {
+        header: 'STATUS',
+        get: (row) => (row.error ? 'FAILED' : row.underperforming ? 'SLOW' : 'OK'),
+      },
+    })
+
+    const underperforming = results.filter((r) => r.underperforming)
+
+    if (!underperforming.length) {
+      this.log(`\nAll ${results.length} connections are performing well.`)
+      return
+    }
+
+    const count = `${underperforming.length} of ${results.length}`
+    this.log(`\n${count} connections are underperforming:`)
+
+    for (const result of underperforming) {
+      const peer = result.name || result.address || result.identity || '-'
+      this.log(`\n  ${peer} (${result.type})`)
+
+      if (result.error) {
+        this.log(`    Error: ${result.error}`)
+      }
+
+      for (const hint of result.hints) {
+        this.log(`    - ${hint}`)
+      }
+    }
+  }
+}
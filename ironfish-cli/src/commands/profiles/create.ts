@@ -0,0 +1,90 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { Config, Profiles } from '@ironfish/sdk'
+import { Flags } from '@oclif/core'
+import { IronfishCommand } from '../../command'
+import { DataDirFlag, DataDirFlagKey, VerboseFlag, VerboseFlagKey } from '../../flags'
+
+export class CreateCommand extends IronfishCommand {
+  static description = `Create a named profile with its own data dir and config`
+
+  static args = [
+    {
+      name: 'name',
+      parse: (input: string): Promise<string> => Promise.resolve(input.trim()),
+      required: true,
+      description: 'name of the profile, for example mainnet-archive or miner',
+    },
+  ]
+
+  static flags = {
+    [VerboseFlagKey]: VerboseFlag,
+    [DataDirFlagKey]: DataDirFlag,
+    from: Flags.string({
+      description: 'copy the config of an existing profile',
+    }),
+    port: Flags.integer({
+      description: 'the port the profile listens for peers on',
+    }),
+    rpcPort: Flags.integer({
+      description: 'the port the profile listens for TCP RPC connections on',
+    }),
+  }
+
+  static examples = [
+    '$ ironfish profiles:create testnet-dev --port 9035 --rpcPort 8021',
+    '$ ironfish start --profile testnet-dev',
+  ]
+
+  async start(): Promise<void> {
+    const { args, flags } = await this.parse(CreateCommand)
+    const name = args.name as string
+    const files = this.sdk.fileSystem
+
+    if (!Profiles.isValidName(name)) {
+      this.error(`Invalid profile name ${name}, use letters, numbers, dashes, or underscores`)
+    }
+
+    const dataDir = Profiles.getDataDir(files, this.sdk.config.dataDir, name)
+
+    if (await files.exists(dataDir)) {
+      this.error(`Profile ${name} already exists at ${dataDir}`)
+    }
+
+    const config = new Config(files, dataDir)
+    await config.load()
+
+    if (flags.from) {
+      if (!Profiles.isValidName(flags.from)) {
+        this.error(`Invalid profile name ${flags.from}`)
+      }
+
+      const fromDataDir = Profiles.getDataDir(files, this.sdk.config.dataDir, flags.from)
+      if (!(await files.exists(fromDataDir))) {
+        this.error(`No profile named ${flags.from}`)
+      }
+
+      const from = new Config(files, fromDataDir)
+      await from.load()
+
+      for (const key of from.keysLoaded) {
+        // eslint-disable-next-line @typescript-eslint/no-explicit-any
+        config.set(key, from.loaded[key] as any)
+      }
+    }
+
+    if (flags.port !== undefined) {
+      config.set('peerPort', flags.port)
+    }
+
+    if (flags.rpcPort !== undefined) {
+      config.set('rpcTcpPort', flags.rpcPort)
+    }
+
+    await config.save()
+
+    this.log(`Created profile ${name} at ${dataDir}`)
+    this.log(`Use it with --profile ${name} or by setting IRONFISH_PROFILE=${name}`)
+  }
+}
@@ -0,0 +1,64 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { Config, Profiles } from '@ironfish/sdk'
+import { CliUx } from '@oclif/core'
+import fsAsync from 'fs/promises'
+import { IronfishCommand } from '../../command'
+import { DataDirFlag, DataDirFlagKey, VerboseFlag, VerboseFlagKey } from '../../flags'
+
+export class ListCommand extends IronfishCommand {
+  static description = `List the profiles in the data dir`
+
+  static flags = {
+    [VerboseFlagKey]: VerboseFlag,
+    [DataDirFlagKey]: DataDirFlag,
+  }
+
+  async start(): Promise<void> {
+    await this.parse(ListCommand)
+
+    const files = this.sdk.fileSystem
+    const profilesDir = Profiles.getProfilesDir(files, this.sdk.config.dataDir)
+
+    if (!(await files.exists(profilesDir))) {
+      this.log(`No profiles found, create one with ironfish profiles:create`)
+      this.exit(0)
+    }
+
+    const entries = await fsAsync.readdir(profilesDir, { withFileTypes: true })
+    const profiles = []
+
+    for (const entry of entries) {
+      if (!entry.isDirectory() || !Profiles.isValidName(entry.name)) {
+        continue
+      }
+
+      const dataDir = Profiles.getDataDir(files, this.sdk.config.dataDir, entry.name)
+      const config = new Config(files, dataDir)
+      await config.load()
+
+      profiles.push({
+        name: entry.name,
+        dataDir,
+        peerPort: config.get('peerPort'),
+        rpcTcpPort: config.get('rpcTcpPort'),
+      })
+    }
+
+    CliUx.ux.table(profiles, {
+      name: {
+        header: 'Profile',
+      },
+      peerPort: {
+        header: 'Port',
+      },
+      rpcTcpPort: {
+        header: 'RPC Port',
+      },
+      dataDir: {
+        header: 'Data Dir',
+      },
+    })
+  }
+}
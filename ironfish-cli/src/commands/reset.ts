@@ -12,8 +12,12 @@ import {
   DatabaseFlagKey,
   DataDirFlag,
   DataDirFlagKey,
+  ProfileFlag,
+  ProfileFlagKey,
   VerboseFlag,
   VerboseFlagKey,
+  YesFlag,
+  YesFlagKey,
 } from '../flags'
 
 export default class Reset extends IronfishCommand {
@@ -23,7 +27,9 @@ export default class Reset extends IronfishCommand {
     [VerboseFlagKey]: VerboseFlag,
     [ConfigFlagKey]: ConfigFlag,
     [DataDirFlagKey]: DataDirFlag,
+    [ProfileFlagKey]: ProfileFlag,
     [DatabaseFlagKey]: DatabaseFlag,
+    [YesFlagKey]: YesFlag,
     confirm: Flags.boolean({
       default: false,
       description: 'confirm without asking',
@@ -41,7 +47,7 @@ export default class Reset extends IronfishCommand {
       `\n/!\\ WARNING: This will permanently delete your accounts. You can back them up by loading the previous version of ironfish and running ironfish export. /!\\\n` +
       '\nHave you read the warning? (Y)es / (N)o'
 
-    confirmed = flags.confirm || (await CliUx.ux.confirm(warningMessage))
+    confirmed = flags.confirm || (await this.confirm(warningMessage))
 
     if (!confirmed) {
       this.log('Reset aborted.')
@@ -64,7 +70,7 @@ export default class Reset extends IronfishCommand {
       `\nIndexes: ${indexDatabasePath}` +
       `\n\nAre you sure? (Y)es / (N)o`
 
-    confirmed = flags.confirm || (await CliUx.ux.confirm(message))
+    confirmed = flags.confirm || (await this.confirm(message))
 
     if (!confirmed) {
       this.log('Reset aborted.')
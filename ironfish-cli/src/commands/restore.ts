@@ -10,7 +10,14 @@ import fsAsync from 'fs/promises'
 import os from 'os'
 import path from 'path'
 import { IronfishCommand } from '../command'
-import { DataDirFlag, DataDirFlagKey, VerboseFlag, VerboseFlagKey } from '../flags'
+import {
+  DataDirFlag,
+  DataDirFlagKey,
+  ProfileFlag,
+  ProfileFlagKey,
+  VerboseFlag,
+  VerboseFlagKey,
+} from '../flags'
 import { ProgressBar } from '../types'
 
 const EXTENSION = '.tar.gz'
@@ -22,6 +29,7 @@ export default class Restore extends IronfishCommand {
   static flags = {
     [VerboseFlagKey]: VerboseFlag,
     [DataDirFlagKey]: DataDirFlag,
+    [ProfileFlagKey]: ProfileFlag,
     lock: Flags.boolean({
       default: true,
       allowNo: true,
@@ -0,0 +1,93 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { ErrorUtils } from '@ironfish/sdk'
+import { CliUx, Flags } from '@oclif/core'
+import os from 'os'
+import path from 'path'
+import { IronfishCommand } from '../../command'
+import {
+  ConfigFlag,
+  ConfigFlagKey,
+  DataDirFlag,
+  DataDirFlagKey,
+  ProfileFlag,
+  ProfileFlagKey,
+  VerboseFlag,
+  VerboseFlagKey,
+} from '../../flags'
+import { getServiceManager, SERVICE_NAME } from '../../utils'
+
+export default class ServiceInstall extends IronfishCommand {
+  static description = `Install the node as a systemd unit on Linux or a Windows service
+
+On Linux a system unit is installed when run as root, otherwise a user unit.
+On Windows NSSM (https://nssm.cc) must be on the PATH.`
+
+  static flags = {
+    [VerboseFlagKey]: VerboseFlag,
+    [ConfigFlagKey]: ConfigFlag,
+    [DataDirFlagKey]: DataDirFlag,
+    [ProfileFlagKey]: ProfileFlag,
+    logFile: Flags.string({
+      description: 'a file to write the node output to instead of the service log',
+    }),
+    runAs: Flags.string({
+      description: 'the user a system unit runs the node as, defaults to the sudo user',
+    }),
+    start: Flags.boolean({
+      default: true,
+      allowNo: true,
+      description: 'start the service after installing it',
+    }),
+  }
+
+  static examples = [
+    '$ ironfish service:install',
+    '$ sudo ironfish service:install --runAs ironfish --datadir /var/lib/ironfish',
+  ]
+
+  async start(): Promise<void> {
+    const { flags } = await this.parse(ServiceInstall)
+
+    const manager = getServiceManager()
+    if (!manager) {
+      this.log(`Installing a service is not supported on ${process.platform}`)
+      this.exit(1)
+    }
+
+    const dataDir = this.sdk.fileSystem.resolve(this.sdk.dataDir)
+    const logFile = flags.logFile ? path.resolve(flags.logFile) : null
+    const runAs = flags.runAs ?? process.env.SUDO_USER ?? null
+
+    CliUx.ux.action.start(`Installing ${SERVICE_NAME} as a ${manager.platform} service`)
+
+    try {
+      await manager.install({
+        dataDir,
+        logFile,
+        runAs,
+        // Leave time for the node to shut down in order before it is killed
+        stopTimeoutMs: this.sdk.config.get('shutdownTimeoutMs') + 5000,
+      })
+
+      if (flags.start) {
+        await manager.start()
+      }
+    } catch (e: unknown) {
+      CliUx.ux.action.stop('failed')
+      this.log(ErrorUtils.renderError(e))
+      this.exit(1)
+    }
+
+    CliUx.ux.action.stop('done')
+
+    this.log(`Data dir: ${dataDir}`)
+    this.log(`Logs:     ${logFile ?? manager.defaultLogs}`)
+
+    if (manager.platform === 'systemd' && process.getuid?.() !== 0) {
+      const linger = `loginctl enable-linger ${os.userInfo().username}`
+      this.log(`\nUser units stop when you log out, run '${linger}' to keep the node running`)
+    }
+  }
+}
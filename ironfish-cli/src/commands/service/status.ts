@@ -0,0 +1,46 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { IronfishCommand } from '../../command'
+import { JsonFlag, JsonFlagKey, VerboseFlag, VerboseFlagKey } from '../../flags'
+import { getServiceManager, SERVICE_NAME } from '../../utils'
+
+export default class ServiceStatus extends IronfishCommand {
+  static description = 'Show the status of the node service installed with service:install'
+
+  static flags = {
+    [VerboseFlagKey]: VerboseFlag,
+    [JsonFlagKey]: JsonFlag,
+  }
+
+  async start(): Promise<void> {
+    await this.parse(ServiceStatus)
+
+    const manager = getServiceManager()
+    if (!manager) {
+      this.log(`Services are not supported on ${process.platform}`)
+      this.exit(1)
+    }
+
+    const status = await manager.status()
+
+    if (this.json) {
+      this.logJson({ name: SERVICE_NAME, platform: manager.platform, ...status })
+      return
+    }
+
+    if (!status.installed) {
+      this.log(`The ${SERVICE_NAME} service is not installed, run 'ironfish service:install'`)
+      return
+    }
+
+    this.log(`Service    ${SERVICE_NAME} (${manager.platform})`)
+    this.log(`Running    ${status.running ? 'yes' : 'no'}`)
+    this.log(`On boot    ${status.enabled ? 'yes' : 'no'}`)
+    this.log(`Logs       ${manager.defaultLogs}`)
+
+    if (status.details) {
+      this.log(`\n${status.details}`)
+    }
+  }
+}
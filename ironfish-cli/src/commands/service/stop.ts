@@ -0,0 +1,34 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { ErrorUtils } from '@ironfish/sdk'
+import { IronfishCommand } from '../../command'
+import { VerboseFlag, VerboseFlagKey } from '../../flags'
+import { getServiceManager, SERVICE_NAME } from '../../utils'
+
+export default class ServiceStop extends IronfishCommand {
+  static description = 'Stop the node service installed with service:install'
+
+  static flags = {
+    [VerboseFlagKey]: VerboseFlag,
+  }
+
+  async start(): Promise<void> {
+    await this.parse(ServiceStop)
+
+    const manager = getServiceManager()
+    if (!manager) {
+      this.log(`Services are not supported on ${process.platform}`)
+      this.exit(1)
+    }
+
+    try {
+      await manager.stop()
+    } catch (e: unknown) {
+      this.log(ErrorUtils.renderError(e))
+      this.exit(1)
+    }
+
+    this.log(`Stopped the ${SERVICE_NAME} service`)
+  }
+}
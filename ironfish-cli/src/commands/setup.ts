@@ -0,0 +1,198 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import {
+  AccountsValue,
+  DiskUtils,
+  ErrorUtils,
+  FileUtils,
+  GRAFFITI_SIZE,
+  IronfishSdk,
+  JSONUtils,
+  loadNetworkDefinition,
+  RpcClient,
+} from '@ironfish/sdk'
+import { CliUx } from '@oclif/core'
+import { v4 as uuid } from 'uuid'
+import { IronfishCommand } from '../command'
+import {
+  DataDirFlag,
+  DataDirFlagKey,
+  ProfileFlag,
+  ProfileFlagKey,
+  VerboseFlag,
+  VerboseFlagKey,
+} from '../flags'
+import { IronfishCliPKG } from '../package'
+import { ENABLE_TELEMETRY_CONFIG_KEY } from './start'
+
+// The space a node with a full chain and some room to grow is expected to need
+const RECOMMENDED_DISK_SPACE = 10 * 1000 * 1000 * 1000
+
+export default class Setup extends IronfishCommand {
+  static description = 'Interactively configure a new node'
+
+  static flags = {
+    [VerboseFlagKey]: VerboseFlag,
+    [DataDirFlagKey]: DataDirFlag,
+    [ProfileFlagKey]: ProfileFlag,
+  }
+
+  async start(): Promise<void> {
+    await this.parse(Setup)
+
+    this.log('Welcome to Iron Fish! This will walk you through configuring your node.')
+    this.log('Press enter to keep the value shown in brackets.')
+    this.log('')
+
+    const dataDir = this.sdk.fileSystem.resolve(
+      (await CliUx.ux.prompt('Where should the node store its data?', {
+        default: this.sdk.config.dataDir,
+      })) as string,
+    )
+
+    await this.checkDiskSpace(dataDir)
+
+    const sdk =
+      dataDir === this.sdk.config.dataDir
+        ? this.sdk
+        : await IronfishSdk.init({ pkg: IronfishCliPKG, dataDir, logger: this.logger })
+
+    const network = await this.promptNetwork(sdk)
+
+    const nodeName = (await CliUx.ux.prompt('What should your node be called?', {
+      default: sdk.config.get('nodeName'),
+      required: false,
+    })) as string
+
+    const graffiti = await this.promptGraffiti(sdk.config.get('blockGraffiti'))
+
+    this.log('')
+    this.log('Telemetry shares anonymous data about your node to help improve Iron Fish.')
+    const telemetry = await CliUx.ux.confirm('Do you want to enable telemetry? (y)es / (n)o')
+
+    sdk.config.set('network', network)
+    sdk.config.set('nodeName', nodeName.trim())
+    sdk.config.set('blockGraffiti', graffiti)
+    sdk.config.set(ENABLE_TELEMETRY_CONFIG_KEY, telemetry)
+    await sdk.config.save()
+
+    sdk.internal.set('isFirstRun', false)
+    if (!sdk.internal.get('telemetryNodeId')) {
+      sdk.internal.set('telemetryNodeId', uuid())
+    }
+    await sdk.internal.save()
+
+    this.log('')
+    this.log(`Saved your config to ${sdk.config.storage.configPath}`)
+
+    const client = await sdk.connectRpc()
+    await this.setupAccount(client)
+
+    this.log('')
+    this.log('Your node is ready. Start it by running')
+    this.log(
+      dataDir === this.sdk.config.dataDir
+        ? ' > ironfish start'
+        : ` > ironfish start --datadir ${dataDir}`,
+    )
+
+    this.exit(0)
+  }
+
+  async checkDiskSpace(dataDir: string): Promise<void> {
+    const free = await DiskUtils.getFreeSpace(dataDir)
+
+    if (free === null) {
+      this.log(`Could not check the free disk space at ${dataDir}`)
+      return
+    }
+
+    this.log(`${FileUtils.formatFileSize(free)} free at ${dataDir}`)
+
+    if (free < RECOMMENDED_DISK_SPACE) {
+      const recommended = FileUtils.formatFileSize(RECOMMENDED_DISK_SPACE)
+      this.warn(`At least ${recommended} of free space is recommended to run a node.`)
+
+      const confirmed = await CliUx.ux.confirm('Continue anyway? (y)es / (n)o')
+      if (!confirmed) {
+        this.exit(1)
+      }
+    }
+  }
+
+  async promptNetwork(sdk: IronfishSdk): Promise<string> {
+    const question = 'Which network should the node join? testnet, devnet, or a file path'
+
+    for (;;) {
+      const network = (
+        (await CliUx.ux.prompt(question, {
+          default: sdk.config.get('network'),
+        })) as string
+      ).trim()
+
+      try {
+        const definition = await loadNetworkDefinition(sdk.fileSystem, network)
+        this.log(`Using network ${definition.id}`)
+        return network
+      } catch (e: unknown) {
+        this.log(`${ErrorUtils.renderError(e)}, try again.`)
+      }
+    }
+  }
+
+  async promptGraffiti(current: string): Promise<string> {
+    for (;;) {
+      const graffiti = (
+        (await CliUx.ux.prompt('What graffiti should blocks you mine include?', {
+          default: current,
+          required: false,
+        })) as string
+      ).trim()
+
+      if (Buffer.byteLength(graffiti, 'utf8') <= GRAFFITI_SIZE) {
+        return graffiti
+      }
+
+      this.log(`Graffiti can be at most ${GRAFFITI_SIZE} bytes, try again.`)
+    }
+  }
+
+  async setupAccount(client: RpcClient): Promise<void> {
+    const response = await client.getAccounts()
+
+    if (response.content.accounts.length) {
+      this.log(`Found existing accounts: ${response.content.accounts.join(', ')}`)
+      return
+    }
+
+    this.log('')
+    const choice = (
+      (await CliUx.ux.prompt('Do you want to (c)reate a new account or (i)mport one?', {
+        default: 'c',
+      })) as string
+    )
+      .trim()
+      .toLowerCase()
+
+    if (choice.startsWith('i')) {
+      const path = (await CliUx.ux.prompt('Enter the path to the exported account', {
+        required: true,
+      })) as string
+
+      const data = await this.sdk.fileSystem.readFile(this.sdk.fileSystem.resolve(path))
+      const account = JSONUtils.parse<AccountsValue>(data)
+
+      const result = await client.importAccount({ account, rescan: true })
+      this.log(`Account ${result.content.name} imported`)
+      return
+    }
+
+    const name = (await CliUx.ux.prompt('Enter the name of the account', {
+      default: 'default',
+    })) as string
+
+    const result = await client.createAccount({ name: name.trim() })
+    this.log(`Account ${name} created with public address ${result.content.publicAddress}`)
+  }
+}
@@ -6,6 +6,8 @@ import { expect as expectCli, test } from '@oclif/test'
 import { v4 as uuid } from 'uuid'
 import { IronfishCliPKG } from '../package'
 
+jest.mock('../utils/updater')
+
 jest.mock('@ironfish/sdk', () => {
   const originalModule = jest.requireActual('@ironfish/sdk')
 
@@ -61,6 +63,7 @@ describe('start command', () => {
       bootstrapNodes: [],
       blockGraffiti: defaultGraffiti,
       generateNewIdentity: false,
+      autoUpdate: 'off',
     }
 
     const internalOptions = {
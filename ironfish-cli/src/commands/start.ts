@@ -1,7 +1,14 @@
 /* This Source Code Form is subject to the terms of the Mozilla Public
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
-import { Assert, IronfishNode, NodeUtils, PrivateIdentity, PromiseUtils } from '@ironfish/sdk'
+import {
+  Assert,
+  ErrorUtils,
+  IronfishNode,
+  NodeUtils,
+  PrivateIdentity,
+  PromiseUtils,
+} from '@ironfish/sdk'
 import { Flags } from '@oclif/core'
 import tweetnacl from 'tweetnacl'
 import { v4 as uuid } from 'uuid'
@@ -13,6 +20,10 @@ import {
   DatabaseFlagKey,
   DataDirFlag,
   DataDirFlagKey,
+  NetworkFlag,
+  NetworkFlagKey,
+  ProfileFlag,
+  ProfileFlagKey,
   RpcTcpHostFlag,
   RpcTcpHostFlagKey,
   RpcTcpPortFlag,
@@ -29,9 +40,12 @@ import {
   VerboseFlagKey,
 } from '../flags'
 import { ONE_FISH_IMAGE } from '../images'
+import { IronfishCliPKG } from '../package'
+import { isInRollout, UPDATE_HEALTHY_AFTER_MS, Updater } from '../utils'
 
 export const ENABLE_TELEMETRY_CONFIG_KEY = 'enableTelemetry'
 const DEFAULT_ACCOUNT_NAME = 'default'
+const UPDATE_CHECK_INTERVAL_MS = 24 * 60 * 60 * 1000
 
 export default class Start extends IronfishCommand {
   static description = 'Start the node'
@@ -40,7 +54,9 @@ export default class Start extends IronfishCommand {
     [VerboseFlagKey]: VerboseFlag,
     [ConfigFlagKey]: ConfigFlag,
     [DataDirFlagKey]: DataDirFlag,
+    [ProfileFlagKey]: ProfileFlag,
     [DatabaseFlagKey]: DatabaseFlag,
+    [NetworkFlagKey]: NetworkFlag,
     [RpcUseIpcFlagKey]: { ...RpcUseIpcFlag, allowNo: true },
     [RpcUseTcpFlagKey]: { ...RpcUseTcpFlag, allowNo: true },
     [RpcTcpTlsFlagKey]: RpcTcpTlsFlag,
@@ -49,7 +65,8 @@ export default class Start extends IronfishCommand {
     [RpcTcpSecureFlagKey]: RpcTcpSecureFlag,
     bootstrap: Flags.string({
       char: 'b',
-      description: 'comma-separated addresses of bootstrap nodes to connect to',
+      description:
+        'comma-separated addresses of bootstrap nodes to connect to, as identity@host:port to pin their identity',
       multiple: true,
     }),
     port: Flags.integer({
@@ -91,6 +108,26 @@ export default class Start extends IronfishCommand {
       description: 'genereate new identity for each new start',
       hidden: true,
     }),
+    checkIntegrity: Flags.boolean({
+      default: undefined,
+      allowNo: true,
+      description: 'check the chain database for corruption before starting',
+    }),
+    localDiscovery: Flags.boolean({
+      default: undefined,
+      allowNo: true,
+      description: 'find and sync from other nodes on the local network with mDNS',
+    }),
+    chainNode: Flags.string({
+      description:
+        'host:port of a chain node to sync from over RPC instead of from peers, to run a wallet in its own process',
+    }),
+    follower: Flags.boolean({
+      default: undefined,
+      allowNo: true,
+      description:
+        'sync from --chainNode and only serve read only RPC routes, to run a read replica',
+    }),
   }
 
   node: IronfishNode | null = null
@@ -103,6 +140,9 @@ export default class Start extends IronfishCommand {
    */
   startDonePromise: Promise<void> | null = null
 
+  updater: Updater | null = null
+  updateTimers: ReturnType<typeof setTimeout>[] = []
+
   async start(): Promise<void> {
     const [startDonePromise, startDoneResolve] = PromiseUtils.split<void>()
     this.startDonePromise = startDonePromise
@@ -118,6 +158,10 @@ export default class Start extends IronfishCommand {
       port,
       workers,
       generateNewIdentity,
+      checkIntegrity,
+      localDiscovery,
+      chainNode,
+      follower,
     } = flags
 
     if (bootstrap !== undefined) {
@@ -144,6 +188,12 @@ export default class Start extends IronfishCommand {
     if (listen !== undefined && listen !== this.sdk.config.get('enableListenP2P')) {
       this.sdk.config.setOverride('enableListenP2P', listen)
     }
+    if (
+      localDiscovery !== undefined &&
+      localDiscovery !== this.sdk.config.get('enableLocalDiscovery')
+    ) {
+      this.sdk.config.setOverride('enableLocalDiscovery', localDiscovery)
+    }
     if (forceMining !== undefined && forceMining !== this.sdk.config.get('miningForce')) {
       this.sdk.config.setOverride('miningForce', forceMining)
     }
@@ -159,12 +209,35 @@ export default class Start extends IronfishCommand {
     ) {
       this.sdk.config.setOverride('generateNewIdentity', generateNewIdentity)
     }
+    if (
+      checkIntegrity !== undefined &&
+      checkIntegrity !== this.sdk.config.get('enableIntegrityCheck')
+    ) {
+      this.sdk.config.setOverride('enableIntegrityCheck', checkIntegrity)
+    }
+    if (chainNode !== undefined && chainNode !== this.sdk.config.get('chainNode')) {
+      this.sdk.config.setOverride('chainNode', chainNode)
+    }
+    if (follower !== undefined && follower !== this.sdk.config.get('follower')) {
+      this.sdk.config.setOverride('follower', follower)
+    }
 
     if (!this.sdk.internal.get('telemetryNodeId')) {
       this.sdk.internal.set('telemetryNodeId', uuid())
       await this.sdk.internal.save()
     }
 
+    this.updater = new Updater({ files: this.sdk.fileSystem, logger: this.logger })
+
+    const rolledBackTo = await this.updater.onStart()
+    if (rolledBackTo) {
+      this.log(
+        `Version ${IronfishCliPKG.version} failed to start after updating,` +
+          ` rolled back to ${rolledBackTo}. Start the node again to use it.`,
+      )
+      this.exit(1)
+    }
+
     const privateIdentity = this.getPrivateIdentity()
 
     const node = await this.sdk.node({ privateIdentity: privateIdentity })
@@ -172,16 +245,25 @@ export default class Start extends IronfishCommand {
     const nodeName = this.sdk.config.get('nodeName').trim() || null
     const blockGraffiti = this.sdk.config.get('blockGraffiti').trim() || null
     const peerPort = this.sdk.config.get('peerPort')
-    const bootstraps = this.sdk.config.getArray('bootstrapNodes')
+    const bootstraps = this.sdk.config.isSet('bootstrapNodes')
+      ? this.sdk.config.getArray('bootstrapNodes')
+      : node.chain.network.bootstrapNodes
 
     this.log(`\n${ONE_FISH_IMAGE}`)
     this.log(`Version       ${node.pkg.version} @ ${node.pkg.git}`)
     this.log(`Node Name     ${nodeName || 'NONE'}`)
     this.log(`Graffiti      ${blockGraffiti || 'NONE'}`)
+    this.log(`Network       ${node.chain.network.id}`)
     this.log(`Peer Identity ${node.peerNetwork.localPeer.publicIdentity}`)
     this.log(`Peer Agent    ${node.peerNetwork.localPeer.agent}`)
     this.log(`Peer Port     ${peerPort}`)
     this.log(`Bootstrap     ${bootstraps.join(',') || 'NONE'}`)
+    if (node.remoteSyncer) {
+      this.log(`Chain Node    ${this.sdk.config.get('chainNode')}`)
+    }
+    if (this.sdk.config.get('follower')) {
+      this.log('Follower      yes')
+    }
     this.log(` `)
 
     await NodeUtils.waitForOpen(node, () => this.closing)
@@ -221,15 +303,78 @@ export default class Start extends IronfishCommand {
     this.node = node
 
     startDoneResolve()
+    // Leave the node time to flush everything before being force closed
+    this.closeTimeoutMs = node.config.get('shutdownTimeoutMs') + 5000
     this.listenForSignals()
+    this.watchForUpdates(node, this.updater)
     await node.waitForShutdown()
+    this.updateTimers.forEach((timer) => clearTimeout(timer))
   }
 
   async closeFromSignal(signal: SIGNALS): Promise<void> {
     this.log(`Shutting down node after ${signal}`)
     await this.startDonePromise
-    await this.node?.shutdown()
-    await this.node?.closeDB()
+
+    if (!this.node) {
+      return
+    }
+
+    this.node.onShutdownStep.on((step) => {
+      this.log(`Shutting down: ${step}...`)
+    })
+
+    await this.node.shutdown()
+
+    this.log('Shutting down: closing databases...')
+    await this.node.closeDB()
+
+    // A clean shutdown means an updated version works, even if it ran briefly
+    this.updateTimers.forEach((timer) => clearTimeout(timer))
+    await this.updater?.onHealthy()
+
+    this.log('Shutdown complete')
+  }
+
+  /**
+   * Trusts an updated version once it has run long enough, and checks for new
+   * versions according to the autoUpdate config
+   */
+  watchForUpdates(node: IronfishNode, updater: Updater): void {
+    this.updateTimers.push(setTimeout(() => void updater.onHealthy(), UPDATE_HEALTHY_AFTER_MS))
+
+    if (node.config.get('autoUpdate') === 'off') {
+      return
+    }
+
+    void this.checkForUpdate(node, updater)
+    this.updateTimers.push(
+      setInterval(() => void this.checkForUpdate(node, updater), UPDATE_CHECK_INTERVAL_MS),
+    )
+  }
+
+  async checkForUpdate(node: IronfishNode, updater: Updater): Promise<void> {
+    const channel = node.config.get('releaseChannel')
+    const version = await updater.check(channel).catch(() => null)
+    if (!version) {
+      return
+    }
+
+    const state = await updater.loadState()
+    if (state.current === version.version) {
+      return
+    }
+
+    const nodeId = node.internal.get('telemetryNodeId')
+    const install = node.config.get('autoUpdate') === 'auto' && updater.supported
+
+    if (install && isInRollout(version, nodeId)) {
+      await updater.install(version).catch((e: unknown) => {
+        this.logger.warn(`Could not install ${version.version}: ${ErrorUtils.renderError(e)}`)
+      })
+      return
+    }
+
+    this.log(`Version ${version.version} is available, install it with ironfish version:update`)
   }
 
   /**
@@ -7,9 +7,18 @@ import { expect as expectCli, test } from '@oclif/test'
 describe('status', () => {
   const responseContent: GetStatusResponse = {
     peerNetwork: { peers: 0, isReady: false, inboundTraffic: 0, outboundTraffic: 0 },
+    blockPropagation: {
+      forwardTime: 0,
+      forwardTimeLast: 0,
+      minedAckTime: 0,
+      minedAckTimeLast: 0,
+    },
     blockchain: {
       synced: true,
       head: '123',
+      finalizedSequence: null,
+      refusedReorg: null,
+      caches: [{ name: 'headers', hitRate: 0.5, items: 1, sizeBytes: 1000, maxBytes: 2000 }],
     },
     node: {
       status: 'started',
@@ -23,11 +32,17 @@ describe('status', () => {
       rss: 3,
       memFree: 4,
       memTotal: 10,
+      pressure: 'normal',
+      budget: 5,
+      consumers: [{ name: 'memPool', bytes: 0 }],
     },
+    clock: { offsetMs: 10, skewed: false, compensationMs: 0 },
+    disk: { status: 'ok', free: 1024, dataDir: '/tmp' },
     miningDirector: { status: 'started', miners: 0, blocks: 0 },
     memPool: { size: 0 },
     blockSyncer: { status: 'stopped', syncing: { blockSpeed: 0, speed: 0, progress: 0 } },
     telemetry: { status: 'stopped', pending: 0, submitted: 0 },
+    accounts: { scanning: false, sequence: 0, endSequence: 0 },
     workers: {
       started: true,
       workers: 1,
@@ -36,6 +51,9 @@ describe('status', () => {
       capacity: 1,
       change: 0,
       speed: 0,
+      maxWorkers: 1,
+      queueLatency: 0,
+      queues: [],
     },
   }
 
@@ -44,6 +62,7 @@ describe('status', () => {
       const originalModule = jest.requireActual('@ironfish/sdk')
       const client = {
         connect: jest.fn(),
+        tryConnect: jest.fn().mockResolvedValue(true),
         status: jest.fn().mockImplementation(() => ({
           content: responseContent,
         })),
@@ -75,13 +94,48 @@ describe('status', () => {
         expectCli(ctx.stdout).include('Version')
         expectCli(ctx.stdout).include('Node')
         expectCli(ctx.stdout).include('Memory')
+        expectCli(ctx.stdout).include('Memory Pressure')
+        expectCli(ctx.stdout).include('Disk')
+        expectCli(ctx.stdout).include('Clock')
         expectCli(ctx.stdout).include('P2P Network')
+        expectCli(ctx.stdout).include('Propagation')
         expectCli(ctx.stdout).include('Mining')
         expectCli(ctx.stdout).include('Mem Pool')
         expectCli(ctx.stdout).include('Syncer')
         expectCli(ctx.stdout).include('Blockchain')
+        expectCli(ctx.stdout).include('Chain Cache')
+        expectCli(ctx.stdout).include('Accounts')
         expectCli(ctx.stdout).include('Telemetry')
         expectCli(ctx.stdout).include('Workers')
       })
   })
+
+  describe('with --wait-until', () => {
+    test
+      .stdout()
+      .command(['status', '--wait-until', 'started'])
+      .exit(0)
+      .it('exits once the node is started', (ctx) => {
+        expectCli(ctx.stdout).include('Node is ready')
+      })
+
+    test
+      .stdout()
+      .command(['status', '--wait-until', 'synced', '--timeout', '1'])
+      .exit(4)
+      .it('exits with the code of the component that is not ready', (ctx) => {
+        expectCli(ctx.stdout).include('Waiting for the node to connect to the network')
+        expectCli(ctx.stdout).include('Timed out after 1 seconds')
+      })
+  })
+
+  describe('with --json', () => {
+    test
+      .stdout()
+      .command(['status', '--json'])
+      .exit(0)
+      .it('logs out the status as JSON', (ctx) => {
+        expect(JSON.parse(ctx.stdout)).toEqual(responseContent)
+      })
+  })
 })
@@ -1,63 +1,188 @@
 /* This Source Code Form is subject to the terms of the Mozilla Public
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
-import { FileUtils, GetStatusResponse, PromiseUtils } from '@ironfish/sdk'
+import { FileUtils, GetStatusResponse, PromiseUtils, RpcConnectionError } from '@ironfish/sdk'
 import { Assert } from '@ironfish/sdk'
 import { Flags } from '@oclif/core'
-import blessed from 'blessed'
 import { IronfishCommand } from '../command'
-import { RemoteFlags } from '../flags'
+import { JsonFlag, JsonFlagKey, RemoteFlags } from '../flags'
+import { StatusDashboard } from '../ui/statusDashboard'
+
+/**
+ * The exit codes of `status --wait-until`. When the timeout is reached the
+ * command exits with the code of the first component that isn't ready.
+ */
+export const STATUS_EXIT_CODES = {
+  READY: 0,
+  NOT_RUNNING: 2,
+  NOT_STARTED: 3,
+  NOT_CONNECTED: 4,
+  NOT_SYNCED: 5,
+} as const
+
+type StatusExitCode = typeof STATUS_EXIT_CODES[keyof typeof STATUS_EXIT_CODES]
+
+const WAITING_MESSAGES: Record<StatusExitCode, string> = {
+  [STATUS_EXIT_CODES.READY]: 'Node is ready',
+  [STATUS_EXIT_CODES.NOT_RUNNING]: 'Waiting for the node to be running',
+  [STATUS_EXIT_CODES.NOT_STARTED]: 'Waiting for the node to start',
+  [STATUS_EXIT_CODES.NOT_CONNECTED]: 'Waiting for the node to connect to the network',
+  [STATUS_EXIT_CODES.NOT_SYNCED]: 'Waiting for the node to sync',
+}
 
 export default class Status extends IronfishCommand {
   static description = 'Show the status of the node'
 
   static flags = {
     ...RemoteFlags,
+    [JsonFlagKey]: JsonFlag,
     follow: Flags.boolean({
       char: 'f',
       default: false,
-      description: 'follow the status of the node live',
+      description: 'follow the status of the node live in a full screen dashboard',
+    }),
+    sort: Flags.string({
+      char: 'o',
+      default: 'IDENTITY',
+      description: 'the peer table column to sort by when following',
+    }),
+    'wait-until': Flags.string({
+      options: ['started', 'synced'],
+      description: 'wait until the node is started or synced, then exit',
+    }),
+    timeout: Flags.integer({
+      default: 0,
+      description: 'seconds to wait with --wait-until before failing, 0 waits forever',
     }),
   }
 
+  static examples = [
+    '$ ironfish status',
+    '$ ironfish status --wait-until synced --timeout 600',
+  ]
+
   async start(): Promise<void> {
     const { flags } = await this.parse(Status)
 
+    const waitUntil = flags['wait-until']
+    if (waitUntil === 'started' || waitUntil === 'synced') {
+      const code = await this.waitUntil(waitUntil, flags.timeout)
+      this.exit(code)
+    }
+
     if (!flags.follow) {
       const client = await this.sdk.connectRpc()
       const response = await client.status()
-      this.log(renderStatus(response.content))
+
+      if (this.json) {
+        this.logJson(response.content)
+      } else {
+        this.log(renderStatus(response.content))
+      }
+
       this.exit(0)
     }
 
     // Console log will create display issues with Blessed
     this.logger.pauseLogs()
 
-    const screen = blessed.screen({ smartCSR: true })
-    const statusText = blessed.text()
-    screen.append(statusText)
+    const dashboard = new StatusDashboard({ sort: flags.sort })
+    dashboard.onQuit(() => {
+      dashboard.screen.destroy()
+      process.exit(0)
+    })
 
     // eslint-disable-next-line no-constant-condition
     while (true) {
       const connected = await this.sdk.client.tryConnect()
 
       if (!connected) {
-        statusText.clearBaseLine(0)
-        statusText.setContent('Node: STOPPED')
-        screen.render()
+        dashboard.setDisconnected()
         await PromiseUtils.sleep(1000)
         continue
       }
 
-      const response = this.sdk.client.statusStream()
+      const statusStream = this.sdk.client.statusStream()
+      const peersStream = this.sdk.client.getPeersStream()
 
-      for await (const value of response.contentStream()) {
-        statusText.clearBaseLine(0)
-        statusText.setContent(renderStatus(value))
-        screen.render()
+      await Promise.all([
+        (async () => {
+          for await (const value of statusStream.contentStream()) {
+            dashboard.updateStatus(value)
+          }
+        })(),
+        (async () => {
+          for await (const value of peersStream.contentStream()) {
+            dashboard.updatePeers(value)
+          }
+        })(),
+      ])
+    }
+  }
+
+  async waitUntil(target: 'started' | 'synced', timeout: number): Promise<StatusExitCode> {
+    const deadline = timeout > 0 ? Date.now() + timeout * 1000 : null
+    let last: StatusExitCode | null = null
+
+    for (;;) {
+      const { code, status } = await this.checkStatus(target)
+
+      if (code !== last) {
+        this.log(WAITING_MESSAGES[code])
+        last = code
+      }
+
+      if (code === STATUS_EXIT_CODES.READY) {
+        if (this.json && status) {
+          this.logJson(status)
+        }
+        return code
+      }
+
+      if (deadline !== null && Date.now() >= deadline) {
+        this.log(`Timed out after ${timeout} seconds`)
+        return code
       }
+
+      await PromiseUtils.sleep(1000)
     }
   }
+
+  async checkStatus(
+    target: 'started' | 'synced',
+  ): Promise<{ code: StatusExitCode; status?: GetStatusResponse }> {
+    // Use the socket client so this never starts a node in process
+    const connected = await this.sdk.client.tryConnect()
+    if (!connected) {
+      return { code: STATUS_EXIT_CODES.NOT_RUNNING }
+    }
+
+    let status: GetStatusResponse
+    try {
+      status = (await this.sdk.client.status()).content
+    } catch (e: unknown) {
+      if (e instanceof RpcConnectionError) {
+        return { code: STATUS_EXIT_CODES.NOT_RUNNING }
+      }
+      throw e
+    }
+
+    if (status.node.status !== 'started') {
+      return { code: STATUS_EXIT_CODES.NOT_STARTED, status }
+    }
+
+    if (target === 'synced') {
+      if (!status.peerNetwork.isReady) {
+        return { code: STATUS_EXIT_CODES.NOT_CONNECTED, status }
+      }
+
+      if (!status.blockchain.synced) {
+        return { code: STATUS_EXIT_CODES.NOT_SYNCED, status }
+      }
+    }
+
+    return { code: STATUS_EXIT_CODES.READY, status }
+  }
 }
 
 function renderStatus(content: GetStatusResponse): string {
@@ -70,6 +195,10 @@ function renderStatus(content: GetStatusResponse): string {
 
   const avgTimeToAddBlock = content.blockSyncer.syncing.blockSpeed
 
+  if (content.blockSyncer.stalled) {
+    blockSyncerStatusDetails.push('sync stalled, rotating peers')
+  }
+
   if (content.blockSyncer.status === 'syncing') {
     blockSyncerStatusDetails.push(`${content.blockSyncer.syncing.speed} blocks per seconds`)
   }
@@ -100,9 +229,29 @@ function renderStatus(content: GetStatusResponse): string {
     content.peerNetwork.peers
   }`
 
-  const blockchainStatus = `${content.blockchain.synced ? 'SYNCED' : 'NOT SYNCED'} @ HEAD ${
+  let blockchainStatus = `${content.blockchain.synced ? 'SYNCED' : 'NOT SYNCED'} @ HEAD ${
     content.blockchain.head
   }`
+  if (content.blockchain.finalizedSequence !== null) {
+    blockchainStatus += `, Finalized: ${content.blockchain.finalizedSequence}`
+  }
+  if (content.blockchain.refusedReorg) {
+    blockchainStatus += `, REFUSED REORG to ${content.blockchain.refusedReorg}`
+  }
+
+  let chainCacheStatus = 'DISABLED'
+  const caches = content.blockchain.caches.filter((c) => c.maxBytes > 0)
+  if (caches.length) {
+    const size = FileUtils.formatMemorySize(caches.reduce((s, c) => s + c.sizeBytes, 0))
+    const max = FileUtils.formatMemorySize(caches.reduce((s, c) => s + c.maxBytes, 0))
+    const hitRates = caches.map((c) => `${c.name} ${(c.hitRate * 100).toFixed(1)}%`)
+    chainCacheStatus = `${size} / ${max}, Hit Rate: ${hitRates.join(', ')}`
+  }
+
+  const propagation = content.blockPropagation
+  const blockPropagationStatus =
+    `Forward avg ${propagation.forwardTime} ms (last ${propagation.forwardTimeLast} ms), ` +
+    `Mined Ack avg ${propagation.minedAckTime} ms (last ${propagation.minedAckTimeLast} ms)`
 
   const miningDirectorStatus = `${content.miningDirector.status.toUpperCase()} - ${
     content.miningDirector.miners
@@ -110,9 +259,26 @@ function renderStatus(content: GetStatusResponse): string {
 
   const memPoolStatus = `${content.memPool.size} tx`
 
+  let accountsStatus = content.accounts.scanning
+    ? `SCANNING - ${content.accounts.sequence} / ${content.accounts.endSequence}`
+    : 'IDLE'
+  for (const account of content.accounts.scanned) {
+    if (account.behind > 0) {
+      const disabled = account.enabled ? '' : ' (scanning disabled)'
+      accountsStatus += `, ${account.name} is ${account.behind} blocks behind${disabled}`
+    }
+  }
+
   let workersStatus = `${content.workers.started ? 'STARTED' : 'STOPPED'}`
   if (content.workers.started) {
     workersStatus += ` - ${content.workers.queued} -> ${content.workers.executing} / ${content.workers.capacity} - ${content.workers.change} jobs Δ, ${content.workers.speed} jobs/s`
+    workersStatus += `, ${content.workers.workers}/${content.workers.maxWorkers} workers`
+    workersStatus += `, ${content.workers.queueLatency} ms queue latency`
+
+    if (content.workers.queues.length) {
+      const queues = content.workers.queues.map((q) => `${q.name} ${q.queued}`).join(', ')
+      workersStatus += ` (${queues})`
+    }
   }
 
   const heapTotal = FileUtils.formatMemorySize(content.memory.heapTotal)
@@ -132,15 +298,44 @@ function renderStatus(content: GetStatusResponse): string {
     100
   ).toFixed(1)}%)`
 
+  const budget = FileUtils.formatMemorySize(content.memory.budget)
+  let memoryPressureStatus = `${content.memory.pressure.toUpperCase()}, Budget: ${budget}`
+  for (const consumer of content.memory.consumers) {
+    memoryPressureStatus += `, ${consumer.name}: ${FileUtils.formatMemorySize(consumer.bytes)}`
+  }
+
+  let clockStatus = 'UNKNOWN'
+  if (content.clock.offsetMs !== null) {
+    const skew = content.clock.skewed ? 'SKEWED' : 'OK'
+    clockStatus = `${skew}, Offset: ${content.clock.offsetMs}ms`
+  }
+  if (content.clock.compensationMs) {
+    clockStatus += `, Compensating: ${content.clock.compensationMs}ms`
+  }
+
+  let diskStatus = content.disk.status.toUpperCase()
+  if (content.disk.free !== null) {
+    diskStatus += `, Free: ${FileUtils.formatFileSize(content.disk.free)}`
+  }
+  if (content.disk.status === 'critical') {
+    diskStatus += ', syncing stopped until space is freed'
+  }
+
   return `
 Version              ${content.node.version} @ ${content.node.git}
 Node                 ${nodeStatus}
 Memory               ${memoryStatus}
+Memory Pressure      ${memoryPressureStatus}
+Disk                 ${diskStatus}
+Clock                ${clockStatus}
 P2P Network          ${peerNetworkStatus}
+Propagation          ${blockPropagationStatus}
 Mining               ${miningDirectorStatus}
 Mem Pool             ${memPoolStatus}
 Syncer               ${blockSyncerStatus}
 Blockchain           ${blockchainStatus}
+Chain Cache          ${chainCacheStatus}
+Accounts             ${accountsStatus}
 Telemetry            ${telemetryStatus}
 Workers              ${workersStatus}`
 }
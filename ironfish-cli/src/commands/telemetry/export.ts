@@ -0,0 +1,87 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { TELEMETRY_CATEGORIES } from '@ironfish/sdk'
+import { Flags } from '@oclif/core'
+import { IronfishCommand } from '../../command'
+import { RemoteFlags } from '../../flags'
+
+export class ExportCommand extends IronfishCommand {
+  static description = `Write telemetry data points to a local JSONL file`
+
+  static flags = {
+    ...RemoteFlags,
+    path: Flags.string({
+      char: 'p',
+      parse: (input: string): Promise<string> => Promise.resolve(input.trim()),
+      required: false,
+      description: 'the file to append telemetry to, defaults to the data dir',
+    }),
+    categories: Flags.string({
+      parse: (input: string): Promise<string> => Promise.resolve(input.trim()),
+      required: false,
+      description: `comma separated categories to collect: ${TELEMETRY_CATEGORIES.join(', ')}`,
+    }),
+    only: Flags.boolean({
+      default: false,
+      description: 'only export locally and stop submitting to the telemetry API',
+    }),
+    stop: Flags.boolean({
+      default: false,
+      description: 'stop exporting telemetry to a file',
+    }),
+    local: Flags.boolean({
+      default: false,
+      description: 'dont connect to the node when updating the config',
+    }),
+  }
+
+  static examples = [
+    '$ ironfish telemetry:export',
+    '$ ironfish telemetry:export --path ~/telemetry.jsonl --categories performance,mining',
+    '$ ironfish telemetry:export --only',
+    '$ ironfish telemetry:export --stop',
+  ]
+
+  async start(): Promise<void> {
+    const { flags } = await this.parse(ExportCommand)
+
+    const client = await this.sdk.connectRpc(flags.local)
+
+    if (flags.stop) {
+      await client.setConfig({ name: 'telemetryExportPath', value: '' })
+      this.log('Stopped exporting telemetry')
+      this.exit(0)
+    }
+
+    if (flags.categories !== undefined) {
+      const categories = flags.categories.split(',').map((c) => c.trim())
+      const expected = TELEMETRY_CATEGORIES.join(', ')
+
+      for (const category of categories) {
+        if (!(TELEMETRY_CATEGORIES as string[]).includes(category)) {
+          this.error(`Unknown telemetry category ${category}, expected ${expected}`)
+        }
+      }
+
+      await client.setConfig({ name: 'telemetryCategories', value: categories })
+    }
+
+    if (flags.only) {
+      await client.setConfig({ name: 'enableTelemetry', value: false })
+    }
+
+    const path = this.sdk.fileSystem.resolve(
+      flags.path ?? this.sdk.fileSystem.join(this.sdk.config.dataDir, 'telemetry.jsonl'),
+    )
+
+    await client.setConfig({ name: 'telemetryExportPath', value: path })
+
+    this.log(`Exporting telemetry to ${path}`)
+    if (flags.only) {
+      this.log('Telemetry will no longer be submitted to the telemetry API')
+    }
+
+    this.exit(0)
+  }
+}
@@ -4,7 +4,14 @@
 import { WebApi } from '@ironfish/sdk'
 import { CliUx, Flags } from '@oclif/core'
 import { IronfishCommand } from '../command'
-import { DataDirFlag, DataDirFlagKey, VerboseFlag, VerboseFlagKey } from '../flags'
+import {
+  DataDirFlag,
+  DataDirFlagKey,
+  ProfileFlag,
+  ProfileFlagKey,
+  VerboseFlag,
+  VerboseFlagKey,
+} from '../flags'
 import { ENABLE_TELEMETRY_CONFIG_KEY } from './start'
 
 export default class Testnet extends IronfishCommand {
@@ -14,6 +21,7 @@ export default class Testnet extends IronfishCommand {
   static flags = {
     [VerboseFlagKey]: VerboseFlag,
     [DataDirFlagKey]: DataDirFlag,
+    [ProfileFlagKey]: ProfileFlag,
     confirm: Flags.boolean({
       default: false,
       description: 'confirm without asking',
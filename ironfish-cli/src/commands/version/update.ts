@@ -0,0 +1,106 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { ErrorUtils, RELEASE_CHANNELS, ReleaseChannel } from '@ironfish/sdk'
+import { CliUx, Flags } from '@oclif/core'
+import { IronfishCommand } from '../../command'
+import {
+  ConfigFlag,
+  ConfigFlagKey,
+  DataDirFlag,
+  DataDirFlagKey,
+  VerboseFlag,
+  VerboseFlagKey,
+  YesFlag,
+  YesFlagKey,
+} from '../../flags'
+import { IronfishCliPKG } from '../../package'
+import { Updater } from '../../utils'
+
+export default class VersionUpdate extends IronfishCommand {
+  static description = `Update the CLI to the latest release
+
+The update is verified against the release signing key and used the next time
+the node starts. If it crashes on startup the previous version is restored.`
+
+  static flags = {
+    [VerboseFlagKey]: VerboseFlag,
+    [ConfigFlagKey]: ConfigFlag,
+    [DataDirFlagKey]: DataDirFlag,
+    [YesFlagKey]: YesFlag,
+    check: Flags.boolean({
+      default: false,
+      description: 'only check if an update is available',
+    }),
+    channel: Flags.string({
+      options: [...RELEASE_CHANNELS],
+      description: 'the release channel to update from, defaults to the releaseChannel config',
+    }),
+    rollback: Flags.boolean({
+      default: false,
+      description: 'go back to the version used before the last update',
+    }),
+  }
+
+  static examples = [
+    '$ ironfish version:update',
+    '$ ironfish version:update --check',
+    '$ ironfish version:update --channel beta',
+    '$ ironfish version:update --rollback',
+  ]
+
+  async start(): Promise<void> {
+    const { flags } = await this.parse(VersionUpdate)
+
+    const updater = new Updater({ files: this.sdk.fileSystem, logger: this.logger })
+
+    if (flags.rollback) {
+      try {
+        const version = await updater.rollback()
+        this.log(`Rolled back to ${version}`)
+      } catch (e: unknown) {
+        this.log(ErrorUtils.renderError(e))
+        this.exit(1)
+      }
+      return
+    }
+
+    if (this.sdk.config.get('autoUpdate') === 'off' && !flags.check) {
+      this.log(`Updates are turned off, set autoUpdate to manual or auto to update`)
+      this.exit(1)
+    }
+
+    const channel =
+      (flags.channel as ReleaseChannel | undefined) ?? this.sdk.config.get('releaseChannel')
+
+    CliUx.ux.action.start(`Checking for updates on the ${channel} channel`)
+    const version = await updater.check(channel)
+    CliUx.ux.action.stop()
+
+    if (!version) {
+      this.log(`You are running the latest version, ${IronfishCliPKG.version}`)
+      return
+    }
+
+    const current = IronfishCliPKG.version
+    this.log(`Version ${version.version} is available, you are running ${current}`)
+
+    if (flags.check) {
+      return
+    }
+
+    const confirmed = await this.confirm(`Install ${version.version}? (Y/N)`)
+    if (!confirmed) {
+      this.exit(0)
+    }
+
+    try {
+      await updater.install(version)
+    } catch (e: unknown) {
+      this.log(`Could not update: ${ErrorUtils.renderError(e)}`)
+      this.exit(1)
+    }
+
+    this.log(`Updated to ${version.version}, restart the node to use it`)
+  }
+}
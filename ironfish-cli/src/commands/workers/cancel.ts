@@ -0,0 +1,36 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { parseNumber } from '../../args'
+import { IronfishCommand } from '../../command'
+import { RemoteFlags } from '../../flags'
+
+export class CancelCommand extends IronfishCommand {
+  static description = 'Cancel a queued or executing worker pool job'
+
+  static args = [
+    {
+      name: 'id',
+      parse: (input: string): Promise<number | null> => Promise.resolve(parseNumber(input)),
+      required: true,
+      description: 'id of the job, see workers:jobs',
+    },
+  ]
+
+  static flags = {
+    ...RemoteFlags,
+  }
+
+  async start(): Promise<void> {
+    const { args } = await this.parse(CancelCommand)
+    const id = args.id as number | null
+
+    if (id === null || !Number.isInteger(id)) {
+      this.error('The job id must be an integer')
+    }
+
+    const client = await this.sdk.connectRpc()
+    await client.cancelWorkerJob({ id })
+    this.log(`Cancelled job ${id}`)
+  }
+}
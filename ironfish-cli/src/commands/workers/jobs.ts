@@ -0,0 +1,46 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { CliUx } from '@oclif/core'
+import { IronfishCommand } from '../../command'
+import { RemoteFlags } from '../../flags'
+
+export class JobsCommand extends IronfishCommand {
+  static description = 'List the queued and executing jobs in the worker pool'
+
+  static flags = {
+    ...RemoteFlags,
+  }
+
+  async start(): Promise<void> {
+    await this.parse(JobsCommand)
+
+    const client = await this.sdk.connectRpc()
+    const response = await client.getWorkerJobs()
+    const now = Date.now()
+
+    CliUx.ux.table(response.content.jobs, {
+      id: {
+        header: 'ID',
+      },
+      name: {
+        header: 'Job',
+      },
+      status: {
+        header: 'Status',
+      },
+      queued: {
+        header: 'Queued (s)',
+        get: (row) => ((now - row.queuedAt) / 1000).toFixed(1),
+      },
+      executing: {
+        header: 'Executing (s)',
+        get: (row) => (row.startedAt ? ((now - row.startedAt) / 1000).toFixed(1) : '-'),
+      },
+      timeout: {
+        header: 'Timeout (s)',
+        get: (row) => (row.timeout ? (row.timeout / 1000).toFixed(0) : '-'),
+      },
+    })
+  }
+}
@@ -16,14 +16,19 @@ type CompletableOptionFlag = Interfaces.CompletableOptionFlag<unknown>
 export const VerboseFlagKey = 'verbose'
 export const ConfigFlagKey = 'config'
 export const ColorFlagKey = 'color'
+export const JsonFlagKey = 'json'
 export const DataDirFlagKey = 'datadir'
+export const ProfileFlagKey = 'profile'
 export const DatabaseFlagKey = 'database'
+export const NetworkFlagKey = 'network'
 export const RpcUseIpcFlagKey = 'rpc.ipc'
 export const RpcUseTcpFlagKey = 'rpc.tcp'
 export const RpcTcpHostFlagKey = 'rpc.tcp.host'
 export const RpcTcpPortFlagKey = 'rpc.tcp.port'
 export const RpcTcpSecureFlagKey = 'rpc.tcp.secure'
 export const RpcTcpTlsFlagKey = 'rpc.tcp.tls'
+export const RpcAuthFlagKey = 'rpc.auth'
+export const YesFlagKey = 'yes'
 
 export const VerboseFlag = Flags.boolean({
   char: 'v',
@@ -37,6 +42,14 @@ export const ColorFlag = Flags.boolean({
   description: 'should colorize the output',
 })
 
+/**
+ * Add this flag to commands that write their result with `IronfishCommand.logJson`
+ */
+export const JsonFlag = Flags.boolean({
+  default: false,
+  description: 'output machine readable JSON to stdout and other text to stderr',
+})
+
 export const ConfigFlag = Flags.string({
   default: DEFAULT_CONFIG_NAME,
   description: 'the name of the config file to use',
@@ -47,12 +60,21 @@ export const DataDirFlag = Flags.string({
   description: 'the path to the data dir',
 })
 
+export const ProfileFlag = Flags.string({
+  env: 'IRONFISH_PROFILE',
+  description: 'the name of the profile in the data dir to use',
+})
+
 export const DatabaseFlag = Flags.string({
   char: 'd',
   default: DEFAULT_DATABASE_NAME,
   description: 'the name of the database to use',
 })
 
+export const NetworkFlag = Flags.string({
+  description: 'the network to join: testnet, devnet, or a path to a network definition file',
+})
+
 export const RpcUseIpcFlag = Flags.boolean({
   default: DEFAULT_USE_RPC_IPC,
   description: 'connect to the RPC over IPC (default)',
@@ -82,11 +104,29 @@ export const RpcTcpTlsFlag = Flags.boolean({
   allowNo: true,
 })
 
+export const RpcAuthFlag = Flags.string({
+  env: 'IRONFISH_RPC_AUTH',
+  description: 'the token to authenticate with when connecting to the RPC over TCP',
+})
+
+/**
+ * Add this flag to commands that ask for confirmation before doing something
+ * destructive, and confirm with `IronfishCommand.confirm`
+ */
+export const YesFlag = Flags.boolean({
+  char: 'y',
+  env: 'IRONFISH_ASSUME_YES',
+  default: false,
+  description: 'answer yes to confirmation prompts',
+})
+
 const localFlags: Record<string, CompletableOptionFlag> = {}
 localFlags[VerboseFlagKey] = VerboseFlag as unknown as CompletableOptionFlag
 localFlags[ConfigFlagKey] = ConfigFlag as unknown as CompletableOptionFlag
 localFlags[DataDirFlagKey] = DataDirFlag as unknown as CompletableOptionFlag
+localFlags[ProfileFlagKey] = ProfileFlag as unknown as CompletableOptionFlag
 localFlags[DatabaseFlagKey] = DatabaseFlag as unknown as CompletableOptionFlag
+localFlags[NetworkFlagKey] = NetworkFlag as unknown as CompletableOptionFlag
 
 /**
  * These flags should usually be used on any command that starts a node,
@@ -98,12 +138,14 @@ const remoteFlags: Record<string, CompletableOptionFlag> = {}
 remoteFlags[VerboseFlagKey] = VerboseFlag as unknown as CompletableOptionFlag
 remoteFlags[ConfigFlagKey] = ConfigFlag as unknown as CompletableOptionFlag
 remoteFlags[DataDirFlagKey] = DataDirFlag as unknown as CompletableOptionFlag
+remoteFlags[ProfileFlagKey] = ProfileFlag as unknown as CompletableOptionFlag
 remoteFlags[RpcUseTcpFlagKey] = RpcUseTcpFlag as unknown as CompletableOptionFlag
 remoteFlags[RpcUseIpcFlagKey] = RpcUseIpcFlag as unknown as CompletableOptionFlag
 remoteFlags[RpcTcpHostFlagKey] = RpcTcpHostFlag as unknown as CompletableOptionFlag
 remoteFlags[RpcTcpPortFlagKey] = RpcTcpPortFlag as unknown as CompletableOptionFlag
 remoteFlags[RpcTcpSecureFlagKey] = RpcTcpSecureFlag as unknown as CompletableOptionFlag
 remoteFlags[RpcTcpTlsFlagKey] = RpcTcpTlsFlag as unknown as CompletableOptionFlag
+remoteFlags[RpcAuthFlagKey] = RpcAuthFlag as unknown as CompletableOptionFlag
 
 /**
  * These flags should usually be used on any command that uses an
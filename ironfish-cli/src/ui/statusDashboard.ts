@@ -0,0 +1,283 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { FileUtils, GetPeersResponse, GetStatusResponse } from '@ironfish/sdk'
+import blessed from 'blessed'
+import { renderSparkline } from '../utils'
+
+type PeerResponse = GetPeersResponse['peers'][0]
+
+type PeerColumn = {
+  header: string
+  width: number
+  get: (peer: PeerResponse) => string | number
+}
+
+const PEER_COLUMNS: PeerColumn[] = [
+  { header: 'IDENTITY', width: 46, get: (p) => p.identity || '-' },
+  { header: 'NAME', width: 16, get: (p) => p.name || '-' },
+  { header: 'SEQ', width: 9, get: (p) => p.sequence ?? 0 },
+  { header: 'AGENT', width: 24, get: (p) => p.agent || '-' },
+  {
+    header: 'ADDRESS',
+    width: 22,
+    get: (p) => (p.address ? `${p.address}${p.port ? `:${p.port}` : ''}` : '-'),
+  },
+  { header: 'CONN', width: 5, get: (p) => p.connections },
+]
+
+const GRAPHS: { label: string; get: (s: GetStatusResponse) => number; format: string }[] = [
+  { label: 'Block add ms', get: (s) => s.blockSyncer.syncing?.blockSpeed ?? 0, format: 'ms' },
+  { label: 'Peers', get: (s) => s.peerNetwork.peers, format: '' },
+  { label: 'Mem pool', get: (s) => s.memPool.size, format: '' },
+  { label: 'Heap used', get: (s) => s.memory.heapUsed, format: 'bytes' },
+  { label: 'Inbound', get: (s) => s.peerNetwork.inboundTraffic, format: 'bytes/s' },
+  { label: 'Outbound', get: (s) => s.peerNetwork.outboundTraffic, format: 'bytes/s' },
+]
+
+/**
+ * A full screen dashboard for `status --follow` that shows panels for each
+ * node component, sparkline graphs of recent values, and a sortable peer table
+ */
+export class StatusDashboard {
+  readonly screen: blessed.Widgets.Screen
+  readonly historyLength: number
+
+  private readonly nodePanel: blessed.Widgets.BoxElement
+  private readonly syncPanel: blessed.Widgets.BoxElement
+  private readonly peersPanel: blessed.Widgets.BoxElement
+  private readonly memPoolPanel: blessed.Widgets.BoxElement
+  private readonly accountsPanel: blessed.Widgets.BoxElement
+  private readonly minersPanel: blessed.Widgets.BoxElement
+  private readonly workersPanel: blessed.Widgets.BoxElement
+  private readonly graphsPanel: blessed.Widgets.BoxElement
+  private readonly peerTable: blessed.Widgets.BoxElement
+
+  private history: number[][] = GRAPHS.map(() => [])
+  private peers: PeerResponse[] = []
+  private sortColumn: number
+  private sortDescending = false
+
+  constructor(options: { sort?: string; historyLength?: number } = {}) {
+    this.historyLength = options.historyLength ?? 120
+
+    const sortColumn = PEER_COLUMNS.findIndex((c) => c.header === options.sort?.toUpperCase())
+    this.sortColumn = sortColumn === -1 ? 0 : sortColumn
+
+    this.screen = blessed.screen({ smartCSR: true, title: 'Iron Fish Status' })
+
+    this.nodePanel = this.createPanel('Node', 0, 0, '40%', 7)
+    this.syncPanel = this.createPanel('Sync', 0, '40%', '30%', 7)
+    this.peersPanel = this.createPanel('Network', 0, '70%', '30%', 7)
+    this.memPoolPanel = this.createPanel('Mem Pool', 7, 0, '20%', 5)
+    this.accountsPanel = this.createPanel('Wallet Scan', 7, '20%', '20%', 5)
+    this.minersPanel = this.createPanel('Miners', 7, '40%', '20%', 5)
+    this.workersPanel = this.createPanel('Workers', 7, '60%', '40%', 5)
+
+    const graphsHeight = GRAPHS.length + 2
+    this.graphsPanel = this.createPanel('Graphs', 12, 0, '100%', graphsHeight)
+
+    const tableTop = 12 + graphsHeight
+    this.peerTable = this.createPanel(
+      'Peers (s: sort, r: reverse, q: quit)',
+      tableTop,
+      0,
+      '100%',
+      `100%-${tableTop}`,
+    )
+
+    this.screen.key('s', () => {
+      this.sortColumn = (this.sortColumn + 1) % PEER_COLUMNS.length
+      this.renderPeers()
+    })
+
+    this.screen.key('r', () => {
+      this.sortDescending = !this.sortDescending
+      this.renderPeers()
+    })
+  }
+
+  onQuit(handler: () => void): void {
+    this.screen.key(['q', 'escape', 'C-c'], handler)
+  }
+
+  setDisconnected(): void {
+    this.nodePanel.setContent('Node: STOPPED')
+    this.screen.render()
+  }
+
+  updateStatus(status: GetStatusResponse): void {
+    for (const [index, graph] of GRAPHS.entries()) {
+      const history = this.history[index]
+      history.push(graph.get(status))
+      if (history.length > this.historyLength) {
+        history.shift()
+      }
+    }
+
+    this.nodePanel.setContent(renderNode(status))
+    this.syncPanel.setContent(renderSync(status))
+    this.peersPanel.setContent(renderNetwork(status))
+    this.memPoolPanel.setContent(`Size: ${status.memPool.size} tx`)
+    this.accountsPanel.setContent(renderAccounts(status))
+    this.minersPanel.setContent(renderMiners(status))
+    this.workersPanel.setContent(renderWorkers(status))
+    this.renderGraphs()
+    this.screen.render()
+  }
+
+  updatePeers(response: GetPeersResponse): void {
+    this.peers = response.peers.filter((p) => p.state === 'CONNECTED')
+    this.renderPeers()
+  }
+
+  private renderGraphs(): void {
+    const width = Math.max(10, Number(this.graphsPanel.width) - 40)
+
+    const lines = GRAPHS.map((graph, index) => {
+      const history = this.history[index]
+      const last = history.length ? history[history.length - 1] : 0
+      const value = formatValue(last, graph.format).padStart(12)
+      return `${graph.label.padEnd(14)} ${value} ${renderSparkline(history, width)}`
+    })
+
+    this.graphsPanel.setContent(lines.join('\n'))
+  }
+
+  private renderPeers(): void {
+    const column = PEER_COLUMNS[this.sortColumn]
+    const direction = this.sortDescending ? -1 : 1
+
+    const sorted = [...this.peers].sort((a, b) => {
+      const left = column.get(a)
+      const right = column.get(b)
+
+      if (typeof left === 'number' && typeof right === 'number') {
+        return (left - right) * direction
+      }
+
+      return String(left).localeCompare(String(right)) * direction
+    })
+
+    const header = PEER_COLUMNS.map((c, index) => {
+      const arrow = index === this.sortColumn ? (this.sortDescending ? '▼' : '▲') : ''
+      return `${c.header}${arrow}`.padEnd(c.width)
+    }).join(' ')
+
+    const rows = sorted.map((peer) =>
+      PEER_COLUMNS.map((c) => String(c.get(peer)).slice(0, c.width).padEnd(c.width)).join(' '),
+    )
+
+    this.peerTable.setContent([header, ...rows].join('\n'))
+    this.screen.render()
+  }
+
+  private createPanel(
+    label: string,
+    top: number,
+    left: number | string,
+    width: string,
+    height: number | string,
+  ): blessed.Widgets.BoxElement {
+    return blessed.box({
+      parent: this.screen,
+      label: ` ${label} `,
+      border: { type: 'line' },
+      padding: { left: 1, right: 1 },
+      top,
+      left,
+      width,
+      height,
+    })
+  }
+}
+
+function formatValue(value: number, format: string): string {
+  if (format === 'bytes') {
+    return FileUtils.formatMemorySize(value)
+  }
+  if (format === 'bytes/s') {
+    return `${FileUtils.formatFileSize(value)}/s`
+  }
+  return `${value.toFixed(format === 'ms' ? 1 : 0)}${format}`
+}
+
+function renderNode(status: GetStatusResponse): string {
+  const heapUsed = FileUtils.formatMemorySize(status.memory.heapUsed)
+  const heapMax = FileUtils.formatMemorySize(status.memory.heapMax)
+  const rss = FileUtils.formatMemorySize(status.memory.rss)
+  const heapPercent = ((status.memory.heapUsed / status.memory.heapMax) * 100).toFixed(1)
+
+  return [
+    `Status:    ${status.node.status.toUpperCase()}`,
+    `Version:   ${status.node.version} @ ${status.node.git}`,
+    `Heap:      ${heapUsed} / ${heapMax} (${heapPercent}%)`,
+    `RSS:       ${rss}`,
+    `Telemetry: ${status.telemetry.status.toUpperCase()}`,
+  ].join('\n')
+}
+
+function renderSync(status: GetStatusResponse): string {
+  const syncing = status.blockSyncer.syncing
+  const progress = syncing ? `${(syncing.progress * 100).toFixed(2)}%` : '-'
+
+  return [
+    `Chain:    ${status.blockchain.synced ? 'SYNCED' : 'NOT SYNCED'}`,
+    `Syncer:   ${status.blockSyncer.status.toUpperCase()}`,
+    `Progress: ${progress}`,
+    `Speed:    ${syncing?.speed ?? 0} blocks/s`,
+    `Head:     ${status.blockchain.head}`,
+  ].join('\n')
+}
+
+function renderNetwork(status: GetStatusResponse): string {
+  const network = status.peerNetwork
+
+  return [
+    `Status:   ${network.isReady ? 'CONNECTED' : 'WAITING'}`,
+    `Peers:    ${network.peers}`,
+    `In:       ${FileUtils.formatFileSize(network.inboundTraffic)}/s`,
+    `Out:      ${FileUtils.formatFileSize(network.outboundTraffic)}/s`,
+    `Forward:  ${status.blockPropagation.forwardTime} ms`,
+  ].join('\n')
+}
+
+function renderAccounts(status: GetStatusResponse): string {
+  const accounts = status.accounts
+
+  if (!accounts.scanning) {
+    return 'Status: IDLE'
+  }
+
+  const progress = accounts.endSequence
+    ? `${((accounts.sequence / accounts.endSequence) * 100).toFixed(1)}%`
+    : '-'
+
+  return [
+    `Status: SCANNING ${progress}`,
+    `Block:  ${accounts.sequence} / ${accounts.endSequence}`,
+  ].join('\n')
+}
+
+function renderMiners(status: GetStatusResponse): string {
+  return [
+    `Miners: ${status.miningDirector.miners}`,
+    `Mined:  ${status.miningDirector.blocks} blocks`,
+  ].join('\n')
+}
+
+function renderWorkers(status: GetStatusResponse): string {
+  const workers = status.workers
+
+  if (!workers.started) {
+    return 'Status: STOPPED'
+  }
+
+  const queues = workers.queues.map((q) => `${q.name} ${q.queued}`).join(', ')
+
+  return [
+    `Jobs:    ${workers.queued} -> ${workers.executing} / ${workers.capacity}`,
+    `Workers: ${workers.workers} / ${workers.maxWorkers}, ${workers.queueLatency} ms wait`,
+    `Queues:  ${queues || '-'}`,
+  ].join('\n')
+}
@@ -0,0 +1,79 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { parseCpuList, setCpuAffinity } from './affinity'
+import { runCommand } from './service'
+
+jest.mock('./service')
+
+describe('parseCpuList', () => {
+  it('parses single CPUs and ranges', () => {
+    expect(parseCpuList('0')).toEqual([0])
+    expect(parseCpuList('0,2-3')).toEqual([0, 2, 3])
+    expect(parseCpuList('4-6,1')).toEqual([1, 4, 5, 6])
+  })
+
+  it('removes duplicate CPUs', () => {
+    expect(parseCpuList('1-3,2,3-4')).toEqual([1, 2, 3, 4])
+  })
+
+  it('rejects malformed lists', () => {
+    for (const list of ['', 'a', '1,', ',1', '1-', '1--2', '1 2', '-1']) {
+      expect(() => parseCpuList(list)).toThrow('Invalid CPU list')
+    }
+  })
+
+  it('rejects ranges that end before they start', () => {
+    expect(() => parseCpuList('0,3-1')).toThrow("Invalid CPU range '3-1'")
+  })
+})
+
+describe('setCpuAffinity', () => {
+  const runCommandMock = runCommand as jest.Mock
+  const platform = process.platform
+
+  const setPlatform = (value: string) =>
+    Object.defineProperty(process, 'platform', { value, configurable: true })
+
+  beforeEach(() => {
+    runCommandMock.mockReset()
+    setPlatform('linux')
+  })
+
+  afterEach(() => {
+    setPlatform(platform)
+  })
+
+  it('pins every thread of the process with taskset', async () => {
+    runCommandMock.mockResolvedValue({ code: 0, stdout: '', stderr: '' })
+
+    await setCpuAffinity('0,2-3')
+
+    expect(runCommandMock).toHaveBeenCalledWith('taskset', [
+      '-a',
+      '-cp',
+      '0,2-3',
+      String(process.pid),
+    ])
+  })
+
+  it('throws when taskset fails', async () => {
+    runCommandMock.mockResolvedValue({ code: 1, stdout: '', stderr: 'Invalid argument\n' })
+
+    await expect(setCpuAffinity('64')).rejects.toThrow(
+      'Could not pin to CPUs 64: Invalid argument',
+    )
+  })
+
+  it('only supports Linux', async () => {
+    setPlatform('darwin')
+
+    await expect(setCpuAffinity('0')).rejects.toThrow('only supported on Linux')
+    expect(runCommandMock).not.toHaveBeenCalled()
+  })
+
+  it('checks the list before running taskset', async () => {
+    await expect(setCpuAffinity('0;reboot')).rejects.toThrow('Invalid CPU list')
+    expect(runCommandMock).not.toHaveBeenCalled()
+  })
+})
@@ -0,0 +1,50 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { runCommand } from './service'
+
+const CPU_LIST = /^\d+(-\d+)?(,\d+(-\d+)?)*$/
+
+/**
+ * Parse a CPU list like `0,2-3` into the CPU numbers it names
+ */
+export function parseCpuList(list: string): number[] {
+  if (!CPU_LIST.test(list)) {
+    throw new Error(`Invalid CPU list '${list}', expected a list like 0,2-3`)
+  }
+
+  const cpus = new Set<number>()
+
+  for (const range of list.split(',')) {
+    const [start, end] = range.split('-').map(Number)
+
+    if (end !== undefined && end < start) {
+      throw new Error(`Invalid CPU range '${range}'`)
+    }
+
+    for (let cpu = start; cpu <= (end ?? start); cpu++) {
+      cpus.add(cpu)
+    }
+  }
+
+  return [...cpus].sort((a, b) => a - b)
+}
+
+/**
+ * Pin every thread of this process to the CPUs in `list`. Threads started
+ * afterwards inherit it, so call this before starting worker threads.
+ * Only Linux is supported since it uses taskset.
+ */
+export async function setCpuAffinity(list: string): Promise<void> {
+  parseCpuList(list)
+
+  if (process.platform !== 'linux') {
+    throw new Error('Pinning to CPUs is only supported on Linux')
+  }
+
+  const result = await runCommand('taskset', ['-a', '-cp', list, String(process.pid)])
+
+  if (result.code !== 0) {
+    throw new Error(`Could not pin to CPUs ${list}: ${(result.stderr || result.stdout).trim()}`)
+  }
+}
@@ -0,0 +1,58 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { Blockchain } from '@ironfish/sdk'
+import { findBlockHeader } from './chain'
+
+describe('findBlockHeader', () => {
+  const header = { sequence: 5 }
+
+  const chain = {
+    head: { sequence: 10 },
+    getHeaderAtSequence: jest.fn().mockResolvedValue(header),
+    getHeader: jest.fn().mockResolvedValue(header),
+  }
+
+  const search = (input: string) => findBlockHeader(chain as unknown as Blockchain, input)
+
+  beforeEach(() => {
+    chain.getHeaderAtSequence.mockClear()
+    chain.getHeader.mockClear()
+  })
+
+  it('finds a block by sequence', async () => {
+    await expect(search(' 5 ')).resolves.toBe(header)
+    expect(chain.getHeaderAtSequence).toHaveBeenCalledWith(5)
+    expect(chain.getHeader).not.toHaveBeenCalled()
+  })
+
+  it('counts negative sequences back from the head', async () => {
+    await search('-1')
+    expect(chain.getHeaderAtSequence).toHaveBeenLastCalledWith(10)
+
+    await search('-3')
+    expect(chain.getHeaderAtSequence).toHaveBeenLastCalledWith(8)
+  })
+
+  it('does not count back past the genesis block', async () => {
+    await search('-100')
+    expect(chain.getHeaderAtSequence).toHaveBeenCalledWith(1)
+  })
+
+  it('finds a block by hash', async () => {
+    const hash = 'ab'.repeat(32)
+
+    await expect(search(hash)).resolves.toBe(header)
+    expect(chain.getHeader).toHaveBeenCalledWith(Buffer.from(hash, 'hex'))
+    expect(chain.getHeaderAtSequence).not.toHaveBeenCalled()
+  })
+
+  it('returns null for input that is not a sequence or a hash', async () => {
+    await expect(search('')).resolves.toBeNull()
+    await expect(search('abc')).resolves.toBeNull()
+    await expect(search('zz'.repeat(32))).resolves.toBeNull()
+
+    expect(chain.getHeader).not.toHaveBeenCalled()
+    expect(chain.getHeaderAtSequence).not.toHaveBeenCalled()
+  })
+})
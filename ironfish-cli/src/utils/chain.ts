@@ -0,0 +1,32 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { Blockchain, BlockHeader, GENESIS_BLOCK_SEQUENCE } from '@ironfish/sdk'
+
+/**
+ * Finds a block header in the local chain by hash or sequence. Negative
+ * sequences count back from the head, so -1 is the head block.
+ */
+export async function findBlockHeader(
+  chain: Blockchain,
+  search: string,
+): Promise<BlockHeader | null> {
+  search = search.trim()
+  const sequence = Number(search)
+
+  if (search && Number.isInteger(sequence)) {
+    if (sequence < 0) {
+      return chain.getHeaderAtSequence(
+        Math.max(chain.head.sequence + sequence + 1, GENESIS_BLOCK_SEQUENCE),
+      )
+    }
+
+    return chain.getHeaderAtSequence(sequence)
+  }
+
+  if (!/^[0-9a-fA-F]{64}$/.test(search)) {
+    return null
+  }
+
+  return chain.getHeader(Buffer.from(search, 'hex'))
+}
@@ -0,0 +1,153 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { Interfaces } from '@oclif/core'
+import {
+  COMPLETION_SHELLS,
+  CompletionCommand,
+  getCompletionCommands,
+  renderCompletion,
+} from './completion'
+
+describe('getCompletionCommands', () => {
+  const config = {
+    commands: [
+      {
+        id: 'accounts:use',
+        description: 'Change the default account\nUsed when no account is given',
+        flags: {},
+        args: [{ name: 'name' }],
+      },
+      {
+        id: 'accounts:pay',
+        description: 'Send coins',
+        flags: {
+          to: { type: 'option', char: 't', description: 'the address' },
+          account: { type: 'option', char: 'f', description: 'the account to send from' },
+          secret: { type: 'boolean', hidden: true, description: 'hidden' },
+          confirm: { type: 'boolean', description: 'skip the prompt' },
+        },
+        args: [],
+      },
+      {
+        id: 'debug',
+        hidden: true,
+        description: 'internal',
+        flags: {},
+        args: [],
+      },
+    ],
+  } as unknown as Interfaces.Config
+
+  it('lists visible commands and flags in order', () => {
+    const commands = getCompletionCommands(config)
+
+    expect(commands.map((c) => c.id)).toEqual(['accounts:pay', 'accounts:use'])
+    expect(commands[0]).toEqual({
+      id: 'accounts:pay',
+      description: 'Send coins',
+      accountArg: false,
+      flags: [
+        {
+          name: 'account',
+          char: 'f',
+          description: 'the account to send from',
+          hasValue: true,
+          account: true,
+        },
+        {
+          name: 'confirm',
+          char: undefined,
+          description: 'skip the prompt',
+          hasValue: false,
+          account: false,
+        },
+        { name: 'to', char: 't', description: 'the address', hasValue: true, account: false },
+      ],
+    })
+  })
+
+  it('only uses the first line of descriptions', () => {
+    const [, use] = getCompletionCommands(config)
+    expect(use.description).toEqual('Change the default account')
+  })
+
+  it('completes account names for the first argument of account commands', () => {
+    const [, use] = getCompletionCommands(config)
+    expect(use.accountArg).toBe(true)
+  })
+})
+
+describe('renderCompletion', () => {
+  const commands: CompletionCommand[] = [
+    {
+      id: 'accounts:pay',
+      description: "Send coins: it's fast",
+      accountArg: false,
+      flags: [
+        {
+          name: 'account',
+          char: 'f',
+          description: 'the [account] to use',
+          hasValue: true,
+          account: true,
+        },
+        { name: 'yes', description: 'skip the prompt', hasValue: false, account: false },
+      ],
+    },
+    {
+      id: 'accounts:use',
+      description: 'Change the default account',
+      accountArg: true,
+      flags: [],
+    },
+  ]
+
+  it.each(COMPLETION_SHELLS)('renders every command and flag for %s', (shell) => {
+    const script = renderCompletion(shell, 'ironfish', commands)
+
+    expect(script).toContain('accounts:pay')
+    expect(script).toContain('accounts:use')
+    expect(script).toContain('yes')
+    expect(script).toContain('ironfish autocomplete --accounts')
+  })
+
+  it('renders bash cases with the account flags of each command', () => {
+    const script = renderCompletion('bash', 'ironfish', commands)
+
+    expect(script).toContain('complete -o default -F _ironfish ironfish')
+    expect(script).toContain(
+      [
+        '    accounts:pay)',
+        '      flags="--account --yes"',
+        '      accountFlags="--account"',
+        '      accountArg=0',
+      ].join('\n'),
+    )
+    expect(script).toContain('    accounts:use)\n      flags=""\n      accountFlags=""')
+  })
+
+  it('escapes descriptions for zsh', () => {
+    const script = renderCompletion('zsh', 'ironfish', commands)
+
+    expect(script).toContain(`'accounts\\:pay:Send coins\\: it'\\''s fast'`)
+    expect(script).toContain(`'--account[the \\[account\\] to use]:account:_ironfish_accounts'`)
+    expect(script).toContain(`'1:account:_ironfish_accounts'`)
+  })
+
+  it('escapes descriptions for fish', () => {
+    const script = renderCompletion('fish', 'ironfish', commands)
+
+    expect(script).toContain(`-a 'accounts:pay' -d 'Send coins: it\\'s fast'`)
+    expect(script).toContain(
+      `-l 'account' -s f -r -a '(__ironfish_accounts)' -d 'the [account] to use'`,
+    )
+  })
+
+  it('lists the flags of each command for powershell', () => {
+    const script = renderCompletion('powershell', 'ironfish', commands)
+
+    expect(script).toContain(`    'accounts:pay' = @('--account', '--yes')`)
+    expect(script).toContain(`$accountArgs = @('accounts:use')`)
+  })
+})
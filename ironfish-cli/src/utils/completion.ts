@@ -0,0 +1,315 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { Interfaces } from '@oclif/core'
+
+export const COMPLETION_SHELLS = ['bash', 'zsh', 'fish', 'powershell'] as const
+export type CompletionShell = typeof COMPLETION_SHELLS[number]
+
+// Commands whose first argument is an account name but isn't called account
+const ACCOUNT_NAME_COMMANDS = ['accounts:remove', 'accounts:use']
+
+export type CompletionFlag = {
+  name: string
+  char?: string
+  description: string
+  hasValue: boolean
+  account: boolean
+}
+
+export type CompletionCommand = {
+  id: string
+  description: string
+  flags: CompletionFlag[]
+  accountArg: boolean
+}
+
+export function getCompletionCommands(config: Interfaces.Config): CompletionCommand[] {
+  return config.commands
+    .filter((command) => !command.hidden)
+    .map((command) => {
+      const flags = Object.entries(command.flags)
+        .filter(([, flag]) => !flag.hidden)
+        .map(([name, flag]) => ({
+          name,
+          char: flag.char,
+          description: firstLine(flag.description),
+          hasValue: flag.type === 'option',
+          account: name === 'account',
+        }))
+        .sort((a, b) => a.name.localeCompare(b.name))
+
+      const firstArg = command.args[0]
+
+      return {
+        id: command.id,
+        description: firstLine(command.description),
+        flags,
+        accountArg: firstArg?.name === 'account' || ACCOUNT_NAME_COMMANDS.includes(command.id),
+      }
+    })
+    .sort((a, b) => a.id.localeCompare(b.id))
+}
+
+/**
+ * Renders a completion script for `shell` that completes command ids, flags,
+ * and account names. Account names are fetched by running
+ * `<bin> autocomplete --accounts` so they're only completed while a node is running.
+ */
+export function renderCompletion(
+  shell: CompletionShell,
+  bin: string,
+  commands: CompletionCommand[],
+): string {
+  switch (shell) {
+    case 'bash':
+      return renderBash(bin, commands)
+    case 'zsh':
+      return renderZsh(bin, commands)
+    case 'fish':
+      return renderFish(bin, commands)
+    case 'powershell':
+      return renderPowershell(bin, commands)
+  }
+}
+
+function renderBash(bin: string, commands: CompletionCommand[]): string {
+  const fn = `_${bin}`
+
+  const cases = commands.map((command) => {
+    const flags = command.flags.map((f) => `--${f.name}`).join(' ')
+    const accountFlags = command.flags
+      .filter((f) => f.account)
+      .map((f) => `--${f.name}`)
+      .join(' ')
+
+    return [
+      `    ${command.id})`,
+      `      flags="${flags}"`,
+      `      accountFlags="${accountFlags}"`,
+      `      accountArg=${command.accountArg ? 1 : 0}`,
+      `      ;;`,
+    ].join('\n')
+  })
+
+  return `# ${bin} bash completion
+# Command ids contain colons, which bash splits words on by default
+COMP_WORDBREAKS=\${COMP_WORDBREAKS//:}
+
+${fn}_accounts() {
+  ${bin} autocomplete --accounts 2>/dev/null
+}
+
+${fn}() {
+  local cur="\${COMP_WORDS[COMP_CWORD]}"
+  local prev="\${COMP_WORDS[COMP_CWORD-1]}"
+  local commands="${commands.map((c) => c.id).join(' ')}"
+  local flags="" accountFlags="" accountArg=0
+
+  if [ "$COMP_CWORD" -eq 1 ]; then
+    COMPREPLY=($(compgen -W "$commands" -- "$cur"))
+    return
+  fi
+
+  case "\${COMP_WORDS[1]}" in
+${cases.join('\n')}
+  esac
+
+  if [ -n "$accountFlags" ] && [[ " $accountFlags " == *" $prev "* ]]; then
+    COMPREPLY=($(compgen -W "$(${fn}_accounts)" -- "$cur"))
+  elif [[ "$cur" == -* ]]; then
+    COMPREPLY=($(compgen -W "$flags" -- "$cur"))
+  elif [ "$accountArg" -eq 1 ] && [ "$COMP_CWORD" -eq 2 ]; then
+    COMPREPLY=($(compgen -W "$(${fn}_accounts)" -- "$cur"))
+  fi
+}
+
+complete -o default -F ${fn} ${bin}
+`
+}
+
+function renderZsh(bin: string, commands: CompletionCommand[]): string {
+  const fn = `_${bin}`
+
+  const descriptions = commands.map(
+    (c) => `    '${escapeZshDescribe(c.id)}:${escapeZshDescribe(c.description)}'`,
+  )
+
+  const cases = commands.map((command) => {
+    const lines = [`    ${command.id})`, `      _arguments \\`]
+
+    for (const flag of command.flags) {
+      const description = escapeZshArgument(flag.description)
+      const action = flag.account ? `:account:${fn}_accounts` : flag.hasValue ? ': :' : ''
+      lines.push(`        '--${flag.name}[${description}]${action}' \\`)
+    }
+
+    if (command.accountArg) {
+      lines.push(`        '1:account:${fn}_accounts' \\`)
+    }
+
+    lines.push(`        '*::arg:_files'`, `      ;;`)
+    return lines.join('\n')
+  })
+
+  return `#compdef ${bin}
+
+${fn}_accounts() {
+  local -a accounts
+  accounts=(\${(f)"$(${bin} autocomplete --accounts 2>/dev/null)"})
+  _describe 'account' accounts
+}
+
+${fn}() {
+  local -a commands
+  commands=(
+${descriptions.join('\n')}
+  )
+
+  if (( CURRENT == 2 )); then
+    _describe 'command' commands
+    return
+  fi
+
+  local command=$words[2]
+  shift words
+  (( CURRENT-- ))
+
+  case $command in
+${cases.join('\n')}
+  esac
+}
+
+compdef ${fn} ${bin}
+`
+}
+
+function renderFish(bin: string, commands: CompletionCommand[]): string {
+  const fn = `__${bin}`
+  const lines = [
+    `# ${bin} fish completion`,
+    `function ${fn}_accounts`,
+    `  ${bin} autocomplete --accounts 2>/dev/null`,
+    `end`,
+    ``,
+    `complete -c ${bin} -f`,
+  ]
+
+  for (const command of commands) {
+    const description = escapeFish(command.description)
+    const condition = `-n '__fish_use_subcommand'`
+    lines.push(`complete -c ${bin} ${condition} -a '${command.id}' -d '${description}'`)
+  }
+
+  for (const command of commands) {
+    const condition = `-n '__fish_seen_subcommand_from ${command.id}'`
+
+    for (const flag of command.flags) {
+      let line = `complete -c ${bin} ${condition} -l '${flag.name}'`
+
+      if (flag.char) {
+        line += ` -s ${flag.char}`
+      }
+      if (flag.account) {
+        line += ` -r -a '(${fn}_accounts)'`
+      } else if (flag.hasValue) {
+        line += ' -r'
+      }
+
+      lines.push(`${line} -d '${escapeFish(flag.description)}'`)
+    }
+
+    if (command.accountArg) {
+      lines.push(`complete -c ${bin} ${condition} -a '(${fn}_accounts)'`)
+    }
+  }
+
+  return lines.join('\n') + '\n'
+}
+
+function renderPowershell(bin: string, commands: CompletionCommand[]): string {
+  const flags = commands.map((command) => {
+    const names = command.flags.map((f) => `'--${f.name}'`).join(', ')
+    return `    '${command.id}' = @(${names})`
+  })
+
+  const accountFlags = commands
+    .filter((c) => c.flags.some((f) => f.account))
+    .map((command) => {
+      const names = command.flags
+        .filter((f) => f.account)
+        .map((f) => `'--${f.name}'`)
+        .join(', ')
+      return `    '${command.id}' = @(${names})`
+    })
+
+  const accountArgs = commands
+    .filter((c) => c.accountArg)
+    .map((c) => `'${c.id}'`)
+    .join(', ')
+
+  return `# ${bin} PowerShell completion
+Register-ArgumentCompleter -Native -CommandName ${bin} -ScriptBlock {
+  param($wordToComplete, $commandAst, $cursorPosition)
+
+  $flags = @{
+${flags.join('\n')}
+  }
+
+  $accountFlags = @{
+${accountFlags.join('\n')}
+  }
+
+  $accountArgs = @(${accountArgs})
+
+  function Complete($values) {
+    $values | Where-Object { $_ -like "$wordToComplete*" } | Sort-Object | ForEach-Object {
+      [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+    }
+  }
+
+  function Accounts() {
+    ${bin} autocomplete --accounts 2>$null
+  }
+
+  $elements = @($commandAst.CommandElements | ForEach-Object { $_.ToString() })
+  $position = if ($wordToComplete) { $elements.Count - 1 } else { $elements.Count }
+
+  if ($position -le 1) {
+    Complete $flags.Keys
+    return
+  }
+
+  $command = $elements[1]
+  $previous = $elements[$position - 1]
+
+  if ($accountFlags[$command] -contains $previous) {
+    Complete (Accounts)
+  } elseif ($wordToComplete -like '-*') {
+    Complete $flags[$command]
+  } elseif ($position -eq 2 -and $accountArgs -contains $command) {
+    Complete (Accounts)
+  }
+}
+`
+}
+
+function firstLine(text: string | undefined): string {
+  return (text ?? '').split('\n')[0].trim()
+}
+
+function escapeSingleQuotes(text: string): string {
+  return text.replace(/'/g, `'\\''`)
+}
+
+function escapeFish(text: string): string {
+  return text.replace(/[\\']/g, '\\$&')
+}
+
+function escapeZshDescribe(text: string): string {
+  return escapeSingleQuotes(text).replace(/:/g, '\\:')
+}
+
+function escapeZshArgument(text: string): string {
+  return escapeSingleQuotes(text).replace(/[[\]:]/g, '\\$&')
+}
@@ -0,0 +1,53 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+const SPARKLINE_TICKS = ['▁', '▂', '▃', '▄', '▅', '▆', '▇', '█']
+
+/**
+ * Renders values as a one line ASCII sparkline, averaging values
+ * into buckets when there are more values than `width`
+ */
+export function renderSparkline(values: number[], width = 60): string {
+  if (values.length === 0) {
+    return ''
+  }
+
+  const buckets: number[] = []
+  const bucketSize = Math.max(1, values.length / width)
+
+  for (let start = 0; start < values.length; start += bucketSize) {
+    const bucket = values.slice(Math.floor(start), Math.floor(start + bucketSize))
+    if (bucket.length) {
+      buckets.push(bucket.reduce((a, b) => a + b, 0) / bucket.length)
+    }
+  }
+
+  const min = Math.min(...buckets)
+  const max = Math.max(...buckets)
+  const range = max - min
+
+  return buckets
+    .map((value) => {
+      if (range === 0) {
+        return SPARKLINE_TICKS[0]
+      }
+
+      const index = Math.round(((value - min) / range) * (SPARKLINE_TICKS.length - 1))
+      return SPARKLINE_TICKS[index]
+    })
+    .join('')
+}
+
+/**
+ * Returns the value at percentile `p` (0 - 100) using the nearest rank method
+ */
+export function percentile(values: number[], p: number): number {
+  if (values.length === 0) {
+    return 0
+  }
+
+  const sorted = [...values].sort((a, b) => a - b)
+  const rank = Math.ceil((p / 100) * sorted.length)
+  return sorted[Math.min(sorted.length, Math.max(rank, 1)) - 1]
+}
@@ -5,3 +5,10 @@ export * from './editor'
 export * from './rpc'
 export * from './terminal'
 export * from './types'
+export * from './graph'
+export * from './completion'
+export * from './chain'
+export * from './service'
+export * from './updater'
+export * from './migrate'
+export * from './affinity'
@@ -0,0 +1,123 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import fs from 'fs'
+import fsAsync from 'fs/promises'
+import os from 'os'
+import path from 'path'
+import { v4 as uuid } from 'uuid'
+import { copyFileVerified, isRemoteTarget, listDataDirFiles } from './migrate'
+
+describe('isRemoteTarget', () => {
+  it('detects rsync style remotes', () => {
+    expect(isRemoteTarget('user@host:/home/user/.ironfish')).toBe(true)
+    expect(isRemoteTarget('host:.ironfish')).toBe(true)
+    expect(isRemoteTarget('user@192.168.1.10:~/.ironfish')).toBe(true)
+  })
+
+  it('treats local and windows paths as local', () => {
+    expect(isRemoteTarget('/mnt/bigdisk/.ironfish')).toBe(false)
+    expect(isRemoteTarget('./data:old')).toBe(false)
+    expect(isRemoteTarget('C:\\ironfish')).toBe(false)
+    expect(isRemoteTarget('D:/ironfish')).toBe(false)
+  })
+})
+
+describe('migrate files', () => {
+  let dir: string
+
+  const write = async (relative: string, content: string): Promise<void> => {
+    await fsAsync.mkdir(path.dirname(path.join(dir, relative)), { recursive: true })
+    await fsAsync.writeFile(path.join(dir, relative), content)
+  }
+
+  beforeEach(async () => {
+    dir = path.join(os.tmpdir(), uuid())
+    await fsAsync.mkdir(dir, { recursive: true })
+  })
+
+  afterEach(async () => {
+    jest.restoreAllMocks()
+    await fsAsync.rm(dir, { recursive: true, force: true })
+  })
+
+  describe('listDataDirFiles', () => {
+    it('lists files recursively with their sizes', async () => {
+      await write('config.json', '{}')
+      await write('databases/chain/000001.log', 'chain')
+      await write('databases/accounts/000001.log', 'accounts!')
+
+      const files = await listDataDirFiles(dir, [])
+      files.sort((a, b) => a.path.localeCompare(b.path))
+
+      expect(files).toEqual([
+        { path: 'config.json', size: 2 },
+        { path: 'databases/accounts/000001.log', size: 9 },
+        { path: 'databases/chain/000001.log', size: 5 },
+      ])
+    })
+
+    it('skips excluded paths', async () => {
+      await write('config.json', '{}')
+      await write('databases/chain/000001.log', 'chain')
+      await write('databases/accounts/000001.log', 'accounts!')
+
+      const files = await listDataDirFiles(dir, [path.join('databases', 'chain')])
+
+      expect(files.map((f) => f.path).sort()).toEqual([
+        'config.json',
+        'databases/accounts/000001.log',
+      ])
+    })
+  })
+
+  describe('copyFileVerified', () => {
+    it('copies a file into missing directories', async () => {
+      await write('source/a.txt', 'hello')
+      const destination = path.join(dir, 'target', 'nested', 'a.txt')
+
+      expect(await copyFileVerified(path.join(dir, 'source/a.txt'), destination)).toBe(true)
+
+      expect(await fsAsync.readFile(destination, 'utf8')).toEqual('hello')
+      expect(fs.existsSync(`${destination}.partial`)).toBe(false)
+    })
+
+    it('skips files that were already copied', async () => {
+      await write('source/a.txt', 'hello')
+      const source = path.join(dir, 'source/a.txt')
+      const destination = path.join(dir, 'target/a.txt')
+      await copyFileVerified(source, destination)
+
+      const copyFile = jest.spyOn(fsAsync, 'copyFile')
+
+      expect(await copyFileVerified(source, destination)).toBe(false)
+      expect(copyFile).not.toHaveBeenCalled()
+    })
+
+    it('copies again over a destination that does not match', async () => {
+      await write('source/a.txt', 'hello')
+      await write('target/a.txt', 'hellp')
+
+      const destination = path.join(dir, 'target/a.txt')
+
+      expect(await copyFileVerified(path.join(dir, 'source/a.txt'), destination)).toBe(true)
+      expect(await fsAsync.readFile(destination, 'utf8')).toEqual('hello')
+    })
+
+    it('throws and removes the partial copy if it does not verify', async () => {
+      await write('source/a.txt', 'hello')
+      const destination = path.join(dir, 'target/a.txt')
+
+      jest
+        .spyOn(fsAsync, 'copyFile')
+        .mockImplementation((_source, partial) => fsAsync.writeFile(partial, 'corrupt'))
+
+      await expect(
+        copyFileVerified(path.join(dir, 'source/a.txt'), destination),
+      ).rejects.toThrowError(`Verifying ${destination} failed`)
+
+      expect(fs.existsSync(destination)).toBe(false)
+      expect(fs.existsSync(`${destination}.partial`)).toBe(false)
+    })
+  })
+})
@@ -0,0 +1,104 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import crypto from 'crypto'
+import fs from 'fs'
+import fsAsync from 'fs/promises'
+import path from 'path'
+
+export type DataDirFile = {
+  /**
+   * The path relative to the data dir, always separated with /
+   */
+  path: string
+  size: number
+}
+
+/**
+ * Returns true if `target` looks like an rsync style remote, like
+ * `user@host:/path` or `host:path`. Two letters before the colon are required so
+ * Windows drive letters are treated as local paths.
+ */
+export function isRemoteTarget(target: string): boolean {
+  return /^([^@:/\\]+@)?[^@:/\\]{2,}:/.test(target)
+}
+
+/**
+ * Lists the files in `dir` recursively, skipping the paths in `exclude` which
+ * are relative to `dir`
+ */
+export async function listDataDirFiles(dir: string, exclude: string[]): Promise<DataDirFile[]> {
+  const files: DataDirFile[] = []
+  const excluded = new Set(exclude.map((e) => e.split(path.sep).join('/')))
+
+  const walk = async (relative: string): Promise<void> => {
+    const entries = await fsAsync.readdir(path.join(dir, relative), { withFileTypes: true })
+
+    for (const entry of entries) {
+      const entryPath = relative ? `${relative}/${entry.name}` : entry.name
+
+      if (excluded.has(entryPath)) {
+        continue
+      }
+
+      if (entry.isDirectory()) {
+        await walk(entryPath)
+      } else if (entry.isFile()) {
+        const stat = await fsAsync.stat(path.join(dir, entryPath))
+        files.push({ path: entryPath, size: stat.size })
+      }
+    }
+  }
+
+  await walk('')
+  return files
+}
+
+export function hashFile(filePath: string): Promise<string> {
+  return new Promise((resolve, reject) => {
+    const hash = crypto.createHash('sha256')
+
+    fs.createReadStream(filePath)
+      .on('data', (chunk) => hash.update(chunk))
+      .on('end', () => resolve(hash.digest('hex')))
+      .on('error', reject)
+  })
+}
+
+/**
+ * Copies a file and verifies the copy against the source hash. Returns false
+ * without copying if the destination already matches, so an interrupted copy can
+ * be resumed by running it again.
+ */
+export async function copyFileVerified(source: string, destination: string): Promise<boolean> {
+  const sourceHash = await hashFile(source)
+
+  if (fs.existsSync(destination)) {
+    const [sourceStat, destinationStat] = await Promise.all([
+      fsAsync.stat(source),
+      fsAsync.stat(destination),
+    ])
+
+    const matches =
+      sourceStat.size === destinationStat.size && (await hashFile(destination)) === sourceHash
+
+    if (matches) {
+      return false
+    }
+  }
+
+  await fsAsync.mkdir(path.dirname(destination), { recursive: true })
+
+  // Copy to a temporary file first so a partial copy never looks complete
+  const partial = `${destination}.partial`
+  await fsAsync.copyFile(source, partial)
+
+  const copiedHash = await hashFile(partial)
+  if (copiedHash !== sourceHash) {
+    await fsAsync.rm(partial, { force: true })
+    throw new Error(`Verifying ${destination} failed, expected ${sourceHash} got ${copiedHash}`)
+  }
+
+  await fsAsync.rename(partial, destination)
+  return true
+}
@@ -0,0 +1,245 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { encodeQrCode, renderQrCode } from './qrcode'
+
+const PAIRING_QUERY = '&host=127.0.0.1&port=8020&name=wallet'
+
+/**
+ * Made with the QR code generator by Kazuhiko Arase at error correction level
+ * M, with dark modules as #. Version 7 has version bits, and version 10 has a
+ * 16 bit length and blocks of different lengths.
+ */
+const REFERENCES: { text: string; version: number; mask: number; rows: string[] }[] = [
+  {
+    text: 'ironfish',
+    version: 1,
+    mask: 0,
+    rows: [
+      '#######....##.#######',
+      '#.....#.####..#.....#',
+      '#.###.#..##.#.#.###.#',
+      '#.###.#..##.#.#.###.#',
+      '#.###.#.##.##.#.###.#',
+      '#.....#...##..#.....#',
+      '#######.#.#.#.#######',
+      '.........#...........',
+      '#.#.#.#..##.#...#..#.',
+      '##..#....#.#.#####..#',
+      '#..#.##.####..###..##',
+      '.##....#...###..#....',
+      '.##...######..#.#....',
+      '........##....#.#.###',
+      '#######..##.#..###.##',
+      '#.....#...#...#....#.',
+      '#.###.#.##..#.#......',
+      '#.###.#..###.#.##.##.',
+      '#.###.#.#.##.####...#',
+      '#.....#....###.##..#.',
+      '#######.#.##.####..##',
+    ],
+  },
+  {
+    text: 'https://ironfish.network',
+    version: 2,
+    mask: 3,
+    rows: [
+      '#######.#.##.#..#.#######',
+      '#.....#.#.#..#....#.....#',
+      '#.###.#..##..#.#..#.###.#',
+      '#.###.#.#..#.##...#.###.#',
+      '#.###.#...#.#.##..#.###.#',
+      '#.....#..#...##.#.#.....#',
+      '#######.#.#.#.#.#.#######',
+      '........#...#............',
+      '#.##.###..###.###.#..#.##',
+      '.##.#....#.##.#.##.#...#.',
+      '.##.####....#...##.##....',
+      '##..##..#..###.##.#####..',
+      '##.#.####.#..######.#.###',
+      '.#.###.#.###...######...#',
+      '.#.####.#.#.#.#..##.#.##.',
+      '#.#..#......#.##.#.##...#',
+      '..#.###..###.#.##########',
+      '........#.#.#...#...#.#.#',
+      '#######.#.#####.#.#.#.###',
+      '#.....#.##..#..##...#..#.',
+      '#.###.#..#.#..#.######..#',
+      '#.###.#.#.#.#.#####.#####',
+      '#.###.#.##.#####.##.#.##.',
+      '#.....#..#.#.#...##.#.#..',
+      '#######.#.#####....######',
+    ],
+  },
+  {
+    text: `ironfish://pair?key=${'cd'.repeat(30)}${PAIRING_QUERY}`,
+    version: 7,
+    mask: 6,
+    rows: [
+      '#######.#.####...#..####..##..#.##..#.#######',
+      '#.....#.#.....##.#...#####.#.#.#.#.#..#.....#',
+      '#.###.#.###.#####..#..#.#..#..####.#..#.###.#',
+      '#.###.#..##...###.#....###.###.##..##.#.###.#',
+      '#.###.#.#...#.##...#########..#...###.#.###.#',
+      '#.....#..#..#.#####.#...#.####.#.#....#.....#',
+      '#######.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#######',
+      '.........##.#########...#..#####..##.........',
+      '#..######.#..####.#######..##.#..#...#..#.###',
+      '.#####.##.#.##.#######.#.#######..#####..###.',
+      '#.#####.##.##....##.#...#..###..#####....####',
+      '.#..##..#.#...##...##.#.#..#....###.##....##.',
+      '...#.###...#.#..#.##....#...##..####.##.##.#.',
+      '#.##...##########....#.#..##.#####.##.#...##.',
+      '####.###.#..#....######.###......#.#.#####.#.',
+      '..###..##.#..###..#.#.#.#.....#...#.#.#..##.#',
+      '#..####.....####..##.#..#.##.#.##.#..#...#..#',
+      '...###....#.....##.#..##.#..####.#####..#...#',
+      '##...###..###...####....##..#..###.###..##..#',
+      '.#####....##.####.#.##..###.####..#.....#.###',
+      '##..#########..#..#######...#.##.#########...',
+      '.#.##...#.#...#.....#...####.##.#.###...#.#..',
+      '.####.#.#..###.....##.#.#..#.#..#.###.#.##.##',
+      '#####...###.#....#..#...#..#.##.##.##...#.###',
+      '..#.######......#..######...#...#..#######.##',
+      '.##....##.....###.#...###.##.####......#..##.',
+      '..#####.##...###..###.#..##.#....#..#....#...',
+      '#####...#...#.#.#..##.######..#...##.#..#####',
+      '#.#.####..#....#..#.......##..###.##...###.##',
+      '.###.#.#...#.#.##......###.##.#####.##.##.###',
+      '.###.##..#..###....#.###.#..##.###.##.##.####',
+      '..##.#..#.##.#...#.###.##...##.#.#.#...#####.',
+      '#.##..##.#.###.###.####.#.#.##...##.##......#',
+      '##.#.#.##..###.#...###.#.###.##.###..#.##.#..',
+      '....#.#.....#####.#.#.#..#.#.#..#########.###',
+      '.####..##.##..#.####....###..####.#...#######',
+      '#..##.####.#####..#########.#..###########.##',
+      '........##.##...#.###...#.#..###...##...##.#.',
+      '#######.##.##.##.#..#.#.###.#..#...##.#.##...',
+      '#.....#.#####.###.###...###..##..#..#...###.#',
+      '#.###.#.#...##.#.##.#####.#..####.########.##',
+      '#.###.#.###.#.##.#####...#.#..#####..#....###',
+      '#.###.#....####...########.#.#.##...###.###.#',
+      '#.....#..###.##.##.#.#...##.##.#.#....#######',
+      '#######.##.########..####.#.###..##.#####....',
+    ],
+  },
+  {
+    text: `ironfish://pair?key=${'ef'.repeat(64)}${PAIRING_QUERY}`,
+    version: 10,
+    mask: 4,
+    rows: [
+      '#######.##...###.#.##.###.#.###.##.#####.##.####..#######',
+      '#.....#.....#....#...##..#.#....#.###.#.....##.#..#.....#',
+      '#.###.#..##.#.#.#...#.#..#.#.......#####...#####..#.###.#',
+      '#.###.#.#..###...##.######..#####.#..#.#####...#..#.###.#',
+      '#.###.#.#####..#..#..###########......#.###.##.#..#.###.#',
+      '#.....#.#....###....#####.#...#..#####.#...#..#...#.....#',
+      '#######.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#######',
+      '........####.#......##...##...#.######.#.....#...........',
+      '#...#.###....####.#..##..#########.##.##.####.#..#####..#',
+      '######....####.#.##.#..##.##.####..##.##.####..##.##.###.',
+      '...#..#....#.##.#.#.#####.#.###...#...#.####.#....#.####.',
+      '#.#......##...#.####..#..#.##...#.##.#.#..#.#.#....#...#.',
+      '##.#####.......##...#..##..####.#.##.#.#....#.#...##....#',
+      '#.###.....#.#..##.#.##..###.####.#....#.##.#.#.##.#.####.',
+      '####.##...###..#.#.#.....#...###.#..#.#.##.#.#.##.#.#..#.',
+      '#...#...###......#.#..##...#..#.#.#.##...#..#.#..#.#...#.',
+      '#..##.#.##.#.##..##.#.##..#.....##..##......##...#.#...#.',
+      '..#.##.####.###.###.....#.##..#.##..#.##..#.##.##.##..#..',
+      '##..#.##########..##.###..###.##.#....##..##.#....#.###..',
+      '.......#####.##.#..####..#.#.#..#.#######...#.#..#.#.#...',
+      '##..###....##....#.##.#..#.#.##.#.####.##...#.##.#.#.#.##',
+      '.#.#.#..##...#..#.#.#.###.#.#.##.#......####.#..#.#.#..#.',
+      '#####.#....#...####..##..#..#..#.#.......###.#.#..#....#.',
+      '##.#.....#####.##.###....#.#...#.#####.#....######.#....#',
+      '..##.#####.#.#...#...#...#.#...###.##.##.##.##.#..##.#..#',
+      '....##..##.##.##.##.#..##.##.##.#..##.##.##.##.##.#.####.',
+      '.########.#.#..#..#..#.########.......#.###..#..########.',
+      '...##...#.#..#...#.#.#....#...###..###.#...##..##...#..#.',
+      '#...#.#.####.#...######..##.#.###.####.#....#####.#.#...#',
+      '#...#...#.###.####.#.#.##.#...##.##...#.###.#..##...####.',
+      '....#####.##....####.##..##########...#.####.#.######..#.',
+      '.#.#....#..###.#..##.#......###.#.#..#.#.#..#.#.#.#.....#',
+      '#...#######..#....#.#...###.###.##..#.##.##.##..#.#.#...#',
+      '....#.......#.#....#.#.#.###.##.##.##.##..#.##.#.#.#.##..',
+      '......#.#.....#.#.#.########...#.#.#..#.#..#.#.#.#.#.##.#',
+      '##..##.#....#.#....#..#.##..###.#.#.#.#...#.#.#.#.#.#....',
+      '...#..#.###....###...#.#...#.##.#.######.#..#.#.....#..##',
+      '##.#.#...##...#.###.#.###.#....#.#.#..##.###.#....##..#..',
+      '#.....###.#..#...#..##.##.#.#..#.#.#..#.####.#.#.#..##...',
+      '...###....#.###..###.###...####.#.###...#...#.#.#.#.##...',
+      '#..##.#.##..#####..##....#.##.#.##.##.#..##.##..##..#..#.',
+      '#.#..#...#..#.#.#..#..####....#.##.#####.##.##.#..##..##.',
+      '.#.##.#..#..#####.#.########.#.#.#...#.#####.#.#.#.#.###.',
+      '.........#.###....###.#......##..#####.#....#...###.#..#.',
+      '...#####.###...##..###......##....####.#....#.....#.#...#',
+      '###..#.##.....#.#.####.#####...#......#.######.##.##.###.',
+      '#.#..###.##.#....##..#...##.#.###.....#.###..#.###.....#.',
+      '#####..#...#.#.#.##.##......##..######.#....#.....#.....#',
+      '......##.####.#.#.####....########.##.##.##.###.#####...#',
+      '........###.#######....####...#....##.##.#####.##...####.',
+      '#######.#.#...#.#....######.#.##..#...#.#####..##.#.####.',
+      '#.....#.....#.#.#...#.....#...#.#.##.#.#..#.#.#.#...#..#.',
+      '#.###.#.##.#..###.#.#####.#####.#.##.#.#....#.#.#####...#',
+      '#.###.#....####.####.#..##..####.#....#.##.#.#....#.#####',
+      '#.###.#....#.#..#...###..##.#..#.#..#.#.##.#.#....#.#....',
+      '#.....#..####...#.#....#.###....#.#.####.##.#.#..#.#.....',
+      '#######.#.##.#...#.#####..##....##.##..#.#..##.###.#....#',
+    ],
+  },
+]
+
+const toRows = (modules: boolean[][]): string[] =>
+  modules.map((row) => row.map((dark) => (dark ? '#' : '.')).join(''))
+
+describe('encodeQrCode', () => {
+  for (const reference of REFERENCES) {
+    const { version, mask } = reference
+
+    it(`matches the reference for version ${version} with mask ${mask}`, () => {
+      const modules = encodeQrCode(reference.text, mask)
+
+      expect(modules).toHaveLength(version * 4 + 17)
+      expect(toRows(modules)).toEqual(reference.rows)
+    })
+  }
+
+  it('uses one of the masks when none is given', () => {
+    for (const reference of REFERENCES) {
+      const modules = toRows(encodeQrCode(reference.text))
+      const masks = [0, 1, 2, 3, 4, 5, 6, 7].map((mask) =>
+        toRows(encodeQrCode(reference.text, mask)),
+      )
+
+      expect(masks).toContainEqual(modules)
+    }
+  })
+
+  it('uses the smallest version that holds the data', () => {
+    expect(encodeQrCode('a'.repeat(14))).toHaveLength(21)
+    expect(encodeQrCode('a'.repeat(15))).toHaveLength(25)
+    expect(encodeQrCode('a'.repeat(213))).toHaveLength(57)
+  })
+
+  it('throws when the data does not fit in version 10', () => {
+    expect(() => encodeQrCode('a'.repeat(214))).toThrow(
+      'Too much data for a QR code: 214 bytes',
+    )
+  })
+})
+
+describe('renderQrCode', () => {
+  it('draws two rows of modules on each line with a quiet zone', () => {
+    const lines = renderQrCode('ironfish').split('\n')
+
+    // 21 modules and a quiet zone of 4 on each side
+    expect(lines).toHaveLength(15)
+    for (const line of lines) {
+      expect(line).toHaveLength(29)
+    }
+
+    // The quiet zone is light, then the top finder pattern edge is dark over its light inside
+    expect(lines[0]).toEqual('█'.repeat(29))
+    expect(lines[2].slice(0, 11)).toEqual('████ ▄▄▄▄▄ ')
+  })
+})
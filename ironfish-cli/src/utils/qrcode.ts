@@ -0,0 +1,466 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+/**
+ * A small QR code encoder for showing pairing URIs in the terminal. It only
+ * encodes bytes at error correction level M in versions 1 to 10, which holds
+ * up to 213 bytes.
+ */
+
+const MAX_VERSION = 10
+
+// Indexed by version, from ISO/IEC 18004 for error correction level M
+const ECC_CODEWORDS_PER_BLOCK = [-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26]
+const NUM_ERROR_CORRECTION_BLOCKS = [-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5]
+
+// The format bits of error correction level M
+const ECC_FORMAT_BITS = 0
+
+const QUIET_ZONE = 4
+
+type Matrix = { modules: boolean[][]; isFunction: boolean[][]; size: number }
+
+function getBit(value: number, index: number): boolean {
+  return ((value >>> index) & 1) !== 0
+}
+
+function getNumRawDataModules(version: number): number {
+  let result = (16 * version + 128) * version + 64
+
+  if (version >= 2) {
+    const numAlign = Math.floor(version / 7) + 2
+    result -= (25 * numAlign - 10) * numAlign - 55
+    if (version >= 7) {
+      result -= 36
+    }
+  }
+
+  return result
+}
+
+function getNumDataCodewords(version: number): number {
+  return (
+    Math.floor(getNumRawDataModules(version) / 8) -
+    ECC_CODEWORDS_PER_BLOCK[version] * NUM_ERROR_CORRECTION_BLOCKS[version]
+  )
+}
+
+function getAlignmentPositions(version: number, size: number): number[] {
+  if (version === 1) {
+    return []
+  }
+
+  const numAlign = Math.floor(version / 7) + 2
+  const step = Math.ceil((version * 4 + 4) / (numAlign * 2 - 2)) * 2
+  const result = [6]
+
+  for (let pos = size - 7; result.length < numAlign; pos -= step) {
+    result.splice(1, 0, pos)
+  }
+
+  return result
+}
+
+function multiply(x: number, y: number): number {
+  let z = 0
+  for (let i = 7; i >= 0; i--) {
+    z = (z << 1) ^ ((z >>> 7) * 0x11d)
+    z ^= ((y >>> i) & 1) * x
+  }
+  return z & 0xff
+}
+
+function reedSolomonDivisor(degree: number): number[] {
+  const result = new Array<number>(degree).fill(0)
+  result[degree - 1] = 1
+
+  let root = 1
+  for (let i = 0; i < degree; i++) {
+    for (let j = 0; j < result.length; j++) {
+      result[j] = multiply(result[j], root)
+      if (j + 1 < result.length) {
+        result[j] ^= result[j + 1]
+      }
+    }
+    root = multiply(root, 0x02)
+  }
+
+  return result
+}
+
+function reedSolomonRemainder(data: number[], divisor: number[]): number[] {
+  const result = new Array<number>(divisor.length).fill(0)
+
+  for (const byte of data) {
+    const factor = byte ^ (result.shift() as number)
+    result.push(0)
+    divisor.forEach((coefficient, i) => (result[i] ^= multiply(coefficient, factor)))
+  }
+
+  return result
+}
+
+function encodeData(data: Buffer, version: number): number[] {
+  const bits: number[] = []
+  const append = (value: number, length: number) => {
+    for (let i = length - 1; i >= 0; i--) {
+      bits.push((value >>> i) & 1)
+    }
+  }
+
+  // Byte mode, with a longer length from version 10
+  append(0x4, 4)
+  append(data.length, version < 10 ? 8 : 16)
+  for (const byte of data) {
+    append(byte, 8)
+  }
+
+  const capacity = getNumDataCodewords(version) * 8
+  append(0, Math.min(4, capacity - bits.length))
+  append(0, (8 - (bits.length % 8)) % 8)
+
+  for (let pad = 0xec; bits.length < capacity; pad ^= 0xec ^ 0x11) {
+    append(pad, 8)
+  }
+
+  const codewords = []
+  for (let i = 0; i < bits.length; i += 8) {
+    codewords.push(parseInt(bits.slice(i, i + 8).join(''), 2))
+  }
+  return codewords
+}
+
+function addEccAndInterleave(data: number[], version: number): number[] {
+  const numBlocks = NUM_ERROR_CORRECTION_BLOCKS[version]
+  const blockEccLength = ECC_CODEWORDS_PER_BLOCK[version]
+  const rawCodewords = Math.floor(getNumRawDataModules(version) / 8)
+  const numShortBlocks = numBlocks - (rawCodewords % numBlocks)
+  const shortBlockLength = Math.floor(rawCodewords / numBlocks)
+  const divisor = reedSolomonDivisor(blockEccLength)
+
+  const blocks: number[][] = []
+  for (let i = 0, k = 0; i < numBlocks; i++) {
+    const length = shortBlockLength - blockEccLength + (i < numShortBlocks ? 0 : 1)
+    const block = data.slice(k, k + length)
+    k += block.length
+
+    const ecc = reedSolomonRemainder(block, divisor)
+    if (i < numShortBlocks) {
+      block.push(0)
+    }
+    blocks.push(block.concat(ecc))
+  }
+
+  const result = []
+  for (let i = 0; i < blocks[0].length; i++) {
+    for (let j = 0; j < blocks.length; j++) {
+      // Skip the padding of the short blocks
+      if (i !== shortBlockLength - blockEccLength || j >= numShortBlocks) {
+        result.push(blocks[j][i])
+      }
+    }
+  }
+  return result
+}
+
+function setFunction(matrix: Matrix, x: number, y: number, dark: boolean): void {
+  matrix.modules[y][x] = dark
+  matrix.isFunction[y][x] = true
+}
+
+function drawFormatBits(matrix: Matrix, mask: number): void {
+  const { size } = matrix
+  const data = (ECC_FORMAT_BITS << 3) | mask
+
+  let remainder = data
+  for (let i = 0; i < 10; i++) {
+    remainder = (remainder << 1) ^ ((remainder >>> 9) * 0x537)
+  }
+  const bits = ((data << 10) | remainder) ^ 0x5412
+
+  for (let i = 0; i <= 5; i++) {
+    setFunction(matrix, 8, i, getBit(bits, i))
+  }
+  setFunction(matrix, 8, 7, getBit(bits, 6))
+  setFunction(matrix, 8, 8, getBit(bits, 7))
+  setFunction(matrix, 7, 8, getBit(bits, 8))
+  for (let i = 9; i < 15; i++) {
+    setFunction(matrix, 14 - i, 8, getBit(bits, i))
+  }
+
+  for (let i = 0; i < 8; i++) {
+    setFunction(matrix, size - 1 - i, 8, getBit(bits, i))
+  }
+  for (let i = 8; i < 15; i++) {
+    setFunction(matrix, 8, size - 15 + i, getBit(bits, i))
+  }
+  setFunction(matrix, 8, size - 8, true)
+}
+
+function drawVersion(matrix: Matrix, version: number): void {
+  if (version < 7) {
+    return
+  }
+
+  let remainder = version
+  for (let i = 0; i < 12; i++) {
+    remainder = (remainder << 1) ^ ((remainder >>> 11) * 0x1f25)
+  }
+  const bits = (version << 12) | remainder
+
+  for (let i = 0; i < 18; i++) {
+    const a = matrix.size - 11 + (i % 3)
+    const b = Math.floor(i / 3)
+    setFunction(matrix, a, b, getBit(bits, i))
+    setFunction(matrix, b, a, getBit(bits, i))
+  }
+}
+
+function drawFunctionPatterns(matrix: Matrix, version: number): void {
+  const { size } = matrix
+
+  for (let i = 0; i < size; i++) {
+    setFunction(matrix, 6, i, i % 2 === 0)
+    setFunction(matrix, i, 6, i % 2 === 0)
+  }
+
+  for (const [cx, cy] of [
+    [3, 3],
+    [size - 4, 3],
+    [3, size - 4],
+  ]) {
+    for (let dy = -4; dy <= 4; dy++) {
+      for (let dx = -4; dx <= 4; dx++) {
+        const x = cx + dx
+        const y = cy + dy
+        if (x >= 0 && x < size && y >= 0 && y < size) {
+          const distance = Math.max(Math.abs(dx), Math.abs(dy))
+          setFunction(matrix, x, y, distance !== 2 && distance !== 4)
+        }
+      }
+    }
+  }
+
+  const positions = getAlignmentPositions(version, size)
+  const last = positions.length - 1
+  for (let i = 0; i < positions.length; i++) {
+    for (let j = 0; j < positions.length; j++) {
+      // The corners with finder patterns don't get alignment patterns
+      if ((i === 0 && j === 0) || (i === 0 && j === last) || (i === last && j === 0)) {
+        continue
+      }
+      for (let dy = -2; dy <= 2; dy++) {
+        for (let dx = -2; dx <= 2; dx++) {
+          const dark = Math.max(Math.abs(dx), Math.abs(dy)) !== 1
+          setFunction(matrix, positions[i] + dx, positions[j] + dy, dark)
+        }
+      }
+    }
+  }
+
+  // Reserve the format bits until the mask is chosen
+  drawFormatBits(matrix, 0)
+  drawVersion(matrix, version)
+}
+
+function drawCodewords(matrix: Matrix, codewords: number[]): void {
+  const { size } = matrix
+  let i = 0
+
+  for (let right = size - 1; right >= 1; right -= 2) {
+    if (right === 6) {
+      right = 5
+    }
+
+    for (let vertical = 0; vertical < size; vertical++) {
+      for (let j = 0; j < 2; j++) {
+        const x = right - j
+        const upward = ((right + 1) & 2) === 0
+        const y = upward ? size - 1 - vertical : vertical
+
+        if (!matrix.isFunction[y][x] && i < codewords.length * 8) {
+          matrix.modules[y][x] = getBit(codewords[i >>> 3], 7 - (i & 7))
+          i++
+        }
+      }
+    }
+  }
+}
+
+function isMasked(mask: number, x: number, y: number): boolean {
+  switch (mask) {
+    case 0:
+      return (x + y) % 2 === 0
+    case 1:
+      return y % 2 === 0
+    case 2:
+      return x % 3 === 0
+    case 3:
+      return (x + y) % 3 === 0
+    case 4:
+      return (Math.floor(x / 3) + Math.floor(y / 2)) % 2 === 0
+    case 5:
+      return ((x * y) % 2) + ((x * y) % 3) === 0
+    case 6:
+      return (((x * y) % 2) + ((x * y) % 3)) % 2 === 0
+    default:
+      return (((x + y) % 2) + ((x * y) % 3)) % 2 === 0
+  }
+}
+
+function applyMask(matrix: Matrix, mask: number): void {
+  for (let y = 0; y < matrix.size; y++) {
+    for (let x = 0; x < matrix.size; x++) {
+      if (!matrix.isFunction[y][x] && isMasked(mask, x, y)) {
+        matrix.modules[y][x] = !matrix.modules[y][x]
+      }
+    }
+  }
+}
+
+/**
+ * The penalty score from the QR code specification, masks with lower scores
+ * are easier to scan
+ */
+function getPenalty(modules: boolean[][]): number {
+  const size = modules.length
+  const finderLike = [
+    [true, false, true, true, true, false, true, false, false, false, false],
+    [false, false, false, false, true, false, true, true, true, false, true],
+  ]
+
+  let penalty = 0
+  let dark = 0
+
+  for (let line = 0; line < size; line++) {
+    for (const get of [
+      (i: number) => modules[line][i],
+      (i: number) => modules[i][line],
+    ]) {
+      let run = 1
+      for (let i = 1; i <= size; i++) {
+        if (i < size && get(i) === get(i - 1)) {
+          run++
+          continue
+        }
+        if (run >= 5) {
+          penalty += run - 2
+        }
+        run = 1
+      }
+
+      for (let i = 0; i + 11 <= size; i++) {
+        for (const pattern of finderLike) {
+          if (pattern.every((value, j) => get(i + j) === value)) {
+            penalty += 40
+          }
+        }
+      }
+    }
+  }
+
+  for (let y = 0; y < size; y++) {
+    for (let x = 0; x < size; x++) {
+      dark += modules[y][x] ? 1 : 0
+
+      if (x + 1 < size && y + 1 < size) {
+        const color = modules[y][x]
+        if (
+          color === modules[y][x + 1] &&
+          color === modules[y + 1][x] &&
+          color === modules[y + 1][x + 1]
+        ) {
+          penalty += 3
+        }
+      }
+    }
+  }
+
+  const total = size * size
+  penalty += (Math.ceil(Math.abs(dark * 20 - total * 10) / total) - 1) * 10
+
+  return penalty
+}
+
+/**
+ * @param mask Use this mask instead of the one with the lowest penalty
+ * @returns the modules of the QR code by row, true is dark
+ */
+export function encodeQrCode(text: string, mask?: number): boolean[][] {
+  const data = Buffer.from(text, 'utf8')
+
+  let version = 1
+  while (version <= MAX_VERSION) {
+    const header = 4 + (version < 10 ? 8 : 16)
+    if (header + data.length * 8 <= getNumDataCodewords(version) * 8) {
+      break
+    }
+    version++
+  }
+
+  if (version > MAX_VERSION) {
+    throw new Error(`Too much data for a QR code: ${data.length} bytes`)
+  }
+
+  const size = version * 4 + 17
+  const codewords = addEccAndInterleave(encodeData(data, version), version)
+
+  const create = () => Array.from({ length: size }, () => new Array<boolean>(size).fill(false))
+  const matrix: Matrix = { modules: create(), isFunction: create(), size }
+
+  drawFunctionPatterns(matrix, version)
+  drawCodewords(matrix, codewords)
+
+  if (mask !== undefined) {
+    applyMask(matrix, mask)
+    drawFormatBits(matrix, mask)
+    return matrix.modules
+  }
+
+  let best: boolean[][] | null = null
+  let bestPenalty = Infinity
+
+  for (let candidate = 0; candidate < 8; candidate++) {
+    applyMask(matrix, candidate)
+    drawFormatBits(matrix, candidate)
+
+    const penalty = getPenalty(matrix.modules)
+    if (penalty < bestPenalty) {
+      best = matrix.modules.map((row) => [...row])
+      bestPenalty = penalty
+    }
+
+    // Masking twice undoes it
+    applyMask(matrix, candidate)
+  }
+
+  return best as boolean[][]
+}
+
+/**
+ * Render a QR code with half blocks, so each line of text holds two rows of
+ * modules. Dark modules are drawn as the terminal background.
+ */
+export function renderQrCode(text: string): string {
+  const modules = encodeQrCode(text)
+  const size = modules.length + QUIET_ZONE * 2
+
+  const isDark = (x: number, y: number) => {
+    const row = modules[y - QUIET_ZONE]
+    return row !== undefined && row[x - QUIET_ZONE] === true
+  }
+
+  const lines = []
+  for (let y = 0; y < size; y += 2) {
+    let line = ''
+    for (let x = 0; x < size; x++) {
+      const top = isDark(x, y)
+      const bottom = y + 1 < size && isDark(x, y + 1)
+      line += top ? (bottom ? ' ' : '▄') : bottom ? '▀' : '█'
+    }
+    lines.push(line)
+  }
+
+  return lines.join('\n')
+}
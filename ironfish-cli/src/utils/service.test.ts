@@ -0,0 +1,216 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { execFile } from 'child_process'
+import fs from 'fs'
+import os from 'os'
+import path from 'path'
+import { v4 as uuid } from 'uuid'
+import {
+  runCommand,
+  ServiceOptions,
+  SystemdServiceManager,
+  WindowsServiceManager,
+} from './service'
+
+jest.mock('child_process')
+
+type ExecFileCallback = (error: { code: number } | null, stdout: string, stderr: string) => void
+
+describe('service', () => {
+  const execFileMock = execFile as unknown as jest.Mock
+  const calls: string[] = []
+
+  // Maps a command line to the exit code it fails with
+  let failures: Record<string, number> = {}
+
+  const options = (overrides: Partial<ServiceOptions> = {}): ServiceOptions => ({
+    dataDir: path.join(os.tmpdir(), uuid()),
+    logFile: null,
+    runAs: null,
+    stopTimeoutMs: 30000,
+    ...overrides,
+  })
+
+  const systemd = (system: boolean): SystemdServiceManager => {
+    const manager = new SystemdServiceManager()
+    jest.spyOn(manager, 'system', 'get').mockReturnValue(system)
+    return manager
+  }
+
+  beforeEach(() => {
+    calls.length = 0
+    failures = {}
+
+    execFileMock.mockImplementation(
+      (command: string, args: string[], _: unknown, callback: ExecFileCallback) => {
+        const line = [command, ...args].join(' ')
+        calls.push(line)
+
+        const code = failures[line]
+        if (code !== undefined) {
+          callback({ code }, '', `${command} failed`)
+        } else {
+          callback(null, `${line} output\n`, '')
+        }
+      },
+    )
+  })
+
+  afterEach(() => {
+    jest.restoreAllMocks()
+  })
+
+  describe('runCommand', () => {
+    it('resolves with the exit code of failed commands', async () => {
+      failures['systemctl is-active ironfish'] = 3
+
+      await expect(runCommand('systemctl', ['is-active', 'ironfish'])).resolves.toEqual({
+        code: 3,
+        stdout: '',
+        stderr: 'systemctl failed',
+      })
+    })
+
+    it('rejects when the command can not be run', async () => {
+      execFileMock.mockImplementation(
+        (_: string, __: string[], ___: unknown, callback: (e: Error) => void) => {
+          callback(Object.assign(new Error('spawn nssm ENOENT'), { code: 'ENOENT' }))
+        },
+      )
+
+      await expect(runCommand('nssm', ['status'])).rejects.toThrow('ENOENT')
+    })
+  })
+
+  describe('SystemdServiceManager', () => {
+    it('renders a user unit', () => {
+      const manager = systemd(false)
+
+      const unit = manager.renderUnit(options({ dataDir: '/data/dir', runAs: 'ironfish' }))
+
+      expect(manager.unitPath).toEqual(
+        path.join(os.homedir(), '.config/systemd/user/ironfish.service'),
+      )
+      expect(unit).toContain('start --datadir /data/dir --no-color')
+      expect(unit).toContain('TimeoutStopSec=30')
+      expect(unit).toContain('WantedBy=default.target')
+      expect(unit).not.toContain('User=')
+      expect(unit).not.toContain('StandardOutput')
+    })
+
+    it('renders a system unit with a user and log file', () => {
+      const manager = systemd(true)
+
+      const unit = manager.renderUnit(
+        options({ logFile: '/var/log/ironfish.log', runAs: 'ironfish', stopTimeoutMs: 1500 }),
+      )
+
+      expect(manager.unitPath).toEqual('/etc/systemd/system/ironfish.service')
+      expect(unit).toContain('User=ironfish')
+      expect(unit).toContain('StandardOutput=append:/var/log/ironfish.log')
+      expect(unit).toContain('StandardError=append:/var/log/ironfish.log')
+      expect(unit).toContain('TimeoutStopSec=2')
+      expect(unit).toContain('WantedBy=multi-user.target')
+    })
+
+    it('quotes arguments with spaces', () => {
+      const manager = systemd(false)
+
+      const unit = manager.renderUnit(options({ dataDir: '/data/my dir' }))
+
+      expect(unit).toContain('--datadir "/data/my dir"')
+    })
+
+    it('creates the data dir readable only by its owner', async () => {
+      const manager = systemd(false)
+      const serviceOptions = options()
+
+      await manager.prepareDataDir(serviceOptions)
+
+      const stat = await fs.promises.stat(serviceOptions.dataDir)
+      expect(stat.isDirectory()).toBe(true)
+      expect(stat.mode & 0o777).toBe(0o700)
+      expect(calls).toEqual([])
+    })
+
+    it('uses the user instance of systemctl when not root', async () => {
+      const manager = systemd(false)
+
+      await manager.start()
+      await manager.stop()
+
+      expect(calls).toEqual([
+        'systemctl --user start ironfish',
+        'systemctl --user stop ironfish',
+      ])
+    })
+
+    it('reports the service status', async () => {
+      failures['systemctl is-enabled ironfish'] = 1
+
+      const status = await systemd(true).status()
+
+      expect(status).toMatchObject({
+        running: true,
+        enabled: false,
+        details: 'systemctl status --no-pager ironfish output',
+      })
+    })
+
+    it('throws when systemctl fails', async () => {
+      failures['systemctl start ironfish'] = 5
+
+      await expect(systemd(true).start()).rejects.toThrow(
+        'systemctl start ironfish failed with code 5: systemctl failed',
+      )
+    })
+  })
+
+  describe('WindowsServiceManager', () => {
+    it('installs the service with NSSM', async () => {
+      const manager = new WindowsServiceManager()
+      const serviceOptions = options()
+
+      await manager.install(serviceOptions)
+
+      const logFile = path.join(serviceOptions.dataDir, 'service.log')
+
+      expect(calls[0]).toEqual(
+        `icacls ${serviceOptions.dataDir} /grant *S-1-5-18:(OI)(CI)F /T /Q`,
+      )
+      expect(calls[1]).toContain(`nssm install ironfish ${process.execPath}`)
+      expect(calls[1]).toContain(`start --datadir ${serviceOptions.dataDir} --no-color`)
+      expect(calls).toContain(`nssm set ironfish AppStdout ${logFile}`)
+      expect(calls).toContain(`nssm set ironfish AppStderr ${logFile}`)
+      expect(calls).toContain('nssm set ironfish AppStopMethodConsole 30000')
+      expect(calls).toContain('nssm set ironfish Start SERVICE_AUTO_START')
+    })
+
+    it('explains how to install NSSM when it is missing', async () => {
+      execFileMock.mockImplementation(
+        (_: string, __: string[], ___: unknown, callback: (e: Error) => void) => {
+          callback(Object.assign(new Error('spawn nssm ENOENT'), { code: 'ENOENT' }))
+        },
+      )
+
+      await expect(new WindowsServiceManager().start()).rejects.toThrow('NSSM was not found')
+    })
+
+    it('reports the service status', async () => {
+      execFileMock.mockImplementation(
+        (command: string, args: string[], _: unknown, callback: ExecFileCallback) => {
+          const stdout = args[0] === 'query' ? 'STATE : 4 RUNNING' : 'START_TYPE : AUTO_START'
+          callback(null, stdout, '')
+        },
+      )
+
+      await expect(new WindowsServiceManager().status()).resolves.toEqual({
+        installed: true,
+        running: true,
+        enabled: true,
+        details: 'STATE : 4 RUNNING',
+      })
+    })
+  })
+})
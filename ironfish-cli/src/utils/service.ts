@@ -0,0 +1,284 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { execFile } from 'child_process'
+import fs from 'fs'
+import os from 'os'
+import path from 'path'
+
+export const SERVICE_NAME = 'ironfish'
+const SERVICE_DESCRIPTION = 'Iron Fish node'
+
+export type ServiceOptions = {
+  /**
+   * The absolute path to the data dir the service runs the node with
+   */
+  dataDir: string
+  /**
+   * A file to write the node output to, defaults to the platform's service log
+   */
+  logFile: string | null
+  /**
+   * The user to run a system wide service as, defaults to the current user
+   */
+  runAs: string | null
+  /**
+   * How long the node may take to shut down before it is killed
+   */
+  stopTimeoutMs: number
+}
+
+export type ServiceStatus = {
+  installed: boolean
+  running: boolean
+  enabled: boolean
+  details: string
+}
+
+type CommandResult = { code: number; stdout: string; stderr: string }
+
+export function runCommand(command: string, args: string[]): Promise<CommandResult> {
+  return new Promise((resolve, reject) => {
+    execFile(command, args, { windowsHide: true }, (error, stdout, stderr) => {
+      if (error && typeof error.code !== 'number') {
+        reject(error)
+        return
+      }
+
+      resolve({ code: error ? Number(error.code) : 0, stdout, stderr })
+    })
+  })
+}
+
+async function runOrThrow(command: string, args: string[]): Promise<string> {
+  const result = await runCommand(command, args)
+
+  if (result.code !== 0) {
+    const output = (result.stderr || result.stdout).trim()
+    throw new Error(`${command} ${args.join(' ')} failed with code ${result.code}: ${output}`)
+  }
+
+  return result.stdout
+}
+
+/**
+ * The command line the service manager runs to start the node
+ */
+function getStartCommand(dataDir: string): string[] {
+  return [process.execPath, path.resolve(process.argv[1]), 'start', '--datadir', dataDir]
+}
+
+export abstract class ServiceManager {
+  abstract readonly platform: string
+
+  /**
+   * Where the node output goes when no log file is given
+   */
+  abstract readonly defaultLogs: string
+
+  abstract install(options: ServiceOptions): Promise<void>
+  abstract start(): Promise<void>
+  abstract stop(): Promise<void>
+  abstract status(): Promise<ServiceStatus>
+
+  /**
+   * Creates the data dir and makes sure only the service can read it, because it
+   * holds the wallet keys
+   */
+  async prepareDataDir(options: ServiceOptions): Promise<void> {
+    await fs.promises.mkdir(options.dataDir, { recursive: true })
+    await fs.promises.chmod(options.dataDir, 0o700)
+  }
+}
+
+/**
+ * Installs the node as a systemd unit. When run as root it installs a system
+ * unit, otherwise a user unit that only runs while the user is logged in unless
+ * lingering is enabled.
+ */
+export class SystemdServiceManager extends ServiceManager {
+  readonly platform = 'systemd'
+
+  get defaultLogs(): string {
+    return `journalctl ${this.system ? '' : '--user '}-u ${SERVICE_NAME}`
+  }
+
+  get system(): boolean {
+    return process.getuid?.() === 0
+  }
+
+  get unitPath(): string {
+    const fileName = `${SERVICE_NAME}.service`
+
+    if (this.system) {
+      return path.join('/etc/systemd/system', fileName)
+    }
+
+    return path.join(os.homedir(), '.config/systemd/user', fileName)
+  }
+
+  private systemctl(...args: string[]): Promise<string> {
+    return runOrThrow('systemctl', this.system ? args : ['--user', ...args])
+  }
+
+  renderUnit(options: ServiceOptions): string {
+    const command = getStartCommand(options.dataDir).concat('--no-color')
+    const execStart = command.map((arg) => (arg.includes(' ') ? `"${arg}"` : arg)).join(' ')
+
+    const service = [
+      'Type=simple',
+      `ExecStart=${execStart}`,
+      'Restart=on-failure',
+      'RestartSec=10',
+      `TimeoutStopSec=${Math.ceil(options.stopTimeoutMs / 1000)}`,
+    ]
+
+    if (this.system && options.runAs) {
+      service.push(`User=${options.runAs}`)
+    }
+
+    if (options.logFile) {
+      service.push(`StandardOutput=append:${options.logFile}`)
+      service.push(`StandardError=append:${options.logFile}`)
+    }
+
+    return [
+      '[Unit]',
+      `Description=${SERVICE_DESCRIPTION}`,
+      'After=network-online.target',
+      'Wants=network-online.target',
+      '',
+      '[Service]',
+      ...service,
+      '',
+      '[Install]',
+      `WantedBy=${this.system ? 'multi-user.target' : 'default.target'}`,
+      '',
+    ].join('\n')
+  }
+
+  async prepareDataDir(options: ServiceOptions): Promise<void> {
+    await super.prepareDataDir(options)
+
+    if (this.system && options.runAs) {
+      await runOrThrow('chown', ['-R', `${options.runAs}:`, options.dataDir])
+    }
+  }
+
+  async install(options: ServiceOptions): Promise<void> {
+    await this.prepareDataDir(options)
+
+    await fs.promises.mkdir(path.dirname(this.unitPath), { recursive: true })
+    await fs.promises.writeFile(this.unitPath, this.renderUnit(options))
+
+    await this.systemctl('daemon-reload')
+    await this.systemctl('enable', SERVICE_NAME)
+  }
+
+  async start(): Promise<void> {
+    await this.systemctl('start', SERVICE_NAME)
+  }
+
+  async stop(): Promise<void> {
+    await this.systemctl('stop', SERVICE_NAME)
+  }
+
+  async status(): Promise<ServiceStatus> {
+    const systemctl = (...args: string[]) =>
+      runCommand('systemctl', this.system ? args : ['--user', ...args])
+
+    const installed = fs.existsSync(this.unitPath)
+    const active = await systemctl('is-active', SERVICE_NAME)
+    const enabled = await systemctl('is-enabled', SERVICE_NAME)
+    const details = await systemctl('status', '--no-pager', SERVICE_NAME)
+
+    return {
+      installed,
+      running: active.code === 0,
+      enabled: enabled.code === 0,
+      details: details.stdout.trim(),
+    }
+  }
+}
+
+/**
+ * Installs the node as a Windows service with NSSM, because node can't answer
+ * the service control manager by itself. NSSM must be on the PATH.
+ */
+export class WindowsServiceManager extends ServiceManager {
+  readonly platform = 'windows'
+
+  get defaultLogs(): string {
+    return 'service.log in the data dir'
+  }
+
+  private nssm(...args: string[]): Promise<string> {
+    return runOrThrow('nssm', args).catch((e: NodeJS.ErrnoException) => {
+      if (e.code === 'ENOENT') {
+        throw new Error(
+          'NSSM was not found, install it from https://nssm.cc and add it to the PATH',
+        )
+      }
+
+      throw e
+    })
+  }
+
+  async prepareDataDir(options: ServiceOptions): Promise<void> {
+    await super.prepareDataDir(options)
+
+    // The service runs as LocalSystem, S-1-5-18, which needs access to the data dir
+    await runOrThrow('icacls', [options.dataDir, '/grant', '*S-1-5-18:(OI)(CI)F', '/T', '/Q'])
+  }
+
+  async install(options: ServiceOptions): Promise<void> {
+    await this.prepareDataDir(options)
+
+    const [command, ...args] = getStartCommand(options.dataDir).concat('--no-color')
+    const logFile = options.logFile ?? path.join(options.dataDir, 'service.log')
+
+    await this.nssm('install', SERVICE_NAME, command, ...args)
+    await this.nssm('set', SERVICE_NAME, 'Description', SERVICE_DESCRIPTION)
+    await this.nssm('set', SERVICE_NAME, 'AppDirectory', options.dataDir)
+    await this.nssm('set', SERVICE_NAME, 'AppStdout', logFile)
+    await this.nssm('set', SERVICE_NAME, 'AppStderr', logFile)
+    await this.nssm('set', SERVICE_NAME, 'AppRotateFiles', '1')
+    await this.nssm('set', SERVICE_NAME, 'AppStopMethodConsole', String(options.stopTimeoutMs))
+    await this.nssm('set', SERVICE_NAME, 'Start', 'SERVICE_AUTO_START')
+  }
+
+  async start(): Promise<void> {
+    await this.nssm('start', SERVICE_NAME)
+  }
+
+  async stop(): Promise<void> {
+    await this.nssm('stop', SERVICE_NAME)
+  }
+
+  async status(): Promise<ServiceStatus> {
+    const status = await runCommand('sc.exe', ['query', SERVICE_NAME])
+    const config = await runCommand('sc.exe', ['qc', SERVICE_NAME])
+
+    return {
+      installed: status.code === 0,
+      running: status.stdout.includes('RUNNING'),
+      enabled: config.stdout.includes('AUTO_START'),
+      details: status.stdout.trim(),
+    }
+  }
+}
+
+/**
+ * Returns the service manager for this platform, or null if it has none
+ */
+export function getServiceManager(): ServiceManager | null {
+  if (process.platform === 'win32') {
+    return new WindowsServiceManager()
+  }
+
+  if (process.platform === 'linux') {
+    return new SystemdServiceManager()
+  }
+
+  return null
+}
@@ -0,0 +1,80 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { ApiVersion, NodeFileProvider, WebApi } from '@ironfish/sdk'
+import os from 'os'
+import path from 'path'
+import { v4 as uuid } from 'uuid'
+import { IronfishCliPKG } from '../package'
+import { compareVersions, Updater } from './updater'
+
+describe('compareVersions', () => {
+  it('compares release versions', () => {
+    expect(compareVersions('0.1.41', '0.1.41')).toBe(0)
+    expect(compareVersions('0.1.42', '0.1.41')).toBe(1)
+    expect(compareVersions('0.1.41', '0.2.0')).toBe(-1)
+    expect(compareVersions('v1.0.0', '0.9.9')).toBe(1)
+    expect(compareVersions('1.0', '1.0.0')).toBe(0)
+  })
+
+  it('orders pre-releases before their release', () => {
+    expect(compareVersions('0.1.41-beta.1', '0.1.41')).toBe(-1)
+    expect(compareVersions('0.1.41', '0.1.41-beta.1')).toBe(1)
+    expect(compareVersions('0.1.41-beta.1', '0.1.40')).toBe(1)
+  })
+
+  it('compares pre-release identifiers', () => {
+    expect(compareVersions('0.1.41-beta.2', '0.1.41-beta.1')).toBe(1)
+    expect(compareVersions('0.1.41-beta.2', '0.1.41-beta.10')).toBe(-1)
+    expect(compareVersions('0.1.41-alpha.1', '0.1.41-beta.1')).toBe(-1)
+    expect(compareVersions('0.1.41-beta', '0.1.41-beta.1')).toBe(-1)
+    expect(compareVersions('0.1.41-nightly.20221010', '0.1.41-nightly.20221010')).toBe(0)
+  })
+})
+
+describe('Updater', () => {
+  const version = (value: string): ApiVersion => ({
+    version: value,
+    channel: 'beta',
+    published_at: '2022-10-10T00:00:00.000Z',
+    rollout: 100,
+    assets: [],
+  })
+
+  const createUpdater = async (latest: ApiVersion | null) => {
+    const files = new NodeFileProvider()
+    await files.init()
+
+    const api = new WebApi()
+    const getLatestVersion = jest.spyOn(api, 'getLatestVersion').mockResolvedValue(latest)
+
+    const updater = new Updater({
+      files,
+      api,
+      clientDir: path.join(os.tmpdir(), uuid()),
+    })
+
+    return { updater, getLatestVersion }
+  }
+
+  it('checks the stable channel by default', async () => {
+    const { updater, getLatestVersion } = await createUpdater(null)
+
+    await expect(updater.check()).resolves.toBeNull()
+    expect(getLatestVersion).toHaveBeenCalledWith('stable')
+  })
+
+  it('returns newer versions from the requested channel', async () => {
+    const latest = version('999.0.0-beta.1')
+    const { updater, getLatestVersion } = await createUpdater(latest)
+
+    await expect(updater.check('beta')).resolves.toBe(latest)
+    expect(getLatestVersion).toHaveBeenCalledWith('beta')
+  })
+
+  it('ignores pre-releases of the running version', async () => {
+    const { updater } = await createUpdater(version(`${IronfishCliPKG.version}-beta.1`))
+
+    await expect(updater.check('beta')).resolves.toBeNull()
+  })
+})
@@ -0,0 +1,345 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import {
+  ApiVersion,
+  ApiVersionAsset,
+  createRootLogger,
+  FileStore,
+  FileSystem,
+  Logger,
+  ReleaseChannel,
+  WebApi,
+} from '@ironfish/sdk'
+import axios from 'axios'
+import crypto from 'crypto'
+import fsAsync from 'fs/promises'
+import os from 'os'
+import path from 'path'
+import nacl from 'tweetnacl'
+import pkg from '../../package.json'
+import { IronfishCliPKG } from '../package'
+import { runCommand } from './service'
+
+/**
+ * How long a newly installed version must run before it is trusted. If it
+ * crashes sooner the next start rolls back to the previous version.
+ */
+export const UPDATE_HEALTHY_AFTER_MS = 60 * 1000
+
+export type UpdateState = {
+  /**
+   * The version the client bin runs
+   */
+  current: string | null
+  /**
+   * The version to roll back to if the current version fails to start
+   */
+  previous: string | null
+  /**
+   * Set when the current version was installed but has not run long enough
+   * to be trusted
+   */
+  pending: boolean
+  /**
+   * How many times the pending version was started without becoming healthy
+   */
+  failedStarts: number
+}
+
+const DEFAULT_UPDATE_STATE: UpdateState = {
+  current: null,
+  previous: null,
+  pending: false,
+  failedStarts: 0,
+}
+
+/**
+ * The directory the bin script runs an updated CLI from, see bin/ironfish
+ */
+export function getClientDir(): string {
+  if (process.env.IRONFISH_OCLIF_CLIENT_HOME) {
+    return process.env.IRONFISH_OCLIF_CLIENT_HOME
+  }
+
+  const dataHome = process.env.XDG_DATA_HOME || path.join(os.homedir(), '.local/share')
+  return path.join(dataHome, 'ironfish', 'client')
+}
+
+/**
+ * The key release packages are signed with. It's inserted into package.json
+ * by scripts/build.sh, builds without one can't update.
+ */
+function getReleaseSigningKey(): string | null {
+  return (pkg as { releaseSigningKey?: string }).releaseSigningKey ?? null
+}
+
+/**
+ * Compares two semver versions. A pre-release like 0.1.41-beta.2 comes before
+ * 0.1.41 and after 0.1.41-beta.1.
+ */
+export function compareVersions(a: string, b: string): number {
+  const parse = (v: string) => {
+    const [release, ...prerelease] = v.replace(/^v/, '').split('-')
+    return {
+      release: release.split('.').map((n) => Number(n) || 0),
+      prerelease: prerelease.length ? prerelease.join('-').split('.') : [],
+    }
+  }
+
+  const [versionA, versionB] = [parse(a), parse(b)]
+  const length = Math.max(versionA.release.length, versionB.release.length)
+
+  for (let i = 0; i < length; i++) {
+    const diff = (versionA.release[i] ?? 0) - (versionB.release[i] ?? 0)
+    if (diff !== 0) {
+      return Math.sign(diff)
+    }
+  }
+
+  // A full release is newer than its pre-releases
+  if (!versionA.prerelease.length || !versionB.prerelease.length) {
+    return Math.sign(versionB.prerelease.length - versionA.prerelease.length)
+  }
+
+  const prereleaseLength = Math.max(versionA.prerelease.length, versionB.prerelease.length)
+
+  for (let i = 0; i < prereleaseLength; i++) {
+    const partA = versionA.prerelease[i]
+    const partB = versionB.prerelease[i]
+
+    if (partA === undefined || partB === undefined) {
+      return partA === undefined ? -1 : 1
+    }
+
+    const [numberA, numberB] = [Number(partA), Number(partB)]
+    const diff =
+      !isNaN(numberA) && !isNaN(numberB) ? numberA - numberB : partA.localeCompare(partB)
+
+    if (diff !== 0) {
+      return Math.sign(diff)
+    }
+  }
+
+  return 0
+}
+
+/**
+ * Returns true if this node is in the staged rollout of a version. Each node
+ * falls in a stable bucket from 0 to 99 derived from its id.
+ */
+export function isInRollout(version: ApiVersion, nodeId: string): boolean {
+  const hash = crypto.createHash('sha256').update(`${nodeId}:${version.version}`).digest()
+  return hash.readUInt32BE(0) % 100 < version.rollout
+}
+
+/**
+ * Downloads, verifies and installs packaged releases of the CLI into the
+ * client dir and swaps the client bin to them, keeping the previous version
+ * to roll back to if the new one crashes on startup
+ */
+export class Updater {
+  readonly files: FileSystem
+  readonly api: WebApi
+  readonly logger: Logger
+  readonly clientDir: string
+  readonly store: FileStore<UpdateState>
+
+  constructor(options: {
+    files: FileSystem
+    api?: WebApi
+    logger?: Logger
+    clientDir?: string
+  }) {
+    this.files = options.files
+    this.api = options.api ?? new WebApi()
+    this.logger = (options.logger ?? createRootLogger()).withTag('updater')
+    this.clientDir = options.clientDir ?? getClientDir()
+    this.store = new FileStore<UpdateState>(this.files, 'update.json', this.clientDir)
+  }
+
+  /**
+   * Packaged releases run from the bash bin script, which Windows doesn't use
+   */
+  get supported(): boolean {
+    return process.platform !== 'win32'
+  }
+
+  async loadState(): Promise<UpdateState> {
+    const state = await this.store.load().catch(() => null)
+
+    return { ...DEFAULT_UPDATE_STATE, ...state }
+  }
+
+  async saveState(state: UpdateState): Promise<void> {
+    await this.store.save(state)
+  }
+
+  /**
+   * Returns the latest release on the channel if it's newer than the running version
+   */
+  async check(channel: ReleaseChannel = 'stable'): Promise<ApiVersion | null> {
+    const latest = await this.api.getLatestVersion(channel)
+
+    if (!latest || compareVersions(latest.version, IronfishCliPKG.version) <= 0) {
+      return null
+    }
+
+    return latest
+  }
+
+  getAsset(version: ApiVersion): ApiVersionAsset | null {
+    return (
+      version.assets.find((a) => a.platform === process.platform && a.arch === process.arch) ??
+      null
+    )
+  }
+
+  async install(version: ApiVersion): Promise<void> {
+    if (!this.supported) {
+      throw new Error(`Updating is not supported on ${process.platform}, reinstall instead`)
+    }
+
+    const signingKey = getReleaseSigningKey()
+    if (!signingKey) {
+      throw new Error('This build has no release signing key and cannot verify updates')
+    }
+
+    const asset = this.getAsset(version)
+    if (!asset) {
+      throw new Error(`Version ${version.version} has no package for ${process.platform}`)
+    }
+
+    this.logger.info(`Downloading ${version.version} from ${asset.url}`)
+
+    const response = await axios.get<ArrayBuffer>(asset.url, { responseType: 'arraybuffer' })
+    const data = Buffer.from(response.data)
+
+    const sha256 = crypto.createHash('sha256').update(data).digest('hex')
+    if (sha256 !== asset.sha256) {
+      throw new Error(`The package checksum ${sha256} does not match ${asset.sha256}`)
+    }
+
+    const verified = nacl.sign.detached.verify(
+      data,
+      Buffer.from(asset.signature, 'hex'),
+      Buffer.from(signingKey, 'hex'),
+    )
+    if (!verified) {
+      throw new Error('The package signature is not valid')
+    }
+
+    const versionDir = path.join(this.clientDir, version.version)
+    const partialDir = `${versionDir}.partial`
+    const tarball = `${versionDir}.tar.gz`
+
+    await fsAsync.mkdir(partialDir, { recursive: true })
+    await fsAsync.writeFile(tarball, data)
+
+    const result = await runCommand('tar', ['-xf', tarball, '-C', partialDir])
+    await fsAsync.rm(tarball, { force: true })
+    if (result.code !== 0) {
+      await fsAsync.rm(partialDir, { recursive: true, force: true })
+      throw new Error(`Could not extract the package: ${result.stderr.trim()}`)
+    }
+
+    await fsAsync.rm(versionDir, { recursive: true, force: true })
+    await fsAsync.rename(partialDir, versionDir)
+
+    const state = await this.loadState()
+    await this.link(version.version)
+
+    await this.saveState({
+      current: version.version,
+      previous: state.current ?? IronfishCliPKG.version,
+      pending: true,
+      failedStarts: 0,
+    })
+
+    this.logger.info(`Installed ${version.version}, it will be used the next time you start`)
+  }
+
+  /**
+   * Swaps the client to run `version`. The new link is renamed over the old
+   * one so the bin never points at a missing version.
+   */
+  private async link(version: string): Promise<void> {
+    const current = path.join(this.clientDir, 'current')
+    const next = `${current}.next`
+
+    await fsAsync.rm(next, { force: true })
+    await fsAsync.symlink(version, next)
+    await fsAsync.rename(next, current)
+
+    const binDir = path.join(this.clientDir, 'bin')
+    const bin = path.join(binDir, 'ironfish')
+
+    await fsAsync.mkdir(binDir, { recursive: true })
+    await fsAsync.writeFile(
+      bin,
+      `#!/usr/bin/env bash\nexec "${current}/ironfish-cli/bin/ironfish" "$@"\n`,
+      { mode: 0o755 },
+    )
+  }
+
+  /**
+   * Call when the CLI starts the node. Returns the version it rolled back to if
+   * the running version is a pending update that failed to start before.
+   */
+  async onStart(): Promise<string | null> {
+    const state = await this.loadState()
+
+    if (!state.pending || state.current !== IronfishCliPKG.version) {
+      return null
+    }
+
+    if (state.failedStarts > 0 && state.previous) {
+      return this.rollback(state)
+    }
+
+    // Counted as failed until the node runs long enough to be trusted
+    await this.saveState({ ...state, failedStarts: state.failedStarts + 1 })
+    return null
+  }
+
+  /**
+   * Call once the node has run for UPDATE_HEALTHY_AFTER_MS, or shut down cleanly
+   */
+  async onHealthy(): Promise<void> {
+    const state = await this.loadState()
+
+    if (!state.pending || state.current !== IronfishCliPKG.version) {
+      return
+    }
+
+    await this.saveState({ ...state, pending: false, failedStarts: 0 })
+  }
+
+  async rollback(state?: UpdateState): Promise<string> {
+    state = state ?? (await this.loadState())
+
+    if (!state.previous) {
+      throw new Error('There is no previous version to roll back to')
+    }
+
+    const previous = state.previous
+    const previousDir = path.join(this.clientDir, previous)
+
+    if (await this.files.exists(previousDir)) {
+      await this.link(previous)
+    } else {
+      // The previous version was not installed by the updater, stop redirecting to the client
+      await fsAsync.rm(path.join(this.clientDir, 'bin', 'ironfish'), { force: true })
+    }
+
+    await this.saveState({
+      current: previous,
+      previous: null,
+      pending: false,
+      failedStarts: 0,
+    })
+
+    this.logger.warn(`Rolled back from ${state.current ?? 'unknown'} to ${previous}`)
+    return previous
+  }
+}
@@ -22,6 +22,16 @@ export interface Key {
 }
 export function generateKey(): Key
 export function generateNewPublicAddress(privateKey: string): Key
+/**
+ * Sign a message with the spending key of `public_address`, proving the
+ * signer owns the address
+ */
+export function signMessage(spendingKey: string, publicAddress: string, message: Buffer): Buffer
+/**
+ * Returns false if the signature is invalid or was not made by the owner of
+ * `public_address` for `message`
+ */
+export function verifyMessage(publicAddress: string, message: Buffer, signature: Buffer): boolean
 export function initializeSapling(): void
 export type NativeNoteEncrypted = NoteEncrypted
 export class NoteEncrypted {
@@ -38,7 +48,39 @@ export class NoteEncrypted {
   decryptNoteForOwner(incomingHexKey: string): Buffer | undefined | null
   /** Returns undefined if the note was unable to be decrypted with the given key. */
   decryptNoteForSpender(outgoingHexKey: string): Buffer | undefined | null
+  /**
+   * Returns undefined if the note can't be decrypted with the given key.
+   * The disclosure key decrypts only this note.
+   */
+  disclosureKeyForOwner(incomingHexKey: string): Buffer | undefined | null
+  /**
+   * Returns undefined if the note can't be decrypted with the given key.
+   * The disclosure key decrypts only this note.
+   */
+  disclosureKeyForSpender(outgoingHexKey: string): Buffer | undefined | null
+  /**
+   * Returns undefined if the disclosure key doesn't decrypt this note for
+   * `owner`
+   */
+  decryptNoteForDisclosure(owner: string, disclosureKey: Buffer): Buffer | undefined | null
+}
+export interface DecryptedNoteMatch {
+  /** The index of the note in the batch */
+  noteIndex: number
+  /** The index of the keys that decrypted the note */
+  keyIndex: number
+  /** True if the note was decrypted as its spender with the outgoing view key */
+  forSpender: boolean
+  note: Buffer
 }
+/**
+ * Trial decrypt many notes with many accounts' view keys in one call, so
+ * the keys are parsed once and each note doesn't cross into native code
+ * for every key. A note is decrypted as its owner with each incoming view
+ * key, or as its spender with the outgoing view key at the same index if
+ * that fails. Returns a match for every note and key pair that decrypts.
+ */
+export function decryptNotes(serializedNotes: Array<Buffer>, incomingHexKeys: Array<string>, outgoingHexKeys: Array<string>): Array<DecryptedNoteMatch>
 export type NativeNote = Note
 export class Note {
   constructor(owner: string, value: bigint, memo: string)
@@ -76,6 +118,11 @@ export class TransactionPosted {
   hash(): Buffer
   expirationSequence(): number
 }
+/**
+ * Verify many serialized transactions at once, batching their proofs together.
+ * Returns false if any transaction is invalid, without saying which one.
+ */
+export function verifyTransactions(serializedTransactions: Array<Buffer>): boolean
 export type NativeTransaction = Transaction
 export class Transaction {
   constructor()
@@ -112,7 +159,7 @@ export class FoundBlockResult {
   constructor(randomness: string, miningRequestId: number)
 }
 export class ThreadPoolHandler {
-  constructor(threadCount: number, batchSize: number)
+  constructor(threadCount: number, batchSize: number, maxCpu?: number | undefined | null)
   newWork(headerBytes: Buffer, target: Buffer, miningRequestId: number): void
   stop(): void
   pause(): void
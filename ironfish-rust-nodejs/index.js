@@ -236,14 +236,18 @@ if (!nativeBinding) {
   throw new Error(`Failed to load native binding`)
 }
 
-const { NoteEncrypted, Note, TransactionPosted, Transaction, generateKey, generateNewPublicAddress, initializeSapling, FoundBlockResult, ThreadPoolHandler } = nativeBinding
+const { NoteEncrypted, decryptNotes, Note, TransactionPosted, verifyTransactions, Transaction, generateKey, generateNewPublicAddress, signMessage, verifyMessage, initializeSapling, FoundBlockResult, ThreadPoolHandler } = nativeBinding
 
 module.exports.NoteEncrypted = NoteEncrypted
+module.exports.decryptNotes = decryptNotes
 module.exports.Note = Note
 module.exports.TransactionPosted = TransactionPosted
+module.exports.verifyTransactions = verifyTransactions
 module.exports.Transaction = Transaction
 module.exports.generateKey = generateKey
 module.exports.generateNewPublicAddress = generateNewPublicAddress
+module.exports.signMessage = signMessage
+module.exports.verifyMessage = verifyMessage
 module.exports.initializeSapling = initializeSapling
 module.exports.FoundBlockResult = FoundBlockResult
 module.exports.ThreadPoolHandler = ThreadPoolHandler
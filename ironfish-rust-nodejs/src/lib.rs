@@ -2,7 +2,7 @@
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 
-use ironfish_rust::SaplingKey;
+use ironfish_rust::{MessageSignature, PublicAddress, SaplingKey};
 use napi::bindgen_prelude::*;
 use napi::Error;
 use napi_derive::napi;
@@ -49,6 +49,45 @@ pub fn generate_new_public_address(private_key: String) -> Result<Key> {
     })
 }
 
+/// Sign a message with the spending key of `public_address`, proving the
+/// signer owns the address
+#[napi]
+pub fn sign_message(
+    spending_key: String,
+    public_address: String,
+    message: Buffer,
+) -> Result<Buffer> {
+    let key =
+        SaplingKey::from_hex(&spending_key).map_err(|err| Error::from_reason(err.to_string()))?;
+    let address = PublicAddress::from_hex(&public_address)
+        .map_err(|err| Error::from_reason(err.to_string()))?;
+
+    let signature = MessageSignature::sign(&key, &address, &message)
+        .map_err(|err| Error::from_reason(err.to_string()))?;
+
+    let mut bytes = vec![];
+    signature
+        .write(&mut bytes)
+        .map_err(|err| Error::from_reason(err.to_string()))?;
+
+    Ok(Buffer::from(bytes))
+}
+
+/// Returns false if the signature is invalid or was not made by the owner of
+/// `public_address` for `message`
+#[napi]
+pub fn verify_message(public_address: String, message: Buffer, signature: Buffer) -> bool {
+    let address = match PublicAddress::from_hex(&public_address) {
+        Ok(address) => address,
+        Err(_) => return false,
+    };
+
+    match MessageSignature::read(&signature[..]) {
+        Ok(signature) => signature.verify(&address, &message),
+        Err(_) => false,
+    }
+}
+
 #[napi]
 pub fn initialize_sapling() {
     let _ = sapling_bls12::SAPLING.clone();
@@ -69,9 +108,13 @@ struct ThreadPoolHandler {
 impl ThreadPoolHandler {
     #[napi(constructor)]
     #[allow(dead_code)]
-    pub fn new(thread_count: u32, batch_size: u32) -> Self {
+    pub fn new(thread_count: u32, batch_size: u32, max_cpu: Option<u32>) -> Self {
         ThreadPoolHandler {
-            threadpool: mining::threadpool::ThreadPool::new(thread_count as usize, batch_size),
+            threadpool: mining::threadpool::ThreadPool::new(
+                thread_count as usize,
+                batch_size,
+                max_cpu.unwrap_or(100),
+            ),
         }
     }
 
@@ -5,6 +5,7 @@
 use ironfish_rust::IncomingViewKey;
 use ironfish_rust::MerkleNoteHash;
 use ironfish_rust::OutgoingViewKey;
+use ironfish_rust::PublicAddress;
 use napi::bindgen_prelude::*;
 use napi_derive::napi;
 
@@ -112,4 +113,130 @@ impl NativeNoteEncrypted {
             },
         )
     }
+
+    /// Returns undefined if the note can't be decrypted with the given key.
+    /// The disclosure key decrypts only this note.
+    #[napi]
+    pub fn disclosure_key_for_owner(&self, incoming_hex_key: String) -> Result<Option<Buffer>> {
+        let incoming_view_key = IncomingViewKey::from_hex(&incoming_hex_key)
+            .map_err(|err| Error::from_reason(err.to_string()))?;
+
+        Ok(self
+            .note
+            .disclosure_key_for_owner(&incoming_view_key)
+            .ok()
+            .map(|key| Buffer::from(key.to_vec())))
+    }
+
+    /// Returns undefined if the note can't be decrypted with the given key.
+    /// The disclosure key decrypts only this note.
+    #[napi]
+    pub fn disclosure_key_for_spender(&self, outgoing_hex_key: String) -> Result<Option<Buffer>> {
+        let outgoing_view_key = OutgoingViewKey::from_hex(&outgoing_hex_key)
+            .map_err(|err| Error::from_reason(err.to_string()))?;
+
+        Ok(self
+            .note
+            .disclosure_key_for_spender(&outgoing_view_key)
+            .ok()
+            .map(|key| Buffer::from(key.to_vec())))
+    }
+
+    /// Returns undefined if the disclosure key doesn't decrypt this note for
+    /// `owner`
+    #[napi]
+    pub fn decrypt_note_for_disclosure(
+        &self,
+        owner: String,
+        disclosure_key: Buffer,
+    ) -> Result<Option<Buffer>> {
+        let owner =
+            PublicAddress::from_hex(&owner).map_err(|err| Error::from_reason(err.to_string()))?;
+
+        let disclosure_key: [u8; 32] = match disclosure_key.as_ref().try_into() {
+            Ok(key) => key,
+            Err(_) => return Ok(None),
+        };
+
+        Ok(
+            match self.note.decrypt_note_for_disclosure(&owner, &disclosure_key) {
+                Ok(note) => {
+                    let mut vec = vec![];
+                    note.write(&mut vec)
+                        .map_err(|err| Error::from_reason(err.to_string()))?;
+                    Some(Buffer::from(vec))
+                }
+                Err(_) => None,
+            },
+        )
+    }
+}
+
+#[napi(object)]
+pub struct DecryptedNoteMatch {
+    /// The index of the note in the batch
+    pub note_index: u32,
+    /// The index of the keys that decrypted the note
+    pub key_index: u32,
+    /// True if the note was decrypted as its spender with the outgoing view key
+    pub for_spender: bool,
+    pub note: Buffer,
+}
+
+/// Trial decrypt many notes with many accounts' view keys in one call, so
+/// the keys are parsed once and each note doesn't cross into native code
+/// for every key. A note is decrypted as its owner with each incoming view
+/// key, or as its spender with the outgoing view key at the same index if
+/// that fails. Returns a match for every note and key pair that decrypts.
+#[napi]
+pub fn decrypt_notes(
+    serialized_notes: Vec<Buffer>,
+    incoming_hex_keys: Vec<String>,
+    outgoing_hex_keys: Vec<String>,
+) -> Result<Vec<DecryptedNoteMatch>> {
+    if incoming_hex_keys.len() != outgoing_hex_keys.len() {
+        return Err(Error::from_reason(
+            "Expected an outgoing view key for every incoming view key".to_string(),
+        ));
+    }
+
+    let mut keys = Vec::with_capacity(incoming_hex_keys.len());
+
+    for (incoming_hex_key, outgoing_hex_key) in incoming_hex_keys.iter().zip(&outgoing_hex_keys) {
+        let incoming_view_key = IncomingViewKey::from_hex(incoming_hex_key)
+            .map_err(|err| Error::from_reason(err.to_string()))?;
+        let outgoing_view_key = OutgoingViewKey::from_hex(outgoing_hex_key)
+            .map_err(|err| Error::from_reason(err.to_string()))?;
+
+        keys.push((incoming_view_key, outgoing_view_key));
+    }
+
+    let mut matches = vec![];
+
+    for (note_index, bytes) in serialized_notes.iter().enumerate() {
+        let note =
+            MerkleNote::read(bytes.as_ref()).map_err(|err| Error::from_reason(err.to_string()))?;
+
+        for (key_index, (incoming_view_key, outgoing_view_key)) in keys.iter().enumerate() {
+            let (decrypted, for_spender) = match note.decrypt_note_for_owner(incoming_view_key) {
+                Ok(decrypted) => (decrypted, false),
+                Err(_) => match note.decrypt_note_for_spender(outgoing_view_key) {
+                    Ok(decrypted) => (decrypted, true),
+                    Err(_) => continue,
+                },
+            };
+
+            let mut vec = vec![];
+            decrypted.write(&mut vec).map_err(|err| Error::from_reason(err.to_string()))?;
+
+            matches.push(DecryptedNoteMatch {
+                note_index: note_index as u32,
+                key_index: key_index as u32,
+                for_spender,
+                note: Buffer::from(vec),
+            });
+        }
+    }
+
+    Ok(matches)
 }
@@ -5,6 +5,7 @@
 use std::cell::RefCell;
 use std::convert::TryInto;
 
+use ironfish_rust::transaction::batch_verify_transactions;
 use ironfish_rust::{MerkleNoteHash, ProposedTransaction, PublicAddress, SaplingKey, Transaction};
 use napi::bindgen_prelude::*;
 use napi_derive::napi;
@@ -143,6 +144,23 @@ impl NativeTransactionPosted {
     }
 }
 
+/// Verify many serialized transactions at once, batching their proofs together.
+/// Returns false if any transaction is invalid, without saying which one.
+#[napi]
+pub fn verify_transactions(serialized_transactions: Vec<Buffer>) -> Result<bool> {
+    let mut transactions: Vec<Transaction> = vec![];
+
+    for bytes in serialized_transactions {
+        let mut cursor = std::io::Cursor::new(bytes);
+        let transaction = Transaction::read(SAPLING.clone(), &mut cursor)
+            .map_err(|err| Error::from_reason(err.to_string()))?;
+
+        transactions.push(transaction);
+    }
+
+    Ok(batch_verify_transactions(transactions.iter()).is_ok())
+}
+
 #[napi(js_name = "Transaction")]
 pub struct NativeTransaction {
     transaction: ProposedTransaction,
@@ -0,0 +1,136 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+//! Signatures that prove the signer holds the spending key for a public
+//! address without having to send a transaction to it.
+//!
+//! Messages are signed with the spend authorizing key `ask`, the same way
+//! spends are authorized, so the view keys alone can't make a signature. The
+//! signature carries the authorizing key `ak` and nullifier deriving key `nk`,
+//! which hash to the incoming view key, so it can be checked against the
+//! transmission key `ivk * g_d` with only the address. Because it has to, the
+//! signature shares the incoming view key with anyone it is given to.
+
+use super::{errors, PublicAddress, SaplingKey};
+use blake2b_simd::Params as Blake2b;
+use group::GroupEncoding;
+use jubjub::SubgroupPoint;
+use rand::rngs::OsRng;
+use zcash_primitives::constants::SPENDING_KEY_GENERATOR;
+use zcash_primitives::redjubjub;
+
+use std::io;
+
+const MESSAGE_SIGNATURE_PERSONALIZATION: &[u8; 16] = b"Iron Fish msgsig";
+
+/// A signature binding a message to a public address
+#[derive(Clone)]
+pub struct MessageSignature {
+    /// `ak`, the public key the signature is checked with
+    authorizing_key: SubgroupPoint,
+
+    /// `nk`, which is hashed with `ak` to check they belong to the address
+    nullifier_deriving_key: SubgroupPoint,
+
+    /// Signature of the message by `ask`
+    signature: redjubjub::Signature,
+}
+
+impl MessageSignature {
+    /// Sign `message` for `address`, which has to belong to `key`
+    pub fn sign(
+        key: &SaplingKey,
+        address: &PublicAddress,
+        message: &[u8],
+    ) -> Result<Self, errors::SaplingKeyError> {
+        let view_key = key.incoming_view_key().view_key;
+        if address.diversifier_point * view_key != address.transmission_key {
+            return Err(errors::SaplingKeyError::InvalidPublicAddress);
+        }
+
+        let ak = &key.authorizing_key;
+        let nk = &key.nullifier_deriving_key;
+        let data = signed_data(address, ak, nk, message);
+
+        let private_key = redjubjub::PrivateKey(key.spend_authorizing_key);
+        let signature = private_key.sign(&data, &mut OsRng, SPENDING_KEY_GENERATOR);
+
+        Ok(MessageSignature {
+            authorizing_key: key.authorizing_key,
+            nullifier_deriving_key: key.nullifier_deriving_key,
+            signature,
+        })
+    }
+
+    /// Check that the signature was made for `message` by the owner of `address`
+    pub fn verify(&self, address: &PublicAddress, message: &[u8]) -> bool {
+        let ak = &self.authorizing_key;
+        let nk = &self.nullifier_deriving_key;
+
+        match SaplingKey::hash_viewing_key(ak, nk) {
+            Ok(view_key) if address.diversifier_point * view_key == address.transmission_key => {}
+            _ => return false,
+        }
+
+        let data = signed_data(address, ak, nk, message);
+        let public_key = redjubjub::PublicKey((*ak).into());
+        public_key.verify(&data, &self.signature, SPENDING_KEY_GENERATOR)
+    }
+
+    /// Load a signature from a Read implementation
+    pub fn read<R: io::Read>(mut reader: R) -> Result<Self, errors::SaplingKeyError> {
+        let authorizing_key = read_point(&mut reader)?;
+        let nullifier_deriving_key = read_point(&mut reader)?;
+        let signature = redjubjub::Signature::read(&mut reader)?;
+
+        Ok(MessageSignature {
+            authorizing_key,
+            nullifier_deriving_key,
+            signature,
+        })
+    }
+
+    /// Store the 128 byte signature in a Write implementation
+    pub fn write<W: io::Write>(&self, mut writer: W) -> io::Result<()> {
+        writer.write_all(&self.authorizing_key.to_bytes())?;
+        writer.write_all(&self.nullifier_deriving_key.to_bytes())?;
+        self.signature.write(&mut writer)?;
+        Ok(())
+    }
+}
+
+fn read_point<R: io::Read>(reader: &mut R) -> Result<SubgroupPoint, errors::SaplingKeyError> {
+    let mut bytes = [0u8; 32];
+    reader.read_exact(&mut bytes)?;
+
+    let point = SubgroupPoint::from_bytes(&bytes);
+    if point.is_none().into() {
+        return Err(errors::SaplingKeyError::IOError);
+    }
+
+    Ok(point.unwrap())
+}
+
+/// Hash the address, the keys and the message into the data that is signed,
+/// so a signature can't be moved to another address, key or message
+fn signed_data(
+    address: &PublicAddress,
+    authorizing_key: &SubgroupPoint,
+    nullifier_deriving_key: &SubgroupPoint,
+    message: &[u8],
+) -> [u8; 64] {
+    let mut hasher = Blake2b::new()
+        .hash_length(64)
+        .personal(MESSAGE_SIGNATURE_PERSONALIZATION)
+        .to_state();
+
+    hasher.update(&address.public_address());
+    hasher.update(&authorizing_key.to_bytes());
+    hasher.update(&nullifier_deriving_key.to_bytes());
+    hasher.update(message);
+
+    let mut hash = [0u8; 64];
+    hash.copy_from_slice(hasher.finalize().as_bytes());
+    hash
+}
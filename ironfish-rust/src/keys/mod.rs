@@ -20,6 +20,8 @@ use zcash_primitives::primitives::{ProofGenerationKey, ViewingKey};
 
 use std::io;
 
+mod message_signature;
+pub use message_signature::*;
 mod public_address;
 pub use public_address::*;
 mod view_keys;
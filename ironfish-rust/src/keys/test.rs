@@ -2,7 +2,7 @@
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 
-use super::{shared_secret, PublicAddress, SaplingKey};
+use super::{shared_secret, MessageSignature, PublicAddress, SaplingKey};
 use group::Curve;
 use jubjub::ExtendedPoint;
 
@@ -88,3 +88,52 @@ fn test_hex_conversion() {
 
     assert!(PublicAddress::from_hex("invalid").is_err());
 }
+
+#[test]
+fn test_message_signature() {
+    let key: SaplingKey = SaplingKey::generate_key();
+    let address = key.generate_public_address();
+    let other_address = SaplingKey::generate_key().generate_public_address();
+
+    let signature = MessageSignature::sign(&key, &address, b"hello")
+        .expect("should be able to sign for an address of the key");
+    assert!(signature.verify(&address, b"hello"));
+    assert!(!signature.verify(&address, b"goodbye"));
+    assert!(!signature.verify(&other_address, b"hello"));
+
+    let mut serialized = [0; 128];
+    signature
+        .write(&mut serialized[..])
+        .expect("should be able to serialize signature");
+    let read_back = MessageSignature::read(&serialized[..])
+        .expect("should be able to load signature from valid bytes");
+    assert!(read_back.verify(&address, b"hello"));
+
+    // Keys can't sign for addresses they don't own
+    assert!(MessageSignature::sign(&key, &other_address, b"hello").is_err());
+}
+
+#[test]
+fn test_message_signature_needs_spending_key() {
+    let key: SaplingKey = SaplingKey::generate_key();
+    let address = key.generate_public_address();
+    let attacker = SaplingKey::generate_key();
+
+    // Someone with only the view keys knows `ak` and `nk` but not `ask`, so
+    // the best they can do is sign with another key and claim the real keys
+    let mut serialized = [0; 128];
+    MessageSignature::sign(&attacker, &attacker.generate_public_address(), b"hello")
+        .unwrap()
+        .write(&mut serialized[..])
+        .unwrap();
+    serialized[0..32].copy_from_slice(&key.authorizing_key.to_bytes());
+    serialized[32..64].copy_from_slice(&key.nullifier_deriving_key.to_bytes());
+
+    let forged = MessageSignature::read(&serialized[..]).unwrap();
+    assert!(!forged.verify(&address, b"hello"));
+
+    // Without claiming them the keys don't hash to the address
+    let own =
+        MessageSignature::sign(&attacker, &attacker.generate_public_address(), b"hello").unwrap();
+    assert!(!own.verify(&address, b"hello"));
+}
@@ -21,7 +21,9 @@ pub mod spending;
 pub mod transaction;
 pub mod witness;
 pub use {
-    keys::{IncomingViewKey, OutgoingViewKey, PublicAddress, SaplingKey, ViewKeys},
+    keys::{
+        IncomingViewKey, MessageSignature, OutgoingViewKey, PublicAddress, SaplingKey, ViewKeys,
+    },
     merkle_note::MerkleNote,
     merkle_note_hash::MerkleNoteHash,
     note::Note,
@@ -180,6 +180,62 @@ impl MerkleNote {
         &self,
         spender_key: &OutgoingViewKey,
     ) -> Result<Note, errors::NoteError> {
+        let (transmission_key, shared_key) = self.spender_shared_key(spender_key)?;
+        let note =
+            Note::from_spender_encrypted(transmission_key, &shared_key, &self.encrypted_note)?;
+        note.verify_commitment(self.note_commitment)?;
+        Ok(note)
+    }
+
+    /// The key this note alone is encrypted with, for the owner to disclose
+    /// the note to someone else without giving them a view key
+    pub fn disclosure_key_for_owner(
+        &self,
+        owner_view_key: &IncomingViewKey,
+    ) -> Result<[u8; 32], errors::NoteError> {
+        self.decrypt_note_for_owner(owner_view_key)?;
+        Ok(owner_view_key.shared_secret(&self.ephemeral_public_key))
+    }
+
+    /// The key this note alone is encrypted with, for the spender to disclose
+    /// the note to someone else without giving them a view key
+    pub fn disclosure_key_for_spender(
+        &self,
+        spender_key: &OutgoingViewKey,
+    ) -> Result<[u8; 32], errors::NoteError> {
+        self.decrypt_note_for_spender(spender_key)?;
+        let (_, shared_key) = self.spender_shared_key(spender_key)?;
+        Ok(shared_key)
+    }
+
+    /// Decrypt the note with a key from `disclosure_key_for_owner` or
+    /// `disclosure_key_for_spender`. This fails unless the decrypted note is
+    /// owned by `owner` and matches the note commitment.
+    pub fn decrypt_note_for_disclosure(
+        &self,
+        owner: &PublicAddress,
+        disclosure_key: &[u8; 32],
+    ) -> Result<Note, errors::NoteError> {
+        let note = Note::from_spender_encrypted(
+            owner.transmission_key,
+            disclosure_key,
+            &self.encrypted_note,
+        )?;
+        note.verify_commitment(self.note_commitment)?;
+
+        if note.owner.public_address() != owner.public_address() {
+            return Err(errors::NoteError::KeyError);
+        }
+
+        Ok(note)
+    }
+
+    /// Decrypt the transmission key of the owner and the shared key the note
+    /// is encrypted with using the spender's view key
+    fn spender_shared_key(
+        &self,
+        spender_key: &OutgoingViewKey,
+    ) -> Result<(SubgroupPoint, [u8; 32]), errors::NoteError> {
         let encryption_key = calculate_key_for_encryption_keys(
             spender_key,
             &self.value_commitment,
@@ -197,10 +253,7 @@ impl MerkleNote {
         let transmission_key = PublicAddress::load_transmission_key(&note_encryption_keys[..32])?;
         let secret_key = read_scalar(&note_encryption_keys[32..])?;
         let shared_key = shared_secret(&secret_key, &transmission_key, &self.ephemeral_public_key);
-        let note =
-            Note::from_spender_encrypted(transmission_key, &shared_key, &self.encrypted_note)?;
-        note.verify_commitment(self.note_commitment)?;
-        Ok(note)
+        Ok((transmission_key, shared_key))
     }
 }
 
@@ -304,6 +357,52 @@ mod test {
             .expect("should be able to decrypt note");
     }
 
+    #[test]
+    fn test_disclosure_key() {
+        let spender_key: SaplingKey = SaplingKey::generate_key();
+        let receiver_key: SaplingKey = SaplingKey::generate_key();
+        let receiver_address = receiver_key.generate_public_address();
+        let note = Note::new(receiver_address.clone(), 42, Memo::from("invoice 1"));
+        let diffie_hellman_keys = note.owner.generate_diffie_hellman_keys();
+
+        let mut buffer = [0u8; 64];
+        thread_rng().fill(&mut buffer[..]);
+
+        let value_commitment = ValueCommitment {
+            value: note.value,
+            randomness: jubjub::Fr::from_bytes_wide(&buffer),
+        };
+
+        let merkle_note =
+            MerkleNote::new(&spender_key, &note, &value_commitment, &diffie_hellman_keys);
+
+        let owner_key = merkle_note
+            .disclosure_key_for_owner(receiver_key.incoming_view_key())
+            .expect("should be able to get the disclosure key as the owner");
+        let spender_disclosure_key = merkle_note
+            .disclosure_key_for_spender(spender_key.outgoing_view_key())
+            .expect("should be able to get the disclosure key as the spender");
+        assert_eq!(owner_key, spender_disclosure_key);
+
+        let disclosed = merkle_note
+            .decrypt_note_for_disclosure(&receiver_address, &owner_key)
+            .expect("should be able to decrypt the disclosed note");
+        assert_eq!(disclosed.value, 42);
+        assert_eq!(disclosed.memo, note.memo);
+
+        // The key only works for the owner it was disclosed for
+        let other_address = spender_key.generate_public_address();
+        assert!(merkle_note
+            .decrypt_note_for_disclosure(&other_address, &owner_key)
+            .is_err());
+        assert!(merkle_note
+            .decrypt_note_for_disclosure(&receiver_address, &[0; 32])
+            .is_err());
+        assert!(merkle_note
+            .disclosure_key_for_owner(spender_key.incoming_view_key())
+            .is_err());
+    }
+
     #[test]
     fn test_receipt_invalid_commitment() {
         let spender_key: SaplingKey = SaplingKey::generate_key();
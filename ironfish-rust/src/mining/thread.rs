@@ -3,8 +3,9 @@
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 use std::{
     collections::VecDeque,
-    sync::mpsc::{self, Receiver, SendError, Sender},
+    sync::mpsc::{self, Receiver, RecvTimeoutError, SendError, Sender},
     thread,
+    time::{Duration, Instant},
 };
 
 use super::mine;
@@ -30,6 +31,7 @@ impl Thread {
         hash_rate_channel: Sender<u32>,
         pool_size: usize,
         batch_size: u32,
+        max_cpu: u32,
     ) -> Self {
         let (work_sender, work_receiver) = mpsc::channel::<Command>();
 
@@ -43,6 +45,7 @@ impl Thread {
                     id,
                     pool_size,
                     batch_size as u64,
+                    max_cpu,
                 )
             })
             .unwrap();
@@ -78,6 +81,7 @@ fn process_commands(
     start: u64,
     step_size: usize,
     default_batch_size: u64,
+    max_cpu: u32,
 ) {
     let mut commands: VecDeque<Command> = VecDeque::new();
     loop {
@@ -98,6 +102,7 @@ fn process_commands(
                     } else {
                         remaining_search_space
                     };
+                    let batch_started = Instant::now();
                     let match_found = mine::mine_batch(
                         &mut header_bytes,
                         &target,
@@ -105,6 +110,7 @@ fn process_commands(
                         step_size,
                         batch_size,
                     );
+                    let busy = batch_started.elapsed();
 
                     // Submit amount of work done
                     let work_done = match match_found {
@@ -127,6 +133,19 @@ fn process_commands(
                         }
                     }
 
+                    // Rest long enough that mining takes at most max_cpu percent of
+                    // the time, waking up early if there is a new command
+                    if max_cpu < 100 {
+                        match work_receiver.recv_timeout(throttle_rest(busy, max_cpu)) {
+                            Ok(cmd) => {
+                                commands.push_back(cmd);
+                                break;
+                            }
+                            Err(RecvTimeoutError::Timeout) => {}
+                            Err(RecvTimeoutError::Disconnected) => return,
+                        }
+                    }
+
                     if remaining_search_space < default_batch_size {
                         // miner has exhausted it's search space, stop mining
                         println!("Search space exhausted, no longer mining this block.");
@@ -144,3 +163,42 @@ fn process_commands(
         }
     }
 }
+
+/// How long a thread that was busy mining for `busy` has to rest so that
+/// mining takes at most `max_cpu` percent of its time
+fn throttle_rest(busy: Duration, max_cpu: u32) -> Duration {
+    if max_cpu >= 100 {
+        return Duration::ZERO;
+    }
+
+    busy * (100 - max_cpu) / max_cpu.max(1)
+}
+
+#[cfg(test)]
+mod test {
+    use std::time::Duration;
+
+    use super::throttle_rest;
+
+    #[test]
+    fn test_throttle_rest() {
+        let busy = Duration::from_millis(100);
+
+        assert_eq!(throttle_rest(busy, 100), Duration::ZERO);
+        assert_eq!(throttle_rest(busy, 50), Duration::from_millis(100));
+        assert_eq!(throttle_rest(busy, 25), Duration::from_millis(300));
+        assert_eq!(throttle_rest(busy, 10), Duration::from_millis(900));
+        assert_eq!(throttle_rest(busy, 1), Duration::from_millis(9900));
+    }
+
+    #[test]
+    fn test_throttle_rest_keeps_the_max_cpu_share() {
+        for max_cpu in 1..=100 {
+            let busy = Duration::from_millis(40);
+            let total = busy + throttle_rest(busy, max_cpu);
+            let share = busy.as_secs_f64() / total.as_secs_f64() * 100.0;
+
+            assert!((share - max_cpu as f64).abs() < 0.01);
+        }
+    }
+}
@@ -12,7 +12,9 @@ pub struct ThreadPool {
     mining_request_id: u32,
 }
 impl ThreadPool {
-    pub fn new(thread_count: usize, batch_size: u32) -> Self {
+    /// `max_cpu` is the percent of the time each thread may spend mining,
+    /// from 1 to 100. Threads rest between batches to stay under it.
+    pub fn new(thread_count: usize, batch_size: u32, max_cpu: u32) -> Self {
         let (block_found_channel, block_found_receiver) = mpsc::channel::<(u64, u32)>();
 
         let (hash_rate_channel, hash_rate_receiver) = mpsc::channel::<u32>();
@@ -25,6 +27,7 @@ impl ThreadPool {
                 hash_rate_channel.clone(),
                 thread_count,
                 batch_size,
+                max_cpu.clamp(1, 100),
             ));
         }
 
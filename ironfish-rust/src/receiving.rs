@@ -135,6 +135,21 @@ impl ReceiptProof {
     /// Verify that the proof demonstrates knowledge that a note exists with
     /// the value_commitment, public_key, and note_commitment on this proof.
     pub fn verify_proof(&self, sapling: &Sapling) -> Result<(), errors::SaplingProofError> {
+        let public_input = self.public_inputs()?;
+
+        match groth16::verify_proof(
+            &sapling.receipt_verifying_key,
+            &self.proof,
+            &public_input[..],
+        ) {
+            Ok(()) => Ok(()),
+            _ => Err(errors::SaplingProofError::VerificationFailed),
+        }
+    }
+
+    /// Convert the values on this proof into the public inputs of the output
+    /// circuit. Shared by single and batch proof verification.
+    pub(crate) fn public_inputs(&self) -> Result<[Scalar; 5], errors::SaplingProofError> {
         if self.merkle_note.value_commitment.is_small_order().into()
             || ExtendedPoint::from(self.merkle_note.ephemeral_public_key)
                 .is_small_order()
@@ -153,15 +168,9 @@ impl ReceiptProof {
 
         public_input[4] = self.merkle_note.note_commitment;
 
-        match groth16::verify_proof(
-            &sapling.receipt_verifying_key,
-            &self.proof,
-            &public_input[..],
-        ) {
-            Ok(()) => Ok(()),
-            _ => Err(errors::SaplingProofError::VerificationFailed),
-        }
+        Ok(public_input)
     }
+
     /// Get a MerkleNote, which can be used as a node in a Merkle Tree.
     pub fn merkle_note(&self) -> MerkleNote {
         self.merkle_note.clone()
@@ -338,6 +338,17 @@ impl SpendProof {
     /// This entails converting all the values to appropriate inputs to the
     /// bellman circuit and executing it.
     pub fn verify_proof(&self, sapling: &Sapling) -> Result<(), errors::SaplingProofError> {
+        let public_input = self.public_inputs()?;
+
+        match groth16::verify_proof(&sapling.spend_verifying_key, &self.proof, &public_input[..]) {
+            Ok(()) => Ok(()),
+            _ => Err(errors::SaplingProofError::VerificationFailed),
+        }
+    }
+
+    /// Convert the values on this proof into the public inputs of the spend
+    /// circuit. Shared by single and batch proof verification.
+    pub(crate) fn public_inputs(&self) -> Result<[Scalar; 7], errors::SaplingProofError> {
         if self.value_commitment.is_small_order().into() {
             return Err(errors::SaplingProofError::VerificationFailed);
         }
@@ -358,10 +369,7 @@ impl SpendProof {
         public_input[5] = nullifier[0];
         public_input[6] = nullifier[1];
 
-        match groth16::verify_proof(&sapling.spend_verifying_key, &self.proof, &public_input[..]) {
-            Ok(()) => Ok(()),
-            _ => Err(errors::SaplingProofError::VerificationFailed),
-        }
+        Ok(public_input)
     }
 
     /// Serialize the fields that are needed in calculating a signature to
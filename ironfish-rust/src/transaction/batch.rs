@@ -0,0 +1,113 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+use super::Transaction;
+use crate::errors::TransactionError;
+use bellman::groth16;
+use bls12_381::{multi_miller_loop, Bls12, G1Affine, G1Projective, G2Prepared, Gt, Scalar};
+use ff::Field;
+use rand::rngs::OsRng;
+
+/// Groth16 proofs for a single circuit that are checked together.
+///
+/// Each proof is weighted by a random scalar so that the individual pairing
+/// equations can be folded into one multi miller loop and a single final
+/// exponentiation. A forged proof can only pass if it cancels out against the
+/// random weights, which happens with negligible probability.
+struct ProofBatch {
+    items: Vec<(groth16::Proof<Bls12>, Vec<Scalar>)>,
+}
+
+impl ProofBatch {
+    fn new() -> Self {
+        ProofBatch { items: vec![] }
+    }
+
+    fn queue(&mut self, proof: &groth16::Proof<Bls12>, public_inputs: &[Scalar]) {
+        self.items.push((proof.clone(), public_inputs.to_vec()));
+    }
+
+    fn verify(&self, vk: &groth16::VerifyingKey<Bls12>) -> Result<(), TransactionError> {
+        if self.items.is_empty() {
+            return Ok(());
+        }
+
+        let mut terms: Vec<(G1Affine, G2Prepared)> = Vec::with_capacity(self.items.len() + 3);
+        let mut weight_sum = Scalar::zero();
+        let mut inputs_sum = G1Projective::identity();
+        let mut c_sum = G1Projective::identity();
+
+        for (proof, public_inputs) in self.items.iter() {
+            if public_inputs.len() + 1 != vk.ic.len() {
+                return Err(TransactionError::VerificationFailed);
+            }
+
+            let weight = Scalar::random(&mut OsRng);
+
+            let mut inputs = G1Projective::from(vk.ic[0]);
+            for (input, base) in public_inputs.iter().zip(vk.ic.iter().skip(1)) {
+                inputs += base * input;
+            }
+
+            inputs_sum += inputs * weight;
+            c_sum += proof.c * weight;
+            weight_sum += weight;
+
+            terms.push((G1Affine::from(proof.a * weight), G2Prepared::from(proof.b)));
+        }
+
+        // e(A, B) = e(alpha, beta) * e(inputs, gamma) * e(C, delta) for every
+        // proof, so the weighted product of all of them must be the identity
+        terms.push((G1Affine::from(-(vk.alpha_g1 * weight_sum)), G2Prepared::from(vk.beta_g2)));
+        terms.push((G1Affine::from(-inputs_sum), G2Prepared::from(vk.gamma_g2)));
+        terms.push((G1Affine::from(-c_sum), G2Prepared::from(vk.delta_g2)));
+
+        let terms: Vec<(&G1Affine, &G2Prepared)> = terms.iter().map(|(a, b)| (a, b)).collect();
+
+        if multi_miller_loop(&terms[..]).final_exponentiation() == Gt::identity() {
+            Ok(())
+        } else {
+            Err(TransactionError::VerificationFailed)
+        }
+    }
+}
+
+/// Validate a group of transactions at once. This is equivalent to calling
+/// verify on each transaction, but all of the spend proofs and all of the
+/// receipt proofs are checked in one batch each, which is much cheaper than
+/// checking them one at a time.
+///
+/// An error means at least one transaction is invalid, but not which one.
+/// Callers that need to know should fall back to verifying individually.
+pub fn batch_verify_transactions<'a, I>(transactions: I) -> Result<(), TransactionError>
+where
+    I: IntoIterator<Item = &'a Transaction>,
+{
+    let mut spends = ProofBatch::new();
+    let mut receipts = ProofBatch::new();
+    let mut sapling = None;
+
+    for transaction in transactions {
+        for spend in transaction.spends.iter() {
+            spends.queue(&spend.proof, &spend.public_inputs()?);
+        }
+
+        for receipt in transaction.receipts.iter() {
+            receipts.queue(&receipt.proof, &receipt.public_inputs()?);
+        }
+
+        transaction.verify_signatures()?;
+        sapling.get_or_insert_with(|| transaction.sapling.clone());
+    }
+
+    let sapling = match sapling {
+        Some(sapling) => sapling,
+        None => return Ok(()),
+    };
+
+    spends.verify(&sapling.spend_params.vk)?;
+    receipts.verify(&sapling.receipt_params.vk)?;
+
+    Ok(())
+}
@@ -29,6 +29,9 @@ use std::{io, slice::Iter, sync::Arc};
 use std::ops::AddAssign;
 use std::ops::SubAssign;
 
+mod batch;
+pub use batch::batch_verify_transactions;
+
 #[cfg(test)]
 mod tests;
 
@@ -418,19 +421,32 @@ impl Transaction {
     ///     containing those proofs (and only those proofs)
     ///
     pub fn verify(&self) -> Result<(), TransactionError> {
+        for spend in self.spends.iter() {
+            spend.verify_proof(&self.sapling)?;
+        }
+
+        for receipt in self.receipts.iter() {
+            receipt.verify_proof(&self.sapling)?;
+        }
+
+        self.verify_signatures()
+    }
+
+    /// Validate everything about the transaction except the zero knowledge
+    /// proofs. Confirms that each spend was signed by the owner and that the
+    /// binding signature covers all of the spends and receipts.
+    pub(crate) fn verify_signatures(&self) -> Result<(), TransactionError> {
         // Context to accumulate a signature of all the spends and outputs and
         // guarantee they are part of this transaction, unmodified.
         let mut binding_verification_key = ExtendedPoint::identity();
 
         for spend in self.spends.iter() {
-            spend.verify_proof(&self.sapling)?;
             let mut tmp = spend.value_commitment;
             tmp += binding_verification_key;
             binding_verification_key = tmp;
         }
 
         for receipt in self.receipts.iter() {
-            receipt.verify_proof(&self.sapling)?;
             let mut tmp = receipt.merkle_note.value_commitment;
             tmp = -tmp;
             tmp += binding_verification_key;
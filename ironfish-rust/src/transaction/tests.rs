@@ -3,7 +3,7 @@
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 
 #[cfg(test)]
-use super::{ProposedTransaction, Transaction};
+use super::{batch_verify_transactions, ProposedTransaction, Transaction};
 use crate::{
     keys::SaplingKey,
     merkle_note::NOTE_ENCRYPTION_MINER_KEYS,
@@ -112,6 +112,45 @@ fn test_miners_fee() {
     );
 }
 
+#[test]
+fn test_batch_verify_transactions() {
+    let sapling = sapling_bls12::SAPLING.clone();
+    let spender_key: SaplingKey = SaplingKey::generate_key();
+    let receiver_key: SaplingKey = SaplingKey::generate_key();
+
+    let in_note = Note::new(spender_key.generate_public_address(), 42, Memo::default());
+    let out_note = Note::new(receiver_key.generate_public_address(), 40, Memo::default());
+    let witness = make_fake_witness(&in_note);
+
+    let mut transaction = ProposedTransaction::new(sapling.clone());
+    transaction
+        .spend(spender_key.clone(), &in_note, &witness)
+        .expect("should be able to prove spend");
+    transaction
+        .receive(&spender_key, &out_note)
+        .expect("should be able to prove receipt");
+    let posted_transaction = transaction
+        .post(&spender_key, None, 1)
+        .expect("should be able to post transaction");
+
+    let mut miners_fee = ProposedTransaction::new(sapling);
+    miners_fee
+        .receive(&receiver_key, &out_note)
+        .expect("It's a valid note");
+    let mut posted_miners_fee = miners_fee
+        .post_miners_fee()
+        .expect("it is a valid miner's fee");
+
+    batch_verify_transactions(vec![&posted_transaction, &posted_miners_fee])
+        .expect("should be able to verify a batch of transactions");
+    batch_verify_transactions(Vec::<&Transaction>::new()).expect("an empty batch is valid");
+
+    // A proof moved from another transaction doesn't match the public inputs
+    posted_miners_fee.receipts[0].proof = posted_transaction.receipts[0].proof.clone();
+    assert!(posted_miners_fee.verify().is_err());
+    assert!(batch_verify_transactions(vec![&posted_transaction, &posted_miners_fee]).is_err());
+}
+
 #[test]
 fn test_transaction_signature() {
     let sapling = sapling_bls12::SAPLING.clone();
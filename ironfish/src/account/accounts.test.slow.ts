@@ -177,6 +177,17 @@ describe('Accounts', () => {
       0,
     )
 
+    // The spent note is held until the transaction is mined
+    await expect(node.accounts.getHeldBalance(account)).resolves.toEqual(BigInt(2000000000))
+
+    // The change is pending until the transaction is mined
+    const [breakdown] = await node.accounts.getBalanceBreakdown(account)
+    expect(breakdown).toMatchObject({
+      available: BigInt(0),
+      pendingChange: BigInt(1999999998),
+      locked: BigInt(2000000000),
+    })
+
     // Create a block with a miner's fee
     const minersfee2 = await strategy.createMinersFee(
       transaction.fee(),
@@ -193,6 +204,7 @@ describe('Accounts', () => {
       confirmed: BigInt(1999999998),
       unconfirmed: BigInt(1999999998),
     })
+    await expect(node.accounts.getHeldBalance(account)).resolves.toEqual(BigInt(0))
   }, 600000)
 
   it('Creates valid transactions when the worker pool is enabled', async () => {
@@ -792,4 +804,42 @@ describe('Accounts', () => {
       unconfirmed: BigInt(2),
     })
   }, 600000)
+
+  it('Sweeps at most maxNotes notes in each transaction', async () => {
+    const strategy = nodeTest.strategy
+    const node = nodeTest.node
+    const chain = nodeTest.chain
+
+    const account = await node.accounts.createAccount('sweep', true)
+    const recipient = generateKey().public_address
+
+    for (let sequence = 2; sequence <= 3; sequence++) {
+      const minersfee = await strategy.createMinersFee(BigInt(0), sequence, account.spendingKey)
+      const block = await chain.newBlock([], minersfee)
+      await expect(chain.addBlock(block)).resolves.toMatchObject({ isAdded: true })
+    }
+
+    await node.accounts.updateHead()
+    await expect(node.accounts.getBalance(account)).resolves.toMatchObject({
+      confirmed: BigInt(4000000000),
+    })
+
+    const expiration = node.config.get('defaultTransactionExpirationSequenceDelta')
+    const sweep = () =>
+      node.accounts.sweep(node.memPool, account, recipient, BigInt(1), expiration, {
+        maxNotes: 1,
+      })
+
+    const first = await sweep()
+    expect(first).toMatchObject({ amount: BigInt(1999999999), notes: 1 })
+    expect(first?.transaction.spendsLength()).toBe(1)
+
+    // The note spent by the first sweep is pending, so only one is left
+    await expect(node.accounts.getBalance(account)).resolves.toMatchObject({
+      unconfirmed: BigInt(2000000000),
+    })
+
+    await expect(sweep()).resolves.toMatchObject({ amount: BigInt(1999999999), notes: 1 })
+    await expect(sweep()).resolves.toBeNull()
+  }, 600000)
 })
@@ -1,13 +1,17 @@
 /* This Source Code Form is subject to the terms of the Mozilla Public
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { Assert } from '../assert'
 import { GENESIS_BLOCK_SEQUENCE, VerificationResultReason } from '../consensus'
+import { Transaction } from '../primitives/transaction'
 import {
   createNodeTest,
   useAccountFixture,
   useMinerBlockFixture,
   useTxFixture,
 } from '../testUtilities'
+import { WalletEventType } from './database/events'
+import { NotEnoughFundsError } from './errors'
 
 describe('Accounts', () => {
   const nodeTest = createNodeTest()
@@ -152,6 +156,469 @@ describe('Accounts', () => {
         confirmed: BigInt(0),
         unconfirmed: BigInt(0),
       })
+
+      // The reorg on nodeB removed B1 to B4
+      expect(nodeA.accounts.getConfirmationWarning(1)).toBeNull()
+      expect(nodeB.accounts.getConfirmationWarning(4)).not.toBeNull()
+      expect(nodeB.accounts.getConfirmationWarning(5)).toBeNull()
+    })
+
+    it('returns balances with the given minimum confirmations', async () => {
+      const { node } = await nodeTest.createSetup()
+      const account = await useAccountFixture(node.accounts, 'account')
+
+      // G -> A1 -> A2 -> A3
+      for (let sequence = 2; sequence <= 4; sequence++) {
+        const block = await useMinerBlockFixture(node.chain, sequence, account)
+        await expect(node.chain).toAddBlock(block)
+      }
+      await node.accounts.updateHead()
+
+      const balance = await node.accounts.getBalance(account, { minimumBlockConfirmations: 1 })
+      expect(balance).toEqual({
+        confirmed: BigInt(4000000000),
+        unconfirmed: BigInt(6000000000),
+      })
+
+      expect(await node.accounts.getBalanceBuckets(account, [0, 1, 2, 3])).toEqual([
+        { confirmations: 0, balance: BigInt(6000000000) },
+        { confirmations: 1, balance: BigInt(4000000000) },
+        { confirmations: 2, balance: BigInt(2000000000) },
+        { confirmations: 3, balance: BigInt(0) },
+      ])
+    })
+
+    it('breaks the balance down into available and immature notes', async () => {
+      const { node } = await nodeTest.createSetup()
+      const account = await useAccountFixture(node.accounts, 'account')
+
+      // G -> A1 -> A2 -> A3
+      for (let sequence = 2; sequence <= 4; sequence++) {
+        const block = await useMinerBlockFixture(node.chain, sequence, account)
+        await expect(node.chain).toAddBlock(block)
+      }
+      await node.accounts.updateHead()
+
+      const breakdown = await node.accounts.getBalanceBreakdown(account, {
+        minimumBlockConfirmations: 2,
+      })
+
+      expect(breakdown).toEqual([
+        {
+          asset: '$IRON',
+          available: BigInt(2000000000),
+          immature: BigInt(4000000000),
+          unconfirmed: BigInt(0),
+          pendingChange: BigInt(0),
+          locked: BigInt(0),
+        },
+      ])
+    })
+  })
+
+  describe('dust', () => {
+    it('tags notes worth less than the dust threshold', async () => {
+      const { node } = await nodeTest.createSetup({
+        config: { dustThreshold: 3000000000 },
+      })
+      const account = await useAccountFixture(node.accounts, 'account')
+
+      const block = await useMinerBlockFixture(node.chain, 2, account)
+      await expect(node.chain).toAddBlock(block)
+      await node.accounts.updateHead()
+
+      expect(node.accounts.isDust(BigInt(2000000000))).toBe(true)
+      expect(node.accounts.isDust(BigInt(3000000000))).toBe(false)
+      expect(node.accounts.isDust(BigInt(0))).toBe(false)
+
+      const { notes } = node.accounts.getNotes(account)
+      expect(notes).toHaveLength(1)
+      expect(notes[0]).toMatchObject({ amount: 2000000000, dust: true })
+
+      // Miner's fees are never dust
+      const transactions = await node.accounts.getTransactions(account)
+      expect(transactions).toHaveLength(1)
+      expect(transactions[0]).toMatchObject({ isMinersFee: true, dust: false })
+    })
+
+    it('adds dust change to the fee', async () => {
+      const { node } = await nodeTest.createSetup({ config: { dustThreshold: 10 } })
+      const sender = await useAccountFixture(node.accounts, 'sender')
+      const feePayer = await useAccountFixture(node.accounts, 'feePayer')
+      const recipient = await useAccountFixture(node.accounts, 'recipient')
+
+      await expect(node.chain).toAddBlock(await useMinerBlockFixture(node.chain, 2, sender))
+      await expect(node.chain).toAddBlock(await useMinerBlockFixture(node.chain, 3, feePayer))
+      await node.accounts.updateHead()
+
+      // Only the fee the transaction is built with matters, so skip proving it
+      const createSpy = jest
+        .spyOn(node.workerPool, 'createTransaction')
+        .mockRejectedValue(new Error('proving skipped'))
+
+      const receives = [
+        { publicAddress: recipient.publicAddress, amount: BigInt(1999999995), memo: '' },
+      ]
+      const options = { minimumBlockConfirmations: 0 }
+
+      // The sender's change of 4 is dust
+      await expect(
+        node.accounts.createTransaction(sender, receives, BigInt(1), 0, options),
+      ).rejects.toThrow('proving skipped')
+      expect(createSpy.mock.calls[0][1]).toEqual(BigInt(5))
+      expect(createSpy.mock.calls[0][3]).toHaveLength(1)
+
+      // The sender's change of 5 and the fee payer's change of 3 are dust, and
+      // the notes filter only applies to the sender
+      const [note] = await node.accounts['getUnspentNotes'](sender, 0)
+      await expect(
+        node.accounts.createTransaction(sender, receives, BigInt(1999999997), 0, {
+          ...options,
+          feePayer,
+          notes: [note.hash],
+        }),
+      ).rejects.toThrow('proving skipped')
+      expect(createSpy.mock.calls[1][1]).toEqual(BigInt(2000000005))
+      expect(createSpy.mock.calls[1][2]).toHaveLength(2)
+      expect(createSpy.mock.calls[1][3]).toHaveLength(1)
+    })
+  })
+
+  describe('createTransaction', () => {
+    const setup = async () => {
+      const { node } = nodeTest
+      const sender = await useAccountFixture(node.accounts, 'reserveSender')
+      const recipient = await useAccountFixture(node.accounts, 'reserveRecipient')
+
+      await expect(node.chain).toAddBlock(await useMinerBlockFixture(node.chain, 2, sender))
+      await node.accounts.updateHead()
+
+      // Only the reserved notes matter, so skip proving the transactions
+      let created = 0
+      jest.spyOn(node.workerPool, 'createTransaction').mockImplementation(() => {
+        const hash = Buffer.from(`transaction${created++}`)
+        return Promise.resolve({
+          unsignedHash: () => hash,
+          spendsLength: () => 1,
+          notesLength: () => 1,
+        } as unknown as Transaction)
+      })
+
+      // Spends the miner's fee note
+      const create = () =>
+        node.accounts.createTransaction(
+          sender,
+          [{ publicAddress: recipient.publicAddress, amount: BigInt(1999999999), memo: '' }],
+          BigInt(1),
+          0,
+          { minimumBlockConfirmations: 0 },
+        )
+
+      return { node, sender, create }
+    }
+
+    it('keeps the notes reserved until the transaction is released', async () => {
+      const { node, sender, create } = await setup()
+
+      const [first, second] = await Promise.allSettled([create(), create()])
+      Assert.isTrue(first.status === 'fulfilled')
+      expect(second).toMatchObject({ status: 'rejected', reason: new NotEnoughFundsError() })
+
+      await expect(
+        node.accounts.getHeldBalance(sender, { minimumBlockConfirmations: 0 }),
+      ).resolves.toEqual(BigInt(2000000000))
+      await expect(create()).rejects.toThrowError(NotEnoughFundsError)
+
+      node.accounts.releaseTransaction(first.value)
+
+      await expect(
+        node.accounts.getHeldBalance(sender, { minimumBlockConfirmations: 0 }),
+      ).resolves.toEqual(BigInt(0))
+      await expect(create()).resolves.toBeTruthy()
+    })
+
+    it('releases the notes when the reservation expires', async () => {
+      const { create } = await setup()
+
+      await create()
+      await expect(create()).rejects.toThrowError(NotEnoughFundsError)
+
+      const now = Date.now()
+      jest.spyOn(Date, 'now').mockReturnValue(now + 10 * 60 * 1000)
+
+      await expect(create()).resolves.toBeTruthy()
+    })
+  })
+
+  describe('aliases', () => {
+    it('resolves accounts by name or alias', async () => {
+      const { node } = nodeTest
+      const account = await useAccountFixture(node.accounts, 'aliasA')
+      const other = await useAccountFixture(node.accounts, 'aliasB')
+
+      await node.accounts.setAlias('savings', account)
+      expect(node.accounts.resolveAccount('savings')).toBe(account)
+      expect(node.accounts.resolveAccount('aliasA')).toBe(account)
+      expect(node.accounts.getAliases(other)).toEqual([])
+
+      await node.accounts.setAlias('savings', other)
+      expect(node.accounts.resolveAccount('savings')).toBe(other)
+
+      await expect(node.accounts.setAlias('aliasA', other)).rejects.toThrow(
+        'There is already an account with the name aliasA',
+      )
+
+      await node.accounts.removeAccount(other.name)
+      expect(node.accounts.resolveAccount('savings')).toBeNull()
+      expect(node.accounts.getAliases()).toEqual([])
+    })
+  })
+
+  describe('scanning', () => {
+    it('skips accounts with scanning disabled until it is enabled again', async () => {
+      const { node } = await nodeTest.createSetup()
+      const account = await useAccountFixture(node.accounts, 'scanningA')
+
+      await node.accounts.setScanningEnabled(account, false)
+      expect(node.accounts.isScanningEnabled(account)).toBe(false)
+      expect(node.accounts.getScanningDisabledSequence(account)).toBe(GENESIS_BLOCK_SEQUENCE)
+
+      const block = await useMinerBlockFixture(node.chain, 2, account)
+      await expect(node.chain).toAddBlock(block)
+      await node.accounts.updateHead()
+
+      expect(node.accounts.getNotes(account).notes).toHaveLength(0)
+
+      await node.accounts.setScanningEnabled(account, true)
+      expect(node.accounts.isScanningEnabled(account)).toBe(true)
+      expect(node.accounts.getScanningDisabledSequence(account)).toBeNull()
+      expect(account.rescan).not.toBeNull()
+
+      await node.accounts.scanTransactions()
+      expect(node.accounts.getNotes(account).notes).toHaveLength(1)
+    })
+
+    it('emits onScanLag once while an account is too far behind the chain head', async () => {
+      const { node } = await nodeTest.createSetup({ config: { walletScanLagWarning: 1 } })
+      const account = await useAccountFixture(node.accounts, 'scanningB')
+      const onScanLag = jest.fn()
+      node.accounts.onScanLag.on(onScanLag)
+
+      const block = await useMinerBlockFixture(node.chain, 2, account)
+      await expect(node.chain).toAddBlock(block)
+
+      expect(node.accounts.getScanLag(account)).toBe(2)
+      node.accounts.checkScanLag()
+      node.accounts.checkScanLag()
+      expect(onScanLag).toHaveBeenCalledTimes(1)
+      expect(onScanLag).toHaveBeenCalledWith(account, 2)
+
+      await node.accounts.updateHead()
+      expect(node.accounts.getScannedSequence(account)).toBe(2)
+      expect(node.accounts.getScanLag(account)).toBe(0)
+      node.accounts.checkScanLag()
+      expect(onScanLag).toHaveBeenCalledTimes(1)
+    })
+  })
+
+  describe('updateHead', () => {
+    it('leaves the maps unchanged when syncing a block is aborted', async () => {
+      const { node } = await nodeTest.createSetup()
+      const account = await useAccountFixture(node.accounts, 'abortA')
+      await node.accounts.updateHead()
+
+      const block = await useMinerBlockFixture(node.chain, 2, account)
+      await expect(node.chain).toAddBlock(block)
+
+      // Fail the block's transaction after its notes have been written to it
+      const setHeadHash = node.accounts.db.setHeadHash.bind(node.accounts.db)
+      const setHeadHashSpy = jest
+        .spyOn(node.accounts.db, 'setHeadHash')
+        .mockImplementation((hash, tx) =>
+          hash === block.header.hash.toString('hex')
+            ? Promise.reject(new Error('abort'))
+            : setHeadHash(hash, tx),
+        )
+
+      await expect(node.accounts.updateHead()).rejects.toThrowError('abort')
+
+      expect(node.accounts.headSequence).toBe(1)
+      expect(node.accounts.getNotes(account).notes).toHaveLength(0)
+      expect(node.accounts['transactionMap'].size).toBe(0)
+      expect(node.accounts['nullifierToNote'].size).toBe(0)
+      expect(await node.accounts.db.noteToNullifier.getAllKeys()).toHaveLength(0)
+
+      setHeadHashSpy.mockRestore()
+      await node.accounts.updateHead()
+
+      expect(node.accounts.headSequence).toBe(2)
+      expect(node.accounts.getNotes(account).notes).toHaveLength(1)
+    })
+  })
+
+  describe('rebuild', () => {
+    it('derives the same notes and balance again from the events', async () => {
+      const { node } = await nodeTest.createSetup()
+      const account = await useAccountFixture(node.accounts, 'rebuildA')
+
+      const block = await useMinerBlockFixture(node.chain, 2, account)
+      await expect(node.chain).toAddBlock(block)
+      await node.accounts.updateHead()
+
+      const notes = node.accounts.getNotes(account).notes
+      const balance = await node.accounts.getBalance(account)
+      expect(notes).toHaveLength(1)
+
+      // Lose the derived state like a corrupted database would
+      await node.accounts.db.noteToNullifier.clear()
+      node.accounts['noteToNullifier'].clear()
+      expect(node.accounts.getNotes(account).notes).toHaveLength(0)
+
+      const result = await node.accounts.rebuild()
+
+      expect(result.events).toBeGreaterThan(0)
+      expect(result).toMatchObject({ transactions: 1, notes: 1 })
+      expect(node.accounts.getNotes(account).notes).toEqual(notes)
+      expect(await node.accounts.getBalance(account)).toEqual(balance)
+      expect(await node.accounts.db.noteToNullifier.getAllKeys()).toHaveLength(1)
+    })
+
+    it('starts the events from the existing state of older wallets', async () => {
+      const { node } = await nodeTest.createSetup()
+      const account = await useAccountFixture(node.accounts, 'rebuildB')
+
+      const block = await useMinerBlockFixture(node.chain, 2, account)
+      await expect(node.chain).toAddBlock(block)
+      await node.accounts.updateHead()
+
+      await node.accounts.db.clearEvents()
+      await node.accounts.loadTransactionsFromDb()
+
+      const result = await node.accounts.rebuild()
+
+      expect(result).toEqual({ events: 1, transactions: 1, notes: 1 })
+      expect(node.accounts.getNotes(account).notes).toHaveLength(1)
+    })
+
+    it('reads the transactions in blocks from the chain', async () => {
+      const { node } = await nodeTest.createSetup()
+      const account = await useAccountFixture(node.accounts, 'rebuildC')
+
+      const block = await useMinerBlockFixture(node.chain, 2, account)
+      await expect(node.chain).toAddBlock(block)
+      await node.accounts.updateHead()
+
+      const events = []
+      for await (const [, event] of node.accounts.db.loadEvents()) {
+        events.push(event)
+      }
+
+      expect(events).toEqual([
+        {
+          type: WalletEventType.SYNC,
+          hash: block.transactions[0].unsignedHash(),
+          blockHash: block.header.hash.toString('hex'),
+          submittedSequence: null,
+          transaction: null,
+          initialNoteIndex: expect.any(Number),
+        },
+      ])
+    })
+
+    it('replaces the events with a snapshot that rebuilds the same state', async () => {
+      const { node } = await nodeTest.createSetup()
+      const account = await useAccountFixture(node.accounts, 'rebuildD')
+
+      const blockA = await useMinerBlockFixture(node.chain, 2, account)
+      await expect(node.chain).toAddBlock(blockA)
+      await node.accounts.updateHead()
+
+      const blockB = await useMinerBlockFixture(node.chain, 3, account)
+      await expect(node.chain).toAddBlock(blockB)
+      await node.accounts.updateHead()
+
+      const notes = node.accounts.getNotes(account).notes
+      expect(notes).toHaveLength(2)
+
+      await node.accounts['addSnapshotEvent']()
+
+      const events = await node.accounts.db.events.getAllValues()
+      expect(events).toHaveLength(1)
+      expect(events[0].type).toBe(WalletEventType.IMPORT)
+
+      const result = await node.accounts.rebuild()
+
+      expect(result).toEqual({ events: 1, transactions: 2, notes: 2 })
+      expect(node.accounts.getNotes(account).notes).toEqual(notes)
+    })
+  })
+
+  describe('exportState', () => {
+    it('should import the state on another node and scan the blocks after it', async () => {
+      const { node: nodeA } = await nodeTest.createSetup()
+      const { node: nodeB } = await nodeTest.createSetup()
+
+      const accountA = await useAccountFixture(nodeA.accounts, 'a')
+
+      const blockA1 = await useMinerBlockFixture(
+        nodeA.chain,
+        undefined,
+        accountA,
+        nodeA.accounts,
+      )
+      await expect(nodeA.chain).toAddBlock(blockA1)
+      await nodeA.accounts.updateHead()
+
+      const state = await nodeA.accounts.exportState(accountA)
+      expect(state.head).toEqual({
+        hash: blockA1.header.hash.toString('hex'),
+        sequence: blockA1.header.sequence,
+      })
+      expect(state.transactions).toHaveLength(1)
+      expect(state.notes).toHaveLength(1)
+
+      const blockA2 = await useMinerBlockFixture(
+        nodeA.chain,
+        undefined,
+        accountA,
+        nodeA.accounts,
+      )
+      await expect(nodeA.chain).toAddBlock(blockA2)
+
+      await expect(nodeB.chain).toAddBlock(blockA1)
+      await expect(nodeB.chain).toAddBlock(blockA2)
+      await nodeB.accounts.updateHead()
+
+      const { account, result } = await nodeB.accounts.importState(state)
+      expect(result).toEqual({ transactions: 1, notes: 1, scanned: 1, rescan: false })
+      expect(account.rescan).toBeNull()
+
+      await expect(nodeB.accounts.getBalance(account)).resolves.toMatchObject({
+        unconfirmed: BigInt(4000000000),
+      })
+    })
+
+    it('should rescan if the exported head is not on the chain', async () => {
+      const { node: nodeA } = await nodeTest.createSetup()
+      const { node: nodeB } = await nodeTest.createSetup()
+
+      const accountA = await useAccountFixture(nodeA.accounts, 'a')
+      const blockA1 = await useMinerBlockFixture(
+        nodeA.chain,
+        undefined,
+        accountA,
+        nodeA.accounts,
+      )
+      await expect(nodeA.chain).toAddBlock(blockA1)
+      await nodeA.accounts.updateHead()
+
+      const state = await nodeA.accounts.exportState(accountA)
+
+      const scanSpy = jest.spyOn(nodeB.accounts, 'startScanTransactionsFor').mockResolvedValue()
+      const { result } = await nodeB.accounts.importState(state)
+
+      expect(result.rescan).toBe(true)
+      expect(scanSpy).toHaveBeenCalledTimes(1)
     })
   })
 
@@ -11,10 +11,12 @@ import { Config } from '../fileStores'
 import { createRootLogger, Logger } from '../logger'
 import { MemPool } from '../memPool'
 import { NoteWitness } from '../merkletree/witness'
+import { Tracer } from '../metrics'
+import { RollingAverage } from '../metrics/rollingAverage'
 import { Mutex } from '../mutex'
 import { Note } from '../primitives/note'
-import { Transaction } from '../primitives/transaction'
-import { ValidationError } from '../rpc/adapters/errors'
+import { getTransactionSize, Transaction } from '../primitives/transaction'
+import { ERROR_CODES, ValidationError } from '../rpc/adapters/errors'
 import { IDatabaseTransaction } from '../storage'
 import { PromiseResolve, PromiseUtils, SetTimeoutToken } from '../utils'
 import { WorkerPool } from '../workerPool'
@@ -23,7 +25,69 @@ import { UnspentNote } from '../workerPool/tasks/getUnspentNotes'
 import { Account } from './account'
 import { AccountDefaults, AccountsDB } from './accountsdb'
 import { AccountsValue } from './database/accounts'
+import {
+  WalletEvent,
+  WalletEventTransaction,
+  WalletEventType,
+  WalletImportedNote,
+} from './database/events'
+import { createDisclosure, PaymentDisclosure } from './disclosure'
+import { NotEnoughFundsError } from './errors'
+import { pickNotes } from './noteSelection'
+import { createReceipt, getReceiptNotes, RECEIPT_ASSET, TransactionReceipt } from './receipt'
+import { AccountSummary, SummaryTransaction, summarizeTransactions } from './summary'
 import { validateAccount } from './validator'
+import { WALLET_STATE_VERSION, WalletState, WalletStateImport } from './walletState'
+
+/**
+ * The confirmation depths balances are reported at, so callers can pick how
+ * much reorg risk they accept without asking again
+ */
+export const BALANCE_CONFIRMATION_BUCKETS = [1, 6, 12]
+
+/**
+ * Where the value of an account's notes is, for one asset. Only `available`
+ * can be spent, and `available` plus `locked` is the confirmed balance.
+ */
+export type BalanceBreakdown = {
+  asset: string
+  // Confirmed notes that are not held by a transaction
+  available: bigint
+  // Notes on the main chain with fewer than the minimum confirmations
+  immature: bigint
+  // Notes received in transactions that are in the mempool or on a fork
+  unconfirmed: bigint
+  // Notes the account pays back to itself in its own transactions that are
+  // in the mempool or on a fork
+  pendingChange: bigint
+  // Confirmed notes spent by transactions that have not been mined, or being
+  // spent by transactions that are still being created
+  locked: bigint
+}
+
+export type CreateTransactionOptions = {
+  minimumBlockConfirmations?: number
+  // Another account that spends its notes to pay the fee
+  feePayer?: Account
+  // Only spend notes with these hashes
+  notes?: string[]
+  // Called as each proof of the transaction is built
+  onProgress?: (done: number, total: number) => void
+}
+
+// How long each spend or note proof is expected to take to build until this
+// node has built a transaction, in ms
+const DEFAULT_PROOF_TIME_MS = 1000
+
+// How long a reorg counts as a sign the chain is unstable
+const RECENT_REORG_MS = 24 * 60 * 60 * 1000
+
+// How long the notes of a transaction from createTransaction stay reserved
+// if it is never synced or released, in ms
+const TRANSACTION_RESERVATION_MS = 10 * 60 * 1000
+
+// How many events are added before they are compacted into a snapshot
+const SNAPSHOT_EVENTS = 10000
 
 type SyncTransactionParams =
   // Used when receiving a transaction from a block with notes
@@ -33,6 +97,33 @@ type SyncTransactionParams =
   | { submittedSequence: number }
   | Record<string, never>
 
+type TransactionMapValue = Readonly<{
+  transaction: Transaction
+  blockHash: string | null
+  submittedSequence: number | null
+}>
+
+type NoteToNullifierValue = Readonly<{
+  nullifierHash: string | null
+  noteIndex: number | null
+  spent: boolean
+}>
+
+type SyncedNote = {
+  merkleHash: string
+  nullifier: string | null
+  noteIndex: number | null
+  forSpender: boolean
+}
+
+// Changes made to the transaction and note maps in a database transaction,
+// with null for deleted keys. They are applied once the transaction commits
+type PendingMapChanges = {
+  transactions: BufferMap<TransactionMapValue | null>
+  noteToNullifier: Map<string, NoteToNullifierValue | null>
+  nullifierToNote: Map<string, string | null>
+}
+
 export class Accounts {
   readonly onDefaultAccountChange = new Event<
     [account: Account | null, oldAccount: Account | null]
@@ -41,38 +132,59 @@ export class Accounts {
   readonly onAccountImported = new Event<[account: Account]>()
   readonly onAccountRemoved = new Event<[account: Account]>()
   readonly onBroadcastTransaction = new Event<[transaction: Transaction]>()
+  readonly onScanLag = new Event<[account: Account, behind: number]>()
 
   scan: ScanState | null = null
   updateHeadState: ScanState | null = null
+  // The sequence of the block at the accounts head, once it's known
+  headSequence: number | null = null
 
-  protected readonly transactionMap = new BufferMap<
-    Readonly<{
-      transaction: Transaction
-      blockHash: string | null
-      submittedSequence: number | null
-    }>
-  >()
-  protected readonly noteToNullifier = new Map<
-    string,
-    Readonly<{ nullifierHash: string | null; noteIndex: number | null; spent: boolean }>
-  >()
+  protected readonly transactionMap = new BufferMap<TransactionMapValue>()
+  protected readonly noteToNullifier = new Map<string, NoteToNullifierValue>()
   protected readonly nullifierToNote = new Map<string, string>()
+  protected readonly pendingMaps = new WeakMap<IDatabaseTransaction, PendingMapChanges>()
+  // The id the next wallet event is added with
+  protected nextEventId = 0
+  // The id of the oldest event that wasn't compacted into a snapshot yet
+  protected firstEventId = 0
+  protected rebuilding = false
+
+  // Notes picked for transactions that are still being created. They are not
+  // marked as spent until the transaction is synced, so they are held here to
+  // keep another transaction from spending them first
+  protected readonly reservedNotes = new Set<string>()
+  // Reservations of transactions from createTransaction, by unsigned hash,
+  // which are held until the transaction is synced, released or expires
+  protected readonly transactionReservations = new BufferMap<{
+    release: () => void
+    expiresAt: number
+  }>()
 
   protected readonly accounts = new Map<string, Account>()
   readonly db: AccountsDB
   readonly logger: Logger
   readonly workerPool: WorkerPool
   readonly chain: Blockchain
+  readonly tracer: Tracer
   private readonly config: Config
 
   protected rebroadcastAfter: number
   protected defaultAccount: string | null = null
+  protected aliases = new Map<string, string>()
+  // Accounts not scanned for, to the sequence their scanning was disabled at
+  protected scanningDisabled = new Map<string, number>()
+  // Accounts that onScanLag was emitted for, until they catch up again
+  protected lagging = new Set<string>()
   protected chainProcessor: ChainProcessor
   protected isStarted = false
   protected isOpen = false
   protected eventLoopTimeout: SetTimeoutToken | null = null
+  protected recentReorgs: { depth: number; timestamp: number }[] = []
   private readonly createTransactionMutex: Mutex
 
+  // How long recent transactions took to build for each proof, in ms
+  readonly proofTime = new RollingAverage(20)
+
   constructor({
     chain,
     config,
@@ -80,6 +192,7 @@ export class Accounts {
     logger = createRootLogger(),
     rebroadcastAfter,
     workerPool,
+    tracer,
   }: {
     chain: Blockchain
     config: Config
@@ -87,12 +200,14 @@ export class Accounts {
     logger?: Logger
     rebroadcastAfter?: number
     workerPool: WorkerPool
+    tracer?: Tracer
   }) {
     this.chain = chain
     this.config = config
     this.logger = logger.withTag('accounts')
     this.db = database
     this.workerPool = workerPool
+    this.tracer = tracer || new Tracer({ logger: this.logger })
     this.rebroadcastAfter = rebroadcastAfter ?? 10
     this.createTransactionMutex = new Mutex()
 
@@ -102,36 +217,106 @@ export class Accounts {
       head: null,
     })
 
+    // Each block is synced in one transaction with the head hash so a crash can never
+    // leave the wallet with part of a block or a head that doesn't match its notes. The
+    // notes are decrypted first so the database isn't locked while the workers run
     this.chainProcessor.onAdd.on(async (header) => {
       this.logger.debug(`AccountHead ADD: ${Number(header.sequence) - 1} => ${header.sequence}`)
 
+      const synced = []
       for await (const {
         transaction,
         blockHash,
         initialNoteIndex,
       } of this.chain.iterateBlockTransactions(header)) {
-        await this.syncTransaction(transaction, {
+        const notes = await this.decryptSyncedNotes(transaction, initialNoteIndex)
+        synced.push({
+          transaction,
           blockHash: blockHash.toString('hex'),
-          initialNoteIndex: initialNoteIndex,
+          initialNoteIndex,
+          notes,
         })
       }
 
-      await this.updateHeadHash(header.hash)
+      await this.mapTransaction(async (tx) => {
+        for (const { transaction, blockHash, initialNoteIndex, notes } of synced) {
+          await this.addSyncEvent(transaction, blockHash, null, initialNoteIndex, notes, tx)
+        }
+
+        await this.updateHeadHash(header.hash, tx)
+      })
+
+      for (const { transaction } of synced) {
+        this.releaseTransaction(transaction)
+      }
+
+      this.headSequence = header.sequence
     })
 
     this.chainProcessor.onRemove.on(async (header) => {
       this.logger.debug(`AccountHead DEL: ${header.sequence} => ${Number(header.sequence) - 1}`)
 
+      const synced = []
       for await (const { transaction } of this.chain.iterateBlockTransactions(header)) {
-        await this.syncTransaction(transaction, {})
+        const notes = await this.decryptSyncedNotes(transaction, null)
+        synced.push({ transaction, notes })
       }
 
-      await this.updateHeadHash(header.previousBlockHash)
+      await this.mapTransaction(async (tx) => {
+        for (const { transaction, notes } of synced) {
+          await this.addSyncEvent(transaction, null, null, null, notes, tx)
+        }
+
+        await this.updateHeadHash(header.previousBlockHash, tx)
+      })
+
+      this.headSequence = header.sequence - 1
+    })
+
+    this.chain.onReorganize.on((oldHead, _newHead, fork) => {
+      this.onReorganize(oldHead.sequence - fork.sequence)
     })
   }
 
+  private onReorganize(depth: number): void {
+    const now = Date.now()
+    this.recentReorgs = this.recentReorgs.filter((r) => r.timestamp > now - RECENT_REORG_MS)
+    this.recentReorgs.push({ depth, timestamp: now })
+
+    const minimumBlockConfirmations = this.config.get('minimumBlockConfirmations')
+    if (depth >= minimumBlockConfirmations) {
+      this.logger.warn(
+        `A reorg removed ${depth} blocks, payments with minimumBlockConfirmations` +
+          ` (${minimumBlockConfirmations}) could have been reversed. Consider raising it.`,
+      )
+    }
+  }
+
+  /**
+   * Payments with `minimumBlockConfirmations` are only safe from reorgs that
+   * remove fewer blocks than that, so warn when the chain has recently had a
+   * reorg at least that deep
+   */
+  getConfirmationWarning(
+    minimumBlockConfirmations = this.config.get('minimumBlockConfirmations'),
+  ): string | null {
+    const since = Date.now() - RECENT_REORG_MS
+    const deepest = this.recentReorgs
+      .filter((r) => r.timestamp > since)
+      .reduce((max, r) => Math.max(max, r.depth), 0)
+
+    if (deepest === 0 || deepest < minimumBlockConfirmations) {
+      return null
+    }
+
+    return (
+      `A reorg removed ${deepest} blocks in the last day, balances with` +
+      ` ${minimumBlockConfirmations} confirmations could still be reversed`
+    )
+  }
+
   async updateHead(): Promise<void> {
-    if (this.scan || this.updateHeadState) {
+    if (this.scan || this.updateHeadState || this.rebuilding) {
       return
     }
 
@@ -190,6 +375,8 @@ export class Accounts {
 
     const meta = await this.db.loadAccountsMeta()
     this.defaultAccount = meta.defaultAccountName
+    this.aliases = await this.db.loadAliases()
+    this.scanningDisabled = await this.db.loadScanningDisabled()
     this.chainProcessor.hash = meta.headHash ? Buffer.from(meta.headHash, 'hex') : null
 
     await this.loadTransactionsFromDb()
@@ -211,9 +398,10 @@ export class Accounts {
     this.isStarted = true
 
     if (this.chainProcessor.hash) {
-      const hasHeadBlock = await this.chain.hasBlock(this.chainProcessor.hash)
+      const head = await this.chain.getHeader(this.chainProcessor.hash)
+      this.headSequence = head?.sequence ?? null
 
-      if (!hasHeadBlock) {
+      if (!head) {
         this.logger.error(
           `Resetting accounts database because accounts head was not found in chain: ${this.chainProcessor.hash.toString(
             'hex',
@@ -255,10 +443,14 @@ export class Accounts {
 
     await this.updateHead()
 
+    this.checkScanLag()
+
     await this.expireTransactions()
 
     await this.rebroadcastTransactions()
 
+    await this.compactEvents()
+
     if (this.isStarted) {
       this.eventLoopTimeout = setTimeout(() => void this.eventLoop(), 1000)
     }
@@ -268,6 +460,48 @@ export class Accounts {
     await this.db.loadNullifierToNoteMap(this.nullifierToNote)
     await this.db.loadNoteToNullifierMap(this.noteToNullifier)
     await this.db.loadTransactionsIntoMap(this.transactionMap)
+
+    const lastEventId = await this.db.getLastEventId()
+    this.nextEventId = lastEventId === null ? 0 : lastEventId + 1
+    this.firstEventId = (await this.db.getFirstEventId()) ?? this.nextEventId
+
+    // Wallets from before the events were added start them from what they have
+    if (lastEventId === null && this.transactionMap.size > 0) {
+      await this.addSnapshotEvent()
+    }
+  }
+
+  /**
+   * Add an event that imports everything the wallet has now, so replaying the
+   * events gives the same state, and delete the events before it
+   */
+  private async addSnapshotEvent(): Promise<void> {
+    await this.mapTransaction(async (tx) => {
+      // The maps are only partly rebuilt during a rebuild
+      if (this.rebuilding) {
+        return
+      }
+
+      const transactions = Array.from(this.transactionMap.values()).map((value) =>
+        this.toEventTransaction(value),
+      )
+
+      const notes = Array.from(this.noteToNullifier).map(([merkleHash, nullifier]) => ({
+        merkleHash,
+        ...nullifier,
+      }))
+
+      const eventId = this.nextEventId++
+      await this.db.addEvent(eventId, { type: WalletEventType.IMPORT, transactions, notes }, tx)
+      await this.db.deleteEventsBefore(eventId, tx)
+      this.firstEventId = eventId
+    })
+  }
+
+  private async compactEvents(): Promise<void> {
+    if (!this.rebuilding && this.nextEventId - this.firstEventId >= SNAPSHOT_EVENTS) {
+      await this.addSnapshotEvent()
+    }
   }
 
   async saveTransactionsToDb(): Promise<void> {
@@ -278,18 +512,22 @@ export class Accounts {
 
   async updateTransactionMap(
     transactionHash: Buffer,
-    transaction: Readonly<{
-      transaction: Transaction
-      blockHash: string | null
-      submittedSequence: number | null
-    }> | null,
+    transaction: TransactionMapValue | null,
     tx?: IDatabaseTransaction,
   ): Promise<void> {
-    if (transaction === null) {
+    const pending = tx && this.pendingMaps.get(tx)
+
+    if (pending) {
+      pending.transactions.set(transactionHash, transaction)
+    } else if (transaction === null) {
       this.transactionMap.delete(transactionHash)
-      await this.db.removeTransaction(transactionHash, tx)
     } else {
       this.transactionMap.set(transactionHash, transaction)
+    }
+
+    if (transaction === null) {
+      await this.db.removeTransaction(transactionHash, tx)
+    } else {
       await this.db.saveTransaction(transactionHash, transaction, tx)
     }
   }
@@ -299,36 +537,48 @@ export class Accounts {
     note: string | null,
     tx?: IDatabaseTransaction,
   ): Promise<void> {
-    if (note === null) {
+    const pending = tx && this.pendingMaps.get(tx)
+
+    if (pending) {
+      pending.nullifierToNote.set(nullifier, note)
+    } else if (note === null) {
       this.nullifierToNote.delete(nullifier)
-      await this.db.removeNullifierToNote(nullifier, tx)
     } else {
       this.nullifierToNote.set(nullifier, note)
+    }
+
+    if (note === null) {
+      await this.db.removeNullifierToNote(nullifier, tx)
+    } else {
       await this.db.saveNullifierToNote(nullifier, note, tx)
     }
   }
 
   async updateNoteToNullifierMap(
     noteHash: string,
-    note: Readonly<{
-      nullifierHash: string | null
-      noteIndex: number | null
-      spent: boolean
-    }> | null,
+    note: NoteToNullifierValue | null,
     tx?: IDatabaseTransaction,
   ): Promise<void> {
-    if (note === null) {
+    const pending = tx && this.pendingMaps.get(tx)
+
+    if (pending) {
+      pending.noteToNullifier.set(noteHash, note)
+    } else if (note === null) {
       this.noteToNullifier.delete(noteHash)
-      await this.db.removeNoteToNullifier(noteHash, tx)
     } else {
       this.noteToNullifier.set(noteHash, note)
+    }
+
+    if (note === null) {
+      await this.db.removeNoteToNullifier(noteHash, tx)
+    } else {
       await this.db.saveNoteToNullifier(noteHash, note, tx)
     }
   }
 
-  async updateHeadHash(headHash: Buffer | null): Promise<void> {
+  async updateHeadHash(headHash: Buffer | null, tx?: IDatabaseTransaction): Promise<void> {
     const hashString = headHash && headHash.toString('hex')
-    await this.db.setHeadHash(hashString)
+    await this.db.setHeadHash(hashString, tx)
   }
 
   async reset(): Promise<void> {
@@ -336,6 +586,10 @@ export class Accounts {
     this.noteToNullifier.clear()
     this.nullifierToNote.clear()
     this.chainProcessor.hash = null
+    this.headSequence = null
+    this.nextEventId = 0
+    this.firstEventId = 0
+    await this.db.clearEvents()
     await this.saveTransactionsToDb()
     await this.updateHeadHash(null)
   }
@@ -352,7 +606,7 @@ export class Accounts {
       account: Account
     }>
   > {
-    const accounts = this.listAccounts()
+    const accounts = this.listAccounts().filter((a) => this.isScanningEnabled(a))
     const decryptedNotes = new Array<{
       noteIndex: number | null
       nullifier: string | null
@@ -414,7 +668,11 @@ export class Accounts {
     }>
   > {
     const decryptedNotes = []
-    const response = await this.workerPool.decryptNotes(decryptNotesPayloads)
+    const response = await this.tracer.trace(
+      'wallet.decryptBatch',
+      () => this.workerPool.decryptNotes(decryptNotesPayloads),
+      { account: account.name, notes: decryptNotesPayloads.length },
+    )
 
     for (const decryptedNote of response) {
       if (decryptedNote) {
@@ -440,89 +698,205 @@ export class Accounts {
   async syncTransaction(
     transaction: Transaction,
     params: SyncTransactionParams,
+    tx?: IDatabaseTransaction,
   ): Promise<void> {
     const initialNoteIndex = 'initialNoteIndex' in params ? params.initialNoteIndex : null
     const blockHash = 'blockHash' in params ? params.blockHash : null
     const submittedSequence = 'submittedSequence' in params ? params.submittedSequence : null
 
-    let newSequence = submittedSequence
+    const notes = await this.decryptSyncedNotes(transaction, initialNoteIndex)
+
+    await this.mapTransaction(
+      (tx) =>
+        this.addSyncEvent(
+          transaction,
+          blockHash,
+          submittedSequence,
+          initialNoteIndex,
+          notes,
+          tx,
+        ),
+      tx,
+    )
 
-    await transaction.withReference(async () => {
-      const notes = await this.decryptNotes(transaction, initialNoteIndex)
-
-      return this.db.database.transaction(async (tx) => {
-        if (notes.length > 0) {
-          const transactionHash = transaction.unsignedHash()
-
-          const existingT = this.transactionMap.get(transactionHash)
-          // If we passed in a submittedSequence, set submittedSequence to that value.
-          // Otherwise, if we already have a submittedSequence, keep that value regardless of whether
-          // submittedSequence was passed in.
-          // Otherwise, we don't have an existing sequence or new sequence, so set submittedSequence null
-          newSequence = submittedSequence || existingT?.submittedSequence || null
-
-          // The transaction is useful if we want to display transaction history,
-          // but since we spent the note, we don't need to put it in the nullifierToNote mappings
-          await this.updateTransactionMap(
-            transactionHash,
-            {
-              transaction,
-              blockHash,
-              submittedSequence: newSequence,
-            },
-            tx,
-          )
-        }
+    // The notes are only marked as spent once the outer transaction commits
+    if (!tx) {
+      this.releaseTransaction(transaction)
+    }
+  }
 
-        for (const { noteIndex, nullifier, forSpender, merkleHash } of notes) {
-          // The transaction is useful if we want to display transaction history,
-          // but since we spent the note, we don't need to put it in the nullifierToNote mappings
-          if (!forSpender) {
-            if (nullifier !== null) {
-              await this.updateNullifierToNoteMap(nullifier, merkleHash, tx)
-            }
+  private async decryptSyncedNotes(
+    transaction: Transaction,
+    initialNoteIndex: number | null,
+  ): Promise<SyncedNote[]> {
+    return transaction.withReference(async () => {
+      const decrypted = await this.decryptNotes(transaction, initialNoteIndex)
+      return decrypted.map(({ merkleHash, nullifier, noteIndex, forSpender }) => ({
+        merkleHash,
+        nullifier,
+        noteIndex,
+        forSpender,
+      }))
+    })
+  }
 
-            await this.updateNoteToNullifierMap(
-              merkleHash,
-              {
-                nullifierHash: nullifier,
-                noteIndex: noteIndex,
-                spent: false,
-              },
-              tx,
-            )
-          }
-        }
+  /**
+   * Apply a synced transaction to the maps, and add its event if it changed them
+   */
+  private async addSyncEvent(
+    transaction: Transaction,
+    blockHash: string | null,
+    submittedSequence: number | null,
+    initialNoteIndex: number | null,
+    notes: SyncedNote[],
+    tx: IDatabaseTransaction,
+  ): Promise<void> {
+    const eventId = this.nextEventId++
+    const changed = await this.applySync(transaction, blockHash, submittedSequence, notes, tx)
+
+    if (changed) {
+      await this.db.addEvent(
+        eventId,
+        {
+          type: WalletEventType.SYNC,
+          ...this.toEventTransaction({ transaction, blockHash, submittedSequence }),
+          initialNoteIndex,
+        },
+        tx,
+      )
+    }
+  }
 
-        // If newSequence is null and blockHash is null, we're removing the transaction from
-        // the chain and it wasn't created by us, so unmark notes as spent
-        const isRemovingTransaction = newSequence === null && blockHash === null
+  private toEventTransaction(value: TransactionMapValue): WalletEventTransaction {
+    return {
+      hash: value.transaction.unsignedHash(),
+      blockHash: value.blockHash,
+      submittedSequence: value.submittedSequence,
+      transaction: value.blockHash === null ? value.transaction.serialize() : null,
+    }
+  }
 
-        for (const spend of transaction.spends()) {
-          const nullifier = spend.nullifier.toString('hex')
-          const noteHash = this.nullifierToNote.get(nullifier)
+  /**
+   * Get the transaction of an event, from the chain if it's in a block
+   *
+   * @returns null if its block is no longer in the chain
+   */
+  private async loadEventTransaction(
+    value: WalletEventTransaction,
+  ): Promise<Transaction | null> {
+    if (value.transaction !== null) {
+      return new Transaction(value.transaction)
+    }
 
-          if (noteHash) {
-            const nullifier = this.noteToNullifier.get(noteHash)
+    Assert.isNotNull(value.blockHash)
+    const block = await this.chain.getBlock(Buffer.from(value.blockHash, 'hex'))
+    const transaction = block?.transactions.find((t) => t.unsignedHash().equals(value.hash))
 
-            if (!nullifier) {
-              throw new Error(
-                'nullifierToNote mappings must have a corresponding noteToNullifier map',
-              )
-            }
+    if (!transaction) {
+      this.logger.warn(
+        `Skipping wallet event transaction ${value.hash.toString('hex')},` +
+          ` it is not in block ${value.blockHash} of the chain`,
+      )
+      return null
+    }
 
-            await this.updateNoteToNullifierMap(
-              noteHash,
-              {
-                ...nullifier,
-                spent: !isRemovingTransaction,
-              },
-              tx,
-            )
-          }
-        }
-      })
-    })
+    return transaction
+  }
+
+  /**
+   * Run `handler` in a database transaction, holding its changes to the
+   * transaction and note maps until its writes succeed so an aborted
+   * transaction can't leave the maps ahead of the database. The changes made
+   * in a `tx` that is passed in are applied the same way by whoever created it.
+   */
+  private async mapTransaction<TResult>(
+    handler: (tx: IDatabaseTransaction) => Promise<TResult>,
+    tx?: IDatabaseTransaction,
+  ): Promise<TResult> {
+    if (tx) {
+      return this.db.database.withTransaction(tx, handler)
+    }
+
+    tx = this.db.database.transaction()
+    const pending: PendingMapChanges = {
+      transactions: new BufferMap(),
+      noteToNullifier: new Map(),
+      nullifierToNote: new Map(),
+    }
+    this.pendingMaps.set(tx, pending)
+
+    try {
+      await tx.acquireLock()
+      const result = await handler(tx)
+      // Write the changes before applying them, but keep the lock until they
+      // are applied so the next transaction sees them
+      await tx.update()
+      this.applyPendingMaps(pending)
+      await tx.commit()
+      return result
+    } catch (error: unknown) {
+      await tx.abort()
+      throw error
+    } finally {
+      this.pendingMaps.delete(tx)
+    }
+  }
+
+  private applyPendingMaps(pending: PendingMapChanges): void {
+    for (const [hash, value] of pending.transactions) {
+      if (value === null) {
+        this.transactionMap.delete(hash)
+      } else {
+        this.transactionMap.set(hash, value)
+      }
+    }
+
+    for (const [noteHash, value] of pending.noteToNullifier) {
+      if (value === null) {
+        this.noteToNullifier.delete(noteHash)
+      } else {
+        this.noteToNullifier.set(noteHash, value)
+      }
+    }
+
+    for (const [nullifier, noteHash] of pending.nullifierToNote) {
+      if (noteHash === null) {
+        this.nullifierToNote.delete(nullifier)
+      } else {
+        this.nullifierToNote.set(nullifier, noteHash)
+      }
+    }
+  }
+
+  /**
+   * Get a map value as of the changes made in `tx`, which can be ahead of the maps
+   */
+  private getTransactionMapValue(
+    hash: Buffer,
+    tx: IDatabaseTransaction,
+  ): TransactionMapValue | undefined {
+    const pending = this.pendingMaps.get(tx)?.transactions
+    return pending?.has(hash) ? pending.get(hash) ?? undefined : this.transactionMap.get(hash)
+  }
+
+  private getNoteToNullifierValue(
+    noteHash: string,
+    tx: IDatabaseTransaction,
+  ): NoteToNullifierValue | undefined {
+    const pending = this.pendingMaps.get(tx)?.noteToNullifier
+    return pending?.has(noteHash)
+      ? pending.get(noteHash) ?? undefined
+      : this.noteToNullifier.get(noteHash)
+  }
+
+  private getNullifierToNoteValue(
+    nullifier: string,
+    tx: IDatabaseTransaction,
+  ): string | undefined {
+    const pending = this.pendingMaps.get(tx)?.nullifierToNote
+    return pending?.has(nullifier)
+      ? pending.get(nullifier) ?? undefined
+      : this.nullifierToNote.get(nullifier)
   }
 
   /**
@@ -530,48 +904,268 @@ export class Accounts {
    * the related maps.
    */
   async removeTransaction(transaction: Transaction): Promise<void> {
-    const transactionHash = transaction.unsignedHash()
+    await this.mapTransaction(async (tx) => {
+      const eventId = this.nextEventId++
+      await this.applyRemove(transaction, tx)
+      await this.db.addEvent(
+        eventId,
+        { type: WalletEventType.REMOVE, hash: transaction.unsignedHash() },
+        tx,
+      )
+    })
+  }
+
+  /**
+   * Derive the transactions and notes again by replaying the wallet's events,
+   * to repair them after a bug or corruption. Balances are computed from
+   * these, so they are rebuilt too.
+   */
+  async rebuild(): Promise<{ events: number; transactions: number; notes: number }> {
+    if (this.scan) {
+      throw new Error('Cannot rebuild the wallet while a rescan is running')
+    }
 
-    await this.db.database.transaction(async (tx) => {
-      await this.updateTransactionMap(transactionHash, null, tx)
+    if (this.rebuilding) {
+      throw new Error('The wallet is already being rebuilt')
+    }
 
-      for (const note of transaction.notes()) {
-        const merkleHash = note.merkleHash().toString('hex')
-        const noteToNullifier = this.noteToNullifier.get(merkleHash)
+    this.rebuilding = true
+
+    try {
+      // Setting this.rebuilding keeps the head from being updated again
+      await this.updateHeadState?.wait()
 
-        if (noteToNullifier) {
-          await this.updateNoteToNullifierMap(merkleHash, null, tx)
+      this.transactionMap.clear()
+      this.noteToNullifier.clear()
+      this.nullifierToNote.clear()
+      await this.saveTransactionsToDb()
+
+      let events = 0
+      for await (const [, event] of this.db.loadEvents()) {
+        await this.replayEvent(event)
+        events++
+      }
+
+      return {
+        events,
+        transactions: this.transactionMap.size,
+        notes: this.noteToNullifier.size,
+      }
+    } finally {
+      this.rebuilding = false
+    }
+  }
+
+  /**
+   * Apply an event again, decrypting the notes of its transactions again
+   */
+  private async replayEvent(event: WalletEvent): Promise<void> {
+    switch (event.type) {
+      case WalletEventType.SYNC: {
+        const transaction = await this.loadEventTransaction(event)
+        if (!transaction) {
+          return
+        }
 
-          if (noteToNullifier.nullifierHash) {
-            await this.updateNullifierToNoteMap(noteToNullifier.nullifierHash, null, tx)
+        const notes = await this.decryptSyncedNotes(transaction, event.initialNoteIndex)
+        await this.mapTransaction((tx) =>
+          this.applySync(transaction, event.blockHash, event.submittedSequence, notes, tx),
+        )
+        break
+      }
+      case WalletEventType.REMOVE: {
+        // The events before it were replayed, so the transaction is in the map
+        const value = this.transactionMap.get(event.hash)
+        if (value) {
+          await this.mapTransaction((tx) => this.applyRemove(value.transaction, tx))
+        }
+        break
+      }
+      case WalletEventType.IMPORT: {
+        const transactions = []
+        for (const value of event.transactions) {
+          const transaction = await this.loadEventTransaction(value)
+          if (transaction) {
+            transactions.push({
+              transaction,
+              blockHash: value.blockHash,
+              submittedSequence: value.submittedSequence,
+            })
           }
         }
+
+        await this.mapTransaction((tx) => this.applyImport(transactions, event.notes, tx))
+        break
       }
+    }
+  }
+
+  /**
+   * Update the transaction and note maps for a synced transaction
+   *
+   * @returns true if the transaction changed the maps
+   */
+  private async applySync(
+    transaction: Transaction,
+    blockHash: string | null,
+    submittedSequence: number | null,
+    notes: SyncedNote[],
+    tx: IDatabaseTransaction,
+  ): Promise<boolean> {
+    let changed = false
+    let newSequence = submittedSequence
 
-      for (const spend of transaction.spends()) {
-        const nullifierHash = spend.nullifier.toString('hex')
-        const noteHash = this.nullifierToNote.get(nullifierHash)
+    if (notes.length > 0) {
+      const transactionHash = transaction.unsignedHash()
+
+      const existingT = this.getTransactionMapValue(transactionHash, tx)
+      // If we passed in a submittedSequence, set submittedSequence to that value.
+      // Otherwise, if we already have a submittedSequence, keep that value regardless of whether
+      // submittedSequence was passed in.
+      // Otherwise, we don't have an existing sequence or new sequence, so set submittedSequence null
+      newSequence = submittedSequence || existingT?.submittedSequence || null
+
+      // The transaction is useful if we want to display transaction history,
+      // but since we spent the note, we don't need to put it in the nullifierToNote mappings
+      await this.updateTransactionMap(
+        transactionHash,
+        {
+          transaction,
+          blockHash,
+          submittedSequence: newSequence,
+        },
+        tx,
+      )
+      changed = true
+    }
 
-        if (noteHash) {
-          const nullifier = this.noteToNullifier.get(noteHash)
+    for (const { noteIndex, nullifier, forSpender, merkleHash } of notes) {
+      // The transaction is useful if we want to display transaction history,
+      // but since we spent the note, we don't need to put it in the nullifierToNote mappings
+      if (!forSpender) {
+        if (nullifier !== null) {
+          await this.updateNullifierToNoteMap(nullifier, merkleHash, tx)
+        }
 
-          if (!nullifier) {
-            throw new Error(
-              'nullifierToNote mappings must have a corresponding noteToNullifier map',
-            )
-          }
+        await this.updateNoteToNullifierMap(
+          merkleHash,
+          {
+            nullifierHash: nullifier,
+            noteIndex: noteIndex,
+            spent: false,
+          },
+          tx,
+        )
+      }
+    }
+
+    // If newSequence is null and blockHash is null, we're removing the transaction from
+    // the chain and it wasn't created by us, so unmark notes as spent
+    const isRemovingTransaction = newSequence === null && blockHash === null
+
+    for (const spend of transaction.spends()) {
+      const nullifier = spend.nullifier.toString('hex')
+      const noteHash = this.getNullifierToNoteValue(nullifier, tx)
 
-          await this.updateNoteToNullifierMap(
-            noteHash,
-            {
-              ...nullifier,
-              spent: false,
-            },
-            tx,
+      if (noteHash) {
+        const nullifier = this.getNoteToNullifierValue(noteHash, tx)
+
+        if (!nullifier) {
+          throw new Error(
+            'nullifierToNote mappings must have a corresponding noteToNullifier map',
           )
         }
+
+        await this.updateNoteToNullifierMap(
+          noteHash,
+          {
+            ...nullifier,
+            spent: !isRemovingTransaction,
+          },
+          tx,
+        )
+        changed = true
       }
-    })
+    }
+
+    return changed
+  }
+
+  private async applyRemove(transaction: Transaction, tx: IDatabaseTransaction): Promise<void> {
+    const transactionHash = transaction.unsignedHash()
+    await this.updateTransactionMap(transactionHash, null, tx)
+
+    for (const note of transaction.notes()) {
+      const merkleHash = note.merkleHash().toString('hex')
+      const noteToNullifier = this.getNoteToNullifierValue(merkleHash, tx)
+
+      if (noteToNullifier) {
+        await this.updateNoteToNullifierMap(merkleHash, null, tx)
+
+        if (noteToNullifier.nullifierHash) {
+          await this.updateNullifierToNoteMap(noteToNullifier.nullifierHash, null, tx)
+        }
+      }
+    }
+
+    for (const spend of transaction.spends()) {
+      const nullifierHash = spend.nullifier.toString('hex')
+      const noteHash = this.getNullifierToNoteValue(nullifierHash, tx)
+
+      if (noteHash) {
+        const nullifier = this.getNoteToNullifierValue(noteHash, tx)
+
+        if (!nullifier) {
+          throw new Error(
+            'nullifierToNote mappings must have a corresponding noteToNullifier map',
+          )
+        }
+
+        await this.updateNoteToNullifierMap(
+          noteHash,
+          {
+            ...nullifier,
+            spent: false,
+          },
+          tx,
+        )
+      }
+    }
+  }
+
+  /**
+   * Add the transactions and notes of an imported account that the wallet
+   * doesn't have yet
+   */
+  private async applyImport(
+    transactions: Array<{
+      transaction: Transaction
+      blockHash: string | null
+      submittedSequence: number | null
+    }>,
+    notes: WalletImportedNote[],
+    tx: IDatabaseTransaction,
+  ): Promise<void> {
+    for (const value of transactions) {
+      const hash = value.transaction.unsignedHash()
+      if (this.getTransactionMapValue(hash, tx)) {
+        continue
+      }
+
+      await this.updateTransactionMap(hash, value, tx)
+    }
+
+    for (const { merkleHash, ...nullifier } of notes) {
+      if (this.getNoteToNullifierValue(merkleHash, tx)) {
+        continue
+      }
+
+      if (nullifier.nullifierHash !== null) {
+        await this.updateNullifierToNoteMap(nullifier.nullifierHash, merkleHash, tx)
+      }
+
+      await this.updateNoteToNullifierMap(merkleHash, nullifier, tx)
+    }
   }
 
   async scanTransactions(): Promise<void> {
@@ -613,6 +1207,7 @@ export class Accounts {
 
     let syncChainProcessor = false
     let lastBlockHash: Buffer | null = null
+    let lastSequence: number | null = null
 
     // Go through every transaction in the chain and add notes that we can decrypt
     for await (const {
@@ -637,6 +1232,7 @@ export class Accounts {
 
       if (syncChainProcessor && !chainProcessorHash.equals(previousBlockHash)) {
         this.chainProcessor.hash = previousBlockHash
+        this.headSequence = sequence - 1
         await this.updateHeadHash(previousBlockHash)
       }
 
@@ -645,12 +1241,16 @@ export class Accounts {
         initialNoteIndex: initialNoteIndex,
       })
 
+      scan.sequence = sequence
+      scan.endSequence = accountHead.sequence
       scan.onTransaction.emit(sequence, accountHead.sequence)
       lastBlockHash = blockHash
+      lastSequence = sequence
     }
 
     if (syncChainProcessor) {
       this.chainProcessor.hash = lastBlockHash
+      this.headSequence = lastSequence
       await this.updateHeadHash(lastBlockHash)
     }
 
@@ -671,12 +1271,20 @@ export class Accounts {
     this.scan = null
   }
 
-  getNotes(account: Account): {
+  /**
+   * If a note is worth less than the dustThreshold config
+   */
+  isDust(value: bigint): boolean {
+    return value > BigInt(0) && value < BigInt(this.config.get('dustThreshold'))
+  }
+
+  getNotes(account: Account): {
     notes: {
       spender: boolean
       amount: number
       memo: string
       noteTxHash: string
+      dust: boolean
     }[]
   } {
     this.assertHasAccount(account)
@@ -703,6 +1311,8 @@ export class Accounts {
             amount: Number(decryptedNote.value()),
             memo: decryptedNote.memo().replace(/\x00/g, ''),
             noteTxHash: transaction.unsignedHash().toString('hex'),
+            // Only notes other accounts send can be dust
+            dust: !spender && this.isDust(decryptedNote.value()),
           })
         }
       }
@@ -711,15 +1321,19 @@ export class Accounts {
     return { notes }
   }
 
-  private async getUnspentNotes(account: Account): Promise<
+  private async getUnspentNotes(
+    account: Account,
+    minimumBlockConfirmations = this.config.get('minimumBlockConfirmations'),
+  ): Promise<
     ReadonlyArray<{
       hash: string
       note: Note
       index: number | null
       confirmed: boolean
+      // null if the note is not on the main chain
+      confirmations: number | null
     }>
   > {
-    const minimumBlockConfirmations = this.config.get('minimumBlockConfirmations')
     const unspentNotes = []
 
     for await (const { blockHash, note } of this.unspentNotesGenerator(account)) {
@@ -729,23 +1343,14 @@ export class Accounts {
       }
 
       if (!map.spent) {
-        let confirmed = false
-
-        if (blockHash) {
-          const header = await this.chain.getHeader(Buffer.from(blockHash, 'hex'))
-          Assert.isNotNull(header)
-          const main = await this.chain.isHeadChain(header)
-          if (main) {
-            const confirmations = this.chain.head.sequence - header.sequence
-            confirmed = confirmations >= minimumBlockConfirmations
-          }
-        }
+        const confirmations = await this.getConfirmations(blockHash)
 
         unspentNotes.push({
           hash: note.hash,
           note: new Note(note.note),
           index: map.noteIndex,
-          confirmed,
+          confirmed: confirmations !== null && confirmations >= minimumBlockConfirmations,
+          confirmations,
         })
       }
     }
@@ -753,7 +1358,26 @@ export class Accounts {
     return unspentNotes
   }
 
+  /**
+   * The number of blocks on top of the block, or null if it is not on the main chain
+   */
+  private async getConfirmations(blockHash: string | null): Promise<number | null> {
+    if (!blockHash) {
+      return null
+    }
+
+    const header = await this.chain.getHeader(Buffer.from(blockHash, 'hex'))
+    Assert.isNotNull(header)
+
+    if (!(await this.chain.isHeadChain(header))) {
+      return null
+    }
+
+    return this.chain.head.sequence - header.sequence
+  }
+
   private async *unspentNotesGenerator(account: Account): AsyncGenerator<{
+    transaction: Transaction
     blockHash: string | null
     note: UnspentNote
   }> {
@@ -764,6 +1388,7 @@ export class Accounts {
     const getUnspentNotes = async (transaction: Transaction, blockHash: string | null) => {
       return {
         ...(await this.workerPool.getUnspentNotes(transaction.serialize(), incomingViewKeys)),
+        transaction,
         blockHash,
       }
     }
@@ -774,9 +1399,9 @@ export class Accounts {
       if (jobs.length >= batchSize) {
         const responses = await Promise.all(jobs)
 
-        for (const { blockHash, notes } of responses) {
+        for (const { transaction, blockHash, notes } of responses) {
           for (const note of notes) {
-            yield { blockHash, note }
+            yield { transaction, blockHash, note }
           }
 
           jobs = []
@@ -787,18 +1412,21 @@ export class Accounts {
     if (jobs.length) {
       const responses = await Promise.all(jobs)
 
-      for (const { blockHash, notes } of responses) {
+      for (const { transaction, blockHash, notes } of responses) {
         for (const note of notes) {
-          yield { blockHash, note }
+          yield { transaction, blockHash, note }
         }
       }
     }
   }
 
-  async getBalance(account: Account): Promise<{ unconfirmed: BigInt; confirmed: BigInt }> {
+  async getBalance(
+    account: Account,
+    options: { minimumBlockConfirmations?: number } = {},
+  ): Promise<{ unconfirmed: BigInt; confirmed: BigInt }> {
     this.assertHasAccount(account)
 
-    const notes = await this.getUnspentNotes(account)
+    const notes = await this.getUnspentNotes(account, options.minimumBlockConfirmations)
 
     let unconfirmed = BigInt(0)
     let confirmed = BigInt(0)
@@ -808,7 +1436,7 @@ export class Accounts {
 
       unconfirmed += value
 
-      if (note.index !== null && note.confirmed) {
+      if (note.index !== null && note.confirmed && !this.isReserved(note.hash)) {
         confirmed += value
       }
     }
@@ -816,6 +1444,140 @@ export class Accounts {
     return { unconfirmed, confirmed }
   }
 
+  /**
+   * The value of confirmed notes held by outgoing transactions that are being
+   * created or are waiting to be mined. They are already left out of the
+   * confirmed balance from getBalance, which is what can still be spent.
+   */
+  async getHeldBalance(
+    account: Account,
+    options: { minimumBlockConfirmations?: number } = {},
+  ): Promise<bigint> {
+    this.assertHasAccount(account)
+
+    const minimumBlockConfirmations =
+      options.minimumBlockConfirmations ?? this.config.get('minimumBlockConfirmations')
+
+    const pendingSpends = new Set<string>()
+    for (const { transaction, blockHash } of this.transactionMap.values()) {
+      if (blockHash === null) {
+        for (const spend of transaction.spends()) {
+          pendingSpends.add(spend.nullifier.toString('hex'))
+        }
+      }
+    }
+
+    let held = BigInt(0)
+
+    for await (const { blockHash, note } of this.unspentNotesGenerator(account)) {
+      const map = this.noteToNullifier.get(note.hash)
+      if (!map || map.noteIndex === null) {
+        continue
+      }
+
+      const pending = map.nullifierHash !== null && pendingSpends.has(map.nullifierHash)
+      const reserved = !map.spent && this.isReserved(note.hash)
+      if (!pending && !reserved) {
+        continue
+      }
+
+      const confirmations = await this.getConfirmations(blockHash)
+      if (confirmations !== null && confirmations >= minimumBlockConfirmations) {
+        held += new Note(note.note).value()
+      }
+    }
+
+    return held
+  }
+
+  /**
+   * Break the account's balance down by why notes can or cannot be spent yet,
+   * one entry for each asset
+   */
+  async getBalanceBreakdown(
+    account: Account,
+    options: { minimumBlockConfirmations?: number } = {},
+  ): Promise<BalanceBreakdown[]> {
+    this.assertHasAccount(account)
+
+    const minimumBlockConfirmations =
+      options.minimumBlockConfirmations ?? this.config.get('minimumBlockConfirmations')
+
+    const locked = await this.getHeldBalance(account, { minimumBlockConfirmations })
+
+    let available = BigInt(0)
+    let immature = BigInt(0)
+    let unconfirmed = BigInt(0)
+    let pendingChange = BigInt(0)
+
+    const created = new BufferMap<boolean>()
+
+    for await (const { transaction, blockHash, note } of this.unspentNotesGenerator(account)) {
+      const map = this.noteToNullifier.get(note.hash)
+      if (!map || map.spent) {
+        continue
+      }
+
+      const value = new Note(note.note).value()
+      const confirmations = await this.getConfirmations(blockHash)
+
+      if (map.noteIndex === null || confirmations === null) {
+        const hash = transaction.unsignedHash()
+        let isCreator = created.get(hash)
+        if (isCreator === undefined) {
+          isCreator = false
+          for (const encrypted of transaction.notes()) {
+            if (encrypted.decryptNoteForSpender(account.outgoingViewKey)) {
+              isCreator = true
+              break
+            }
+          }
+          created.set(hash, isCreator)
+        }
+
+        if (isCreator) {
+          pendingChange += value
+        } else {
+          unconfirmed += value
+        }
+      } else if (confirmations < minimumBlockConfirmations) {
+        immature += value
+      } else if (!this.isReserved(note.hash)) {
+        available += value
+      }
+    }
+
+    return [{ asset: RECEIPT_ASSET, available, immature, unconfirmed, pendingChange, locked }]
+  }
+
+  /**
+   * The balance of notes with at least each number of confirmations
+   */
+  async getBalanceBuckets(
+    account: Account,
+    buckets: number[] = BALANCE_CONFIRMATION_BUCKETS,
+  ): Promise<{ confirmations: number; balance: bigint }[]> {
+    this.assertHasAccount(account)
+
+    const notes = await this.getUnspentNotes(account)
+
+    return buckets.map((confirmations) => {
+      let balance = BigInt(0)
+
+      for (const note of notes) {
+        if (
+          note.index !== null &&
+          note.confirmations !== null &&
+          note.confirmations >= confirmations
+        ) {
+          balance += note.note.value()
+        }
+      }
+
+      return { confirmations, balance }
+    })
+  }
+
   async pay(
     memPool: MemPool,
     sender: Account,
@@ -823,6 +1585,7 @@ export class Accounts {
     transactionFee: bigint,
     defaultTransactionExpirationSequenceDelta: number,
     expirationSequence?: number | null,
+    options: CreateTransactionOptions = {},
   ): Promise<Transaction> {
     const heaviestHead = this.chain.head
     if (heaviestHead === null) {
@@ -833,106 +1596,267 @@ export class Accounts {
       expirationSequence ?? heaviestHead.sequence + defaultTransactionExpirationSequenceDelta
 
     if (this.chain.verifier.isExpiredSequence(expirationSequence, this.chain.head.sequence)) {
-      throw new ValidationError('Invalid expiration sequence for transaction')
+      throw new ValidationError(
+        'Invalid expiration sequence for transaction',
+        undefined,
+        ERROR_CODES.EXPIRED,
+      )
     }
 
-    const transaction = await this.createTransaction(
+    const { transaction, release } = await this.createReservedTransaction(
       sender,
       receives,
       transactionFee,
       expirationSequence,
+      options,
     )
 
-    await this.syncTransaction(transaction, { submittedSequence: heaviestHead.sequence })
+    // The notes stay reserved until syncing marks them as spent
+    try {
+      await this.syncTransaction(transaction, { submittedSequence: heaviestHead.sequence })
+    } finally {
+      release()
+    }
+
     await memPool.acceptTransaction(transaction)
     this.broadcastTransaction(transaction)
 
     return transaction
   }
 
+  /**
+   * Send the whole value of up to `maxNotes` confirmed notes to
+   * `publicAddress`, less the fee, so an account can be emptied over several
+   * transactions that each stay a reasonable size
+   * @returns null if the notes that can be spent are not worth more than the fee
+   */
+  async sweep(
+    memPool: MemPool,
+    sender: Account,
+    publicAddress: string,
+    transactionFee: bigint,
+    defaultTransactionExpirationSequenceDelta: number,
+    options: { maxNotes: number; memo?: string; minimumBlockConfirmations?: number },
+  ): Promise<{ transaction: Transaction; amount: bigint; notes: number } | null> {
+    this.assertHasAccount(sender)
+
+    const notes = (await this.getUnspentNotes(sender, options.minimumBlockConfirmations))
+      .filter((n) => n.index !== null && n.confirmed && !this.isReserved(n.hash))
+      .filter((n) => n.note.value() > BigInt(0) && !this.isDust(n.note.value()))
+      .slice(0, options.maxNotes)
+
+    const total = notes.reduce((sum, n) => sum + n.note.value(), BigInt(0))
+    if (total <= transactionFee) {
+      return null
+    }
+
+    const amount = total - transactionFee
+
+    const transaction = await this.pay(
+      memPool,
+      sender,
+      [{ publicAddress, amount, memo: options.memo ?? '' }],
+      transactionFee,
+      defaultTransactionExpirationSequenceDelta,
+      null,
+      {
+        minimumBlockConfirmations: options.minimumBlockConfirmations,
+        notes: notes.map((n) => n.hash),
+      },
+    )
+
+    return { transaction, amount, notes: notes.length }
+  }
+
+  /**
+   * Estimate the transaction `createTransaction` would build, without
+   * building it. The notes may still change before it is built, and spends
+   * that turn out to be unusable are replaced.
+   */
+  async estimateTransaction(
+    sender: Account,
+    receives: { publicAddress: string; amount: bigint; memo: string }[],
+    transactionFee: bigint,
+    options: { minimumBlockConfirmations?: number } = {},
+  ): Promise<{ spends: number; notes: number; size: number; proofTimeMs: number }> {
+    this.assertHasAccount(sender)
+
+    const amount =
+      receives.reduce((acc, receive) => acc + receive.amount, BigInt(0)) + transactionFee
+
+    const candidates = await this.getSpendableNotes(sender, options)
+    const picked = pickNotes(candidates, amount, (n) => n.note.value())
+
+    if (picked === null) {
+      throw new NotEnoughFundsError()
+    }
+
+    const change = picked.reduce((sum, n) => sum + n.note.value(), BigInt(0)) - amount
+    const makesChange = change > BigInt(0) && !this.isDust(change)
+
+    const spends = picked.length
+    const notes = receives.length + (makesChange ? 1 : 0)
+    const proofTime = this.proofTime.average || DEFAULT_PROOF_TIME_MS
+
+    return {
+      spends,
+      notes,
+      size: getTransactionSize(spends, notes),
+      proofTimeMs: Math.round((spends + notes) * proofTime),
+    }
+  }
+
+  /**
+   * Create a transaction without sending it. Its notes stay reserved until it
+   * is synced, when they are marked as spent, or `releaseTransaction` is
+   * called if it is never sent. The reservation expires if neither happens.
+   */
   async createTransaction(
     sender: Account,
     receives: { publicAddress: string; amount: bigint; memo: string }[],
     transactionFee: bigint,
     expirationSequence: number,
+    options: CreateTransactionOptions = {},
   ): Promise<Transaction> {
+    const { transaction, release } = await this.createReservedTransaction(
+      sender,
+      receives,
+      transactionFee,
+      expirationSequence,
+      options,
+    )
+
+    this.transactionReservations.set(transaction.unsignedHash(), {
+      release,
+      expiresAt: Date.now() + TRANSACTION_RESERVATION_MS,
+    })
+
+    return transaction
+  }
+
+  /**
+   * Release the notes reserved for a transaction from `createTransaction`, so
+   * other transactions can spend them
+   */
+  releaseTransaction(transaction: Transaction): void {
+    const hash = transaction.unsignedHash()
+    const reservation = this.transactionReservations.get(hash)
+    if (!reservation) {
+      return
+    }
+
+    reservation.release()
+    this.transactionReservations.delete(hash)
+  }
+
+  /**
+   * If a note is reserved by a transaction that is being created or has not
+   * been synced yet
+   */
+  private isReserved(noteHash: string): boolean {
+    const now = Date.now()
+    for (const [hash, reservation] of this.transactionReservations) {
+      if (reservation.expiresAt <= now) {
+        reservation.release()
+        this.transactionReservations.delete(hash)
+      }
+    }
+
+    return this.reservedNotes.has(noteHash)
+  }
+
+  /**
+   * Create a transaction with its notes reserved, so no other transaction can
+   * pick them until `release` is called. With a `feePayer` the fee is spent
+   * from its notes instead, and its change is returned to it. Change that
+   * would be dust is added to the fee instead.
+   */
+  private async createReservedTransaction(
+    sender: Account,
+    receives: { publicAddress: string; amount: bigint; memo: string }[],
+    transactionFee: bigint,
+    expirationSequence: number,
+    options: CreateTransactionOptions = {},
+  ): Promise<{ transaction: Transaction; release: () => void }> {
     const unlock = await this.createTransactionMutex.lock()
+    const notesToSpend: Array<{ note: Note; witness: NoteWitness; spendKey?: string }> = []
+    const reserved: string[] = []
+    const release = () => {
+      for (const hash of reserved) {
+        this.reservedNotes.delete(hash)
+      }
+    }
+
+    const feePayer =
+      options.feePayer && options.feePayer.spendingKey !== sender.spendingKey
+        ? options.feePayer
+        : null
+    receives = [...receives]
 
     try {
       this.assertHasAccount(sender)
 
-      // TODO: If we're spending from multiple accounts, we need to figure out a
-      // way to split the transaction fee. - deekerno
-      let amountNeeded =
-        receives.reduce((acc, receive) => acc + receive.amount, BigInt(0)) + transactionFee
-
-      const notesToSpend: Array<{ note: Note; witness: NoteWitness }> = []
-      const unspentNotes = await this.getUnspentNotes(sender)
-
-      for (const unspentNote of unspentNotes) {
-        // Skip unconfirmed notes
-        if (unspentNote.index === null || !unspentNote.confirmed) {
-          continue
-        }
+      const amount = receives.reduce((acc, receive) => acc + receive.amount, BigInt(0))
+      const selectOptions = {
+        minimumBlockConfirmations: options.minimumBlockConfirmations,
+        hashes: options.notes,
+      }
 
-        if (unspentNote.note.value() > BigInt(0)) {
-          // Double-check that the nullifier for the note isn't in the tree already
-          // This would indicate a bug in the account transaction stores
-          const nullifier = Buffer.from(
-            unspentNote.note.nullifier(sender.spendingKey, BigInt(unspentNote.index)),
-          )
+      if (feePayer) {
+        this.assertHasAccount(feePayer)
 
-          if (await this.chain.nullifiers.contains(nullifier)) {
-            this.logger.debug(
-              `Note was marked unspent, but nullifier found in tree: ${nullifier.toString(
-                'hex',
-              )}`,
-            )
-
-            // Update our map so this doesn't happen again
-            const noteMapValue = this.noteToNullifier.get(unspentNote.hash)
-            if (noteMapValue) {
-              this.logger.debug(`Unspent note has index ${String(noteMapValue.noteIndex)}`)
-              await this.updateNoteToNullifierMap(unspentNote.hash, {
-                ...noteMapValue,
-                spent: true,
-              })
-            }
+        const spent = await this.selectNotes(sender, amount, selectOptions)
+        // The notes filter only picks the sender's notes
+        const payment = await this.selectNotes(feePayer, transactionFee, {
+          minimumBlockConfirmations: options.minimumBlockConfirmations,
+        })
 
-            // Move on to the next note
-            continue
-          }
+        notesToSpend.push(...spent.notes)
+        notesToSpend.push(
+          ...payment.notes.map((n) => ({ ...n, spendKey: feePayer.spendingKey })),
+        )
+        reserved.push(...spent.hashes, ...payment.hashes)
 
-          // Try creating a witness from the note
-          const witness = await this.chain.notes.witness(unspentNote.index)
+        const senderChange = spent.total - amount
+        const feePayerChange = payment.total - transactionFee
 
-          if (witness === null) {
-            this.logger.debug(
-              `Could not create a witness for note with index ${unspentNote.index}`,
-            )
-            continue
-          }
+        if (this.isDust(senderChange)) {
+          transactionFee += senderChange
+        }
 
-          // Otherwise, push the note into the list of notes to spend
-          this.logger.debug(
-            `Accounts: spending note ${unspentNote.index} ${
-              unspentNote.hash
-            } ${unspentNote.note.value()}`,
-          )
-          notesToSpend.push({ note: unspentNote.note, witness: witness })
-          amountNeeded -= unspentNote.note.value()
+        // The transaction only makes change for the sender
+        if (this.isDust(feePayerChange)) {
+          transactionFee += feePayerChange
+        } else if (feePayerChange > BigInt(0)) {
+          receives.push({
+            publicAddress: feePayer.publicAddress,
+            amount: feePayerChange,
+            memo: '',
+          })
         }
+      } else {
+        const spent = await this.selectNotes(sender, amount + transactionFee, selectOptions)
 
-        if (amountNeeded <= 0) {
-          break
+        notesToSpend.push(...spent.notes)
+        reserved.push(...spent.hashes)
+
+        const change = spent.total - amount - transactionFee
+        if (this.isDust(change)) {
+          transactionFee += change
         }
       }
 
-      if (amountNeeded > 0) {
-        throw new Error('Insufficient funds')
+      for (const hash of reserved) {
+        this.reservedNotes.add(hash)
       }
+    } finally {
+      unlock()
+    }
 
-      return this.workerPool.createTransaction(
+    try {
+      const start = Date.now()
+
+      const transaction = await this.workerPool.createTransaction(
         sender.spendingKey,
         transactionFee,
         notesToSpend.map((n) => ({
@@ -940,15 +1864,140 @@ export class Accounts {
           treeSize: n.witness.treeSize(),
           authPath: n.witness.authenticationPath,
           rootHash: n.witness.rootHash,
+          spendKey: n.spendKey,
         })),
         receives,
         expirationSequence,
+        options.onProgress,
       )
-    } finally {
-      unlock()
+
+      const proofs = transaction.spendsLength() + transaction.notesLength()
+      this.proofTime.add((Date.now() - start) / proofs)
+
+      return { transaction, release }
+    } catch (e: unknown) {
+      release()
+      throw e
     }
   }
 
+  /**
+   * Pick confirmed notes of `account` worth at least `amount` that no other
+   * transaction is spending, avoiding change where it can. Only notes in
+   * `hashes` are picked if it is given. Call with createTransactionMutex locked.
+   */
+  private async selectNotes(
+    account: Account,
+    amount: bigint,
+    options: { minimumBlockConfirmations?: number; hashes?: string[] } = {},
+  ): Promise<{
+    notes: Array<{ note: Note; witness: NoteWitness }>
+    hashes: string[]
+    total: bigint
+  }> {
+    let candidates = await this.getSpendableNotes(account, options)
+
+    for (;;) {
+      const picked = pickNotes(candidates, amount, (n) => n.note.value())
+
+      if (picked === null) {
+        throw new NotEnoughFundsError()
+      }
+
+      const notes: Array<{ note: Note; witness: NoteWitness }> = []
+      const unusable = new Set<string>()
+
+      for (const unspentNote of picked) {
+        const index = unspentNote.index
+        Assert.isNotNull(index)
+
+        const witness = await this.getSpendableWitness(account, unspentNote, index)
+        if (witness === null) {
+          unusable.add(unspentNote.hash)
+          continue
+        }
+
+        this.logger.debug(
+          `Accounts: spending note ${unspentNote.index} ${
+            unspentNote.hash
+          } ${unspentNote.note.value()}`,
+        )
+        notes.push({ note: unspentNote.note, witness: witness })
+      }
+
+      // Pick again without the notes that can't be spent
+      if (unusable.size) {
+        candidates = candidates.filter((n) => !unusable.has(n.hash))
+        continue
+      }
+
+      return {
+        notes,
+        hashes: picked.map((n) => n.hash),
+        total: picked.reduce((sum, n) => sum + n.note.value(), BigInt(0)),
+      }
+    }
+  }
+
+  /**
+   * The notes of `account` that can be picked to spend, skipping unconfirmed
+   * notes, notes another transaction is spending, and dust
+   */
+  private async getSpendableNotes(
+    account: Account,
+    options: { minimumBlockConfirmations?: number; hashes?: string[] },
+  ): Promise<Array<{ hash: string; note: Note; index: number | null }>> {
+    const only = options.hashes && new Set(options.hashes)
+
+    return (await this.getUnspentNotes(account, options.minimumBlockConfirmations))
+      .filter((n) => n.index !== null && n.confirmed && !this.isReserved(n.hash))
+      .filter((n) => n.note.value() > BigInt(0) && !this.isDust(n.note.value()))
+      .filter((n) => !only || only.has(n.hash))
+  }
+
+  /**
+   * Create a witness to spend the note, or null if it can't be spent
+   */
+  private async getSpendableWitness(
+    account: Account,
+    unspentNote: { hash: string; note: Note },
+    index: number,
+  ): Promise<NoteWitness | null> {
+    // Double-check that the nullifier for the note isn't in the tree already
+    // This would indicate a bug in the account transaction stores
+    const nullifier = Buffer.from(
+      unspentNote.note.nullifier(account.spendingKey, BigInt(index)),
+    )
+
+    if (await this.chain.nullifiers.contains(nullifier)) {
+      this.logger.debug(
+        `Note was marked unspent, but nullifier found in tree: ${nullifier.toString('hex')}`,
+      )
+
+      // Update our map so this doesn't happen again
+      const noteMapValue = this.noteToNullifier.get(unspentNote.hash)
+      if (noteMapValue) {
+        this.logger.debug(`Unspent note has index ${String(noteMapValue.noteIndex)}`)
+        await this.updateNoteToNullifierMap(unspentNote.hash, {
+          ...noteMapValue,
+          spent: true,
+        })
+      }
+
+      return null
+    }
+
+    // Try creating a witness from the note
+    const witness = await this.chain.notes.witness(index)
+
+    if (witness === null) {
+      this.logger.debug(`Could not create a witness for note with index ${index}`)
+      return null
+    }
+
+    return witness
+  }
+
   broadcastTransaction(transaction: Transaction): void {
     this.onBroadcastTransaction.emit(transaction)
   }
@@ -1097,6 +2146,7 @@ export class Accounts {
       notes: number
       spends: number
       expiration: number
+      dust: boolean
     }>
   > {
     this.assertHasAccount(account)
@@ -1109,13 +2159,18 @@ export class Accounts {
       // check if account created transaction
       let transactionCreator = false
       let transactionRecipient = false
+      let received = BigInt(0)
 
       for (const note of transaction.notes()) {
         if (note.decryptNoteForSpender(account.outgoingViewKey)) {
           transactionCreator = true
           break
-        } else if (note.decryptNoteForOwner(account.incomingViewKey)) {
+        }
+
+        const decryptedNote = note.decryptNoteForOwner(account.incomingViewKey)
+        if (decryptedNote) {
           transactionRecipient = true
+          received += decryptedNote.value()
         }
       }
 
@@ -1143,6 +2198,8 @@ export class Accounts {
           notes: transaction.notesLength(),
           spends: transaction.spendsLength(),
           expiration: transaction.expirationSequence(),
+          // Transactions from other accounts that only pay this one dust
+          dust: !transactionCreator && !transaction.isMinersFee() && this.isDust(received),
         })
       }
     }
@@ -1216,6 +2273,123 @@ export class Accounts {
     return { transactionInfo, transactionNotes }
   }
 
+  /**
+   * Disclose the notes `account` sent or received in the transaction with
+   * `hash`, or only the note at `noteIndex`
+   */
+  getDisclosures(account: Account, hash: string, noteIndex?: number): PaymentDisclosure[] {
+    this.assertHasAccount(account)
+
+    const transactionMapValue = this.transactionMap.get(Buffer.from(hash, 'hex'))
+    if (!transactionMapValue) {
+      return []
+    }
+
+    const { transaction, blockHash } = transactionMapValue
+    const indexes =
+      noteIndex !== undefined ? [noteIndex] : [...Array(transaction.notesLength()).keys()]
+
+    const disclosures = []
+    for (const index of indexes) {
+      const disclosure = createDisclosure(account, transaction, index, blockHash)
+      if (disclosure && disclosure.amount !== '0') {
+        disclosures.push(disclosure)
+      }
+    }
+
+    return disclosures
+  }
+
+  /**
+   * Summarize what `account` sent and received in transactions mined on the
+   * main chain between `start` and `end`, which are block timestamps in ms.
+   * Confirmation times are measured from the last time the wallet broadcast
+   * a transaction.
+   */
+  async getSummary(
+    account: Account,
+    options: { start?: number; end?: number; limit?: number } = {},
+  ): Promise<AccountSummary> {
+    this.assertHasAccount(account)
+
+    const start = options.start ?? null
+    const end = options.end ?? null
+    const transactions: SummaryTransaction[] = []
+    let pending = 0
+
+    for (const { transaction, blockHash, submittedSequence } of this.transactionMap.values()) {
+      const { notes, sent } = getReceiptNotes(account, transaction)
+      if (!notes.length) {
+        continue
+      }
+
+      const header = blockHash
+        ? await this.chain.getHeader(Buffer.from(blockHash, 'hex'))
+        : null
+
+      if (!header || !(await this.chain.isHeadChain(header))) {
+        pending++
+        continue
+      }
+
+      const timestamp = header.timestamp.getTime()
+      if ((start !== null && timestamp < start) || (end !== null && timestamp > end)) {
+        continue
+      }
+
+      let confirmationTime = null
+      if (sent && submittedSequence !== null && submittedSequence <= header.sequence) {
+        const submitted = await this.chain.getHeaderAtSequence(submittedSequence)
+        if (submitted) {
+          confirmationTime = timestamp - submitted.timestamp.getTime()
+        }
+      }
+
+      transactions.push({
+        notes,
+        sent,
+        fee: transaction.fee(),
+        isMinersFee: transaction.isMinersFee(),
+        timestamp,
+        confirmationTime,
+      })
+    }
+
+    return summarizeTransactions(transactions, {
+      start,
+      end,
+      pending,
+      limit: options.limit ?? 5,
+    })
+  }
+
+  /**
+   * Create a signed receipt for what the transaction with `hash` sent to or
+   * received for `account`
+   */
+  async getReceipt(account: Account, hash: string): Promise<TransactionReceipt | null> {
+    this.assertHasAccount(account)
+
+    const transactionMapValue = this.transactionMap.get(Buffer.from(hash, 'hex'))
+    if (!transactionMapValue) {
+      return null
+    }
+
+    const { transaction, blockHash } = transactionMapValue
+
+    let status: TransactionReceipt['status'] = 'pending'
+    const header = blockHash ? await this.chain.getHeader(Buffer.from(blockHash, 'hex')) : null
+    if (header) {
+      status = (await this.chain.isHeadChain(header)) ? 'completed' : 'forked'
+    }
+
+    return createReceipt(account, transaction, {
+      status,
+      header,
+      headSequence: this.chain.head.sequence,
+    })
+  }
+
   async importAccount(toImport: Partial<AccountsValue>): Promise<Account> {
     validateAccount(toImport)
 
@@ -1238,6 +2412,146 @@ export class Accounts {
     return account
   }
 
+  /**
+   * Collect the transactions and notes the wallet has for the account, so
+   * importState can add it to another node without a rescan
+   */
+  async exportState(account: Account): Promise<WalletState> {
+    this.assertHasAccount(account)
+
+    if (account.rescan !== null) {
+      throw new Error(`${account.name} is still being scanned, try again once it is done`)
+    }
+
+    const transactions: WalletState['transactions'] = []
+    const notes: WalletState['notes'] = []
+
+    for (const { transaction, blockHash, submittedSequence } of this.transactionMap.values()) {
+      let related = false
+
+      for (const note of transaction.notes()) {
+        if (note.decryptNoteForOwner(account.incomingViewKey)) {
+          related = true
+
+          const merkleHash = note.merkleHash().toString('hex')
+          const nullifier = this.noteToNullifier.get(merkleHash)
+          if (nullifier) {
+            notes.push({ merkleHash, ...nullifier })
+          }
+        } else if (note.decryptNoteForSpender(account.outgoingViewKey)) {
+          related = true
+        }
+      }
+
+      if (related) {
+        transactions.push({
+          hash: transaction.unsignedHash().toString('hex'),
+          transaction: transaction.serialize().toString('hex'),
+          blockHash,
+          submittedSequence,
+        })
+      }
+    }
+
+    const head = this.chainProcessor.hash
+      ? await this.chain.getHeader(this.chainProcessor.hash)
+      : null
+
+    return {
+      version: WALLET_STATE_VERSION,
+      createdAt: new Date().toISOString(),
+      account: account.serialize(),
+      head: head && { hash: head.hash.toString('hex'), sequence: head.sequence },
+      transactions,
+      notes,
+    }
+  }
+
+  /**
+   * Import an account with the state exported by exportState. The blocks the
+   * wallet has scanned past the exported head are scanned for it, and if the
+   * exported head is not on the main chain it is rescanned instead.
+   */
+  async importState(
+    state: WalletState,
+  ): Promise<{ account: Account; result: WalletStateImport }> {
+    if (state.version !== WALLET_STATE_VERSION) {
+      throw new Error(`Unsupported wallet state version ${state.version}`)
+    }
+
+    const exportedHead = state.head
+      ? await this.chain.getHeader(Buffer.from(state.head.hash, 'hex'))
+      : null
+
+    const walletHead = this.chainProcessor.hash
+      ? await this.chain.getHeader(this.chainProcessor.hash)
+      : null
+
+    const account = await this.importAccount({ ...state.account, rescan: null })
+
+    if (
+      !exportedHead ||
+      !walletHead ||
+      !(await this.chain.isHeadChain(exportedHead)) ||
+      !(await this.chain.isHeadChain(walletHead))
+    ) {
+      void this.startScanTransactionsFor(account)
+      return { account, result: { transactions: 0, notes: 0, scanned: 0, rescan: true } }
+    }
+
+    const transactions = state.transactions.map((value) => ({
+      transaction: new Transaction(Buffer.from(value.transaction, 'hex')),
+      blockHash: value.blockHash,
+      submittedSequence: value.submittedSequence,
+    }))
+
+    await this.mapTransaction(async (tx) => {
+      const eventId = this.nextEventId++
+
+      await this.applyImport(transactions, state.notes, tx)
+
+      await this.db.addEvent(
+        eventId,
+        {
+          type: WalletEventType.IMPORT,
+          transactions: transactions.map((value) => this.toEventTransaction(value)),
+          notes: state.notes,
+        },
+        tx,
+      )
+    })
+
+    // Syncing a transaction again is harmless for the other accounts, so catch
+    // the account up to the wallet the same way a scan would
+    let scanned = 0
+    if (walletHead.sequence > exportedHead.sequence) {
+      const transactions = this.chain.iterateTransactions(exportedHead.hash, walletHead.hash)
+
+      for await (const { transaction, blockHash, initialNoteIndex } of transactions) {
+        if (blockHash.equals(exportedHead.hash)) {
+          continue
+        }
+
+        await this.syncTransaction(transaction, {
+          blockHash: blockHash.toString('hex'),
+          initialNoteIndex,
+        })
+      }
+
+      scanned = walletHead.sequence - exportedHead.sequence
+    }
+
+    return {
+      account,
+      result: {
+        transactions: state.transactions.length,
+        notes: state.notes.length,
+        scanned,
+        rescan: false,
+      },
+    }
+  }
+
   listAccounts(): Account[] {
     return Array.from(this.accounts.values())
   }
@@ -1259,6 +2573,16 @@ export class Accounts {
       this.onDefaultAccountChange.emit(null, account)
     }
 
+    for (const [alias, aliasName] of this.aliases) {
+      if (aliasName === name) {
+        await this.removeAlias(alias)
+      }
+    }
+
+    if (this.scanningDisabled.delete(name)) {
+      await this.db.enableScanning(name)
+    }
+
     this.accounts.delete(name)
     await this.db.removeAccount(name)
     this.onAccountRemoved.emit(account)
@@ -1295,6 +2619,141 @@ export class Accounts {
     return this.accounts.get(name) || null
   }
 
+  /**
+   * Find an account by its name or one of its aliases. Names take precedence
+   * so an alias can never hide an account.
+   */
+  resolveAccount(nameOrAlias: string): Account | null {
+    const account = this.getAccountByName(nameOrAlias)
+    if (account) {
+      return account
+    }
+
+    const name = this.aliases.get(nameOrAlias)
+    return name ? this.getAccountByName(name) : null
+  }
+
+  /** Point `alias` at `account`, replacing what it pointed to before */
+  async setAlias(alias: string, account: Account): Promise<void> {
+    this.assertHasAccount(account)
+
+    if (this.accounts.has(alias)) {
+      throw new Error(`There is already an account with the name ${alias}`)
+    }
+
+    this.aliases.set(alias, account.name)
+    await this.db.setAlias(alias, account.name)
+  }
+
+  async removeAlias(alias: string): Promise<boolean> {
+    if (!this.aliases.has(alias)) {
+      return false
+    }
+
+    this.aliases.delete(alias)
+    await this.db.removeAlias(alias)
+    return true
+  }
+
+  /** The aliases for every account, or only for `account` */
+  getAliases(account?: Account): { alias: string; account: string }[] {
+    const aliases = []
+
+    for (const [alias, name] of this.aliases) {
+      if (!account || account.name === name) {
+        aliases.push({ alias, account: name })
+      }
+    }
+
+    return aliases
+  }
+
+  isScanningEnabled(account: Account): boolean {
+    return !this.scanningDisabled.has(account.name)
+  }
+
+  /** The head sequence when scanning was disabled for `account`, or null if it's enabled */
+  getScanningDisabledSequence(account: Account): number | null {
+    return this.scanningDisabled.get(account.name) ?? null
+  }
+
+  /**
+   * Stop or start decrypting the notes of new transactions for `account`.
+   * Enabling scanning again rescans the chain for the account, since it
+   * will have missed any transactions in the blocks added in between.
+   */
+  async setScanningEnabled(account: Account, enabled: boolean): Promise<void> {
+    if (enabled === this.isScanningEnabled(account)) {
+      return
+    }
+
+    if (!enabled) {
+      const sequence = this.chain.head.sequence
+      this.scanningDisabled.set(account.name, sequence)
+      await this.db.disableScanning(account.name, sequence)
+      return
+    }
+
+    this.scanningDisabled.delete(account.name)
+    await this.db.enableScanning(account.name)
+
+    account.rescan = Date.now()
+    await this.db.setAccount(account)
+
+    if (this.isStarted) {
+      void this.scanTransactions()
+    }
+  }
+
+  /**
+   * The sequence of the highest block scanned for `account`. This stops
+   * where scanning was disabled, and is the progress of the rescan while an
+   * account is being rescanned.
+   */
+  getScannedSequence(account: Account): number {
+    const disabledSequence = this.scanningDisabled.get(account.name)
+    if (disabledSequence !== undefined) {
+      return disabledSequence
+    }
+
+    if (account.rescan !== null) {
+      return this.scan && account.rescan <= this.scan.startedAt ? this.scan.sequence : 0
+    }
+
+    return this.headSequence ?? 0
+  }
+
+  /** How many blocks behind the chain head `account` is scanned to */
+  getScanLag(account: Account): number {
+    return Math.max(0, this.chain.head.sequence - this.getScannedSequence(account))
+  }
+
+  /**
+   * Emit onScanLag for accounts that fall more than walletScanLagWarning
+   * blocks behind the chain head, once until they catch up again. Accounts
+   * with scanning disabled are expected to fall behind and are skipped.
+   */
+  checkScanLag(): void {
+    const threshold = this.config.get('walletScanLagWarning')
+
+    for (const account of this.accounts.values()) {
+      const behind = this.getScanLag(account)
+
+      if (threshold <= 0 || behind <= threshold || !this.isScanningEnabled(account)) {
+        this.lagging.delete(account.name)
+        continue
+      }
+
+      if (!this.lagging.has(account.name)) {
+        this.lagging.add(account.name)
+        this.logger.warn(
+          `Account ${account.displayName} is ${behind} blocks behind the chain head, its balance may be out of date`,
+        )
+        this.onScanLag.emit(account, behind)
+      }
+    }
+  }
+
   getDefaultAccount(): Account | null {
     if (!this.defaultAccount) {
       return null
@@ -1328,6 +2787,8 @@ export class ScanState {
 
   readonly startedAt: number
   readonly abortController: AbortController
+  sequence = 0
+  endSequence = 0
   private runningPromise: Promise<void>
   private runningResolve: PromiseResolve<void>
 
@@ -12,11 +12,14 @@ import {
   IDatabaseTransaction,
   StringEncoding,
   StringHashEncoding,
+  U32_BE_ENCODING,
+  U32_ENCODING,
 } from '../storage'
 import { createDB } from '../storage/utils'
 import { WorkerPool } from '../workerPool'
 import { Account } from './account'
 import { AccountsValue, AccountsValueEncoding } from './database/accounts'
+import { WalletEvent, WalletEventEncoding } from './database/events'
 import { AccountsDBMeta, MetaValue, MetaValueEncoding } from './database/meta'
 import {
   NoteToNullifiersValue,
@@ -24,7 +27,7 @@ import {
 } from './database/noteToNullifiers'
 import { TransactionsValue, TransactionsValueEncoding } from './database/transactions'
 
-const DATABASE_VERSION = 5
+export const ACCOUNTS_DATABASE_VERSION = 5
 
 export const AccountDefaults: AccountsValue = {
   name: '',
@@ -48,6 +51,12 @@ export class AccountsDB {
 
   accounts: IDatabaseStore<{ key: string; value: AccountsValue }>
 
+  // Alternate names for accounts, from the alias to the account name
+  aliases: IDatabaseStore<{ key: string; value: string }>
+
+  // Accounts that new blocks aren't scanned for, to the sequence scanning stopped at
+  scanningDisabled: IDatabaseStore<{ key: string; value: number }>
+
   meta: IDatabaseStore<{
     key: keyof AccountsDBMeta
     value: MetaValue
@@ -66,6 +75,10 @@ export class AccountsDB {
     value: TransactionsValue
   }>
 
+  // Every change to the transaction stores above in the order it was made, so
+  // they can be derived again by replaying them
+  events: IDatabaseStore<{ key: number; value: WalletEvent }>
+
   constructor({
     files,
     location,
@@ -95,6 +108,18 @@ export class AccountsDB {
       valueEncoding: new AccountsValueEncoding(),
     })
 
+    this.aliases = this.database.addStore<{ key: string; value: string }>({
+      name: 'aliases',
+      keyEncoding: new StringEncoding(),
+      valueEncoding: new StringEncoding(),
+    })
+
+    this.scanningDisabled = this.database.addStore<{ key: string; value: number }>({
+      name: 'scanningDisabled',
+      keyEncoding: new StringEncoding(),
+      valueEncoding: U32_ENCODING,
+    })
+
     this.noteToNullifier = this.database.addStore<{
       key: string
       value: NoteToNullifiersValue
@@ -118,6 +143,12 @@ export class AccountsDB {
       keyEncoding: BUFFER_ENCODING,
       valueEncoding: new TransactionsValueEncoding(),
     })
+
+    this.events = this.database.addStore<{ key: number; value: WalletEvent }>({
+      name: 'events',
+      keyEncoding: U32_BE_ENCODING,
+      valueEncoding: new WalletEventEncoding(),
+    })
   }
 
   async open(options: { upgrade?: boolean } = { upgrade: true }): Promise<void> {
@@ -126,7 +157,7 @@ export class AccountsDB {
     await this.database.open()
 
     if (options.upgrade) {
-      await this.database.upgrade(DATABASE_VERSION)
+      await this.database.upgrade(ACCOUNTS_DATABASE_VERSION)
     }
   }
 
@@ -142,12 +173,51 @@ export class AccountsDB {
     await this.accounts.del(name)
   }
 
+  async setAlias(alias: string, name: string): Promise<void> {
+    await this.aliases.put(alias, name)
+  }
+
+  async removeAlias(alias: string): Promise<void> {
+    await this.aliases.del(alias)
+  }
+
+  async loadAliases(): Promise<Map<string, string>> {
+    const aliases = new Map<string, string>()
+
+    for await (const [alias, name] of this.aliases.getAllIter()) {
+      aliases.set(alias, name)
+    }
+
+    return aliases
+  }
+
+  async disableScanning(name: string, sequence: number): Promise<void> {
+    await this.scanningDisabled.put(name, sequence)
+  }
+
+  async enableScanning(name: string): Promise<void> {
+    await this.scanningDisabled.del(name)
+  }
+
+  async loadScanningDisabled(): Promise<Map<string, number>> {
+    const disabled = new Map<string, number>()
+
+    for await (const [name, sequence] of this.scanningDisabled.getAllIter()) {
+      disabled.set(name, sequence)
+    }
+
+    return disabled
+  }
+
   async setDefaultAccount(name: AccountsDBMeta['defaultAccountName']): Promise<void> {
     await this.meta.put('defaultAccountName', name)
   }
 
-  async setHeadHash(hash: AccountsDBMeta['headHash']): Promise<void> {
-    await this.meta.put('headHash', hash)
+  async setHeadHash(
+    hash: AccountsDBMeta['headHash'],
+    tx?: IDatabaseTransaction,
+  ): Promise<void> {
+    await this.meta.put('headHash', hash, tx)
   }
 
   async loadAccountsMeta(): Promise<AccountsDBMeta> {
@@ -306,4 +376,58 @@ export class AccountsDB {
       }
     })
   }
+
+  async addEvent(id: number, event: WalletEvent, tx?: IDatabaseTransaction): Promise<void> {
+    await this.events.put(id, event, tx)
+  }
+
+  async *loadEvents(): AsyncGenerator<[number, WalletEvent], void, unknown> {
+    for await (const entry of this.events.getAllIter()) {
+      yield entry
+    }
+  }
+
+  /**
+   * @returns the id of the last event, or null if there are none
+   */
+  async getLastEventId(): Promise<number | null> {
+    // Event ids are big endian, so the last key is the largest id
+    for await (const id of this.events.getAllKeysIter(undefined, { reverse: true, limit: 1 })) {
+      return id
+    }
+
+    return null
+  }
+
+  /**
+   * @returns the id of the first event, or null if there are none
+   */
+  async getFirstEventId(): Promise<number | null> {
+    for await (const id of this.events.getAllKeysIter(undefined, { limit: 1 })) {
+      return id
+    }
+
+    return null
+  }
+
+  /**
+   * Delete the events before `id`, once an event that replaces them was added
+   */
+  async deleteEventsBefore(id: number, tx?: IDatabaseTransaction): Promise<void> {
+    const ids = []
+    for await (const eventId of this.events.getAllKeysIter(tx)) {
+      if (eventId >= id) {
+        break
+      }
+      ids.push(eventId)
+    }
+
+    for (const eventId of ids) {
+      await this.events.del(eventId, tx)
+    }
+  }
+
+  async clearEvents(): Promise<void> {
+    await this.events.clear()
+  }
 }
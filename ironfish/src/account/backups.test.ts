@@ -0,0 +1,114 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import fsAsync from 'fs/promises'
+import os from 'os'
+import path from 'path'
+import { v4 as uuid } from 'uuid'
+import { createNodeTest, useAccountFixture } from '../testUtilities'
+import { PromiseUtils } from '../utils'
+import {
+  decryptWalletBackup,
+  DirectoryBackupLocation,
+  encryptWalletBackup,
+  readBackupPassphrase,
+  WALLET_BACKUP_PASSPHRASE_ENV,
+  WalletBackups,
+} from './backups'
+
+describe('WalletBackups', () => {
+  const nodeTest = createNodeTest()
+
+  it('encrypts backups with the passphrase', () => {
+    const backup = { version: 1, createdAt: new Date().toISOString(), accounts: [] }
+    const encrypted = encryptWalletBackup(backup, 'hunter2')
+
+    expect(decryptWalletBackup(encrypted, 'hunter2')).toEqual(backup)
+    expect(() => decryptWalletBackup(encrypted, 'wrong')).toThrow('passphrase is wrong')
+  })
+
+  it('writes backups and keeps the retention count', async () => {
+    const account = await useAccountFixture(nodeTest.accounts, 'a')
+    const dir = path.join(os.tmpdir(), uuid())
+
+    const backups = new WalletBackups({
+      accounts: nodeTest.accounts,
+      location: dir,
+      passphrase: 'hunter2',
+      intervalHours: 24,
+      retention: 2,
+      reminder: true,
+    })
+
+    const names = []
+    for (let i = 0; i < 3; i++) {
+      names.push(await backups.backup())
+      // Backups are named by time, so make sure each one gets a new name
+      await PromiseUtils.sleep(5)
+    }
+
+    const location = new DirectoryBackupLocation(dir)
+    const files = (await location.list()).map((f) => f.name).sort()
+    expect(files).toEqual([names[1], names[2]])
+
+    const restored = decryptWalletBackup(await location.read(files[1]), 'hunter2')
+    expect(restored.accounts).toContainEqual(account.serialize())
+
+    await fsAsync.rm(dir, { recursive: true, force: true })
+  })
+
+  it('reminds users without backups set up', async () => {
+    await useAccountFixture(nodeTest.accounts, 'a')
+
+    const backups = new WalletBackups({
+      accounts: nodeTest.accounts,
+      location: '',
+      passphrase: '',
+      intervalHours: 24,
+      retention: 7,
+      reminder: true,
+    })
+
+    const warn = jest.spyOn(backups.logger, 'warn').mockImplementation()
+    await backups.run()
+
+    expect(backups.enabled).toBe(false)
+    expect(warn).toHaveBeenCalledWith(expect.stringContaining('not backed up'))
+  })
+
+  it('reads the passphrase from the environment before the file', async () => {
+    const file = path.join(os.tmpdir(), uuid())
+    await fsAsync.writeFile(file, 'from file\n')
+
+    expect(await readBackupPassphrase(file, {})).toEqual('from file')
+    expect(await readBackupPassphrase(file, { [WALLET_BACKUP_PASSPHRASE_ENV]: 'env' })).toEqual(
+      'env',
+    )
+    expect(await readBackupPassphrase('', {})).toEqual('')
+
+    await fsAsync.rm(file, { force: true })
+  })
+
+  it('loads the passphrase from the passphrase file on start', async () => {
+    const file = path.join(os.tmpdir(), uuid())
+    await fsAsync.writeFile(file, 'hunter2\n', { mode: 0o600 })
+
+    const backups = new WalletBackups({
+      accounts: nodeTest.accounts,
+      location: path.join(os.tmpdir(), uuid()),
+      passphraseFile: file,
+      intervalHours: 24,
+      retention: 7,
+      reminder: true,
+    })
+
+    jest.spyOn(backups, 'run').mockResolvedValue()
+    expect(backups.enabled).toBe(false)
+
+    await backups.start()
+    backups.stop()
+
+    expect(backups.enabled).toBe(true)
+    await fsAsync.rm(file, { force: true })
+  })
+})
@@ -0,0 +1,400 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import crypto from 'crypto'
+import fsAsync from 'fs/promises'
+import path from 'path'
+import { createRootLogger, Logger } from '../logger'
+import { S3Client } from '../s3Client'
+import { ErrorUtils, SetIntervalToken } from '../utils'
+import { Accounts } from './accounts'
+import { AccountsValue } from './database/accounts'
+
+const BACKUP_VERSION = 1
+const BACKUP_PREFIX = 'wallet-backup-'
+const BACKUP_EXTENSION = '.json'
+
+const SCRYPT_KEY_LENGTH = 32
+const SCRYPT_OPTIONS = { N: 16384, r: 8, p: 1 }
+
+/**
+ * The environment variable the backup passphrase is read from, it is used
+ * instead of walletBackupPassphraseFile when it is set
+ */
+export const WALLET_BACKUP_PASSPHRASE_ENV = 'IRONFISH_WALLET_BACKUP_PASSPHRASE'
+
+export type WalletBackup = {
+  version: number
+  createdAt: string
+  accounts: AccountsValue[]
+}
+
+/**
+ * The file a backup is written to. The accounts are encrypted with
+ * aes-256-gcm using a key derived from the passphrase with scrypt.
+ */
+export type EncryptedWalletBackup = {
+  version: number
+  kdf: 'scrypt'
+  salt: string
+  iv: string
+  tag: string
+  data: string
+}
+
+export type WalletBackupFile = {
+  name: string
+  size: number
+  createdAt: Date
+}
+
+/**
+ * Encrypt any JSON value the same way as wallet backups, so other files with
+ * keys in them share one format
+ */
+export function encryptWalletData(value: unknown, passphrase: string): string {
+  const salt = crypto.randomBytes(16)
+  const iv = crypto.randomBytes(12)
+  const key = crypto.scryptSync(passphrase, salt, SCRYPT_KEY_LENGTH, SCRYPT_OPTIONS)
+
+  const cipher = crypto.createCipheriv('aes-256-gcm', key, iv)
+  const data = Buffer.concat([cipher.update(JSON.stringify(value), 'utf8'), cipher.final()])
+
+  const encrypted: EncryptedWalletBackup = {
+    version: BACKUP_VERSION,
+    kdf: 'scrypt',
+    salt: salt.toString('hex'),
+    iv: iv.toString('hex'),
+    tag: cipher.getAuthTag().toString('hex'),
+    data: data.toString('base64'),
+  }
+
+  return JSON.stringify(encrypted, undefined, '  ')
+}
+
+export function decryptWalletData(
+  contents: string,
+  passphrase: string,
+  description = 'wallet backup',
+): unknown {
+  const encrypted = JSON.parse(contents) as EncryptedWalletBackup
+
+  if (encrypted.version !== BACKUP_VERSION || encrypted.kdf !== 'scrypt') {
+    throw new Error(`Unsupported ${description} version ${String(encrypted.version)}`)
+  }
+
+  const salt = Buffer.from(encrypted.salt, 'hex')
+  const key = crypto.scryptSync(passphrase, salt, SCRYPT_KEY_LENGTH, SCRYPT_OPTIONS)
+
+  const decipher = crypto.createDecipheriv('aes-256-gcm', key, Buffer.from(encrypted.iv, 'hex'))
+  decipher.setAuthTag(Buffer.from(encrypted.tag, 'hex'))
+
+  let data
+  try {
+    data = Buffer.concat([
+      decipher.update(Buffer.from(encrypted.data, 'base64')),
+      decipher.final(),
+    ])
+  } catch {
+    throw new Error(`Could not decrypt the ${description}, the passphrase is wrong`)
+  }
+
+  return JSON.parse(data.toString('utf8')) as unknown
+}
+
+export function encryptWalletBackup(backup: WalletBackup, passphrase: string): string {
+  return encryptWalletData(backup, passphrase)
+}
+
+export function decryptWalletBackup(contents: string, passphrase: string): WalletBackup {
+  return decryptWalletData(contents, passphrase) as WalletBackup
+}
+
+/**
+ * Read the backup passphrase from the environment, or from `file` if it is
+ * not set there. Returns an empty string if there is neither.
+ */
+export async function readBackupPassphrase(
+  file: string,
+  env: NodeJS.ProcessEnv = process.env,
+): Promise<string> {
+  const passphrase = env[WALLET_BACKUP_PASSPHRASE_ENV]
+  if (passphrase) {
+    return passphrase
+  }
+
+  if (!file) {
+    return ''
+  }
+
+  // The newline editors end files with isn't part of the passphrase
+  const contents = await fsAsync.readFile(file, 'utf8')
+  return contents.replace(/\r?\n$/, '')
+}
+
+/**
+ * Where wallet backups are kept
+ */
+export interface WalletBackupLocation {
+  readonly description: string
+  list(): Promise<WalletBackupFile[]>
+  read(name: string): Promise<string>
+  write(name: string, contents: string): Promise<void>
+  remove(name: string): Promise<void>
+}
+
+function isBackupName(name: string): boolean {
+  return name.startsWith(BACKUP_PREFIX) && name.endsWith(BACKUP_EXTENSION)
+}
+
+export class DirectoryBackupLocation implements WalletBackupLocation {
+  readonly dir: string
+
+  constructor(dir: string) {
+    this.dir = dir
+  }
+
+  get description(): string {
+    return this.dir
+  }
+
+  async list(): Promise<WalletBackupFile[]> {
+    let names
+    try {
+      names = await fsAsync.readdir(this.dir)
+    } catch (e) {
+      if (ErrorUtils.isNoEntityError(e)) {
+        return []
+      }
+      throw e
+    }
+
+    const files = []
+    for (const name of names.filter(isBackupName)) {
+      const stat = await fsAsync.stat(path.join(this.dir, name))
+      files.push({ name, size: stat.size, createdAt: stat.mtime })
+    }
+
+    return files
+  }
+
+  read(name: string): Promise<string> {
+    return fsAsync.readFile(path.join(this.dir, name), 'utf8')
+  }
+
+  async write(name: string, contents: string): Promise<void> {
+    await fsAsync.mkdir(this.dir, { recursive: true })
+
+    // Write to a temporary file first so a partial backup never looks complete
+    const destination = path.join(this.dir, name)
+    await fsAsync.writeFile(`${destination}.partial`, contents, { mode: 0o600 })
+    await fsAsync.rename(`${destination}.partial`, destination)
+  }
+
+  async remove(name: string): Promise<void> {
+    await fsAsync.rm(path.join(this.dir, name), { force: true })
+  }
+}
+
+export class S3BackupLocation implements WalletBackupLocation {
+  readonly client: S3Client
+  readonly bucket: string
+  readonly prefix: string
+
+  constructor(url: string, client = S3Client.fromEnvironment()) {
+    const { bucket, key } = S3Client.parseUrl(url)
+    this.client = client
+    this.bucket = bucket
+    this.prefix = key && !key.endsWith('/') ? `${key}/` : key
+  }
+
+  get description(): string {
+    return `s3://${this.bucket}/${this.prefix}`
+  }
+
+  async list(): Promise<WalletBackupFile[]> {
+    const objects = await this.client.listObjects(this.bucket, this.prefix)
+
+    return objects
+      .map((o) => ({
+        name: o.key.slice(this.prefix.length),
+        size: o.size,
+        createdAt: o.lastModified,
+      }))
+      .filter((f) => isBackupName(f.name))
+  }
+
+  async read(name: string): Promise<string> {
+    const data = await this.client.getObject(this.bucket, `${this.prefix}${name}`)
+    return data.toString('utf8')
+  }
+
+  async write(name: string, contents: string): Promise<void> {
+    await this.client.putObject(this.bucket, `${this.prefix}${name}`, Buffer.from(contents))
+  }
+
+  async remove(name: string): Promise<void> {
+    await this.client.deleteObject(this.bucket, `${this.prefix}${name}`)
+  }
+}
+
+/**
+ * Open a directory, or an S3 bucket if `location` is an s3:// URL
+ */
+export function openBackupLocation(location: string): WalletBackupLocation {
+  if (S3Client.isS3Url(location)) {
+    return new S3BackupLocation(location)
+  }
+
+  return new DirectoryBackupLocation(location)
+}
+
+/**
+ * Backups sort by name because the name starts with the time it was made
+ */
+export function sortBackups(files: WalletBackupFile[]): WalletBackupFile[] {
+  return [...files].sort((a, b) => (a.name < b.name ? 1 : a.name > b.name ? -1 : 0))
+}
+
+/**
+ * Periodically writes an encrypted copy of every account to a backup
+ * location, and deletes the oldest backups past the retention count. When
+ * backups are not set up, it reminds users to back up their accounts instead.
+ */
+export class WalletBackups {
+  readonly accounts: Accounts
+  readonly logger: Logger
+  readonly location: WalletBackupLocation | null
+  readonly passphraseFile: string
+  readonly intervalMs: number
+  readonly retention: number
+  readonly reminder: boolean
+
+  lastBackup: Date | null = null
+
+  private passphrase: string
+  private interval: SetIntervalToken | null = null
+
+  constructor(options: {
+    accounts: Accounts
+    location: string
+    // The passphrase is read from passphraseFile or the environment on start if it's not given
+    passphrase?: string
+    passphraseFile?: string
+    intervalHours: number
+    retention: number
+    reminder: boolean
+    logger?: Logger
+  }) {
+    this.accounts = options.accounts
+    this.logger = (options.logger ?? createRootLogger()).withTag('walletbackups')
+    this.location = options.location ? openBackupLocation(options.location) : null
+    this.passphrase = options.passphrase ?? ''
+    this.passphraseFile = options.passphraseFile ?? ''
+    this.intervalMs = options.intervalHours * 60 * 60 * 1000
+    this.retention = options.retention
+    this.reminder = options.reminder
+  }
+
+  get enabled(): boolean {
+    return this.location !== null && this.passphrase.length > 0
+  }
+
+  async start(): Promise<void> {
+    if (this.interval || this.intervalMs <= 0) {
+      return
+    }
+
+    if (!this.passphrase) {
+      try {
+        this.passphrase = await readBackupPassphrase(this.passphraseFile)
+      } catch (e: unknown) {
+        this.logger.error(`Could not read the backup passphrase: ${ErrorUtils.renderError(e)}`)
+      }
+    }
+
+    if (this.location && !this.passphrase) {
+      this.logger.warn(
+        `Wallet backups are disabled until ${WALLET_BACKUP_PASSPHRASE_ENV} or` +
+          ' walletBackupPassphraseFile is set, because backups are always encrypted',
+      )
+    }
+
+    void this.run()
+    this.interval = setInterval(() => void this.run(), this.intervalMs)
+  }
+
+  stop(): void {
+    if (this.interval) {
+      clearInterval(this.interval)
+      this.interval = null
+    }
+  }
+
+  async run(): Promise<void> {
+    if (!this.enabled) {
+      this.remind()
+      return
+    }
+
+    try {
+      await this.backup()
+    } catch (e: unknown) {
+      this.logger.error(`Could not back up the wallet: ${ErrorUtils.renderError(e)}`)
+    }
+  }
+
+  remind(): void {
+    if (!this.reminder || !this.accounts.listAccounts().length) {
+      return
+    }
+
+    this.logger.warn(
+      'Your accounts are not backed up. Run accounts:export, or set walletBackupPath and' +
+        ' a backup passphrase to back them up automatically.',
+    )
+  }
+
+  /**
+   * Write an encrypted backup of every account and prune old backups
+   * @returns the name of the backup, or null if there are no accounts
+   */
+  async backup(): Promise<string | null> {
+    if (!this.location || !this.passphrase) {
+      throw new Error('Wallet backups need walletBackupPath and a backup passphrase')
+    }
+
+    const accounts = this.accounts.listAccounts().map((a) => a.serialize())
+    if (!accounts.length) {
+      return null
+    }
+
+    const createdAt = new Date()
+    const backup = { version: BACKUP_VERSION, createdAt: createdAt.toISOString(), accounts }
+    const timestamp = createdAt.toISOString().replace(/[:.]/g, '-')
+    const name = `${BACKUP_PREFIX}${timestamp}${BACKUP_EXTENSION}`
+
+    await this.location.write(name, encryptWalletBackup(backup, this.passphrase))
+    this.lastBackup = createdAt
+
+    this.logger.info(`Backed up ${accounts.length} accounts to ${this.location.description}`)
+
+    await this.prune()
+    return name
+  }
+
+  /**
+   * Delete the oldest backups past the retention count, 0 keeps every backup
+   */
+  async prune(): Promise<void> {
+    if (!this.location || this.retention <= 0) {
+      return
+    }
+
+    const expired = sortBackups(await this.location.list()).slice(this.retention)
+
+    for (const file of expired) {
+      await this.location.remove(file.name)
+    }
+  }
+}
@@ -0,0 +1,70 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { WalletEvent, WalletEventEncoding, WalletEventType } from './events'
+
+describe('WalletEventEncoding', () => {
+  const encoder = new WalletEventEncoding()
+  const hash = (n: number) => Buffer.alloc(32, n).toString('hex')
+
+  it('serializes sync events', () => {
+    const value: WalletEvent = {
+      type: WalletEventType.SYNC,
+      hash: Buffer.alloc(32, 1),
+      blockHash: hash(2),
+      submittedSequence: null,
+      transaction: null,
+      initialNoteIndex: 0,
+    }
+
+    expect(encoder.deserialize(encoder.serialize(value))).toEqual(value)
+  })
+
+  it('serializes sync events of transactions that are not in a block', () => {
+    const value: WalletEvent = {
+      type: WalletEventType.SYNC,
+      hash: Buffer.alloc(32, 1),
+      blockHash: null,
+      submittedSequence: 5,
+      transaction: Buffer.from('mock-transaction'),
+      initialNoteIndex: null,
+    }
+
+    expect(encoder.deserialize(encoder.serialize(value))).toEqual(value)
+  })
+
+  it('serializes remove events', () => {
+    const value: WalletEvent = {
+      type: WalletEventType.REMOVE,
+      hash: Buffer.alloc(32, 1),
+    }
+
+    expect(encoder.deserialize(encoder.serialize(value))).toEqual(value)
+  })
+
+  it('serializes import events', () => {
+    const value: WalletEvent = {
+      type: WalletEventType.IMPORT,
+      transactions: [
+        {
+          hash: Buffer.alloc(32, 1),
+          blockHash: null,
+          submittedSequence: 5,
+          transaction: Buffer.from('mock-transaction'),
+        },
+        {
+          hash: Buffer.alloc(32, 4),
+          blockHash: hash(5),
+          submittedSequence: null,
+          transaction: null,
+        },
+      ],
+      notes: [
+        { merkleHash: hash(2), nullifierHash: hash(3), noteIndex: 7, spent: true },
+        { merkleHash: hash(6), nullifierHash: null, noteIndex: null, spent: false },
+      ],
+    }
+
+    expect(encoder.deserialize(encoder.serialize(value))).toEqual(value)
+  })
+})
@@ -0,0 +1,221 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import bufio from 'bufio'
+import { IDatabaseEncoding } from '../../storage'
+
+export enum WalletEventType {
+  // A transaction with notes the wallet decrypted or spent was synced
+  SYNC = 1,
+  // A transaction was removed from the wallet, like when it expired
+  REMOVE = 2,
+  // Transactions and notes were imported from an account's exported state
+  IMPORT = 3,
+}
+
+export type WalletImportedNote = {
+  merkleHash: string
+  nullifierHash: string | null
+  noteIndex: number | null
+  spent: boolean
+}
+
+/**
+ * A transaction an event refers to. Transactions in a block are read from the
+ * chain again when the events are replayed, so only the bytes of the others
+ * are stored.
+ */
+export type WalletEventTransaction = {
+  hash: Buffer
+  blockHash: string | null
+  submittedSequence: number | null
+  transaction: Buffer | null
+}
+
+export type WalletEvent =
+  | ({ type: WalletEventType.SYNC; initialNoteIndex: number | null } & WalletEventTransaction)
+  | { type: WalletEventType.REMOVE; hash: Buffer }
+  | {
+      type: WalletEventType.IMPORT
+      transactions: WalletEventTransaction[]
+      notes: WalletImportedNote[]
+    }
+
+export class WalletEventEncoding implements IDatabaseEncoding<WalletEvent> {
+  serialize(value: WalletEvent): Buffer {
+    const bw = bufio.write(this.getSize(value))
+    bw.writeU8(value.type)
+
+    switch (value.type) {
+      case WalletEventType.SYNC: {
+        this.writeTransaction(bw, value)
+        bw.writeU8(Number(value.initialNoteIndex !== null))
+        if (value.initialNoteIndex !== null) {
+          bw.writeU32(value.initialNoteIndex)
+        }
+        break
+      }
+      case WalletEventType.REMOVE: {
+        bw.writeHash(value.hash)
+        break
+      }
+      case WalletEventType.IMPORT: {
+        bw.writeU32(value.transactions.length)
+        for (const transaction of value.transactions) {
+          this.writeTransaction(bw, transaction)
+        }
+        bw.writeU32(value.notes.length)
+        for (const note of value.notes) {
+          this.writeNote(bw, note)
+        }
+        break
+      }
+    }
+
+    return bw.render()
+  }
+
+  deserialize(buffer: Buffer): WalletEvent {
+    const reader = bufio.read(buffer, true)
+    const type = reader.readU8() as WalletEventType
+
+    switch (type) {
+      case WalletEventType.SYNC: {
+        const transaction = this.readTransaction(reader)
+        const initialNoteIndex = reader.readU8() ? reader.readU32() : null
+        return { type, ...transaction, initialNoteIndex }
+      }
+      case WalletEventType.REMOVE: {
+        return { type, hash: reader.readHash() }
+      }
+      case WalletEventType.IMPORT: {
+        const transactions = []
+        const transactionCount = reader.readU32()
+        for (let i = 0; i < transactionCount; i++) {
+          transactions.push(this.readTransaction(reader))
+        }
+
+        const notes = []
+        const noteCount = reader.readU32()
+        for (let i = 0; i < noteCount; i++) {
+          notes.push(this.readNote(reader))
+        }
+        return { type, transactions, notes }
+      }
+      default:
+        throw new Error(`Unknown wallet event type ${String(type)}`)
+    }
+  }
+
+  getSize(value: WalletEvent): number {
+    let size = 1
+
+    switch (value.type) {
+      case WalletEventType.SYNC: {
+        size += this.getTransactionSize(value)
+        size += 1
+        if (value.initialNoteIndex !== null) {
+          size += 4
+        }
+        break
+      }
+      case WalletEventType.REMOVE: {
+        size += 32
+        break
+      }
+      case WalletEventType.IMPORT: {
+        size += 4
+        for (const transaction of value.transactions) {
+          size += this.getTransactionSize(transaction)
+        }
+        size += 4
+        for (const note of value.notes) {
+          size += this.getNoteSize(note)
+        }
+        break
+      }
+    }
+
+    return size
+  }
+
+  private writeTransaction(bw: bufio.StaticWriter, value: WalletEventTransaction): void {
+    let flags = 0
+    flags |= Number(value.blockHash !== null) << 0
+    flags |= Number(value.submittedSequence !== null) << 1
+    flags |= Number(value.transaction !== null) << 2
+    bw.writeU8(flags)
+
+    bw.writeHash(value.hash)
+    if (value.blockHash !== null) {
+      bw.writeHash(value.blockHash)
+    }
+    if (value.submittedSequence !== null) {
+      bw.writeU32(value.submittedSequence)
+    }
+    if (value.transaction !== null) {
+      bw.writeVarBytes(value.transaction)
+    }
+  }
+
+  private readTransaction(reader: bufio.BufferReader): WalletEventTransaction {
+    const flags = reader.readU8()
+    const hash = reader.readHash()
+    const blockHash = flags & (1 << 0) ? reader.readHash('hex') : null
+    const submittedSequence = flags & (1 << 1) ? reader.readU32() : null
+    const transaction = flags & (1 << 2) ? reader.readVarBytes() : null
+
+    return { hash, blockHash, submittedSequence, transaction }
+  }
+
+  private getTransactionSize(value: WalletEventTransaction): number {
+    let size = 1 + 32
+    if (value.blockHash !== null) {
+      size += 32
+    }
+    if (value.submittedSequence !== null) {
+      size += 4
+    }
+    if (value.transaction !== null) {
+      size += bufio.sizeVarBytes(value.transaction)
+    }
+    return size
+  }
+
+  private writeNote(bw: bufio.StaticWriter, note: WalletImportedNote): void {
+    let flags = 0
+    flags |= Number(note.nullifierHash !== null) << 0
+    flags |= Number(note.noteIndex !== null) << 1
+    flags |= Number(note.spent) << 2
+    bw.writeU8(flags)
+
+    bw.writeHash(note.merkleHash)
+    if (note.nullifierHash !== null) {
+      bw.writeHash(note.nullifierHash)
+    }
+    if (note.noteIndex !== null) {
+      bw.writeU32(note.noteIndex)
+    }
+  }
+
+  private readNote(reader: bufio.BufferReader): WalletImportedNote {
+    const flags = reader.readU8()
+    const merkleHash = reader.readHash('hex')
+    const nullifierHash = flags & (1 << 0) ? reader.readHash('hex') : null
+    const noteIndex = flags & (1 << 1) ? reader.readU32() : null
+    const spent = Boolean(flags & (1 << 2))
+
+    return { merkleHash, nullifierHash, noteIndex, spent }
+  }
+
+  private getNoteSize(note: WalletImportedNote): number {
+    let size = 1 + 32
+    if (note.nullifierHash !== null) {
+      size += 32
+    }
+    if (note.noteIndex !== null) {
+      size += 4
+    }
+    return size
+  }
+}
@@ -0,0 +1,126 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { Note } from '../primitives/note'
+import { Transaction } from '../primitives/transaction'
+import { Account } from './account'
+import { isValidPublicAddress } from './validator'
+
+export const DISCLOSURE_VERSION = 1
+
+/**
+ * What the sender or the recipient of a note shares to prove it was paid in a
+ * transaction. The disclosure key only decrypts this one note, so nothing
+ * else about either account is revealed.
+ */
+export type PaymentDisclosure = {
+  version: number
+  transactionHash: string
+  // null if the transaction was not on the chain when it was disclosed
+  blockHash: string | null
+  noteIndex: number
+  owner: string
+  amount: string
+  memo: string
+  disclosureKey: string
+}
+
+export type DisclosureVerification =
+  | { valid: true; owner: string; amount: string; memo: string }
+  | { valid: false; reason: string }
+
+function renderMemo(note: Note): string {
+  return note.memo().replace(/\x00/g, '')
+}
+
+/**
+ * Disclose the note at `noteIndex` of `transaction` if `account` sent or
+ * received it
+ */
+export function createDisclosure(
+  account: Account,
+  transaction: Transaction,
+  noteIndex: number,
+  blockHash: string | null,
+): PaymentDisclosure | null {
+  if (noteIndex < 0 || noteIndex >= transaction.notesLength()) {
+    return null
+  }
+
+  const encrypted = transaction.getNote(noteIndex)
+
+  const disclosureKey =
+    encrypted.disclosureKeyForOwner(account.incomingViewKey) ??
+    encrypted.disclosureKeyForSpender(account.outgoingViewKey)
+
+  const note =
+    encrypted.decryptNoteForOwner(account.incomingViewKey) ??
+    encrypted.decryptNoteForSpender(account.outgoingViewKey)
+
+  if (!disclosureKey || !note) {
+    return null
+  }
+
+  return {
+    version: DISCLOSURE_VERSION,
+    transactionHash: transaction.unsignedHash().toString('hex'),
+    blockHash,
+    noteIndex,
+    owner: note.owner(),
+    amount: note.value().toString(),
+    memo: renderMemo(note),
+    disclosureKey: disclosureKey.toString('hex'),
+  }
+}
+
+/**
+ * Check that the disclosed note is in `transaction` with the disclosed owner,
+ * amount and memo. Checking that the transaction is on the chain is up to the
+ * caller.
+ */
+export function verifyDisclosure(
+  transaction: Transaction,
+  disclosure: PaymentDisclosure,
+): DisclosureVerification {
+  if (disclosure.version !== DISCLOSURE_VERSION) {
+    return { valid: false, reason: `Unknown disclosure version ${disclosure.version}` }
+  }
+
+  if (transaction.unsignedHash().toString('hex') !== disclosure.transactionHash) {
+    return { valid: false, reason: 'The disclosure is for another transaction' }
+  }
+
+  const { noteIndex, owner } = disclosure
+  if (!Number.isInteger(noteIndex) || noteIndex < 0 || noteIndex >= transaction.notesLength()) {
+    return { valid: false, reason: `The transaction has no note ${noteIndex}` }
+  }
+
+  if (!isValidPublicAddress(owner)) {
+    return { valid: false, reason: `${owner} is not a valid public address` }
+  }
+
+  const disclosureKey = Buffer.from(disclosure.disclosureKey, 'hex')
+
+  let note
+  try {
+    note = transaction.getNote(noteIndex).decryptNoteForDisclosure(owner, disclosureKey)
+  } catch {
+    // Hex that doesn't decode to a public address
+    return { valid: false, reason: `${owner} is not a valid public address` }
+  }
+
+  if (!note) {
+    return { valid: false, reason: 'The disclosure key does not decrypt the note' }
+  }
+
+  const amount = note.value().toString()
+  if (amount !== disclosure.amount) {
+    return { valid: false, reason: `The note is for ${amount} ore, not ${disclosure.amount}` }
+  }
+
+  if (renderMemo(note) !== disclosure.memo) {
+    return { valid: false, reason: 'The memo does not match the note' }
+  }
+
+  return { valid: true, owner: note.owner(), amount, memo: renderMemo(note) }
+}
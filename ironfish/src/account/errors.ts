@@ -0,0 +1,11 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { ERROR_CODES, ValidationError } from '../rpc/adapters/errors'
+
+/** Thrown when an account doesn't have enough spendable notes to pay an amount */
+export class NotEnoughFundsError extends ValidationError {
+  constructor(message = 'Insufficient funds') {
+    super(message, 400, ERROR_CODES.INSUFFICIENT_BALANCE)
+  }
+}
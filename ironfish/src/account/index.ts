@@ -3,6 +3,12 @@
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 export * from './account'
 export * from './accounts'
+export * from './backups'
+export * from './disclosure'
+export * from './errors'
+export * from './receipt'
+export * from './summary'
+export * from './walletState'
 export { AccountsValue } from './database/accounts'
 export * from './validator'
 export * from './accountsdb'
@@ -0,0 +1,35 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { pickNotes } from './noteSelection'
+
+describe('pickNotes', () => {
+  const pick = (values: number[], amount: number) =>
+    pickNotes(values.map((v) => BigInt(v)), BigInt(amount), (v) => v)?.map(Number)
+
+  it('picks a note worth exactly the amount', () => {
+    expect(pick([5, 10, 7], 7)).toEqual([7])
+  })
+
+  it('picks two notes worth exactly the amount', () => {
+    expect(pick([5, 10, 4, 3], 8)).toEqual([5, 3])
+    expect(pick([4, 4], 8)).toEqual([4, 4])
+  })
+
+  it('picks the smallest note worth more than the amount', () => {
+    expect(pick([50, 12, 30], 11)).toEqual([12])
+  })
+
+  it('picks notes in order until they are worth enough', () => {
+    expect(pick([3, 3, 3, 3], 7)).toEqual([3, 3, 3])
+  })
+
+  it('returns null if the notes are not worth enough', () => {
+    expect(pick([3, 3], 7)).toBeNull()
+    expect(pick([], 1)).toBeNull()
+  })
+
+  it('picks nothing for a zero amount', () => {
+    expect(pick([3], 0)).toEqual([])
+  })
+})
@@ -0,0 +1,73 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+/**
+ * Pick which of `notes` to spend to pay `amount`, preferring picks that
+ * don't leave change, since every change note fragments the wallet and links
+ * the spent notes to it. In order of preference this picks
+ *
+ * - one note worth exactly the amount
+ * - two notes worth exactly the amount together
+ * - the smallest note worth more than the amount
+ * - notes in the order given until they are worth enough
+ *
+ * @returns null if the notes together are not worth the amount
+ */
+export function pickNotes<T>(
+  notes: T[],
+  amount: bigint,
+  value: (note: T) => bigint,
+): T[] | null {
+  if (amount <= BigInt(0)) {
+    return []
+  }
+
+  const byValue = new Map<bigint, T>()
+  let smallest: T | null = null
+  let smallestValue = BigInt(0)
+
+  for (const note of notes) {
+    const noteValue = value(note)
+
+    if (noteValue === amount) {
+      return [note]
+    }
+
+    if (noteValue > amount && (smallest === null || noteValue < smallestValue)) {
+      smallest = note
+      smallestValue = noteValue
+    }
+  }
+
+  for (const note of notes) {
+    const noteValue = value(note)
+    const other = byValue.get(amount - noteValue)
+
+    if (other !== undefined) {
+      return [other, note]
+    }
+
+    if (!byValue.has(noteValue)) {
+      byValue.set(noteValue, note)
+    }
+  }
+
+  if (smallest !== null) {
+    return [smallest]
+  }
+
+  const picked: T[] = []
+  let total = BigInt(0)
+
+  for (const note of notes) {
+    picked.push(note)
+    total += value(note)
+
+    if (total >= amount) {
+      return picked
+    }
+  }
+
+  return null
+}
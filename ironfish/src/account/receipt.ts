@@ -0,0 +1,244 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { signMessage, verifyMessage } from '@ironfish/rust-nodejs'
+import { BlockHeader } from '../primitives/blockheader'
+import { Transaction } from '../primitives/transaction'
+import { displayIronAmountWithCurrency, oreToIron } from '../utils/currency'
+import { Account } from './account'
+
+export const RECEIPT_VERSION = 1
+
+// There is only one asset on the chain for now
+export const RECEIPT_ASSET = '$IRON'
+
+export type ReceiptNote = {
+  // True if the account sent this note, false if the account received it
+  sent: boolean
+  owner: string
+  amount: string
+  memo: string
+}
+
+/**
+ * A record of what a transaction did for one account, as of the time it was
+ * exported. The signature is made with the account's spending key so anyone
+ * with the receipt can check it came from the owner of `publicAddress`.
+ */
+export type TransactionReceipt = {
+  version: number
+  account: string
+  publicAddress: string
+  transactionHash: string
+  status: 'pending' | 'completed' | 'forked'
+  asset: string
+  fee: string
+  // Received minus sent, negative when the account paid out
+  netAmount: string
+  notes: ReceiptNote[]
+  block: { hash: string; sequence: number; timestamp: number } | null
+  confirmations: number
+  exportedAt: number
+  signature: string
+}
+
+export type UnsignedTransactionReceipt = Omit<TransactionReceipt, 'signature'>
+
+/**
+ * The bytes that are signed for a receipt. The fields are written in a fixed
+ * order so the receipt can be checked after it was stored or reformatted.
+ */
+export function serializeReceipt(receipt: UnsignedTransactionReceipt): Buffer {
+  const message = {
+    version: receipt.version,
+    account: receipt.account,
+    publicAddress: receipt.publicAddress,
+    transactionHash: receipt.transactionHash,
+    status: receipt.status,
+    asset: receipt.asset,
+    fee: receipt.fee,
+    netAmount: receipt.netAmount,
+    notes: receipt.notes.map((n) => ({
+      sent: n.sent,
+      owner: n.owner,
+      amount: n.amount,
+      memo: n.memo,
+    })),
+    block: receipt.block && {
+      hash: receipt.block.hash,
+      sequence: receipt.block.sequence,
+      timestamp: receipt.block.timestamp,
+    },
+    confirmations: receipt.confirmations,
+    exportedAt: receipt.exportedAt,
+  }
+
+  return Buffer.from(JSON.stringify(message), 'utf8')
+}
+
+/**
+ * Decrypt the notes `account` sent or received in `transaction`. `netAmount`
+ * includes the fee if the account paid it.
+ */
+export function getReceiptNotes(
+  account: Account,
+  transaction: Transaction,
+): { notes: ReceiptNote[]; netAmount: bigint; sent: boolean } {
+  const notes: ReceiptNote[] = []
+  let netAmount = BigInt(0)
+  let sent = false
+
+  for (const encrypted of transaction.notes()) {
+    const ownerNote = encrypted.decryptNoteForOwner(account.incomingViewKey)
+    const spenderNote = encrypted.decryptNoteForSpender(account.outgoingViewKey)
+    const note = ownerNote ?? spenderNote
+
+    if (!note || note.value() === BigInt(0)) {
+      continue
+    }
+
+    // Change the account sent to itself is both sent and received
+    const received = !!ownerNote
+    const isSender = !!spenderNote
+    sent = sent || isSender
+
+    if (received && !isSender) {
+      netAmount += note.value()
+    } else if (!received) {
+      netAmount -= note.value()
+    }
+
+    notes.push({
+      sent: !received,
+      owner: note.owner(),
+      amount: note.value().toString(),
+      memo: note.memo().replace(/\x00/g, ''),
+    })
+  }
+
+  // The sender pays the fee, miners fee transactions have a negative fee
+  if (sent && !transaction.isMinersFee()) {
+    netAmount -= transaction.fee()
+  }
+
+  return { notes, netAmount, sent }
+}
+
+/**
+ * Build a signed receipt for the notes `account` sent or received in
+ * `transaction`, or return null if it has none. Accounts without a spending
+ * key can't sign receipts.
+ */
+export function createReceipt(
+  account: Account,
+  transaction: Transaction,
+  options: {
+    status: TransactionReceipt['status']
+    header: BlockHeader | null
+    headSequence: number
+    exportedAt?: number
+  },
+): TransactionReceipt | null {
+  if (!account.spendingKey) {
+    throw new Error(`${account.name} has no spending key, so it can't sign receipts`)
+  }
+
+  const { notes, netAmount } = getReceiptNotes(account, transaction)
+
+  if (!notes.length) {
+    return null
+  }
+
+  const { header, status } = options
+  const confirmations =
+    header && status === 'completed' ? Math.max(0, options.headSequence - header.sequence) : 0
+
+  const unsigned: UnsignedTransactionReceipt = {
+    version: RECEIPT_VERSION,
+    account: account.name,
+    publicAddress: account.publicAddress,
+    transactionHash: transaction.unsignedHash().toString('hex'),
+    status,
+    asset: RECEIPT_ASSET,
+    fee: transaction.fee().toString(),
+    netAmount: netAmount.toString(),
+    notes,
+    block: header && {
+      hash: header.hash.toString('hex'),
+      sequence: header.sequence,
+      timestamp: header.timestamp.getTime(),
+    },
+    confirmations,
+    exportedAt: options.exportedAt ?? Date.now(),
+  }
+
+  const signature = signMessage(
+    account.spendingKey,
+    account.publicAddress,
+    serializeReceipt(unsigned),
+  )
+
+  return { ...unsigned, signature: signature.toString('hex') }
+}
+
+/**
+ * Check that the receipt was signed by the owner of its public address and
+ * was not changed since. This does not check the receipt against the chain.
+ */
+export function verifyReceipt(receipt: TransactionReceipt): boolean {
+  try {
+    return verifyMessage(
+      receipt.publicAddress,
+      serializeReceipt(receipt),
+      Buffer.from(receipt.signature, 'hex'),
+    )
+  } catch {
+    return false
+  }
+}
+
+function displayOre(ore: string): string {
+  return displayIronAmountWithCurrency(oreToIron(Number(ore)), true)
+}
+
+/**
+ * Render the receipt as text for bookkeeping
+ */
+export function renderReceipt(receipt: TransactionReceipt): string {
+  const lines = [
+    `Transaction receipt for ${receipt.account}`,
+    `Address:        ${receipt.publicAddress}`,
+    `Transaction:    ${receipt.transactionHash}`,
+    `Status:         ${receipt.status}`,
+    `Asset:          ${receipt.asset}`,
+    `Net amount:     ${displayOre(receipt.netAmount)}`,
+    `Fee:            ${displayOre(receipt.fee)}`,
+  ]
+
+  if (receipt.block) {
+    const minedAt = new Date(receipt.block.timestamp).toISOString()
+    lines.push(`Block:          ${receipt.block.sequence} ${receipt.block.hash}`)
+    lines.push(`Mined at:       ${minedAt}`)
+    lines.push(`Confirmations:  ${receipt.confirmations}`)
+  } else {
+    lines.push(`Block:          not on the chain`)
+  }
+
+  lines.push(`Exported at:    ${new Date(receipt.exportedAt).toISOString()}`)
+  lines.push('')
+  lines.push('Notes:')
+
+  for (const note of receipt.notes) {
+    const direction = note.sent ? 'Sent' : 'Received'
+    const preposition = note.sent ? 'to' : 'at'
+    lines.push(`  ${direction} ${displayOre(note.amount)} ${preposition} ${note.owner}`)
+    if (note.memo) {
+      lines.push(`    Memo: ${note.memo}`)
+    }
+  }
+
+  lines.push('')
+  lines.push(`Signature: ${receipt.signature}`)
+
+  return lines.join('\n')
+}
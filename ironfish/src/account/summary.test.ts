@@ -0,0 +1,60 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { SummaryTransaction, summarizeTransactions } from './summary'
+
+describe('summarizeTransactions', () => {
+  const options = { start: null, end: null, pending: 1, limit: 2 }
+
+  it('totals payments without counting change', () => {
+    const transactions: SummaryTransaction[] = [
+      {
+        notes: [{ sent: false, owner: 'me', amount: '2000', memo: '' }],
+        sent: false,
+        fee: BigInt(-2000),
+        isMinersFee: true,
+        timestamp: 1,
+        confirmationTime: null,
+      },
+      {
+        notes: [
+          { sent: true, owner: 'shop', amount: '300', memo: 'order 1' },
+          { sent: false, owner: 'me', amount: '1690', memo: '' },
+        ],
+        sent: true,
+        fee: BigInt(10),
+        isMinersFee: false,
+        timestamp: 2,
+        confirmationTime: 4000,
+      },
+      {
+        notes: [{ sent: false, owner: 'me', amount: '50', memo: 'refund' }],
+        sent: false,
+        fee: BigInt(1),
+        isMinersFee: false,
+        timestamp: 3,
+        confirmationTime: null,
+      },
+    ]
+
+    const summary = summarizeTransactions(transactions, options)
+
+    expect(summary).toMatchObject({
+      transactions: 3,
+      pending: 1,
+      assets: [{ asset: '$IRON', received: BigInt(2050), sent: BigInt(300) }],
+      feesPaid: BigInt(10),
+      minersFees: BigInt(2000),
+      averageConfirmationTime: 4000,
+    })
+
+    expect(summary.largest).toEqual([
+      { sent: false, amount: BigInt(2000), memo: '', counterparty: null, timestamp: 1 },
+      { sent: true, amount: BigInt(300), memo: 'order 1', counterparty: 'shop', timestamp: 2 },
+    ])
+  })
+
+  it('has no average confirmation time without sent transactions', () => {
+    expect(summarizeTransactions([], options).averageConfirmationTime).toBeNull()
+  })
+})
@@ -0,0 +1,103 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { RECEIPT_ASSET, ReceiptNote } from './receipt'
+
+export type SummaryTransaction = {
+  notes: ReceiptNote[]
+  sent: boolean
+  fee: bigint
+  isMinersFee: boolean
+  // When the block with the transaction was mined
+  timestamp: number
+  // How long the transaction took to be mined after the wallet sent it
+  confirmationTime: number | null
+}
+
+export type SummaryPayment = {
+  sent: boolean
+  amount: bigint
+  memo: string
+  // The address of the recipient, only known for sent notes
+  counterparty: string | null
+  timestamp: number
+}
+
+export type AccountSummary = {
+  start: number | null
+  end: number | null
+  transactions: number
+  pending: number
+  assets: { asset: string; received: bigint; sent: bigint }[]
+  feesPaid: bigint
+  minersFees: bigint
+  largest: SummaryPayment[]
+  averageConfirmationTime: number | null
+}
+
+/**
+ * Total up the mined transactions of an account. The largest payments are
+ * sorted by amount, with at most `limit` of them.
+ */
+export function summarizeTransactions(
+  transactions: SummaryTransaction[],
+  options: { start: number | null; end: number | null; pending: number; limit: number },
+): AccountSummary {
+  let received = BigInt(0)
+  let sent = BigInt(0)
+  let feesPaid = BigInt(0)
+  let minersFees = BigInt(0)
+  let confirmationTime = 0
+  let confirmed = 0
+  const payments: SummaryPayment[] = []
+
+  for (const transaction of transactions) {
+    if (transaction.sent) {
+      feesPaid += transaction.fee
+
+      if (transaction.confirmationTime !== null) {
+        confirmationTime += transaction.confirmationTime
+        confirmed++
+      }
+    }
+
+    for (const note of transaction.notes) {
+      // Notes an account receives in a transaction it sent are change
+      if (transaction.sent && !note.sent) {
+        continue
+      }
+
+      const amount = BigInt(note.amount)
+
+      if (transaction.isMinersFee) {
+        minersFees += amount
+      } else if (note.sent) {
+        sent += amount
+      } else {
+        received += amount
+      }
+
+      payments.push({
+        sent: note.sent,
+        amount,
+        memo: note.memo,
+        counterparty: note.sent ? note.owner : null,
+        timestamp: transaction.timestamp,
+      })
+    }
+  }
+
+  payments.sort((a, b) => (a.amount === b.amount ? 0 : a.amount > b.amount ? -1 : 1))
+
+  return {
+    start: options.start,
+    end: options.end,
+    transactions: transactions.length,
+    pending: options.pending,
+    assets: [{ asset: RECEIPT_ASSET, received: received + minersFees, sent }],
+    feesPaid,
+    minersFees,
+    largest: payments.slice(0, options.limit),
+    averageConfirmationTime: confirmed ? Math.round(confirmationTime / confirmed) : null,
+  }
+}
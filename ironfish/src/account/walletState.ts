@@ -0,0 +1,41 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { AccountsValue } from './database/accounts'
+
+export const WALLET_STATE_VERSION = 1
+
+/**
+ * What a wallet has derived for one account from scanning the chain, so the
+ * account can be moved to another node without rescanning. It holds the
+ * spending key, so it is only ever written to disk encrypted.
+ */
+export type WalletState = {
+  version: number
+  createdAt: string
+  account: AccountsValue
+  // The block the wallet had scanned to, null if it had not scanned any
+  head: { hash: string; sequence: number } | null
+  transactions: {
+    hash: string
+    // The serialized transaction in hex
+    transaction: string
+    blockHash: string | null
+    submittedSequence: number | null
+  }[]
+  notes: {
+    merkleHash: string
+    nullifierHash: string | null
+    noteIndex: number | null
+    spent: boolean
+  }[]
+}
+
+export type WalletStateImport = {
+  transactions: number
+  notes: number
+  // The number of blocks on top of the exported head that were scanned
+  scanned: number
+  // True if the exported head is not on the chain, so a rescan was started
+  rescan: boolean
+}
@@ -0,0 +1,29 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { BLOCK_ARCHIVE_VERSION, readBlockArchive, writeBlockArchive } from './archive'
+
+describe('Block archive', () => {
+  const genesis = Buffer.alloc(32, 1)
+  const blocks = [Buffer.from('first'), Buffer.alloc(0), Buffer.alloc(1000, 7)]
+
+  it('should read what was written', () => {
+    const archive = writeBlockArchive({ genesis, start: 2, stop: 4 }, blocks)
+
+    expect(readBlockArchive(archive)).toEqual({
+      header: { version: BLOCK_ARCHIVE_VERSION, genesis, start: 2, stop: 4, count: 3 },
+      blocks,
+    })
+  })
+
+  it('should reject corrupted archives', () => {
+    const archive = writeBlockArchive({ genesis, start: 2, stop: 4 }, blocks)
+
+    const corrupted = Buffer.from(archive)
+    corrupted[50] ^= 0xff
+    expect(() => readBlockArchive(corrupted)).toThrow('checksum does not match')
+
+    expect(() => readBlockArchive(archive.subarray(0, 60))).toThrow('checksum')
+    expect(() => readBlockArchive(Buffer.alloc(100))).toThrow('Not a block archive')
+  })
+})
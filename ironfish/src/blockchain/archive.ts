@@ -0,0 +1,111 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { blake3 } from '@napi-rs/blake-hash'
+import bufio from 'bufio'
+
+/**
+ * A block archive stores a range of blocks so they can be moved between nodes
+ * without a network connection. All integers are little endian.
+ *
+ *   magic     4 bytes   "IFBA"
+ *   version   u8        BLOCK_ARCHIVE_VERSION
+ *   genesis   32 bytes  hash of the genesis block of the archived chain
+ *   start     u32       sequence of the first block
+ *   stop      u32       sequence of the last block
+ *   count     u32       number of blocks
+ *   blocks    count times a u32 length followed by the block, serialized the
+ *             same way blocks are sent on the network
+ *   checksum  32 bytes  blake3 of every byte before it
+ */
+export const BLOCK_ARCHIVE_MAGIC = Buffer.from('IFBA', 'ascii')
+export const BLOCK_ARCHIVE_VERSION = 1
+
+const CHECKSUM_LENGTH = 32
+
+export type BlockArchiveHeader = {
+  version: number
+  genesis: Buffer
+  start: number
+  stop: number
+  count: number
+}
+
+export class BlockArchiveError extends Error {
+  name = this.constructor.name
+}
+
+export function writeBlockArchive(
+  options: { genesis: Buffer; start: number; stop: number },
+  blocks: Buffer[],
+): Buffer {
+  const bw = bufio.write()
+  bw.writeBytes(BLOCK_ARCHIVE_MAGIC)
+  bw.writeU8(BLOCK_ARCHIVE_VERSION)
+  bw.writeHash(options.genesis)
+  bw.writeU32(options.start)
+  bw.writeU32(options.stop)
+  bw.writeU32(blocks.length)
+
+  for (const block of blocks) {
+    bw.writeU32(block.length)
+    bw.writeBytes(block)
+  }
+
+  const body = bw.render()
+  return Buffer.concat([body, blake3(body)])
+}
+
+/**
+ * Read an archive written by writeBlockArchive, and throw a BlockArchiveError
+ * if it is truncated, corrupted, or from an unknown version
+ */
+export function readBlockArchive(buffer: Buffer): {
+  header: BlockArchiveHeader
+  blocks: Buffer[]
+} {
+  if (
+    buffer.length < BLOCK_ARCHIVE_MAGIC.length + CHECKSUM_LENGTH ||
+    !buffer.subarray(0, BLOCK_ARCHIVE_MAGIC.length).equals(BLOCK_ARCHIVE_MAGIC)
+  ) {
+    throw new BlockArchiveError('Not a block archive')
+  }
+
+  const body = buffer.subarray(0, buffer.length - CHECKSUM_LENGTH)
+  const checksum = buffer.subarray(buffer.length - CHECKSUM_LENGTH)
+
+  if (!blake3(body).equals(checksum)) {
+    throw new BlockArchiveError('The block archive checksum does not match')
+  }
+
+  const reader = bufio.read(body, true)
+  reader.seek(BLOCK_ARCHIVE_MAGIC.length)
+
+  const version = reader.readU8()
+  if (version !== BLOCK_ARCHIVE_VERSION) {
+    throw new BlockArchiveError(`Unknown block archive version ${version}`)
+  }
+
+  const header = {
+    version,
+    genesis: reader.readHash(),
+    start: reader.readU32(),
+    stop: reader.readU32(),
+    count: reader.readU32(),
+  }
+
+  const blocks: Buffer[] = []
+  try {
+    for (let i = 0; i < header.count; i++) {
+      blocks.push(reader.readBytes(reader.readU32()))
+    }
+  } catch {
+    throw new BlockArchiveError(`The block archive is missing blocks`)
+  }
+
+  if (reader.left() !== 0) {
+    throw new BlockArchiveError(`The block archive has data after the last block`)
+  }
+
+  return { header, blocks }
+}
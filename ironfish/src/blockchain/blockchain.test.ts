@@ -770,4 +770,115 @@ describe('Blockchain', () => {
       reason: VerificationResultReason.MINERS_FEE_MISMATCH,
     })
   })
+
+  describe('getBlockHashByTransactionHash', () => {
+    it('finds the transactions of the main chain', async () => {
+      const { node } = await nodeTest.createSetup()
+      const { chain } = node
+
+      const blockA1 = await useMinerBlockFixture(chain, 2)
+      await expect(chain).toAddBlock(blockA1)
+
+      const blockA2 = await useMinerBlockFixture(chain, 3)
+      await expect(chain).toAddBlock(blockA2)
+
+      const [transactionA1] = blockA1.transactions
+      const [transactionA2] = blockA2.transactions
+
+      expect(await chain.getBlockHashByTransactionHash(transactionA1.hash())).toEqual(
+        blockA1.header.hash,
+      )
+      expect(await chain.getBlockHashByTransactionHash(transactionA2.hash())).toEqual(
+        blockA2.header.hash,
+      )
+
+      await chain.removeBlock(blockA2.header.hash)
+
+      expect(await chain.getBlockHashByTransactionHash(transactionA2.hash())).toBeNull()
+      expect(await chain.getBlockHashByTransactionHash(Buffer.alloc(32))).toBeNull()
+    })
+
+    it('skips the database for transactions that are not in the filter', async () => {
+      const { node } = await nodeTest.createSetup()
+      const { chain } = node
+
+      const block = await useMinerBlockFixture(chain, 2)
+      await expect(chain).toAddBlock(block)
+
+      await chain['loadTransactionFilter']()
+      const get = jest.spyOn(chain.transactionHashToBlockHash, 'get')
+
+      expect(await chain.getBlockHashByTransactionHash(Buffer.alloc(32))).toBeNull()
+      expect(get).not.toHaveBeenCalled()
+
+      expect(await chain.getBlockHashByTransactionHash(block.transactions[0].hash())).toEqual(
+        block.header.hash,
+      )
+      expect(get).toHaveBeenCalledTimes(1)
+    })
+  })
+
+  describe('caches', () => {
+    it('reads headers and blocks from the caches', async () => {
+      const { node } = await nodeTest.createSetup()
+      node.chain.setCacheSize(1024 * 1024)
+
+      const block = await useMinerBlockFixture(node.chain)
+      await expect(node.chain).toAddBlock(block)
+      node.chain.clearCaches()
+
+      const get = jest.spyOn(node.chain.headers, 'get')
+
+      const header = await node.chain.getHeader(block.header.hash)
+      expect(await node.chain.getHeader(block.header.hash)).toBe(header)
+      expect(get).toHaveBeenCalledTimes(1)
+
+      const cached = await node.chain.getBlock(block.header.hash)
+      expect(await node.chain.getBlock(block.header.hash)).toBe(cached)
+
+      expect(node.chain.headerCache.hits).toBeGreaterThan(0)
+      expect(node.chain.blockCache.hits).toBeGreaterThan(0)
+      expect(node.chain.cacheSizeBytes).toBeGreaterThan(0)
+    })
+
+    it('forgets removed blocks', async () => {
+      const { node } = await nodeTest.createSetup()
+      node.chain.setCacheSize(1024 * 1024)
+
+      const block = await useMinerBlockFixture(node.chain)
+      await expect(node.chain).toAddBlock(block)
+      await node.chain.getBlock(block.header.hash)
+
+      await node.chain.removeBlock(block.header.hash)
+
+      expect(await node.chain.getHeader(block.header.hash)).toBeNull()
+      expect(await node.chain.getBlock(block.header.hash)).toBeNull()
+    })
+  })
+
+  describe('recover', () => {
+    it('finds nothing to fix in a consistent chain', async () => {
+      const { node } = await nodeTest.createSetup()
+      const block = await useMinerBlockFixture(node.chain)
+      await expect(node.chain).toAddBlock(block)
+
+      await expect(node.chain.recover()).resolves.toEqual([])
+    })
+
+    it('rolls back writes past the head', async () => {
+      const { node } = await nodeTest.createSetup()
+      const block = await useMinerBlockFixture(node.chain)
+      await expect(node.chain).toAddBlock(block)
+
+      // Simulate the writes of a block that was cut off before the head was updated
+      await node.chain.notes.add(block.transactions[0].getNote(0))
+      await node.chain.sequenceToHash.put(block.header.sequence + 1, block.header.hash)
+
+      const fixes = await node.chain.recover()
+
+      expect(fixes).toHaveLength(2)
+      expect(await node.chain.notes.size()).toEqual(block.header.noteCommitment.size)
+      expect(await node.chain.getHashAtSequence(block.header.sequence + 1)).toBeNull()
+    })
+  })
 })
@@ -5,22 +5,25 @@
 import LRU from 'blru'
 import { BufferMap } from 'buffer-map'
 import { Assert } from '../assert'
+import { BloomFilter } from '../bloomFilter'
 import {
+  ConsensusParameters,
   GENESIS_BLOCK_PREVIOUS,
   GENESIS_BLOCK_SEQUENCE,
   MAX_SYNCED_AGE_MS,
-  TARGET_BLOCK_TIME_IN_SECONDS,
 } from '../consensus'
 import { VerificationResultReason, Verifier } from '../consensus/verifier'
 import { Event } from '../event'
-import { genesisBlockData } from '../genesis'
 import { createRootLogger, Logger } from '../logger'
+import { CacheStats, LRUCache } from '../lruCache'
 import { MerkleTree } from '../merkletree'
 import { NoteLeafEncoding, NullifierLeafEncoding } from '../merkletree/database/leaves'
 import { NodeEncoding } from '../merkletree/database/nodes'
-import { Meter, MetricsMonitor } from '../metrics'
+import { NODE_SIZE_ESTIMATE } from '../merkletree/merkletree'
+import { Meter, MetricsMonitor, Span, Tracer } from '../metrics'
 import { BAN_SCORE } from '../network/peers/peer'
-import { Block, SerializedBlock } from '../primitives/block'
+import { BUILTIN_NETWORKS, DEFAULT_NETWORK, NetworkDefinition } from '../networkDefinitions'
+import { Block } from '../primitives/block'
 import { BlockHash, BlockHeader, isBlockHeavier, isBlockLater } from '../primitives/blockheader'
 import {
   NoteEncrypted,
@@ -30,8 +33,7 @@ import {
 } from '../primitives/noteEncrypted'
 import { Nullifier, NullifierHash } from '../primitives/nullifier'
 import { Target } from '../primitives/target'
-import { Transaction } from '../primitives/transaction'
-import { IJSON } from '../serde'
+import { Transaction, TransactionHash } from '../primitives/transaction'
 import {
   BUFFER_ENCODING,
   IDatabase,
@@ -53,10 +55,19 @@ import {
   MetaSchema,
   SequenceToHashesSchema,
   SequenceToHashSchema,
+  TransactionHashToBlockHashSchema,
   TransactionsSchema,
 } from './schema'
 
-const DATABASE_VERSION = 6
+export const CHAIN_DATABASE_VERSION = 8
+
+// A rough number of heap bytes used by a cached header
+const HEADER_SIZE_ESTIMATE = 1000
+
+// How much of the chain cache each kind of cache gets
+const HEADER_CACHE_SHARE = 0.2
+const BLOCK_CACHE_SHARE = 0.5
+const TREE_NODE_CACHE_SHARE = 0.3
 
 export class Blockchain {
   db: IDatabase
@@ -64,6 +75,7 @@ export class Blockchain {
   strategy: Strategy
   verifier: Verifier
   metrics: MetricsMonitor
+  network: NetworkDefinition
 
   synced = false
   opened = false
@@ -76,10 +88,30 @@ export class Blockchain {
   nullifiers: MerkleTree<Nullifier, NullifierHash, string, string>
 
   addSpeed: Meter
+  tracer: Tracer
   invalid: LRU<Buffer, VerificationResultReason>
   logAllBlockAdd: boolean
   // Whether to seed the chain with a genesis block when opening the database.
   autoSeed: boolean
+  // Reorganizations that disconnect more blocks than this wait for the operator
+  // to confirm them with confirmReorganization. 0 allows any reorganization.
+  maxReorgDepth: number
+  // Keep bloom filters of the nullifiers and transaction hashes in memory so
+  // looking up ones that are not on the chain doesn't read the database
+  enableChainFilters: boolean
+  // Holds the hash of every transaction on the main chain when
+  // enableChainFilters is set
+  transactionFilter: BloomFilter | null = null
+  // The head of the heaviest fork that was too deep to reorganize to
+  refusedReorg: BlockHeader | null = null
+
+  // Recently read headers and blocks, sized with setCacheSize
+  readonly headerCache: LRUCache<BlockHash, BlockHeader>
+  readonly blockCache: LRUCache<BlockHash, Block>
+  // Transactions that wrote headers or blocks, which must not read from or fill the caches
+  private readonly cacheWrites = new WeakSet<IDatabaseTransaction>()
+  // Changes when a block is saved or removed, so reads that overlap it don't fill the caches
+  private cacheGeneration = 0
 
   // Contains flat fields
   meta: IDatabaseStore<MetaSchema>
@@ -93,6 +125,8 @@ export class Blockchain {
   sequenceToHash: IDatabaseStore<SequenceToHashSchema>
   // BlockHash -> BlockHash
   hashToNextHash: IDatabaseStore<HashToNextSchema>
+  // TransactionHash -> BlockHash
+  transactionHashToBlockHash: IDatabaseStore<TransactionHashToBlockHashSchema>
 
   // When ever the blockchain becomes synced
   onSynced = new Event<[]>()
@@ -102,6 +136,14 @@ export class Blockchain {
   onDisconnectBlock = new Event<[block: Block, tx?: IDatabaseTransaction]>()
   // When ever a block is added to a fork
   onForkBlock = new Event<[block: Block, tx?: IDatabaseTransaction]>()
+  // When ever the heaviest chain switches to a fork, after the blocks are reconnected
+  onReorganize = new Event<[oldHead: BlockHeader, newHead: BlockHeader, fork: BlockHeader]>()
+  // When ever the head changes, after the changes are committed
+  onHeadChange = new Event<[head: BlockHeader, previous: BlockHeader | null]>()
+  // When ever a heavier fork is deeper than maxReorgDepth and was not reorganized to
+  onReorganizeRefused = new Event<
+    [head: BlockHeader, forkHead: BlockHeader, fork: BlockHeader]
+  >()
 
   private _head: BlockHeader | null = null
   get head(): BlockHeader {
@@ -145,8 +187,12 @@ export class Blockchain {
     workerPool: WorkerPool
     logger?: Logger
     metrics?: MetricsMonitor
+    tracer?: Tracer
     logAllBlockAdd?: boolean
     autoSeed?: boolean
+    network?: NetworkDefinition
+    maxReorgDepth?: number
+    enableChainFilters?: boolean
   }) {
     const logger = options.logger || createRootLogger()
 
@@ -156,9 +202,31 @@ export class Blockchain {
     this.verifier = new Verifier(this, options.workerPool)
     this.db = createDB({ location: options.location })
     this.addSpeed = this.metrics.addMeter()
+    this.tracer = options.tracer || new Tracer({ logger: this.logger })
     this.invalid = new LRU(100, null, BufferMap)
     this.logAllBlockAdd = options.logAllBlockAdd || false
     this.autoSeed = options.autoSeed ?? true
+    this.maxReorgDepth = options.maxReorgDepth ?? 0
+    this.enableChainFilters = options.enableChainFilters ?? false
+    this.network = options.network ?? BUILTIN_NETWORKS[DEFAULT_NETWORK]()
+
+    this.headerCache = new LRUCache<BlockHash, BlockHeader>({
+      name: 'headers',
+      getSize: () => HEADER_SIZE_ESTIMATE,
+      map: BufferMap,
+      hits: this.metrics.chain_HeaderCacheHits,
+      misses: this.metrics.chain_HeaderCacheMisses,
+    })
+
+    this.blockCache = new LRUCache<BlockHash, Block>({
+      name: 'blocks',
+      getSize: (block) =>
+        block.transactions.reduce((size, t) => size + t.serialize().byteLength, 0) +
+        HEADER_SIZE_ESTIMATE,
+      map: BufferMap,
+      hits: this.metrics.chain_BlockCacheHits,
+      misses: this.metrics.chain_BlockCacheMisses,
+    })
 
     // Flat Fields
     this.meta = this.db.addStore({
@@ -201,6 +269,13 @@ export class Blockchain {
       valueEncoding: BUFFER_ENCODING,
     })
 
+    // TransactionHash -> BlockHash
+    this.transactionHashToBlockHash = this.db.addStore({
+      name: 'bT',
+      keyEncoding: BUFFER_ENCODING,
+      valueEncoding: BUFFER_ENCODING,
+    })
+
     this.notes = new MerkleTree({
       hasher: this.strategy.noteHasher,
       leafIndexKeyEncoding: BUFFER_ENCODING,
@@ -209,6 +284,12 @@ export class Blockchain {
       db: this.db,
       name: 'n',
       depth: 32,
+      nodeCache: new LRUCache({
+        name: 'noteNodes',
+        getSize: () => NODE_SIZE_ESTIMATE,
+        hits: this.metrics.chain_TreeNodeCacheHits,
+        misses: this.metrics.chain_TreeNodeCacheMisses,
+      }),
     })
 
     this.nullifiers = new MerkleTree({
@@ -219,6 +300,12 @@ export class Blockchain {
       db: this.db,
       name: 'u',
       depth: 32,
+      nodeCache: new LRUCache({
+        name: 'nullifierNodes',
+        getSize: () => NODE_SIZE_ESTIMATE,
+        hits: this.metrics.chain_TreeNodeCacheHits,
+        misses: this.metrics.chain_TreeNodeCacheMisses,
+      }),
     })
   }
 
@@ -226,15 +313,50 @@ export class Blockchain {
     return !this._head
   }
 
+  get consensus(): ConsensusParameters {
+    return this.network.consensus
+  }
+
   get hasGenesisBlock(): boolean {
     return !!this._genesis
   }
 
+  get cacheSizeBytes(): number {
+    return this.getCacheStats().reduce((size, c) => size + c.sizeBytes, 0)
+  }
+
+  /**
+   * Split maxBytes between the caches of headers, blocks and tree nodes. This
+   * empties the caches, and 0 disables them.
+   */
+  setCacheSize(maxBytes: number): void {
+    this.headerCache.resize(maxBytes * HEADER_CACHE_SHARE)
+    this.blockCache.resize(maxBytes * BLOCK_CACHE_SHARE)
+    this.notes.nodeCache.resize((maxBytes * TREE_NODE_CACHE_SHARE) / 2)
+    this.nullifiers.nodeCache.resize((maxBytes * TREE_NODE_CACHE_SHARE) / 2)
+  }
+
+  clearCaches(): void {
+    this.headerCache.clear()
+    this.blockCache.clear()
+    this.notes.nodeCache.clear()
+    this.nullifiers.nodeCache.clear()
+  }
+
+  getCacheStats(): CacheStats[] {
+    return [
+      this.headerCache.getStats(),
+      this.blockCache.getStats(),
+      this.notes.nodeCache.getStats(),
+      this.nullifiers.nodeCache.getStats(),
+    ]
+  }
+
   getProgress(): number {
     const start = this.genesis.timestamp.valueOf()
     const current = this.head.timestamp.valueOf()
     const end = Date.now()
-    const offset = TARGET_BLOCK_TIME_IN_SECONDS * 4 * 1000
+    const offset = this.consensus.targetBlockTimeInSeconds * 4 * 1000
 
     const progress = (current - start) / (end - offset - start)
 
@@ -242,8 +364,7 @@ export class Blockchain {
   }
 
   private async seed() {
-    const serialized = IJSON.parse(genesisBlockData) as SerializedBlock
-    const genesis = this.strategy.blockSerde.deserialize(serialized)
+    const genesis = this.strategy.blockSerde.deserialize(this.network.genesis)
 
     const result = await this.addBlock(genesis)
     Assert.isTrue(result.isAdded, `Could not seed genesis: ${result.reason || 'unknown'}`)
@@ -269,16 +390,39 @@ export class Blockchain {
     await this.db.open()
 
     if (options.upgrade) {
-      await this.db.upgrade(DATABASE_VERSION)
+      await this.db.upgrade(CHAIN_DATABASE_VERSION)
       await this.notes.upgrade()
       await this.nullifiers.upgrade()
     }
 
+    if (options.load && this.enableChainFilters) {
+      const start = BenchUtils.start()
+      await this.nullifiers.loadFilter()
+      await this.loadTransactionFilter()
+
+      this.logger.debug(
+        `Loaded ${String(this.nullifiers.filter?.count)} nullifiers and` +
+          ` ${String(this.transactionFilter?.count)} transactions into the filters` +
+          ` in ${BenchUtils.end(start).toFixed(0)}ms`,
+      )
+    }
+
     let genesisHeader = await this.getHeaderAtSequence(GENESIS_BLOCK_SEQUENCE)
     if (!genesisHeader && this.autoSeed) {
       genesisHeader = await this.seed()
     }
 
+    if (genesisHeader && this.autoSeed) {
+      const expected = this.strategy.blockSerde.deserialize(this.network.genesis)
+
+      if (!genesisHeader.hash.equals(expected.header.hash)) {
+        throw new Error(
+          `The chain database was created for a different network than ${this.network.id}.` +
+            ` Use a different data dir or database for each network.`,
+        )
+      }
+    }
+
     if (genesisHeader) {
       this.genesis = genesisHeader
       this.head = this.genesis
@@ -322,6 +466,59 @@ export class Blockchain {
     await this.db.close()
   }
 
+  /**
+   * Checks that the trees and indexes written with each block agree with the
+   * head, and rolls back anything written past it. Blocks are written in one
+   * atomic transaction, so this only finds something if a write was cut off
+   * outside of one. Returns a description of each fix that was made.
+   */
+  async recover(): Promise<string[]> {
+    const fixes: string[] = []
+
+    await this.db.transaction(async (tx) => {
+      const head = this.head
+
+      const trees = [
+        { name: 'notes', tree: this.notes, size: head.noteCommitment.size },
+        { name: 'nullifiers', tree: this.nullifiers, size: head.nullifierCommitment.size },
+      ]
+
+      for (const { name, tree, size } of trees) {
+        const treeSize = await tree.size(tx)
+
+        if (treeSize > size) {
+          await tree.truncate(size, tx)
+          fixes.push(`Rolled back ${treeSize - size} ${name} written past the head`)
+        } else if (treeSize < size) {
+          const missing = size - treeSize
+          throw new Error(`The ${name} tree is missing ${missing} items, run chain:repair`)
+        }
+      }
+
+      const headHash = await this.sequenceToHash.get(head.sequence, tx)
+      if (!headHash || !headHash.equals(head.hash)) {
+        await this.sequenceToHash.put(head.sequence, head.hash, tx)
+        fixes.push(`Replayed the main chain index for the head at ${head.sequence}`)
+      }
+
+      for (let sequence = head.sequence + 1; ; sequence++) {
+        if (!(await this.sequenceToHash.get(sequence, tx))) {
+          break
+        }
+
+        await this.sequenceToHash.del(sequence, tx)
+        fixes.push(`Rolled back the main chain index at ${sequence} past the head`)
+      }
+
+      if (await this.hashToNextHash.get(head.hash, tx)) {
+        await this.hashToNextHash.del(head.hash, tx)
+        fixes.push(`Rolled back the next block link of the head`)
+      }
+    })
+
+    return fixes
+  }
+
   async addBlock(block: Block): Promise<{
     isAdded: boolean
     isFork: boolean | null
@@ -329,6 +526,9 @@ export class Blockchain {
     score: number | null
   }> {
     let connectResult = null
+    let commitSpan = null as Span | null
+    const previousHead = this._head
+
     try {
       connectResult = await this.db.transaction(async (tx) => {
         const hash = block.header.recomputeHash()
@@ -364,8 +564,15 @@ export class Blockchain {
 
         await this.resolveOrphans(block)
 
+        commitSpan = this.tracer.start('block.commit', {
+          sequence: block.header.sequence,
+          hash: HashUtils.renderHash(hash),
+        })
+
         return connectResult
       })
+
+      commitSpan?.end()
     } catch (e) {
       if (e instanceof VerifyError) {
         return { isAdded: false, isFork: null, reason: e.reason, score: e.score }
@@ -373,6 +580,8 @@ export class Blockchain {
       throw e
     }
 
+    this.emitHeadChange(previousHead)
+
     return { isAdded: true, isFork: connectResult.isFork, reason: null, score: null }
   }
 
@@ -566,7 +775,11 @@ export class Blockchain {
     const work = block.header.target.toDifficulty()
     block.header.work = (prev ? prev.work : BigInt(0)) + work
 
-    const isFork = !this.isEmpty && !isBlockHeavier(block.header, this.head)
+    let isFork = !this.isEmpty && !isBlockHeavier(block.header, this.head)
+
+    if (!isFork && prev && !block.header.previousBlockHash.equals(this.head.hash)) {
+      isFork = await this.isReorganizationRefused(block.header, prev, tx)
+    }
 
     if (isFork) {
       await this.addForkToChain(block, prev, tx)
@@ -592,6 +805,72 @@ export class Blockchain {
     return { isFork: isFork }
   }
 
+  /**
+   * The last sequence that can only change if the operator confirms a
+   * reorganization, or null if maxReorgDepth is not set
+   */
+  get finalizedSequence(): number | null {
+    if (this.maxReorgDepth <= 0 || this.isEmpty) {
+      return null
+    }
+
+    return Math.max(this.head.sequence - this.maxReorgDepth, GENESIS_BLOCK_SEQUENCE)
+  }
+
+  /**
+   * Whether connecting `header` on `prev` would disconnect more than
+   * maxReorgDepth blocks, in which case it's kept as a fork instead
+   */
+  private async isReorganizationRefused(
+    header: BlockHeader,
+    prev: BlockHeader,
+    tx: IDatabaseTransaction,
+  ): Promise<boolean> {
+    if (this.maxReorgDepth <= 0) {
+      return false
+    }
+
+    const { fork } = await this.findFork(this.head, prev, tx)
+    const depth = this.head.sequence - fork.sequence
+
+    if (depth <= this.maxReorgDepth) {
+      return false
+    }
+
+    this.logger.error(
+      `Refused to reorganize ${depth} blocks from ${HashUtils.renderHash(this.head.hash)} (${
+        this.head.sequence
+      }) to ${HashUtils.renderHash(header.hash)} (${header.sequence}), deeper than` +
+        ` maxReorgDepth ${this.maxReorgDepth}. Run chain:confirmreorg to reorganize.`,
+    )
+
+    this.refusedReorg = header
+    this.onReorganizeRefused.emit(this.head, header, fork)
+    return true
+  }
+
+  /**
+   * Reorganize to a fork that was refused for being deeper than maxReorgDepth
+   * @returns false if the block is unknown or not heavier than the head
+   */
+  async confirmReorganization(hash: BlockHash): Promise<boolean> {
+    const header = await this.getHeader(hash)
+    if (!header || !isBlockHeavier(header, this.head)) {
+      return false
+    }
+
+    const previousHead = this._head
+
+    await this.db.transaction(async (tx) => {
+      await this.reorganizeChain(header, tx)
+    })
+
+    this.refusedReorg = null
+    this.updateSynced()
+    this.emitHeadChange(previousHead)
+    return true
+  }
+
   private async disconnect(block: Block, tx: IDatabaseTransaction): Promise<void> {
     Assert.isTrue(
       block.header.hash.equals(this.head.hash),
@@ -642,7 +921,11 @@ export class Blockchain {
     prev: BlockHeader | null,
     tx: IDatabaseTransaction,
   ): Promise<void> {
-    const { valid, reason } = await this.verifier.verifyBlockAdd(block, prev)
+    const { valid, reason } = await this.tracer.trace(
+      'block.verify',
+      () => this.verifier.verifyBlockAdd(block, prev),
+      { sequence: block.header.sequence, hash: HashUtils.renderHash(block.header.hash) },
+    )
 
     if (!valid) {
       Assert.isNotUndefined(reason)
@@ -692,7 +975,11 @@ export class Blockchain {
       await this.reorganizeChain(prev, tx)
     }
 
-    const { valid, reason } = await this.verifier.verifyBlockAdd(block, prev)
+    const { valid, reason } = await this.tracer.trace(
+      'block.verify',
+      () => this.verifier.verifyBlockAdd(block, prev),
+      { sequence: block.header.sequence, hash: HashUtils.renderHash(block.header.hash) },
+    )
     if (!valid) {
       Assert.isNotUndefined(reason)
 
@@ -772,6 +1059,8 @@ export class Blockchain {
         ` new: ${HashUtils.renderHash(newHead.hash)} (${newHead.sequence}),` +
         ` fork: ${HashUtils.renderHash(fork.hash)} (${fork.sequence})`,
     )
+
+    this.onReorganize.emit(oldHead, newHead, fork)
   }
 
   private addOrphan(_block: Block): void {
@@ -792,9 +1081,19 @@ export class Blockchain {
     const blockHeader = hashOrHeader instanceof BlockHeader ? hashOrHeader : null
     const blockHash = hashOrHeader instanceof BlockHeader ? hashOrHeader.hash : hashOrHeader
 
+    const cacheable = !tx || !this.cacheWrites.has(tx)
+    if (cacheable) {
+      const cached = this.blockCache.get(blockHash)
+      if (cached) {
+        return cached
+      }
+    }
+
+    const generation = this.cacheGeneration
+
     return this.db.withTransaction(tx, async (tx) => {
       const [header, transactions] = await Promise.all([
-        blockHeader || this.headers.get(blockHash, tx).then((result) => result?.header),
+        blockHeader || this.getHeader(blockHash, tx),
         this.transactions.get(blockHash, tx),
       ])
 
@@ -808,7 +1107,13 @@ export class Blockchain {
         )
       }
 
-      return new Block(header, transactions.transactions)
+      const block = new Block(header, transactions.transactions)
+
+      if (cacheable && generation === this.cacheGeneration) {
+        this.blockCache.set(blockHash, block)
+      }
+
+      return block
     })
   }
 
@@ -893,7 +1198,12 @@ export class Blockchain {
         if (!previousHeader && previousSequence !== 1) {
           throw new Error('There is no previous block to calculate a target')
         }
-        target = Target.calculateTarget(timestamp, heaviestHead.timestamp, heaviestHead.target)
+        target = Target.calculateTarget(
+          timestamp,
+          heaviestHead.timestamp,
+          heaviestHead.target,
+          this.consensus,
+        )
       }
 
       for (const transaction of transactions) {
@@ -999,7 +1309,22 @@ export class Blockchain {
   }
 
   async getHeader(hash: BlockHash, tx?: IDatabaseTransaction): Promise<BlockHeader | null> {
-    return (await this.headers.get(hash, tx))?.header || null
+    const cacheable = !tx || !this.cacheWrites.has(tx)
+    if (cacheable) {
+      const cached = this.headerCache.get(hash)
+      if (cached) {
+        return cached
+      }
+    }
+
+    const generation = this.cacheGeneration
+    const header = (await this.headers.get(hash, tx))?.header || null
+
+    if (header && cacheable && generation === this.cacheGeneration) {
+      this.headerCache.set(hash, header)
+    }
+
+    return header
   }
 
   async getPrevious(
@@ -1014,6 +1339,51 @@ export class Blockchain {
     return next || null
   }
 
+  /**
+   * Gets the hash of the block on the head chain that has the transaction
+   */
+  async getBlockHashByTransactionHash(
+    hash: TransactionHash,
+    tx?: IDatabaseTransaction,
+  ): Promise<BlockHash | null> {
+    if (this.transactionFilter && !this.transactionFilter.has(hash)) {
+      return null
+    }
+
+    return (await this.transactionHashToBlockHash.get(hash, tx)) ?? null
+  }
+
+  private async addTransactionHash(
+    hash: TransactionHash,
+    blockHash: BlockHash,
+    tx: IDatabaseTransaction,
+  ): Promise<void> {
+    await this.transactionHashToBlockHash.put(hash, blockHash, tx)
+
+    if (this.transactionFilter) {
+      if (!this.transactionFilter.has(hash)) {
+        this.transactionFilter.add(hash)
+      }
+
+      // Built again once it fills up, like the filters of the trees
+      if (this.transactionFilter.count >= this.transactionFilter.initialCapacity) {
+        await this.loadTransactionFilter(tx)
+      }
+    }
+  }
+
+  private async loadTransactionFilter(tx?: IDatabaseTransaction): Promise<void> {
+    // Counted first so the hashes don't all have to be held in memory
+    const count = await AsyncUtils.count(this.transactionHashToBlockHash.getAllKeysIter(tx))
+
+    const filter = new BloomFilter({ capacity: Math.max(count * 2, 1024) })
+    for await (const hash of this.transactionHashToBlockHash.getAllKeysIter(tx)) {
+      filter.add(hash)
+    }
+
+    this.transactionFilter = filter
+  }
+
   /**
    * Gets the hash of the block at the sequence on the head chain
    */
@@ -1076,8 +1446,15 @@ export class Blockchain {
     return this.getHeader(hash, tx)
   }
 
+  private emitHeadChange(previous: BlockHeader | null): void {
+    if (this._head && (!previous || !this._head.hash.equals(previous.hash))) {
+      this.onHeadChange.emit(this._head, previous)
+    }
+  }
+
   async removeBlock(hash: Buffer): Promise<void> {
     this.logger.info(`Deleting block ${hash.toString('hex')}`)
+    const previousHead = this._head
 
     await this.db.transaction(async (tx) => {
       if (!(await this.hasBlock(hash, tx))) {
@@ -1110,6 +1487,7 @@ export class Blockchain {
 
       await this.transactions.del(hash, tx)
       await this.headers.del(hash, tx)
+      this.uncacheBlock(hash, tx)
 
       // TODO: use a new heads table to recalculate this
       if (this.latest.hash.equals(hash)) {
@@ -1117,6 +1495,21 @@ export class Blockchain {
         await this.meta.put('latest', this.head.hash, tx)
       }
     })
+
+    // Reads that don't use a transaction can run until the removal is committed
+    this.uncacheBlock(hash)
+
+    this.emitHeadChange(previousHead)
+  }
+
+  private uncacheBlock(hash: BlockHash, tx?: IDatabaseTransaction): void {
+    if (tx) {
+      this.cacheWrites.add(tx)
+    }
+
+    this.cacheGeneration++
+    this.headerCache.remove(hash)
+    this.blockCache.remove(hash)
   }
 
   /**
@@ -1206,17 +1599,25 @@ export class Blockchain {
     prev: BlockHeader | null,
     tx: IDatabaseTransaction,
   ): Promise<void> {
-    // TODO: transaction goes here
     if (prev) {
       await this.hashToNextHash.put(prev.hash, block.header.hash, tx)
     }
 
+    for (const transaction of block.transactions) {
+      await this.addTransactionHash(transaction.hash(), block.header.hash, tx)
+    }
+
     await this.sequenceToHash.put(block.header.sequence, block.header.hash, tx)
     await this.meta.put('head', block.header.hash, tx)
 
     let notesIndex = prev?.noteCommitment.size || 0
     let nullifierIndex = prev?.nullifierCommitment.size || 0
 
+    const treeSpan = this.tracer.start('block.treeInsert', {
+      sequence: block.header.sequence,
+      hash: HashUtils.renderHash(block.header.hash),
+    })
+
     for (const note of block.allNotes()) {
       await this.addNote(notesIndex, note, tx)
       notesIndex++
@@ -1227,7 +1628,13 @@ export class Blockchain {
       nullifierIndex++
     }
 
-    const verify = await this.verifier.verifyConnectedBlock(block, tx)
+    treeSpan.end()
+
+    const verify = await this.tracer.trace(
+      'block.verifyConnected',
+      () => this.verifier.verifyConnectedBlock(block, tx),
+      { sequence: block.header.sequence, hash: HashUtils.renderHash(block.header.hash) },
+    )
 
     if (!verify.valid) {
       Assert.isNotUndefined(verify.reason)
@@ -1241,7 +1648,12 @@ export class Blockchain {
     prev: BlockHeader,
     tx: IDatabaseTransaction,
   ): Promise<void> {
-    // TODO: transaction goes here
+    // The hashes stay in the transaction filter, which only costs a database
+    // read when they are looked up
+    for (const transaction of block.transactions) {
+      await this.transactionHashToBlockHash.del(transaction.hash(), tx)
+    }
+
     await this.hashToNextHash.del(prev.hash, tx)
     await this.sequenceToHash.del(block.header.sequence, tx)
 
@@ -1265,6 +1677,7 @@ export class Blockchain {
     const sequence = block.header.sequence
 
     // Update BlockHash -> BlockHeader
+    this.uncacheBlock(hash, tx)
     await this.headers.put(hash, { header: block.header }, tx)
 
     // Update BlockHash -> Transaction
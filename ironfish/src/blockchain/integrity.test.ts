@@ -0,0 +1,46 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import '../testUtilities/matchers'
+import { createNodeTest, useMinerBlockFixture } from '../testUtilities'
+import { checkChainIntegrity } from './integrity'
+
+describe('checkChainIntegrity', () => {
+  const nodeTest = createNodeTest()
+
+  it('passes for a consistent chain', async () => {
+    const { chain } = nodeTest
+
+    const block = await useMinerBlockFixture(chain, 2)
+    await expect(chain).toAddBlock(block)
+
+    const report = await checkChainIntegrity(chain, { depth: 10 })
+    expect(report).toMatchObject({ valid: true, problems: [], checked: 1 })
+  })
+
+  it('finds a broken main chain index', async () => {
+    const { chain } = nodeTest
+
+    const block = await useMinerBlockFixture(chain, 2)
+    await expect(chain).toAddBlock(block)
+    await chain.sequenceToHash.del(2)
+
+    const report = await checkChainIntegrity(chain, { depth: 10 })
+    expect(report.valid).toBe(false)
+    expect(report.problems).toEqual([
+      'The main chain index at 2 does not point to the head chain',
+    ])
+  })
+
+  it('finds trees that do not match the head', async () => {
+    const { chain } = nodeTest
+
+    const block = await useMinerBlockFixture(chain, 2)
+    await expect(chain).toAddBlock(block)
+    await chain.notes.truncate(chain.genesis.noteCommitment.size)
+
+    const report = await checkChainIntegrity(chain, { depth: 10 })
+    expect(report.valid).toBe(false)
+    expect(report.problems[0]).toContain('The notes tree has')
+  })
+})
@@ -0,0 +1,101 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { BlockHeader } from '../primitives/blockheader'
+import { Blockchain, CHAIN_DATABASE_VERSION } from './blockchain'
+
+export type IntegrityReport = {
+  valid: boolean
+  problems: string[]
+  // How many headers behind the head were checked
+  checked: number
+  durationMs: number
+}
+
+/**
+ * A quick check for the corruption that otherwise only shows up deep into a
+ * sync: the stored schema version, the last `depth` headers linking up to the
+ * head on the main chain, and the merkle tree roots matching the head.
+ */
+export async function checkChainIntegrity(
+  chain: Blockchain,
+  options: { depth: number },
+): Promise<IntegrityReport> {
+  const start = Date.now()
+  const problems: string[] = []
+  let checked = 0
+
+  const report = (): IntegrityReport => ({
+    valid: problems.length === 0,
+    problems,
+    checked,
+    durationMs: Date.now() - start,
+  })
+
+  const version = await chain.db.getVersion()
+  if (version !== CHAIN_DATABASE_VERSION) {
+    problems.push(
+      `The chain database version is ${String(version)}, expected ${CHAIN_DATABASE_VERSION}`,
+    )
+  }
+
+  if (chain.isEmpty) {
+    return report()
+  }
+
+  const head = chain.head
+  let header: BlockHeader = head
+
+  while (checked < options.depth && header.sequence > chain.genesis.sequence) {
+    const hashAtSequence = await chain.getHashAtSequence(header.sequence)
+    if (!hashAtSequence || !hashAtSequence.equals(header.hash)) {
+      problems.push(
+        `The main chain index at ${header.sequence} does not point to the head chain`,
+      )
+      break
+    }
+
+    const previous = await chain.getHeader(header.previousBlockHash)
+    if (!previous) {
+      problems.push(
+        `Block ${header.sequence} links to a missing header ` +
+          header.previousBlockHash.toString('hex'),
+      )
+      break
+    }
+
+    if (previous.sequence !== header.sequence - 1) {
+      problems.push(
+        `Block ${header.sequence} links to a header with sequence ${previous.sequence}`,
+      )
+      break
+    }
+
+    header = previous
+    checked++
+  }
+
+  const trees = [
+    { name: 'notes', tree: chain.notes, commitment: head.noteCommitment },
+    { name: 'nullifiers', tree: chain.nullifiers, commitment: head.nullifierCommitment },
+  ] as const
+
+  for (const { name, tree, commitment } of trees) {
+    const size = await tree.size()
+    if (size !== commitment.size) {
+      problems.push(`The ${name} tree has ${size} leaves, the head expects ${commitment.size}`)
+      continue
+    }
+
+    if (size === 0) {
+      continue
+    }
+
+    const root = await tree.pastRoot(size)
+    if (!root.equals(commitment.commitment)) {
+      problems.push(`The ${name} tree root does not match the head`)
+    }
+  }
+
+  return report()
+}
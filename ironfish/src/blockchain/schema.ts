@@ -3,6 +3,7 @@
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 
 import { BlockHash } from '../primitives/blockheader'
+import { TransactionHash } from '../primitives/transaction'
 import { DatabaseSchema } from '../storage'
 import { HeaderValue } from './database/headers'
 import { SequenceToHashesValue } from './database/sequenceToHashes'
@@ -39,3 +40,9 @@ export interface HashToNextSchema extends DatabaseSchema {
   key: BlockHash
   value: BlockHash
 }
+
+// Main Chain
+export interface TransactionHashToBlockHashSchema extends DatabaseSchema {
+  key: TransactionHash
+  value: BlockHash
+}
@@ -0,0 +1,54 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+import { BloomFilter } from './bloomFilter'
+
+describe('BloomFilter', () => {
+  const value = (i: number) => {
+    const buffer = Buffer.alloc(32)
+    buffer.writeUInt32BE(i)
+    return buffer
+  }
+
+  it('has every value that was added', () => {
+    const filter = new BloomFilter({ capacity: 16 })
+
+    for (let i = 0; i < 1000; i++) {
+      filter.add(value(i))
+    }
+
+    for (let i = 0; i < 1000; i++) {
+      expect(filter.has(value(i))).toBe(true)
+    }
+
+    expect(filter.count).toBe(1000)
+  })
+
+  it('keeps false positives near the error rate as it grows', () => {
+    const filter = new BloomFilter({ capacity: 100, errorRate: 0.01 })
+
+    for (let i = 0; i < 5000; i++) {
+      filter.add(value(i))
+    }
+
+    let falsePositives = 0
+    for (let i = 5000; i < 15000; i++) {
+      if (filter.has(value(i))) {
+        falsePositives++
+      }
+    }
+
+    expect(falsePositives / 10000).toBeLessThan(0.02)
+  })
+
+  it('clears', () => {
+    const filter = new BloomFilter()
+    filter.add(value(1))
+
+    filter.clear()
+
+    expect(filter.has(value(1))).toBe(false)
+    expect(filter.count).toBe(0)
+  })
+})
@@ -0,0 +1,125 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+const FNV_PRIME = 0x01000193
+const FNV_OFFSET = 0x811c9dc5
+
+// Each partition is twice the size of the last, and its error rate is scaled by
+// this so the rate of all of the partitions together stays under the target
+const ERROR_TIGHTENING = 0.5
+
+function fnv1a(value: Buffer, seed: number): number {
+  let hash = (FNV_OFFSET ^ seed) >>> 0
+
+  for (let i = 0; i < value.length; i++) {
+    hash ^= value[i]
+    hash = Math.imul(hash, FNV_PRIME) >>> 0
+  }
+
+  return hash
+}
+
+class BloomFilterPartition {
+  readonly capacity: number
+  readonly bits: Buffer
+  readonly bitCount: number
+  readonly hashCount: number
+  count = 0
+
+  constructor(capacity: number, errorRate: number) {
+    this.capacity = capacity
+    this.bitCount = Math.ceil((-capacity * Math.log(errorRate)) / Math.LN2 ** 2)
+    this.hashCount = Math.max(1, Math.round((this.bitCount / capacity) * Math.LN2))
+    this.bits = Buffer.alloc(Math.ceil(this.bitCount / 8))
+  }
+
+  get full(): boolean {
+    return this.count >= this.capacity
+  }
+
+  add(h1: number, h2: number): void {
+    for (let i = 0; i < this.hashCount; i++) {
+      const bit = (h1 + i * h2) % this.bitCount
+      this.bits[bit >>> 3] |= 1 << (bit & 7)
+    }
+
+    this.count++
+  }
+
+  has(h1: number, h2: number): boolean {
+    for (let i = 0; i < this.hashCount; i++) {
+      const bit = (h1 + i * h2) % this.bitCount
+      if (!(this.bits[bit >>> 3] & (1 << (bit & 7)))) {
+        return false
+      }
+    }
+
+    return true
+  }
+}
+
+/**
+ * A set that can only answer "maybe" or "definitely not", in a fraction of the
+ * memory of the values themselves. It grows by adding larger partitions as it
+ * fills up, so it doesn't need to know how many values it will hold. Values
+ * can't be removed, so removed values keep answering "maybe".
+ *
+ * The filters in @ironfish/bfilter are sized up front, or forget old values
+ * like RollingFilter, neither of which works for a set that only grows.
+ */
+export class BloomFilter {
+  readonly errorRate: number
+  // How many values it holds before it grows
+  readonly initialCapacity: number
+  private partitions: BloomFilterPartition[] = []
+
+  constructor(options: { capacity?: number; errorRate?: number } = {}) {
+    this.initialCapacity = Math.max(1, options.capacity ?? 1024)
+    this.errorRate = options.errorRate ?? 0.01
+    this.clear()
+  }
+
+  /**
+   * How many values were added, including ones added more than once
+   */
+  get count(): number {
+    return this.partitions.reduce((count, p) => count + p.count, 0)
+  }
+
+  get sizeBytes(): number {
+    return this.partitions.reduce((size, p) => size + p.bits.byteLength, 0)
+  }
+
+  add(value: Buffer): void {
+    let partition = this.partitions[this.partitions.length - 1]
+
+    if (partition.full) {
+      const errorRate =
+        this.errorRate * (1 - ERROR_TIGHTENING) * ERROR_TIGHTENING ** this.partitions.length
+      partition = new BloomFilterPartition(partition.capacity * 2, errorRate)
+      this.partitions.push(partition)
+    }
+
+    const [h1, h2] = this.hash(value)
+    partition.add(h1, h2)
+  }
+
+  /**
+   * @returns false if the value was never added, true if it probably was
+   */
+  has(value: Buffer): boolean {
+    const [h1, h2] = this.hash(value)
+    return this.partitions.some((p) => p.has(h1, h2))
+  }
+
+  clear(): void {
+    const errorRate = this.errorRate * (1 - ERROR_TIGHTENING)
+    this.partitions = [new BloomFilterPartition(this.initialCapacity, errorRate)]
+  }
+
+  private hash(value: Buffer): [number, number] {
+    // Double hashing, the second hash is made odd so it's never 0
+    return [fnv1a(value, 0), (fnv1a(value, 0x5bd1e995) | 1) >>> 0]
+  }
+}
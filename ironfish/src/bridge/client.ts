@@ -0,0 +1,127 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import WSWebSocket from 'ws'
+import {
+  BridgeApp,
+  BridgeError,
+  BridgeMethods,
+  BridgePairing,
+  BridgeResponse,
+  BridgeSendTransactionParams,
+  isBridgeEnvelope,
+  openMessage,
+  parsePairingUri,
+  sealMessage,
+} from './protocol'
+
+/**
+ * What an app uses to talk to the signing bridge after scanning its pairing
+ * URI. Keep the URI to reconnect to the same session later.
+ */
+export class BridgeClient {
+  readonly pairing: BridgePairing
+  readonly socket: WSWebSocket
+
+  private nextId = 0
+  private readonly requests = new Map<
+    number,
+    { resolve: (result: unknown) => void; reject: (error: Error) => void }
+  >()
+
+  private constructor(pairing: BridgePairing, socket: WSWebSocket) {
+    this.pairing = pairing
+    this.socket = socket
+
+    socket.on('message', (data) => this.onMessage(data.toString()))
+    socket.on('close', (_code, reason) => {
+      const error = new Error(`The bridge closed the connection: ${reason.toString()}`)
+      for (const request of this.requests.values()) {
+        request.reject(error)
+      }
+      this.requests.clear()
+    })
+  }
+
+  static connect(uri: string): Promise<BridgeClient> {
+    const pairing = parsePairingUri(uri)
+    const socket = new WSWebSocket(`ws://${pairing.host}:${pairing.port}`)
+
+    return new Promise((resolve, reject) => {
+      socket.once('open', () => resolve(new BridgeClient(pairing, socket)))
+      socket.once('error', reject)
+    })
+  }
+
+  /**
+   * Wait for the user to approve this app
+   */
+  pair(app: BridgeApp): Promise<{ account: string; network: string }> {
+    return this.request(BridgeMethods.Pair, app) as Promise<{
+      account: string
+      network: string
+    }>
+  }
+
+  getAccount(): Promise<{ account: string; publicAddress: string }> {
+    return this.request(BridgeMethods.GetAccount) as Promise<{
+      account: string
+      publicAddress: string
+    }>
+  }
+
+  getBalance(): Promise<{ confirmed: string; unconfirmed: string }> {
+    return this.request(BridgeMethods.GetBalance) as Promise<{
+      confirmed: string
+      unconfirmed: string
+    }>
+  }
+
+  /**
+   * Ask the user to approve and send a transaction
+   */
+  sendTransaction(params: BridgeSendTransactionParams): Promise<{ hash: string }> {
+    return this.request(BridgeMethods.SendTransaction, params) as Promise<{ hash: string }>
+  }
+
+  request(method: string, params?: unknown): Promise<unknown> {
+    const id = this.nextId++
+    const { session, key } = this.pairing
+
+    return new Promise((resolve, reject) => {
+      this.requests.set(id, { resolve, reject })
+      this.socket.send(JSON.stringify(sealMessage(session, key, { id, method, params })))
+    })
+  }
+
+  close(): void {
+    this.socket.close()
+  }
+
+  private onMessage(data: string): void {
+    let envelope: unknown
+    try {
+      envelope = JSON.parse(data)
+    } catch {
+      return
+    }
+
+    if (!isBridgeEnvelope(envelope)) {
+      return
+    }
+
+    const response = openMessage<BridgeResponse>(envelope, this.pairing.key)
+    const request = response ? this.requests.get(response.id) : undefined
+    if (!response || !request) {
+      return
+    }
+
+    this.requests.delete(response.id)
+
+    if (response.error) {
+      request.reject(new BridgeError(response.error.code, response.error.message))
+    } else {
+      request.resolve(response.result)
+    }
+  }
+}
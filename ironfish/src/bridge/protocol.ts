@@ -0,0 +1,171 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import tweetnacl from 'tweetnacl'
+
+/**
+ * The signing bridge lets other applications ask a wallet to send
+ * transactions without ever seeing its keys. A pairing URI, usually shown as
+ * a QR code, holds the address of the bridge, a session id, and a secret key
+ * the app and the bridge encrypt every message with.
+ *
+ *   ironfish-bridge://<host>:<port>/<session>?key=<hex>&network=<id>
+ *
+ * The app connects over a WebSocket and sends `bridge_pair` first, which the
+ * user approves in the CLI. Paired apps can then make requests, and every
+ * transaction has to be approved again.
+ */
+export const BRIDGE_PROTOCOL = 'ironfish-bridge:'
+export const BRIDGE_VERSION = 1
+
+export const BridgeMethods = {
+  Pair: 'bridge_pair',
+  GetAccount: 'ironfish_getAccount',
+  GetBalance: 'ironfish_getBalance',
+  SendTransaction: 'ironfish_sendTransaction',
+} as const
+
+export enum BridgeErrorCode {
+  Error = 'error',
+  Unpaired = 'unpaired',
+  Rejected = 'rejected',
+  UnknownMethod = 'unknown-method',
+  InvalidRequest = 'invalid-request',
+}
+
+export class BridgeError extends Error {
+  readonly code: BridgeErrorCode
+
+  constructor(code: BridgeErrorCode, message: string) {
+    super(message)
+    this.code = code
+  }
+}
+
+/**
+ * What an app tells the user about itself when it pairs
+ */
+export type BridgeApp = {
+  name: string
+  url?: string
+  description?: string
+}
+
+export type BridgeSession = {
+  id: string
+  key: string
+  account: string
+  app: BridgeApp | null
+  createdAt: number
+  lastUsedAt: number | null
+}
+
+export type BridgeSendTransactionParams = {
+  receives: { publicAddress: string; amount: string; memo: string }[]
+  fee: string
+  expirationSequence?: number | null
+}
+
+export type BridgeRequest = {
+  id: number
+  method: string
+  params?: unknown
+}
+
+export type BridgeResponse = {
+  id: number
+  result?: unknown
+  error?: { code: BridgeErrorCode; message: string }
+}
+
+/**
+ * The only thing sent in plain text, so the bridge can find the key
+ */
+export type BridgeEnvelope = {
+  session: string
+  nonce: string
+  box: string
+}
+
+export type BridgePairing = {
+  host: string
+  port: number
+  session: string
+  key: string
+  network: string
+}
+
+export function createBridgeKey(): string {
+  return Buffer.from(tweetnacl.randomBytes(tweetnacl.secretbox.keyLength)).toString('hex')
+}
+
+export function renderPairingUri(pairing: BridgePairing): string {
+  const uri = new URL(`${BRIDGE_PROTOCOL}//${pairing.host}:${pairing.port}/${pairing.session}`)
+  uri.searchParams.set('key', pairing.key)
+  uri.searchParams.set('network', pairing.network)
+  uri.searchParams.set('v', String(BRIDGE_VERSION))
+  return uri.toString()
+}
+
+export function parsePairingUri(uri: string): BridgePairing {
+  const parsed = new URL(uri)
+  const session = parsed.pathname.replace(/^\//, '')
+  const key = parsed.searchParams.get('key')
+
+  if (parsed.protocol !== BRIDGE_PROTOCOL || !session || !key || !parsed.port) {
+    throw new Error(`${uri} is not a signing bridge pairing URI`)
+  }
+
+  return {
+    host: parsed.hostname,
+    port: Number(parsed.port),
+    session,
+    key,
+    network: parsed.searchParams.get('network') ?? '',
+  }
+}
+
+export function sealMessage(session: string, key: string, message: unknown): BridgeEnvelope {
+  const nonce = tweetnacl.randomBytes(tweetnacl.secretbox.nonceLength)
+  const box = tweetnacl.secretbox(
+    Buffer.from(JSON.stringify(message), 'utf8'),
+    nonce,
+    Buffer.from(key, 'hex'),
+  )
+
+  return {
+    session,
+    nonce: Buffer.from(nonce).toString('base64'),
+    box: Buffer.from(box).toString('base64'),
+  }
+}
+
+/**
+ * @returns the message, or null if it was not encrypted with `key`
+ */
+export function openMessage<T>(envelope: BridgeEnvelope, key: string): T | null {
+  const opened = tweetnacl.secretbox.open(
+    Buffer.from(envelope.box, 'base64'),
+    Buffer.from(envelope.nonce, 'base64'),
+    Buffer.from(key, 'hex'),
+  )
+
+  if (!opened) {
+    return null
+  }
+
+  try {
+    return JSON.parse(Buffer.from(opened).toString('utf8')) as T
+  } catch {
+    return null
+  }
+}
+
+export function isBridgeEnvelope(value: unknown): value is BridgeEnvelope {
+  const envelope = value as Partial<BridgeEnvelope> | null
+  return (
+    typeof envelope?.session === 'string' &&
+    typeof envelope?.nonce === 'string' &&
+    typeof envelope?.box === 'string'
+  )
+}
@@ -0,0 +1,98 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import os from 'os'
+import path from 'path'
+import { v4 as uuid } from 'uuid'
+import { NodeFileProvider } from '../fileSystems'
+import { BridgeClient } from './client'
+import { BridgeErrorCode, BridgeMethods, parsePairingUri, renderPairingUri } from './protocol'
+import { BridgeRequestHandler, BridgeServer } from './server'
+import { BridgeSessionStore } from './sessions'
+
+describe('BridgeServer', () => {
+  const files = new NodeFileProvider()
+  let server: BridgeServer | null = null
+
+  beforeAll(async () => {
+    await files.init()
+  })
+
+  afterEach(async () => {
+    await server?.stop()
+    server = null
+  })
+
+  async function createServer(options: {
+    approve: boolean
+    onRequest?: BridgeRequestHandler
+  }): Promise<BridgeServer> {
+    server = new BridgeServer({
+      host: 'localhost',
+      port: 0,
+      network: 'testnet',
+      sessions: new BridgeSessionStore(files, path.join(os.tmpdir(), uuid())),
+      onPair: () => Promise.resolve(options.approve),
+      onRequest: options.onRequest ?? (() => Promise.resolve(null)),
+    })
+
+    await server.start()
+    return server
+  }
+
+  it('renders and parses pairing URIs', () => {
+    const pairing = { host: 'localhost', port: 8021, session: 'a', key: 'ff', network: 'x' }
+    expect(parsePairingUri(renderPairingUri(pairing))).toEqual(pairing)
+    expect(() => parsePairingUri('https://localhost/a')).toThrow('not a signing bridge')
+  })
+
+  it('pairs apps and sends them responses', async () => {
+    const onRequest = jest.fn().mockResolvedValue({ hash: 'abc' })
+    const bridge = await createServer({ approve: true, onRequest })
+    const { session, uri } = bridge.createPairing('default')
+
+    const client = await BridgeClient.connect(uri)
+
+    await expect(client.getAccount()).rejects.toMatchObject({ code: BridgeErrorCode.Unpaired })
+
+    await expect(client.pair({ name: 'Shop' })).resolves.toEqual({
+      account: 'default',
+      network: 'testnet',
+    })
+    expect(bridge.sessions.find(session.id)?.app).toMatchObject({ name: 'Shop' })
+
+    const params = { receives: [{ publicAddress: 'a', amount: '1', memo: '' }], fee: '1' }
+    await expect(client.sendTransaction(params)).resolves.toEqual({ hash: 'abc' })
+    expect(onRequest).toHaveBeenCalledWith(
+      expect.objectContaining({ id: session.id }),
+      BridgeMethods.SendTransaction,
+      params,
+    )
+
+    client.close()
+  })
+
+  it('rejects pairings the user does not approve', async () => {
+    const bridge = await createServer({ approve: false })
+    const { session, uri } = bridge.createPairing('default')
+
+    const client = await BridgeClient.connect(uri)
+    await expect(client.pair({ name: 'Shop' })).rejects.toMatchObject({
+      code: BridgeErrorCode.Rejected,
+    })
+    expect(bridge.sessions.find(session.id)).toBeNull()
+
+    client.close()
+  })
+
+  it('closes connections without the session key', async () => {
+    const bridge = await createServer({ approve: true })
+    const { uri } = bridge.createPairing('default')
+
+    const pairing = parsePairingUri(uri)
+    const wrongKey = renderPairingUri({ ...pairing, key: '00'.repeat(32) })
+
+    const client = await BridgeClient.connect(wrongKey)
+    await expect(client.pair({ name: 'Shop' })).rejects.toThrow('closed the connection')
+  })
+})
@@ -0,0 +1,254 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { AddressInfo } from 'net'
+import { v4 as uuid } from 'uuid'
+import WSWebSocket from 'ws'
+import { createRootLogger, Logger } from '../logger'
+import { ErrorUtils } from '../utils'
+import {
+  BridgeApp,
+  BridgeError,
+  BridgeErrorCode,
+  BridgeMethods,
+  BridgeRequest,
+  BridgeResponse,
+  BridgeSession,
+  createBridgeKey,
+  isBridgeEnvelope,
+  openMessage,
+  renderPairingUri,
+  sealMessage,
+} from './protocol'
+import { BridgeSessionStore } from './sessions'
+
+const MAX_MESSAGE_BYTES = 256 * 1024
+
+/**
+ * Asks the user to approve pairing an app with an account
+ */
+export type BridgePairHandler = (session: BridgeSession, app: BridgeApp) => Promise<boolean>
+
+/**
+ * Handles a request from a paired app. Throw a BridgeError to send the app an
+ * error, like BridgeErrorCode.Rejected when the user does not approve it.
+ */
+export type BridgeRequestHandler = (
+  session: BridgeSession,
+  method: string,
+  params: unknown,
+) => Promise<unknown>
+
+/**
+ * The WebSocket server apps connect to. It only decrypts messages and keeps
+ * track of sessions, everything else is up to `onPair` and `onRequest`, so
+ * the keys stay wherever the handlers send the transactions to be made.
+ */
+export class BridgeServer {
+  readonly host: string
+  readonly sessions: BridgeSessionStore
+  readonly network: string
+  readonly logger: Logger
+  readonly onPair: BridgePairHandler
+  readonly onRequest: BridgeRequestHandler
+
+  /**
+   * Sessions shown to the user that no app has paired with yet
+   */
+  readonly pending = new Map<string, BridgeSession>()
+
+  server: WSWebSocket.Server | null = null
+  private requestedPort: number
+
+  constructor(options: {
+    host: string
+    port: number
+    network: string
+    sessions: BridgeSessionStore
+    onPair: BridgePairHandler
+    onRequest: BridgeRequestHandler
+    logger?: Logger
+  }) {
+    this.host = options.host
+    this.requestedPort = options.port
+    this.network = options.network
+    this.sessions = options.sessions
+    this.onPair = options.onPair
+    this.onRequest = options.onRequest
+    this.logger = (options.logger ?? createRootLogger()).withTag('bridge')
+  }
+
+  /**
+   * The port the server is listening on, which is only known after starting
+   * when it was created with port 0
+   */
+  get port(): number {
+    const address = this.server?.address() as AddressInfo | undefined
+    return address?.port ?? this.requestedPort
+  }
+
+  async start(): Promise<void> {
+    if (this.server) {
+      return
+    }
+
+    const server = new WSWebSocket.Server({
+      host: this.host,
+      port: this.requestedPort,
+      maxPayload: MAX_MESSAGE_BYTES,
+    })
+    this.server = server
+
+    await new Promise<void>((resolve, reject) => {
+      server.once('listening', resolve)
+      server.once('error', reject)
+    })
+
+    server.on('connection', (socket) => this.onConnection(socket))
+  }
+
+  async stop(): Promise<void> {
+    const server = this.server
+    if (!server) {
+      return
+    }
+
+    this.server = null
+    for (const client of server.clients) {
+      client.terminate()
+    }
+    await new Promise<void>((resolve) => server.close(() => resolve()))
+  }
+
+  /**
+   * Start a session for `account` that the first app to connect with the
+   * returned URI can pair with
+   */
+  createPairing(account: string): { session: BridgeSession; uri: string } {
+    const session: BridgeSession = {
+      id: uuid(),
+      key: createBridgeKey(),
+      account,
+      app: null,
+      createdAt: Date.now(),
+      lastUsedAt: null,
+    }
+
+    this.pending.set(session.id, session)
+
+    const uri = renderPairingUri({
+      host: this.host,
+      port: this.port,
+      session: session.id,
+      key: session.key,
+      network: this.network,
+    })
+
+    return { session, uri }
+  }
+
+  private onConnection(socket: WSWebSocket): void {
+    // Requests from one connection are handled one at a time, in order
+    let queue = Promise.resolve()
+
+    socket.on('message', (data) => {
+      queue = queue.then(() => this.onMessage(socket, data.toString()))
+    })
+  }
+
+  private async onMessage(socket: WSWebSocket, data: string): Promise<void> {
+    let envelope: unknown
+    try {
+      envelope = JSON.parse(data)
+    } catch {
+      envelope = null
+    }
+
+    if (!isBridgeEnvelope(envelope)) {
+      socket.close(1008, 'invalid message')
+      return
+    }
+
+    // Reload the sessions so ones revoked by another process can't be used
+    let session = this.pending.get(envelope.session) ?? null
+    if (!session) {
+      await this.sessions.reload()
+      session = this.sessions.find(envelope.session)
+    }
+
+    const request = session ? openMessage<BridgeRequest>(envelope, session.key) : null
+
+    // Someone without the key can't be told anything useful
+    if (!session || !request || typeof request.id !== 'number') {
+      socket.close(1008, 'unknown session')
+      return
+    }
+
+    const response = await this.handle(session, request)
+
+    if (socket.readyState === WSWebSocket.OPEN) {
+      socket.send(JSON.stringify(sealMessage(session.id, session.key, response)))
+    }
+  }
+
+  private async handle(
+    session: BridgeSession,
+    request: BridgeRequest,
+  ): Promise<BridgeResponse> {
+    try {
+      if (request.method === BridgeMethods.Pair) {
+        return { id: request.id, result: await this.pair(session, request.params) }
+      }
+
+      if (!session.app || this.pending.has(session.id)) {
+        throw new BridgeError(BridgeErrorCode.Unpaired, `Send ${BridgeMethods.Pair} first`)
+      }
+
+      await this.sessions.touch(session.id)
+
+      const result = await this.onRequest(session, request.method, request.params)
+      return { id: request.id, result }
+    } catch (e: unknown) {
+      if (e instanceof BridgeError) {
+        return { id: request.id, error: { code: e.code, message: e.message } }
+      }
+
+      this.logger.error(`Error handling ${request.method}: ${ErrorUtils.renderError(e, true)}`)
+      return {
+        id: request.id,
+        error: { code: BridgeErrorCode.Error, message: ErrorUtils.renderError(e) },
+      }
+    }
+  }
+
+  private async pair(
+    session: BridgeSession,
+    params: unknown,
+  ): Promise<{ account: string; network: string }> {
+    const app = params as Partial<BridgeApp> | undefined
+
+    if (typeof app?.name !== 'string' || !app.name) {
+      throw new BridgeError(BridgeErrorCode.InvalidRequest, 'Apps must send their name')
+    }
+
+    // Sessions that are already paired can pair again to check they still are
+    if (!this.pending.has(session.id)) {
+      return { account: session.account, network: this.network }
+    }
+
+    // Only the first app to connect gets to ask
+    this.pending.delete(session.id)
+
+    const paired: BridgeSession = {
+      ...session,
+      app: { name: app.name, url: app.url, description: app.description },
+    }
+
+    if (!(await this.onPair(paired, paired.app as BridgeApp))) {
+      throw new BridgeError(BridgeErrorCode.Rejected, 'The pairing was rejected')
+    }
+
+    await this.sessions.add(paired)
+    return { account: paired.account, network: this.network }
+  }
+}
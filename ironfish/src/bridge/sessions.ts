@@ -0,0 +1,63 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { KeyStore } from '../fileStores/keyStore'
+import { FileSystem } from '../fileSystems'
+import { BridgeSession } from './protocol'
+
+export type BridgeSessionsOptions = {
+  sessions: BridgeSession[]
+}
+
+export const BridgeSessionsDefaults: BridgeSessionsOptions = {
+  sessions: [],
+}
+
+export const BRIDGE_SESSIONS_FILE_NAME = 'bridgeSessions.json'
+
+/**
+ * The apps paired with the signing bridge, so they can reconnect without
+ * pairing again until the session is revoked
+ */
+export class BridgeSessionStore extends KeyStore<BridgeSessionsOptions> {
+  constructor(files: FileSystem, dataDir: string) {
+    super(files, BRIDGE_SESSIONS_FILE_NAME, BridgeSessionsDefaults, dataDir)
+  }
+
+  list(): BridgeSession[] {
+    return this.get('sessions')
+  }
+
+  find(id: string): BridgeSession | null {
+    return this.list().find((s) => s.id === id) ?? null
+  }
+
+  async add(session: BridgeSession): Promise<void> {
+    this.set('sessions', [...this.list().filter((s) => s.id !== session.id), session])
+    await this.save()
+  }
+
+  async touch(id: string): Promise<void> {
+    this.set(
+      'sessions',
+      this.list().map((s) => (s.id === id ? { ...s, lastUsedAt: Date.now() } : s)),
+    )
+    await this.save()
+  }
+
+  /**
+   * @returns true if the session existed
+   */
+  async revoke(id: string): Promise<boolean> {
+    const sessions = this.list()
+    const remaining = sessions.filter((s) => s.id !== id)
+
+    if (remaining.length === sessions.length) {
+      return false
+    }
+
+    this.set('sessions', remaining)
+    await this.save()
+    return true
+  }
+}
@@ -0,0 +1,72 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import dgram from 'dgram'
+import { AddressInfo } from 'net'
+import { ClockMonitor, measureNtpOffset } from './clockMonitor'
+
+describe('ClockMonitor', () => {
+  it('reports skew past the tolerance', () => {
+    const monitor = new ClockMonitor({ ntpServer: '', toleranceMs: 1000, maxCompensationMs: 0 })
+    const onMeasured = jest.fn()
+    monitor.onMeasured.on(onMeasured)
+
+    expect(monitor.skewed).toBe(false)
+
+    monitor.update(500)
+    expect(monitor.skewed).toBe(false)
+    expect(onMeasured).toHaveBeenLastCalledWith(500)
+
+    monitor.update(-1500)
+    expect(monitor.skewed).toBe(true)
+    expect(onMeasured).toHaveBeenLastCalledWith(-1500)
+  })
+
+  it('limits the compensation', () => {
+    const monitor = new ClockMonitor({
+      ntpServer: '',
+      toleranceMs: 1000,
+      maxCompensationMs: 15000,
+    })
+
+    expect(monitor.compensationMs).toBe(0)
+
+    monitor.update(5000)
+    expect(monitor.compensationMs).toBe(5000)
+
+    monitor.update(60000)
+    expect(monitor.compensationMs).toBe(15000)
+
+    monitor.update(-60000)
+    expect(monitor.compensationMs).toBe(-15000)
+  })
+
+  describe('measureNtpOffset', () => {
+    it('measures the offset from an NTP server', async () => {
+      const server = dgram.createSocket('udp4')
+      const offsetMs = 10000
+
+      server.on('message', (_, remote) => {
+        const ntpMs = Date.now() + offsetMs + 2208988800 * 1000
+        const seconds = Math.floor(ntpMs / 1000)
+        const fraction = Math.floor(((ntpMs % 1000) / 1000) * 0x100000000)
+
+        const response = Buffer.alloc(48)
+        response.writeUInt32BE(seconds, 32)
+        response.writeUInt32BE(fraction, 36)
+        response.writeUInt32BE(seconds, 40)
+        response.writeUInt32BE(fraction, 44)
+
+        server.send(response, remote.port, remote.address)
+      })
+
+      await new Promise<void>((resolve) => server.bind(0, '127.0.0.1', resolve))
+      const { port } = server.address() as AddressInfo
+
+      const measured = await measureNtpOffset('127.0.0.1', port)
+      server.close()
+
+      expect(Math.abs(measured - offsetMs)).toBeLessThan(100)
+    })
+  })
+})
@@ -0,0 +1,165 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import dgram from 'dgram'
+import { Event } from './event'
+import { createRootLogger, Logger } from './logger'
+import { ErrorUtils, SetIntervalToken } from './utils'
+
+const NTP_PORT = 123
+const NTP_TIMEOUT_MS = 5000
+
+// Seconds between the NTP epoch, 1900, and the unix epoch, 1970
+const NTP_EPOCH_OFFSET_SECONDS = 2208988800
+
+/**
+ * Read a 64 bit NTP timestamp as unix milliseconds
+ */
+function readNtpTimestamp(buffer: Buffer, offset: number): number {
+  const seconds = buffer.readUInt32BE(offset)
+  const fraction = buffer.readUInt32BE(offset + 4)
+  return (seconds - NTP_EPOCH_OFFSET_SECONDS) * 1000 + (fraction * 1000) / 0x100000000
+}
+
+/**
+ * Ask an NTP server for the time with a single SNTP request
+ * @returns how many milliseconds the server clock is ahead of the local clock
+ */
+export function measureNtpOffset(host: string, port = NTP_PORT): Promise<number> {
+  return new Promise((resolve, reject) => {
+    const socket = dgram.createSocket('udp4')
+
+    // Version 4, client mode
+    const request = Buffer.alloc(48)
+    request[0] = (4 << 3) | 3
+
+    const timeout = setTimeout(() => {
+      socket.close()
+      reject(new Error(`Timed out asking ${host} for the time after ${NTP_TIMEOUT_MS}ms`))
+    }, NTP_TIMEOUT_MS)
+
+    let sentAt = 0
+
+    socket.once('error', (error) => {
+      clearTimeout(timeout)
+      socket.close()
+      reject(error)
+    })
+
+    socket.once('message', (message) => {
+      const receivedAt = Date.now()
+      clearTimeout(timeout)
+      socket.close()
+
+      if (message.length < 48) {
+        reject(new Error(`Invalid NTP response from ${host}`))
+        return
+      }
+
+      const serverReceivedAt = readNtpTimestamp(message, 32)
+      const serverSentAt = readNtpTimestamp(message, 40)
+
+      resolve((serverReceivedAt - sentAt + (serverSentAt - receivedAt)) / 2)
+    })
+
+    sentAt = Date.now()
+    socket.send(request, port, host)
+  })
+}
+
+/**
+ * Periodically measures how far the local clock is from NTP time, because
+ * blocks from peers are rejected as being in the future when the local clock
+ * falls behind
+ */
+export class ClockMonitor {
+  readonly logger: Logger
+  readonly ntpServer: string
+
+  /**
+   * How many milliseconds the clock may be off before it's reported as skewed
+   */
+  readonly toleranceMs: number
+
+  /**
+   * The most milliseconds the offset can be compensated for. Past this the node
+   * only warns, since trusting one time server too far is its own risk.
+   */
+  readonly maxCompensationMs: number
+
+  readonly onMeasured = new Event<[offsetMs: number]>()
+
+  /**
+   * How many milliseconds NTP time is ahead of the local clock, null until
+   * it has been measured
+   */
+  offsetMs: number | null = null
+
+  private interval: SetIntervalToken | null = null
+  private readonly checkPeriodMs = 10 * 60 * 1000
+
+  constructor(options: {
+    ntpServer: string
+    toleranceMs: number
+    maxCompensationMs: number
+    logger?: Logger
+  }) {
+    this.logger = (options.logger ?? createRootLogger()).withTag('clock')
+    this.ntpServer = options.ntpServer
+    this.toleranceMs = options.toleranceMs
+    this.maxCompensationMs = options.maxCompensationMs
+  }
+
+  start(): void {
+    if (this.interval || !this.ntpServer) {
+      return
+    }
+
+    void this.check()
+    this.interval = setInterval(() => void this.check(), this.checkPeriodMs)
+  }
+
+  stop(): void {
+    if (this.interval) {
+      clearInterval(this.interval)
+      this.interval = null
+    }
+  }
+
+  get skewed(): boolean {
+    return this.offsetMs !== null && Math.abs(this.offsetMs) > this.toleranceMs
+  }
+
+  /**
+   * The offset to add to the local clock, limited to `maxCompensationMs`
+   */
+  get compensationMs(): number {
+    if (this.offsetMs === null) {
+      return 0
+    }
+
+    return Math.max(-this.maxCompensationMs, Math.min(this.maxCompensationMs, this.offsetMs))
+  }
+
+  async check(): Promise<void> {
+    try {
+      this.update(await measureNtpOffset(this.ntpServer))
+    } catch (e: unknown) {
+      this.logger.debug(`Could not measure clock skew: ${ErrorUtils.renderError(e)}`)
+    }
+  }
+
+  update(offsetMs: number): void {
+    this.offsetMs = Math.round(offsetMs)
+
+    if (this.skewed) {
+      const direction = this.offsetMs > 0 ? 'behind' : 'ahead of'
+      this.logger.warn(
+        `Your clock is ${Math.abs(this.offsetMs)}ms ${direction} ${this.ntpServer}. ` +
+          `Blocks may be rejected, enable time sync for your OS to fix it.`,
+      )
+    }
+
+    this.onMeasured.emit(this.offsetMs)
+  }
+}
@@ -0,0 +1,49 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { Cluster } from './cluster'
+
+describe('Cluster', () => {
+  let cluster: Cluster | null = null
+
+  afterEach(async () => {
+    await cluster?.shutdown()
+    cluster = null
+  })
+
+  it('propagates mined blocks across a line', async () => {
+    cluster = await Cluster.create({ size: 3, basePort: 19433 })
+    await cluster.start()
+
+    cluster.connectTopology('line')
+    await cluster.waitForConnections(1)
+
+    const [block] = await cluster.mine(cluster.getNode(0), 1)
+    await cluster.waitForSync()
+
+    for (const { node } of cluster.nodes) {
+      expect(node.chain.head.hash).toEqualHash(block.header.hash)
+    }
+  }, 60000)
+
+  it('forks while partitioned and converges after healing', async () => {
+    cluster = await Cluster.create({ size: 2, basePort: 19443 })
+    await cluster.start()
+
+    const [a, b] = cluster.nodes
+    cluster.connect(b, a)
+    await cluster.waitForConnections(1)
+
+    cluster.partition([[a], [b]])
+    await cluster.mine(a, 1)
+    const blocksB = await cluster.mine(b, 2)
+
+    expect(a.node.chain.head.sequence).toBe(2)
+    expect(b.node.chain.head.sequence).toBe(3)
+
+    cluster.heal()
+    await cluster.waitForSync()
+
+    expect(a.node.chain.head.hash).toEqualHash(blocksB[1].header.hash)
+  }, 60000)
+})
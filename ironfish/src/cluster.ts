@@ -0,0 +1,345 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import fsAsync from 'fs/promises'
+import os from 'os'
+import path from 'path'
+import { v4 as uuid } from 'uuid'
+import { Account } from './account'
+import { Assert } from './assert'
+import { ConfigOptions } from './fileStores/config'
+import { createRootLogger, Logger } from './logger'
+import { DevBlockProducer } from './mining/devBlockProducer'
+import { Identity } from './network/identity'
+import { DisconnectingReason } from './network/messages/disconnecting'
+import { IronfishNode } from './node'
+import { Block } from './primitives/block'
+import { IronfishSdk } from './sdk'
+import { TestStrategy } from './testUtilities/strategy'
+import { PromiseUtils } from './utils'
+
+const DEFAULT_BASE_PORT = 19333
+const DEFAULT_TIMEOUT_MS = 30 * 1000
+const POLL_INTERVAL_MS = 100
+
+export type ClusterTopology = 'line' | 'ring' | 'star' | 'full'
+
+export type ClusterNode = {
+  name: string
+  sdk: IronfishSdk
+  node: IronfishNode
+  port: number
+  address: string
+  account: Account
+  producer: DevBlockProducer
+}
+
+export type ClusterOptions = {
+  /**
+   * How many nodes to create
+   */
+  size: number
+  /**
+   * Keep each node's data dir in a folder here and leave it on disk after
+   * shutdown. By default every node uses a temporary data dir that is deleted
+   * when the cluster shuts down.
+   */
+  dataDir?: string
+  /**
+   * The first peer port, each node listens on the next port
+   */
+  basePort?: number
+  /**
+   * Config overrides applied to every node
+   */
+  config?: Partial<ConfigOptions>
+  logger?: Logger
+}
+
+/**
+ * Runs several nodes in one process so multi node behaviour like block
+ * propagation, syncing and forks can be tested without scripts. Nodes listen on
+ * localhost, use the test strategy and skip proof of work so blocks can be mined
+ * instantly with {@link Cluster.mine}.
+ *
+ * Connections made with {@link Cluster.connect} are the initial links, nodes can
+ * still learn about each other from peer lists unless they are partitioned.
+ */
+export class Cluster {
+  readonly nodes: ClusterNode[] = []
+  readonly logger: Logger
+
+  private readonly dataDir: string | null
+  private readonly basePort: number
+  private readonly config: Partial<ConfigOptions>
+  private readonly links = new Array<[ClusterNode, ClusterNode]>()
+  private readonly bans = new Array<[ClusterNode, Identity]>()
+  private readonly tempDirs: string[] = []
+
+  constructor(options: Omit<ClusterOptions, 'size'> = {}) {
+    this.logger = (options.logger ?? createRootLogger()).withTag('cluster')
+    this.dataDir = options.dataDir ?? null
+    this.basePort = options.basePort ?? DEFAULT_BASE_PORT
+    this.config = options.config ?? {}
+  }
+
+  static async create(options: ClusterOptions): Promise<Cluster> {
+    const cluster = new Cluster(options)
+
+    for (let i = 0; i < options.size; i++) {
+      await cluster.addNode()
+    }
+
+    return cluster
+  }
+
+  async addNode(config: Partial<ConfigOptions> = {}): Promise<ClusterNode> {
+    const index = this.nodes.length
+    const name = `node${index}`
+    const port = this.basePort + index
+
+    let dataDir
+    if (this.dataDir) {
+      dataDir = path.join(this.dataDir, name)
+    } else {
+      dataDir = path.join(os.tmpdir(), uuid())
+      this.tempDirs.push(dataDir)
+    }
+
+    const sdk = await IronfishSdk.init({
+      dataDir,
+      strategyClass: TestStrategy,
+      logger: this.logger.withTag(name),
+    })
+
+    sdk.config.setOverride('bootstrapNodes', [''])
+    sdk.config.setOverride('enableListenP2P', true)
+    sdk.config.setOverride('peerPort', port)
+    sdk.config.setOverride('enableRpc', false)
+    sdk.config.setOverride('enableTelemetry', false)
+    sdk.config.setOverride('minimumBlockConfirmations', 0)
+    sdk.config.setOverride('ntpServer', '')
+    sdk.config.setOverride('nodeName', name)
+
+    for (const overrides of [this.config, config]) {
+      for (const key in overrides) {
+        const configKey = key as keyof ConfigOptions
+        // eslint-disable-next-line @typescript-eslint/no-explicit-any
+        sdk.config.setOverride(configKey, overrides[configKey] as any)
+      }
+    }
+
+    const node = await sdk.node()
+    node.chain.verifier.enableVerifyTarget = false
+    await node.openDB()
+
+    const account =
+      node.accounts.getDefaultAccount() ?? (await node.accounts.createAccount(name, true))
+
+    const producer = new DevBlockProducer({ node, logger: node.logger })
+    node.devBlockProducer = producer
+
+    const clusterNode = {
+      name,
+      sdk,
+      node,
+      port,
+      address: `ws://127.0.0.1:${port}`,
+      account,
+      producer,
+    }
+
+    this.nodes.push(clusterNode)
+    return clusterNode
+  }
+
+  getNode(index: number): ClusterNode {
+    const node = this.nodes[index]
+    Assert.isNotUndefined(node, `No node ${index} in a cluster of ${this.nodes.length}`)
+    return node
+  }
+
+  async start(): Promise<void> {
+    for (const { node } of this.nodes) {
+      if (!node.started) {
+        await node.start()
+      }
+    }
+  }
+
+  /**
+   * Connect `from` to `to` and remember the link so {@link heal} can restore it
+   */
+  connect(from: ClusterNode, to: ClusterNode): void {
+    Assert.isFalse(from === to, 'Cannot connect a node to itself')
+    this.links.push([from, to])
+    from.node.peerNetwork.peerManager.connectToWebSocketAddress(to.address, true)
+  }
+
+  /**
+   * Connect every node in the cluster
+   *  - line: each node to the next
+   *  - ring: a line with the last node connected to the first
+   *  - star: every node to the first node
+   *  - full: every node to every other node
+   */
+  connectTopology(topology: ClusterTopology): void {
+    const nodes = this.nodes
+
+    switch (topology) {
+      case 'line':
+      case 'ring':
+        for (let i = 1; i < nodes.length; i++) {
+          this.connect(nodes[i], nodes[i - 1])
+        }
+        if (topology === 'ring' && nodes.length > 2) {
+          this.connect(nodes[0], nodes[nodes.length - 1])
+        }
+        break
+      case 'star':
+        for (let i = 1; i < nodes.length; i++) {
+          this.connect(nodes[i], nodes[0])
+        }
+        break
+      case 'full':
+        for (let i = 0; i < nodes.length; i++) {
+          for (let j = i + 1; j < nodes.length; j++) {
+            this.connect(nodes[j], nodes[i])
+          }
+        }
+        break
+    }
+  }
+
+  /**
+   * Split the cluster so nodes can only talk to nodes in their own group. Nodes
+   * that are not in any group are isolated. Peers across groups are banned, so
+   * they won't reconnect until {@link heal} is called.
+   */
+  partition(groups: ClusterNode[][]): void {
+    const groupOf = new Map<ClusterNode, number>()
+    groups.forEach((group, index) => group.forEach((n) => groupOf.set(n, index)))
+
+    for (const a of this.nodes) {
+      for (const b of this.nodes) {
+        if (a === b) {
+          continue
+        }
+
+        const groupA = groupOf.get(a)
+        if (groupA !== undefined && groupA === groupOf.get(b)) {
+          continue
+        }
+
+        const identity = b.node.peerNetwork.localPeer.publicIdentity
+        const peerManager = a.node.peerNetwork.peerManager
+
+        peerManager.banned.add(identity)
+        this.bans.push([a, identity])
+
+        const peer = peerManager.getPeer(identity)
+        if (peer) {
+          peerManager.disconnect(peer, DisconnectingReason.ShuttingDown, Date.now())
+        }
+      }
+    }
+  }
+
+  /**
+   * Lift every partition and reconnect the links made with {@link connect}
+   */
+  heal(): void {
+    for (const [node, identity] of this.bans) {
+      node.node.peerNetwork.peerManager.banned.delete(identity)
+    }
+    this.bans.length = 0
+
+    for (const [from, to] of this.links) {
+      const identity = to.node.peerNetwork.localPeer.publicIdentity
+      const peer = from.node.peerNetwork.peerManager.getPeer(identity)
+
+      if (!peer || peer.state.type === 'DISCONNECTED') {
+        from.node.peerNetwork.peerManager.connectToWebSocketAddress(to.address, true)
+      }
+    }
+  }
+
+  /**
+   * Mine `count` blocks on `node` without proof of work, paying its account
+   */
+  async mine(node: ClusterNode, count = 1): Promise<Block[]> {
+    return node.producer.produceBlocks(count, node.account)
+  }
+
+  /**
+   * Wait until every node in `nodes` has at least `count` connected peers
+   */
+  async waitForConnections(
+    count: number,
+    nodes: ClusterNode[] = this.nodes,
+    timeoutMs = DEFAULT_TIMEOUT_MS,
+  ): Promise<void> {
+    const connected = (n: ClusterNode) =>
+      n.node.peerNetwork.peerManager.getConnectedPeers().length >= count
+
+    await this.waitFor(
+      () => nodes.every(connected),
+      timeoutMs,
+      `Timed out waiting for ${nodes.length} nodes to have ${count} peers`,
+    )
+  }
+
+  /**
+   * Wait until every node in `nodes` has the same head
+   */
+  async waitForSync(
+    nodes: ClusterNode[] = this.nodes,
+    timeoutMs = DEFAULT_TIMEOUT_MS,
+  ): Promise<void> {
+    await this.waitFor(
+      () => {
+        const head = nodes[0].node.chain.head.hash
+        return nodes.every((n) => n.node.chain.head.hash.equals(head))
+      },
+      timeoutMs,
+      `Timed out waiting for ${nodes.length} nodes to sync`,
+    )
+  }
+
+  /**
+   * Shut every node down and delete the temporary data dirs
+   */
+  async shutdown(): Promise<void> {
+    for (const { node } of this.nodes) {
+      if (node.started) {
+        await node.shutdown()
+      }
+      await node.closeDB()
+    }
+
+    for (const dir of this.tempDirs) {
+      await fsAsync.rm(dir, { recursive: true, force: true })
+    }
+
+    this.nodes.length = 0
+    this.links.length = 0
+    this.bans.length = 0
+    this.tempDirs.length = 0
+  }
+
+  private async waitFor(
+    condition: () => boolean,
+    timeoutMs: number,
+    message: string,
+  ): Promise<void> {
+    const deadline = Date.now() + timeoutMs
+
+    while (!condition()) {
+      if (Date.now() > deadline) {
+        throw new Error(message)
+      }
+
+      await PromiseUtils.sleep(POLL_INTERVAL_MS)
+    }
+  }
+}
@@ -64,3 +64,18 @@ export const GRAFFITI_SIZE = 32
  * It's used in calculating how much a miner should get in rewards.
  */
 export const IRON_FISH_YEAR_IN_BLOCKS = (365 * 24 * 60 * 60) / TARGET_BLOCK_TIME_IN_SECONDS
+
+/**
+ * The consensus values that a network definition can change
+ */
+export type ConsensusParameters = {
+  allowedBlockFutureSeconds: number
+  targetBlockTimeInSeconds: number
+  targetBucketTimeInSeconds: number
+}
+
+export const DEFAULT_CONSENSUS_PARAMETERS: ConsensusParameters = {
+  allowedBlockFutureSeconds: ALLOWED_BLOCK_FUTURE_SECONDS,
+  targetBlockTimeInSeconds: TARGET_BLOCK_TIME_IN_SECONDS,
+  targetBucketTimeInSeconds: TARGET_BUCKET_TIME_IN_SECONDS,
+}
@@ -63,7 +63,7 @@ describe('Verifier', () => {
     it('rejects a block with an invalid transaction', async () => {
       const block = await useMinerBlockFixture(nodeTest.chain)
 
-      jest.spyOn(nodeTest.verifier, 'verifyTransactionContextual').mockResolvedValue({
+      jest.spyOn(nodeTest.verifier, 'verifyTransactionsContextual').mockResolvedValue({
         valid: false,
         reason: VerificationResultReason.VERIFY_TRANSACTION,
       })
@@ -122,6 +122,48 @@ describe('Verifier', () => {
     })
   })
 
+  describe('verifyTransactionsContextual', () => {
+    const nodeTest = createNodeTest()
+
+    it('finds the invalid transaction when a batch fails', async () => {
+      const { block, transaction } = await useBlockWithTx(nodeTest.node)
+      const { workerPool } = nodeTest
+
+      jest.spyOn(workerPool, 'verifyTransactions').mockResolvedValue({ valid: false })
+      const verifySpy = jest
+        .spyOn(workerPool, 'verify')
+        .mockImplementation((t) =>
+          Promise.resolve({ valid: !t.hash().equals(transaction.hash()) }),
+        )
+
+      const result = await nodeTest.verifier.verifyTransactionsContextual(
+        block.transactions,
+        block.header,
+      )
+
+      expect(result).toEqual({
+        valid: false,
+        reason: VerificationResultReason.VERIFY_TRANSACTION,
+        hash: transaction.hash(),
+      })
+      expect(verifySpy).toHaveBeenCalledTimes(block.transactions.length)
+    })
+
+    it('is valid when every transaction in a failed batch verifies on its own', async () => {
+      const { block } = await useBlockWithTx(nodeTest.node)
+      const { workerPool } = nodeTest
+
+      jest.spyOn(workerPool, 'verifyTransactions').mockRejectedValue(new Error('worker died'))
+
+      const result = await nodeTest.verifier.verifyTransactionsContextual(
+        block.transactions,
+        block.header,
+      )
+
+      expect(result).toEqual({ valid: true })
+    })
+  })
+
   describe('BlockHeader', () => {
     const nodeTest = createNodeTest()
     let header: BlockHeader
@@ -153,6 +195,14 @@ describe('Verifier', () => {
       })
     })
 
+    it('compensates for the clock offset when checking the timestamp', () => {
+      jest.spyOn(global.Date, 'now').mockImplementationOnce(() => 1598467858637)
+      header.timestamp = new Date(1598467898637)
+      nodeTest.verifier.clockOffsetMs = 30000
+
+      expect(nodeTest.verifier.verifyBlockHeader(header).valid).toBe(true)
+    })
+
     it('fails validation if graffiti field is not equal to 32 bytes', () => {
       header.graffiti = Buffer.alloc(31)
 
@@ -511,4 +561,53 @@ describe('Verifier', () => {
       }, 60000)
     })
   })
+
+  describe('verifyTransactionsContextual', () => {
+    const nodeTest = createNodeTest()
+
+    it('returns TRANSACTION_EXPIRED if any transaction is expired', async () => {
+      const account = await useAccountFixture(nodeTest.accounts)
+      const transaction = await useMinersTxFixture(nodeTest.accounts, account)
+
+      jest.spyOn(transaction, 'expirationSequence').mockImplementationOnce(() => 1)
+
+      const head = nodeTest.chain.head
+      const result = await nodeTest.verifier.verifyTransactionsContextual([transaction], head)
+
+      expect(result).toEqual({
+        valid: false,
+        reason: VerificationResultReason.TRANSACTION_EXPIRED,
+      })
+    }, 60000)
+
+    it('verifies the transactions in a batch', async () => {
+      const account = await useAccountFixture(nodeTest.accounts)
+      const transaction = await useMinersTxFixture(nodeTest.accounts, account)
+      const verifyTransactions = jest.spyOn(nodeTest.workerPool, 'verifyTransactions')
+
+      const head = nodeTest.chain.head
+      const result = await nodeTest.verifier.verifyTransactionsContextual([transaction], head)
+
+      expect(result).toEqual({ valid: true })
+      expect(verifyTransactions).toHaveBeenCalledWith([transaction])
+    }, 60000)
+
+    it('returns VERIFY_TRANSACTION when the batch is invalid', async () => {
+      const account = await useAccountFixture(nodeTest.accounts)
+      const transaction = await useMinersTxFixture(nodeTest.accounts, account)
+
+      jest.spyOn(nodeTest.workerPool, 'verifyTransactions').mockResolvedValueOnce({
+        valid: false,
+        reason: VerificationResultReason.ERROR,
+      })
+
+      const head = nodeTest.chain.head
+      const result = await nodeTest.verifier.verifyTransactionsContextual([transaction], head)
+
+      expect(result).toEqual({
+        valid: false,
+        reason: VerificationResultReason.VERIFY_TRANSACTION,
+      })
+    }, 60000)
+  })
 })
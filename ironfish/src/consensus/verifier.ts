@@ -3,6 +3,7 @@
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 
 import { BufferSet } from 'buffer-map'
+import _ from 'lodash'
 import { Blockchain } from '../blockchain'
 import { Spend } from '../primitives'
 import { Block } from '../primitives/block'
@@ -12,12 +13,18 @@ import { SerializedTransaction, Transaction } from '../primitives/transaction'
 import { IDatabaseTransaction } from '../storage'
 import { WorkerPool } from '../workerPool'
 import { VerifyTransactionOptions } from '../workerPool/tasks/verifyTransaction'
-import { ALLOWED_BLOCK_FUTURE_SECONDS, GENESIS_BLOCK_SEQUENCE } from './consensus'
+import { GENESIS_BLOCK_SEQUENCE } from './consensus'
 
 export class Verifier {
   chain: Blockchain
   private readonly workerPool: WorkerPool
 
+  /**
+   * Milliseconds added to the local clock when checking if a block is too far
+   * in the future, to compensate for a measured clock skew
+   */
+  clockOffsetMs = 0
+
   /**
    * Used to disable verifying the target on the Verifier for testing purposes
    */
@@ -45,15 +52,12 @@ export class Verifier {
     }
 
     // Verify the transactions
-    const verificationResults = await Promise.all(
-      block.transactions.map((t) =>
-        this.verifyTransactionContextual(t, block.header, { verifyFees: false }),
-      ),
+    const transactionsValid = await this.verifyTransactionsContextual(
+      block.transactions,
+      block.header,
     )
-
-    const invalidResult = verificationResults.find((f) => !f.valid)
-    if (invalidResult !== undefined) {
-      return invalidResult
+    if (!transactionsValid.valid) {
+      return transactionsValid
     }
 
     // Sum the totalTransactionFees and minersFee
@@ -119,7 +123,9 @@ export class Verifier {
       return { valid: false, reason: VerificationResultReason.HASH_NOT_MEET_TARGET }
     }
 
-    if (blockHeader.timestamp.getTime() > Date.now() + ALLOWED_BLOCK_FUTURE_SECONDS * 1000) {
+    const allowedFutureMs = this.chain.consensus.allowedBlockFutureSeconds * 1000
+
+    if (blockHeader.timestamp.getTime() > Date.now() + this.clockOffsetMs + allowedFutureMs) {
       return { valid: false, reason: VerificationResultReason.TOO_FAR_IN_FUTURE }
     }
 
@@ -167,6 +173,62 @@ export class Verifier {
     return await this.verifyTransactionNoncontextual(transaction, options)
   }
 
+  /**
+   * Verify a group of transactions, such as the transactions in a block. The
+   * proofs are checked in batches split across the worker pool, which is much
+   * cheaper than verifying each transaction on its own. A failed batch doesn't
+   * say which transaction is invalid, so its transactions are then verified one
+   * at a time and the hash of the first invalid one is returned. Fees are not
+   * verified.
+   */
+  async verifyTransactionsContextual(
+    transactions: Transaction[],
+    block: BlockHeader,
+  ): Promise<VerificationResult> {
+    for (const transaction of transactions) {
+      if (this.isExpiredSequence(transaction.expirationSequence(), block.sequence)) {
+        return {
+          valid: false,
+          reason: VerificationResultReason.TRANSACTION_EXPIRED,
+        }
+      }
+    }
+
+    const batchCount = Math.max(1, this.workerPool.numWorkers)
+    const batchSize = Math.max(1, Math.ceil(transactions.length / batchCount))
+
+    const batches = _.chunk(transactions, batchSize)
+    const results = await Promise.all(
+      batches.map((batch) =>
+        this.workerPool
+          .verifyTransactions(batch)
+          .catch((): VerificationResult => ({ valid: false })),
+      ),
+    )
+
+    for (const [index, result] of results.entries()) {
+      if (result.valid) {
+        continue
+      }
+
+      for (const transaction of batches[index]) {
+        const verified = await this.verifyTransactionNoncontextual(transaction, {
+          verifyFees: false,
+        })
+
+        if (!verified.valid) {
+          return {
+            valid: false,
+            reason: VerificationResultReason.VERIFY_TRANSACTION,
+            hash: transaction.hash(),
+          }
+        }
+      }
+    }
+
+    return { valid: true }
+  }
+
   async verifyTransactionNoncontextual(
     transaction: Transaction,
     options?: VerifyTransactionOptions,
@@ -241,10 +303,10 @@ export class Verifier {
       return { valid: false, reason: VerificationResultReason.NULLIFIER_COMMITMENT_SIZE }
     }
 
-    if (
-      current.header.timestamp.getTime() <
-      previousHeader.timestamp.getTime() - ALLOWED_BLOCK_FUTURE_SECONDS * 1000
-    ) {
+    const allowedFutureMs = this.chain.consensus.allowedBlockFutureSeconds * 1000
+    const minimumTimestamp = previousHeader.timestamp.getTime() - allowedFutureMs
+
+    if (current.header.timestamp.getTime() < minimumTimestamp) {
       return { valid: false, reason: VerificationResultReason.BLOCK_TOO_OLD }
     }
 
@@ -271,6 +333,7 @@ export class Verifier {
       header.timestamp,
       previous.timestamp,
       previous.target,
+      this.chain.consensus,
     )
 
     return header.target.targetValue === expectedTarget.targetValue
@@ -0,0 +1,37 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { DiskMonitor } from './diskMonitor'
+
+describe('DiskMonitor', () => {
+  it('reports the status against the thresholds', () => {
+    const monitor = new DiskMonitor({ dataDir: '/tmp', lowBytes: 100, criticalBytes: 10 })
+    const onStatusChanged = jest.fn()
+    monitor.onStatusChanged.on(onStatusChanged)
+
+    monitor.update(500)
+    expect(monitor.status).toBe('ok')
+    expect(onStatusChanged).not.toHaveBeenCalled()
+
+    monitor.update(50)
+    expect(monitor.status).toBe('low')
+    expect(onStatusChanged).toHaveBeenLastCalledWith('low', 'ok')
+
+    monitor.update(5)
+    expect(monitor.status).toBe('critical')
+    expect(monitor.critical).toBe(true)
+    expect(onStatusChanged).toHaveBeenLastCalledWith('critical', 'low')
+
+    monitor.update(500)
+    expect(monitor.status).toBe('ok')
+    expect(onStatusChanged).toHaveBeenLastCalledWith('ok', 'critical')
+  })
+
+  it('is ok when the free space is unknown', () => {
+    const monitor = new DiskMonitor({ dataDir: '/tmp', lowBytes: 100, criticalBytes: 10 })
+
+    monitor.update(null)
+    expect(monitor.status).toBe('ok')
+    expect(monitor.free).toBeNull()
+  })
+})
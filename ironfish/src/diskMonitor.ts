@@ -0,0 +1,108 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { Event } from './event'
+import { createRootLogger, Logger } from './logger'
+import { DiskUtils, FileUtils, SetIntervalToken } from './utils'
+
+/**
+ * How much space is left on the disk that holds the data dir
+ *  - ok: nothing is held back
+ *  - low: a warning is logged, nothing is held back yet
+ *  - critical: syncing stops and new transactions are dropped so the databases
+ *    are not corrupted by running out of space mid write
+ */
+export type DiskStatus = 'ok' | 'low' | 'critical'
+
+export class DiskMonitor {
+  readonly logger: Logger
+  readonly dataDir: string
+
+  /**
+   * Free bytes below which the disk is low
+   */
+  readonly lowBytes: number
+
+  /**
+   * Free bytes below which the disk is critical
+   */
+  readonly criticalBytes: number
+
+  readonly onStatusChanged = new Event<[status: DiskStatus, previous: DiskStatus]>()
+
+  status: DiskStatus = 'ok'
+
+  /**
+   * The free bytes from the last check, null if it could not be determined
+   */
+  free: number | null = null
+
+  private interval: SetIntervalToken | null = null
+  private readonly checkPeriodMs = 60 * 1000
+
+  constructor(options: {
+    dataDir: string
+    lowBytes: number
+    criticalBytes: number
+    logger?: Logger
+  }) {
+    this.logger = (options.logger ?? createRootLogger()).withTag('diskmonitor')
+    this.dataDir = options.dataDir
+    this.lowBytes = options.lowBytes
+    this.criticalBytes = options.criticalBytes
+  }
+
+  async start(): Promise<void> {
+    if (this.interval) {
+      return
+    }
+
+    this.interval = setInterval(() => void this.check(), this.checkPeriodMs)
+    await this.check()
+  }
+
+  stop(): void {
+    if (this.interval) {
+      clearInterval(this.interval)
+      this.interval = null
+    }
+  }
+
+  get critical(): boolean {
+    return this.status === 'critical'
+  }
+
+  async check(): Promise<void> {
+    this.update(await DiskUtils.getFreeSpace(this.dataDir))
+  }
+
+  update(free: number | null): void {
+    this.free = free
+
+    let status: DiskStatus = 'ok'
+    if (free !== null && free < this.criticalBytes) {
+      status = 'critical'
+    } else if (free !== null && free < this.lowBytes) {
+      status = 'low'
+    }
+
+    if (status === this.status) {
+      return
+    }
+
+    const previous = this.status
+    this.status = status
+
+    const message = `Disk space is ${status}, ${FileUtils.formatFileSize(free ?? 0)} free`
+
+    if (status === 'critical') {
+      this.logger.error(`${message}, syncing and new transactions stop until space is freed`)
+    } else if (status === 'low') {
+      this.logger.warn(`${message} in ${this.dataDir}`)
+    } else {
+      this.logger.info(message)
+    }
+
+    this.onStatusChanged.emit(status, previous)
+  }
+}
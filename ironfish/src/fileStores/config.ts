@@ -3,6 +3,8 @@
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 import * as yup from 'yup'
 import { FileSystem } from '../fileSystems'
+import type { ReleaseChannel } from '../webApi'
+import { ConfigIssue, formatConfigIssue, validateConfig } from './configValidator'
 import { KeyStore } from './keyStore'
 
 export const DEFAULT_CONFIG_NAME = 'config.json'
@@ -33,8 +35,14 @@ export const DEFAULT_POOL_ATTEMPT_PAYOUT_INTERVAL = 15 * 60 // 15 minutes
 export const DEFAULT_POOL_SUCCESSFUL_PAYOUT_INTERVAL = 2 * 60 * 60 // 2 hours
 export const DEFAULT_POOL_STATUS_NOTIFICATION_INTERVAL = 30 * 60 // 30 minutes
 export const DEFAULT_POOL_RECENT_SHARE_CUTOFF = 2 * 60 * 60 // 2 hours
+export const DEFAULT_POOL_PAYOUT_BATCH_SIZE = 20
+export const DEFAULT_POOL_SHARE_RETENTION = 30 * 24 * 60 * 60 // 30 days
 
 export type ConfigOptions = {
+  /**
+   * Nodes to connect to on start, as `host:port`. Write a node as
+   * `identity@host:port` to only accept it if it has that identity.
+   */
   bootstrapNodes: string[]
   databaseName: string
   editor: string
@@ -45,8 +53,31 @@ export type ConfigOptions = {
   enableRpcTcp: boolean
   enableRpcTls: boolean
   enableSyncing: boolean
+  /**
+   * The most blocks a reorganization may disconnect before the node waits for
+   * the operator to confirm it with chain:confirmreorg. Blocks this deep are
+   * reported as finalized. Setting to 0 allows any reorganization
+   */
+  maxReorgDepth: number
+  /**
+   * Keep bloom filters of the nullifiers and transaction hashes on the chain in
+   * memory, so verifying spends and transactions skips the database read for
+   * ones that are not on the chain. Takes about 2 bytes of memory per nullifier
+   * and transaction.
+   */
+  enableChainFilters: boolean
   enableTelemetry: boolean
   enableMetrics: boolean
+  /**
+   * Should the node keep a rolling 7 day history of key metrics in the data
+   * directory. Requires `enableMetrics`.
+   */
+  enableMetricsHistory: boolean
+  /**
+   * Should the node keep decrypted transaction feeds for the view keys
+   * registered through the scanner RPC namespace
+   */
+  enableViewKeyScanner: boolean
   getFundsApi: string
   ipcPath: string
   /**
@@ -85,6 +116,12 @@ export type ConfigOptions = {
    * Length is truncated to 32 bytes.
    */
   blockGraffiti: string
+  /**
+   * The network to join, either the name of a built in network like testnet or
+   * devnet, or the path to a network definition file. If bootstrapNodes is not
+   * set, the bootstrap nodes of the network are used.
+   */
+  network: string
   nodeName: string
   /**
    * The number of CPU workers to use for long-running node operations, like creating
@@ -98,11 +135,77 @@ export type ConfigOptions = {
    * The max number of node workers. See config "nodeWorkers"
    */
   nodeWorkersMax: number
+  /**
+   * The number of workers the pool may scale up to while jobs are waiting in the
+   * queue. Extra workers are stopped again once the queue has been empty for a
+   * minute. -1 uses the number of CPU cores, and 0 disables autoscaling.
+   */
+  nodeWorkersAutoscaleMax: number
+  /**
+   * How many milliseconds a job may wait in the worker queue before the pool
+   * starts another worker. See config "nodeWorkersAutoscaleMax"
+   */
+  nodeWorkersScaleUpLatency: number
+  /**
+   * How many milliseconds a worker job may execute before it's aborted and the
+   * worker running it is replaced. 0 disables job timeouts. Transaction and
+   * miner's fee proofs are never timed out, since building them can't be
+   * cancelled and can take a long time on slow machines.
+   */
+  nodeWorkersJobTimeout: number
+  /**
+   * The number of extra workers that only build transaction and miner's fee
+   * proofs. They load the proving keys when the node starts and are never
+   * scaled down, so a transaction doesn't wait for the keys to load or behind
+   * other jobs. 0 builds proofs on the other workers.
+   */
+  nodeWorkersProving: number
   p2pSimulateLatency: number
   peerPort: number
   rpcTcpHost: string
   rpcTcpPort: number
   rpcTcpSecure: boolean
+  /**
+   * Only serve the RPC routes that read the chain over TCP, like a read replica
+   * that syncs from a primary with chainNode and serves explorer or API traffic
+   */
+  rpcTcpReadOnly: boolean
+  /**
+   * The most TCP and TLS RPC clients connected at once, and from one IP
+   * address. Connections over the limits are closed. 0 allows any number.
+   */
+  rpcTcpMaxConnections: number
+  rpcTcpMaxConnectionsPerIp: number
+  /**
+   * Close TCP and TLS RPC clients that have no requests open and send nothing
+   * for this many milliseconds. 0 never closes idle clients.
+   */
+  rpcTcpIdleTimeoutMs: number
+  /**
+   * When set, TCP and TLS RPC clients must send this token with every request.
+   * An empty string allows unauthenticated requests.
+   */
+  rpcAuthToken: string
+  /**
+   * The host:port of a chain node to sync blocks from over RPC, instead of from
+   * the peer network. The node then has no peers, so a wallet can run in its own
+   * process away from the node that is exposed to the internet. An empty string
+   * syncs from peers.
+   */
+  chainNode: string
+  /**
+   * Connect to chainNode over TLS
+   */
+  chainNodeTls: boolean
+  /**
+   * The rpcAuthToken of the chain node
+   */
+  chainNodeAuthToken: string
+  /**
+   * Run as a read replica that syncs from chainNode and only serves the read
+   * only RPC routes over TCP, as if rpcTcpReadOnly was set. Requires chainNode
+   */
+  follower: boolean
   tlsKeyPath: string
   tlsCertPath: string
   /**
@@ -116,9 +219,38 @@ export type ConfigOptions = {
    * establish new connections when below this number.
    */
   targetPeers: number
+  /**
+   * The URL telemetry points are submitted to. Point this at your own collector
+   * to gather the telemetry of your nodes, see the submission format in
+   * ironfish/src/telemetry/README.md.
+   */
   telemetryApi: string
+  /**
+   * If set, sent as a bearer token in the Authorization header when
+   * submitting telemetry to `telemetryApi`.
+   */
+  telemetryApiKey: string
+  /**
+   * The categories of telemetry to collect for submission or export.
+   * Any of `performance`, `network` and `mining`.
+   */
+  telemetryCategories: string[]
+  /**
+   * If set, telemetry points are also appended to this file as JSON lines. Points
+   * are exported even if `enableTelemetry` is off, in which case nothing is sent
+   * to the telemetry API.
+   */
+  telemetryExportPath: string
   accountName: string
 
+  /**
+   * The account CLI commands use when `--account` is not passed, instead of the
+   * wallet's default account. Set it per profile so each profile targets its own
+   * account. An `.ironfish-account` file in the working directory or a parent
+   * directory takes precedence.
+   */
+  defaultAccount: string
+
   /**
    * When the option is true, then each invocation of start command will invoke generation of new identity.
    * In situation, when the option is false, the app check if identity already exists in internal.json file,
@@ -144,10 +276,27 @@ export type ConfigOptions = {
 
   /**
    * The minimum number of block confirmations needed when computing account
-   * balance.
+   * balance. A reorg can reverse payments with fewer confirmations than the
+   * blocks it removes, so raise this if the node warns about deep reorgs.
+   * RPC calls can ask for a different number with minConfirmations.
    */
   minimumBlockConfirmations: number
 
+  /**
+   * Notes worth less than this many ore are treated as dust. Other accounts
+   * can send dust to tie many tiny notes to a wallet, so dust is hidden from
+   * wallet views unless asked for and is never picked to pay for transactions.
+   * Set to 0 to turn this off.
+   */
+  dustThreshold: number
+
+  /**
+   * Warn when an account is scanned to more than this many blocks behind the
+   * chain head, since its balance won't include the blocks after that.
+   * Set to 0 to turn this off.
+   */
+  walletScanLagWarning: number
+
   /**
    * The name that the pool will use in block graffiti and transaction memo.
    */
@@ -204,6 +353,51 @@ export type ConfigOptions = {
    */
   poolRecentShareCutoff: number
 
+  /**
+   * The most miners the pool will pay in one transaction. Larger payouts are
+   * split into several transactions.
+   */
+  poolPayoutBatchSize: number
+
+  /**
+   * The least amount of ore the pool will pay a miner. Miners owed less keep
+   * their shares until a later payout owes them enough.
+   */
+  poolMinimumPayout: number
+
+  /**
+   * The highest fee in ore the pool will pay for each payout transaction.
+   * Payouts wait while the estimated fee is higher. Setting to 0 disables the limit
+   */
+  poolMaxPayoutFee: number
+
+  /**
+   * The percent of each payout paid to poolFeeAddress for the pool operator
+   */
+  poolFeePercent: number
+
+  /**
+   * The public address the pool fee is paid to. Has to be set when
+   * poolFeePercent is above 0
+   */
+  poolFeeAddress: string
+
+  /**
+   * The percent of each payout donated to poolDonationAddress
+   */
+  poolDonationPercent: number
+
+  /**
+   * The public address donations are paid to. Setting to '' disables donations
+   */
+  poolDonationAddress: string
+
+  /**
+   * The length of time in seconds the pool keeps shares after they are paid.
+   * Unpaid shares are always kept. Setting to 0 keeps paid shares forever
+   */
+  poolShareRetention: number
+
   /**
    * The discord webhook URL to post pool critical pool information to
    */
@@ -236,6 +430,214 @@ export type ConfigOptions = {
    * URL for viewing transaction information in a block explorer
    */
   explorerTransactionsUrl: string
+
+  /**
+   * The number of milliseconds a traced operation, like a block add stage, a
+   * wallet scan batch, or an RPC handler, may take before it is logged as a
+   * slow operation. Setting this to 0 disables slow operation logging.
+   */
+  slowOperationThresholdMs: number
+  /**
+   * Watch the config file while the node is running and apply changes to
+   * options that are safe to change without a restart, see `config:reload`
+   */
+  enableConfigWatcher: boolean
+
+  /**
+   * Check the chain database when the node starts, and refuse to start if it is
+   * corrupt. This checks the schema version, the last `integrityCheckDepth`
+   * headers on the main chain and the merkle tree roots at the head.
+   */
+  enableIntegrityCheck: boolean
+  integrityCheckDepth: number
+
+  /**
+   * Only relay transactions from other nodes that pay at least this many ore per
+   * kilobyte. Transactions below it are still added to the mem pool.
+   */
+  relayMinFeeRate: number
+  /**
+   * The largest transaction in bytes to relay from other nodes, 0 for no limit
+   */
+  relayMaxTransactionSize: number
+  /**
+   * Relay new blocks from other nodes before the chain is synced. Turn it off to
+   * save bandwidth during the initial sync.
+   */
+  relayWhileSyncing: boolean
+
+  /**
+   * Identities of peers to sync from before any other peer, such as a node you
+   * run yourself. The node falls back to other peers if they stall.
+   */
+  preferredSyncPeers: string[]
+
+  /**
+   * Seconds to wait for blocks from the peer we sync from before switching to
+   * another peer, 0 to never switch
+   */
+  syncStallTimeout: number
+
+  /**
+   * The fewest gossip message nonces to remember, so messages from many peers
+   * are only handled once
+   */
+  gossipCacheSize: number
+  /**
+   * How many more nonces to remember per connected peer, 0 to not scale the
+   * cache with the number of peers
+   */
+  gossipCacheSizePerPeer: number
+  /**
+   * How many block hashes to remember sending to or receiving from each peer
+   */
+  knownBlocksCacheSize: number
+
+  /**
+   * Record which peer each block and transaction came from and which peers it
+   * was forwarded to, see chain:trace
+   */
+  enablePropagationTracing: boolean
+
+  /**
+   * Find other nodes on the local network with mDNS and connect to them. Nodes
+   * found this way are preferred for syncing.
+   */
+  enableLocalDiscovery: boolean
+
+  /**
+   * Save the transactions in the mem pool when the node shuts down and add them
+   * back to the mem pool when it starts
+   */
+  persistMemPool: boolean
+
+  /**
+   * The most milliseconds the node may spend shutting down. Steps that are still
+   * running when it runs out are abandoned so the node can exit.
+   */
+  shutdownTimeoutMs: number
+
+  /**
+   * The most megabytes of heap the node may use before it starts to back off by
+   * pausing sync, shrinking the mem pool and delaying gossip. 0 uses the heap limit.
+   */
+  memoryBudgetMb: number
+
+  /**
+   * Megabytes of memory for caching recently read headers, blocks and merkle
+   * tree nodes. 0 uses a tenth of the memory budget, and -1 disables the caches.
+   */
+  chainCacheMb: number
+
+  /**
+   * Megabytes free on the disk that holds the data dir below which the node
+   * warns that space is running low
+   */
+  diskLowMb: number
+
+  /**
+   * Megabytes free on the disk that holds the data dir below which the node
+   * stops syncing and accepting transactions so the databases don't corrupt
+   */
+  diskCriticalMb: number
+
+  /**
+   * The NTP server to measure clock skew against, or an empty string to not
+   * measure it
+   */
+  ntpServer: string
+
+  /**
+   * How many milliseconds the local clock may be off from NTP time before the
+   * node warns about it
+   */
+  clockSkewToleranceMs: number
+
+  /**
+   * Correct for the measured clock skew when checking if blocks are too far in
+   * the future, up to the allowed block future time
+   */
+  compensateClockSkew: boolean
+
+  /**
+   * How the CLI updates itself when a new version is released
+   *  - auto: download and install the update, used the next time the node starts
+   *  - manual: only tell you about the update, install it with version:update
+   *  - off: never check for updates
+   */
+  autoUpdate: 'auto' | 'manual' | 'off'
+
+  /**
+   * The release channel to check for new versions on: stable, or beta and
+   * nightly to test pre-releases
+   */
+  releaseChannel: ReleaseChannel
+
+  /**
+   * CLI command ids, like `accounts:remove`, that skip their confirmation
+   * prompt as if they were run with --yes
+   */
+  skipConfirmations: string[]
+
+  /**
+   * CLI command ids that always ask for confirmation, even when run with --yes
+   * or IRONFISH_ASSUME_YES
+   */
+  requireConfirmations: string[]
+
+  /**
+   * A directory or s3:// URL to write encrypted wallet backups to, or an empty
+   * string to not back up. S3 credentials are read from the AWS environment
+   * variables.
+   */
+  walletBackupPath: string
+
+  /**
+   * A file with the passphrase wallet backups are encrypted with, so it is not
+   * kept in the config. The IRONFISH_WALLET_BACKUP_PASSPHRASE environment
+   * variable is used instead when it is set. Backups are not written until
+   * there is a passphrase.
+   */
+  walletBackupPassphraseFile: string
+
+  /**
+   * Hours between wallet backups, also how often the reminder is shown
+   */
+  walletBackupIntervalHours: number
+
+  /**
+   * How many wallet backups to keep, 0 keeps every backup
+   */
+  walletBackupRetention: number
+
+  /**
+   * Remind you to back up your accounts when automatic backups are not set up
+   */
+  walletBackupReminder: boolean
+
+  /**
+   * Serve the Rosetta Data and Construction APIs over HTTP for exchanges and
+   * custodians
+   */
+  enableRosetta: boolean
+
+  /**
+   * The host and port to serve the Rosetta API on
+   */
+  rosettaHost: string
+  rosettaPort: number
+
+  /**
+   * The ids of view keys registered with the scanner that belong to cold
+   * storage. Any spend the scanner sees from one of them raises an alert.
+   */
+  coldStorageViewKeys: string[]
+
+  /**
+   * A URL that cold storage alerts are POSTed to as JSON, or an empty string to
+   * only log them
+   */
+  coldStorageWebhook: string
 }
 
 export const ConfigOptionsSchema: yup.ObjectSchema<Partial<ConfigOptions>> = yup
@@ -244,6 +646,11 @@ export const ConfigOptionsSchema: yup.ObjectSchema<Partial<ConfigOptions>> = yup
   .defined()
 
 export class Config extends KeyStore<ConfigOptions> {
+  /**
+   * Problems found in the config file the last time it was loaded
+   */
+  issues: ConfigIssue[] = []
+
   constructor(files: FileSystem, dataDir: string, configName?: string) {
     super(
       files,
@@ -254,6 +661,27 @@ export class Config extends KeyStore<ConfigOptions> {
     )
   }
 
+  async load(): Promise<void> {
+    await super.load()
+    this.issues = validateConfig(this.loaded, this.defaults)
+  }
+
+  /**
+   * Load the config file again and apply its changed values. If the file has
+   * errors nothing is changed and an error is thrown.
+   * @returns the options whose value changed
+   */
+  async reload(): Promise<Array<keyof ConfigOptions>> {
+    const data = (await this.storage.load()) ?? {}
+    const errors = validateConfig(data, this.defaults).filter((i) => i.level === 'error')
+
+    if (errors.length) {
+      throw new Error(errors.map((e) => formatConfigIssue(e)).join('\n'))
+    }
+
+    return super.reload()
+  }
+
   get chainDatabasePath(): string {
     return this.files.join(this.storage.dataDir, 'databases', this.get('databaseName'))
   }
@@ -266,6 +694,10 @@ export class Config extends KeyStore<ConfigOptions> {
     return this.files.join(this.storage.dataDir, 'indexes', this.get('databaseName'))
   }
 
+  get scannerDatabasePath(): string {
+    return this.files.join(this.storage.dataDir, 'scanner', this.get('databaseName'))
+  }
+
   static GetDefaults(files: FileSystem, dataDir: string): ConfigOptions {
     return {
       bootstrapNodes: [DEFAULT_BOOTSTRAP_NODE],
@@ -279,8 +711,12 @@ export class Config extends KeyStore<ConfigOptions> {
       enableRpcTcp: DEFAULT_USE_RPC_TCP,
       enableRpcTls: DEFAULT_USE_RPC_TLS,
       enableSyncing: true,
+      maxReorgDepth: 0,
+      enableChainFilters: true,
       enableTelemetry: false,
       enableMetrics: true,
+      enableMetricsHistory: true,
+      enableViewKeyScanner: false,
       getFundsApi: DEFAULT_GET_FUNDS_API,
       ipcPath: files.resolve(files.join(dataDir, 'ironfish.ipc')),
       logLevel: '*:info',
@@ -288,22 +724,42 @@ export class Config extends KeyStore<ConfigOptions> {
       logPrefix: '',
       miningForce: false,
       blockGraffiti: '',
+      network: 'testnet',
       nodeName: '',
       nodeWorkers: -1,
       nodeWorkersMax: 6,
+      nodeWorkersAutoscaleMax: -1,
+      nodeWorkersScaleUpLatency: 500,
+      nodeWorkersJobTimeout: 0,
+      nodeWorkersProving: 0,
       p2pSimulateLatency: 0,
       peerPort: DEFAULT_WEBSOCKET_PORT,
       rpcTcpHost: 'localhost',
       rpcTcpPort: 8020,
       rpcTcpSecure: false,
+      rpcTcpReadOnly: false,
+      rpcTcpMaxConnections: 100,
+      rpcTcpMaxConnectionsPerIp: 50,
+      rpcTcpIdleTimeoutMs: 10 * 60 * 1000,
+      rpcAuthToken: '',
+      chainNode: '',
+      chainNodeTls: true,
+      chainNodeAuthToken: '',
+      follower: false,
       tlsKeyPath: files.resolve(files.join(dataDir, 'certs', 'node-key.pem')),
       tlsCertPath: files.resolve(files.join(dataDir, 'certs', 'node-cert.pem')),
       maxPeers: 50,
       minimumBlockConfirmations: 12,
+      dustThreshold: 0,
+      walletScanLagWarning: 100,
       minPeers: 1,
       targetPeers: 50,
       telemetryApi: DEFAULT_TELEMETRY_API,
+      telemetryApiKey: '',
+      telemetryCategories: ['performance', 'network', 'mining'],
+      telemetryExportPath: '',
       accountName: DEFAULT_WALLET_NAME,
+      defaultAccount: '',
       generateNewIdentity: false,
       blocksPerMessage: 20,
       minerBatchSize: DEFAULT_MINER_BATCH_SIZE,
@@ -318,12 +774,57 @@ export class Config extends KeyStore<ConfigOptions> {
       poolSuccessfulPayoutInterval: DEFAULT_POOL_SUCCESSFUL_PAYOUT_INTERVAL,
       poolStatusNotificationInterval: DEFAULT_POOL_STATUS_NOTIFICATION_INTERVAL,
       poolRecentShareCutoff: DEFAULT_POOL_RECENT_SHARE_CUTOFF,
+      poolPayoutBatchSize: DEFAULT_POOL_PAYOUT_BATCH_SIZE,
+      poolMinimumPayout: 0,
+      poolMaxPayoutFee: 0,
+      poolFeePercent: 0,
+      poolFeeAddress: '',
+      poolDonationPercent: 0,
+      poolDonationAddress: '',
+      poolShareRetention: DEFAULT_POOL_SHARE_RETENTION,
       poolDiscordWebhook: '',
       poolMaxConnectionsPerIp: 0,
       poolLarkWebhook: '',
       jsonLogs: false,
       explorerBlocksUrl: DEFAULT_EXPLORER_BLOCKS_URL,
       explorerTransactionsUrl: DEFAULT_EXPLORER_TRANSACTIONS_URL,
+      slowOperationThresholdMs: 1000,
+      enableConfigWatcher: true,
+      enableIntegrityCheck: false,
+      integrityCheckDepth: 100,
+      relayMinFeeRate: 0,
+      relayMaxTransactionSize: 0,
+      relayWhileSyncing: true,
+      preferredSyncPeers: [],
+      syncStallTimeout: 60,
+      gossipCacheSize: 100000,
+      gossipCacheSizePerPeer: 2000,
+      knownBlocksCacheSize: 1024,
+      enablePropagationTracing: false,
+      enableLocalDiscovery: false,
+      persistMemPool: true,
+      shutdownTimeoutMs: 30000,
+      memoryBudgetMb: 0,
+      chainCacheMb: 0,
+      diskLowMb: 5 * 1024,
+      diskCriticalMb: 1024,
+      ntpServer: 'pool.ntp.org',
+      clockSkewToleranceMs: 5000,
+      compensateClockSkew: false,
+      autoUpdate: 'manual',
+      releaseChannel: 'stable',
+      skipConfirmations: [],
+      requireConfirmations: [],
+      walletBackupPath: '',
+      walletBackupPassphraseFile: '',
+      walletBackupIntervalHours: 24,
+      walletBackupRetention: 7,
+      walletBackupReminder: true,
+      enableRosetta: false,
+      rosettaHost: 'localhost',
+      rosettaPort: 8080,
+      coldStorageViewKeys: [],
+      coldStorageWebhook: '',
     }
   }
 }
@@ -0,0 +1,63 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { NodeFileProvider } from '../fileSystems'
+import { Config, ConfigOptions } from './config'
+import { validateConfig } from './configValidator'
+
+describe('validateConfig', () => {
+  let defaults: ConfigOptions
+
+  beforeAll(async () => {
+    const files = await new NodeFileProvider().init()
+    defaults = Config.GetDefaults(files, '/tmp')
+  })
+
+  it('accepts valid options', () => {
+    const issues = validateConfig({ maxPeers: 10, bootstrapNodes: ['a', 'b'] }, defaults)
+    expect(issues).toEqual([])
+  })
+
+  it('reports unknown options with the closest known option', () => {
+    expect(validateConfig({ maxPeer: 10 }, defaults)).toEqual([
+      {
+        key: 'maxPeer',
+        level: 'warning',
+        message: 'Unknown config option maxPeer',
+        suggestion: 'Did you mean maxPeers?',
+      },
+    ])
+
+    expect(validateConfig({ somethingElse: 10 }, defaults)).toMatchObject([
+      { key: 'somethingElse', suggestion: 'Remove somethingElse from your config' },
+    ])
+  })
+
+  it('reports deprecated options', () => {
+    expect(validateConfig({ enableMiningDirector: true }, defaults)).toMatchObject([
+      { key: 'enableMiningDirector', level: 'warning' },
+    ])
+  })
+
+  it('reports type errors with a converted value', () => {
+    const issues = validateConfig(
+      { maxPeers: '10', enableRpc: 'false', bootstrapNodes: 'a, b', nodeName: [] },
+      defaults,
+    )
+
+    expect(issues).toEqual([
+      expect.objectContaining({
+        key: 'maxPeers',
+        level: 'error',
+        message: 'maxPeers must be a number but is a string',
+        suggestion: 'Change the value to 10',
+      }),
+      expect.objectContaining({ key: 'enableRpc', suggestion: 'Change the value to false' }),
+      expect.objectContaining({
+        key: 'bootstrapNodes',
+        suggestion: 'Change the value to ["a","b"]',
+      }),
+      expect.objectContaining({ key: 'nodeName', suggestion: 'Change the value to ""' }),
+    ])
+  })
+})
@@ -0,0 +1,160 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { StringUtils } from '../utils/string'
+import type { ConfigOptions } from './config'
+
+export type ConfigIssue = {
+  key: string
+  level: 'error' | 'warning'
+  message: string
+  suggestion?: string
+}
+
+type ConfigValueType = 'string' | 'number' | 'boolean' | 'array' | 'object' | 'null'
+
+/**
+ * Options that used to be supported and are now ignored. Set `replacement` if
+ * another option took over for it.
+ */
+export const DEPRECATED_CONFIG_OPTIONS: Readonly<
+  Record<string, { message: string; replacement?: keyof ConfigOptions }>
+> = {
+  enableMiningDirector: {
+    message: 'the node always serves block templates to miners that connect to it',
+  },
+}
+
+/**
+ * Checks the options loaded from a config file against the known options and
+ * the types of their default values
+ *
+ * @returns the problems found, errors will prevent the node from starting
+ */
+export function validateConfig(
+  data: Record<string, unknown>,
+  defaults: ConfigOptions,
+): ConfigIssue[] {
+  const issues: ConfigIssue[] = []
+  const knownKeys = Object.keys(defaults)
+
+  for (const key of Object.keys(data)) {
+    const value = data[key]
+
+    const deprecated = DEPRECATED_CONFIG_OPTIONS[key]
+    if (deprecated) {
+      issues.push({
+        key,
+        level: 'warning',
+        message: `${key} is deprecated, ${deprecated.message}`,
+        suggestion: deprecated.replacement
+          ? `Use ${deprecated.replacement} instead`
+          : `Remove ${key} from your config`,
+      })
+      continue
+    }
+
+    if (!(key in defaults)) {
+      const closest = findClosestKey(key, knownKeys)
+
+      issues.push({
+        key,
+        level: 'warning',
+        message: `Unknown config option ${key}`,
+        suggestion: closest ? `Did you mean ${closest}?` : `Remove ${key} from your config`,
+      })
+      continue
+    }
+
+    const defaultValue = defaults[key as keyof ConfigOptions]
+    const expected = getValueType(defaultValue)
+    const actual = getValueType(value)
+
+    if (expected !== actual) {
+      const converted = convertValue(value, expected)
+      const fixed = converted !== undefined ? converted : defaultValue
+
+      issues.push({
+        key,
+        level: 'error',
+        message: `${key} must be a ${expected} but is a ${actual}`,
+        suggestion: `Change the value to ${JSON.stringify(fixed)}`,
+      })
+      continue
+    }
+
+    if (Array.isArray(value) && !value.every((v) => typeof v === 'string')) {
+      issues.push({
+        key,
+        level: 'error',
+        message: `${key} must be an array of strings`,
+        suggestion: `Change the value to ${JSON.stringify(value.map(String))}`,
+      })
+    }
+  }
+
+  return issues
+}
+
+export function formatConfigIssue(issue: ConfigIssue): string {
+  const level = issue.level === 'error' ? 'Error' : 'Warning'
+  const suggestion = issue.suggestion ? ` (${issue.suggestion})` : ''
+  return `${level}: ${issue.message}${suggestion}`
+}
+
+function getValueType(value: unknown): ConfigValueType {
+  if (value === null) {
+    return 'null'
+  }
+  if (Array.isArray(value)) {
+    return 'array'
+  }
+  return typeof value as ConfigValueType
+}
+
+/**
+ * Converts values that were written with the wrong JSON type, such as "true"
+ * or "50" written as strings, to the expected type
+ */
+function convertValue(value: unknown, expected: ConfigValueType): unknown {
+  if (expected === 'string' && (typeof value === 'number' || typeof value === 'boolean')) {
+    return String(value)
+  }
+
+  if (typeof value !== 'string') {
+    return undefined
+  }
+
+  const trimmed = value.trim()
+
+  if (expected === 'number' && trimmed !== '' && !isNaN(Number(trimmed))) {
+    return Number(trimmed)
+  }
+
+  if (expected === 'boolean' && (trimmed === 'true' || trimmed === 'false')) {
+    return trimmed === 'true'
+  }
+
+  if (expected === 'array') {
+    return trimmed === '' ? [] : trimmed.split(',').map((v) => v.trim())
+  }
+
+  return undefined
+}
+
+function findClosestKey(key: string, knownKeys: string[]): string | null {
+  let closest: string | null = null
+  let closestDistance = Infinity
+
+  for (const knownKey of knownKeys) {
+    const distance = StringUtils.distance(key.toLowerCase(), knownKey.toLowerCase())
+
+    if (distance < closestDistance) {
+      closest = knownKey
+      closestDistance = distance
+    }
+  }
+
+  // Only suggest keys that are a few typos away
+  return closestDistance <= Math.max(2, Math.floor(key.length / 4)) ? closest : null
+}
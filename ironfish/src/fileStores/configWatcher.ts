@@ -0,0 +1,77 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import fs from 'fs'
+import path from 'path'
+import { Logger } from '../logger'
+import { ErrorUtils, SetTimeoutToken } from '../utils'
+import { Config } from './config'
+
+const DEBOUNCE_MS = 500
+
+/**
+ * Watches the config file and calls `onChange` after it has been written to.
+ * Editors often replace the file instead of writing to it, so the directory
+ * is watched rather than the file itself.
+ */
+export class ConfigWatcher {
+  readonly config: Config
+  readonly logger: Logger
+  readonly onChange: () => Promise<void> | void
+
+  private watcher: fs.FSWatcher | null = null
+  private timeout: SetTimeoutToken | null = null
+
+  constructor(options: {
+    config: Config
+    logger: Logger
+    onChange: () => Promise<void> | void
+  }) {
+    this.config = options.config
+    this.logger = options.logger.withTag('configwatcher')
+    this.onChange = options.onChange
+  }
+
+  get started(): boolean {
+    return this.watcher !== null
+  }
+
+  start(): void {
+    if (this.watcher) {
+      return
+    }
+
+    const configPath = this.config.storage.configPath
+
+    try {
+      this.watcher = fs.watch(path.dirname(configPath), (_, filename) => {
+        if (filename === path.basename(configPath)) {
+          this.schedule()
+        }
+      })
+    } catch (e: unknown) {
+      this.logger.debug(`Could not watch ${configPath}: ${ErrorUtils.renderError(e)}`)
+    }
+  }
+
+  stop(): void {
+    if (this.timeout) {
+      clearTimeout(this.timeout)
+      this.timeout = null
+    }
+
+    this.watcher?.close()
+    this.watcher = null
+  }
+
+  private schedule(): void {
+    if (this.timeout) {
+      clearTimeout(this.timeout)
+    }
+
+    this.timeout = setTimeout(() => {
+      this.timeout = null
+      void this.onChange()
+    }, DEBOUNCE_MS)
+  }
+}
@@ -2,6 +2,11 @@
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 export * from './config'
+export * from './configValidator'
+export * from './configWatcher'
 export * from './fileStore'
 export * from './internal'
 export * from './hosts'
+export * from './memPool'
+export * from './metricsHistory'
+export * from './profiles'
@@ -6,12 +6,18 @@ import { KeyStore } from './keyStore'
 
 export type InternalOptions = {
   isFirstRun: boolean
+  /**
+   * Set to false while the node runs and back to true when it shuts down, so a
+   * crash can be detected the next time it starts
+   */
+  cleanShutdown: boolean
   networkIdentity: string
   telemetryNodeId: string
 }
 
 export const InternalOptionsDefaults: InternalOptions = {
   isFirstRun: true,
+  cleanShutdown: true,
   networkIdentity: '',
   telemetryNodeId: '',
 }
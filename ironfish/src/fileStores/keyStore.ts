@@ -1,6 +1,7 @@
 /* This Source Code Form is subject to the terms of the Mozilla Public
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { isEqual } from 'lodash'
 import * as yup from 'yup'
 import { Event } from '../event'
 import { FileSystem } from '../fileSystems'
@@ -76,6 +77,41 @@ export class KeyStore<TSchema extends Record<string, unknown>> {
     }
   }
 
+  /**
+   * Load the file again and emit onConfigChange for every value that changed
+   * @returns the keys whose value changed
+   */
+  async reload(): Promise<Array<keyof TSchema>> {
+    const keys = new Set<keyof TSchema>([
+      ...(Object.keys(this.defaults) as Array<keyof TSchema>),
+      ...this.keysLoaded,
+    ])
+
+    const previous = new Map<keyof TSchema, unknown>()
+    for (const key of keys) {
+      previous.set(key, this.config[key])
+    }
+
+    await this.load()
+
+    for (const key of this.keysLoaded) {
+      keys.add(key)
+    }
+
+    const changed = []
+    for (const key of keys) {
+      if (!isEqual(previous.get(key), this.config[key])) {
+        changed.push(key)
+      }
+    }
+
+    for (const key of changed) {
+      this.onConfigChange.emit(key, this.config[key])
+    }
+
+    return changed
+  }
+
   async save(): Promise<void> {
     const save: PartialRecursive<TSchema> = {}
 
@@ -115,6 +151,13 @@ export class KeyStore<TSchema extends Record<string, unknown>> {
     }
   }
 
+  /**
+   * @returns true if the value comes from the file or an override instead of the defaults
+   */
+  isSet<T extends keyof TSchema>(key: T): boolean {
+    return this.keysLoaded.has(key) || Object.prototype.hasOwnProperty.call(this.overrides, key)
+  }
+
   get<T extends keyof TSchema>(key: T): TSchema[T] {
     return this.config[key]
   }
@@ -0,0 +1,38 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import os from 'os'
+import path from 'path'
+import { v4 as uuid } from 'uuid'
+import { NodeFileProvider } from '../fileSystems'
+import { MemPoolStore } from './memPool'
+
+describe('MemPoolStore', () => {
+  const files = new NodeFileProvider()
+
+  beforeAll(async () => {
+    await files.init()
+  })
+
+  it('loads no transactions without a file', async () => {
+    const store = new MemPoolStore(files, path.join(os.tmpdir(), uuid()))
+    await expect(store.loadTransactions()).resolves.toEqual([])
+  })
+
+  it('saves and loads transactions', async () => {
+    const store = new MemPoolStore(files, path.join(os.tmpdir(), uuid()))
+    const transactions = [Buffer.from('aabb', 'hex'), Buffer.from('ccdd', 'hex')]
+
+    await store.saveTransactions(transactions)
+    await expect(store.loadTransactions()).resolves.toEqual(transactions)
+  })
+
+  it('ignores a corrupt file', async () => {
+    const store = new MemPoolStore(files, path.join(os.tmpdir(), uuid()))
+
+    await files.mkdir(store.dataDir, { recursive: true })
+    await files.writeFile(store.configPath, '{ not json')
+
+    await expect(store.loadTransactions()).resolves.toEqual([])
+  })
+})
@@ -0,0 +1,51 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { FileSystem } from '../fileSystems'
+import { createRootLogger, Logger } from '../logger'
+import { ParseJsonError } from '../utils/json'
+import { FileStore } from './fileStore'
+
+export type MemPoolFile = {
+  /**
+   * The serialized transactions in hex
+   */
+  transactions: string[]
+}
+
+export const MEMPOOL_FILE_NAME = 'mempool.json'
+
+/**
+ * Persists the transactions in the mem pool when the node shuts down so they
+ * can be added back when it starts again
+ */
+export class MemPoolStore extends FileStore<MemPoolFile> {
+  logger: Logger
+
+  constructor(files: FileSystem, dataDir: string) {
+    super(files, MEMPOOL_FILE_NAME, dataDir)
+    this.logger = createRootLogger()
+  }
+
+  async loadTransactions(): Promise<Buffer[]> {
+    let data = null
+
+    try {
+      data = await this.load()
+    } catch (e) {
+      if (e instanceof ParseJsonError) {
+        this.logger.debug(`Error: Could not parse JSON at ${this.configPath}, ignoring file.`)
+        return []
+      }
+
+      throw e
+    }
+
+    const transactions = data?.transactions ?? []
+    return transactions.filter((t): t is string => !!t).map((t) => Buffer.from(t, 'hex'))
+  }
+
+  async saveTransactions(transactions: Buffer[]): Promise<void> {
+    await this.save({ transactions: transactions.map((t) => t.toString('hex')) })
+  }
+}
@@ -0,0 +1,51 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { FileSystem } from '../fileSystems'
+import { createRootLogger, Logger } from '../logger'
+import { ParseJsonError } from '../utils/json'
+import { KeyStore } from './keyStore'
+
+export type MetricsHistorySample = {
+  timestamp: number
+  blockAddTime: number
+  headSequence: number
+  peers: number
+  memPoolSize: number
+  heapUsed: number
+  hashRate: number
+}
+
+export type MetricsHistoryOptions = {
+  samples: MetricsHistorySample[]
+}
+
+export const MetricsHistoryOptionsDefaults: MetricsHistoryOptions = {
+  samples: [],
+}
+
+export const METRICS_HISTORY_FILE_NAME = 'metrics.json'
+
+export class MetricsHistoryStore extends KeyStore<MetricsHistoryOptions> {
+  logger: Logger
+
+  constructor(files: FileSystem, dataDir: string) {
+    super(files, METRICS_HISTORY_FILE_NAME, MetricsHistoryOptionsDefaults, dataDir)
+    this.logger = createRootLogger()
+  }
+
+  async load(): Promise<void> {
+    try {
+      await super.load()
+    } catch (e) {
+      if (e instanceof ParseJsonError) {
+        this.logger.debug(
+          `Error: Could not parse JSON at ${this.storage.configPath}, overwriting file.`,
+        )
+        await super.save()
+      } else {
+        throw e
+      }
+    }
+  }
+}
@@ -0,0 +1,28 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { NodeFileProvider } from '../fileSystems'
+import { Profiles } from './profiles'
+
+describe('Profiles', () => {
+  const files = new NodeFileProvider()
+
+  beforeAll(async () => {
+    await files.init()
+  })
+
+  it('resolves a profile data dir under the data dir', () => {
+    expect(Profiles.getDataDir(files, '/tmp/ironfish', 'testnet-dev')).toEqual(
+      '/tmp/ironfish/profiles/testnet-dev',
+    )
+  })
+
+  it('rejects names that could escape the profiles dir', () => {
+    expect(Profiles.isValidName('miner_1')).toBe(true)
+    expect(Profiles.isValidName('../miner')).toBe(false)
+    expect(Profiles.isValidName('')).toBe(false)
+    expect(() => Profiles.getDataDir(files, '/tmp/ironfish', 'a/b')).toThrow(
+      'Invalid profile name',
+    )
+  })
+})
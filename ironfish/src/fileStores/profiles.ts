@@ -0,0 +1,33 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { FileSystem } from '../fileSystems'
+
+export const PROFILES_DIR_NAME = 'profiles'
+
+const PROFILE_NAME_REGEX = /^[a-zA-Z0-9_-]+$/
+
+/**
+ * Profiles are named sets of node files, such as mainnet-archive or miner,
+ * that each live in their own data dir under `<dataDir>/profiles/<name>` so
+ * they have their own config, databases, and ports.
+ */
+export const Profiles = {
+  isValidName(name: string): boolean {
+    return PROFILE_NAME_REGEX.test(name)
+  },
+
+  getProfilesDir(files: FileSystem, dataDir: string): string {
+    return files.join(files.resolve(dataDir), PROFILES_DIR_NAME)
+  },
+
+  getDataDir(files: FileSystem, dataDir: string, profile: string): string {
+    if (!Profiles.isValidName(profile)) {
+      throw new Error(
+        `Invalid profile name ${profile}, use only letters, numbers, dashes, and underscores`,
+      )
+    }
+
+    return files.join(Profiles.getProfilesDir(files, dataDir), profile)
+  },
+}
@@ -4,25 +4,36 @@
 export * from './account'
 export * from './assert'
 export * from './blockchain'
+export * from './bridge'
 export * from './consensus'
+export * from './diskMonitor'
 export * from './chainProcessor'
+export * from './clockMonitor'
+export * from './cluster'
 export * from './event'
 export * from './fileStores'
 export * from './fileSystems'
 export * from './genesis'
 export * from './sdk'
 export * from './logger'
+export * from './lruCache'
+export * from './memoryBudget'
 export * from './node'
 export * from './rpc'
+export * from './rosetta'
+export * from './s3Client'
 export * from './serde'
 export * from './strategy'
 export * from './storage'
 export * from './mining'
 export * from './metrics'
+export * from './migrations'
 export * from './telemetry'
 export * from './utils'
 export * from './network'
+export * from './networkDefinitions'
 export * from './package'
+export * from './payments'
 export * from './platform'
 export * from './primitives'
 export * from './webApi'
@@ -0,0 +1,89 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { Event } from '../event'
+import { createRootLogger } from '../logger'
+import { ColdStorageMonitor } from './coldStorageMonitor'
+import { FeedEntry, ViewKeyScanner } from './viewKeyScanner'
+
+describe('ColdStorageMonitor', () => {
+  const createMonitor = (webhook: string | null = null) => {
+    const scanner = {
+      onFeedEntry: new Event<[entry: FeedEntry]>(),
+      listViewKeys: () => [{ id: 'cold' }, { id: 'hot' }],
+    } as unknown as ViewKeyScanner
+
+    const post = jest.fn().mockResolvedValue(undefined)
+    const monitor = new ColdStorageMonitor({
+      scanner,
+      viewKeyIds: ['cold'],
+      webhook,
+      post,
+      logger: createRootLogger().withTag('test'),
+    })
+
+    const onSpend = jest.fn()
+    monitor.onSpend.on(onSpend)
+    monitor.start()
+
+    return { scanner, monitor, post, onSpend }
+  }
+
+  const createEntry = (id: string, sent: boolean, connected = true): FeedEntry => ({
+    id,
+    index: 0,
+    connected,
+    sequence: 10,
+    blockHash: Buffer.alloc(32, 1),
+    transactionHash: Buffer.alloc(32, 2),
+    notes: [
+      { sent, value: BigInt(5), memo: '' },
+      { sent, value: BigInt(7), memo: '' },
+    ],
+  })
+
+  it('alerts on notes sent by a cold storage key', () => {
+    const { scanner, onSpend } = createMonitor()
+
+    scanner.onFeedEntry.emit(createEntry('cold', true))
+
+    expect(onSpend).toHaveBeenCalledTimes(1)
+    expect(onSpend).toHaveBeenCalledWith({
+      viewKeyId: 'cold',
+      sequence: 10,
+      blockHash: Buffer.alloc(32, 1).toString('hex'),
+      transactionHash: Buffer.alloc(32, 2).toString('hex'),
+      sent: BigInt(12),
+    })
+  })
+
+  it('ignores received notes, other keys and disconnected blocks', () => {
+    const { scanner, onSpend } = createMonitor()
+
+    scanner.onFeedEntry.emit(createEntry('cold', false))
+    scanner.onFeedEntry.emit(createEntry('hot', true))
+    scanner.onFeedEntry.emit(createEntry('cold', true, false))
+
+    expect(onSpend).not.toHaveBeenCalled()
+  })
+
+  it('posts alerts to the webhook', () => {
+    const { scanner, post } = createMonitor('http://localhost/alert')
+
+    scanner.onFeedEntry.emit(createEntry('cold', true))
+
+    expect(post).toHaveBeenCalledWith(
+      'http://localhost/alert',
+      expect.objectContaining({ event: 'cold_storage_spend', viewKeyId: 'cold', sent: '12' }),
+    )
+  })
+
+  it('stops alerting when stopped', () => {
+    const { scanner, monitor, onSpend } = createMonitor()
+
+    monitor.stop()
+    scanner.onFeedEntry.emit(createEntry('cold', true))
+
+    expect(onSpend).not.toHaveBeenCalled()
+  })
+})
@@ -0,0 +1,127 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import Axios from 'axios'
+import { Event } from '../event'
+import { createRootLogger, Logger } from '../logger'
+import { ErrorUtils } from '../utils'
+import { FeedEntry, ViewKeyScanner } from './viewKeyScanner'
+
+export type ColdStorageSpend = {
+  viewKeyId: string
+  sequence: number
+  blockHash: string
+  transactionHash: string
+  /**
+   * The value of the notes the cold storage key sent, in ore
+   */
+  sent: bigint
+}
+
+/**
+ * Raises an alert when a cold storage key spends. Cold storage keys are view
+ * keys registered with the {@link ViewKeyScanner} that should never send
+ * anything, so a spend means their spending key is in someone's hands.
+ *
+ * Spends are seen through the notes a transaction encrypts for its sender with
+ * the outgoing view key. Software that sends with a different outgoing view key
+ * is not detected, so this is an early warning and not a guarantee.
+ */
+export class ColdStorageMonitor {
+  readonly logger: Logger
+  readonly scanner: ViewKeyScanner
+  readonly viewKeyIds: Set<string>
+  readonly webhook: string | null
+
+  readonly onSpend = new Event<[spend: ColdStorageSpend]>()
+
+  private started = false
+  private readonly post: (url: string, body: unknown) => Promise<unknown>
+
+  constructor(options: {
+    scanner: ViewKeyScanner
+    viewKeyIds: string[]
+    webhook?: string | null
+    logger?: Logger
+    post?: (url: string, body: unknown) => Promise<unknown>
+  }) {
+    this.logger = (options.logger ?? createRootLogger()).withTag('coldstorage')
+    this.scanner = options.scanner
+    this.viewKeyIds = new Set(options.viewKeyIds)
+    this.webhook = options.webhook || null
+    this.post = options.post ?? ((url, body) => Axios.post(url, body))
+  }
+
+  start(): void {
+    if (this.started || !this.viewKeyIds.size) {
+      return
+    }
+    this.started = true
+
+    const registered = new Set(this.scanner.listViewKeys().map((k) => k.id))
+    for (const id of this.viewKeyIds) {
+      if (!registered.has(id)) {
+        this.logger.warn(`Cold storage view key ${id} is not registered with the scanner`)
+      }
+    }
+
+    this.scanner.onFeedEntry.on(this.onFeedEntry)
+  }
+
+  stop(): void {
+    if (!this.started) {
+      return
+    }
+    this.started = false
+
+    this.scanner.onFeedEntry.off(this.onFeedEntry)
+  }
+
+  private onFeedEntry = (entry: FeedEntry): void => {
+    // Disconnected blocks were already alerted on when they were connected
+    if (!entry.connected || !this.viewKeyIds.has(entry.id)) {
+      return
+    }
+
+    const sentNotes = entry.notes.filter((n) => n.sent)
+    if (!sentNotes.length) {
+      return
+    }
+
+    const spend = {
+      viewKeyId: entry.id,
+      sequence: entry.sequence,
+      blockHash: entry.blockHash.toString('hex'),
+      transactionHash: entry.transactionHash.toString('hex'),
+      sent: sentNotes.reduce((total, n) => total + n.value, BigInt(0)),
+    }
+
+    this.logger.error(
+      `Cold storage view key ${spend.viewKeyId} sent ${spend.sent} ore in transaction` +
+        ` ${spend.transactionHash} at block ${spend.sequence}. Its spending key may be` +
+        ` compromised.`,
+    )
+
+    this.onSpend.emit(spend)
+    this.sendWebhook(spend)
+  }
+
+  private sendWebhook(spend: ColdStorageSpend): void {
+    if (!this.webhook) {
+      return
+    }
+
+    const body = {
+      event: 'cold_storage_spend',
+      viewKeyId: spend.viewKeyId,
+      sequence: spend.sequence,
+      blockHash: spend.blockHash,
+      transactionHash: spend.transactionHash,
+      sent: spend.sent.toString(),
+    }
+
+    this.post(this.webhook, body).catch((e) => {
+      this.logger.error(`Error sending cold storage alert: ${ErrorUtils.renderError(e)}`)
+    })
+  }
+}
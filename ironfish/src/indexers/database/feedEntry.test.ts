@@ -0,0 +1,25 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { FeedEntryValue, FeedEntryValueEncoding } from './feedEntry'
+
+describe('FeedEntryValueEncoding', () => {
+  it('serializes the object into a buffer and deserializes to the original object', () => {
+    const encoder = new FeedEntryValueEncoding()
+
+    const value: FeedEntryValue = {
+      connected: true,
+      sequence: 123,
+      blockHash: Buffer.alloc(32, 1),
+      transactionHash: Buffer.alloc(32, 2),
+      notes: [
+        { sent: false, value: BigInt(100000000), memo: 'deposit' },
+        { sent: true, value: BigInt(5), memo: '' },
+      ],
+    }
+
+    const buffer = encoder.serialize(value)
+    const deserializedValue = encoder.deserialize(buffer)
+    expect(deserializedValue).toEqual(value)
+  })
+})
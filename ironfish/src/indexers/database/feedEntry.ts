@@ -0,0 +1,88 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import bufio from 'bufio'
+import { IDatabaseEncoding } from '../../storage'
+import { BigIntUtils } from '../../utils/bigint'
+
+export interface FeedNote {
+  /**
+   * True if the note was decrypted with the outgoing view key, so it was sent
+   * by the key instead of received
+   */
+  sent: boolean
+  value: bigint
+  memo: string
+}
+
+/**
+ * A transaction in a block that was connected to or disconnected from the main
+ * chain. Disconnected blocks get new entries instead of removing the old ones so
+ * readers following the feed see the reorg.
+ */
+export interface FeedEntryValue {
+  connected: boolean
+  sequence: number
+  blockHash: Buffer
+  transactionHash: Buffer
+  notes: FeedNote[]
+}
+
+export class FeedEntryValueEncoding implements IDatabaseEncoding<FeedEntryValue> {
+  serialize(value: FeedEntryValue): Buffer {
+    const bw = bufio.write(this.getSize(value))
+
+    bw.writeU8(value.connected ? 1 : 0)
+    bw.writeU32(value.sequence)
+    bw.writeHash(value.blockHash)
+    bw.writeHash(value.transactionHash)
+
+    bw.writeU32(value.notes.length)
+    for (const note of value.notes) {
+      bw.writeU8(note.sent ? 1 : 0)
+      bw.writeBytes(BigIntUtils.toBytesLE(note.value, 8))
+      bw.writeVarString(note.memo, 'utf8')
+    }
+
+    return bw.render()
+  }
+
+  deserialize(buffer: Buffer): FeedEntryValue {
+    const reader = bufio.read(buffer, true)
+    const connected = Boolean(reader.readU8())
+    const sequence = reader.readU32()
+    const blockHash = reader.readHash()
+    const transactionHash = reader.readHash()
+
+    const notes = []
+    const notesLength = reader.readU32()
+    for (let i = 0; i < notesLength; i++) {
+      const sent = Boolean(reader.readU8())
+      const value = BigIntUtils.fromBytesLE(reader.readBytes(8))
+      const memo = reader.readVarString('utf8')
+      notes.push({ sent, value, memo })
+    }
+
+    return {
+      connected,
+      sequence,
+      blockHash,
+      transactionHash,
+      notes,
+    }
+  }
+
+  getSize(value: FeedEntryValue): number {
+    let size = 1
+    size += 4
+    size += 32
+    size += 32
+    size += 4
+    for (const note of value.notes) {
+      size += 1
+      size += 8
+      size += bufio.sizeVarString(note.memo, 'utf8')
+    }
+    return size
+  }
+}
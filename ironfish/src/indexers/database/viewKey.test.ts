@@ -0,0 +1,38 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { ViewKeyValue, ViewKeyValueEncoding } from './viewKey'
+
+describe('ViewKeyValueEncoding', () => {
+  it('serializes the object into a buffer and deserializes to the original object', () => {
+    const encoder = new ViewKeyValueEncoding()
+
+    const value: ViewKeyValue = {
+      incomingViewKey: Buffer.alloc(32, 1).toString('hex'),
+      outgoingViewKey: Buffer.alloc(32, 2).toString('hex'),
+      createdAt: 1650000000000,
+      feedLength: 12,
+      catchUpSequence: 5,
+    }
+
+    const buffer = encoder.serialize(value)
+    const deserializedValue = encoder.deserialize(buffer)
+    expect(deserializedValue).toEqual(value)
+  })
+
+  it('serializes a key that has caught up', () => {
+    const encoder = new ViewKeyValueEncoding()
+
+    const value: ViewKeyValue = {
+      incomingViewKey: Buffer.alloc(32, 1).toString('hex'),
+      outgoingViewKey: Buffer.alloc(32, 2).toString('hex'),
+      createdAt: 1650000000000,
+      feedLength: 0,
+      catchUpSequence: null,
+    }
+
+    const buffer = encoder.serialize(value)
+    const deserializedValue = encoder.deserialize(buffer)
+    expect(deserializedValue).toEqual(value)
+  })
+})
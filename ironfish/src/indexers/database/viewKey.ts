@@ -0,0 +1,69 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import bufio from 'bufio'
+import { ACCOUNT_KEY_LENGTH } from '../../account'
+import { IDatabaseEncoding } from '../../storage'
+
+export interface ViewKeyValue {
+  incomingViewKey: string
+  outgoingViewKey: string
+  createdAt: number
+  /**
+   * How many entries are in the feed, the next entry is stored at this index
+   */
+  feedLength: number
+  /**
+   * The next sequence to scan blocks from before the key catches up to the
+   * scanner head, null once it has
+   */
+  catchUpSequence: number | null
+}
+
+export class ViewKeyValueEncoding implements IDatabaseEncoding<ViewKeyValue> {
+  serialize(value: ViewKeyValue): Buffer {
+    const bw = bufio.write(this.getSize(value))
+
+    bw.writeBytes(Buffer.from(value.incomingViewKey, 'hex'))
+    bw.writeBytes(Buffer.from(value.outgoingViewKey, 'hex'))
+    bw.writeU64(value.createdAt)
+    bw.writeU32(value.feedLength)
+
+    if (value.catchUpSequence !== null) {
+      bw.writeU8(1)
+      bw.writeU32(value.catchUpSequence)
+    } else {
+      bw.writeU8(0)
+    }
+
+    return bw.render()
+  }
+
+  deserialize(buffer: Buffer): ViewKeyValue {
+    const reader = bufio.read(buffer, true)
+    const incomingViewKey = reader.readBytes(ACCOUNT_KEY_LENGTH).toString('hex')
+    const outgoingViewKey = reader.readBytes(ACCOUNT_KEY_LENGTH).toString('hex')
+    const createdAt = reader.readU64()
+    const feedLength = reader.readU32()
+    const catchUpSequence = reader.readU8() ? reader.readU32() : null
+
+    return {
+      incomingViewKey,
+      outgoingViewKey,
+      createdAt,
+      feedLength,
+      catchUpSequence,
+    }
+  }
+
+  getSize(value: ViewKeyValue): number {
+    let size = ACCOUNT_KEY_LENGTH + ACCOUNT_KEY_LENGTH
+    size += 8
+    size += 4
+    size += 1
+    if (value.catchUpSequence !== null) {
+      size += 4
+    }
+    return size
+  }
+}
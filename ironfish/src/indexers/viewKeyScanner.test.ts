@@ -0,0 +1,90 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { createNodeTest, useAccountFixture, useMinerBlockFixture } from '../testUtilities'
+import { AsyncUtils } from '../utils'
+
+describe('ViewKeyScanner', () => {
+  const nodeTest = createNodeTest()
+
+  afterEach(async () => {
+    await nodeTest.node.viewKeyScanner.close()
+  })
+
+  it('adds received notes to the feed of a view key', async () => {
+    const { node, chain, strategy } = nodeTest
+    const scanner = node.viewKeyScanner
+    await scanner.open()
+
+    const account = await useAccountFixture(node.accounts, 'a')
+    await scanner.addViewKey('user-a', account.incomingViewKey, account.outgoingViewKey)
+
+    const block = await useMinerBlockFixture(chain, undefined, account)
+    await expect(chain).toAddBlock(block)
+
+    await scanner.updateHead()
+
+    const feed = await AsyncUtils.materialize(scanner.getFeed('user-a'))
+    expect(feed).toHaveLength(1)
+    expect(feed[0]).toMatchObject({
+      id: 'user-a',
+      index: 0,
+      connected: true,
+      sequence: block.header.sequence,
+      blockHash: block.header.hash,
+      notes: [
+        {
+          sent: false,
+          value: BigInt(strategy.miningReward(block.header.sequence)),
+        },
+      ],
+    })
+
+    expect(scanner.head).toEqualHash(block.header.hash)
+  })
+
+  it('catches up a key added with a start sequence', async () => {
+    const { node, chain } = nodeTest
+    const scanner = node.viewKeyScanner
+    await scanner.open()
+
+    const account = await useAccountFixture(node.accounts, 'a')
+    const block = await useMinerBlockFixture(chain, undefined, account)
+    await expect(chain).toAddBlock(block)
+
+    await scanner.updateHead()
+    await scanner.addViewKey('user-a', account.incomingViewKey, account.outgoingViewKey, 1)
+
+    // The key was added after the block was scanned
+    await scanner.updateHead()
+    expect(scanner.getViewKey('user-a')?.feedLength).toEqual(0)
+
+    await scanner.catchUp()
+
+    expect(scanner.getViewKey('user-a')).toMatchObject({
+      feedLength: 1,
+      catchUpSequence: null,
+    })
+  })
+
+  it('removes a view key and its feed', async () => {
+    const { node, chain } = nodeTest
+    const scanner = node.viewKeyScanner
+    await scanner.open()
+
+    const account = await useAccountFixture(node.accounts, 'a')
+    await scanner.addViewKey('user-a', account.incomingViewKey, account.outgoingViewKey)
+
+    const block = await useMinerBlockFixture(chain, undefined, account)
+    await expect(chain).toAddBlock(block)
+    await scanner.updateHead()
+
+    await scanner.removeViewKey('user-a')
+
+    expect(scanner.getViewKey('user-a')).toBeNull()
+    expect(scanner.listViewKeys()).toHaveLength(0)
+    await expect(AsyncUtils.materialize(scanner.getFeed('user-a'))).rejects.toThrow(
+      'No view key is registered with the id user-a',
+    )
+  })
+})
@@ -0,0 +1,501 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { ACCOUNT_KEY_LENGTH } from '../account'
+import { Assert } from '../assert'
+import { Blockchain } from '../blockchain'
+import { ChainProcessor } from '../chainProcessor'
+import { Event } from '../event'
+import { FileSystem } from '../fileSystems'
+import { createRootLogger, Logger } from '../logger'
+import { Mutex } from '../mutex'
+import { Block, BlockHeader } from '../primitives'
+import { Note } from '../primitives/note'
+import { Transaction } from '../primitives/transaction'
+import { IDatabase, IDatabaseStore, IDatabaseTransaction, StringEncoding } from '../storage'
+import { createDB } from '../storage/utils'
+import { SetTimeoutToken } from '../utils'
+import { WorkerPool } from '../workerPool'
+import { DecryptNoteOptions } from '../workerPool/tasks/decryptNotes'
+import { FeedEntryValue, FeedEntryValueEncoding, FeedNote } from './database/feedEntry'
+import { MetaValue, MetaValueEncoding, MinedBlocksDBMeta } from './database/meta'
+import { ViewKeyValue, ViewKeyValueEncoding } from './database/viewKey'
+
+const DATABASE_VERSION = 1
+
+const DECRYPT_BATCH_SIZE = 20
+
+// How many blocks a newly added key scans each event loop while catching up
+const CATCH_UP_BATCH_SIZE = 100
+
+// Nullifiers are only computed for notes with an index, which the scanner never
+// passes, so the spending key a view key doesn't have is never used
+const NO_SPENDING_KEY = Buffer.alloc(ACCOUNT_KEY_LENGTH).toString('hex')
+
+export type FeedEntry = FeedEntryValue & { id: string; index: number }
+
+export type ViewKey = {
+  id: string
+  incomingViewKey: string
+  outgoingViewKey: string
+  createdAt: number
+  feedLength: number
+  catchUpSequence: number | null
+}
+
+export class ViewKeyNotFoundError extends Error {
+  constructor(id: string) {
+    super(`No view key is registered with the id ${id}`)
+  }
+}
+
+/**
+ * Keeps a feed of decrypted transactions for each registered pair of view keys.
+ * Unlike accounts it never holds spending keys, so custodial platforms can scan
+ * for many users without a wallet account for each of them.
+ */
+export class ViewKeyScanner {
+  protected meta: IDatabaseStore<{
+    key: keyof MinedBlocksDBMeta
+    value: MetaValue
+  }>
+  protected viewKeys: IDatabaseStore<{ key: string; value: ViewKeyValue }>
+  protected feed: IDatabaseStore<{ key: string; value: FeedEntryValue }>
+
+  protected files: FileSystem
+  protected database: IDatabase
+  protected location: string
+  protected readonly logger: Logger
+  protected readonly workerPool: WorkerPool
+  protected isOpen: boolean
+  protected isStarted: boolean
+  protected eventLoopTimeout: SetTimeoutToken | null = null
+  protected chain: Blockchain
+  protected chainProcessor: ChainProcessor
+
+  /**
+   * Every registered view key, loaded from the database on open
+   */
+  protected keys = new Map<string, ViewKeyValue>()
+
+  // Scanning blocks, catching up and changing keys all update feeds
+  private readonly mutex = new Mutex()
+
+  readonly onFeedEntry = new Event<[entry: FeedEntry]>()
+
+  constructor({
+    files,
+    location,
+    chain,
+    workerPool,
+    logger = createRootLogger(),
+  }: {
+    files: FileSystem
+    location: string
+    chain: Blockchain
+    workerPool: WorkerPool
+    logger?: Logger
+  }) {
+    this.files = files
+    this.location = location
+    this.database = createDB({ location })
+    this.chain = chain
+    this.workerPool = workerPool
+    this.logger = logger.withTag('viewkeyscanner')
+    this.isOpen = false
+    this.isStarted = false
+
+    this.meta = this.database.addStore<{
+      key: keyof MinedBlocksDBMeta
+      value: MinedBlocksDBMeta[keyof MinedBlocksDBMeta]
+    }>({
+      name: 'meta',
+      keyEncoding: new StringEncoding<keyof MinedBlocksDBMeta>(),
+      valueEncoding: new MetaValueEncoding(),
+    })
+
+    this.viewKeys = this.database.addStore<{ key: string; value: ViewKeyValue }>({
+      name: 'viewKeys',
+      keyEncoding: new StringEncoding(),
+      valueEncoding: new ViewKeyValueEncoding(),
+    })
+
+    this.feed = this.database.addStore<{ key: string; value: FeedEntryValue }>({
+      name: 'feed',
+      keyEncoding: new StringEncoding(),
+      valueEncoding: new FeedEntryValueEncoding(),
+    })
+
+    this.chainProcessor = new ChainProcessor({ logger, chain, head: null })
+
+    this.chainProcessor.onAdd.on(async (header) => {
+      const block = await this.chain.getBlock(header)
+      Assert.isNotNull(block)
+
+      const keys = [...this.keys].filter(([, key]) => key.catchUpSequence === null)
+      await this.scanBlock(block, true, keys, (tx) => this.updateHeadHash(header.hash, tx))
+    })
+
+    this.chainProcessor.onRemove.on(async (header) => {
+      const block = await this.chain.getBlock(header)
+      Assert.isNotNull(block)
+
+      const keys = [...this.keys].filter(([, key]) => key.catchUpSequence === null)
+      await this.scanBlock(block, false, keys, (tx) =>
+        this.updateHeadHash(header.previousBlockHash, tx),
+      )
+    })
+  }
+
+  async open(
+    options: { upgrade?: boolean; load?: boolean } = { upgrade: true, load: true },
+  ): Promise<void> {
+    if (this.isOpen) {
+      return
+    }
+
+    this.isOpen = true
+    await this.openDB(options)
+
+    if (options.load) {
+      await this.load()
+    }
+  }
+
+  async close(): Promise<void> {
+    if (!this.isOpen) {
+      return
+    }
+
+    this.isOpen = false
+    await this.closeDB()
+  }
+
+  async openDB(options: { upgrade?: boolean } = { upgrade: true }): Promise<void> {
+    await this.files.mkdir(this.location, { recursive: true })
+    await this.database.open()
+
+    if (options.upgrade) {
+      await this.database.upgrade(DATABASE_VERSION)
+    }
+  }
+
+  async closeDB(): Promise<void> {
+    await this.database.close()
+  }
+
+  async load(): Promise<void> {
+    const headHash = await this.meta.get('headHash')
+    this.chainProcessor.hash = headHash ? Buffer.from(headHash, 'hex') : null
+
+    this.keys.clear()
+    for await (const [id, value] of this.viewKeys.getAllIter()) {
+      this.keys.set(id, value)
+    }
+  }
+
+  async start(): Promise<void> {
+    if (this.isStarted) {
+      return
+    }
+    this.isStarted = true
+
+    if (this.chainProcessor.hash) {
+      const hasHeadBlock = await this.chain.hasBlock(this.chainProcessor.hash)
+
+      if (!hasHeadBlock) {
+        this.logger.error(
+          `Rescanning view keys because the scanner head was not found in chain: ${this.chainProcessor.hash.toString(
+            'hex',
+          )}`,
+        )
+
+        await this.reset()
+      }
+    }
+
+    void this.eventLoop()
+  }
+
+  async stop(): Promise<void> {
+    if (!this.isStarted) {
+      return
+    }
+    this.isStarted = false
+
+    if (this.eventLoopTimeout) {
+      clearTimeout(this.eventLoopTimeout)
+    }
+
+    // Wait for a block being scanned to finish
+    await this.mutex.dispatch(() => undefined)
+
+    if (this.database.isOpen) {
+      await this.updateHeadHash(this.chainProcessor.hash)
+    }
+  }
+
+  async eventLoop(): Promise<void> {
+    if (!this.isStarted) {
+      return
+    }
+
+    await this.updateHead()
+    await this.catchUp()
+
+    if (this.isStarted) {
+      this.eventLoopTimeout = setTimeout(() => void this.eventLoop(), 1000)
+    }
+  }
+
+  async updateHead(): Promise<void> {
+    await this.mutex.dispatch(async () => {
+      const { hashChanged } = await this.chainProcessor.update()
+
+      if (hashChanged) {
+        this.logger.debug(
+          `Updated ViewKeyScanner Head: ${String(this.chainProcessor.hash?.toString('hex'))}`,
+        )
+      }
+    })
+  }
+
+  /**
+   * Scans the main chain up to the scanner head for keys that were added with
+   * a start sequence, a batch of blocks at a time
+   */
+  async catchUp(): Promise<void> {
+    await this.mutex.dispatch(async () => {
+      const catchingUp = [...this.keys].filter(([, key]) => key.catchUpSequence !== null)
+      if (!catchingUp.length || !this.chainProcessor.hash) {
+        return
+      }
+
+      const scannerHead = await this.chain.getHeader(this.chainProcessor.hash)
+      if (!scannerHead) {
+        return
+      }
+
+      for (const [id, key] of catchingUp) {
+        Assert.isNotNull(key.catchUpSequence)
+        const start = key.catchUpSequence
+        const end = Math.min(start + CATCH_UP_BATCH_SIZE, scannerHead.sequence + 1)
+
+        for (let sequence = start; sequence < end; sequence++) {
+          const header = await this.chain.getHeaderAtSequence(sequence)
+          const block = header ? await this.chain.getBlock(header) : null
+          if (!block) {
+            break
+          }
+
+          // Progress is saved with the entries so a crash never scans a block twice
+          await this.scanBlock(block, true, [[id, key]], (tx) => {
+            const next = sequence + 1
+            key.catchUpSequence = next > scannerHead.sequence ? null : next
+            return this.viewKeys.put(id, key, tx)
+          })
+
+          if (key.catchUpSequence === null) {
+            this.logger.debug(`View key ${id} caught up to ${scannerHead.sequence}`)
+            break
+          }
+        }
+      }
+    })
+  }
+
+  /**
+   * The hash of the last block scanned for every key that has caught up
+   */
+  get head(): Buffer | null {
+    return this.chainProcessor.hash
+  }
+
+  async reset(): Promise<void> {
+    await this.mutex.dispatch(async () => {
+      this.chainProcessor.hash = null
+      await this.updateHeadHash(null)
+    })
+  }
+
+  async updateHeadHash(headHash: Buffer | null, tx?: IDatabaseTransaction): Promise<void> {
+    const hashString = headHash && headHash.toString('hex')
+    await this.meta.put('headHash', hashString, tx)
+  }
+
+  /**
+   * Registers a pair of view keys. The feed starts at the scanner head, or at
+   * `startSequence` if it's given, in which case earlier blocks are scanned in
+   * the background.
+   */
+  async addViewKey(
+    id: string,
+    incomingViewKey: string,
+    outgoingViewKey: string,
+    startSequence?: number,
+  ): Promise<ViewKey> {
+    return this.mutex.dispatch(async () => {
+      if (this.keys.has(id)) {
+        throw new Error(`A view key is already registered with the id ${id}`)
+      }
+
+      const value: ViewKeyValue = {
+        incomingViewKey,
+        outgoingViewKey,
+        createdAt: Date.now(),
+        feedLength: 0,
+        catchUpSequence: startSequence ?? null,
+      }
+
+      await this.viewKeys.put(id, value)
+      this.keys.set(id, value)
+
+      return { id, ...value }
+    })
+  }
+
+  async removeViewKey(id: string): Promise<void> {
+    await this.mutex.dispatch(async () => {
+      const key = this.keys.get(id)
+      if (!key) {
+        throw new ViewKeyNotFoundError(id)
+      }
+
+      this.keys.delete(id)
+
+      await this.database.transaction(async (tx) => {
+        for (let index = 0; index < key.feedLength; index++) {
+          await this.feed.del(this.getFeedKey(id, index), tx)
+        }
+
+        await this.viewKeys.del(id, tx)
+      })
+    })
+  }
+
+  getViewKey(id: string): ViewKey | null {
+    const key = this.keys.get(id)
+    return key ? { id, ...key } : null
+  }
+
+  listViewKeys(): ViewKey[] {
+    return [...this.keys].map(([id, key]) => ({ id, ...key }))
+  }
+
+  /**
+   * Yields the entries in a view key's feed starting at `start`
+   */
+  async *getFeed(id: string, start = 0, limit?: number): AsyncGenerator<FeedEntry> {
+    const key = this.keys.get(id)
+    if (!key) {
+      throw new ViewKeyNotFoundError(id)
+    }
+
+    const end = limit !== undefined ? Math.min(start + limit, key.feedLength) : key.feedLength
+
+    for (let index = start; index < end; index++) {
+      const entry = await this.feed.get(this.getFeedKey(id, index))
+      if (entry) {
+        yield { id, index, ...entry }
+      }
+    }
+  }
+
+  private getFeedKey(id: string, index: number): string {
+    return `${id}:${index}`
+  }
+
+  private async scanBlock(
+    block: Block,
+    connected: boolean,
+    keys: [id: string, key: ViewKeyValue][],
+    onScanned: (tx: IDatabaseTransaction) => Promise<void>,
+  ): Promise<void> {
+    const entries: FeedEntry[] = []
+
+    await this.database.transaction(async (tx) => {
+      for (const [id, key] of keys) {
+        for (const transaction of block.transactions) {
+          const notes = await this.decryptTransaction(transaction, key)
+          if (!notes.length) {
+            continue
+          }
+
+          const entry = {
+            id,
+            index: key.feedLength,
+            ...this.createEntry(block.header, transaction, connected, notes),
+          }
+
+          await this.feed.put(this.getFeedKey(id, entry.index), entry, tx)
+          key.feedLength++
+          entries.push(entry)
+        }
+
+        if (entries.some((e) => e.id === id)) {
+          await this.viewKeys.put(id, key, tx)
+        }
+      }
+
+      await onScanned(tx)
+    })
+
+    for (const entry of entries) {
+      this.onFeedEntry.emit(entry)
+    }
+  }
+
+  private createEntry(
+    header: BlockHeader,
+    transaction: Transaction,
+    connected: boolean,
+    notes: FeedNote[],
+  ): FeedEntryValue {
+    return {
+      connected,
+      sequence: header.sequence,
+      blockHash: header.hash,
+      transactionHash: transaction.unsignedHash(),
+      notes,
+    }
+  }
+
+  private async decryptTransaction(
+    transaction: Transaction,
+    key: ViewKeyValue,
+  ): Promise<FeedNote[]> {
+    const notes: FeedNote[] = []
+    let payloads: DecryptNoteOptions[] = []
+
+    const decrypt = async () => {
+      for (const decrypted of await this.workerPool.decryptNotes(payloads)) {
+        if (decrypted) {
+          const note = new Note(decrypted.serializedNote)
+          notes.push({ sent: decrypted.forSpender, value: note.value(), memo: note.memo() })
+        }
+      }
+
+      payloads = []
+    }
+
+    for (const note of transaction.notes()) {
+      payloads.push({
+        serializedNote: note.serialize(),
+        incomingViewKey: key.incomingViewKey,
+        outgoingViewKey: key.outgoingViewKey,
+        spendingKey: NO_SPENDING_KEY,
+        currentNoteIndex: null,
+      })
+
+      if (payloads.length >= DECRYPT_BATCH_SIZE) {
+        await decrypt()
+      }
+    }
+
+    if (payloads.length) {
+      await decrypt()
+    }
+
+    return notes
+  }
+}
@@ -4,7 +4,7 @@
 
 import type { Consola } from 'consola'
 import consola, { LogLevel } from 'consola'
-import { parseLogLevelConfig } from './logLevelParser'
+import { configLevelToLogLevel, parseLogLevelConfig } from './logLevelParser'
 import { ConsoleReporter } from './reporters/console'
 export * from './reporters/intercept'
 
@@ -40,6 +40,16 @@ export const setLogLevelFromConfig = (logLevelConfig: string): void => {
   }
 }
 
+/**
+ * Updates the reporter's log level for a single module at runtime.
+ *
+ * @param tag The module tag to override, or `*` to set the default level
+ * @param level A config log level string like `debug` or `warn`
+ */
+export const setLogLevelForTag = (tag: string, level: string): void => {
+  ConsoleReporterInstance.setLogLevel(tag.toLowerCase(), configLevelToLogLevel(level))
+}
+
 /**
  * @param logToJSON Whether console logs should be in JSON format
  */
@@ -27,7 +27,7 @@ const configToLogLevel: {
  * @throws `level` does not exist as a key of `configToLogLevel`
  * @param level A config log level string
  */
-const configLevelToLogLevel = (level: string): LogLevel => {
+export const configLevelToLogLevel = (level: string): LogLevel => {
   level = level.toLowerCase()
   const configLevel = configToLogLevel[level]
   if (configLevel === undefined) {
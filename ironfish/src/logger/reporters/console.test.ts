@@ -7,7 +7,7 @@
 
 import { LogLevel, logType } from 'consola'
 import { format } from 'date-fns'
-import { ConsoleReporter, getConsoleLogger, loggers } from './console'
+import { ConsoleReporter, getConsoleLogger, logObjToJSON, loggers } from './console'
 
 describe('setLogLevel', () => {
   it('sets defaultMinimumLogLevel when tag is *', () => {
@@ -154,3 +154,26 @@ describe('getConsoleLogger', () => {
     expect(() => getConsoleLogger('test' as unknown as logType)).toThrowError()
   })
 })
+
+describe('logObjToJSON', () => {
+  it('includes the module from the most specific tag', () => {
+    const date = new Date()
+
+    const json = logObjToJSON({
+      args: ['testlog', { peer: 'abc' }],
+      date: date,
+      level: LogLevel.Info,
+      type: 'info',
+      tag: 'ironfishnode:syncer',
+    })
+
+    expect(JSON.parse(json)).toMatchObject({
+      peer: 'abc',
+      level: LogLevel.Info,
+      type: 'info',
+      tag: 'ironfishnode:syncer',
+      module: 'syncer',
+      message: 'testlog',
+    })
+  })
+})
@@ -41,10 +41,15 @@ export const logObjToJSON = (logObj: ConsolaReporterLogObject): string => {
   ) as Record<string, unknown>[]
   const otherArgs = logObj.args.filter((a) => typeof a != 'object')
 
+  // The most specific tag is the module that created the log
+  const tags = logObj.tag ? logObj.tag.split(':') : []
+
   const toLog = {
     ...objectArgs[0],
     level: logObj.level,
+    type: logObj.type,
     tag: logObj.tag,
+    module: tags.length ? tags[tags.length - 1] : '',
     date: logObj.date,
     message: otherArgs.join(' '),
   }
@@ -0,0 +1,56 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+import { LRUCache } from './lruCache'
+
+describe('LRUCache', () => {
+  const createCache = (maxBytes: number) =>
+    new LRUCache<string, string>({ name: 'test', maxBytes, getSize: (value) => value.length })
+
+  it('counts hits and misses', () => {
+    const cache = createCache(100)
+    cache.set('a', 'aaaa')
+
+    expect(cache.get('a')).toBe('aaaa')
+    expect(cache.get('b')).toBeNull()
+    expect(cache.get('a')).toBe('aaaa')
+
+    expect(cache.hits).toBe(2)
+    expect(cache.misses).toBe(1)
+    expect(cache.hitRate).toBeCloseTo(2 / 3)
+  })
+
+  it('evicts the least recently used values past maxBytes', () => {
+    const cache = createCache(10)
+    cache.set('a', 'aaaa')
+    cache.set('b', 'bbbb')
+    cache.get('a')
+    cache.set('c', 'cccc')
+
+    expect(cache.get('a')).toBe('aaaa')
+    expect(cache.get('b')).toBeNull()
+    expect(cache.get('c')).toBe('cccc')
+    expect(cache.sizeBytes).toBe(8)
+  })
+
+  it('holds nothing when disabled', () => {
+    const cache = createCache(0)
+    cache.set('a', 'aaaa')
+
+    expect(cache.enabled).toBe(false)
+    expect(cache.get('a')).toBeNull()
+    expect(cache.misses).toBe(0)
+  })
+
+  it('empties when resized', () => {
+    const cache = createCache(10)
+    cache.set('a', 'aaaa')
+
+    cache.resize(20)
+
+    expect(cache.maxBytes).toBe(20)
+    expect(cache.items).toBe(0)
+    expect(cache.get('a')).toBeNull()
+  })
+})
@@ -0,0 +1,126 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import LRU from 'blru'
+import { Meter } from './metrics'
+
+export type CacheStats = {
+  name: string
+  hits: number
+  misses: number
+  hitRate: number
+  items: number
+  sizeBytes: number
+  maxBytes: number
+}
+
+/**
+ * A least recently used cache that holds up to maxBytes of values, as
+ * estimated by getSize, and counts how often lookups find what they want.
+ * A cache with a maxBytes of 0 is disabled and holds nothing.
+ */
+export class LRUCache<K, V> {
+  readonly name: string
+
+  hits = 0
+  misses = 0
+
+  private lru: LRU<K, V>
+  private readonly getSize: (value: V, key: K) => number
+  private readonly map: typeof Map | unknown | null
+  private readonly hitsMeter: Meter | null
+  private readonly missesMeter: Meter | null
+
+  constructor(options: {
+    name: string
+    maxBytes?: number
+    getSize: (value: V, key: K) => number
+    map?: typeof Map | unknown
+    hits?: Meter
+    misses?: Meter
+  }) {
+    this.name = options.name
+    this.getSize = options.getSize
+    this.map = options.map ?? null
+    this.hitsMeter = options.hits ?? null
+    this.missesMeter = options.misses ?? null
+    this.lru = new LRU(Math.max(Math.floor(options.maxBytes ?? 0), 0), this.getSize, this.map)
+  }
+
+  get enabled(): boolean {
+    return this.maxBytes > 0
+  }
+
+  get maxBytes(): number {
+    return this.lru.capacity
+  }
+
+  get sizeBytes(): number {
+    return this.lru.size
+  }
+
+  get items(): number {
+    return this.lru.items
+  }
+
+  /**
+   * The fraction of lookups that were found in the cache, or 0 before any lookups
+   */
+  get hitRate(): number {
+    const lookups = this.hits + this.misses
+    return lookups ? this.hits / lookups : 0
+  }
+
+  get(key: K): V | null {
+    if (!this.enabled) {
+      return null
+    }
+
+    const value = this.lru.get(key)
+
+    if (value === null) {
+      this.misses++
+      this.missesMeter?.add(1)
+    } else {
+      this.hits++
+      this.hitsMeter?.add(1)
+    }
+
+    return value
+  }
+
+  set(key: K, value: V): void {
+    if (!this.enabled || this.getSize(value, key) > this.maxBytes) {
+      return
+    }
+
+    this.lru.set(key, value)
+  }
+
+  remove(key: K): void {
+    this.lru.remove(key)
+  }
+
+  clear(): void {
+    this.lru.reset()
+  }
+
+  /**
+   * Change how many bytes the cache can hold. This empties the cache.
+   */
+  resize(maxBytes: number): void {
+    this.lru = new LRU(Math.max(Math.floor(maxBytes), 0), this.getSize, this.map)
+  }
+
+  getStats(): CacheStats {
+    return {
+      name: this.name,
+      hits: this.hits,
+      misses: this.misses,
+      hitRate: this.hitRate,
+      items: this.items,
+      sizeBytes: this.sizeBytes,
+      maxBytes: this.maxBytes,
+    }
+  }
+}
@@ -0,0 +1,105 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { createNodeTest } from '../testUtilities'
+import { buildHistogram, percentile } from './feeEstimator'
+
+describe('FeeEstimator', () => {
+  const nodeTest = createNodeTest()
+
+  describe('percentile', () => {
+    it('returns null for no values', () => {
+      expect(percentile([], 50)).toBeNull()
+    })
+
+    it('uses the nearest rank', () => {
+      const values = [1, 2, 3, 4, 5, 6, 7, 8, 9, 10].map(BigInt)
+
+      expect(percentile(values, 10)).toEqual(BigInt(1))
+      expect(percentile(values, 50)).toEqual(BigInt(5))
+      expect(percentile(values, 90)).toEqual(BigInt(9))
+      expect(percentile(values, 100)).toEqual(BigInt(10))
+      expect(percentile(values, 0)).toEqual(BigInt(1))
+    })
+  })
+
+  describe('estimateFees', () => {
+    it('returns the minimum fee without any transactions', async () => {
+      const estimates = await nodeTest.node.feeEstimator.estimateFees()
+
+      expect(estimates).toEqual({
+        slow: BigInt(1),
+        average: BigInt(1),
+        fast: BigInt(1),
+      })
+    })
+
+    it('uses the fees of mem pool transactions', async () => {
+      const fees = [10, 20, 30, 40, 50, 60, 70, 80, 90, 100].map(BigInt)
+
+      jest
+        .spyOn(nodeTest.node.memPool, 'orderedTransactions')
+        .mockImplementation(function* () {
+          for (const fee of fees) {
+            yield { fee: () => fee } as never
+          }
+        })
+
+      const estimates = await nodeTest.node.feeEstimator.estimateFees()
+
+      expect(estimates).toEqual({
+        slow: BigInt(10),
+        average: BigInt(50),
+        fast: BigInt(90),
+      })
+    })
+  })
+  describe('buildHistogram', () => {
+    it('returns no buckets for no values', () => {
+      expect(buildHistogram([])).toEqual([])
+    })
+
+    it('counts values into doubling buckets', () => {
+      const buckets = buildHistogram([0, 1, 3, 3, 5].map(BigInt))
+
+      expect(buckets).toEqual([
+        { min: BigInt(0), max: BigInt(1), count: 1 },
+        { min: BigInt(1), max: BigInt(2), count: 1 },
+        { min: BigInt(2), max: BigInt(4), count: 2 },
+        { min: BigInt(4), max: BigInt(8), count: 1 },
+      ])
+    })
+  })
+
+  describe('getFeeHistogram', () => {
+    it('uses the fee rates of mem pool transactions', async () => {
+      const fees = [10, 20, 30, 40, 50, 60, 70, 80, 90, 100].map(BigInt)
+
+      jest
+        .spyOn(nodeTest.node.memPool, 'orderedTransactions')
+        .mockImplementation(function* () {
+          for (const fee of fees) {
+            yield { fee: () => fee, serialize: () => Buffer.alloc(1000) } as never
+          }
+        })
+
+      const histogram = await nodeTest.node.feeEstimator.getFeeHistogram()
+
+      expect(histogram.blocks).toHaveLength(0)
+      expect(histogram.memPool.transactions).toEqual(10)
+      expect(histogram.memPool.histogram.slice(4)).toEqual([
+        { min: BigInt(8), max: BigInt(16), count: 1 },
+        { min: BigInt(16), max: BigInt(32), count: 2 },
+        { min: BigInt(32), max: BigInt(64), count: 3 },
+        { min: BigInt(64), max: BigInt(128), count: 4 },
+      ])
+
+      // Without any blocks to compare against every tier is expected to be included
+      expect(histogram.tiers).toEqual([
+        { tier: 'slow', feeRate: BigInt(10), inclusionProbability: 1 },
+        { tier: 'average', feeRate: BigInt(50), inclusionProbability: 1 },
+        { tier: 'fast', feeRate: BigInt(90), inclusionProbability: 1 },
+      ])
+    })
+  })
+})
@@ -0,0 +1,261 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { Blockchain } from '../blockchain'
+import { GENESIS_BLOCK_SEQUENCE } from '../consensus'
+import { Transaction } from '../primitives/transaction'
+import { MemPool } from './memPool'
+
+export type FeeTier = 'slow' | 'average' | 'fast'
+
+export const FEE_TIERS: FeeTier[] = ['slow', 'average', 'fast']
+
+/**
+ * The percentile of recent transaction fees each tier pays
+ */
+export const FEE_TIER_PERCENTILES: Record<FeeTier, number> = {
+  slow: 10,
+  average: 50,
+  fast: 90,
+}
+
+export type FeeEstimates = Record<FeeTier, bigint>
+
+/**
+ * The number of transactions paying a fee rate, in ore per kilobyte, from `min`
+ * up to but not including `max`
+ */
+export type FeeRateBucket = {
+  min: bigint
+  max: bigint
+  count: number
+}
+
+export type BlockFeeRates = {
+  sequence: number
+  hash: Buffer
+  transactions: number
+  /**
+   * The lowest fee rate included in the block, null if it has no transactions
+   */
+  minFeeRate: bigint | null
+  histogram: FeeRateBucket[]
+}
+
+export type FeeTierEstimate = {
+  tier: FeeTier
+  feeRate: bigint
+  /**
+   * The fraction of recent blocks, from 0 to 1, that included every
+   * transaction paying at least this fee rate
+   */
+  inclusionProbability: number
+}
+
+export type FeeHistogram = {
+  blocks: BlockFeeRates[]
+  memPool: {
+    transactions: number
+    histogram: FeeRateBucket[]
+  }
+  tiers: FeeTierEstimate[]
+}
+
+/**
+ * Estimates the fee a transaction should pay from the fees paid by the
+ * transactions in recent blocks and the ones waiting in the mem pool
+ */
+export class FeeEstimator {
+  readonly chain: Blockchain
+  readonly memPool: MemPool
+
+  /**
+   * How many blocks back from the head to collect fees from
+   */
+  readonly blocks: number
+
+  /**
+   * The lowest fee that will ever be estimated, in ore
+   */
+  readonly minimumFee: bigint
+
+  constructor(options: {
+    chain: Blockchain
+    memPool: MemPool
+    blocks?: number
+    minimumFee?: bigint
+  }) {
+    this.chain = options.chain
+    this.memPool = options.memPool
+    this.blocks = options.blocks ?? 10
+    this.minimumFee = options.minimumFee ?? BigInt(1)
+  }
+
+  async estimateFees(): Promise<FeeEstimates> {
+    const fees = await this.getRecentFees()
+    fees.sort((a, b) => (a < b ? -1 : a > b ? 1 : 0))
+
+    const estimates = {} as FeeEstimates
+
+    for (const tier of FEE_TIERS) {
+      const fee = percentile(fees, FEE_TIER_PERCENTILES[tier]) ?? this.minimumFee
+      estimates[tier] = fee > this.minimumFee ? fee : this.minimumFee
+    }
+
+    return estimates
+  }
+
+  /**
+   * Builds histograms of the fee rates paid in recent blocks and the mem pool,
+   * and estimates how likely a transaction paying each tier's rate is to be
+   * included in the next block
+   */
+  async getFeeHistogram(blocks = this.blocks): Promise<FeeHistogram> {
+    const blockRates: BlockFeeRates[] = []
+    const allRates: bigint[] = []
+
+    let header = this.chain.head
+
+    for (let i = 0; i < blocks && header.sequence > GENESIS_BLOCK_SEQUENCE; i++) {
+      const block = await this.chain.getBlock(header)
+      if (!block) {
+        break
+      }
+
+      const rates = block.transactions.filter((t) => !t.isMinersFee()).map(getFeeRate)
+      rates.sort(compareBigInt)
+      allRates.push(...rates)
+
+      blockRates.push({
+        sequence: header.sequence,
+        hash: header.hash,
+        transactions: rates.length,
+        minFeeRate: rates.length ? rates[0] : null,
+        histogram: buildHistogram(rates),
+      })
+
+      const previous = await this.chain.getHeader(header.previousBlockHash)
+      if (!previous) {
+        break
+      }
+      header = previous
+    }
+
+    const memPoolRates = [...this.memPool.orderedTransactions()].map(getFeeRate)
+    memPoolRates.sort(compareBigInt)
+    allRates.push(...memPoolRates)
+    allRates.sort(compareBigInt)
+
+    const tiers = FEE_TIERS.map((tier) => {
+      const feeRate = percentile(allRates, FEE_TIER_PERCENTILES[tier]) ?? BigInt(0)
+
+      // An empty block would have included a transaction paying any fee
+      const included = blockRates.filter(
+        (b) => b.minFeeRate === null || b.minFeeRate <= feeRate,
+      )
+      const inclusionProbability = blockRates.length ? included.length / blockRates.length : 1
+
+      return { tier, feeRate, inclusionProbability }
+    })
+
+    return {
+      blocks: blockRates,
+      memPool: {
+        transactions: memPoolRates.length,
+        histogram: buildHistogram(memPoolRates),
+      },
+      tiers,
+    }
+  }
+
+  /**
+   * Returns the fees of every transaction that isn't a miner's fee in the
+   * recent blocks and the mem pool, unsorted
+   */
+  async getRecentFees(): Promise<bigint[]> {
+    const fees: bigint[] = []
+
+    let header = this.chain.head
+
+    for (let i = 0; i < this.blocks && header.sequence > GENESIS_BLOCK_SEQUENCE; i++) {
+      const block = await this.chain.getBlock(header)
+      if (!block) {
+        break
+      }
+
+      for (const transaction of block.transactions) {
+        if (!transaction.isMinersFee()) {
+          fees.push(transaction.fee())
+        }
+      }
+
+      const previous = await this.chain.getHeader(header.previousBlockHash)
+      if (!previous) {
+        break
+      }
+      header = previous
+    }
+
+    for (const transaction of this.memPool.orderedTransactions()) {
+      fees.push(transaction.fee())
+    }
+
+    return fees
+  }
+}
+
+/**
+ * The fee a transaction pays in ore per kilobyte of its serialized size
+ */
+export function getFeeRate(transaction: Transaction): bigint {
+  const size = BigInt(Math.max(transaction.serialize().byteLength, 1))
+  return (transaction.fee() * BigInt(1000)) / size
+}
+
+function compareBigInt(a: bigint, b: bigint): number {
+  return a < b ? -1 : a > b ? 1 : 0
+}
+
+/**
+ * Counts fee rates into buckets that double in size, [0, 1), [1, 2), [2, 4)
+ * and so on, up to the highest rate. Every histogram shares the same bucket
+ * edges so they can be compared.
+ */
+export function buildHistogram(sorted: bigint[]): FeeRateBucket[] {
+  const buckets: FeeRateBucket[] = []
+
+  if (!sorted.length) {
+    return buckets
+  }
+
+  const highest = sorted[sorted.length - 1]
+  let min = BigInt(0)
+  let max = BigInt(1)
+  let index = 0
+
+  while (min <= highest) {
+    let count = 0
+    while (index < sorted.length && sorted[index] < max) {
+      count++
+      index++
+    }
+
+    buckets.push({ min, max, count })
+    min = max
+    max = max * BigInt(2)
+  }
+
+  return buckets
+}
+
+/**
+ * Returns the value at `percent` in a sorted list using the nearest rank method
+ */
+export function percentile(sorted: bigint[], percent: number): bigint | null {
+  if (!sorted.length) {
+    return null
+  }
+
+  const rank = Math.ceil((percent / 100) * sorted.length)
+  return sorted[Math.min(Math.max(rank, 1), sorted.length) - 1]
+}
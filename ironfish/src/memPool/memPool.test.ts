@@ -56,6 +56,27 @@ describe('MemPool', () => {
     })
   })
 
+  describe('getConflicts', () => {
+    const nodeTest = createNodeTest()
+
+    it('returns transactions that spend the same notes', async () => {
+      const { node } = nodeTest
+      const { accounts, memPool } = node
+      const accountA = await useAccountFixture(accounts, 'accountA')
+      const accountB = await useAccountFixture(accounts, 'accountB')
+      const { transaction } = await useBlockWithTx(node, accountA, accountB)
+      const { transaction: transaction2 } = await useBlockWithTx(node, accountA, accountB)
+
+      expect(memPool.getConflicts(transaction2)).toEqual([])
+
+      await memPool.acceptTransaction(transaction)
+
+      const conflicts = memPool.getConflicts(transaction2)
+      expect(conflicts.map((t) => t.hash())).toEqual([transaction.hash()])
+      expect(memPool.getConflicts(transaction)).toEqual([])
+    }, 60000)
+  })
+
   describe('orderedTransactions', () => {
     const nodeTest = createNodeTest()
 
@@ -107,6 +128,34 @@ describe('MemPool', () => {
     }, 60000)
   })
 
+  describe('evict', () => {
+    const nodeTest = createNodeTest()
+
+    it('removes the transactions paying the lowest fees', async () => {
+      const { node } = nodeTest
+      const { accounts, memPool } = node
+      const accountA = await useAccountFixture(accounts, 'accountA')
+      const accountB = await useAccountFixture(accounts, 'accountB')
+      const { transaction: transactionA } = await useBlockWithTx(node, accountA, accountB)
+      const { transaction: transactionB } = await useBlockWithTx(node, accountB, accountA)
+
+      jest.spyOn(transactionA, 'fee').mockImplementation(() => BigInt(1))
+      jest.spyOn(transactionB, 'fee').mockImplementation(() => BigInt(4))
+
+      await memPool.acceptTransaction(transactionA)
+      await memPool.acceptTransaction(transactionB)
+
+      expect(memPool.sizeBytes()).toBe(
+        transactionA.serialize().byteLength + transactionB.serialize().byteLength,
+      )
+
+      expect(memPool.evict(1)).toBe(1)
+      expect(memPool.exists(transactionA.hash())).toBe(false)
+      expect(memPool.exists(transactionB.hash())).toBe(true)
+      expect(memPool.sizeBytes()).toBe(transactionB.serialize().byteLength)
+    }, 60000)
+  })
+
   describe('acceptTransaction', () => {
     describe('with a coinbase transaction', () => {
       const nodeTest = createNodeTest()
@@ -137,6 +186,23 @@ describe('MemPool', () => {
       }, 60000)
     })
 
+    describe('with a transaction on the chain', () => {
+      const nodeTest = createNodeTest()
+
+      it('returns false', async () => {
+        const { node } = nodeTest
+        const { accounts, chain, memPool } = node
+        const accountA = await useAccountFixture(accounts, 'accountA')
+        const accountB = await useAccountFixture(accounts, 'accountB')
+        const { block, transaction } = await useBlockWithTx(node, accountA, accountB)
+
+        await expect(chain).toAddBlock(block)
+
+        expect(await memPool.acceptTransaction(transaction)).toBe(false)
+        expect(memPool.exists(transaction.hash())).toBe(false)
+      }, 60000)
+    })
+
     describe('with an expired sequence', () => {
       const nodeTest = createNodeTest()
 
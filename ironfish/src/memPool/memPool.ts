@@ -22,6 +22,11 @@ export class MemPool {
   private readonly queue: FastPriorityQueue<MempoolEntry>
   head: BlockHeader | null
 
+  /**
+   * The total size of the serialized transactions in the mem pool
+   */
+  private _sizeBytes = 0
+
   private readonly chain: Blockchain
   private readonly logger: Logger
   private readonly metrics: MetricsMonitor
@@ -54,6 +59,33 @@ export class MemPool {
     return this.transactions.size
   }
 
+  sizeBytes(): number {
+    return this._sizeBytes
+  }
+
+  /**
+   * Removes up to `count` of the transactions paying the lowest fees, used to
+   * free memory when the node is running out. Returns how many were removed.
+   */
+  evict(count: number): number {
+    const entries = [...this.transactions.values()]
+      .sort((a, b) => (a.fee() < b.fee() ? -1 : a.fee() > b.fee() ? 1 : 0))
+      .slice(0, count)
+
+    let evicted = 0
+    for (const transaction of entries) {
+      if (this.deleteTransaction(transaction)) {
+        evicted++
+      }
+    }
+
+    if (evicted) {
+      this.logger.debug(`Evicted ${evicted} transactions`)
+    }
+
+    return evicted
+  }
+
   exists(hash: TransactionHash): boolean {
     return this.transactions.has(hash)
   }
@@ -66,6 +98,25 @@ export class MemPool {
     return this.transactions.get(hash)
   }
 
+  /**
+   * Returns the transactions in the mempool that spend any of the same notes
+   * as the given transaction
+   */
+  getConflicts(transaction: Transaction): Transaction[] {
+    const conflicts = new BufferMap<Transaction>()
+
+    for (const spend of transaction.spends()) {
+      const hash = this.nullifiers.get(spend.nullifier)
+      const existing = hash && this.transactions.get(hash)
+
+      if (existing && !existing.hash().equals(transaction.hash())) {
+        conflicts.set(existing.hash(), existing)
+      }
+    }
+
+    return Array.from(conflicts.values())
+  }
+
   *orderedTransactions(): Generator<Transaction, void, unknown> {
     const clone = this.queue.clone()
 
@@ -95,6 +146,11 @@ export class MemPool {
       return false
     }
 
+    if (await this.chain.getBlockHashByTransactionHash(transaction.hash())) {
+      this.logger.debug(`Invalid transaction '${hash}': already on the chain`)
+      return false
+    }
+
     const isExpiredSequence = this.chain.verifier.isExpiredSequence(
       sequence,
       this.chain.head.sequence,
@@ -202,12 +258,15 @@ export class MemPool {
     }
 
     this.queue.add({ fee: transaction.fee(), hash })
+    this._sizeBytes += transaction.serialize().byteLength
     this.metrics.memPoolSize.value = this.size()
   }
 
   private deleteTransaction(transaction: Transaction): boolean {
     const hash = transaction.hash()
-    this.transactions.delete(hash)
+    if (this.transactions.delete(hash)) {
+      this._sizeBytes -= transaction.serialize().byteLength
+    }
 
     for (const spend of transaction.spends()) {
       this.nullifiers.delete(spend.nullifier)
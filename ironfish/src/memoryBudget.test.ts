@@ -0,0 +1,65 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { MemoryBudget } from './memoryBudget'
+
+describe('MemoryBudget', () => {
+  it('reports pressure against the budget', () => {
+    const budget = new MemoryBudget({ budget: 100 })
+    const onPressureChanged = jest.fn()
+    budget.onPressureChanged.on(onPressureChanged)
+
+    budget.refresh(50)
+    expect(budget.pressure).toBe('normal')
+    expect(onPressureChanged).not.toHaveBeenCalled()
+
+    budget.refresh(80)
+    expect(budget.pressure).toBe('elevated')
+    expect(onPressureChanged).toHaveBeenLastCalledWith('elevated', 'normal')
+
+    budget.refresh(95)
+    expect(budget.pressure).toBe('critical')
+    expect(onPressureChanged).toHaveBeenLastCalledWith('critical', 'elevated')
+
+    budget.refresh(10)
+    expect(budget.pressure).toBe('normal')
+    expect(onPressureChanged).toHaveBeenLastCalledWith('normal', 'critical')
+    expect(onPressureChanged).toHaveBeenCalledTimes(3)
+  })
+
+  it('reports registered consumers', () => {
+    const budget = new MemoryBudget({ budget: 100 })
+    budget.register('memPool', () => 42)
+
+    expect(budget.getConsumers()).toEqual([{ name: 'memPool', bytes: 42 }])
+  })
+
+  describe('waitForCapacity', () => {
+    it('resolves immediately when pressure is normal', async () => {
+      const budget = new MemoryBudget({ budget: 100 })
+      budget.refresh(10)
+
+      await expect(budget.waitForCapacity()).resolves.toBeUndefined()
+    })
+
+    it('resolves when pressure returns to normal', async () => {
+      const budget = new MemoryBudget({ budget: 100 })
+      budget.refresh(95)
+
+      const promise = budget.waitForCapacity()
+      budget.refresh(80)
+      budget.refresh(10)
+
+      await expect(promise).resolves.toBeUndefined()
+      expect(budget.onPressureChanged.subscribers).toBe(0)
+    })
+
+    it('resolves after the timeout', async () => {
+      const budget = new MemoryBudget({ budget: 100 })
+      budget.refresh(95)
+
+      await expect(budget.waitForCapacity(10)).resolves.toBeUndefined()
+      expect(budget.pressure).toBe('critical')
+    })
+  })
+})
@@ -0,0 +1,158 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { getHeapStatistics } from 'v8'
+import { Event } from './event'
+import { createRootLogger, Logger } from './logger'
+import { FileUtils, SetIntervalToken } from './utils'
+
+/**
+ * How close the heap is to the memory budget
+ *  - normal: nothing is held back
+ *  - elevated: work that can wait, like prefetching blocks while syncing, is paused
+ *  - critical: the mem pool is shrunk and new work from peers is dropped
+ */
+export type MemoryPressure = 'normal' | 'elevated' | 'critical'
+
+const PRESSURE_LEVELS: MemoryPressure[] = ['normal', 'elevated', 'critical']
+
+export type MemoryConsumer = {
+  name: string
+  bytes: number
+}
+
+/**
+ * Tracks how much of the heap the node is using against a budget and reports
+ * the pressure so components can back off before the process runs out of memory
+ */
+export class MemoryBudget {
+  readonly logger: Logger
+
+  /**
+   * The number of heap bytes the node may use
+   */
+  readonly budget: number
+
+  /**
+   * The fraction of the budget the heap can use before pressure is elevated
+   */
+  readonly elevatedRatio: number
+
+  /**
+   * The fraction of the budget the heap can use before pressure is critical
+   */
+  readonly criticalRatio: number
+
+  readonly onPressureChanged = new Event<[pressure: MemoryPressure, previous: MemoryPressure]>()
+
+  pressure: MemoryPressure = 'normal'
+  heapUsed = 0
+
+  private readonly consumers = new Map<string, () => number>()
+  private interval: SetIntervalToken | null = null
+  private readonly refreshPeriodMs = 1000
+
+  constructor(options?: {
+    budget?: number
+    elevatedRatio?: number
+    criticalRatio?: number
+    logger?: Logger
+  }) {
+    this.logger = (options?.logger ?? createRootLogger()).withTag('memorybudget')
+    this.budget = options?.budget || getHeapStatistics().heap_size_limit
+    this.elevatedRatio = options?.elevatedRatio ?? 0.75
+    this.criticalRatio = options?.criticalRatio ?? 0.9
+  }
+
+  start(): void {
+    if (this.interval) {
+      return
+    }
+
+    this.refresh()
+    this.interval = setInterval(() => this.refresh(), this.refreshPeriodMs)
+  }
+
+  stop(): void {
+    if (this.interval) {
+      clearInterval(this.interval)
+      this.interval = null
+    }
+  }
+
+  /**
+   * Register a component that holds a lot of memory so its estimated size in
+   * bytes is reported with the pressure
+   */
+  register(name: string, estimate: () => number): void {
+    this.consumers.set(name, estimate)
+  }
+
+  getConsumers(): MemoryConsumer[] {
+    return [...this.consumers.entries()].map(([name, estimate]) => ({
+      name,
+      bytes: estimate(),
+    }))
+  }
+
+  get ratio(): number {
+    return this.heapUsed / this.budget
+  }
+
+  refresh(heapUsed = process.memoryUsage().heapUsed): void {
+    this.heapUsed = heapUsed
+
+    let pressure: MemoryPressure = 'normal'
+    if (this.ratio >= this.criticalRatio) {
+      pressure = 'critical'
+    } else if (this.ratio >= this.elevatedRatio) {
+      pressure = 'elevated'
+    }
+
+    if (pressure === this.pressure) {
+      return
+    }
+
+    const previous = this.pressure
+    this.pressure = pressure
+
+    const used = FileUtils.formatMemorySize(this.heapUsed)
+    const budget = FileUtils.formatMemorySize(this.budget)
+    const message = `Memory pressure ${previous} -> ${pressure}, heap ${used} / ${budget}`
+
+    if (PRESSURE_LEVELS.indexOf(pressure) > PRESSURE_LEVELS.indexOf(previous)) {
+      this.logger.warn(message)
+    } else {
+      this.logger.info(message)
+    }
+
+    this.onPressureChanged.emit(pressure, previous)
+  }
+
+  /**
+   * Resolves once the pressure is back to normal, or after `timeoutMs`
+   */
+  async waitForCapacity(timeoutMs = 60 * 1000): Promise<void> {
+    if (this.pressure === 'normal') {
+      return
+    }
+
+    return new Promise((resolve) => {
+      const onChange = (pressure: MemoryPressure) => {
+        if (pressure === 'normal') {
+          done()
+        }
+      }
+
+      const timeout = setTimeout(() => done(), timeoutMs)
+
+      const done = () => {
+        clearTimeout(timeout)
+        this.onPressureChanged.off(onChange)
+        resolve()
+      }
+
+      this.onPressureChanged.on(onChange)
+    })
+  }
+}
@@ -19,7 +19,6 @@ describe('NoteLeafEncoding', () => {
     const noteLeafValue = {
       element: noteEncrypted,
       merkleHash: Buffer.alloc(32, 'hashOfSibling'),
-      parentIndex: 14,
     } as const
 
     const buffer = encoding.serialize(noteLeafValue)
@@ -35,7 +34,6 @@ describe('NullifierLeafEncoding', () => {
     const nullifierLeafValue = {
       element: Buffer.alloc(32, 'element'),
       merkleHash: Buffer.alloc(32, 'hashOfSibling'),
-      parentIndex: 14,
     } as const
 
     const buffer = encoding.serialize(nullifierLeafValue)
@@ -9,7 +9,6 @@ import { NoteEncrypted } from '../../primitives/noteEncrypted'
 export interface LeafValue<T> {
   element: T
   merkleHash: Buffer
-  parentIndex: number
 }
 
 const NOTE_BYTES = 275
@@ -25,7 +24,6 @@ export class NoteLeafEncoding implements IDatabaseEncoding<NoteLeafValue> {
 
     bw.writeBytes(value.element.serialize())
     bw.writeHash(value.merkleHash)
-    bw.writeU32(value.parentIndex)
 
     return bw.render()
   }
@@ -35,12 +33,10 @@ export class NoteLeafEncoding implements IDatabaseEncoding<NoteLeafValue> {
 
     const element = new NoteEncrypted(reader.readBytes(NOTE_BYTES))
     const merkleHash = reader.readHash()
-    const parentIndex = reader.readU32()
 
     return {
       element,
       merkleHash,
-      parentIndex,
     }
   }
 
@@ -48,7 +44,6 @@ export class NoteLeafEncoding implements IDatabaseEncoding<NoteLeafValue> {
     let size = 0
     size += NOTE_BYTES // element
     size += 32 // merkleHash
-    size += 4 // parentIndex
     return size
   }
 }
@@ -59,7 +54,6 @@ export class NullifierLeafEncoding implements IDatabaseEncoding<NullifierLeafVal
 
     bw.writeBytes(value.element)
     bw.writeHash(value.merkleHash)
-    bw.writeU32(value.parentIndex)
 
     return bw.render()
   }
@@ -69,12 +63,10 @@ export class NullifierLeafEncoding implements IDatabaseEncoding<NullifierLeafVal
 
     const element = reader.readBytes(NULLIFIER_BYTES)
     const merkleHash = reader.readHash()
-    const parentIndex = reader.readU32()
 
     return {
       element,
       merkleHash,
-      parentIndex,
     }
   }
 
@@ -82,7 +74,6 @@ export class NullifierLeafEncoding implements IDatabaseEncoding<NullifierLeafVal
     let size = 0
     size += NULLIFIER_BYTES // element
     size += 32 // merkleHash
-    size += 4 // parentIndex
     return size
   }
 }
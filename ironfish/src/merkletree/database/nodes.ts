@@ -4,71 +4,20 @@
 
 import type { IDatabaseEncoding } from '../../storage/database/types'
 import bufio from 'bufio'
-import { Side } from '../merkletree'
 
-export type NodeValue<H> = LeftNodeValue<H> | RightNodeValue<H>
-
-type LeftNodeValue<H> = {
-  side: Side.Left
-  hashOfSibling: H
-  parentIndex: number // left nodes have a parent index
-}
-
-type RightNodeValue<H> = {
-  side: Side.Right
-  hashOfSibling: H
-  leftIndex: number // right nodes have a left index
-}
-
-export class NodeEncoding implements IDatabaseEncoding<NodeValue<Buffer>> {
-  serialize(value: NodeValue<Buffer>): Buffer {
+export class NodeEncoding implements IDatabaseEncoding<Buffer> {
+  serialize(value: Buffer): Buffer {
     const bw = bufio.write(this.getSize())
-
-    bw.writeHash(value.hashOfSibling)
-
-    if (value.side === Side.Left) {
-      bw.writeU8(0)
-      bw.writeU32(value.parentIndex)
-    } else {
-      bw.writeU8(1)
-      bw.writeU32(value.leftIndex)
-    }
-
+    bw.writeHash(value)
     return bw.render()
   }
 
-  deserialize(buffer: Buffer): NodeValue<Buffer> {
+  deserialize(buffer: Buffer): Buffer {
     const reader = bufio.read(buffer, true)
-
-    const hashOfSibling = reader.readHash()
-
-    const sideNumber = reader.readU8()
-    const side = sideNumber === 0 ? Side.Left : Side.Right
-
-    const otherIndex = reader.readU32()
-
-    if (side === Side.Left) {
-      const leftNode: LeftNodeValue<Buffer> = {
-        side,
-        hashOfSibling,
-        parentIndex: otherIndex,
-      }
-      return leftNode
-    }
-
-    const rightNode: RightNodeValue<Buffer> = {
-      side,
-      hashOfSibling,
-      leftIndex: otherIndex,
-    }
-    return rightNode
+    return reader.readHash()
   }
 
   getSize(): number {
-    let size = 0
-    size += 1 // side
-    size += 32 // merkleHash
-    size += 4 // parentIndex
-    return size
+    return 32 // hash
   }
 }
@@ -5,7 +5,7 @@
 import { IJSON, JsonSerializable } from '../serde'
 import { IDatabaseEncoding } from '../storage'
 import { MerkleHasher } from './hasher'
-import { LeavesSchema, NodeValue } from './schema'
+import { LeavesSchema, NodesSchema } from './schema'
 
 export class LeafEncoding<E, H, SE extends JsonSerializable, SH extends JsonSerializable>
   implements IDatabaseEncoding<LeavesSchema<E, H>['value']>
@@ -43,7 +43,7 @@ export class LeafEncoding<E, H, SE extends JsonSerializable, SH extends JsonSeri
 }
 
 export class NodeEncoding<E, H, SE extends JsonSerializable, SH extends JsonSerializable>
-  implements IDatabaseEncoding<NodeValue<H>>
+  implements IDatabaseEncoding<NodesSchema<H>['value']>
 {
   hasher: MerkleHasher<E, H, SE, SH>
 
@@ -51,23 +51,14 @@ export class NodeEncoding<E, H, SE extends JsonSerializable, SH extends JsonSeri
     this.hasher = hasher
   }
 
-  serialize = (value: NodeValue<H>): Buffer => {
-    const intermediate = {
-      ...value,
-      hashOfSibling: this.hasher.hashSerde().serialize(value.hashOfSibling),
-    }
+  serialize = (value: NodesSchema<H>['value']): Buffer => {
+    const intermediate = this.hasher.hashSerde().serialize(value)
     return Buffer.from(IJSON.stringify(intermediate), 'utf8')
   }
-  deserialize = (buffer: Buffer): NodeValue<H> => {
-    const intermediate = IJSON.parse(buffer.toString('utf8')) as Omit<
-      NodeValue<H>,
-      'hashOfSibling'
-    > & { hashOfSibling: SH }
 
-    return {
-      ...intermediate,
-      hashOfSibling: this.hasher.hashSerde().deserialize(intermediate.hashOfSibling),
-    }
+  deserialize = (buffer: Buffer): NodesSchema<H>['value'] => {
+    const intermediate = IJSON.parse(buffer.toString('utf8')) as SH
+    return this.hasher.hashSerde().deserialize(intermediate)
   }
 
   equals(): boolean {
@@ -3,9 +3,10 @@
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 
 import '../testUtilities/matchers/merkletree'
+import { Assert } from '../assert'
 import { makeTree } from '../testUtilities/helpers/merkletree'
 import { MerkleTree, Side } from './merkletree'
-import { depthAtLeafCount } from './utils'
+import { completeNodeCount, depthAtLeafCount, nodePosition } from './utils'
 
 describe('Merkle tree', function () {
   it('initializes database', async () => {
@@ -13,7 +14,6 @@ describe('Merkle tree', function () {
 
     await expect(tree.size()).resolves.toBe(0)
     await expect(tree.getCount('Leaves')).resolves.toBe(0)
-    await expect(tree.getCount('Nodes')).resolves.toBe(1)
   })
 
   it("doesn't reset db on second run", async () => {
@@ -54,95 +54,86 @@ describe('Merkle tree', function () {
     const tree = await makeTree()
 
     await tree.add('a')
-    await expect(tree).toHaveLeaves('a', [0])
+    await expect(tree).toHaveLeaves('a')
     await expect(tree).toHaveNodes([])
 
     await tree.add('b')
-    await expect(tree).toHaveLeaves('ab', [1, 1])
-    await expect(tree).toHaveNodes([[1, Side.Left, 0, '<a|b-0>']])
+    await expect(tree).toHaveLeaves('ab')
+    await expect(tree).toHaveNodes(['<a|b-0>'])
 
     await tree.add('c')
-    await expect(tree).toHaveLeaves('abc', [1, 1, 2])
-    await expect(tree).toHaveNodes([
-      [1, Side.Left, 3, '<c|c-0>'],
-      [2, Side.Right, 1, '<a|b-0>'],
-      [3, Side.Left, 0, '<<a|b-0>|<c|c-0>-1>'],
-    ])
+    await expect(tree).toHaveLeaves('abc')
+    await expect(tree).toHaveNodes(['<a|b-0>'])
 
     await tree.add('d')
-    await expect(tree).toHaveLeaves('abcd', [1, 1, 2, 2])
-    await expect(tree).toHaveNodes([
-      [1, Side.Left, 3, '<c|d-0>'],
-      [2, Side.Right, 1, '<a|b-0>'],
-      [3, Side.Left, 0, '<<a|b-0>|<c|d-0>-1>'],
-    ])
+    await expect(tree).toHaveLeaves('abcd')
+    await expect(tree).toHaveNodes(['<a|b-0>', '<c|d-0>', '<<a|b-0>|<c|d-0>-1>'])
 
     await tree.add('e')
-    await expect(tree).toHaveLeaves('abcde', [1, 1, 2, 2, 4])
-    await expect(tree).toHaveNodes([
-      [1, Side.Left, 3, '<c|d-0>'],
-      [2, Side.Right, 1, '<a|b-0>'],
-      [3, Side.Left, 6, '<<e|e-0>|<e|e-0>-1>'],
-      [4, Side.Left, 5, '<e|e-0>'],
-      [5, Side.Right, 3, '<<a|b-0>|<c|d-0>-1>'],
-      [6, Side.Left, 0, '<<<a|b-0>|<c|d-0>-1>|<<e|e-0>|<e|e-0>-1>-2>'],
-    ])
+    await expect(tree).toHaveLeaves('abcde')
+    await expect(tree).toHaveNodes(['<a|b-0>', '<c|d-0>', '<<a|b-0>|<c|d-0>-1>'])
 
     await tree.add('f')
-    await expect(tree).toHaveLeaves('abcdef', [1, 1, 2, 2, 4, 4])
-    await expect(tree).toHaveNodes([
-      [1, Side.Left, 3, '<c|d-0>'],
-      [2, Side.Right, 1, '<a|b-0>'],
-      [3, Side.Left, 6, '<<e|f-0>|<e|f-0>-1>'],
-      [4, Side.Left, 5, '<e|f-0>'],
-      [5, Side.Right, 3, '<<a|b-0>|<c|d-0>-1>'],
-      [6, Side.Left, 0, '<<<a|b-0>|<c|d-0>-1>|<<e|f-0>|<e|f-0>-1>-2>'],
-    ])
+    await expect(tree).toHaveLeaves('abcdef')
+    await expect(tree).toHaveNodes(['<a|b-0>', '<c|d-0>', '<<a|b-0>|<c|d-0>-1>', '<e|f-0>'])
 
     await tree.add('g')
-    await expect(tree).toHaveLeaves('abcdefg', [1, 1, 2, 2, 4, 4, 7])
-    await expect(tree).toHaveNodes([
-      [1, Side.Left, 3, '<c|d-0>'],
-      [2, Side.Right, 1, '<a|b-0>'],
-      [3, Side.Left, 6, '<<e|f-0>|<g|g-0>-1>'],
-      [4, Side.Left, 5, '<g|g-0>'],
-      [5, Side.Right, 3, '<<a|b-0>|<c|d-0>-1>'],
-      [6, Side.Left, 0, '<<<a|b-0>|<c|d-0>-1>|<<e|f-0>|<g|g-0>-1>-2>'],
-      [7, Side.Right, 4, '<e|f-0>'],
-    ])
+    await expect(tree).toHaveLeaves('abcdefg')
+    await expect(tree).toHaveNodes(['<a|b-0>', '<c|d-0>', '<<a|b-0>|<c|d-0>-1>', '<e|f-0>'])
+
+    const nodes = [
+      '<a|b-0>',
+      '<c|d-0>',
+      '<<a|b-0>|<c|d-0>-1>',
+      '<e|f-0>',
+      '<g|h-0>',
+      '<<e|f-0>|<g|h-0>-1>',
+      '<<<a|b-0>|<c|d-0>-1>|<<e|f-0>|<g|h-0>-1>-2>',
+    ]
 
     await tree.add('h')
-    await expect(tree).toHaveLeaves('abcdefgh', [1, 1, 2, 2, 4, 4, 7, 7])
-    await expect(tree).toHaveNodes([
-      [1, Side.Left, 3, '<c|d-0>'],
-      [2, Side.Right, 1, '<a|b-0>'],
-      [3, Side.Left, 6, '<<e|f-0>|<g|h-0>-1>'],
-      [4, Side.Left, 5, '<g|h-0>'],
-      [5, Side.Right, 3, '<<a|b-0>|<c|d-0>-1>'],
-      [6, Side.Left, 0, '<<<a|b-0>|<c|d-0>-1>|<<e|f-0>|<g|h-0>-1>-2>'],
-      [7, Side.Right, 4, '<e|f-0>'],
-    ])
+    await expect(tree).toHaveLeaves('abcdefgh')
+    await expect(tree).toHaveNodes(nodes)
 
     await tree.add('i')
-    await expect(tree).toHaveLeaves('abcdefghi', [1, 1, 2, 2, 4, 4, 7, 7, 8])
-    await expect(tree).toHaveNodes([
-      [1, Side.Left, 3, '<c|d-0>'],
-      [2, Side.Right, 1, '<a|b-0>'],
-      [3, Side.Left, 6, '<<e|f-0>|<g|h-0>-1>'],
-      [4, Side.Left, 5, '<g|h-0>'],
-      [5, Side.Right, 3, '<<a|b-0>|<c|d-0>-1>'],
-      [6, Side.Left, 11, '<<<i|i-0>|<i|i-0>-1>|<<i|i-0>|<i|i-0>-1>-2>'],
-      [7, Side.Right, 4, '<e|f-0>'],
-      [8, Side.Left, 9, '<i|i-0>'],
-      [9, Side.Left, 10, '<<i|i-0>|<i|i-0>-1>'],
-      [10, Side.Right, 6, '<<<a|b-0>|<c|d-0>-1>|<<e|f-0>|<g|h-0>-1>-2>'],
-      [
-        11,
-        Side.Left,
-        0,
-        '<<<<a|b-0>|<c|d-0>-1>|<<e|f-0>|<g|h-0>-1>-2>|<<<i|i-0>|<i|i-0>-1>|<<i|i-0>|<i|i-0>-1>-2>-3>',
-      ],
-    ])
+    await expect(tree).toHaveLeaves('abcdefghi')
+    await expect(tree).toHaveNodes(nodes)
+  })
+
+  it('appends nodes in separate transactions', async () => {
+    const tree = await makeTree()
+
+    for (const leaf of 'abcdefghijk') {
+      await tree.db.transaction((tx) => tree.add(leaf, tx))
+    }
+
+    const other = await makeTree()
+    await other.db.transaction(async (tx) => {
+      for (const leaf of 'abcdefghijk') {
+        await other.add(leaf, tx)
+      }
+    })
+
+    await expect(tree).toMatchTree(other)
+    await expect(tree.rootHash()).resolves.toEqual(await other.rootHash())
+  })
+
+  it('rebuilds the node log', async () => {
+    const tree = await makeTree({ leaves: 'abcdefghijk' })
+    const rootHash = await tree.rootHash()
+
+    await tree.db.transaction(async (tx) => {
+      await tree.nodes.put(0, 'wrong', tx)
+      await tree.nodes.put(100, 'stale', tx)
+    })
+
+    const onLeaf = jest.fn(() => Promise.resolve())
+    await tree.db.transaction((tx) => tree.rebuild(tx, onLeaf))
+
+    expect(onLeaf).toHaveBeenCalledTimes(11)
+    await expect(tree).toMatchTree(await makeTree({ leaves: 'abcdefghijk' }))
+    await expect(tree.rootHash()).resolves.toEqual(rootHash)
+    await expect(tree.getNodeOrNull(100)).resolves.toBeNull()
   })
 
   it('truncates nodes correctly', async () => {
@@ -351,6 +342,87 @@ describe('Merkle tree', function () {
     }
   })
 
+  it('finds contained values with a filter', async () => {
+    const tree = await makeTree()
+    await tree.add('a')
+    await tree.add('b')
+
+    await tree.loadFilter()
+    await tree.add('c')
+
+    const get = jest.spyOn(tree.leavesIndex, 'get')
+
+    expect(await tree.contains('a')).toBe(true)
+    expect(await tree.contains('c')).toBe(true)
+    expect(get).toHaveBeenCalledTimes(2)
+
+    expect(await tree.contains('d')).toBe(false)
+    expect(get).toHaveBeenCalledTimes(2)
+
+    // Truncated leaves are still in the filter, so they are read from the database
+    await tree.truncate(2)
+    expect(await tree.contains('c')).toBe(false)
+  })
+
+  it('reads nodes from the node cache', async () => {
+    const tree = await makeTree({ leaves: 'abcdefg' })
+    const uncached = await makeTree({ leaves: 'abcdefg' })
+    tree.nodeCache.resize(1024 * 1024)
+
+    const get = jest.spyOn(tree.nodes, 'get')
+
+    const root = await tree.rootHash()
+    const reads = get.mock.calls.length
+    expect(reads).toBeGreaterThan(0)
+
+    expect(await tree.rootHash()).toEqual(root)
+    expect(get).toHaveBeenCalledTimes(reads)
+    expect(tree.nodeCache.hits).toBeGreaterThan(0)
+
+    // Adding and truncating rewrites nodes that are in the cache
+    for (const t of [tree, uncached]) {
+      await t.add('h')
+      await t.add('i')
+      await t.truncate(8)
+    }
+
+    expect(await tree.rootHash()).toEqual(await uncached.rootHash())
+    expect((await tree.witness(3))?.authenticationPath).toEqual(
+      (await uncached.witness(3))?.authenticationPath,
+    )
+  })
+
+  it('builds the filter again once the tree grows past it', async () => {
+    const tree = await makeTree()
+    await tree.loadFilter()
+    Assert.isNotNull(tree.filter)
+    expect(tree.filter.initialCapacity).toBe(1024)
+
+    const leaves = Array.from({ length: 1100 }, (_, i) => `leaf${i}`)
+    await tree.db.transaction(async (tx) => {
+      for (const leaf of leaves) {
+        await tree.add(leaf, tx)
+      }
+    })
+
+    // It was built for the tree once the leaves filled it
+    expect(tree.filter.initialCapacity).toBeGreaterThan(2000)
+    expect(tree.filter.count).toBe(1100)
+
+    for (const leaf of leaves) {
+      expect(tree.filter.has(tree.leavesIndex.keyEncoding.serialize(leaf))).toBe(true)
+    }
+
+    let falsePositives = 0
+    for (let i = 0; i < 10000; i++) {
+      if (tree.filter.has(tree.leavesIndex.keyEncoding.serialize(`missing${i}`))) {
+        falsePositives++
+      }
+    }
+
+    expect(falsePositives / 10000).toBeLessThan(0.02)
+  })
+
   it('calculates correct witnesses', async () => {
     const witnessOrThrowFactory =
       (witnessTree: MerkleTree<string, string, string, string>) => async (index: number) => {
@@ -572,4 +644,23 @@ describe('Merkle tree', function () {
     expect(depthAtLeafCount(32)).toBe(6)
     expect(depthAtLeafCount(33)).toBe(7)
   })
+
+  it('calculates node log positions', () => {
+    expect(completeNodeCount(0)).toBe(0)
+    expect(completeNodeCount(1)).toBe(0)
+    expect(completeNodeCount(2)).toBe(1)
+    expect(completeNodeCount(4)).toBe(3)
+    expect(completeNodeCount(7)).toBe(4)
+    expect(completeNodeCount(8)).toBe(7)
+    expect(completeNodeCount(2 ** 32)).toBe(2 ** 32 - 1)
+
+    expect(nodePosition(1, 0)).toBe(0)
+    expect(nodePosition(1, 1)).toBe(1)
+    expect(nodePosition(2, 0)).toBe(2)
+    expect(nodePosition(1, 2)).toBe(3)
+    expect(nodePosition(1, 3)).toBe(4)
+    expect(nodePosition(2, 1)).toBe(5)
+    expect(nodePosition(3, 0)).toBe(6)
+    expect(nodePosition(32, 0)).toBe(2 ** 32 - 2)
+  })
 })
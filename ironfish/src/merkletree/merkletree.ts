@@ -2,7 +2,8 @@
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 
-import { Assert } from '../assert'
+import { BloomFilter } from '../bloomFilter'
+import { LRUCache } from '../lruCache'
 import { JsonSerializable } from '../serde'
 import {
   DatabaseKey,
@@ -16,9 +17,12 @@ import {
 } from '../storage'
 import { MerkleHasher } from './hasher'
 import { CounterSchema, LeavesIndexSchema, LeavesSchema, NodesSchema } from './schema'
-import { depthAtLeafCount, isEmpty, isRight } from './utils'
+import { completeNodeCount, isRight, nodePosition } from './utils'
 import { Witness, WitnessNode } from './witness'
 
+// A rough number of heap bytes used by a cached node hash
+export const NODE_SIZE_ESTIMATE = 100
+
 export class MerkleTree<
   E,
   H extends DatabaseKey,
@@ -33,8 +37,32 @@ export class MerkleTree<
   readonly counter: IDatabaseStore<CounterSchema>
   readonly leaves: IDatabaseStore<LeavesSchema<E, H>>
   readonly leavesIndex: IDatabaseStore<LeavesIndexSchema<H>>
+  /**
+   * An append-only log of the hashes of complete nodes, the nodes whose leaves
+   * are all in the tree, in the order they were completed. Adding a leaf only
+   * appends the nodes it completes to the end of the log, and hashes of the
+   * incomplete nodes on the right edge of the tree are computed when read.
+   */
   readonly nodes: IDatabaseStore<NodesSchema<H>>
 
+  /**
+   * Holds the hash of every leaf once {@link MerkleTree.loadFilter} is called,
+   * so lookups of elements that are not in the tree skip the database
+   */
+  filter: BloomFilter | null = null
+
+  /**
+   * Recently read nodes. Only reads inside a transaction that hasn't written
+   * nodes fill it, since those hold the database lock and see committed nodes.
+   */
+  readonly nodeCache: LRUCache<NodeIndex, H>
+
+  // Transactions that wrote nodes, which must not read from or fill the cache
+  private readonly nodeWrites = new WeakSet<IDatabaseTransaction>()
+
+  // The right edge of the tree as it is being added to in each transaction
+  private readonly frontiers = new WeakMap<IDatabaseTransaction, Frontier<H>>()
+
   constructor({
     hasher,
     db,
@@ -43,6 +71,7 @@ export class MerkleTree<
     nodeEncoding,
     name = '',
     depth = 32,
+    nodeCache,
   }: {
     hasher: MerkleHasher<E, H, SE, SH>
     db: IDatabase
@@ -51,15 +80,22 @@ export class MerkleTree<
     nodeEncoding: IDatabaseEncoding<NodesSchema<H>['value']>
     name?: string
     depth?: number
+    nodeCache?: LRUCache<NodeIndex, H>
   }) {
     this.hasher = hasher
     this.db = db
     this.name = name
     this.depth = depth
+    this.nodeCache =
+      nodeCache ??
+      new LRUCache<NodeIndex, H>({
+        name: `${name}nodes`,
+        getSize: () => NODE_SIZE_ESTIMATE,
+      })
 
     this.counter = db.addStore({
       name: `${name}c`,
-      keyEncoding: new StringEncoding<'Leaves' | 'Nodes'>(),
+      keyEncoding: new StringEncoding<'Leaves'>(),
       valueEncoding: U32_ENCODING,
     })
 
@@ -86,10 +122,23 @@ export class MerkleTree<
     if ((await this.counter.get('Leaves')) === undefined) {
       await this.counter.put('Leaves', 0)
     }
+  }
 
-    if ((await this.counter.get('Nodes')) === undefined) {
-      await this.counter.put('Nodes', 1)
+  /**
+   * Build {@link MerkleTree.filter} from the leaves in the database. Leaves
+   * added after this are added to the filter as well, and it is built again
+   * once they fill it, so it stays sized for the tree instead of growing
+   * partitions with a higher error rate.
+   */
+  async loadFilter(tx?: IDatabaseTransaction): Promise<void> {
+    const size = await this.size(tx)
+    const filter = new BloomFilter({ capacity: Math.max(size * 2, 1024) })
+
+    for await (const merkleHash of this.leavesIndex.getAllKeysIter(tx)) {
+      filter.add(this.leavesIndex.keyEncoding.serialize(merkleHash))
     }
+
+    this.filter = filter
   }
 
   /**
@@ -145,37 +194,92 @@ export class MerkleTree<
   }
 
   /**
-   * Get the node element at the given index. Throws an error if the
-   * index is not in bounds.
+   * Get the hash stored at the given position of the node log. Throws an
+   * error if the position is not in the log.
    */
-  async getNode(
-    index: NodeIndex,
-    tx?: IDatabaseTransaction,
-  ): Promise<SchemaValue<NodesSchema<H>>> {
+  async getNode(index: NodeIndex, tx?: IDatabaseTransaction): Promise<H> {
     const node = await this.getNodeOrNull(index, tx)
-    if (!node) {
+    if (node === null) {
       throw new Error(`No node found in tree ${this.name} at index ${index}`)
     }
     return node
   }
 
   /**
-   * Get the node element at the given index. Returns null if the
-   * index is not in bounds.
+   * Get the hash stored at the given position of the node log. Returns null
+   * if the position is not in the log.
    */
-  async getNodeOrNull(
-    index: NodeIndex,
-    tx?: IDatabaseTransaction,
-  ): Promise<SchemaValue<NodesSchema<H>> | null> {
+  async getNodeOrNull(index: NodeIndex, tx?: IDatabaseTransaction): Promise<H | null> {
+    const cacheable = !tx || !this.nodeWrites.has(tx)
+
+    if (cacheable) {
+      const cached = this.nodeCache.get(index)
+      if (cached !== null) {
+        return cached
+      }
+    }
+
     const node = await this.nodes.get(index, tx)
-    return node || null
+
+    if (node !== undefined && tx && cacheable) {
+      this.nodeCache.set(index, node)
+    }
+
+    return node ?? null
+  }
+
+  private async putNode(index: NodeIndex, hash: H, tx: IDatabaseTransaction): Promise<void> {
+    this.nodeWrites.add(tx)
+    this.nodeCache.remove(index)
+    await this.nodes.put(index, hash, tx)
+  }
+
+  /**
+   * Get the hash of the complete node at `index` of `level`, where level 0
+   * holds the leaves
+   */
+  private async getNodeHash(
+    level: number,
+    index: number,
+    tx: IDatabaseTransaction,
+  ): Promise<H> {
+    if (level === 0) {
+      return (await this.getLeaf(index, tx)).merkleHash
+    }
+
+    return await this.getNode(nodePosition(level, index), tx)
+  }
+
+  /**
+   * Get the hash the node at `index` of `level` had when the tree contained
+   * `size` leaves. Only nodes on the right edge of the tree are incomplete, so
+   * this reads and hashes at most one node per level.
+   */
+  private async hashAt(
+    level: number,
+    index: number,
+    size: number,
+    tx: IDatabaseTransaction,
+  ): Promise<H> {
+    if ((index + 1) * 2 ** level <= size) {
+      return await this.getNodeHash(level, index, tx)
+    }
+
+    // An incomplete node without a right child is hashed with itself
+    const left = await this.hashAt(level - 1, index * 2, size, tx)
+    const right =
+      (index * 2 + 1) * 2 ** (level - 1) < size
+        ? await this.hashAt(level - 1, index * 2 + 1, size, tx)
+        : left
+
+    return this.hasher.combineHash(level - 1, left, right)
   }
 
   /**
    * Get the count of a given tree. Throws an error if the
    * count is not in the store.
    */
-  async getCount(countType: 'Leaves' | 'Nodes', tx?: IDatabaseTransaction): Promise<LeafIndex> {
+  async getCount(countType: 'Leaves', tx?: IDatabaseTransaction): Promise<LeafIndex> {
     const count = await this.counter.get(countType, tx)
     if (count === undefined) {
       throw new Error(`No counts found in tree ${this.name} for type ${countType}`)
@@ -200,159 +304,54 @@ export class MerkleTree<
   }
 
   /**
-   * Add the new leaf element into the tree, and update all hashes.
+   * Add the new leaf element into the tree, and append the nodes it completes
+   * to the node log.
    */
   async add(element: E, tx?: IDatabaseTransaction): Promise<void> {
     await this.db.withTransaction(tx, async (tx) => {
       const merkleHash = this.hasher.merkleHash(element)
-      const indexOfNewLeaf = await this.getCount('Leaves', tx)
-
-      let newParentIndex: NodeIndex
-
-      if (indexOfNewLeaf === 0) {
-        // Special case where this is the first leaf, with no parent
-        newParentIndex = 0
-      } else if (indexOfNewLeaf === 1) {
-        // Special case where this is the second leaf, and both leaves need a new parent
-        newParentIndex = 1
-
-        const leftLeafIndex = 0
-        const leftLeaf = await this.getLeaf(leftLeafIndex, tx)
-        const hashOfSibling = this.hasher.combineHash(0, leftLeaf.merkleHash, merkleHash)
-
-        await this.nodes.put(
-          newParentIndex,
-          {
-            side: Side.Left,
-            parentIndex: 0,
-            hashOfSibling,
-          },
-          tx,
-        )
-
-        await this.addLeaf(
-          leftLeafIndex,
-          {
-            element: leftLeaf.element,
-            merkleHash: leftLeaf.merkleHash,
-            parentIndex: newParentIndex,
-          },
-          tx,
-        )
-
-        await this.counter.put('Nodes', 2, tx)
-      } else if (isRight(indexOfNewLeaf)) {
-        // Simple case where we are adding a new node to a parent with an empty right child
-        const leftLeafIndex = indexOfNewLeaf - 1
-        const leaf = await this.getLeaf(leftLeafIndex, tx)
-        newParentIndex = leaf.parentIndex
-      } else {
-        // Walk up the path from the previous leaf until finding an empty or right-hand node
-        // Create a bunch of left-hand nodes for each step up that path
-        const previousLeafIndex = indexOfNewLeaf - 1
-        const previousLeaf = await this.getLeaf(previousLeafIndex, tx)
-        let previousParentIndex = previousLeaf.parentIndex
-
-        let nextNodeIndex = await this.getCount('Nodes', tx)
-        let myHash = this.hasher.combineHash(0, merkleHash, merkleHash)
-        let depth = 1
-        let shouldContinue = true
-
-        newParentIndex = nextNodeIndex
-
-        while (shouldContinue) {
-          const previousParent = await this.getNode(previousParentIndex, tx)
-
-          if (previousParent.side === Side.Left) {
-            // found a node we can attach a child too; hook it up to the new chain of left nodes
-            const newNode = {
-              side: Side.Right,
-              leftIndex: previousParentIndex,
-              hashOfSibling: previousParent.hashOfSibling,
-              index: nextNodeIndex,
-            }
-
-            await this.nodes.put(nextNodeIndex, newNode, tx)
-            nextNodeIndex += 1
-
-            await this.counter.put('Nodes', nextNodeIndex, tx)
-
-            if (!previousParent.parentIndex || isEmpty(previousParent.parentIndex)) {
-              const newParent = {
-                side: Side.Left,
-                parentIndex: 0,
-                hashOfSibling: this.hasher.combineHash(
-                  depth,
-                  previousParent.hashOfSibling,
-                  myHash,
-                ),
-                index: nextNodeIndex,
-              }
-
-              await this.nodes.put(nextNodeIndex, newParent, tx)
-
-              await this.nodes.put(
-                previousParentIndex,
-                {
-                  side: Side.Left,
-                  hashOfSibling: previousParent.hashOfSibling,
-                  parentIndex: nextNodeIndex,
-                },
-                tx,
-              )
-
-              nextNodeIndex += 1
-              await this.counter.put('Nodes', nextNodeIndex, tx)
-            }
-
-            shouldContinue = false
-          } else {
-            // previous parent is a right node, gotta go up a step
-            myHash = this.hasher.combineHash(depth, myHash, myHash)
-
-            if (previousParent.leftIndex === undefined) {
-              throw new Error(`Parent has no left sibling`)
-            }
-
-            const leftSibling = await this.getNode(previousParent.leftIndex, tx)
-
-            if (leftSibling.parentIndex === undefined) {
-              throw new Error(`Left sibling has no parent`)
-            }
-            const leftSiblingParentIndex = leftSibling.parentIndex
-
-            const newNode = {
-              side: Side.Left,
-              parentIndex: nextNodeIndex + 1, // where the next node will be (in the next iteration)
-              hashOfSibling: myHash,
-              index: nextNodeIndex,
-            }
-            await this.nodes.put(nextNodeIndex, newNode, tx)
-
-            nextNodeIndex += 1
-
-            await this.counter.put('Nodes', nextNodeIndex, tx)
-
-            previousParentIndex = leftSiblingParentIndex
-            depth += 1
-          }
-        }
+      const index = await this.getCount('Leaves', tx)
+
+      let frontier = this.frontiers.get(tx)
+      if (!frontier || frontier.size !== index) {
+        frontier = { size: index, hashes: [] }
+        this.frontiers.set(tx, frontier)
+      }
+
+      await this.addLeaf(index, { element, merkleHash }, tx)
+      await this.appendNodes(index, merkleHash, frontier, tx)
+      await this.counter.put('Leaves', index + 1, tx)
+    })
+  }
+
+  /**
+   * Walk up from the leaf at `index`, appending each node the leaf completes
+   * to the node log. The left siblings on the way come from the frontier when
+   * they were added in this transaction.
+   */
+  private async appendNodes(
+    index: LeafIndex,
+    merkleHash: H,
+    frontier: Frontier<H>,
+    tx: IDatabaseTransaction,
+  ): Promise<void> {
+    let hash = merkleHash
+    let nodeIndex = index
+
+    for (let level = 0; level < this.depth; level++) {
+      if (!isRight(nodeIndex)) {
+        frontier.hashes[level] = hash
+        break
       }
 
-      await this.counter.put('Leaves', indexOfNewLeaf + 1, tx)
+      const left = frontier.hashes[level] ?? (await this.getNodeHash(level, nodeIndex - 1, tx))
+      hash = this.hasher.combineHash(level, left, hash)
+      nodeIndex = Math.floor(nodeIndex / 2)
 
-      await this.addLeaf(
-        indexOfNewLeaf,
-        {
-          element,
-          merkleHash,
-          parentIndex: newParentIndex,
-        },
-        tx,
-      )
+      await this.putNode(nodePosition(level + 1, nodeIndex), hash, tx)
+    }
 
-      await this.rehashRightPath(tx)
-    })
+    frontier.size = index + 1
   }
 
   async addLeaf(
@@ -365,12 +364,26 @@ export class MerkleTree<
       {
         element: value.element,
         merkleHash: value.merkleHash,
-        parentIndex: value.parentIndex,
       },
       tx,
     )
 
     await this.leavesIndex.put(value.merkleHash, index, tx)
+
+    // Leaves from aborted transactions or truncated later stay in the filter,
+    // which only costs a database read when they are looked up
+    if (this.filter) {
+      const key = this.leavesIndex.keyEncoding.serialize(value.merkleHash)
+      if (!this.filter.has(key)) {
+        this.filter.add(key)
+      }
+
+      // The transaction holds the lock while the filter is built again, so no
+      // leaf can be added that the new filter misses
+      if (this.filter.count >= this.filter.initialCapacity) {
+        await this.loadFilter(tx)
+      }
+    }
   }
 
   /**
@@ -382,7 +395,7 @@ export class MerkleTree<
    *
    * This function doesn't do any garbage collection. The old leaves and nodes
    * are still in the database, but they will be overwritten as the new tree
-   * grows.
+   * grows, since the node log only holds the nodes of the first pastSize leaves.
    */
   async truncate(pastSize: number, tx?: IDatabaseTransaction): Promise<void> {
     return await this.db.withTransaction(tx, async (tx) => {
@@ -399,54 +412,40 @@ export class MerkleTree<
         await this.leavesIndex.del(leaf.merkleHash, tx)
       }
 
-      if (pastSize === 0) {
-        await this.counter.put('Nodes', 1, tx)
-        return
-      }
-
-      if (pastSize === 1) {
-        await this.counter.put('Nodes', 1, tx)
-
-        const index = 0
-        const firstLeaf = await this.getLeaf(index, tx)
-        firstLeaf.parentIndex = 0
-        await this.addLeaf(index, firstLeaf, tx)
-        return
-      }
-
-      const depth = depthAtLeafCount(pastSize) - 2
-      const leaf = await this.getLeaf(pastSize - 1, tx)
-      let parentIndex = leaf.parentIndex
-      let maxParentIndex = parentIndex
-
-      for (let i = 0; i < depth; i++) {
-        let parent = await this.getNode(parentIndex, tx)
+      this.frontiers.delete(tx)
+    })
+  }
 
-        if (parent.side === Side.Right) {
-          Assert.isNotUndefined(parent.leftIndex)
-          parent = await this.getNode(parent.leftIndex, tx)
-        }
+  /**
+   * Rewrite the leaves and node log from the leaves in the database, deleting
+   * nodes past the end of the log. Used to migrate trees stored in an older
+   * format. `onLeaf` is called after each leaf is rewritten.
+   */
+  async rebuild(
+    tx: IDatabaseTransaction,
+    onLeaf?: (index: LeafIndex) => Promise<void>,
+  ): Promise<void> {
+    const size = await this.size(tx)
+    const nodeCount = completeNodeCount(size)
 
-        Assert.isNotUndefined(parent.parentIndex)
-        parentIndex = parent.parentIndex
+    this.nodeWrites.add(tx)
+    this.nodeCache.clear()
 
-        if (parent.parentIndex > maxParentIndex) {
-          maxParentIndex = parent.parentIndex
-        }
+    for await (const index of this.nodes.getAllKeysIter(tx)) {
+      if (index >= nodeCount) {
+        await this.nodes.del(index, tx)
       }
+    }
 
-      const parent = await this.getNode(parentIndex, tx)
-
-      if (parent.side === Side.Right) {
-        // Indicates error in this method's code
-        throw new Error('Expected new root node to be a left node')
-      }
+    const frontier: Frontier<H> = { size: 0, hashes: [] }
+    this.frontiers.set(tx, frontier)
 
-      parent.parentIndex = 0
-      await this.nodes.put(parentIndex, parent, tx)
-      await this.counter.put('Nodes', maxParentIndex + 1, tx)
-      await this.rehashRightPath(tx)
-    })
+    for (let index = 0; index < size; index++) {
+      const leaf = await this.getLeaf(index, tx)
+      await this.leaves.put(index, { element: leaf.element, merkleHash: leaf.merkleHash }, tx)
+      await this.appendNodes(index, leaf.merkleHash, frontier, tx)
+      await onLeaf?.(index)
+    }
   }
 
   /**
@@ -463,51 +462,7 @@ export class MerkleTree<
         throw new Error(`Unable to get past size ${pastSize} for tree with ${leafCount} nodes`)
       }
 
-      const rootDepth = depthAtLeafCount(pastSize)
-      const minTreeDepth = Math.min(rootDepth, this.depth)
-      const leafIndex = pastSize - 1
-      const leaf = await this.getLeaf(leafIndex, tx)
-
-      let currentHash = leaf.merkleHash
-      let currentNodeIndex = leaf.parentIndex
-
-      if (isRight(leafIndex)) {
-        const sibling = await this.getLeaf(leafIndex - 1, tx)
-        const siblingHash = sibling.merkleHash
-        currentHash = this.hasher.combineHash(0, siblingHash, currentHash)
-      } else {
-        currentHash = this.hasher.combineHash(0, currentHash, currentHash)
-      }
-
-      for (let depth = 1; depth < minTreeDepth; depth++) {
-        const node = await this.getNode(currentNodeIndex, tx)
-
-        switch (node.side) {
-          case Side.Left:
-            Assert.isNotUndefined(node.parentIndex)
-            currentNodeIndex = node.parentIndex
-            currentHash = this.hasher.combineHash(depth, currentHash, currentHash)
-            break
-
-          case Side.Right: {
-            Assert.isNotUndefined(node.leftIndex)
-            const leftNode = await this.getNode(node.leftIndex, tx)
-            Assert.isNotUndefined(leftNode.parentIndex)
-            currentNodeIndex = leftNode.parentIndex
-            currentHash = this.hasher.combineHash(depth, node.hashOfSibling, currentHash)
-            break
-          }
-
-          default:
-            Assert.isUnreachable(node.side)
-        }
-      }
-
-      for (let depth = rootDepth; depth < this.depth; depth++) {
-        currentHash = this.hasher.combineHash(depth, currentHash, currentHash)
-      }
-
-      return currentHash
+      return await this.hashAt(this.depth, 0, pastSize, tx)
     })
   }
 
@@ -523,8 +478,14 @@ export class MerkleTree<
    * Check if the tree contained the given element when it was the given size.
    */
   async contained(value: E, pastSize: number, tx?: IDatabaseTransaction): Promise<boolean> {
+    const merkleHash = this.hasher.merkleHash(value)
+
+    if (this.filter && !this.filter.has(this.leavesIndex.keyEncoding.serialize(merkleHash))) {
+      return false
+    }
+
     return this.db.withTransaction(tx, async (tx) => {
-      const elementIndex = await this.leavesIndex.get(this.hasher.merkleHash(value), tx)
+      const elementIndex = await this.leavesIndex.get(merkleHash, tx)
 
       return elementIndex !== undefined && elementIndex < pastSize
     })
@@ -562,125 +523,30 @@ export class MerkleTree<
         return null
       }
 
-      const leaf = await this.getLeaf(index, tx)
-      let currentHash = leaf.merkleHash
-      let currentPosition = leaf.parentIndex as NodeIndex | undefined
-
-      if (isRight(index)) {
-        const hashOfSibling = (await this.getLeaf(index - 1, tx)).merkleHash
-        authenticationPath.push({ side: Side.Right, hashOfSibling })
-        currentHash = this.hasher.combineHash(0, hashOfSibling, currentHash)
-      } else if (index < leafCount - 1) {
-        // Left leaf and have a right sibling
-        const hashOfSibling = (await this.getLeaf(index + 1, tx)).merkleHash
-        authenticationPath.push({ side: Side.Left, hashOfSibling })
-        currentHash = this.hasher.combineHash(0, currentHash, hashOfSibling)
-      } else {
-        // Left leaf and rightmost node
-        authenticationPath.push({ side: Side.Left, hashOfSibling: currentHash })
-        currentHash = this.hasher.combineHash(0, currentHash, currentHash)
-      }
+      let currentHash = (await this.getLeaf(index, tx)).merkleHash
+      let nodeIndex = index
 
-      for (let depth = 1; depth < this.depth; depth++) {
-        const node =
-          currentPosition !== undefined ? await this.getNodeOrNull(currentPosition, tx) : null
-
-        if (node === null) {
-          authenticationPath.push({ side: Side.Left, hashOfSibling: currentHash })
-          currentHash = this.hasher.combineHash(depth, currentHash, currentHash)
-        } else if (node.side === Side.Left) {
-          authenticationPath.push({ side: Side.Left, hashOfSibling: node.hashOfSibling })
-          currentHash = this.hasher.combineHash(depth, currentHash, node.hashOfSibling)
-          currentPosition = node.parentIndex
+      for (let level = 0; level < this.depth; level++) {
+        if (isRight(nodeIndex)) {
+          const hashOfSibling = await this.getNodeHash(level, nodeIndex - 1, tx)
+          authenticationPath.push({ side: Side.Right, hashOfSibling })
+          currentHash = this.hasher.combineHash(level, hashOfSibling, currentHash)
         } else {
-          authenticationPath.push({ side: Side.Right, hashOfSibling: node.hashOfSibling })
-          currentHash = this.hasher.combineHash(depth, node.hashOfSibling, currentHash)
-          Assert.isNotUndefined(node.leftIndex)
-          const leftSibling = await this.getNode(node.leftIndex, tx)
-          currentPosition = leftSibling.parentIndex
+          // The rightmost node of a level is its own sibling
+          const hashOfSibling =
+            (nodeIndex + 1) * 2 ** level < leafCount
+              ? await this.hashAt(level, nodeIndex + 1, leafCount, tx)
+              : currentHash
+          authenticationPath.push({ side: Side.Left, hashOfSibling })
+          currentHash = this.hasher.combineHash(level, currentHash, hashOfSibling)
         }
+
+        nodeIndex = Math.floor(nodeIndex / 2)
       }
 
       return new Witness(leafCount, currentHash, authenticationPath, this.hasher)
     })
   }
-
-  /**
-   * Recalculate all the hashes between the most recently added leaf in the group
-   * and the root hash.
-   *
-   * `transaction` is passed in so that a rollback happens for the entire change
-   * if a conflict occurs.
-   */
-  private async rehashRightPath(tx: IDatabaseTransaction) {
-    let depth = 0
-    const leafIndex = (await this.getCount('Leaves', tx)) - 1
-    const leaf = await this.getLeaf(leafIndex, tx)
-    let parentIndex = leaf.parentIndex as NodeIndex | undefined
-    const leafHash = leaf.merkleHash
-    let parentHash
-
-    if (isRight(leafIndex)) {
-      const leftSiblingIndex = leafIndex - 1
-      const leftSibling = await this.getLeaf(leftSiblingIndex, tx)
-      const leftSiblingHash = leftSibling.merkleHash
-      parentHash = this.hasher.combineHash(depth, leftSiblingHash, leafHash)
-    } else {
-      parentHash = this.hasher.combineHash(depth, leafHash, leafHash)
-    }
-
-    while (!isEmpty(parentIndex)) {
-      const node = await this.getNode(parentIndex, tx)
-      depth += 1
-
-      switch (node.side) {
-        case Side.Left: {
-          // Since we are walking the rightmost path, left nodes do not
-          // have right children. Therefore its sibling hash is set to its
-          // own hash and its parent hash is set to the combination of that hash
-          // with itself
-          await this.nodes.put(
-            parentIndex,
-            {
-              side: Side.Left,
-              hashOfSibling: parentHash,
-              parentIndex: node.parentIndex,
-            },
-            tx,
-          )
-
-          parentIndex = node.parentIndex
-          parentHash = this.hasher.combineHash(depth, parentHash, parentHash)
-          break
-        }
-
-        case Side.Right: {
-          // since this is a new right node, we know that we have the correct
-          // hash because we set it correctly when we inserted it. But the left
-          // node needs to have its hashOfSibling set to our current hash.
-          if (node.leftIndex === undefined) {
-            throw new Error(`Expected node ${parentIndex} to have left node`)
-          }
-
-          const leftNode = await this.getNode(node.leftIndex, tx)
-
-          await this.nodes.put(
-            node.leftIndex,
-            {
-              side: Side.Left,
-              parentIndex: leftNode.parentIndex,
-              hashOfSibling: parentHash,
-            },
-            tx,
-          )
-
-          parentIndex = leftNode.parentIndex
-          parentHash = this.hasher.combineHash(depth, node.hashOfSibling, parentHash)
-          break
-        }
-      }
-    }
-  }
 }
 
 export enum Side {
@@ -690,3 +556,10 @@ export enum Side {
 
 export type LeafIndex = number
 export type NodeIndex = number
+
+type Frontier<H> = {
+  // The number of leaves the frontier was built for
+  size: number
+  // The hash of the last node added to each level
+  hashes: H[]
+}
@@ -14,13 +14,6 @@ export function isRight(index: LeafIndex): boolean {
   return index % 2 === 1
 }
 
-/**
- * Is the given node index the empty node above the root node?
- */
-export function isEmpty(index: NodeIndex | undefined): index is undefined | 0 {
-  return index === 0 || index === undefined
-}
-
 /**
  * The depth of the tree when it contains a certain number of leaf nodes
  */
@@ -35,3 +28,32 @@ export function depthAtLeafCount(size: number): number {
 
   return Math.floor(Math.log2(size - 1)) + 2
 }
+
+/**
+ * The number of complete nodes above the leaves, the nodes in the node log,
+ * when the tree contains a certain number of leaf nodes
+ */
+export function completeNodeCount(size: number): number {
+  return size - popcount(size)
+}
+
+/**
+ * The position in the node log of the node at `index` of `level`, where level
+ * 0 holds the leaves. The node is appended to the log when its last leaf is
+ * added, after the nodes below it.
+ */
+export function nodePosition(level: number, index: number): NodeIndex {
+  const size = (index + 1) * 2 ** level
+  return completeNodeCount(size - 1) + level - 1
+}
+
+// Sizes can be larger than 2^31, which bitwise operators don't support
+function popcount(value: number): number {
+  let count = 0
+
+  for (let rest = value; rest > 0; rest = Math.floor(rest / 2)) {
+    count += rest % 2
+  }
+
+  return count
+}
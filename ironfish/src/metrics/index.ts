@@ -4,3 +4,5 @@
 export * from './meter'
 export * from './metricsMonitor'
 export * from './ewmAverage'
+export * from './metricsHistory'
+export * from './tracer'
@@ -0,0 +1,31 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { createNodeTest } from '../testUtilities'
+import { METRICS_HISTORY_RETENTION_MS } from './metricsHistory'
+
+describe('MetricsHistory', () => {
+  const nodeTest = createNodeTest()
+
+  it('prunes samples older than the retention period', () => {
+    const history = nodeTest.node.metricsHistory
+    const now = Date.now()
+
+    history.sample(now - METRICS_HISTORY_RETENTION_MS - 1)
+    history.sample(now - 1000)
+    history.sample(now)
+
+    const samples = history.getSamples()
+    expect(samples).toHaveLength(2)
+    expect(samples[0].timestamp).toBe(now - 1000)
+  })
+
+  it('saves samples to the store', async () => {
+    const history = nodeTest.node.metricsHistory
+    const sample = history.sample()
+
+    await history.save()
+
+    expect(history.store.get('samples')).toEqual([sample])
+  })
+})
@@ -0,0 +1,104 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { MetricsHistorySample, MetricsHistoryStore } from '../fileStores/metricsHistory'
+import { createRootLogger, Logger } from '../logger'
+import { IronfishNode } from '../node'
+import { ErrorUtils, MathUtils, SetIntervalToken } from '../utils'
+
+/**
+ * How long samples are kept before being pruned from the history
+ */
+export const METRICS_HISTORY_RETENTION_MS = 7 * 24 * 60 * 60 * 1000
+
+const SAMPLE_INTERVAL_MS = 60 * 1000
+const SAVE_INTERVAL_MS = 5 * 60 * 1000
+
+/**
+ * Keeps a rolling time-series of key node metrics in the data dir so
+ * users can compare current performance against the recent past.
+ */
+export class MetricsHistory {
+  readonly store: MetricsHistoryStore
+  readonly node: IronfishNode
+  readonly logger: Logger
+
+  private samples: MetricsHistorySample[] = []
+  private sampleInterval: SetIntervalToken | null = null
+  private saveInterval: SetIntervalToken | null = null
+  private started = false
+
+  constructor(options: { store: MetricsHistoryStore; node: IronfishNode; logger?: Logger }) {
+    this.store = options.store
+    this.node = options.node
+    this.logger = (options.logger ?? createRootLogger()).withTag('metricshistory')
+  }
+
+  start(): void {
+    if (this.started) {
+      return
+    }
+
+    this.started = true
+    this.samples = this.prune(this.store.get('samples'))
+
+    this.sampleInterval = setInterval(() => this.sample(), SAMPLE_INTERVAL_MS)
+    this.saveInterval = setInterval(() => void this.save(), SAVE_INTERVAL_MS)
+  }
+
+  async stop(): Promise<void> {
+    if (!this.started) {
+      return
+    }
+
+    this.started = false
+
+    if (this.sampleInterval) {
+      clearInterval(this.sampleInterval)
+    }
+
+    if (this.saveInterval) {
+      clearInterval(this.saveInterval)
+    }
+
+    await this.save()
+  }
+
+  /**
+   * Returns all samples recorded at or after `since`, a unix timestamp in milliseconds
+   */
+  getSamples(since = 0): MetricsHistorySample[] {
+    return this.samples.filter((s) => s.timestamp >= since)
+  }
+
+  sample(now = Date.now()): MetricsHistorySample {
+    const sample: MetricsHistorySample = {
+      timestamp: now,
+      blockAddTime: MathUtils.round(this.node.chain.addSpeed.avg, 2),
+      headSequence: this.node.chain.head.sequence,
+      peers: this.node.metrics.p2p_PeersCount.value,
+      memPoolSize: this.node.metrics.memPoolSize.value,
+      heapUsed: this.node.metrics.heapUsed.value,
+      hashRate: this.node.miningManager.poolHashRate,
+    }
+
+    this.samples.push(sample)
+    this.samples = this.prune(this.samples, now)
+    return sample
+  }
+
+  async save(): Promise<void> {
+    this.store.set('samples', this.samples)
+
+    try {
+      await this.store.save()
+    } catch (e) {
+      this.logger.error(`Failed to save metrics history: ${ErrorUtils.renderError(e)}`)
+    }
+  }
+
+  private prune(samples: MetricsHistorySample[], now = Date.now()): MetricsHistorySample[] {
+    const cutoff = now - METRICS_HISTORY_RETENTION_MS
+    return samples.filter((s) => s.timestamp >= cutoff)
+  }
+}
@@ -10,6 +10,7 @@ import { NetworkMessageType } from '../network/types'
 import { NumberEnumUtils, SetIntervalToken } from '../utils'
 import { Gauge } from './gauge'
 import { Meter } from './meter'
+import { RollingAverage } from './rollingAverage'
 
 export class MetricsMonitor {
   private _started = false
@@ -26,6 +27,30 @@ export class MetricsMonitor {
   readonly p2p_OutboundTrafficByMessage: Map<NetworkMessageType, Meter> = new Map()
   readonly p2p_PeersCount: Gauge
 
+  /**
+   * Milliseconds from first hearing about a block to validating and forwarding it
+   */
+  readonly p2p_BlockPropagation: RollingAverage
+
+  /**
+   * Milliseconds from broadcasting a block we mined to a peer relaying it back to us
+   */
+  readonly p2p_MinedBlockAcknowledgement: RollingAverage
+
+  // Gossip messages that were already seen, or seen for the first time
+  readonly p2p_GossipCacheHits: Meter
+  readonly p2p_GossipCacheMisses: Meter
+  readonly p2p_GossipCacheSize: Gauge
+
+  // Chain reads that were found in, or missed, the caches of recent headers,
+  // blocks and merkle tree nodes
+  readonly chain_HeaderCacheHits: Meter
+  readonly chain_HeaderCacheMisses: Meter
+  readonly chain_BlockCacheHits: Meter
+  readonly chain_BlockCacheMisses: Meter
+  readonly chain_TreeNodeCacheHits: Meter
+  readonly chain_TreeNodeCacheMisses: Meter
+
   // Elements of this map are managed by Peer and PeerNetwork
   p2p_OutboundMessagesByPeer: Map<Identity, Meter> = new Map()
 
@@ -56,6 +81,18 @@ export class MetricsMonitor {
     }
 
     this.p2p_PeersCount = new Gauge()
+    this.p2p_BlockPropagation = new RollingAverage(100)
+    this.p2p_MinedBlockAcknowledgement = new RollingAverage(100)
+    this.p2p_GossipCacheHits = this.addMeter()
+    this.p2p_GossipCacheMisses = this.addMeter()
+    this.p2p_GossipCacheSize = new Gauge()
+
+    this.chain_HeaderCacheHits = this.addMeter()
+    this.chain_HeaderCacheMisses = this.addMeter()
+    this.chain_BlockCacheHits = this.addMeter()
+    this.chain_BlockCacheMisses = this.addMeter()
+    this.chain_TreeNodeCacheHits = this.addMeter()
+    this.chain_TreeNodeCacheMisses = this.addMeter()
 
     this.heapTotal = new Gauge()
     this.heapUsed = new Gauge()
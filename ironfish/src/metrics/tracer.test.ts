@@ -0,0 +1,71 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+import { Tracer } from './tracer'
+
+describe('Tracer', () => {
+  it('reports operations that exceed the threshold', () => {
+    const tracer = new Tracer({ thresholdMs: 100 })
+    const warn = jest.spyOn(tracer.logger, 'warn').mockImplementation()
+    const onSlow = jest.fn()
+    tracer.onSlowOperation.on(onSlow)
+
+    tracer.report('block.verify', 50)
+    expect(onSlow).not.toHaveBeenCalled()
+
+    tracer.report('block.verify', 150, { sequence: 2 })
+    expect(onSlow).toHaveBeenCalledWith({
+      operation: 'block.verify',
+      elapsedMs: 150,
+      thresholdMs: 100,
+      details: { sequence: 2 },
+    })
+    expect(warn).toHaveBeenCalledWith(
+      'Slow operation block.verify took 150.0ms',
+      expect.objectContaining({ operation: 'block.verify', elapsedMs: 150, sequence: 2 }),
+    )
+  })
+
+  it('does not report when the threshold is 0', () => {
+    const tracer = new Tracer({ thresholdMs: 0 })
+    const onSlow = jest.fn()
+    tracer.onSlowOperation.on(onSlow)
+
+    tracer.report('block.verify', 5000)
+    expect(onSlow).not.toHaveBeenCalled()
+  })
+
+  it('emits every span regardless of the threshold', () => {
+    const tracer = new Tracer({ thresholdMs: 0 })
+    const onSpan = jest.fn()
+    tracer.onSpan.on(onSpan)
+
+    tracer.report('block.commit', 5, { sequence: 3 })
+    expect(onSpan).toHaveBeenCalledWith('block.commit', 5, { sequence: 3 })
+  })
+
+  it('ends a span only once', () => {
+    const tracer = new Tracer({ thresholdMs: 100 })
+    const report = jest.spyOn(tracer, 'report').mockImplementation()
+
+    const span = tracer.start('block.commit', { sequence: 3 })
+    const elapsed = span.end({ notes: 1 })
+
+    expect(span.ended).toBe(true)
+    expect(span.end()).toBe(elapsed)
+    expect(report).toHaveBeenCalledTimes(1)
+    expect(report).toHaveBeenCalledWith('block.commit', elapsed, { sequence: 3, notes: 1 })
+  })
+
+  it('traces async handlers that throw', async () => {
+    const tracer = new Tracer({ thresholdMs: 100 })
+    const report = jest.spyOn(tracer, 'report').mockImplementation()
+
+    await expect(
+      tracer.trace('rpc.handler', () => Promise.reject(new Error('failed'))),
+    ).rejects.toThrow('failed')
+
+    expect(report).toHaveBeenCalledWith('rpc.handler', expect.any(Number), {})
+  })
+})
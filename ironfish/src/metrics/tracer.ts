@@ -0,0 +1,111 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { Event } from '../event'
+import { createRootLogger, Logger } from '../logger'
+import { BenchUtils, HRTime } from '../utils/bench'
+
+export type SpanDetails = Record<string, string | number | boolean>
+
+export type SlowOperation = {
+  operation: string
+  elapsedMs: number
+  thresholdMs: number
+  details: SpanDetails
+}
+
+export class Span {
+  readonly tracer: Tracer
+  readonly operation: string
+  readonly details: SpanDetails
+  private readonly startTime: HRTime
+  private elapsed: number | null = null
+
+  constructor(tracer: Tracer, operation: string, details: SpanDetails = {}) {
+    this.tracer = tracer
+    this.operation = operation
+    this.details = details
+    this.startTime = BenchUtils.start()
+  }
+
+  get ended(): boolean {
+    return this.elapsed !== null
+  }
+
+  /**
+   * Ends the span and reports it to the tracer. Calling end more than once
+   * returns the elapsed time of the first call.
+   * @returns milliseconds since the span started
+   */
+  end(details?: SpanDetails): number {
+    if (this.elapsed !== null) {
+      return this.elapsed
+    }
+
+    this.elapsed = BenchUtils.end(this.startTime)
+    this.tracer.report(this.operation, this.elapsed, { ...this.details, ...details })
+    return this.elapsed
+  }
+}
+
+/**
+ * Records timing spans for operations and logs a structured "slow op"
+ * entry whenever one takes longer than the configured threshold
+ */
+export class Tracer {
+  readonly logger: Logger
+  readonly onSlowOperation = new Event<[SlowOperation]>()
+
+  /**
+   * Emitted for every span that ends, regardless of the threshold
+   */
+  readonly onSpan = new Event<[operation: string, elapsedMs: number, details: SpanDetails]>()
+
+  /**
+   * The number of milliseconds an operation may take before it's reported
+   * as slow. 0 disables reporting.
+   */
+  thresholdMs: number
+
+  constructor(options: { thresholdMs?: number; logger?: Logger } = {}) {
+    this.logger = (options.logger ?? createRootLogger()).withTag('tracer')
+    this.thresholdMs = options.thresholdMs ?? 0
+  }
+
+  start(operation: string, details?: SpanDetails): Span {
+    return new Span(this, operation, details)
+  }
+
+  async trace<T>(
+    operation: string,
+    handler: () => Promise<T>,
+    details?: SpanDetails,
+  ): Promise<T> {
+    const span = this.start(operation, details)
+
+    try {
+      return await handler()
+    } finally {
+      span.end()
+    }
+  }
+
+  report(operation: string, elapsedMs: number, details: SpanDetails = {}): void {
+    this.onSpan.emit(operation, elapsedMs, details)
+
+    if (this.thresholdMs <= 0 || elapsedMs < this.thresholdMs) {
+      return
+    }
+
+    const slow = { operation, elapsedMs, thresholdMs: this.thresholdMs, details }
+
+    this.logger.warn(`Slow operation ${operation} took ${elapsedMs.toFixed(1)}ms`, {
+      ...details,
+      operation,
+      elapsedMs: Number(elapsedMs.toFixed(1)),
+      thresholdMs: this.thresholdMs,
+    })
+
+    this.onSlowOperation.emit(slow)
+  }
+}
@@ -0,0 +1,34 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { createNodeTest } from '../../testUtilities'
+import { Migrator } from '../migrator'
+import { Migration007 } from './007-merkleNodeLog'
+
+describe('Migration007', () => {
+  const nodeTest = createNodeTest()
+
+  it('rebuilds the node logs of the trees', async () => {
+    const { node, chain } = nodeTest
+
+    for (let i = 0; i < 5; i++) {
+      await chain.nullifiers.add(Buffer.alloc(32, i))
+    }
+
+    const noteRoot = await chain.notes.rootHash()
+    const nullifierRoot = await chain.nullifiers.rootHash()
+
+    // Nodes stored before the migration are in other positions of the store
+    await chain.nullifiers.nodes.clear()
+    await chain.nullifiers.nodes.put(100, Buffer.alloc(32))
+    await chain.db.setVersion(6)
+
+    const migrator = new Migrator({ node, migrations: [new Migration007()] })
+    await migrator.migrate({ backup: false })
+
+    expect(await chain.db.getVersion()).toBe(7)
+    await expect(chain.notes.rootHash()).resolves.toEqual(noteRoot)
+    await expect(chain.nullifiers.rootHash()).resolves.toEqual(nullifierRoot)
+    await expect(chain.nullifiers.getNodeOrNull(100)).resolves.toBeNull()
+  })
+})
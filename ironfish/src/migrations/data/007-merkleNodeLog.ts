@@ -0,0 +1,45 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { Migration, MigrationContext, MigrationDatabase } from '../migration'
+
+// Commit every so many leaves so the transaction doesn't hold the whole tree
+const LEAVES_PER_UPDATE = 10000
+
+/**
+ * Store the nodes of the notes and nullifiers trees in an append-only log of
+ * hashes, and drop the parent index from their leaves. The 'Nodes' counter the
+ * trees used to keep is no longer read, and is left in the database.
+ */
+export class Migration007 extends Migration {
+  readonly version = 7
+  readonly name = 'merkle-node-log'
+  readonly database = MigrationDatabase.CHAIN
+
+  async forward({ node, tx, logger, dryRun }: MigrationContext): Promise<void> {
+    const trees = [
+      { label: 'notes', tree: node.chain.notes },
+      { label: 'nullifiers', tree: node.chain.nullifiers },
+    ]
+
+    for (const { label, tree } of trees) {
+      const size = await tree.size(tx)
+      logger.info(`Rebuilding the ${label} tree with ${size} leaves`)
+
+      await tree.rebuild(tx, async (index) => {
+        if ((index + 1) % LEAVES_PER_UPDATE !== 0) {
+          return
+        }
+
+        logger.info(`Rebuilt ${index + 1} of ${size} ${label}`)
+
+        // Rebuilding is idempotent, so a migration that fails after this
+        // commits can be run again. A dry run has to hold every change to
+        // abort them.
+        if (!dryRun) {
+          await tx.update()
+        }
+      })
+    }
+  }
+}
@@ -0,0 +1,30 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { createNodeTest, useMinerBlockFixture } from '../../testUtilities'
+import { Migrator } from '../migrator'
+import { Migration008 } from './008-transactionHashIndex'
+
+describe('Migration008', () => {
+  const nodeTest = createNodeTest()
+
+  it('indexes the transactions of the main chain', async () => {
+    const { node, chain } = nodeTest
+
+    const block = await useMinerBlockFixture(chain, 2)
+    await expect(chain).toAddBlock(block)
+    const [transaction] = block.transactions
+
+    // Chains from before the index have no entries in it
+    await chain.transactionHashToBlockHash.clear()
+    await chain.db.setVersion(7)
+
+    const migrator = new Migrator({ node, migrations: [new Migration008()] })
+    await migrator.migrate({ backup: false })
+
+    expect(await chain.db.getVersion()).toBe(8)
+    await expect(chain.transactionHashToBlockHash.get(transaction.hash())).resolves.toEqual(
+      block.header.hash,
+    )
+  })
+})
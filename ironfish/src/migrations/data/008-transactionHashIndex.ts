@@ -0,0 +1,59 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { GENESIS_BLOCK_SEQUENCE } from '../../consensus'
+import { Migration, MigrationContext, MigrationDatabase } from '../migration'
+
+// Commit every so many blocks so the transaction doesn't hold the whole chain
+const BLOCKS_PER_UPDATE = 1000
+
+/**
+ * Index the hashes of the transactions on the main chain by the hash of their
+ * block. Blocks connected after this index their transactions themselves.
+ */
+export class Migration008 extends Migration {
+  readonly version = 8
+  readonly name = 'transaction-hash-index'
+  readonly database = MigrationDatabase.CHAIN
+
+  async forward({ node, tx, logger, dryRun }: MigrationContext): Promise<void> {
+    const chain = node.chain
+    const head = await chain.meta.get('head', tx)
+    const headHeader = head ? await chain.headers.get(head, tx) : undefined
+    const headSequence = headHeader?.header.sequence ?? 0
+
+    logger.info(`Indexing the transactions of ${headSequence} blocks`)
+
+    for (let sequence = GENESIS_BLOCK_SEQUENCE; sequence <= headSequence; sequence++) {
+      const blockHash = await chain.sequenceToHash.get(sequence, tx)
+      if (!blockHash) {
+        continue
+      }
+
+      const value = await chain.transactions.get(blockHash, tx)
+      for (const transaction of value?.transactions ?? []) {
+        await chain.transactionHashToBlockHash.put(transaction.hash(), blockHash, tx)
+      }
+
+      if (sequence % BLOCKS_PER_UPDATE !== 0) {
+        continue
+      }
+
+      logger.info(`Indexed ${sequence} of ${headSequence} blocks`)
+
+      // Indexing is idempotent, so a migration that fails after this commits
+      // can be run again. A dry run has to hold every change to abort them.
+      if (!dryRun) {
+        await tx.update()
+      }
+    }
+  }
+
+  async backward({ node, tx }: MigrationContext): Promise<void> {
+    const store = node.chain.transactionHashToBlockHash
+
+    for (const hash of await store.getAllKeys(tx)) {
+      await store.del(hash, tx)
+    }
+  }
+}
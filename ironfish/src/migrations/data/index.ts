@@ -0,0 +1,13 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { Migration } from '../migration'
+import { Migration007 } from './007-merkleNodeLog'
+import { Migration008 } from './008-transactionHashIndex'
+
+/**
+ * Every migration that has shipped. When you add a migration, also bump
+ * CHAIN_DATABASE_VERSION or ACCOUNTS_DATABASE_VERSION to its version, or the
+ * node will not open the migrated database.
+ */
+export const MIGRATIONS: Migration[] = [new Migration007(), new Migration008()]
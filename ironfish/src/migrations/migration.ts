@@ -0,0 +1,51 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { Logger } from '../logger'
+import { IronfishNode } from '../node'
+import { IDatabase, IDatabaseTransaction } from '../storage'
+
+export enum MigrationDatabase {
+  CHAIN = 'chain',
+  WALLET = 'wallet',
+}
+
+export type MigrationContext = {
+  node: IronfishNode
+  db: IDatabase
+  tx: IDatabaseTransaction
+  logger: Logger
+  /**
+   * True if the migration is run with --dry-run. The migration runs in a
+   * transaction that is aborted, so this is only needed to skip side effects
+   * outside of the database
+   */
+  dryRun: boolean
+}
+
+/**
+ * A change to the schema of the chain or wallet database. Each migration
+ * upgrades its database from `version - 1` to `version`, and migrations run in
+ * order of their version inside a database transaction.
+ */
+export abstract class Migration {
+  /**
+   * The schema version of the database after this migration has run
+   */
+  abstract readonly version: number
+  abstract readonly name: string
+  abstract readonly database: MigrationDatabase
+
+  abstract forward(context: MigrationContext): Promise<void>
+
+  /**
+   * Undo {@link Migration.forward}. Migrations that can't be undone, like ones
+   * that delete data, leave this undefined and can only be rolled back by
+   * restoring the backup taken before they ran.
+   */
+  backward?(context: MigrationContext): Promise<void>
+
+  get reversible(): boolean {
+    return this.backward !== undefined
+  }
+}
@@ -0,0 +1,103 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import fs from 'fs'
+import { ACCOUNTS_DATABASE_VERSION } from '../account/accountsdb'
+import { createNodeTest } from '../testUtilities'
+import { Migration, MigrationContext, MigrationDatabase } from './migration'
+import { Migrator } from './migrator'
+
+class RenameMigration extends Migration {
+  readonly version = ACCOUNTS_DATABASE_VERSION
+  readonly name = 'rename-default'
+  readonly database = MigrationDatabase.WALLET
+
+  async forward({ node, tx }: MigrationContext): Promise<void> {
+    await node.accounts.db.meta.put('defaultAccountName', 'migrated', tx)
+  }
+
+  async backward({ node, tx }: MigrationContext): Promise<void> {
+    await node.accounts.db.meta.del('defaultAccountName', tx)
+  }
+}
+
+class IrreversibleMigration extends Migration {
+  readonly version = ACCOUNTS_DATABASE_VERSION
+  readonly name = 'irreversible'
+  readonly database = MigrationDatabase.WALLET
+
+  forward(): Promise<void> {
+    return Promise.resolve()
+  }
+}
+
+describe('Migrator', () => {
+  const nodeTest = createNodeTest()
+
+  const setup = async (migration: Migration = new RenameMigration()) => {
+    const { node } = nodeTest
+    const migrator = new Migrator({ node, migrations: [migration] })
+    await node.accounts.db.database.setVersion(ACCOUNTS_DATABASE_VERSION - 1)
+    return { node, migrator }
+  }
+
+  it('reports pending migrations', async () => {
+    const { migrator } = await setup()
+
+    const status = await migrator.status()
+    const wallet = status.find((s) => s.database === MigrationDatabase.WALLET)
+
+    expect(wallet?.version).toBe(ACCOUNTS_DATABASE_VERSION - 1)
+    expect(wallet?.pending.map((m) => m.name)).toEqual(['rename-default'])
+    expect(wallet?.applied).toHaveLength(0)
+  })
+
+  it('runs pending migrations after a backup', async () => {
+    const { node, migrator } = await setup()
+
+    const [result] = await migrator.migrate()
+
+    expect(result.to).toBe(ACCOUNTS_DATABASE_VERSION)
+    expect(await node.accounts.db.database.getVersion()).toBe(ACCOUNTS_DATABASE_VERSION)
+    expect(await node.accounts.db.meta.get('defaultAccountName')).toBe('migrated')
+
+    expect(result.backup).not.toBeNull()
+    expect(fs.existsSync(result.backup as string)).toBe(true)
+  })
+
+  it('does not change the database in a dry run', async () => {
+    const { node, migrator } = await setup()
+
+    const [result] = await migrator.migrate({ dryRun: true })
+
+    expect(result.to).toBe(ACCOUNTS_DATABASE_VERSION - 1)
+    expect(result.backup).toBeNull()
+    expect(await node.accounts.db.database.getVersion()).toBe(ACCOUNTS_DATABASE_VERSION - 1)
+    expect(await node.accounts.db.meta.get('defaultAccountName')).toBeUndefined()
+  })
+
+  it('rolls back reversible migrations', async () => {
+    const { node, migrator } = await setup()
+    await migrator.migrate({ backup: false })
+
+    const [result] = await migrator.rollback({ backup: false })
+
+    expect(result.to).toBe(ACCOUNTS_DATABASE_VERSION - 1)
+    expect(await node.accounts.db.database.getVersion()).toBe(ACCOUNTS_DATABASE_VERSION - 1)
+    expect(await node.accounts.db.meta.get('defaultAccountName')).toBeUndefined()
+  })
+
+  it('does not roll back irreversible migrations', async () => {
+    const { migrator } = await setup(new IrreversibleMigration())
+    await migrator.migrate({ backup: false })
+
+    await expect(migrator.rollback()).rejects.toThrowError("can't be rolled back")
+  })
+
+  it('throws if a migration is missing', async () => {
+    const { node, migrator } = await setup()
+    await node.accounts.db.database.setVersion(ACCOUNTS_DATABASE_VERSION - 2)
+
+    await expect(migrator.migrate()).rejects.toThrowError('There is no migration')
+  })
+})
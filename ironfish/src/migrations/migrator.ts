@@ -0,0 +1,312 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import fsAsync from 'fs/promises'
+import path from 'path'
+import { ACCOUNTS_DATABASE_VERSION } from '../account/accountsdb'
+import { Assert } from '../assert'
+import { CHAIN_DATABASE_VERSION } from '../blockchain'
+import { createRootLogger, Logger } from '../logger'
+import { IronfishNode } from '../node'
+import { IDatabase, IDatabaseTransaction } from '../storage'
+import { MIGRATIONS } from './data'
+import { Migration, MigrationContext, MigrationDatabase } from './migration'
+
+export type MigrationStatus = {
+  database: MigrationDatabase
+  location: string
+  /**
+   * The schema version of the database, or null if it was never opened
+   */
+  version: number | null
+  /**
+   * The schema version this version of ironfish expects
+   */
+  latest: number
+  applied: Migration[]
+  pending: Migration[]
+}
+
+export type MigrationResult = {
+  database: MigrationDatabase
+  from: number
+  to: number
+  migrations: Migration[]
+  /**
+   * Where the database was copied to before it was changed, or null if it wasn't
+   */
+  backup: string | null
+}
+
+/**
+ * Runs the {@link Migration}s of the chain and wallet databases. The databases
+ * must be opened without upgrading them first, with
+ * `node.openDB({ upgrade: false, load: false })`.
+ */
+export class Migrator {
+  readonly node: IronfishNode
+  readonly logger: Logger
+  readonly migrations: Migration[]
+
+  constructor(options: { node: IronfishNode; logger?: Logger; migrations?: Migration[] }) {
+    this.node = options.node
+    this.logger = options.logger ?? createRootLogger()
+    this.migrations = [...(options.migrations ?? MIGRATIONS)].sort(
+      (a, b) => a.version - b.version,
+    )
+  }
+
+  get backupsDir(): string {
+    return path.join(this.node.config.dataDir, 'backups', 'migrations')
+  }
+
+  async status(): Promise<MigrationStatus[]> {
+    const statuses = []
+
+    for (const database of Object.values(MigrationDatabase)) {
+      const { db, location, latest } = this.getDatabase(database)
+      const version = await db.getVersion()
+      const migrations = this.migrations.filter((m) => m.database === database)
+
+      statuses.push({
+        database,
+        location,
+        version,
+        latest,
+        applied: migrations.filter((m) => version !== null && m.version <= version),
+        pending: migrations.filter(
+          (m) => version !== null && m.version > version && m.version <= latest,
+        ),
+      })
+    }
+
+    return statuses
+  }
+
+  /**
+   * Run the pending migrations of each database, after copying it to
+   * {@link Migrator.backupsDir}. With `dryRun` the migrations run in a
+   * transaction that is aborted instead of committed.
+   */
+  async migrate(
+    options: { dryRun?: boolean; backup?: boolean } = {},
+  ): Promise<MigrationResult[]> {
+    const dryRun = options.dryRun ?? false
+    const results = []
+
+    for (const status of await this.status()) {
+      if (status.version === null || status.version >= status.latest) {
+        continue
+      }
+
+      const from = status.version
+      const versions = status.pending.map((m) => m.version)
+
+      for (let version = from + 1; version <= status.latest; version++) {
+        if (!versions.includes(version)) {
+          throw new Error(
+            `There is no migration to upgrade the ${status.database} database from` +
+              ` version ${version - 1} to ${version}.\nRun "ironfish reset" to reset it.`,
+          )
+        }
+      }
+
+      const { db } = this.getDatabase(status.database)
+
+      let backup = null
+      if (!dryRun && options.backup !== false) {
+        backup = await this.backup(status.database, from)
+      }
+
+      if (dryRun) {
+        // Run all of the migrations in one transaction, so each one sees the
+        // changes of the ones before it
+        const tx = db.transaction()
+        try {
+          for (const migration of status.pending) {
+            await this.runForward(migration, db, tx, true)
+          }
+        } finally {
+          await tx.abort()
+        }
+      } else {
+        for (const migration of status.pending) {
+          await db.transaction((tx) => this.runForward(migration, db, tx, false))
+        }
+      }
+
+      results.push({
+        database: status.database,
+        from,
+        to: dryRun ? from : status.latest,
+        migrations: status.pending,
+        backup,
+      })
+    }
+
+    return results
+  }
+
+  /**
+   * Undo the last migration that was applied to each database, or only to
+   * `database` if it's given. Throws if that migration isn't reversible.
+   */
+  async rollback(
+    options: { dryRun?: boolean; backup?: boolean; database?: MigrationDatabase } = {},
+  ): Promise<MigrationResult[]> {
+    const dryRun = options.dryRun ?? false
+    const results = []
+
+    for (const status of await this.status()) {
+      if (options.database && status.database !== options.database) {
+        continue
+      }
+
+      const migration = status.applied[status.applied.length - 1]
+      if (!migration || migration.version !== status.version) {
+        continue
+      }
+
+      if (!migration.reversible) {
+        throw new Error(
+          `The ${migration.name} migration of the ${status.database} database can't be` +
+            ` rolled back.\nRestore the backup of the database in ${this.backupsDir} instead.`,
+        )
+      }
+
+      const { db } = this.getDatabase(status.database)
+
+      let backup = null
+      if (!dryRun && options.backup !== false) {
+        backup = await this.backup(status.database, migration.version)
+      }
+
+      if (dryRun) {
+        const tx = db.transaction()
+        try {
+          await this.runBackward(migration, db, tx, true)
+        } finally {
+          await tx.abort()
+        }
+      } else {
+        await db.transaction((tx) => this.runBackward(migration, db, tx, false))
+      }
+
+      results.push({
+        database: status.database,
+        from: migration.version,
+        to: dryRun ? migration.version : migration.version - 1,
+        migrations: [migration],
+        backup,
+      })
+    }
+
+    return results
+  }
+
+  private async runForward(
+    migration: Migration,
+    db: IDatabase,
+    tx: IDatabaseTransaction,
+    dryRun: boolean,
+  ): Promise<void> {
+    this.logger.info(`${dryRun ? '[dry run] ' : ''}Running ${this.describe(migration)}`)
+
+    await migration.forward(this.createContext(migration, db, tx, dryRun))
+    await db.setVersion(migration.version, tx)
+
+    // Migrations write to the stores directly, around the chain caches
+    this.node.chain.clearCaches()
+  }
+
+  private async runBackward(
+    migration: Migration,
+    db: IDatabase,
+    tx: IDatabaseTransaction,
+    dryRun: boolean,
+  ): Promise<void> {
+    Assert.isNotUndefined(migration.backward)
+    this.logger.info(`${dryRun ? '[dry run] ' : ''}Rolling back ${this.describe(migration)}`)
+
+    await migration.backward(this.createContext(migration, db, tx, dryRun))
+    await db.setVersion(migration.version - 1, tx)
+    this.node.chain.clearCaches()
+  }
+
+  private createContext(
+    migration: Migration,
+    db: IDatabase,
+    tx: IDatabaseTransaction,
+    dryRun: boolean,
+  ): MigrationContext {
+    return {
+      node: this.node,
+      db,
+      tx,
+      logger: this.logger.withTag(migration.name),
+      dryRun,
+    }
+  }
+
+  private describe(migration: Migration): string {
+    return `${migration.database} migration ${migration.version} ${migration.name}`
+  }
+
+  /**
+   * Copy the files of a database to {@link Migrator.backupsDir}. The database
+   * is closed while it's copied so the copy is consistent.
+   */
+  private async backup(database: MigrationDatabase, version: number): Promise<string> {
+    const { db, location } = this.getDatabase(database)
+    const destination = path.join(this.backupsDir, `${database}.v${version}.${Date.now()}`)
+
+    this.logger.info(`Backing up the ${database} database to ${destination}`)
+
+    await db.close()
+    try {
+      await copyDirectory(location, destination)
+    } finally {
+      await db.open()
+    }
+
+    return destination
+  }
+
+  private getDatabase(database: MigrationDatabase): {
+    db: IDatabase
+    location: string
+    latest: number
+  } {
+    switch (database) {
+      case MigrationDatabase.CHAIN:
+        return {
+          db: this.node.chain.db,
+          location: this.node.config.chainDatabasePath,
+          latest: CHAIN_DATABASE_VERSION,
+        }
+      case MigrationDatabase.WALLET:
+        return {
+          db: this.node.accounts.db.database,
+          location: this.node.accounts.db.location,
+          latest: ACCOUNTS_DATABASE_VERSION,
+        }
+    }
+
+    Assert.isNever(database)
+  }
+}
+
+async function copyDirectory(source: string, destination: string): Promise<void> {
+  await fsAsync.mkdir(destination, { recursive: true })
+
+  for (const entry of await fsAsync.readdir(source, { withFileTypes: true })) {
+    const from = path.join(source, entry.name)
+    const to = path.join(destination, entry.name)
+
+    if (entry.isDirectory()) {
+      await copyDirectory(from, to)
+    } else if (entry.isFile()) {
+      await fsAsync.copyFile(from, to)
+    }
+  }
+}
@@ -0,0 +1,37 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { createNodeTest } from '../testUtilities'
+import { isTransactionMine } from '../testUtilities/helpers/transaction'
+import { DevBlockProducer } from './devBlockProducer'
+
+describe('DevBlockProducer', () => {
+  const nodeTest = createNodeTest()
+
+  it('produces blocks that pay the requested account', async () => {
+    const { node, chain } = nodeTest
+    await node.accounts.createAccount('default', true)
+    const account = await node.accounts.createAccount('other')
+
+    const producer = new DevBlockProducer({ node, logger: node.logger })
+    const onNewBlock = jest.fn()
+    node.miningManager.onNewBlock.on(onNewBlock)
+
+    const blocks = await producer.produceBlocks(2, account)
+
+    expect(blocks.map((b) => b.header.sequence)).toEqual([2, 3])
+    expect(chain.head.hash.equals(blocks[1].header.hash)).toBe(true)
+    expect(isTransactionMine(blocks[0].transactions[0], account)).toBe(true)
+    expect(onNewBlock).toHaveBeenCalledTimes(2)
+  }, 20000)
+
+  it('requires target verification to be disabled', async () => {
+    const { node } = nodeTest
+    await node.accounts.createAccount('default', true)
+
+    node.chain.verifier.enableVerifyTarget = true
+    const producer = new DevBlockProducer({ node, logger: node.logger })
+
+    await expect(producer.produceBlocks(1)).rejects.toThrow('target verification')
+  })
+})
@@ -0,0 +1,122 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { Account } from '../account'
+import { Assert } from '../assert'
+import { Logger } from '../logger'
+import { IronfishNode } from '../node'
+import { Block } from '../primitives/block'
+import { ErrorUtils } from '../utils/error'
+import { GraffitiUtils } from '../utils/graffiti'
+import { SetTimeoutToken } from '../utils/types'
+
+/**
+ * Produces blocks instantly for a single node development chain. Blocks are
+ * built from the mem pool like a normal block template but are added to the
+ * chain without doing any proof of work, so the node must have target
+ * verification disabled.
+ */
+export class DevBlockProducer {
+  readonly node: IronfishNode
+  readonly logger: Logger
+
+  /**
+   * Milliseconds between produced blocks, 0 only produces blocks on demand
+   */
+  readonly interval: number
+
+  private timeout: SetTimeoutToken | null = null
+  private queue: Promise<unknown> = Promise.resolve()
+
+  constructor(options: { node: IronfishNode; logger: Logger; interval?: number }) {
+    this.node = options.node
+    this.logger = options.logger.withTag('devblocks')
+    this.interval = options.interval ?? 0
+  }
+
+  get started(): boolean {
+    return this.timeout !== null
+  }
+
+  start(): void {
+    if (this.timeout || this.interval <= 0) {
+      return
+    }
+
+    this.schedule()
+  }
+
+  stop(): void {
+    if (this.timeout) {
+      clearTimeout(this.timeout)
+      this.timeout = null
+    }
+  }
+
+  /**
+   * Produce blocks one after another, optionally paying the miner's reward to
+   * `account` instead of the default account
+   */
+  async produceBlocks(count: number, account?: Account): Promise<Block[]> {
+    // Queue behind blocks that are already being produced so they don't fork
+    const result = this.queue.then(() => this.produce(count, account))
+    this.queue = result.catch(() => undefined)
+    return result
+  }
+
+  private async produce(count: number, account?: Account): Promise<Block[]> {
+    Assert.isFalse(
+      this.node.chain.verifier.enableVerifyTarget,
+      'Dev blocks can only be produced when target verification is disabled',
+    )
+
+    const miner = account ?? this.node.accounts.getDefaultAccount()
+    Assert.isNotNull(miner, 'Cannot produce blocks without an account')
+
+    const blocks = []
+
+    for (let i = 0; i < count; i++) {
+      const sequence = this.node.chain.head.sequence + 1
+
+      const { totalFees, blockTransactions } =
+        await this.node.miningManager.getNewBlockTransactions(sequence)
+
+      const minersFee = await this.node.strategy.createMinersFee(
+        totalFees,
+        sequence,
+        miner.spendingKey,
+      )
+
+      const block = await this.node.chain.newBlock(
+        blockTransactions,
+        minersFee,
+        GraffitiUtils.fromString(this.node.config.get('blockGraffiti')),
+      )
+
+      const { isAdded, reason } = await this.node.chain.addBlock(block)
+      Assert.isTrue(isAdded, `Could not add dev block: ${String(reason)}`)
+
+      const transactions = block.transactions.length
+      this.logger.info(`Produced block ${sequence} with ${transactions} transactions`)
+
+      this.node.miningManager.onNewBlock.emit(block)
+      blocks.push(block)
+    }
+
+    return blocks
+  }
+
+  private schedule(): void {
+    this.timeout = setTimeout(() => {
+      void this.produceBlocks(1)
+        .catch((e: unknown) => {
+          this.logger.error(`Error producing block: ${ErrorUtils.renderError(e)}`)
+        })
+        .finally(() => {
+          if (this.timeout) {
+            this.schedule()
+          }
+        })
+    }, this.interval)
+  }
+}
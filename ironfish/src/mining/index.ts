@@ -2,12 +2,15 @@
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 
+export { DevBlockProducer } from './devBlockProducer'
 export { MiningManager } from './manager'
 export { Discord } from './webhooks'
 export { Lark } from './webhooks'
 export { WebhookNotifier } from './webhooks'
 export { MiningPool } from './pool'
+export { PoolDatabase } from './poolDatabase'
+export { MiningPoolShareStats, ShareCounts, ShareRejectReason } from './poolShareStats'
 export { MiningPoolMiner } from './poolMiner'
 export { MiningSoloMiner } from './soloMiner'
-export { StratumClient } from './stratum'
+export { StratumClient, StratumShareStats } from './stratum'
 export { MiningStatusMessage } from './stratum'
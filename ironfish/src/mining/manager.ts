@@ -32,6 +32,8 @@ export class MiningManager {
 
   blocksMined = 0
   minersConnected = 0
+  // The hashrate the mining pool last reported, estimated from its shares
+  poolHashRate = 0
 
   readonly onNewBlock = new Event<[Block]>()
 
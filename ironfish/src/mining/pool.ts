@@ -14,6 +14,7 @@ import { ErrorUtils } from '../utils/error'
 import { FileUtils } from '../utils/file'
 import { SetIntervalToken, SetTimeoutToken } from '../utils/types'
 import { MiningPoolShares } from './poolShares'
+import { MiningPoolShareStats, ShareRejectReason } from './poolShareStats'
 import { MiningStatusMessage } from './stratum/messages'
 import { StratumServer } from './stratum/stratumServer'
 import { StratumServerClient } from './stratum/stratumServerClient'
@@ -22,11 +23,18 @@ import { WebhookNotifier } from './webhooks'
 
 const RECALCULATE_TARGET_TIMEOUT = 10000
 
+// How often the estimated hashrate is reported to the node for its metrics history
+const REPORT_HASH_RATE_INTERVAL = 60 * 1000
+
+// How many recent share hashes are remembered to reject duplicates
+const DUPLICATE_SHARE_WINDOW = 50000
+
 export class MiningPool {
   readonly stratum: StratumServer
   readonly rpc: RpcSocketClient
   readonly logger: Logger
   readonly shares: MiningPoolShares
+  readonly shareStats: MiningPoolShareStats
   readonly config: Config
   readonly webhooks: WebhookNotifier[]
 
@@ -41,7 +49,7 @@ export class MiningPool {
 
   nextMiningRequestId: number
   miningRequestBlocks: LeastRecentlyUsed<number, SerializedBlockTemplate>
-  recentSubmissions: Map<number, string[]>
+  recentSubmissions: LeastRecentlyUsed<string, true>
 
   difficulty: bigint
   target: Buffer
@@ -52,6 +60,7 @@ export class MiningPool {
   recalculateTargetInterval: SetIntervalToken | null
 
   private notifyStatusInterval: SetIntervalToken | null
+  private reportHashRateInterval: SetIntervalToken | null
 
   private constructor(options: {
     rpc: RpcSocketClient
@@ -78,7 +87,8 @@ export class MiningPool {
     this.shares = options.shares
     this.nextMiningRequestId = 0
     this.miningRequestBlocks = new LeastRecentlyUsed(12)
-    this.recentSubmissions = new Map()
+    this.recentSubmissions = new LeastRecentlyUsed(DUPLICATE_SHARE_WINDOW)
+    this.shareStats = new MiningPoolShareStats()
     this.currentHeadTimestamp = null
     this.currentHeadDifficulty = null
 
@@ -94,6 +104,7 @@ export class MiningPool {
 
     this.recalculateTargetInterval = null
     this.notifyStatusInterval = null
+    this.reportHashRateInterval = null
   }
 
   static async init(options: {
@@ -155,6 +166,11 @@ export class MiningPool {
       )
     }
 
+    this.reportHashRateInterval = setInterval(
+      () => void this.reportHashRate(),
+      REPORT_HASH_RATE_INTERVAL,
+    )
+
     void this.startConnectingRpc()
   }
 
@@ -187,6 +203,10 @@ export class MiningPool {
     if (this.notifyStatusInterval) {
       clearInterval(this.notifyStatusInterval)
     }
+
+    if (this.reportHashRateInterval) {
+      clearInterval(this.reportHashRateInterval)
+    }
   }
 
   async waitForStop(): Promise<void> {
@@ -201,39 +221,47 @@ export class MiningPool {
     client: StratumServerClient,
     miningRequestId: number,
     randomness: string,
-  ): Promise<void> {
-    Assert.isNotNull(client.publicAddress)
-    Assert.isNotNull(client.graffiti)
-    if (miningRequestId !== this.nextMiningRequestId - 1) {
+  ): Promise<'accepted' | ShareRejectReason> {
+    const result = await this.validateWork(client, miningRequestId, randomness)
+
+    if (result !== 'accepted') {
       this.logger.debug(
-        `Client ${client.id} submitted work for stale mining request: ${miningRequestId}`,
+        `Client ${client.id} share for mining request ${miningRequestId} rejected: ${result}`,
       )
-      return
+    }
+
+    if (client.publicAddress) {
+      this.shareStats.record(client.publicAddress, result)
+    }
+
+    return result
+  }
+
+  private async validateWork(
+    client: StratumServerClient,
+    miningRequestId: number,
+    randomness: string,
+  ): Promise<'accepted' | ShareRejectReason> {
+    if (!client.publicAddress || !client.graffiti) {
+      return ShareRejectReason.NOT_SUBSCRIBED
     }
 
     const originalBlockTemplate = this.miningRequestBlocks.get(miningRequestId)
 
+    if (miningRequestId !== this.nextMiningRequestId - 1) {
+      return originalBlockTemplate ? ShareRejectReason.STALE : ShareRejectReason.UNKNOWN_JOB
+    }
+
     if (!originalBlockTemplate) {
       this.logger.warn(
         `Client ${client.id} work for invalid mining request: ${miningRequestId}`,
       )
-      return
+      return ShareRejectReason.UNKNOWN_JOB
     }
 
     const blockTemplate = Object.assign({}, originalBlockTemplate)
     blockTemplate.header = Object.assign({}, originalBlockTemplate.header)
 
-    const isDuplicate = this.isDuplicateSubmission(client.id, randomness)
-
-    if (isDuplicate) {
-      this.logger.warn(
-        `Client ${client.id} submitted a duplicate mining request: ${miningRequestId}, ${randomness}`,
-      )
-      return
-    }
-
-    this.addWorkSubmission(client.id, randomness)
-
     blockTemplate.header.graffiti = client.graffiti.toString('hex')
     blockTemplate.header.randomness = randomness
 
@@ -242,10 +270,22 @@ export class MiningPool {
       headerBytes = mineableHeaderString(blockTemplate.header)
     } catch (error) {
       this.stratum.peers.punish(client, `${client.id} sent malformed work.`)
-      return
+      return ShareRejectReason.MALFORMED
     }
 
     const hashedHeader = blake3(headerBytes)
+    const hashedHeaderHex = hashedHeader.toString('hex')
+
+    // The graffiti is unique to each client, so the same hash means the
+    // same client sent the same work again
+    if (this.recentSubmissions.has(hashedHeaderHex)) {
+      this.logger.warn(
+        `Client ${client.id} submitted a duplicate mining request: ${miningRequestId}, ${randomness}`,
+      )
+      return ShareRejectReason.DUPLICATE
+    }
+
+    this.recentSubmissions.set(hashedHeaderHex, true)
 
     if (hashedHeader.compare(Buffer.from(blockTemplate.header.target, 'hex')) !== 1) {
       this.logger.debug('Valid block, submitting to node')
@@ -254,7 +294,6 @@ export class MiningPool {
 
       if (result.content.added) {
         const hashRate = await this.estimateHashRate()
-        const hashedHeaderHex = hashedHeader.toString('hex')
 
         this.logger.info(
           `Block ${hashedHeaderHex} submitted successfully! ${FileUtils.formatHashRate(
@@ -269,10 +308,13 @@ export class MiningPool {
       }
     }
 
-    if (hashedHeader.compare(this.target) !== 1) {
-      this.logger.debug('Valid pool share submitted')
-      await this.shares.submitShare(client.publicAddress)
+    if (hashedHeader.compare(this.target) === 1) {
+      return ShareRejectReason.LOW_DIFFICULTY
     }
+
+    this.logger.debug('Valid pool share submitted')
+    await this.shares.submitShare(client.publicAddress)
+    return 'accepted'
   }
 
   private async startConnectingRpc(): Promise<void> {
@@ -373,7 +415,6 @@ export class MiningPool {
 
     const miningRequestId = this.nextMiningRequestId++
     this.miningRequestBlocks.set(miningRequestId, newBlock)
-    this.recentSubmissions.clear()
 
     this.stratum.newWork(miningRequestId, newBlock)
   }
@@ -388,24 +429,6 @@ export class MiningPool {
     }, RECALCULATE_TARGET_TIMEOUT)
   }
 
-  private isDuplicateSubmission(clientId: number, randomness: string): boolean {
-    const submissions = this.recentSubmissions.get(clientId)
-    if (submissions == null) {
-      return false
-    }
-    return submissions.includes(randomness)
-  }
-
-  private addWorkSubmission(clientId: number, randomness: string): void {
-    const submissions = this.recentSubmissions.get(clientId)
-    if (submissions == null) {
-      this.recentSubmissions.set(clientId, [randomness])
-    } else {
-      submissions.push(randomness)
-      this.recentSubmissions.set(clientId, submissions)
-    }
-  }
-
   async estimateHashRate(publicAddress?: string): Promise<number> {
     // BigInt can't contain decimals, so multiply then divide to give decimal precision
     const shareRate = await this.shares.shareRate(publicAddress)
@@ -422,6 +445,20 @@ export class MiningPool {
     this.webhooks.map((w) => w.poolStatus(status))
   }
 
+  async reportHashRate(): Promise<void> {
+    if (!this.rpc.isConnected) {
+      return
+    }
+
+    const hashRate = await this.estimateHashRate()
+
+    try {
+      await this.rpc.submitHashRate({ hashRate })
+    } catch (e: unknown) {
+      this.logger.debug(`Failed to report hashrate to node: ${ErrorUtils.renderError(e)}`)
+    }
+  }
+
   async getStatus(publicAddress?: string): Promise<MiningStatusMessage> {
     const [hashRate, sharesPending] = await Promise.all([
       this.estimateHashRate(),
@@ -437,6 +474,13 @@ export class MiningPool {
       sharesPending: sharesPending,
       bans: this.stratum.peers.banCount,
       clients: this.stratum.clients.size,
+      shares: { ...this.shareStats.total },
+      fees: {
+        poolFeePercent: this.shares.feePercent,
+        feeAddress: this.shares.feeAddress ?? undefined,
+        donationPercent: this.shares.donationPercent,
+        donationAddress: this.shares.donationAddress ?? undefined,
+      },
     }
 
     if (publicAddress) {
@@ -460,6 +504,7 @@ export class MiningPool {
         miners: addressMinerCount,
         connectedMiners: addressConnectedMiners,
         sharesPending: addressSharesPending,
+        shares: this.shareStats.get(publicAddress),
       }
     }
 
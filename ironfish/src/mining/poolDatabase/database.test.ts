@@ -0,0 +1,196 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import os from 'os'
+import { Database, open } from 'sqlite'
+import sqlite3 from 'sqlite3'
+import { Assert } from '../../assert'
+import { Config } from '../../fileStores/config'
+import { NodeFileProvider } from '../../fileSystems/nodeFileSystem'
+import { createRootLogger } from '../../logger'
+import { PoolDatabase } from './database'
+import { Migrator } from './migrator'
+
+describe('PoolDatabase', () => {
+  let db: Database
+  let poolDatabase: PoolDatabase
+
+  const now = () => Math.floor(Date.now() / 1000)
+  const DAY = 24 * 60 * 60
+
+  beforeEach(async () => {
+    const files = new NodeFileProvider()
+    await files.init()
+
+    db = await open({ filename: ':memory:', driver: sqlite3.Database })
+    poolDatabase = new PoolDatabase({
+      db,
+      config: new Config(files, os.tmpdir()),
+      logger: createRootLogger(),
+    })
+
+    await poolDatabase.start()
+  })
+
+  afterEach(async () => {
+    await poolDatabase.stop()
+  })
+
+  const addPayout = async (createdAt: number): Promise<number> => {
+    const result = await db.run(
+      "INSERT INTO payout (succeeded, createdAt) VALUES (TRUE, datetime(?, 'unixepoch'))",
+      createdAt,
+    )
+    return Number(result.lastID)
+  }
+
+  const addShare = async (
+    createdAt: number,
+    payoutId: number | null,
+    publicAddress = 'a',
+  ): Promise<void> => {
+    await db.run(
+      `INSERT INTO share (publicAddress, createdAt, payoutId)
+         VALUES (?, datetime(?, 'unixepoch'), ?)`,
+      publicAddress,
+      createdAt,
+      payoutId,
+    )
+  }
+
+  const count = async (table: 'share' | 'payout'): Promise<number> => {
+    const result = await db.get<{ count: number }>(`SELECT COUNT(*) AS count FROM ${table}`)
+    return result?.count ?? 0
+  }
+
+  describe('payouts', () => {
+    it('marks the shares of only the given addresses as paid', async () => {
+      await addShare(now() - 60, null, 'a')
+      await addShare(now() - 60, null, 'b')
+      await addShare(now() - 60, null, 'c')
+      // Shares after the payout's cutoff are left for the next payout
+      await addShare(now() + 60, null, 'a')
+
+      const payoutId = await poolDatabase.newPayout(now())
+      Assert.isNotNull(payoutId)
+
+      await poolDatabase.markSharesPaid(payoutId, now(), ['a', 'c'])
+
+      await expect(poolDatabase.getSharesCountForPayout('a')).resolves.toBe(1)
+      await expect(poolDatabase.getSharesCountForPayout('b')).resolves.toBe(1)
+      await expect(poolDatabase.getSharesCountForPayout('c')).resolves.toBe(0)
+
+      const paid = await db.all<{ publicAddress: string }[]>(
+        'SELECT publicAddress FROM share WHERE payoutId = ? ORDER BY publicAddress',
+        payoutId,
+      )
+      expect(paid).toEqual([{ publicAddress: 'a' }, { publicAddress: 'c' }])
+    })
+
+    it('stores the hash of every transaction of a payout', async () => {
+      const payoutId = await poolDatabase.newPayout(now())
+      Assert.isNotNull(payoutId)
+
+      await poolDatabase.markPayoutSuccess(payoutId, ['hash1', 'hash2', 'hash3'])
+
+      const payout = await db.get<{ succeeded: number; transactionHash: string }>(
+        'SELECT succeeded, transactionHash FROM payout WHERE id = ?',
+        payoutId,
+      )
+      expect(payout).toEqual({ succeeded: 1, transactionHash: 'hash1,hash2,hash3' })
+    })
+  })
+
+  describe('prune', () => {
+    it('deletes paid shares from before the timestamp', async () => {
+      const payoutId = await addPayout(now() - 10 * DAY)
+      await addShare(now() - 10 * DAY, payoutId)
+      await addShare(now() - 10 * DAY, payoutId)
+      await addShare(now() - DAY, payoutId)
+
+      const pruned = await poolDatabase.prune(now() - 5 * DAY)
+
+      expect(pruned).toEqual({ shares: 2, payouts: 0 })
+      expect(await count('share')).toBe(1)
+    })
+
+    it('keeps unpaid shares', async () => {
+      await addShare(now() - 10 * DAY, null)
+
+      const pruned = await poolDatabase.prune(now() - 5 * DAY)
+
+      expect(pruned).toEqual({ shares: 0, payouts: 0 })
+      expect(await count('share')).toBe(1)
+      expect(await poolDatabase.getSharesCountForPayout()).toBe(1)
+    })
+
+    it('deletes old payouts once they have no shares', async () => {
+      const prunedPayout = await addPayout(now() - 10 * DAY)
+      await addShare(now() - 10 * DAY, prunedPayout)
+
+      const keptPayout = await addPayout(now() - 10 * DAY)
+      await addShare(now() - DAY, keptPayout)
+
+      const pruned = await poolDatabase.prune(now() - 5 * DAY)
+
+      expect(pruned).toEqual({ shares: 1, payouts: 1 })
+
+      const payouts = await db.all<{ id: number }[]>('SELECT id FROM payout')
+      expect(payouts).toEqual([{ id: keptPayout }])
+    })
+
+    it('keeps the latest payouts so the next payout is not early', async () => {
+      // A payout with no shares from just now is still needed to space out payouts
+      await addPayout(now() - 60)
+
+      const pruned = await poolDatabase.prune(now())
+
+      expect(pruned).toEqual({ shares: 0, payouts: 0 })
+      await expect(poolDatabase.newPayout(now())).resolves.toBeNull()
+    })
+  })
+
+  describe('migrations', () => {
+    it('applies every migration on start', async () => {
+      const status = await poolDatabase.migrations.status()
+
+      expect(status.length).toBeGreaterThan(0)
+      expect(status.every((m) => m.applied)).toBe(true)
+      await expect(poolDatabase.migrations.migrated()).resolves.toBe(true)
+    })
+
+    it('reverts the latest migration', async () => {
+      const migrations = poolDatabase.migrations
+      const latest = migrations.getLatest()
+
+      const reverted = await migrations.revert()
+
+      expect(reverted).toBe(latest)
+      expect(reverted?.name).toEqual('005-add-payout-created-at-index')
+      await expect(migrations.getCurrentId()).resolves.toBe(4)
+      await expect(migrations.migrated()).resolves.toBe(false)
+
+      const index = await db.get(
+        "SELECT name FROM sqlite_master WHERE type = 'index' AND name = ?",
+        'idx_payout_created_at',
+      )
+      expect(index).toBeUndefined()
+
+      const status = await migrations.status()
+      expect(status[status.length - 1]).toMatchObject({ id: 5, applied: false })
+
+      await migrations.migrate()
+      await expect(migrations.migrated()).resolves.toBe(true)
+    })
+
+    it('reverts nothing when no migrations were applied', async () => {
+      const emptyDb = await open({ filename: ':memory:', driver: sqlite3.Database })
+      const migrator = new Migrator({ db: emptyDb, logger: createRootLogger() })
+
+      await expect(migrator.revert()).resolves.toBeNull()
+      await expect(migrator.getCurrentId()).resolves.toBe(0)
+
+      await emptyDb.close()
+    })
+  })
+})
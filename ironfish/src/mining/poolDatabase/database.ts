@@ -11,7 +11,7 @@ import { Migrator } from './migrator'
 export class PoolDatabase {
   private readonly db: Database
   private readonly config: Config
-  private readonly migrations: Migrator
+  readonly migrations: Migrator
   private readonly attemptPayoutInterval: number
   private readonly successfulPayoutInterval: number
 
@@ -98,23 +98,73 @@ export class PoolDatabase {
     return null
   }
 
-  async markPayoutSuccess(
-    id: number,
-    timestamp: number,
-    transactionHash: string,
-  ): Promise<void> {
+  /**
+   * Mark the payout as succeeded with the hashes of the transactions it sent,
+   * comma separated when it was split into several
+   */
+  async markPayoutSuccess(id: number, transactionHashes: string[]): Promise<void> {
     await this.db.run(
       'UPDATE payout SET succeeded = TRUE, transactionHash = ? WHERE id = ?',
+      transactionHashes.join(','),
       id,
-      transactionHash,
     )
+  }
+
+  /**
+   * Mark the shares of the addresses from before the timestamp as paid by
+   * the payout
+   */
+  async markSharesPaid(
+    id: number,
+    timestamp: number,
+    publicAddresses: string[],
+  ): Promise<void> {
+    if (!publicAddresses.length) {
+      return
+    }
+
+    const placeholders = publicAddresses.map(() => '?').join(', ')
+
     await this.db.run(
-      "UPDATE share SET payoutId = ? WHERE payoutId IS NULL AND createdAt < datetime(?, 'unixepoch')",
+      `UPDATE share SET payoutId = ? WHERE payoutId IS NULL AND createdAt < datetime(?, 'unixepoch') AND publicAddress IN (${placeholders})`,
       id,
       timestamp,
+      ...publicAddresses,
     )
   }
 
+  /**
+   * Delete paid shares from before the timestamp, and the payouts that no
+   * longer have shares. Unpaid shares are kept so miners are still paid for
+   * them, and so are payouts recent enough to decide when the next one is.
+   */
+  async prune(timestamp: number): Promise<{ shares: number; payouts: number }> {
+    const payoutCutoff = Math.min(
+      timestamp,
+      Math.floor(Date.now() / 1000) - this.successfulPayoutInterval,
+    )
+
+    const shares = await this.db.run(
+      "DELETE FROM share WHERE payoutId IS NOT NULL AND createdAt < datetime(?, 'unixepoch')",
+      timestamp,
+    )
+
+    const payouts = await this.db.run(
+      `DELETE FROM payout WHERE createdAt < datetime(?, 'unixepoch')
+         AND NOT EXISTS (SELECT 1 FROM share WHERE share.payoutId = payout.id)`,
+      payoutCutoff,
+    )
+
+    return { shares: shares.changes ?? 0, payouts: payouts.changes ?? 0 }
+  }
+
+  /**
+   * Give the space freed by pruning back to the file system
+   */
+  async vacuum(): Promise<void> {
+    await this.db.run('VACUUM;')
+  }
+
   async shareCountSince(timestamp: number, publicAddress?: string): Promise<number> {
     let sql = "SELECT COUNT(id) AS count FROM share WHERE createdAt > datetime(?, 'unixepoch')"
 
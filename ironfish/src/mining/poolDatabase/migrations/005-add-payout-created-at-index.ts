@@ -0,0 +1,20 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+import { Database } from 'sqlite'
+import { Migration } from '../migration'
+
+export default class Migration005 extends Migration {
+  name = '005-add-payout-created-at-index'
+
+  async forward(db: Database): Promise<void> {
+    await db.run(`
+      CREATE INDEX idx_payout_created_at ON payout (createdAt);
+     `)
+  }
+
+  async backward(db: Database): Promise<void> {
+    await db.run('DROP INDEX IF EXISTS idx_payout_created_at;')
+  }
+}
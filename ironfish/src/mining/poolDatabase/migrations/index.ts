@@ -5,5 +5,7 @@
 import Migration001 from './001-initial'
 import Migration002 from './002-add-shares-index'
 import Migration003 from './003-add-transaction-hash'
+import Migration004 from './004-add-shares-address-index'
+import Migration005 from './005-add-payout-created-at-index'
 
-export const MIGRATIONS = [Migration001, Migration002, Migration003]
+export const MIGRATIONS = [Migration001, Migration002, Migration003, Migration004, Migration005]
@@ -39,6 +39,44 @@ export class Migrator {
     return latest.id <= current
   }
 
+  /**
+   * Every migration and whether it was applied to the database
+   */
+  async status(): Promise<{ id: number; name: string; applied: boolean }[]> {
+    const current = await this.getCurrentId()
+    return this.migrations.map((m) => ({ id: m.id, name: m.name, applied: m.id <= current }))
+  }
+
+  /**
+   * Undo the most recently applied migration
+   * @returns the migration that was reverted, or null if none were applied
+   */
+  async revert(): Promise<Migration | null> {
+    const current = await this.getCurrentId()
+    const applied = this.migrations.filter((m) => m.id <= current)
+    const migration = applied[applied.length - 1]
+    if (!migration) {
+      return null
+    }
+
+    const previous = applied[applied.length - 2]?.id ?? 0
+
+    try {
+      await this.db.run('begin transaction')
+      this.logger.info(`Reverting ${migration.name}`)
+      await migration.backward(this.db)
+      await this.db.run(`PRAGMA user_version = ${previous};`)
+      await this.db.run('COMMIT;')
+    } catch (e) {
+      await this.db.run('ROLLBACK;').catch(() => {
+        /* do nothing */
+      })
+      throw e
+    }
+
+    return migration
+  }
+
   async migrate(): Promise<void> {
     if (await this.migrated()) {
       return
@@ -32,6 +32,10 @@ export class MiningPoolMiner {
   constructor(options: {
     threadCount: number
     batchSize: number
+    /**
+     * The percent of the time each thread may spend mining, defaults to 100
+     */
+    maxCpu?: number
     logger: Logger
     publicAddress: string
     host: string
@@ -47,7 +51,7 @@ export class MiningPoolMiner {
     }
 
     const threadCount = options.threadCount ?? 1
-    this.threadPool = new ThreadPoolHandler(threadCount, options.batchSize)
+    this.threadPool = new ThreadPoolHandler(threadCount, options.batchSize, options.maxCpu)
 
     this.stratum = new StratumClient({
       host: options.host,
@@ -0,0 +1,22 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { MiningPoolShareStats, ShareRejectReason } from './poolShareStats'
+
+describe('MiningPoolShareStats', () => {
+  it('counts shares for each address and in total', () => {
+    const stats = new MiningPoolShareStats()
+
+    stats.record('a', 'accepted')
+    stats.record('a', 'accepted')
+    stats.record('a', ShareRejectReason.STALE)
+    stats.record('b', ShareRejectReason.DUPLICATE)
+    stats.record('b', ShareRejectReason.LOW_DIFFICULTY)
+    stats.record('b', ShareRejectReason.MALFORMED)
+
+    expect(stats.get('a')).toEqual({ accepted: 2, stale: 1, duplicate: 0, invalid: 0 })
+    expect(stats.get('b')).toEqual({ accepted: 0, stale: 0, duplicate: 1, invalid: 2 })
+    expect(stats.get('c')).toEqual({ accepted: 0, stale: 0, duplicate: 0, invalid: 0 })
+    expect(stats.total).toEqual({ accepted: 2, stale: 1, duplicate: 1, invalid: 2 })
+  })
+})
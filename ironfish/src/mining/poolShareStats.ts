@@ -0,0 +1,65 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+/**
+ * Why the pool did not count a submitted share
+ */
+export enum ShareRejectReason {
+  // The share is for a job the pool has moved on from
+  STALE = 'stale',
+  // The same work was already submitted
+  DUPLICATE = 'duplicate',
+  // The pool never sent out the job, or forgot it
+  UNKNOWN_JOB = 'unknown_job',
+  // The randomness could not be put into the header
+  MALFORMED = 'malformed',
+  // The hash does not meet the pool target
+  LOW_DIFFICULTY = 'low_difficulty',
+  // The client submitted before subscribing
+  NOT_SUBSCRIBED = 'not_subscribed',
+}
+
+export type ShareCounts = {
+  accepted: number
+  stale: number
+  duplicate: number
+  invalid: number
+}
+
+function emptyCounts(): ShareCounts {
+  return { accepted: 0, stale: 0, duplicate: 0, invalid: 0 }
+}
+
+/**
+ * Counts the shares the pool accepted and rejected for each public address
+ * since it started, to see which miners are sending bad work
+ */
+export class MiningPoolShareStats {
+  readonly total: ShareCounts = emptyCounts()
+  private readonly byAddress = new Map<string, ShareCounts>()
+
+  record(publicAddress: string, result: 'accepted' | ShareRejectReason): void {
+    let counts = this.byAddress.get(publicAddress)
+    if (!counts) {
+      counts = emptyCounts()
+      this.byAddress.set(publicAddress, counts)
+    }
+
+    const key =
+      result === 'accepted'
+        ? 'accepted'
+        : result === ShareRejectReason.STALE
+        ? 'stale'
+        : result === ShareRejectReason.DUPLICATE
+        ? 'duplicate'
+        : 'invalid'
+
+    counts[key]++
+    this.total[key]++
+  }
+
+  get(publicAddress: string): ShareCounts {
+    return { ...(this.byAddress.get(publicAddress) ?? emptyCounts()) }
+  }
+}
@@ -0,0 +1,189 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import fs from 'fs/promises'
+import os from 'os'
+import path from 'path'
+import { v4 as uuid } from 'uuid'
+import { Assert } from '../assert'
+import { Config } from '../fileStores/config'
+import { NodeFileProvider } from '../fileSystems/nodeFileSystem'
+import { createRootLogger } from '../logger'
+import { RpcSocketClient } from '../rpc/clients/socketClient'
+import { MiningPoolShares } from './poolShares'
+
+describe('MiningPoolShares', () => {
+  let dataDir: string
+  let config: Config
+  let shares: MiningPoolShares | undefined
+
+  const rpc = {
+    getAccountBalance: jest.fn(),
+    estimateFees: jest.fn(),
+    sendTransaction: jest.fn(),
+  }
+
+  beforeEach(async () => {
+    const files = new NodeFileProvider()
+    await files.init()
+
+    dataDir = path.join(os.tmpdir(), uuid())
+    config = new Config(files, dataDir)
+    config.setOverride('poolPayoutBatchSize', 2)
+
+    rpc.getAccountBalance.mockResolvedValue({ content: { available: '10000000' } })
+    rpc.estimateFees.mockResolvedValue({ content: { slow: '1' } })
+    rpc.sendTransaction.mockImplementation(() =>
+      Promise.resolve({ content: { hash: `hash${rpc.sendTransaction.mock.calls.length}` } }),
+    )
+  })
+
+  afterEach(async () => {
+    await shares?.stop()
+    shares = undefined
+    await fs.rm(dataDir, { recursive: true, force: true })
+    jest.resetAllMocks()
+  })
+
+  const feeAddress = 'f'.repeat(86)
+  const donationAddress = 'd'.repeat(86)
+
+  const start = async (): Promise<MiningPoolShares> => {
+    const started = await MiningPoolShares.init({
+      rpc: rpc as unknown as RpcSocketClient,
+      config,
+      logger: createRootLogger(),
+      enablePayouts: false,
+    })
+
+    shares = started
+    await started.start()
+    return started
+  }
+
+  // Shares are only paid once they are older than the payout's cutoff
+  const addShares = async (publicAddress: string, count: number): Promise<void> => {
+    Assert.isNotUndefined(shares)
+    for (let i = 0; i < count; i++) {
+      await shares['db']['db'].run(
+        "INSERT INTO share (publicAddress, createdAt) VALUES (?, datetime('now', '-1 minute'))",
+        publicAddress,
+      )
+    }
+  }
+
+  const getPayouts = async (pool: MiningPoolShares) =>
+    pool['db']['db'].all<{ succeeded: number; transactionHash: string }[]>(
+      'SELECT succeeded, transactionHash FROM payout ORDER BY id',
+    )
+
+  describe('splitPayout', () => {
+    it('rounds the fee and donation down and pays the rest to miners', async () => {
+      config.setOverride('poolFeePercent', 3)
+      config.setOverride('poolFeeAddress', feeAddress)
+      config.setOverride('poolDonationPercent', 1.5)
+      config.setOverride('poolDonationAddress', donationAddress)
+      const pool = await start()
+
+      expect(pool.splitPayout(999)).toEqual({ fee: 29, donation: 14, miners: 956 })
+      expect(pool.splitPayout(1000000)).toEqual({
+        fee: 30000,
+        donation: 15000,
+        miners: 955000,
+      })
+      expect(pool.splitPayout(1)).toEqual({ fee: 0, donation: 0, miners: 1 })
+      expect(pool.splitPayout(0)).toEqual({ fee: 0, donation: 0, miners: 0 })
+    })
+
+    it('ignores the donation percent without a donation address', async () => {
+      config.setOverride('poolDonationPercent', 5)
+      const pool = await start()
+
+      expect(pool.splitPayout(1000)).toEqual({ fee: 0, donation: 0, miners: 1000 })
+    })
+  })
+
+  describe('createPayout', () => {
+    it('pays the fee out with every payout so later payouts do not split it', async () => {
+      config.setOverride('poolFeePercent', 10)
+      config.setOverride('poolFeeAddress', feeAddress)
+      const pool = await start()
+
+      // The pool account is left with what the first payout did not send
+      rpc.getAccountBalance
+        .mockResolvedValueOnce({ content: { available: '10000000' } })
+        .mockResolvedValueOnce({ content: { available: '9000000' } })
+
+      await addShares('a', 1)
+      await pool.createPayout()
+
+      // Let the next payout happen right away
+      await pool['db']['db'].run("UPDATE payout SET createdAt = datetime('now', '-1 day')")
+
+      await addShares('a', 1)
+      await pool.createPayout()
+
+      const receives = rpc.sendTransaction.mock.calls.map(
+        ([{ receives }]: [{ receives: { publicAddress: string; amount: string }[] }]) =>
+          receives.map((r) => [r.publicAddress, r.amount]),
+      )
+
+      expect(receives).toEqual([
+        [
+          ['a', '900000'],
+          [feeAddress, '100000'],
+        ],
+        [
+          ['a', '810000'],
+          [feeAddress, '90000'],
+        ],
+      ])
+
+      // The fee address is not paid for shares
+      await expect(pool.sharesPendingPayout()).resolves.toBe(0)
+      expect(await getPayouts(pool)).toHaveLength(2)
+    })
+
+    it('splits the payout into transactions of at most the batch size', async () => {
+      const pool = await start()
+      await addShares('a', 1)
+      await addShares('b', 1)
+      await addShares('c', 2)
+
+      await pool.createPayout()
+
+      expect(rpc.sendTransaction).toHaveBeenCalledTimes(2)
+      const [[first], [second]] = rpc.sendTransaction.mock.calls as [
+        { receives: { publicAddress: string }[] },
+      ][]
+
+      expect(first.receives.map((r) => r.publicAddress)).toEqual(['a', 'b'])
+      expect(second.receives.map((r) => r.publicAddress)).toEqual(['c'])
+
+      await expect(pool.sharesPendingPayout()).resolves.toBe(0)
+
+      expect(await getPayouts(pool)).toEqual([
+        { succeeded: 1, transactionHash: 'hash1,hash2' },
+      ])
+    })
+
+    it('keeps the shares of miners in batches that failed', async () => {
+      const pool = await start()
+      await addShares('a', 1)
+      await addShares('b', 1)
+      await addShares('c', 1)
+
+      rpc.sendTransaction
+        .mockResolvedValueOnce({ content: { hash: 'hash1' } })
+        .mockRejectedValueOnce(new Error('send failed'))
+
+      await pool.createPayout()
+
+      await expect(pool.sharesPendingPayout('a')).resolves.toBe(0)
+      await expect(pool.sharesPendingPayout('b')).resolves.toBe(0)
+      await expect(pool.sharesPendingPayout('c')).resolves.toBe(1)
+
+      expect(await getPayouts(pool)).toEqual([{ succeeded: 1, transactionHash: 'hash1' }])
+    })
+  })
+})
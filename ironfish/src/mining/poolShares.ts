@@ -1,12 +1,12 @@
 /* This Source Code Form is subject to the terms of the Mozilla Public
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { isValidPublicAddress } from '../account/validator'
 import { Config } from '../fileStores/config'
 import { Logger } from '../logger'
 import { RpcSocketClient } from '../rpc/clients/socketClient'
 import { ErrorUtils } from '../utils'
 import { BigIntUtils } from '../utils/bigint'
-import { MapUtils } from '../utils/map'
 import { SetTimeoutToken } from '../utils/types'
 import { DatabaseShare, PoolDatabase } from './poolDatabase'
 import { WebhookNotifier } from './webhooks'
@@ -17,6 +17,12 @@ export class MiningPoolShares {
   readonly logger: Logger
   readonly webhooks: WebhookNotifier[]
 
+  // What is taken out of each payout before it is split between miners
+  readonly feePercent: number
+  readonly feeAddress: string | null
+  readonly donationPercent: number
+  readonly donationAddress: string | null
+
   private readonly db: PoolDatabase
   private enablePayouts: boolean
   private payoutInterval: SetTimeoutToken | null
@@ -27,6 +33,10 @@ export class MiningPoolShares {
   private accountName: string
   private balancePercentPayout: bigint
   private balancePercentPayoutFlag: number | undefined
+  private payoutBatchSize: number
+  private minimumPayout: number
+  private maxPayoutFee: number
+  private shareRetention: number
 
   private constructor(options: {
     db: PoolDatabase
@@ -50,6 +60,36 @@ export class MiningPoolShares {
     this.accountName = this.config.get('poolAccountName')
     this.balancePercentPayout = BigInt(this.config.get('poolBalancePercentPayout'))
     this.balancePercentPayoutFlag = options.balancePercentPayoutFlag
+    this.payoutBatchSize = Math.max(1, this.config.get('poolPayoutBatchSize'))
+    this.minimumPayout = this.config.get('poolMinimumPayout')
+    this.maxPayoutFee = this.config.get('poolMaxPayoutFee')
+    this.shareRetention = this.config.get('poolShareRetention')
+
+    this.feePercent = this.config.get('poolFeePercent')
+    this.feeAddress = this.config.get('poolFeeAddress') || null
+    this.donationAddress = this.config.get('poolDonationAddress') || null
+    this.donationPercent = this.donationAddress ? this.config.get('poolDonationPercent') : 0
+
+    if (
+      this.feePercent < 0 ||
+      this.donationPercent < 0 ||
+      this.feePercent + this.donationPercent > 100
+    ) {
+      throw new Error('poolFeePercent and poolDonationPercent must add up to at most 100')
+    }
+
+    // A fee left in the pool account would be paid to miners by the next payout
+    if (this.feePercent > 0 && !this.feeAddress) {
+      throw new Error('poolFeeAddress must be set to take a poolFeePercent')
+    }
+
+    if (this.feeAddress && !isValidPublicAddress(this.feeAddress)) {
+      throw new Error(`Invalid poolFeeAddress: ${this.feeAddress}`)
+    }
+
+    if (this.donationAddress && !isValidPublicAddress(this.donationAddress)) {
+      throw new Error(`Invalid poolDonationAddress: ${this.donationAddress}`)
+    }
 
     this.payoutInterval = null
   }
@@ -94,13 +134,11 @@ export class MiningPoolShares {
     await this.db.newShare(publicAddress)
   }
 
+  /**
+   * Pay the miners with shares since the last payout, in transactions of at
+   * most `poolPayoutBatchSize` outputs
+   */
   async createPayout(): Promise<void> {
-    // TODO: Make a max payout amount per transaction
-    //   - its currently possible to have a payout include so many inputs that it expires before it
-    //     gets added to the mempool. suspect this would cause issues elsewhere
-    //  As a simple stop-gap, we could probably make payout interval = every x hours OR if confirmed balance > 200 or something
-    //  OR we could combine them, every x minutes, pay 10 inputs into 1 output?
-
     // Since timestamps have a 1 second granularity, make the cutoff 1 second ago, just to avoid potential issues
     const shareCutoff = new Date()
     shareCutoff.setSeconds(shareCutoff.getSeconds() - 1)
@@ -124,7 +162,7 @@ export class MiningPoolShares {
     }
 
     const balance = await this.rpc.getAccountBalance({ account: this.accountName })
-    const confirmedBalance = BigInt(balance.content.confirmed)
+    const confirmedBalance = BigInt(balance.content.available)
 
     let payoutAmount: number
     if (this.balancePercentPayoutFlag !== undefined) {
@@ -136,50 +174,138 @@ export class MiningPoolShares {
       payoutAmount = BigIntUtils.divide(confirmedBalance, this.balancePercentPayout)
     }
 
-    if (payoutAmount <= shareCounts.totalShares + shareCounts.shares.size) {
+    // The operator fee and the donation are paid as other outputs, the rest is
+    // split between miners by their shares
+    const { fee: poolFee, donation, miners } = this.splitPayout(payoutAmount)
+
+    if (miners <= shareCounts.totalShares + shareCounts.shares.size) {
       // If the pool cannot pay out at least 1 ORE per share and pay transaction fees, no payout can be made.
       this.logger.info('Insufficient funds for payout, skipping.')
       return
     }
 
-    const transactionReceives = MapUtils.map(
-      shareCounts.shares,
-      (shareCount, publicAddress) => {
-        const payoutPercentage = shareCount / shareCounts.totalShares
-        const amt = Math.floor(payoutPercentage * payoutAmount)
+    const memo = `${this.poolName} payout ${shareCutoff.toUTCString()}`
+    const transactionReceives = []
 
-        return {
-          publicAddress,
-          amount: amt.toString(),
-          memo: `${this.poolName} payout ${shareCutoff.toUTCString()}`,
-        }
-      },
-    )
+    for (const [publicAddress, shareCount] of shareCounts.shares) {
+      const payoutPercentage = shareCount / shareCounts.totalShares
+      const amt = Math.floor(payoutPercentage * miners)
 
-    try {
-      this.webhooks.map((w) =>
-        w.poolPayoutStarted(payoutId, transactionReceives, shareCounts.totalShares),
+      // Miners owed less than the minimum keep their shares for a later payout
+      if (amt <= 0 || amt < this.minimumPayout) {
+        continue
+      }
+
+      transactionReceives.push({ publicAddress, amount: amt.toString(), memo })
+    }
+
+    if (transactionReceives.length === 0) {
+      this.logger.info('No miners are owed the minimum payout, skipping.')
+      return
+    }
+
+    const feeEstimate = await this.estimatePayoutFee()
+
+    if (this.maxPayoutFee > 0 && feeEstimate > BigInt(this.maxPayoutFee)) {
+      this.logger.info(
+        `Estimated fee of ${feeEstimate} ore is above the maximum of ${this.maxPayoutFee}, ` +
+          'waiting for fees to drop.',
       )
+      return
+    }
 
-      const transaction = await this.rpc.sendTransaction({
-        fromAccountName: this.accountName,
-        receives: transactionReceives,
-        fee: transactionReceives.length.toString(),
+    this.logger.info(
+      `Paying out ${payoutAmount} ore: ${miners} to miners, ` +
+        `${poolFee} pool fee, ${donation} donation`,
+    )
+
+    const poolReceives: { publicAddress: string; amount: string; memo: string }[] = []
+    if (this.feeAddress && poolFee > 0) {
+      poolReceives.push({
+        publicAddress: this.feeAddress,
+        amount: poolFee.toString(),
+        memo: `${this.poolName} fee ${shareCutoff.toUTCString()}`,
+      })
+    }
+    if (this.donationAddress && donation > 0) {
+      poolReceives.push({
+        publicAddress: this.donationAddress,
+        amount: donation.toString(),
+        memo: `${this.poolName} donation ${shareCutoff.toUTCString()}`,
       })
+    }
+    transactionReceives.push(...poolReceives)
+
+    const transactionHashes: string[] = []
+
+    for (let i = 0; i < transactionReceives.length; i += this.payoutBatchSize) {
+      const receives = transactionReceives.slice(i, i + this.payoutBatchSize)
+
+      // Pay at least 1 ore for each output, as the pool always has
+      const fee = BigIntUtils.max(feeEstimate, BigInt(receives.length))
+
+      try {
+        this.webhooks.map((w) =>
+          w.poolPayoutStarted(payoutId, receives, shareCounts.totalShares),
+        )
+
+        const transaction = await this.rpc.sendTransaction({
+          fromAccountName: this.accountName,
+          receives,
+          fee: fee.toString(),
+        })
+
+        transactionHashes.push(transaction.content.hash)
+
+        // The fee and donation are not paid for shares, even if the address also mines
+        const paid = receives
+          .filter((r) => !poolReceives.includes(r))
+          .map((r) => r.publicAddress)
+
+        await this.db.markSharesPaid(payoutId, timestamp, paid)
+
+        this.webhooks.map((w) =>
+          w.poolPayoutSuccess(
+            payoutId,
+            transaction.content.hash,
+            receives,
+            shareCounts.totalShares,
+          ),
+        )
+      } catch (e) {
+        // The miners left in this and later batches keep their shares for the next payout
+        this.logger.error(
+          `There was an error with the transaction ${ErrorUtils.renderError(e)}`,
+        )
+        this.webhooks.map((w) => w.poolPayoutError(e))
+        break
+      }
+    }
+
+    if (transactionHashes.length) {
+      await this.db.markPayoutSuccess(payoutId, transactionHashes)
+    }
+  }
 
-      await this.db.markPayoutSuccess(payoutId, timestamp, transaction.content.hash)
+  /**
+   * Split a payout into the operator fee, the donation and what miners are paid
+   */
+  splitPayout(amount: number): { fee: number; donation: number; miners: number } {
+    const fee = Math.floor((amount * this.feePercent) / 100)
+    const donation = Math.floor((amount * this.donationPercent) / 100)
+    return { fee, donation, miners: amount - fee - donation }
+  }
 
-      this.webhooks.map((w) =>
-        w.poolPayoutSuccess(
-          payoutId,
-          transaction.content.hash,
-          transactionReceives,
-          shareCounts.totalShares,
-        ),
-      )
+  /**
+   * The fee for a payout transaction, from the node's slow fee estimate
+   */
+  private async estimatePayoutFee(): Promise<bigint> {
+    try {
+      const estimate = await this.rpc.estimateFees()
+      return BigInt(estimate.content.slow)
     } catch (e) {
-      this.logger.error(`There was an error with the transaction ${ErrorUtils.renderError(e)}`)
-      this.webhooks.map((w) => w.poolPayoutError(e))
+      this.logger.warn(`Could not estimate fees, ${ErrorUtils.renderError(e)}`)
+      return BigInt(0)
     }
   }
 
@@ -216,9 +342,29 @@ export class MiningPoolShares {
     return await this.db.shareCountSince(timestamp, publicAddress)
   }
 
+  /**
+   * Delete the paid shares older than the retention period
+   */
+  async prune(): Promise<void> {
+    if (this.shareRetention <= 0) {
+      return
+    }
+
+    const cutoff = Math.floor(Date.now() / 1000) - this.shareRetention
+
+    try {
+      const pruned = await this.db.prune(cutoff)
+      if (pruned.shares || pruned.payouts) {
+        this.logger.debug(`Pruned ${pruned.shares} paid shares and ${pruned.payouts} payouts`)
+      }
+    } catch (e) {
+      this.logger.error(`Error pruning the pool database: ${ErrorUtils.renderError(e)}`)
+    }
+  }
+
   private startPayoutInterval() {
     this.payoutInterval = setInterval(() => {
-      void this.createPayout()
+      void this.createPayout().then(() => this.prune())
     }, this.attemptPayoutInterval * 1000)
   }
 
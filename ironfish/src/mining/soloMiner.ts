@@ -46,6 +46,10 @@ export class MiningSoloMiner {
   constructor(options: {
     threadCount: number
     batchSize: number
+    /**
+     * The percent of the time each thread may spend mining, defaults to 100
+     */
+    maxCpu?: number
     logger: Logger
     graffiti: Buffer
     rpc: RpcSocketClient
@@ -55,7 +59,7 @@ export class MiningSoloMiner {
     this.graffiti = options.graffiti
 
     const threadCount = options.threadCount ?? 1
-    this.threadPool = new ThreadPoolHandler(threadCount, options.batchSize)
+    this.threadPool = new ThreadPoolHandler(threadCount, options.batchSize, options.maxCpu)
 
     this.miningRequestId = 0
     this.nextMiningRequestId = 0
@@ -2,6 +2,6 @@
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 
-export { StratumClient } from './stratumClient'
+export { StratumClient, StratumShareStats } from './stratumClient'
 export { StratumServer } from './stratumServer'
 export { MiningStatusMessage } from './messages'
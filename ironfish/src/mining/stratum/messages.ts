@@ -2,6 +2,7 @@
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 import * as yup from 'yup'
+import { ShareCounts } from '../poolShareStats'
 
 export type StratumMessage = {
   id: number
@@ -58,12 +59,22 @@ export type MiningStatusMessage = {
   clients: number
   bans: number
   sharesPending: number
+  // Shares accepted and rejected since the pool started, older pools leave it out
+  shares?: ShareCounts
+  // The percents taken out of each payout before it is split between miners
+  fees?: {
+    poolFeePercent: number
+    feeAddress?: string
+    donationPercent: number
+    donationAddress?: string
+  }
   addressStatus?: {
     publicAddress: string
     connectedMiners: string[]
     hashRate: number
     miners: number
     sharesPending: number
+    shares?: ShareCounts
   }
 }
 
@@ -129,6 +140,15 @@ export const MiningGetStatusSchema: yup.ObjectSchema<MiningGetStatusMessage> = y
   })
   .default(undefined)
 
+const ShareCountsSchema = yup
+  .object({
+    accepted: yup.number().required(),
+    stale: yup.number().required(),
+    duplicate: yup.number().required(),
+    invalid: yup.number().required(),
+  })
+  .default(undefined)
+
 export const MiningStatusSchema: yup.ObjectSchema<MiningStatusMessage> = yup
   .object({
     name: yup.string().required(),
@@ -137,6 +157,15 @@ export const MiningStatusSchema: yup.ObjectSchema<MiningStatusMessage> = yup
     sharesPending: yup.number().required(),
     clients: yup.number().required(),
     bans: yup.number().required(),
+    shares: ShareCountsSchema,
+    fees: yup
+      .object({
+        poolFeePercent: yup.number().required(),
+        feeAddress: yup.string().optional(),
+        donationPercent: yup.number().required(),
+        donationAddress: yup.string().optional(),
+      })
+      .default(undefined),
     addressStatus: yup
       .object({
         publicAddress: yup.string().required(),
@@ -144,6 +173,7 @@ export const MiningStatusSchema: yup.ObjectSchema<MiningStatusMessage> = yup
         hashRate: yup.number().required(),
         miners: yup.number().required(),
         sharesPending: yup.number().required(),
+        shares: ShareCountsSchema,
       })
       .default(undefined),
   })
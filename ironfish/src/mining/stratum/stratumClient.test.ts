@@ -0,0 +1,122 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { createRootLogger } from '../../logger'
+import { StratumClient } from './stratumClient'
+
+jest.useFakeTimers()
+
+describe('StratumClient', () => {
+  const createClient = () => {
+    const client = new StratumClient({
+      host: 'localhost',
+      port: 9034,
+      logger: createRootLogger(),
+    })
+    const write = jest.spyOn(client.socket, 'write').mockImplementation(() => true)
+
+    const receive = (method: string, body: unknown) =>
+      client['onData'](Buffer.from(JSON.stringify({ id: 0, method, body }) + '\n'))
+
+    const subscribe = async (miningRequestId: number) => {
+      client['connected'] = true
+      await receive('mining.subscribed', { clientId: 1, graffiti: 'foo' })
+      await receive('mining.notify', { miningRequestId, header: 'ab' })
+    }
+
+    const submitted = () =>
+      write.mock.calls
+        .map(([data]) => JSON.parse(String(data)) as { method: string; body: unknown })
+        .filter((message) => message.method === 'mining.submit')
+        .map((message) => message.body)
+
+    return { client, subscribe, submitted }
+  }
+
+  it('submits shares while subscribed', async () => {
+    const { client, subscribe, submitted } = createClient()
+    await subscribe(5)
+
+    client.submit(5, 'aa')
+
+    expect(submitted()).toEqual([{ miningRequestId: 5, randomness: 'aa' }])
+    expect(client.shareStats).toEqual({ submitted: 1, resubmitted: 0, stale: 0 })
+  })
+
+  it('buffers shares until the pool sends work after reconnecting', async () => {
+    const { client, subscribe, submitted } = createClient()
+
+    client.submit(4, 'aa')
+    client.submit(5, 'bb')
+    client.submit(5, 'cc')
+    expect(submitted()).toEqual([])
+
+    await subscribe(5)
+
+    expect(submitted()).toEqual([
+      { miningRequestId: 5, randomness: 'bb' },
+      { miningRequestId: 5, randomness: 'cc' },
+    ])
+    expect(client.shareStats).toEqual({ submitted: 2, resubmitted: 2, stale: 1 })
+  })
+
+  it('buffers shares found after a disconnect', async () => {
+    const { client, subscribe, submitted } = createClient()
+    await subscribe(5)
+
+    client['onDisconnect']()
+    client.submit(5, 'aa')
+    expect(submitted()).toEqual([])
+
+    // The pool moved on to new work while the client was disconnected
+    await subscribe(6)
+
+    expect(submitted()).toEqual([])
+    expect(client.shareStats).toEqual({ submitted: 0, resubmitted: 0, stale: 1 })
+  })
+
+  it('drops the oldest shares when too many are buffered', async () => {
+    const { client, subscribe, submitted } = createClient()
+
+    for (let i = 0; i < 101; i++) {
+      client.submit(5, i.toString())
+    }
+
+    expect(client.shareStats.stale).toBe(1)
+
+    await subscribe(5)
+
+    const shares = submitted()
+    expect(shares).toHaveLength(100)
+    expect(shares[0]).toEqual({ miningRequestId: 5, randomness: '1' })
+    expect(client.shareStats).toEqual({ submitted: 100, resubmitted: 100, stale: 1 })
+  })
+
+  it('backs off reconnecting up to a minute', () => {
+    const { client } = createClient()
+    const setTimeoutSpy = jest.spyOn(global, 'setTimeout')
+
+    for (let i = 0; i < 8; i++) {
+      client['scheduleReconnect']()
+    }
+
+    const delays = setTimeoutSpy.mock.calls.map(([, delay]) => delay)
+    expect(delays).toEqual([1000, 2000, 4000, 8000, 16000, 32000, 60000, 60000])
+
+    client.stop()
+  })
+
+  it('only reconnects after a disconnect while started', () => {
+    const { client } = createClient()
+
+    client['onDisconnect']()
+    expect(client['connectTimeout']).toBeNull()
+
+    client['started'] = true
+    client['onDisconnect']()
+    expect(client['connectTimeout']).not.toBeNull()
+    expect(client['subscribed']).toBe(false)
+
+    client.stop()
+  })
+})
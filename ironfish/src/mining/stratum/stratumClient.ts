@@ -29,6 +29,21 @@ import {
 } from './messages'
 import { STRATUM_VERSION_PROTOCOL } from './version'
 
+const RECONNECT_MIN_MS = 1000
+const RECONNECT_MAX_MS = 60 * 1000
+
+// How many shares are kept while disconnected, the oldest are dropped first
+const MAX_BUFFERED_SHARES = 100
+
+export type StratumShareStats = {
+  // Shares sent to the pool, including ones sent after reconnecting
+  submitted: number
+  // Shares found while disconnected that were sent after reconnecting
+  resubmitted: number
+  // Shares found while disconnected for a job the pool moved on from
+  stale: number
+}
+
 export class StratumClient {
   readonly socket: net.Socket
   readonly host: string
@@ -44,6 +59,14 @@ export class StratumClient {
   private nextMessageId: number
   private messageBuffer = ''
 
+  private subscribed = false
+  private reconnectAttempts = 0
+  // The job the pool last sent, null until it sends one after connecting
+  private currentMiningRequestId: number | null = null
+  private bufferedShares: { miningRequestId: number; randomness: string }[] = []
+
+  readonly shareStats: StratumShareStats = { submitted: 0, resubmitted: 0, stale: 0 }
+
   private disconnectReason: string | null = null
   private disconnectUntil: number | null = null
   private disconnectVersion: number | null = null
@@ -103,16 +126,29 @@ export class StratumClient {
         this.connectWarned = true
       }
 
-      this.connectTimeout = setTimeout(() => void this.startConnecting(), 5000)
+      this.scheduleReconnect()
       return
     }
 
     this.connectWarned = false
+    this.reconnectAttempts = 0
     this.onConnect()
     this.onConnected.emit()
   }
 
+  /**
+   * Try to connect again after a delay that doubles with every failed attempt
+   */
+  private scheduleReconnect(): void {
+    const delay = Math.min(RECONNECT_MIN_MS * 2 ** this.reconnectAttempts, RECONNECT_MAX_MS)
+    this.reconnectAttempts++
+
+    this.logger.debug(`Reconnecting to pool in ${delay}ms`)
+    this.connectTimeout = setTimeout(() => void this.startConnecting(), delay)
+  }
+
   stop(): void {
+    this.started = false
     this.socket.end()
 
     if (this.connectTimeout) {
@@ -130,11 +166,58 @@ export class StratumClient {
     this.logger.info('Subscribing to pool to receive work')
   }
 
+  /**
+   * Submit a share, or keep it to submit after reconnecting if the pool is
+   * not connected
+   */
   submit(miningRequestId: number, randomness: string): void {
+    if (!this.subscribed) {
+      this.bufferedShares.push({ miningRequestId, randomness })
+
+      if (this.bufferedShares.length > MAX_BUFFERED_SHARES) {
+        this.bufferedShares.shift()
+        this.shareStats.stale++
+      }
+      return
+    }
+
     this.send('mining.submit', {
       miningRequestId: miningRequestId,
       randomness: randomness,
     })
+
+    this.shareStats.submitted++
+  }
+
+  /**
+   * Send the shares found while disconnected that are still for the job the
+   * pool is working on, the rest are stale
+   */
+  private submitBufferedShares(): void {
+    if (!this.subscribed || this.currentMiningRequestId === null) {
+      return
+    }
+
+    const shares = this.bufferedShares
+    this.bufferedShares = []
+
+    let resubmitted = 0
+    for (const share of shares) {
+      if (share.miningRequestId === this.currentMiningRequestId) {
+        this.submit(share.miningRequestId, share.randomness)
+        resubmitted++
+      }
+    }
+
+    const stale = shares.length - resubmitted
+    this.shareStats.resubmitted += resubmitted
+    this.shareStats.stale += stale
+
+    if (shares.length) {
+      this.logger.info(
+        `Submitted ${resubmitted} shares found while disconnected, ${stale} were stale`,
+      )
+    }
   }
 
   getStatus(publicAddress?: string): void {
@@ -172,6 +255,8 @@ export class StratumClient {
 
   private onDisconnect = (): void => {
     this.connected = false
+    this.subscribed = false
+    this.currentMiningRequestId = null
     this.messageBuffer = ''
     this.socket.off('error', this.onError)
     this.socket.off('close', this.onDisconnect)
@@ -198,7 +283,9 @@ export class StratumClient {
       this.logger.info('Disconnected from pool unexpectedly. Reconnecting.')
     }
 
-    this.connectTimeout = setTimeout(() => void this.startConnecting(), 5000)
+    if (this.started) {
+      this.scheduleReconnect()
+    }
   }
 
   private onError = (error: unknown): void => {
@@ -249,8 +336,10 @@ export class StratumClient {
           }
 
           this.id = body.result.clientId
+          this.subscribed = true
           this.logger.debug(`Server has identified us as client ${this.id}`)
           this.onSubscribed.emit(body.result)
+          this.submitBufferedShares()
           break
         }
 
@@ -270,6 +359,8 @@ export class StratumClient {
           if (body.error) {
             throw new ServerMessageMalformedError(body.error, header.result.method)
           }
+          this.currentMiningRequestId = body.result.miningRequestId
+          this.submitBufferedShares()
           this.onNotify.emit(body.result)
           break
         }
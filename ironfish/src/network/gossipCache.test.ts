@@ -0,0 +1,35 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { GossipCache } from './gossipCache'
+
+describe('GossipCache', () => {
+  const nonce = (i: number) => Buffer.from(i.toString().padStart(16, '0'))
+
+  it('should only add a nonce once', () => {
+    const cache = new GossipCache(100, 0.000001)
+
+    expect(cache.added(nonce(1))).toBe(true)
+    expect(cache.added(nonce(1))).toBe(false)
+
+    cache.add(nonce(2))
+    expect(cache.added(nonce(2))).toBe(false)
+  })
+
+  it('should remember nonces from before a resize', () => {
+    const cache = new GossipCache(100, 0.000001)
+
+    for (let i = 0; i < 50; i++) {
+      cache.add(nonce(i))
+    }
+
+    cache.resize(1000)
+    expect(cache.size).toBe(1000)
+
+    for (let i = 0; i < 50; i++) {
+      expect(cache.added(nonce(i))).toBe(false)
+    }
+
+    expect(cache.added(nonce(50))).toBe(true)
+  })
+})
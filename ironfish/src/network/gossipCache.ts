@@ -0,0 +1,67 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { RollingFilter } from '@ironfish/bfilter'
+
+/**
+ * Remembers the nonces of gossip messages we have seen so they are only
+ * handled once. The filter forgets the oldest nonces as it fills up, so it
+ * can be resized to keep up with how many messages the node receives.
+ */
+export class GossipCache {
+  readonly falsePositiveRate: number
+
+  private filter: RollingFilter
+  // The filter from before a resize, kept so its nonces are still known
+  // until the new filter has seen as many
+  private previous: RollingFilter | null = null
+  private addedSinceResize = 0
+
+  private _size: number
+  get size(): number {
+    return this._size
+  }
+
+  constructor(size: number, falsePositiveRate: number) {
+    this._size = size
+    this.falsePositiveRate = falsePositiveRate
+    this.filter = new RollingFilter(size, falsePositiveRate)
+  }
+
+  /**
+   * Add the nonce, and return true if it was not seen before
+   */
+  added(nonce: Buffer): boolean {
+    if (this.previous && this.previous.test(nonce)) {
+      this.filter.add(nonce)
+      return false
+    }
+
+    if (!this.filter.added(nonce)) {
+      return false
+    }
+
+    this.addedSinceResize++
+
+    if (this.previous && this.addedSinceResize >= this._size) {
+      this.previous = null
+    }
+
+    return true
+  }
+
+  add(nonce: Buffer): void {
+    this.filter.add(nonce)
+  }
+
+  resize(size: number): void {
+    if (size === this._size) {
+      return
+    }
+
+    this.previous = this.filter
+    this.filter = new RollingFilter(size, this.falsePositiveRate)
+    this.addedSinceResize = 0
+    this._size = size
+  }
+}
@@ -3,6 +3,7 @@
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 
 export { PeerNetwork } from './peerNetwork'
+export { NEARBY_LATENCY_MS } from './peers/addressManager'
 
 export type { Connection } from './peers/connections'
 export type { Peer } from './peers/peer'
@@ -0,0 +1,57 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import {
+  createAnnouncement,
+  decodeDnsPacket,
+  encodeDnsPacket,
+  MDNS_SERVICE,
+  parseAnnouncement,
+} from './localDiscovery'
+import { mockIdentity } from './testUtilities'
+
+describe('LocalDiscovery', () => {
+  const identity = mockIdentity('local')
+
+  it('should parse an encoded announcement', () => {
+    const packet = createAnnouncement({ identity, networkId: 'testnet', port: 9033 })
+    const decoded = decodeDnsPacket(encodeDnsPacket(packet))
+
+    expect(decoded.response).toBe(true)
+    expect(parseAnnouncement(decoded)).toEqual({ identity, networkId: 'testnet', port: 9033 })
+  })
+
+  it('should not parse queries as announcements', () => {
+    const query = encodeDnsPacket({
+      response: false,
+      questions: [{ name: MDNS_SERVICE, type: 12 }],
+      answers: [],
+    })
+
+    const decoded = decodeDnsPacket(query)
+    expect(decoded.questions).toEqual([{ name: MDNS_SERVICE, type: 12 }])
+    expect(parseAnnouncement(decoded)).toBeNull()
+  })
+
+  it('should follow compressed names', () => {
+    const packet = encodeDnsPacket({
+      response: true,
+      questions: [{ name: MDNS_SERVICE, type: 12 }],
+      answers: [],
+    })
+
+    // An answer whose name points back to the question name at offset 12
+    const answer = Buffer.from([0xc0, 12, 0, 12, 0, 1, 0, 0, 0, 120, 0, 2, 0xc0, 12])
+    const compressed = Buffer.concat([packet, answer])
+    compressed.writeUInt16BE(1, 6)
+
+    const decoded = decodeDnsPacket(compressed)
+    expect(decoded.answers).toHaveLength(1)
+    expect(decoded.answers[0].name).toEqual(MDNS_SERVICE)
+  })
+
+  it('should throw on truncated packets', () => {
+    const packet = encodeDnsPacket(createAnnouncement({ identity, networkId: 'a', port: 1 }))
+    expect(() => decodeDnsPacket(packet.subarray(0, packet.length - 5))).toThrow()
+  })
+})
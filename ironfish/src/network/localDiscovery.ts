@@ -0,0 +1,397 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import dgram from 'dgram'
+import { Event } from '../event'
+import { createRootLogger, Logger } from '../logger'
+import { ErrorUtils, SetIntervalToken } from '../utils'
+import { Identity } from './identity'
+
+export const MDNS_ADDRESS = '224.0.0.251'
+export const MDNS_PORT = 5353
+export const MDNS_SERVICE = '_ironfish._tcp.local'
+
+const ANNOUNCE_INTERVAL_MS = 60 * 1000
+const RECORD_TTL_SECONDS = 120
+
+const TYPE_PTR = 12
+const TYPE_TXT = 16
+const TYPE_SRV = 33
+const CLASS_IN = 1
+
+export type DnsRecord = {
+  name: string
+  type: number
+  ttl: number
+  data: Buffer
+}
+
+export type DnsPacket = {
+  response: boolean
+  questions: { name: string; type: number }[]
+  answers: DnsRecord[]
+}
+
+export type LocalPeerAnnouncement = {
+  identity: Identity
+  networkId: string
+  port: number
+}
+
+function writeName(name: string): Buffer {
+  const labels = name.split('.').filter((l) => l.length)
+  const parts = labels.map((label) => {
+    const bytes = Buffer.from(label, 'utf8')
+    return Buffer.concat([Buffer.from([bytes.length]), bytes])
+  })
+  return Buffer.concat([...parts, Buffer.from([0])])
+}
+
+/**
+ * Read a name at `offset`, following compression pointers
+ */
+function readName(buffer: Buffer, offset: number): { name: string; offset: number } {
+  const labels: string[] = []
+  let end: number | null = null
+  let jumps = 0
+
+  for (;;) {
+    const length = buffer.readUInt8(offset)
+
+    if (length === 0) {
+      offset += 1
+      break
+    }
+
+    if ((length & 0xc0) === 0xc0) {
+      if (++jumps > 16) {
+        throw new Error('Too many compression pointers in DNS name')
+      }
+      if (end === null) {
+        end = offset + 2
+      }
+      offset = buffer.readUInt16BE(offset) & 0x3fff
+      continue
+    }
+
+    if (offset + 1 + length > buffer.length) {
+      throw new Error('DNS name is out of bounds')
+    }
+
+    labels.push(buffer.toString('utf8', offset + 1, offset + 1 + length))
+    offset += 1 + length
+  }
+
+  return { name: labels.join('.'), offset: end ?? offset }
+}
+
+export function encodeDnsPacket(packet: DnsPacket): Buffer {
+  const header = Buffer.alloc(12)
+  // Authoritative answer for responses, and mDNS uses a query id of 0
+  header.writeUInt16BE(packet.response ? 0x8400 : 0, 2)
+  header.writeUInt16BE(packet.questions.length, 4)
+  header.writeUInt16BE(packet.answers.length, 6)
+
+  const parts = [header]
+
+  for (const question of packet.questions) {
+    const fields = Buffer.alloc(4)
+    fields.writeUInt16BE(question.type, 0)
+    fields.writeUInt16BE(CLASS_IN, 2)
+    parts.push(writeName(question.name), fields)
+  }
+
+  for (const answer of packet.answers) {
+    const fields = Buffer.alloc(10)
+    fields.writeUInt16BE(answer.type, 0)
+    fields.writeUInt16BE(CLASS_IN, 2)
+    fields.writeUInt32BE(answer.ttl, 4)
+    fields.writeUInt16BE(answer.data.length, 8)
+    parts.push(writeName(answer.name), fields, answer.data)
+  }
+
+  return Buffer.concat(parts)
+}
+
+/**
+ * Decode the questions and answers of a DNS packet, the authority and
+ * additional sections are ignored
+ */
+export function decodeDnsPacket(buffer: Buffer): DnsPacket {
+  const flags = buffer.readUInt16BE(2)
+  const questionCount = buffer.readUInt16BE(4)
+  const answerCount = buffer.readUInt16BE(6)
+
+  let offset = 12
+  const questions: DnsPacket['questions'] = []
+  const answers: DnsRecord[] = []
+
+  for (let i = 0; i < questionCount; i++) {
+    const name = readName(buffer, offset)
+    offset = name.offset
+    questions.push({ name: name.name, type: buffer.readUInt16BE(offset) })
+    offset += 4
+  }
+
+  for (let i = 0; i < answerCount; i++) {
+    const name = readName(buffer, offset)
+    offset = name.offset
+
+    const type = buffer.readUInt16BE(offset)
+    const ttl = buffer.readUInt32BE(offset + 4)
+    const length = buffer.readUInt16BE(offset + 8)
+    offset += 10
+
+    if (offset + length > buffer.length) {
+      throw new Error('DNS record is out of bounds')
+    }
+
+    // PTR and SRV data can hold names compressed against the rest of the
+    // packet, so store them uncompressed
+    let data = buffer.subarray(offset, offset + length)
+    if (type === TYPE_PTR) {
+      data = writeName(readName(buffer, offset).name)
+    } else if (type === TYPE_SRV) {
+      data = Buffer.concat([data.subarray(0, 6), writeName(readName(buffer, offset + 6).name)])
+    }
+
+    answers.push({ name: name.name, type, ttl, data })
+    offset += length
+  }
+
+  return { response: (flags & 0x8000) !== 0, questions, answers }
+}
+
+function encodeTxt(values: Record<string, string>): Buffer {
+  return Buffer.concat(
+    Object.entries(values).map(([key, value]) => {
+      const entry = Buffer.from(`${key}=${value}`, 'utf8')
+      return Buffer.concat([Buffer.from([entry.length]), entry])
+    }),
+  )
+}
+
+function decodeTxt(data: Buffer): Record<string, string> {
+  const values: Record<string, string> = {}
+  let offset = 0
+
+  while (offset < data.length) {
+    const length = data.readUInt8(offset)
+    const entry = data.toString('utf8', offset + 1, offset + 1 + length)
+    offset += 1 + length
+
+    const separator = entry.indexOf('=')
+    if (separator > 0) {
+      values[entry.slice(0, separator)] = entry.slice(separator + 1)
+    }
+  }
+
+  return values
+}
+
+/**
+ * The records a node answers with: a PTR from the service to this node's
+ * instance, an SRV with its web socket port, and a TXT with its identity and
+ * network
+ */
+export function createAnnouncement(announcement: LocalPeerAnnouncement): DnsPacket {
+  // Identities are base64, so only keep the characters that are safe in a label
+  const label = announcement.identity.replace(/[^a-zA-Z0-9]/g, '').slice(0, 32)
+  const instance = `${label}.${MDNS_SERVICE}`
+
+  const srv = Buffer.alloc(6)
+  srv.writeUInt16BE(0, 0)
+  srv.writeUInt16BE(0, 2)
+  srv.writeUInt16BE(announcement.port, 4)
+
+  return {
+    response: true,
+    questions: [],
+    answers: [
+      {
+        name: MDNS_SERVICE,
+        type: TYPE_PTR,
+        ttl: RECORD_TTL_SECONDS,
+        data: writeName(instance),
+      },
+      {
+        name: instance,
+        type: TYPE_SRV,
+        ttl: RECORD_TTL_SECONDS,
+        data: Buffer.concat([srv, writeName(instance)]),
+      },
+      {
+        name: instance,
+        type: TYPE_TXT,
+        ttl: RECORD_TTL_SECONDS,
+        data: encodeTxt({ identity: announcement.identity, network: announcement.networkId }),
+      },
+    ],
+  }
+}
+
+/**
+ * Read an announcement from another node out of a response, or null if the
+ * response is not for an Iron Fish node
+ */
+export function parseAnnouncement(packet: DnsPacket): LocalPeerAnnouncement | null {
+  if (!packet.response) {
+    return null
+  }
+
+  const pointer = packet.answers.find((a) => a.type === TYPE_PTR && a.name === MDNS_SERVICE)
+  if (!pointer) {
+    return null
+  }
+
+  const instance = readName(pointer.data, 0).name
+  const srv = packet.answers.find((a) => a.type === TYPE_SRV && a.name === instance)
+  const txt = packet.answers.find((a) => a.type === TYPE_TXT && a.name === instance)
+
+  if (!srv || !txt || srv.data.length < 6) {
+    return null
+  }
+
+  const values = decodeTxt(txt.data)
+  if (!values.identity || !values.network) {
+    return null
+  }
+
+  return {
+    identity: values.identity,
+    networkId: values.network,
+    port: srv.data.readUInt16BE(4),
+  }
+}
+
+/**
+ * Finds other nodes on the local network with multicast DNS and announces
+ * this one, so nodes on the same LAN can connect and sync directly
+ */
+export class LocalDiscovery {
+  readonly logger: Logger
+  readonly identity: Identity
+  readonly networkId: string
+  readonly port: number
+
+  readonly onPeerFound = new Event<[address: string, announcement: LocalPeerAnnouncement]>()
+
+  private socket: dgram.Socket | null = null
+  private interval: SetIntervalToken | null = null
+
+  constructor(options: {
+    identity: Identity
+    networkId: string
+    port: number
+    logger?: Logger
+  }) {
+    this.logger = (options.logger ?? createRootLogger()).withTag('mdns')
+    this.identity = options.identity
+    this.networkId = options.networkId
+    this.port = options.port
+  }
+
+  start(): void {
+    if (this.socket) {
+      return
+    }
+
+    const socket = dgram.createSocket({ type: 'udp4', reuseAddr: true })
+    this.socket = socket
+
+    socket.on('error', (error) => {
+      this.logger.warn(`Local peer discovery stopped: ${ErrorUtils.renderError(error)}`)
+      this.stop()
+    })
+
+    socket.on('message', (message, remote) => this.onMessage(message, remote.address))
+
+    socket.bind(MDNS_PORT, () => {
+      try {
+        socket.addMembership(MDNS_ADDRESS)
+        socket.setMulticastTTL(255)
+        socket.setMulticastLoopback(true)
+      } catch (error: unknown) {
+        this.logger.warn(`Could not join the mDNS group: ${ErrorUtils.renderError(error)}`)
+        this.stop()
+        return
+      }
+
+      this.logger.debug(`Discovering local peers on ${MDNS_ADDRESS}:${MDNS_PORT}`)
+      this.query()
+      this.announce()
+    })
+
+    this.interval = setInterval(() => {
+      this.query()
+      this.announce()
+    }, ANNOUNCE_INTERVAL_MS)
+  }
+
+  stop(): void {
+    if (this.interval) {
+      clearInterval(this.interval)
+      this.interval = null
+    }
+
+    if (this.socket) {
+      const socket = this.socket
+      this.socket = null
+      try {
+        socket.close()
+      } catch {
+        // The socket was not bound yet
+      }
+    }
+  }
+
+  query(): void {
+    this.send({
+      response: false,
+      questions: [{ name: MDNS_SERVICE, type: TYPE_PTR }],
+      answers: [],
+    })
+  }
+
+  announce(): void {
+    const announcement = createAnnouncement({
+      identity: this.identity,
+      networkId: this.networkId,
+      port: this.port,
+    })
+
+    this.send(announcement)
+  }
+
+  private send(packet: DnsPacket): void {
+    this.socket?.send(encodeDnsPacket(packet), MDNS_PORT, MDNS_ADDRESS)
+  }
+
+  private onMessage(message: Buffer, address: string): void {
+    let packet: DnsPacket
+    try {
+      packet = decodeDnsPacket(message)
+    } catch {
+      // Other mDNS traffic on the network can be anything
+      return
+    }
+
+    if (!packet.response) {
+      if (packet.questions.some((q) => q.type === TYPE_PTR && q.name === MDNS_SERVICE)) {
+        this.announce()
+      }
+      return
+    }
+
+    const announcement = parseAnnouncement(packet)
+    if (
+      !announcement ||
+      announcement.identity === this.identity ||
+      announcement.networkId !== this.networkId
+    ) {
+      return
+    }
+
+    this.onPeerFound.emit(address, announcement)
+  }
+}
@@ -12,6 +12,7 @@ import {
 } from './messages/getBlockTransactions'
 import { GossipNetworkMessage } from './messages/gossipNetworkMessage'
 import { IdentifyMessage } from './messages/identify'
+import { IdentityChallengeMessage, IdentityProofMessage } from './messages/identityChallenge'
 import { NetworkMessage } from './messages/networkMessage'
 import { NewBlockMessage } from './messages/newBlock'
 import { NewBlockHashesMessage } from './messages/newBlockHashes'
@@ -111,6 +112,10 @@ const parseGenericNetworkMessage = (type: NetworkMessageType, body: Buffer): Net
       return DisconnectingMessage.deserialize(body)
     case NetworkMessageType.Identify:
       return IdentifyMessage.deserialize(body)
+    case NetworkMessageType.IdentityChallenge:
+      return IdentityChallengeMessage.deserialize(body)
+    case NetworkMessageType.IdentityProof:
+      return IdentityProofMessage.deserialize(body)
     case NetworkMessageType.PeerList:
       return PeerListMessage.deserialize(body)
     case NetworkMessageType.PeerListRequest:
@@ -0,0 +1,30 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { nonceLength } from '../peers/encryption'
+import { IdentityChallengeMessage, IdentityProofMessage } from './identityChallenge'
+
+describe('IdentityChallengeMessage', () => {
+  it('serializes the object into a buffer and deserializes to the original object', () => {
+    const message = new IdentityChallengeMessage({
+      nonce: Buffer.alloc(nonceLength, 'nonce').toString('base64'),
+      challenge: Buffer.alloc(48, 'challenge').toString('base64'),
+    })
+
+    const buffer = message.serialize()
+    const deserializedMessage = IdentityChallengeMessage.deserialize(buffer)
+    expect(deserializedMessage).toEqual(message)
+  })
+})
+
+describe('IdentityProofMessage', () => {
+  it('serializes the object into a buffer and deserializes to the original object', () => {
+    const message = new IdentityProofMessage({
+      challenge: Buffer.alloc(32, 'challenge').toString('base64'),
+    })
+
+    const buffer = message.serialize()
+    const deserializedMessage = IdentityProofMessage.deserialize(buffer)
+    expect(deserializedMessage).toEqual(message)
+  })
+})
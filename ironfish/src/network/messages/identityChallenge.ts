@@ -0,0 +1,74 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import bufio from 'bufio'
+import { nonceLength } from '../peers/encryption'
+import { NetworkMessageType } from '../types'
+import { NetworkMessage } from './networkMessage'
+
+interface CreateIdentityChallengeMessageOptions {
+  nonce: string
+  challenge: string
+}
+
+/**
+ * Sent to a peer with a pinned identity after it identifies. The challenge is
+ * boxed to the pinned identity, so only a peer with its private key can open
+ * it and send it back in an IdentityProofMessage.
+ */
+export class IdentityChallengeMessage extends NetworkMessage {
+  readonly nonce: string
+  readonly challenge: string
+
+  constructor({ nonce, challenge }: CreateIdentityChallengeMessageOptions) {
+    super(NetworkMessageType.IdentityChallenge)
+    this.nonce = nonce
+    this.challenge = challenge
+  }
+
+  serialize(): Buffer {
+    const bw = bufio.write(this.getSize())
+    bw.writeBytes(Buffer.from(this.nonce, 'base64'))
+    bw.writeBytes(Buffer.from(this.challenge, 'base64'))
+    return bw.render()
+  }
+
+  static deserialize(buffer: Buffer): IdentityChallengeMessage {
+    const reader = bufio.read(buffer, true)
+    const nonce = reader.readBytes(nonceLength).toString('base64')
+    const challenge = reader.readBytes(reader.left()).toString('base64')
+    return new IdentityChallengeMessage({ nonce, challenge })
+  }
+
+  getSize(): number {
+    return nonceLength + Buffer.byteLength(this.challenge, 'base64')
+  }
+}
+
+/**
+ * The opened challenge of an IdentityChallengeMessage
+ */
+export class IdentityProofMessage extends NetworkMessage {
+  readonly challenge: string
+
+  constructor({ challenge }: { challenge: string }) {
+    super(NetworkMessageType.IdentityProof)
+    this.challenge = challenge
+  }
+
+  serialize(): Buffer {
+    const bw = bufio.write(this.getSize())
+    bw.writeVarString(this.challenge, 'utf8')
+    return bw.render()
+  }
+
+  static deserialize(buffer: Buffer): IdentityProofMessage {
+    const reader = bufio.read(buffer, true)
+    const challenge = reader.readVarString('utf8')
+    return new IdentityProofMessage({ challenge })
+  }
+
+  getSize(): number {
+    return bufio.sizeVarString(this.challenge, 'utf8')
+  }
+}
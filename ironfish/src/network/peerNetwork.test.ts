@@ -190,6 +190,7 @@ describe('PeerNetwork', () => {
       await node.chain.transactions.put(block.header.hash, {
         transactions: [transaction1, transaction2, transaction3],
       })
+      node.chain.blockCache.clear()
 
       const { peer } = getConnectedPeer(peerNetwork.peerManager)
       const peerIdentity = peer.getIdentityOrThrow()
@@ -375,6 +376,59 @@ describe('PeerNetwork', () => {
         expect(peer2Send).not.toBeCalled()
         expect(peer3Send).toBeCalledWith(newBlockMessage)
       })
+
+      it('should record propagation time for forwarded blocks', async () => {
+        const { strategy, chain, peerNetwork, node } = nodeTest
+
+        const genesis = await chain.getBlock(chain.genesis)
+        Assert.isNotNull(genesis)
+
+        strategy.disableMiningReward()
+        const blockA1 = await makeBlockAfter(chain, genesis)
+
+        const { peer } = getConnectedPeer(peerNetwork.peerManager)
+        const newBlockMessage = new NewBlockMessage(strategy.blockSerde.serialize(blockA1))
+
+        const onBlockPropagated = jest.fn()
+        peerNetwork.onBlockPropagated.on(onBlockPropagated)
+
+        await peerNetwork['handleGossipMessage'](peer, newBlockMessage)
+
+        expect(onBlockPropagated).toHaveBeenCalledTimes(1)
+        expect(onBlockPropagated.mock.calls[0][0].header.hash).toEqual(blockA1.header.hash)
+        expect(node.metrics.p2p_BlockPropagation.average).toBeGreaterThan(0)
+      })
+
+      it('should record acknowledgement when a peer relays a mined block', async () => {
+        const { strategy, chain, peerNetwork, node } = nodeTest
+
+        const genesis = await chain.getBlock(chain.genesis)
+        Assert.isNotNull(genesis)
+
+        strategy.disableMiningReward()
+        const blockA1 = await makeBlockAfter(chain, genesis)
+
+        const { peer: peer1 } = getConnectedPeer(peerNetwork.peerManager)
+        const { peer: peer2 } = getConnectedPeer(peerNetwork.peerManager)
+        const peer1Send = jest.spyOn(peer1, 'send')
+
+        const onMinedBlockAcknowledged = jest.fn()
+        peerNetwork.onMinedBlockAcknowledged.on(onMinedBlockAcknowledged)
+
+        node.miningManager.onNewBlock.emit(blockA1)
+
+        const sent = peer1Send.mock.calls[0][0]
+        Assert.isInstanceOf(sent, NewBlockMessage)
+
+        await peerNetwork['handleGossipMessage'](peer2, sent)
+
+        expect(onMinedBlockAcknowledged).toHaveBeenCalledTimes(1)
+        expect(onMinedBlockAcknowledged.mock.calls[0][2]).toEqual(peer2.getIdentityOrThrow())
+
+        // A second relay of the same block is not counted again
+        await peerNetwork['handleGossipMessage'](peer1, sent)
+        expect(onMinedBlockAcknowledged).toHaveBeenCalledTimes(1)
+      })
     })
 
     describe('handles requests for mempool transactions', () => {
@@ -555,6 +609,52 @@ describe('PeerNetwork', () => {
           expect(acceptTransaction).toHaveBeenCalledTimes(2)
           expect(syncTransaction).toHaveBeenCalledTimes(3)
         })
+
+        it('accepts but does not relay transactions larger than the relay limit', async () => {
+          const chain = mockChain()
+          const node = {
+            ...mockNode(),
+            chain,
+            workerPool: { ...mockWorkerPool, saturated: false },
+          }
+
+          const peerNetwork = new PeerNetwork({
+            identity: mockPrivateIdentity('local'),
+            agent: 'sdk/1/cli',
+            webSocket: ws,
+            node,
+            chain,
+            strategy: mockStrategy(),
+            hostsStore: mockHostsStore(),
+            relayMaxTransactionSize: 8,
+          })
+
+          jest
+            .spyOn(node.chain.verifier, 'verifyNewTransaction')
+            .mockReturnValue(mockTransaction())
+          jest
+            .spyOn(node.chain.verifier, 'verifyTransactionNoncontextual')
+            .mockReturnValue({ valid: true })
+          jest.spyOn(node.memPool, 'exists').mockReturnValue(false)
+          jest.spyOn(node.memPool, 'acceptTransaction').mockReturnValue(true)
+
+          // Relaying a transaction sends it to each connected peer
+          const broadcast = jest.spyOn(peerNetwork.peerManager, 'getConnectedPeers')
+
+          const small = {
+            peerIdentity: '',
+            message: new NewTransactionMessage(Buffer.alloc(8), Buffer.alloc(16, 'small')),
+          }
+          await expect(peerNetwork['onNewTransaction'](small)).resolves.toBe(true)
+          expect(broadcast).toHaveBeenCalledTimes(1)
+
+          const large = {
+            peerIdentity: '',
+            message: new NewTransactionMessage(Buffer.alloc(9), Buffer.alloc(16, 'large')),
+          }
+          await expect(peerNetwork['onNewTransaction'](large)).resolves.toBe(true)
+          expect(broadcast).toHaveBeenCalledTimes(1)
+        })
       })
     })
   })
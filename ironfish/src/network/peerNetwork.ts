@@ -2,26 +2,28 @@
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 
-import { RollingFilter } from '@ironfish/bfilter'
 import tweetnacl from 'tweetnacl'
 import { Assert } from '../assert'
 import { Blockchain } from '../blockchain'
-import { MAX_REQUESTED_BLOCKS } from '../consensus'
+import { GENESIS_BLOCK_SEQUENCE, MAX_REQUESTED_BLOCKS } from '../consensus'
 import { Event } from '../event'
 import { DEFAULT_WEBSOCKET_PORT } from '../fileStores/config'
 import { HostsStore } from '../fileStores/hosts'
 import { createRootLogger, Logger } from '../logger'
+import { getFeeRate } from '../memPool/feeEstimator'
 import { MetricsMonitor } from '../metrics'
 import { IronfishNode } from '../node'
 import { IronfishPKG } from '../package'
 import { Platform } from '../platform'
 import { Transaction } from '../primitives'
-import { SerializedBlock } from '../primitives/block'
+import { Block, SerializedBlock } from '../primitives/block'
 import { BlockHeader } from '../primitives/blockheader'
 import { SerializedTransaction } from '../primitives/transaction'
 import { Strategy } from '../strategy'
-import { ErrorUtils } from '../utils'
-import { PrivateIdentity } from './identity'
+import { BenchUtils, ErrorUtils, HRTime, SetIntervalToken } from '../utils'
+import { GossipCache } from './gossipCache'
+import { isIdentity, PrivateIdentity } from './identity'
+import { LocalDiscovery } from './localDiscovery'
 import { CannotSatisfyRequest } from './messages/cannotSatisfyRequest'
 import { DisconnectingMessage, DisconnectingReason } from './messages/disconnecting'
 import { GetBlockHashesRequest, GetBlockHashesResponse } from './messages/getBlockHashes'
@@ -60,8 +62,9 @@ import { LocalPeer } from './peers/localPeer'
 import { BAN_SCORE, KnownBlockHashesValue, Peer } from './peers/peer'
 import { PeerConnectionManager } from './peers/peerConnectionManager'
 import { PeerManager } from './peers/peerManager'
+import { PropagationTracer } from './propagationTracer'
 import { IsomorphicWebSocketConstructor } from './types'
-import { parseUrl } from './utils/parseUrl'
+import { parseBootstrapNode, parseUrl } from './utils/parseUrl'
 import { VERSION_PROTOCOL } from './version'
 import { WebSocketServer } from './webSocketServer'
 
@@ -73,9 +76,29 @@ import { WebSocketServer } from './webSocketServer'
  */
 const GOSSIP_FILTER_SIZE = 100000
 const GOSSIP_FILTER_FP_RATE = 0.000001
+// Resizing starts a new filter, so only resize when the size changes by this much
+const GOSSIP_FILTER_RESIZE_FACTOR = 1.5
 
 const MAX_GET_BLOCK_TRANSACTIONS_DEPTH = 10
 
+/**
+ * The most milliseconds a new transaction is held back while memory pressure is
+ * elevated before it is processed anyway
+ */
+const GOSSIP_BACKPRESSURE_DELAY_MS = 1000
+
+/**
+ * The number of mined blocks we keep track of while waiting for a peer
+ * to relay them back to us, which we use as the acknowledgement that the
+ * block has propagated.
+ */
+const MAX_PENDING_MINED_BLOCKS = 10
+
+/**
+ * How often the round trip time to each connected peer is measured
+ */
+const LATENCY_PROBE_INTERVAL_MS = 60 * 1000
+
 type RpcRequest = {
   resolve: (value: IncomingPeerMessage<RpcNetworkMessage>) => void
   reject: (e: unknown) => void
@@ -94,10 +117,15 @@ export class PeerNetwork {
   readonly peerManager: PeerManager
   readonly onIsReadyChanged = new Event<[boolean]>()
   readonly onTransactionAccepted = new Event<[transaction: Transaction, received: Date]>()
+  readonly onBlockPropagated = new Event<[block: Block, elapsedMs: number]>()
+  readonly onMinedBlockAcknowledged = new Event<
+    [block: Block, elapsedMs: number, peerIdentity: string]
+  >()
 
   private started = false
-  private readonly minPeers: number
-  private readonly bootstrapNodes: string[]
+  private latencyProbeInterval: SetIntervalToken | null = null
+  private minPeers: number
+  readonly bootstrapNodes: string[]
   private readonly listen: boolean
   private readonly peerConnectionManager: PeerConnectionManager
   private readonly logger: Logger
@@ -105,9 +133,23 @@ export class PeerNetwork {
   private readonly node: IronfishNode
   private readonly strategy: Strategy
   private readonly chain: Blockchain
-  private readonly seenGossipFilter: RollingFilter
+  private readonly seenGossipFilter: GossipCache
+  private readonly gossipCacheSize: number
+  private readonly gossipCacheSizePerPeer: number
   private readonly requests: Map<RpcId, RpcRequest>
   private readonly enableSyncing: boolean
+  private readonly relayMinFeeRate: bigint
+  private readonly relayMaxTransactionSize: number
+  private readonly relayWhileSyncing: boolean
+  private readonly localDiscovery: LocalDiscovery | null = null
+  // Identities of peers found on the local network
+  readonly localIdentities = new Set<string>()
+  private readonly pendingMinedBlocks = new Map<string, { block: Block; sent: HRTime }>()
+
+  /**
+   * Records how blocks and transactions propagate, null unless tracing is on
+   */
+  propagation: PropagationTracer | null = null
 
   /**
    * If the peer network is ready for messages to be sent or not
@@ -129,6 +171,14 @@ export class PeerNetwork {
     minPeers?: number
     targetPeers?: number
     enableSyncing?: boolean
+    relayMinFeeRate?: number
+    relayMaxTransactionSize?: number
+    relayWhileSyncing?: boolean
+    gossipCacheSize?: number
+    gossipCacheSizePerPeer?: number
+    knownBlocksCacheSize?: number
+    enableLocalDiscovery?: boolean
+    enablePropagationTracing?: boolean
     logPeerMessages?: boolean
     simulateLatency?: number
     logger?: Logger
@@ -141,6 +191,9 @@ export class PeerNetwork {
     const identity = options.identity || tweetnacl.box.keyPair()
 
     this.enableSyncing = options.enableSyncing ?? true
+    this.relayMinFeeRate = BigInt(options.relayMinFeeRate ?? 0)
+    this.relayMaxTransactionSize = options.relayMaxTransactionSize ?? 0
+    this.relayWhileSyncing = options.relayWhileSyncing ?? true
     this.node = options.node
     this.chain = options.chain
     this.strategy = options.strategy
@@ -173,10 +226,12 @@ export class PeerNetwork {
       maxPeers,
       targetPeers,
       logPeerMessages,
+      options.knownBlocksCacheSize,
     )
     this.peerManager.onMessage.on((peer, message) => this.handleMessage(peer, message))
     this.peerManager.onConnectedPeersChanged.on(() => {
       this.metrics.p2p_PeersCount.value = this.peerManager.getConnectedPeers().length
+      this.updateGossipCacheSize()
       this.updateIsReady()
     })
 
@@ -187,7 +242,25 @@ export class PeerNetwork {
     this.minPeers = options.minPeers || 1
     this.listen = options.listen === undefined ? true : options.listen
 
-    this.seenGossipFilter = new RollingFilter(GOSSIP_FILTER_SIZE, GOSSIP_FILTER_FP_RATE)
+    // Other nodes can only connect to us if we listen
+    if (options.enableLocalDiscovery && this.listen && this.localPeer.port !== null) {
+      this.localDiscovery = new LocalDiscovery({
+        identity: this.localPeer.publicIdentity,
+        networkId: this.chain.network.id,
+        port: this.localPeer.port,
+        logger: this.logger,
+      })
+
+      this.localDiscovery.onPeerFound.on((address, { identity, port }) =>
+        this.onLocalPeerFound(address, identity, port),
+      )
+    }
+
+    this.gossipCacheSize = options.gossipCacheSize || GOSSIP_FILTER_SIZE
+    this.gossipCacheSizePerPeer = options.gossipCacheSizePerPeer ?? 0
+    this.seenGossipFilter = new GossipCache(this.gossipCacheSize, GOSSIP_FILTER_FP_RATE)
+    this.setPropagationTracing(options.enablePropagationTracing ?? false)
+    this.metrics.p2p_GossipCacheSize.value = this.seenGossipFilter.size
     this.requests = new Map<RpcId, RpcRequest>()
 
     if (options.name && options.name.length > 32) {
@@ -196,14 +269,19 @@ export class PeerNetwork {
 
     this.node.miningManager.onNewBlock.on((block) => {
       const serializedBlock = this.strategy.blockSerde.serialize(block)
+      const message = new NewBlockMessage(serializedBlock)
 
-      this.broadcastBlock(new NewBlockMessage(serializedBlock))
+      this.trackMinedBlock(message.nonce, block)
+      this.propagation?.created('block', message.nonce, block.header.hash)
+      this.broadcastBlock(message)
     })
 
     this.node.accounts.onBroadcastTransaction.on((transaction) => {
       const serializedTransaction = transaction.serialize()
+      const message = new NewTransactionMessage(serializedTransaction)
 
-      this.gossip(new NewTransactionMessage(serializedTransaction))
+      this.propagation?.created('transaction', message.nonce, transaction.unsignedHash())
+      this.gossip(message)
     })
   }
 
@@ -288,8 +366,16 @@ export class PeerNetwork {
 
     this.updateIsReady()
 
+    this.localDiscovery?.start()
+
+    this.latencyProbeInterval = setInterval(
+      () => this.probeLatencies(),
+      LATENCY_PROBE_INTERVAL_MS,
+    )
+
     for (const node of this.bootstrapNodes) {
-      const url = parseUrl(node)
+      const { address: nodeAddress, identity } = parseBootstrapNode(node)
+      const url = parseUrl(nodeAddress)
 
       if (!url.hostname) {
         throw new Error(
@@ -301,7 +387,12 @@ export class PeerNetwork {
       // it's running on the default ironfish websocket port
       const port = url.port ? url.port : DEFAULT_WEBSOCKET_PORT
       const address = url.hostname + `:${port}`
-      this.peerManager.connectToWebSocketAddress(address, true)
+
+      if (identity !== null && !isIdentity(identity)) {
+        throw new Error(`The identity of bootstrap node "${node}" is not a valid identity`)
+      }
+
+      this.peerManager.connectToWebSocketAddress(address, true, identity)
     }
   }
 
@@ -311,12 +402,84 @@ export class PeerNetwork {
    */
   async stop(): Promise<void> {
     this.started = false
+    this.localDiscovery?.stop()
     this.peerConnectionManager.stop()
+
+    if (this.latencyProbeInterval) {
+      clearInterval(this.latencyProbeInterval)
+      this.latencyProbeInterval = null
+    }
+
     await this.peerManager.stop()
     this.webSocketServer?.close()
     this.updateIsReady()
   }
 
+  /**
+   * Measure the round trip time to every connected peer that isn't busy. Any
+   * small request works as a probe, so this asks for the genesis block hash,
+   * which every peer can answer without doing any work.
+   */
+  probeLatencies(): void {
+    for (const peer of this.peerManager.getConnectedPeers()) {
+      if (!peer.isSaturated) {
+        void this.probeLatency(peer)
+      }
+    }
+  }
+
+  private async probeLatency(peer: Peer): Promise<void> {
+    const start = Date.now()
+
+    try {
+      await this.requestFrom(peer, new GetBlockHashesRequest(GENESIS_BLOCK_SEQUENCE, 1))
+      peer.recordLatency(Date.now() - start)
+    } catch (e: unknown) {
+      this.logger.debug(
+        `Failed to measure latency to ${peer.displayName}: ${ErrorUtils.renderError(e)}`,
+      )
+    }
+  }
+
+  /**
+   * Grow the seen gossip filter with the number of peers, since every peer can
+   * send us each message
+   */
+  private updateGossipCacheSize(): void {
+    const peers = this.peerManager.getConnectedPeers().length
+    const target = Math.max(this.gossipCacheSize, this.gossipCacheSizePerPeer * peers)
+    const size = this.seenGossipFilter.size
+
+    if (
+      target < size * GOSSIP_FILTER_RESIZE_FACTOR &&
+      target > size / GOSSIP_FILTER_RESIZE_FACTOR
+    ) {
+      return
+    }
+
+    this.logger.debug(`Resizing the seen gossip filter from ${size} to ${target}`)
+    this.seenGossipFilter.resize(target)
+    this.metrics.p2p_GossipCacheSize.value = target
+  }
+
+  isLocalPeer(peer: Peer): boolean {
+    return peer.state.identity !== null && this.localIdentities.has(peer.state.identity)
+  }
+
+  private onLocalPeerFound(address: string, identity: string, port: number): void {
+    if (!this.localIdentities.has(identity)) {
+      this.logger.info(`Found local peer ${identity} at ${address}:${port}`)
+      this.localIdentities.add(identity)
+    }
+
+    const existing = this.peerManager.getPeer(identity)
+    if (existing && existing.state.type !== 'DISCONNECTED') {
+      return
+    }
+
+    this.peerManager.connectToWebSocketAddress(`${address}:${port}`)
+  }
+
   /**
    * Send the message to all connected peers with the expectation that they
    * will forward it to their other peers. The goal is for everyone to
@@ -325,16 +488,22 @@ export class PeerNetwork {
   private gossip(message: GossipNetworkMessage): void {
     this.seenGossipFilter.add(message.nonce)
     this.peerManager.broadcast(message)
+
+    this.propagation?.forwarded(
+      message.nonce,
+      this.peerManager.getConnectedPeers().map((p) => p.getIdentityOrThrow()),
+    )
   }
 
   /**
    * Send a block to all connected peers who haven't yet received the block.
    */
-  private broadcastBlock(message: NewBlockMessage): void {
+  private broadcastBlock(message: NewBlockMessage): Block {
     this.seenGossipFilter.add(message.nonce)
 
     // TODO: This deserialization could be avoided by passing around a Block instead of a SerializedBlock
     const block = this.strategy.blockSerde.deserialize(message.block)
+    const forwarded: string[] = []
 
     for (const peer of this.peerManager.getConnectedPeers()) {
       // Don't send the block to peers who already know about it
@@ -344,8 +513,43 @@ export class PeerNetwork {
 
       if (peer.send(message)) {
         peer.knownBlockHashes.set(block.header.hash, KnownBlockHashesValue.Sent)
+        forwarded.push(peer.getIdentityOrThrow())
+      }
+    }
+
+    this.propagation?.setHash(message.nonce, block.header.hash)
+    this.propagation?.forwarded(message.nonce, forwarded)
+
+    return block
+  }
+
+  /**
+   * Remember when a block we mined was broadcast so we can measure how long
+   * it takes until a peer relays it back to us.
+   */
+  private trackMinedBlock(nonce: Buffer, block: Block): void {
+    if (this.pendingMinedBlocks.size >= MAX_PENDING_MINED_BLOCKS) {
+      const oldest = this.pendingMinedBlocks.keys().next()
+      if (!oldest.done) {
+        this.pendingMinedBlocks.delete(oldest.value)
       }
     }
+
+    this.pendingMinedBlocks.set(nonce.toString('hex'), { block, sent: BenchUtils.start() })
+  }
+
+  private checkMinedBlockAcknowledged(peer: Peer, message: NewBlockMessage): void {
+    const key = message.nonce.toString('hex')
+    const pending = this.pendingMinedBlocks.get(key)
+    if (!pending) {
+      return
+    }
+
+    this.pendingMinedBlocks.delete(key)
+
+    const elapsed = BenchUtils.end(pending.sent)
+    this.metrics.p2p_MinedBlockAcknowledgement.add(elapsed)
+    this.onMinedBlockAcknowledged.emit(pending.block, elapsed, peer.getIdentityOrThrow())
   }
 
   private broadcastTransaction(
@@ -354,6 +558,7 @@ export class PeerNetwork {
   ): void {
     const peersConnections =
       this.peerManager.identifiedPeers.get(peerIdentity)?.knownPeers || new Map<string, Peer>()
+    const forwarded: string[] = []
 
     for (const activePeer of this.peerManager.getConnectedPeers()) {
       if (activePeer.state.type !== 'CONNECTED') {
@@ -370,8 +575,12 @@ export class PeerNetwork {
         continue
       }
 
-      activePeer.send(gossipMessage)
+      if (activePeer.send(gossipMessage)) {
+        forwarded.push(activePeer.state.identity)
+      }
     }
+
+    this.propagation?.forwarded(gossipMessage.nonce, forwarded)
   }
 
   /**
@@ -518,20 +727,47 @@ export class PeerNetwork {
     peer: Peer,
     gossipMessage: GossipNetworkMessage,
   ): Promise<void> {
+    if (gossipMessage instanceof NewBlockMessage) {
+      this.checkMinedBlockAcknowledged(peer, gossipMessage)
+    }
+
+    if (this.propagation) {
+      const type = gossipMessage instanceof NewBlockMessage ? 'block' : 'transaction'
+      this.propagation.received(type, gossipMessage.nonce, peer.getIdentityOrThrow())
+    }
+
     if (!this.seenGossipFilter.added(gossipMessage.nonce)) {
+      this.metrics.p2p_GossipCacheHits.add(1)
       return
     }
 
+    this.metrics.p2p_GossipCacheMisses.add(1)
+
     const peerIdentity = peer.getIdentityOrThrow()
 
     if (gossipMessage instanceof NewBlockMessage) {
+      const received = BenchUtils.start()
       const gossip = await this.onNewBlock({ peerIdentity, message: gossipMessage })
 
-      if (gossip) {
-        this.broadcastBlock(gossipMessage)
+      if (gossip && (this.relayWhileSyncing || this.chain.synced)) {
+        const block = this.broadcastBlock(gossipMessage)
+
+        const elapsed = BenchUtils.end(received)
+        this.metrics.p2p_BlockPropagation.add(elapsed)
+        this.onBlockPropagated.emit(block, elapsed)
+      } else if (this.propagation) {
+        // Hash the header rather than trusting the hash the peer sent
+        gossipMessage.block.header.hash = undefined
+        const block = this.strategy.blockSerde.deserialize(gossipMessage.block)
+        this.propagation.setHash(gossipMessage.nonce, block.header.hash)
       }
     } else if (gossipMessage instanceof NewTransactionMessage) {
       await this.onNewTransaction({ peerIdentity, message: gossipMessage })
+
+      if (this.propagation) {
+        const transaction = new Transaction(gossipMessage.transaction)
+        this.propagation.setHash(gossipMessage.nonce, transaction.unsignedHash())
+      }
     } else {
       throw new Error(`Invalid gossip message type: '${gossipMessage.type}'`)
     }
@@ -604,6 +840,26 @@ export class PeerNetwork {
     this.logger.debug(`Received unimplemented message ${message.type}`)
   }
 
+  setPropagationTracing(enabled: boolean): void {
+    if (enabled && !this.propagation) {
+      this.propagation = new PropagationTracer()
+    } else if (!enabled) {
+      this.propagation = null
+    }
+  }
+
+  /**
+   * Change the peer limits while the node is running. Existing connections are
+   * kept, the new limits apply the next time peers connect or disconnect.
+   */
+  setPeerLimits(options: { maxPeers: number; targetPeers: number; minPeers: number }): void {
+    this.peerManager.maxPeers = options.maxPeers
+    this.peerManager.targetPeers = Math.min(options.targetPeers, options.maxPeers)
+    this.peerConnectionManager.maxPeers = options.maxPeers
+    this.minPeers = options.minPeers
+    this.updateIsReady()
+  }
+
   private updateIsReady(): void {
     const prevIsReady = this._isReady
     this._isReady = this.started && this.peerManager.getConnectedPeers().length >= this.minPeers
@@ -840,6 +1096,19 @@ export class PeerNetwork {
       return false
     }
 
+    // Growing the mem pool could fill the disk when it's nearly full
+    if (this.node.diskMonitor.critical) {
+      return false
+    }
+
+    // Drop new transactions when memory is critical and delay them when it is elevated
+    const memoryBudget = this.node.memoryBudget
+    if (memoryBudget.pressure === 'critical') {
+      return false
+    } else if (memoryBudget.pressure === 'elevated') {
+      await memoryBudget.waitForCapacity(GOSSIP_BACKPRESSURE_DELAY_MS)
+    }
+
     // Force lazy deserialization of the transaction as a first sanity check
     const transaction = this.chain.verifier.verifyNewTransaction(message.message.transaction)
 
@@ -864,16 +1133,37 @@ export class PeerNetwork {
     // the mempool won't accept it, but it is still a valid transaction
     // so we want to gossip it.
     if (this.node.memPool.exists(transaction.hash())) {
-      this.broadcastTransaction(message.message, message.peerIdentity)
+      this.relayTransaction(message.message, message.peerIdentity, transaction)
       return true
     }
 
     if (await this.node.memPool.acceptTransaction(transaction, false)) {
       this.onTransactionAccepted.emit(transaction, received)
-      this.broadcastTransaction(message.message, message.peerIdentity)
+      this.relayTransaction(message.message, message.peerIdentity, transaction)
       return true
     }
 
     return false
   }
+
+  /**
+   * Gossip a transaction from another node unless the relay policy filters
+   * it out. The node still keeps transactions it doesn't relay.
+   */
+  private relayTransaction(
+    gossipMessage: NewTransactionMessage,
+    peerIdentity: string,
+    transaction: Transaction,
+  ): void {
+    const size = gossipMessage.transaction.byteLength
+    if (this.relayMaxTransactionSize > 0 && size > this.relayMaxTransactionSize) {
+      return
+    }
+
+    if (this.relayMinFeeRate > 0 && getFeeRate(transaction) < this.relayMinFeeRate) {
+      return
+    }
+
+    this.broadcastTransaction(gossipMessage, peerIdentity)
+  }
 }
@@ -89,6 +89,21 @@ describe('AddressManager', () => {
     }
   })
 
+  it('getRandomDisconnectedPeer should prefer nearby or far peers if asked', () => {
+    const addressManager = new AddressManager(mockHostsStore())
+    addressManager.hostsStore = mockHostsStore()
+    const nearby = { address: '1.1.1.1', port: 9033, identity: 'a', name: null, latencyMs: 20 }
+    const far = { address: '2.2.2.2', port: 9033, identity: 'b', name: null, latencyMs: 300 }
+    const unknown = { address: '3.3.3.3', port: 9033, identity: 'c', name: null }
+    addressManager.hostsStore.set('priorPeers', [nearby, far, unknown])
+
+    expect(addressManager.getRandomDisconnectedPeerAddress([], true)).toEqual(nearby)
+    expect([far, unknown]).toContainEqual(
+      addressManager.getRandomDisconnectedPeerAddress([], false),
+    )
+    expect(addressManager.getRandomDisconnectedPeerAddress(['a'], true)).not.toEqual(nearby)
+  })
+
   describe('save', () => {
     it('save should persist connected peers', async () => {
       const addressManager = new AddressManager(mockHostsStore())
@@ -102,6 +117,7 @@ describe('AddressManager', () => {
         port: connectedPeer.port,
         identity: connectedPeer.state.identity,
         name: connectedPeer.name,
+        latencyMs: connectedPeer.latencyMs,
       }
 
       await addressManager.save([connectedPeer, connectingPeer, disconnectedPeer])
@@ -7,6 +7,11 @@ import { Peer } from '../peers/peer'
 import { ConnectionDirection, ConnectionType } from './connections'
 import { PeerAddress } from './peerAddress'
 
+/**
+ * Peers with a round trip time up to this many ms are considered nearby
+ */
+export const NEARBY_LATENCY_MS = 100
+
 /**
  * AddressManager stores the necessary data for connecting to new peers
  * and provides functionality for persistence of said data.
@@ -26,8 +31,14 @@ export class AddressManager {
    * Returns a peer address for a disconnected peer by using current peers to
    * filter out peer addresses. It attempts to find a previously-connected
    * peer address that is not part of an active connection.
+   *
+   * If `preferNearby` is given, addresses of peers that were nearby last time
+   * are picked if it's true, and the rest if it's false, when there are any.
    */
-  getRandomDisconnectedPeerAddress(peerIdentities: string[]): PeerAddress | null {
+  getRandomDisconnectedPeerAddress(
+    peerIdentities: string[],
+    preferNearby?: boolean,
+  ): PeerAddress | null {
     if (this.priorConnectedPeerAddresses.length === 0) {
       return null
     }
@@ -38,6 +49,16 @@ export class AddressManager {
       this.priorConnectedPeerAddresses,
       currentPeerIdentities,
     )
+    if (preferNearby !== undefined) {
+      const preferred = disconnectedPriorAddresses.filter(
+        (address) => isNearby(address.latencyMs) === preferNearby,
+      )
+
+      if (preferred.length) {
+        return ArrayUtils.sampleOrThrow(preferred)
+      }
+    }
+
     if (disconnectedPriorAddresses.length) {
       return ArrayUtils.sampleOrThrow(disconnectedPriorAddresses)
     }
@@ -85,6 +106,7 @@ export class AddressManager {
           port: peer.port,
           identity: peer.state.identity ?? null,
           name: peer.name ?? null,
+          latencyMs: peer.latencyMs,
         }
       } else {
         return []
@@ -94,3 +116,7 @@ export class AddressManager {
     await this.hostsStore.save()
   }
 }
+
+export function isNearby(latencyMs: number | null | undefined): boolean {
+  return latencyMs !== null && latencyMs !== undefined && latencyMs <= NEARBY_LATENCY_MS
+}
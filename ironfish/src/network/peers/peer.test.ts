@@ -50,6 +50,19 @@ describe('Starts in the DISCONNECTED state', () => {
   })
 })
 
+describe('recordLatency', () => {
+  it('smooths the latency samples', () => {
+    const peer = new Peer(null)
+    expect(peer.latencyMs).toBeNull()
+
+    peer.recordLatency(100)
+    expect(peer.latencyMs).toBe(100)
+
+    peer.recordLatency(600)
+    expect(peer.latencyMs).toBe(200)
+  })
+})
+
 describe('setWebSocketConnection', () => {
   it('Changes to CONNECTING when in DISCONNECTED', () => {
     const identity = mockIdentity('peer')
@@ -122,6 +122,10 @@ export class Peer {
    * The peers heaviest head sequence
    */
   sequence: number | null = null
+  /**
+   * The smoothed round trip time to the peer in ms, from latency probes
+   */
+  latencyMs: number | null = null
   /**
    * The loggable name of the peer. For a more specific value,
    * try Peer.name or Peer.state.identity.
@@ -143,6 +147,11 @@ export class Peer {
    */
   isWhitelisted = false
 
+  /**
+   * The identity the peer must have, for bootstrap nodes that are pinned to one
+   */
+  expectedIdentity: Identity | null = null
+
   /**
    * address associated with this peer
    */
@@ -205,10 +214,7 @@ export class Peer {
    * Blocks that have been sent or received from this peer. Value is set to true if the block was received
    * from the peer, and false if the block was sent to the peer.
    */
-  readonly knownBlockHashes: LRU<BlockHash, KnownBlockHashesValue> = new LRU<
-    BlockHash,
-    KnownBlockHashesValue
-  >(1024, null, BufferMap)
+  readonly knownBlockHashes: LRU<BlockHash, KnownBlockHashesValue>
 
   /**
    * Event fired for every new incoming message that needs to be processed
@@ -237,12 +243,14 @@ export class Peer {
       maxBanScore = BAN_SCORE.MAX,
       shouldLogMessages = false,
       metrics,
+      knownBlockHashesSize = 1024,
     }: {
       logger?: Logger
       maxPending?: number
       maxBanScore?: number
       shouldLogMessages?: boolean
       metrics?: MetricsMonitor
+      knownBlockHashesSize?: number
     } = {},
   ) {
     this.logger = logger.withTag('Peer')
@@ -250,6 +258,7 @@ export class Peer {
     this.maxBanScore = maxBanScore
     this.metrics = metrics
     this.shouldLogMessages = shouldLogMessages
+    this.knownBlockHashes = new LRU(knownBlockHashesSize, null, BufferMap)
     this._error = null
     this._state = {
       type: 'DISCONNECTED',
@@ -461,6 +470,14 @@ export class Peer {
     }
   }
 
+  /**
+   * Add a round trip time sample to latencyMs, smoothed so one slow
+   * response doesn't make a nearby peer look far away
+   */
+  recordLatency(ms: number): void {
+    this.latencyMs = this.latencyMs === null ? ms : Math.round(this.latencyMs * 0.8 + ms * 0.2)
+  }
+
   /**
    * Sends a message over the peer's connection if CONNECTED, else drops it.
    * @param message The message to send.
@@ -8,4 +8,6 @@ export type PeerAddress = {
   port: number | null
   identity: Identity | null
   name: string | null
+  // The last measured round trip time to the peer in ms
+  latencyMs?: number | null
 }
@@ -24,7 +24,7 @@ const CONNECT_ATTEMPTS_MAX = 5
 export class PeerConnectionManager {
   private readonly logger: Logger
   private readonly peerManager: PeerManager
-  readonly maxPeers: number
+  maxPeers: number
 
   private started = false
   private eventLoopTimer?: SetTimeoutToken
@@ -33,6 +33,7 @@ import { Assert } from '../../assert'
 import { canInitiateWebRTC, privateIdentityToIdentity } from '../identity'
 import { DisconnectingMessage, DisconnectingReason } from '../messages/disconnecting'
 import { IdentifyMessage } from '../messages/identify'
+import { IdentityChallengeMessage, IdentityProofMessage } from '../messages/identityChallenge'
 import { PeerListMessage } from '../messages/peerList'
 import { PeerListRequestMessage } from '../messages/peerListRequest'
 import { SignalMessage } from '../messages/signal'
@@ -776,6 +777,117 @@ describe('PeerManager', () => {
       expect(pm.identifiedPeers.size).toBe(0)
     })
 
+    it('Closes the connection when the identity does not match the pinned identity', () => {
+      const pinned = mockIdentity('pinned')
+      const other = mockIdentity('other')
+      const pm = new PeerManager(mockLocalPeer(), mockHostsStore())
+
+      const { peer, connection } = getWaitingForIdentityPeer(pm)
+      peer.expectedIdentity = pinned
+
+      const closeSpy = jest.spyOn(connection, 'close')
+
+      const identify = new IdentifyMessage({
+        agent: '',
+        head: Buffer.alloc(32, 0),
+        identity: other,
+        port: peer.port,
+        sequence: 1,
+        version: VERSION_PROTOCOL,
+        work: BigInt(0),
+      })
+      peer.onMessage.emit(identify, connection)
+
+      expect(closeSpy).toBeCalled()
+      expect(pm.identifiedPeers.size).toBe(0)
+      expect(peer.error).toBeInstanceOf(Error)
+    })
+
+    describe('when the peer claims the pinned identity', () => {
+      const pinned = mockIdentity('pinned')
+
+      const setup = () => {
+        const localPeer = mockLocalPeer()
+        const pm = new PeerManager(localPeer, mockHostsStore())
+
+        const boxSpy = jest
+          .spyOn(localPeer, 'boxMessage')
+          .mockResolvedValue({ nonce: 'nonce', boxedMessage: 'boxed' })
+
+        const { peer, connection } = getWaitingForIdentityPeer(pm)
+        peer.expectedIdentity = pinned
+
+        const sendSpy = jest.spyOn(connection, 'send')
+        const closeSpy = jest.spyOn(connection, 'close')
+
+        const identify = new IdentifyMessage({
+          agent: '',
+          head: Buffer.alloc(32, 0),
+          identity: pinned,
+          port: peer.port,
+          sequence: 1,
+          version: VERSION_PROTOCOL,
+          work: BigInt(0),
+        })
+        peer.onMessage.emit(identify, connection)
+
+        return { pm, peer, connection, boxSpy, sendSpy, closeSpy }
+      }
+
+      it('Challenges the peer and connects when it returns the challenge', async () => {
+        const { pm, peer, connection, boxSpy, sendSpy, closeSpy } = setup()
+
+        expect(connection.state.type).toBe('WAITING_FOR_IDENTITY')
+        expect(pm.identifiedPeers.size).toBe(0)
+        expect(boxSpy).toHaveBeenCalledWith(expect.any(String), pinned)
+
+        await boxSpy.mock.results[0].value
+        expect(sendSpy).toHaveBeenCalledWith(
+          new IdentityChallengeMessage({ nonce: 'nonce', challenge: 'boxed' }),
+        )
+
+        const challenge = boxSpy.mock.calls[0][0]
+        peer.onMessage.emit(new IdentityProofMessage({ challenge }), connection)
+
+        expect(closeSpy).not.toHaveBeenCalled()
+        expect(connection.state).toEqual({ type: 'CONNECTED', identity: pinned })
+        expect(pm.identifiedPeers.size).toBe(1)
+      })
+
+      it('Closes the connection when the peer cannot open the challenge', async () => {
+        const { pm, peer, connection, boxSpy, closeSpy } = setup()
+
+        await boxSpy.mock.results[0].value
+        peer.onMessage.emit(new IdentityProofMessage({ challenge: 'wrong' }), connection)
+
+        expect(closeSpy).toHaveBeenCalled()
+        expect(pm.identifiedPeers.size).toBe(0)
+        expect(peer.error).toBeInstanceOf(Error)
+      })
+    })
+
+    it('Answers identity challenges by returning the opened challenge', async () => {
+      const localPeer = mockLocalPeer()
+      const pm = new PeerManager(localPeer, mockHostsStore())
+
+      const identity = mockIdentity('peer')
+      const { peer, connection } = getConnectedPeer(pm, identity)
+      const sendSpy = jest.spyOn(connection, 'send')
+
+      const unboxSpy = jest
+        .spyOn(localPeer, 'unboxMessage')
+        .mockResolvedValue({ message: 'challenge' })
+
+      peer.onMessage.emit(
+        new IdentityChallengeMessage({ nonce: 'nonce', challenge: 'boxed' }),
+        connection,
+      )
+
+      expect(unboxSpy).toHaveBeenCalledWith('boxed', 'nonce', identity)
+      await unboxSpy.mock.results[0].value
+      expect(sendSpy).toHaveBeenCalledWith(new IdentityProofMessage({ challenge: 'challenge' }))
+    })
+
     it('Closes the connection when an identity message with an invalid public key is sent', () => {
       const pm = new PeerManager(mockLocalPeer(), mockHostsStore())
 
@@ -3,6 +3,7 @@
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 
 import type { SignalData } from './connections/webRtcConnection'
+import tweetnacl from 'tweetnacl'
 import WSWebSocket from 'ws'
 import { Event } from '../../event'
 import { HostsStore } from '../../fileStores/hosts'
@@ -17,6 +18,7 @@ import {
 } from '../identity'
 import { DisconnectingMessage, DisconnectingReason } from '../messages/disconnecting'
 import { IdentifyMessage } from '../messages/identify'
+import { IdentityChallengeMessage, IdentityProofMessage } from '../messages/identityChallenge'
 import {
   displayNetworkMessageType,
   IncomingPeerMessage,
@@ -28,7 +30,7 @@ import { SignalMessage } from '../messages/signal'
 import { SignalRequestMessage } from '../messages/signalRequest'
 import { parseUrl } from '../utils'
 import { VERSION_PROTOCOL_MIN } from '../version'
-import { AddressManager } from './addressManager'
+import { AddressManager, isNearby } from './addressManager'
 import {
   Connection,
   ConnectionDirection,
@@ -46,6 +48,11 @@ import { Peer } from './peer'
  */
 const MAX_WEBRTC_BROKERING_ATTEMPTS = 5
 
+/**
+ * The share of connected peers the node tries to keep nearby, see NEARBY_LATENCY_MS
+ */
+const NEARBY_PEERS_RATIO = 0.5
+
 /**
  * PeerManager keeps the state of Peers and their underlying connections up to date,
  * determines how to establish a connection to a given Peer, and provides an event
@@ -122,18 +129,33 @@ export class PeerManager {
   /**
    * The maximum number of peers allowed to be in the CONNECTED or CONNECTING state.
    */
-  readonly maxPeers: number
+  maxPeers: number
 
   /**
    * Stops establishing connections to DISCONNECTED peers when at or above this number.
    */
-  readonly targetPeers: number
+  targetPeers: number
 
   /**
    * If true, track all sent and received network messages per-peer.
    */
   readonly logPeerMessages: boolean
 
+  /**
+   * How many block hashes each peer remembers sending or receiving
+   */
+  readonly knownBlocksCacheSize: number | undefined
+
+  /**
+   * Identify messages from peers with a pinned identity, held with the
+   * messages sent after them until the peer opens the challenge we boxed to
+   * the pinned identity
+   */
+  private readonly identityChallenges = new WeakMap<
+    Connection,
+    { identify: IdentifyMessage; challenge: string; messages: NetworkMessage[] }
+  >()
+
   constructor(
     localPeer: LocalPeer,
     hostsStore: HostsStore,
@@ -142,6 +164,7 @@ export class PeerManager {
     maxPeers = 10000,
     targetPeers = 50,
     logPeerMessages = false,
+    knownBlocksCacheSize?: number,
   ) {
     this.logger = logger.withTag('peermanager')
     this.metrics = metrics || new MetricsMonitor({ logger: this.logger })
@@ -149,14 +172,20 @@ export class PeerManager {
     this.maxPeers = maxPeers
     this.targetPeers = Math.min(targetPeers, maxPeers)
     this.logPeerMessages = logPeerMessages
+    this.knownBlocksCacheSize = knownBlocksCacheSize
     this.addressManager = new AddressManager(hostsStore)
   }
 
   /**
    * Connect to a websocket by its uri. Establish a connection and solicit
-   * the server's Identity.
+   * the server's Identity. If `expectedIdentity` is set, the connection is
+   * closed when the server identifies as anyone else.
    */
-  connectToWebSocketAddress(uri: string, isWhitelisted = false): Peer {
+  connectToWebSocketAddress(
+    uri: string,
+    isWhitelisted = false,
+    expectedIdentity: Identity | null = null,
+  ): Peer {
     const url = parseUrl(uri)
 
     if (!url.hostname) {
@@ -166,6 +195,7 @@ export class PeerManager {
     const peer = this.getOrCreatePeer(null)
     peer.setWebSocketAddress(url.hostname, url.port)
     peer.isWhitelisted = isWhitelisted
+    peer.expectedIdentity = expectedIdentity
     this.connectToWebSocket(peer)
     return peer
   }
@@ -680,6 +710,7 @@ export class PeerManager {
       logger: this.logger,
       shouldLogMessages: this.logPeerMessages,
       metrics: this.metrics,
+      knownBlockHashesSize: this.knownBlocksCacheSize,
     })
 
     // Add the peer to peers. It's new, so it shouldn't exist there already
@@ -783,7 +814,10 @@ export class PeerManager {
 
   /**
    * Gets a random disconnected peer address and returns a peer created from
-   * said address
+   * said address. Nearby peers are preferred until they make up half of the
+   * connected peers, and far or unmeasured peers after that, so the node
+   * relays quickly to its region while staying connected to the rest of the
+   * network.
    */
   createRandomDisconnectedPeer(): Peer | null {
     const connectedPeers = Array.from(this.identifiedPeers.values()).flatMap((peer) => {
@@ -794,7 +828,14 @@ export class PeerManager {
       }
     })
 
-    const peerAddress = this.addressManager.getRandomDisconnectedPeerAddress(connectedPeers)
+    const peers = this.getConnectedPeers()
+    const nearby = peers.filter((peer) => isNearby(peer.latencyMs)).length
+    const preferNearby = nearby < peers.length * NEARBY_PEERS_RATIO
+
+    const peerAddress = this.addressManager.getRandomDisconnectedPeerAddress(
+      connectedPeers,
+      preferNearby,
+    )
     if (!peerAddress) {
       return null
     }
@@ -855,7 +896,9 @@ export class PeerManager {
    * peer that sent it to us, not the original source.
    */
   private async handleMessage(peer: Peer, connection: Connection, message: NetworkMessage) {
-    if (connection.state.type === 'WAITING_FOR_IDENTITY') {
+    if (message instanceof IdentityChallengeMessage) {
+      await this.handleIdentityChallengeMessage(peer, connection, message)
+    } else if (connection.state.type === 'WAITING_FOR_IDENTITY') {
       this.handleMessageInWaitingForIdentityState(peer, connection, message)
     } else if (message instanceof IdentifyMessage) {
       this.handleIdentifyMessage(peer, connection, message)
@@ -976,6 +1019,18 @@ export class PeerManager {
     connection: Connection,
     message: NetworkMessage,
   ): void {
+    const pending = this.identityChallenges.get(connection)
+    if (pending) {
+      if (message instanceof IdentityProofMessage) {
+        this.handleIdentityProofMessage(peer, connection, message)
+      } else {
+        // The peer doesn't know we're waiting for the proof, so it can send
+        // other messages first
+        pending.messages.push(message)
+      }
+      return
+    }
+
     // If we receive any message other than an Identity message, close the connection
     if (!(message instanceof IdentifyMessage)) {
       this.logger.debug(
@@ -991,8 +1046,6 @@ export class PeerManager {
 
     const identity = message.identity
     const version = message.version
-    const agent = message.agent
-    const port = message.port
     const name = message.name
 
     if (!isIdentity(identity)) {
@@ -1006,6 +1059,17 @@ export class PeerManager {
       return
     }
 
+    if (peer.expectedIdentity !== null && identity !== peer.expectedIdentity) {
+      const error = `Identity ${identity} does not match the pinned identity ${peer.expectedIdentity}`
+      this.logger.warn(`Disconnecting from ${peer.displayName} - ${error}`)
+
+      peer
+        .getConnectionRetry(connection.type, connection.direction)
+        ?.failedConnection(peer.isWhitelisted)
+      peer.close(new Error(error))
+      return
+    }
+
     if (version < VERSION_PROTOCOL_MIN) {
       const error = `Peer version ${version} is not compatible with our minimum: ${VERSION_PROTOCOL_MIN}`
       this.logger.debug(`Disconnecting from ${identity} - ${error}`)
@@ -1054,6 +1118,25 @@ export class PeerManager {
       return
     }
 
+    // Anyone can claim the pinned identity, so check the peer has its private
+    // key before accepting it
+    if (peer.expectedIdentity !== null) {
+      const challenge = Buffer.from(tweetnacl.randomBytes(32)).toString('base64')
+      this.identityChallenges.set(connection, { identify: message, challenge, messages: [] })
+      void this.sendIdentityChallenge(connection, identity, challenge)
+      return
+    }
+
+    this.acceptIdentify(peer, connection, message)
+  }
+
+  /**
+   * Move a connection to CONNECTED once the peer's identify message passed
+   * the checks in handleMessageInWaitingForIdentityState
+   */
+  private acceptIdentify(peer: Peer, connection: Connection, message: IdentifyMessage): void {
+    const { identity, version, agent, port, name } = message
+
     // If we already know the peer's identity and the new identity doesn't match, move the connection
     // to a Peer with the new identity.
     if (peer.state.identity !== null && peer.state.identity !== identity) {
@@ -1173,6 +1256,84 @@ export class PeerManager {
     connection.setState({ type: 'CONNECTED', identity: identity })
   }
 
+  private async sendIdentityChallenge(
+    connection: Connection,
+    identity: Identity,
+    challenge: string,
+  ): Promise<void> {
+    const { nonce, boxedMessage } = await this.localPeer.boxMessage(challenge, identity)
+    connection.send(new IdentityChallengeMessage({ nonce, challenge: boxedMessage }))
+  }
+
+  /**
+   * Open a challenge from a peer that pinned our identity and send it back,
+   * proving we have our identity's private key
+   */
+  private async handleIdentityChallengeMessage(
+    peer: Peer,
+    connection: Connection,
+    message: IdentityChallengeMessage,
+  ): Promise<void> {
+    // The peer can challenge us while we're challenging it too
+    const sender =
+      connection.state.type === 'CONNECTED'
+        ? connection.state.identity
+        : this.identityChallenges.get(connection)?.identify.identity
+
+    if (!sender) {
+      this.logger.debug(`Ignoring identity challenge from unidentified ${peer.displayName}`)
+      return
+    }
+
+    const { message: challenge } = await this.localPeer.unboxMessage(
+      message.challenge,
+      message.nonce,
+      sender,
+    )
+
+    if (challenge === null) {
+      this.logger.debug(`Could not open the identity challenge from ${peer.displayName}`)
+      return
+    }
+
+    connection.send(new IdentityProofMessage({ challenge }))
+  }
+
+  private handleIdentityProofMessage(
+    peer: Peer,
+    connection: Connection,
+    message: IdentityProofMessage,
+  ): void {
+    const pending = this.identityChallenges.get(connection)
+    if (!pending) {
+      return
+    }
+
+    this.identityChallenges.delete(connection)
+
+    if (message.challenge !== pending.challenge) {
+      const identity = pending.identify.identity
+      const error = `Peer could not prove it has the pinned identity ${identity}`
+      this.logger.warn(`Disconnecting from ${peer.displayName} - ${error}`)
+
+      peer
+        .getConnectionRetry(connection.type, connection.direction)
+        ?.failedConnection(peer.isWhitelisted)
+      peer.close(new Error(error))
+      return
+    }
+
+    this.acceptIdentify(peer, connection, pending.identify)
+
+    // The identified peer can be another one if the connection was moved to it
+    const identified = this.getPeer(pending.identify.identity)
+    if (identified && connection.state.type === 'CONNECTED') {
+      for (const buffered of pending.messages) {
+        void this.handleMessage(identified, connection, buffered)
+      }
+    }
+  }
+
   /**
    * Handle a signal request message relayed by another peer.
    * @param message An incoming SignalRequest message from a peer.
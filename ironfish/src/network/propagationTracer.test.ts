@@ -0,0 +1,52 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { PropagationTracer } from './propagationTracer'
+
+describe('PropagationTracer', () => {
+  const nonce = Buffer.alloc(16, 1)
+  const hash = Buffer.alloc(32, 2)
+
+  it('should trace a received message by its hash', () => {
+    const tracer = new PropagationTracer()
+
+    tracer.received('block', nonce, 'a')
+    tracer.received('block', nonce, 'b')
+
+    // The hash is not known until the block is deserialized
+    expect(tracer.get(hash.toString('hex'))).toBeNull()
+
+    tracer.setHash(nonce, hash)
+    tracer.forwarded(nonce, ['c', 'd'])
+
+    const trace = tracer.get(hash.toString('hex'))
+    expect(trace).toMatchObject({
+      type: 'block',
+      hash: hash.toString('hex'),
+      firstSeenFrom: 'a',
+    })
+    expect(trace?.received.map((e) => e.identity)).toEqual(['a', 'b'])
+    expect(trace?.forwarded.map((e) => e.identity)).toEqual(['c', 'd'])
+  })
+
+  it('should trace messages the node created', () => {
+    const tracer = new PropagationTracer()
+
+    tracer.created('transaction', nonce, hash)
+    tracer.forwarded(nonce, ['a'])
+
+    const trace = tracer.get(hash.toString('hex').toUpperCase())
+    expect(trace).toMatchObject({ type: 'transaction', firstSeenFrom: null, received: [] })
+    expect(trace?.forwarded).toHaveLength(1)
+  })
+
+  it('should only keep the latest traces', () => {
+    const tracer = new PropagationTracer(1)
+
+    tracer.created('block', Buffer.alloc(16, 1), Buffer.alloc(32, 1))
+    tracer.created('block', Buffer.alloc(16, 2), Buffer.alloc(32, 2))
+
+    expect(tracer.get(Buffer.alloc(32, 1).toString('hex'))).toBeNull()
+    expect(tracer.get(Buffer.alloc(32, 2).toString('hex'))).not.toBeNull()
+  })
+})
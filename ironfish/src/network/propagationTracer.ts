@@ -0,0 +1,105 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import LRU from 'blru'
+import { Identity } from './identity'
+
+const DEFAULT_TRACES = 1000
+
+export type PropagationPeerEvent = {
+  identity: Identity
+  // Milliseconds since the node first saw the message
+  elapsedMs: number
+}
+
+export type PropagationTrace = {
+  type: 'block' | 'transaction'
+  // Null until the message was deserialized
+  hash: string | null
+  firstSeenAt: number
+  // The peer we first heard it from, null if this node created it
+  firstSeenFrom: Identity | null
+  received: PropagationPeerEvent[]
+  forwarded: PropagationPeerEvent[]
+}
+
+/**
+ * Records where the gossiped blocks and transactions came from and which
+ * peers they were forwarded to, for debugging how they spread. Only the most
+ * recent traces are kept.
+ */
+export class PropagationTracer {
+  // Traces by the nonce of the gossip message, since duplicates of a message
+  // carry the same nonce before we know its hash
+  private readonly traces: LRU<string, PropagationTrace>
+  private readonly nonces: LRU<string, string>
+
+  constructor(size = DEFAULT_TRACES) {
+    this.traces = new LRU(size)
+    this.nonces = new LRU(size)
+  }
+
+  /**
+   * Record a message this node created, like a mined block or a transaction
+   * sent from the wallet
+   */
+  created(type: PropagationTrace['type'], nonce: Buffer, hash: Buffer): void {
+    const key = nonce.toString('hex')
+    this.traces.set(key, {
+      type,
+      hash: hash.toString('hex'),
+      firstSeenAt: Date.now(),
+      firstSeenFrom: null,
+      received: [],
+      forwarded: [],
+    })
+    this.nonces.set(hash.toString('hex'), key)
+  }
+
+  received(type: PropagationTrace['type'], nonce: Buffer, identity: Identity): void {
+    const key = nonce.toString('hex')
+    const trace = this.traces.get(key)
+
+    if (!trace) {
+      this.traces.set(key, {
+        type,
+        hash: null,
+        firstSeenAt: Date.now(),
+        firstSeenFrom: identity,
+        received: [{ identity, elapsedMs: 0 }],
+        forwarded: [],
+      })
+      return
+    }
+
+    trace.received.push({ identity, elapsedMs: Date.now() - trace.firstSeenAt })
+  }
+
+  forwarded(nonce: Buffer, identities: Identity[]): void {
+    const trace = this.traces.get(nonce.toString('hex'))
+    if (!trace) {
+      return
+    }
+
+    const elapsedMs = Date.now() - trace.firstSeenAt
+    for (const identity of identities) {
+      trace.forwarded.push({ identity, elapsedMs })
+    }
+  }
+
+  setHash(nonce: Buffer, hash: Buffer): void {
+    const key = nonce.toString('hex')
+    const trace = this.traces.get(key)
+    if (!trace || trace.hash !== null) {
+      return
+    }
+
+    trace.hash = hash.toString('hex')
+    this.nonces.set(trace.hash, key)
+  }
+
+  get(hash: string): PropagationTrace | null {
+    const key = this.nonces.get(hash.toLowerCase())
+    return (key && this.traces.get(key)) || null
+  }
+}
@@ -26,6 +26,8 @@ export enum NetworkMessageType {
   NewBlockV2 = 18,
   GetBlockTransactionsRequest = 19,
   GetBlockTransactionsResponse = 20,
+  IdentityChallenge = 21,
+  IdentityProof = 22,
 }
 
 export type IsomorphicWebSocketConstructor = typeof WebSocket | typeof WSWebSocket
@@ -116,3 +116,14 @@ export function getBlockSize(block: SerializedBlock): number {
 
   return size
 }
+
+/**
+ * Serialize a whole block to a buffer in the same format blocks are gossiped in
+ */
+export function writeBlockToBuffer(block: SerializedBlock): Buffer {
+  return writeBlock(bufio.write(getBlockSize(block)), block).render()
+}
+
+export function readBlockFromBuffer(buffer: Buffer): SerializedBlock {
+  return readBlock(bufio.read(buffer, true))
+}
@@ -2,7 +2,7 @@
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 
-import { parseUrl } from './parseUrl'
+import { parseBootstrapNode, parseUrl } from './parseUrl'
 
 describe('parseUrl', () => {
   it('should parse urls', () => {
@@ -57,3 +57,17 @@ describe('parseUrl', () => {
     })
   })
 })
+
+describe('parseBootstrapNode', () => {
+  it('should parse pinned and unpinned nodes', () => {
+    expect(parseBootstrapNode('foo.bar:9033')).toEqual({
+      address: 'foo.bar:9033',
+      identity: null,
+    })
+
+    expect(parseBootstrapNode(' abc+/= @ foo.bar:9033')).toEqual({
+      address: 'foo.bar:9033',
+      identity: 'abc+/=',
+    })
+  })
+})
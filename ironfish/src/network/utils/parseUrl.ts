@@ -4,6 +4,7 @@
 
 const PROTOCOL_SEPARATOR = '://'
 const PORT_SEPARATOR = ':'
+const IDENTITY_SEPARATOR = '@'
 
 /**
  * Liberally parses a URL into its components and returns
@@ -41,3 +42,21 @@ export function parseUrl(url: string): {
 
   return { protocol, hostname, port }
 }
+
+/**
+ * Split a bootstrap node written as `identity@host:port` into the identity it
+ * is pinned to and its address. The identity is null if it isn't pinned.
+ */
+export function parseBootstrapNode(node: string): { address: string; identity: string | null } {
+  node = node.trim()
+
+  const identitySepIndex = node.indexOf(IDENTITY_SEPARATOR)
+  if (identitySepIndex === -1) {
+    return { address: node, identity: null }
+  }
+
+  return {
+    address: node.slice(identitySepIndex + IDENTITY_SEPARATOR.length).trim(),
+    identity: node.slice(0, identitySepIndex).trim(),
+  }
+}
@@ -0,0 +1,60 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { DEFAULT_CONSENSUS_PARAMETERS } from '../consensus'
+import { NodeFileProvider } from '../fileSystems'
+import { IJSON } from '../serde'
+import {
+  BUILTIN_NETWORKS,
+  loadNetworkDefinition,
+  parseNetworkDefinition,
+} from './networkDefinition'
+
+describe('NetworkDefinition', () => {
+  const files = new NodeFileProvider()
+
+  beforeAll(async () => {
+    await files.init()
+  })
+
+  it('loads built in networks by name', async () => {
+    const testnet = await loadNetworkDefinition(files, 'testnet')
+    expect(testnet.id).toEqual('testnet')
+    expect(testnet.bootstrapNodes.length).toBeGreaterThan(0)
+
+    const devnet = await loadNetworkDefinition(files, 'devnet')
+    expect(devnet.id).toEqual('devnet')
+    expect(devnet.bootstrapNodes).toEqual([])
+  })
+
+  it('throws for unknown networks', async () => {
+    await expect(loadNetworkDefinition(files, 'mainnet')).rejects.toThrow('not launched')
+    await expect(loadNetworkDefinition(files, '/does/not/exist.json')).rejects.toThrow(
+      'Unknown network',
+    )
+  })
+
+  it('fills in missing consensus parameters with the defaults', async () => {
+    const genesis = BUILTIN_NETWORKS.devnet().genesis
+
+    const definition = await parseNetworkDefinition(
+      IJSON.stringify({
+        id: 'private',
+        genesis,
+        consensus: { targetBlockTimeInSeconds: 5 },
+      }),
+    )
+
+    expect(definition.bootstrapNodes).toEqual([])
+    expect(definition.consensus).toEqual({
+      ...DEFAULT_CONSENSUS_PARAMETERS,
+      targetBlockTimeInSeconds: 5,
+    })
+  })
+
+  it('rejects invalid definitions', async () => {
+    await expect(parseNetworkDefinition(JSON.stringify({ id: 'private' }))).rejects.toThrow(
+      'Invalid network definition',
+    )
+  })
+})
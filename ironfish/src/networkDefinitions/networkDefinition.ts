@@ -0,0 +1,111 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { ConsensusParameters, DEFAULT_CONSENSUS_PARAMETERS } from '../consensus'
+import { DEFAULT_BOOTSTRAP_NODE } from '../fileStores/config'
+import { FileSystem } from '../fileSystems'
+import { genesisBlockData } from '../genesis/genesisBlock'
+import { genesisBlockData as devGenesisBlockData } from '../genesis/genesisBlockDev'
+import { SerializedBlock } from '../primitives/block'
+import { IJSON } from '../serde'
+import { YupUtils } from '../utils'
+
+export const DEFAULT_NETWORK = 'testnet'
+
+/**
+ * Everything a node needs to join a network: the genesis block every chain on
+ * the network starts from, the consensus parameters, and the nodes to connect
+ * to first
+ */
+export type NetworkDefinition = {
+  id: string
+  bootstrapNodes: string[]
+  genesis: SerializedBlock
+  consensus: ConsensusParameters
+}
+
+/**
+ * The format of a network definition file. Consensus parameters that are left
+ * out use the default values.
+ */
+export type NetworkDefinitionFile = {
+  id: string
+  bootstrapNodes?: string[]
+  genesis: SerializedBlock
+  consensus?: Partial<ConsensusParameters>
+}
+
+export const NetworkDefinitionFileSchema: yup.ObjectSchema<NetworkDefinitionFile> = yup
+  .object({
+    id: yup.string().defined(),
+    bootstrapNodes: yup.array(yup.string().defined()).optional(),
+    genesis: yup.mixed<SerializedBlock>().defined(),
+    consensus: yup
+      .object({
+        allowedBlockFutureSeconds: yup.number().integer().min(0).optional(),
+        targetBlockTimeInSeconds: yup.number().integer().min(1).optional(),
+        targetBucketTimeInSeconds: yup.number().integer().min(1).optional(),
+      })
+      .optional(),
+  })
+  .defined()
+
+export const BUILTIN_NETWORKS: Record<string, () => NetworkDefinition> = {
+  testnet: () => ({
+    id: 'testnet',
+    bootstrapNodes: [DEFAULT_BOOTSTRAP_NODE],
+    genesis: IJSON.parse(genesisBlockData) as SerializedBlock,
+    consensus: DEFAULT_CONSENSUS_PARAMETERS,
+  }),
+  devnet: () => ({
+    id: 'devnet',
+    bootstrapNodes: [],
+    genesis: IJSON.parse(devGenesisBlockData) as SerializedBlock,
+    consensus: DEFAULT_CONSENSUS_PARAMETERS,
+  }),
+}
+
+/**
+ * Load a network by the name of a built in network, or from the path to a
+ * network definition file
+ */
+export async function loadNetworkDefinition(
+  files: FileSystem,
+  network: string,
+): Promise<NetworkDefinition> {
+  const builtin = BUILTIN_NETWORKS[network]
+  if (builtin) {
+    return builtin()
+  }
+
+  if (network === 'mainnet') {
+    throw new Error('mainnet has not launched yet, use testnet or a network definition file')
+  }
+
+  const path = files.resolve(network)
+  if (!(await files.exists(path))) {
+    const names = Object.keys(BUILTIN_NETWORKS).join(', ')
+    throw new Error(`Unknown network ${network}, expected ${names} or a path to a file`)
+  }
+
+  return parseNetworkDefinition(await files.readFile(path))
+}
+
+export async function parseNetworkDefinition(data: string): Promise<NetworkDefinition> {
+  const { result, error } = await YupUtils.tryValidate(
+    NetworkDefinitionFileSchema,
+    IJSON.parse(data),
+  )
+
+  if (error) {
+    throw new Error(`Invalid network definition: ${error.message}`)
+  }
+
+  return {
+    id: result.id,
+    bootstrapNodes: result.bootstrapNodes ?? [],
+    genesis: result.genesis,
+    consensus: { ...DEFAULT_CONSENSUS_PARAMETERS, ...result.consensus },
+  }
+}
@@ -3,31 +3,85 @@
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 import os from 'os'
 import { v4 as uuid } from 'uuid'
-import { Accounts, AccountsDB } from './account'
-import { Blockchain } from './blockchain'
+import { Accounts, AccountsDB, WalletBackups } from './account'
+import { Blockchain, checkChainIntegrity } from './blockchain'
+import { ClockMonitor } from './clockMonitor'
+import { DiskMonitor } from './diskMonitor'
+import { Event } from './event'
 import {
   Config,
   ConfigOptions,
+  ConfigWatcher,
   DEFAULT_DATA_DIR,
+  formatConfigIssue,
   HostsStore,
   InternalStore,
+  MemPoolStore,
+  MetricsHistoryStore,
 } from './fileStores'
 import { FileSystem } from './fileSystems'
+import { ColdStorageMonitor } from './indexers/coldStorageMonitor'
 import { MinedBlocksIndexer } from './indexers/minedBlocksIndexer'
-import { createRootLogger, Logger } from './logger'
-import { MemPool } from './memPool'
-import { MetricsMonitor } from './metrics'
-import { MiningManager } from './mining'
+import { ViewKeyScanner } from './indexers/viewKeyScanner'
+import {
+  ConsoleReporterInstance,
+  createRootLogger,
+  Logger,
+  setLogLevelFromConfig,
+} from './logger'
+import { MemoryBudget } from './memoryBudget'
+import { FeeEstimator, MemPool } from './memPool'
+import { MetricsHistory, MetricsMonitor, Tracer } from './metrics'
+import { DevBlockProducer, MiningManager } from './mining'
 import { PeerNetwork, PrivateIdentity } from './network'
 import { IsomorphicWebSocketConstructor } from './network/types'
+import { parseUrl } from './network/utils/parseUrl'
+import { loadNetworkDefinition } from './networkDefinitions'
 import { Package } from './package'
 import { Platform } from './platform'
+import { Transaction } from './primitives'
+import { RemoteSyncer } from './remoteSyncer'
+import { RosettaServer } from './rosetta'
+import { RpcTcpClient } from './rpc/clients/tcpClient'
+import { RpcTlsClient } from './rpc/clients/tlsClient'
 import { RpcServer } from './rpc/server'
 import { Strategy } from './strategy'
 import { Syncer } from './syncer'
 import { Telemetry } from './telemetry/telemetry'
+import { ErrorUtils } from './utils'
 import { WorkerPool } from './workerPool'
 
+// The fraction of the memory budget the chain caches use when chainCacheMb is 0
+const CHAIN_CACHE_BUDGET_RATIO = 0.1
+
+/**
+ * Options the node applies as soon as they change, either because they're read
+ * whenever they are used or because onConfigChange handles them. Every other
+ * option only takes effect after a restart.
+ */
+const RELOADABLE_CONFIG_OPTIONS: ReadonlySet<keyof ConfigOptions> = new Set([
+  'blockGraffiti',
+  'defaultTransactionExpirationSequenceDelta',
+  'enableMetrics',
+  'enableMetricsHistory',
+  'enablePropagationTracing',
+  'enableRpc',
+  'enableTelemetry',
+  'getFundsApi',
+  'logLevel',
+  'maxPeers',
+  'maxReorgDepth',
+  'minimumBlockConfirmations',
+  'minPeers',
+  'miningForce',
+  'preferredSyncPeers',
+  'slowOperationThresholdMs',
+  'syncStallTimeout',
+  'targetPeers',
+  'telemetryCategories',
+  'telemetryExportPath',
+])
+
 export class IronfishNode {
   chain: Blockchain
   strategy: Strategy
@@ -37,7 +91,10 @@ export class IronfishNode {
   logger: Logger
   miningManager: MiningManager
   metrics: MetricsMonitor
+  metricsHistory: MetricsHistory
+  tracer: Tracer
   memPool: MemPool
+  feeEstimator: FeeEstimator
   workerPool: WorkerPool
   files: FileSystem
   rpc: RpcServer
@@ -46,6 +103,23 @@ export class IronfishNode {
   pkg: Package
   telemetry: Telemetry
   minedBlocksIndexer: MinedBlocksIndexer
+  viewKeyScanner: ViewKeyScanner
+  coldStorageMonitor: ColdStorageMonitor
+  configWatcher: ConfigWatcher
+  memPoolStore: MemPoolStore
+  memoryBudget: MemoryBudget
+  diskMonitor: DiskMonitor
+  clockMonitor: ClockMonitor
+  walletBackups: WalletBackups
+  rosetta: RosettaServer
+  devBlockProducer: DevBlockProducer | null = null
+  // Set when the chain is synced from the chainNode instead of from peers
+  remoteSyncer: RemoteSyncer | null = null
+
+  /**
+   * Emitted before each step of shutting down, with a description of the step
+   */
+  readonly onShutdownStep = new Event<[step: string]>()
 
   started = false
   shutdownPromise: Promise<void> | null = null
@@ -60,13 +134,17 @@ export class IronfishNode {
     accounts,
     strategy,
     metrics,
+    tracer,
     memPool,
     workerPool,
     logger,
     webSocket,
     privateIdentity,
     hostsStore,
+    metricsHistoryStore,
+    memPoolStore,
     minedBlocksIndexer,
+    viewKeyScanner,
   }: {
     pkg: Package
     files: FileSystem
@@ -76,13 +154,17 @@ export class IronfishNode {
     chain: Blockchain
     strategy: Strategy
     metrics: MetricsMonitor
+    tracer: Tracer
     memPool: MemPool
     workerPool: WorkerPool
     logger: Logger
     webSocket: IsomorphicWebSocketConstructor
     privateIdentity?: PrivateIdentity
     hostsStore: HostsStore
+    metricsHistoryStore: MetricsHistoryStore
+    memPoolStore: MemPoolStore
     minedBlocksIndexer: MinedBlocksIndexer
+    viewKeyScanner: ViewKeyScanner
   }) {
     this.files = files
     this.config = config
@@ -91,13 +173,55 @@ export class IronfishNode {
     this.chain = chain
     this.strategy = strategy
     this.metrics = metrics
+    this.tracer = tracer
     this.miningManager = new MiningManager({ chain, memPool, node: this })
     this.memPool = memPool
+    this.feeEstimator = new FeeEstimator({ chain, memPool })
     this.workerPool = workerPool
     this.rpc = new RpcServer(this)
     this.logger = logger
     this.pkg = pkg
     this.minedBlocksIndexer = minedBlocksIndexer
+    this.viewKeyScanner = viewKeyScanner
+    this.memPoolStore = memPoolStore
+    this.memoryBudget = new MemoryBudget({
+      budget: config.get('memoryBudgetMb') * 1024 * 1024,
+      logger,
+    })
+    this.diskMonitor = new DiskMonitor({
+      dataDir: config.dataDir,
+      lowBytes: config.get('diskLowMb') * 1024 * 1024,
+      criticalBytes: config.get('diskCriticalMb') * 1024 * 1024,
+      logger,
+    })
+    this.clockMonitor = new ClockMonitor({
+      ntpServer: config.get('ntpServer'),
+      toleranceMs: config.get('clockSkewToleranceMs'),
+      maxCompensationMs: chain.consensus.allowedBlockFutureSeconds * 1000,
+      logger,
+    })
+    this.walletBackups = new WalletBackups({
+      accounts,
+      location: config.get('walletBackupPath'),
+      passphraseFile: config.get('walletBackupPassphraseFile'),
+      intervalHours: config.get('walletBackupIntervalHours'),
+      retention: config.get('walletBackupRetention'),
+      reminder: config.get('walletBackupReminder'),
+      logger,
+    })
+    this.rosetta = new RosettaServer({
+      node: this,
+      host: config.get('rosettaHost'),
+      port: config.get('rosettaPort'),
+      logger,
+    })
+    this.metricsHistory = new MetricsHistory({ store: metricsHistoryStore, node: this, logger })
+    this.coldStorageMonitor = new ColdStorageMonitor({
+      scanner: viewKeyScanner,
+      viewKeyIds: config.get('coldStorageViewKeys'),
+      webhook: config.get('coldStorageWebhook'),
+      logger,
+    })
 
     this.peerNetwork = new PeerNetwork({
       identity: privateIdentity,
@@ -108,10 +232,20 @@ export class IronfishNode {
       minPeers: config.get('minPeers'),
       listen: config.get('enableListenP2P'),
       enableSyncing: config.get('enableSyncing'),
+      relayMinFeeRate: config.get('relayMinFeeRate'),
+      relayMaxTransactionSize: config.get('relayMaxTransactionSize'),
+      relayWhileSyncing: config.get('relayWhileSyncing'),
+      gossipCacheSize: config.get('gossipCacheSize'),
+      gossipCacheSizePerPeer: config.get('gossipCacheSizePerPeer'),
+      knownBlocksCacheSize: config.get('knownBlocksCacheSize'),
+      enableLocalDiscovery: config.get('enableLocalDiscovery'),
+      enablePropagationTracing: config.get('enablePropagationTracing'),
       targetPeers: config.get('targetPeers'),
       logPeerMessages: config.get('logPeerMessages'),
       simulateLatency: config.get('p2pSimulateLatency'),
-      bootstrapNodes: config.getArray('bootstrapNodes'),
+      bootstrapNodes: config.isSet('bootstrapNodes')
+        ? config.getArray('bootstrapNodes')
+        : chain.network.bootstrapNodes,
       webSocket: webSocket,
       node: this,
       chain: chain,
@@ -125,6 +259,7 @@ export class IronfishNode {
       chain,
       logger,
       config,
+      files,
       metrics,
       workerPool,
       localPeerIdentity: this.peerNetwork.localPeer.publicIdentity,
@@ -143,6 +278,14 @@ export class IronfishNode {
       this.telemetry.submitNewTransactionSeen(transaction, received)
     })
 
+    this.peerNetwork.onBlockPropagated.on((block, elapsedMs) => {
+      this.telemetry.submitBlockPropagated(block, elapsedMs)
+    })
+
+    this.peerNetwork.onMinedBlockAcknowledged.on((block, elapsedMs, peerIdentity) => {
+      this.telemetry.submitMinedBlockAcknowledged(block, elapsedMs, peerIdentity)
+    })
+
     this.syncer = new Syncer({
       chain,
       metrics,
@@ -151,6 +294,87 @@ export class IronfishNode {
       peerNetwork: this.peerNetwork,
       strategy: this.strategy,
       blocksPerMessage: config.get('blocksPerMessage'),
+      memoryBudget: this.memoryBudget,
+      preferredPeers: config.get('preferredSyncPeers'),
+      stallTimeoutMs: config.get('syncStallTimeout') * 1000,
+    })
+
+    const chainNode = config.get('chainNode')
+    if (chainNode) {
+      const { hostname, port } = parseUrl(chainNode)
+      if (!hostname || !port) {
+        throw new Error(`Invalid chainNode '${chainNode}', expected host:port`)
+      }
+
+      const authToken = config.get('chainNodeAuthToken') || null
+      const client = config.get('chainNodeTls')
+        ? new RpcTlsClient(hostname, port, logger, authToken)
+        : new RpcTcpClient(hostname, port, logger, authToken)
+
+      const remoteSyncer = new RemoteSyncer({ chain, strategy: this.strategy, client, logger })
+      this.accounts.onBroadcastTransaction.on((transaction) => {
+        void remoteSyncer.broadcastTransaction(transaction)
+      })
+      this.remoteSyncer = remoteSyncer
+    } else if (config.get('follower')) {
+      throw new Error('A follower needs a chainNode to sync from')
+    }
+
+    const chainCacheMb = config.get('chainCacheMb')
+    chain.setCacheSize(
+      chainCacheMb === 0
+        ? this.memoryBudget.budget * CHAIN_CACHE_BUDGET_RATIO
+        : Math.max(chainCacheMb, 0) * 1024 * 1024,
+    )
+
+    this.memoryBudget.register('memPool', () => this.memPool.sizeBytes())
+    this.memoryBudget.register('chainCache', () => this.chain.cacheSizeBytes)
+
+    this.memoryBudget.onPressureChanged.on((pressure) => {
+      if (pressure !== 'critical') {
+        return
+      }
+
+      this.chain.clearCaches()
+
+      const evicted = this.memPool.evict(Math.ceil(this.memPool.size() / 2))
+      if (evicted) {
+        this.logger.warn(`Evicted ${evicted} transactions from the mem pool to free memory`)
+      }
+    })
+
+    this.clockMonitor.onMeasured.on((offsetMs) => {
+      if (this.config.get('compensateClockSkew')) {
+        this.chain.verifier.clockOffsetMs = this.clockMonitor.compensationMs
+      }
+
+      if (this.clockMonitor.skewed) {
+        this.telemetry.submitClockSkew(offsetMs)
+      }
+    })
+
+    this.coldStorageMonitor.onSpend.on((spend) => {
+      this.telemetry.submitColdStorageSpend(spend.sequence)
+    })
+
+    this.diskMonitor.onStatusChanged.on((status, previous) => {
+      if (status === 'critical') {
+        void this.syncer.stop()
+      } else if (previous === 'critical' && this.peerNetwork.isReady) {
+        this.onPeerNetworkReady()
+      }
+    })
+
+    this.configWatcher = new ConfigWatcher({
+      config,
+      logger,
+      onChange: async () => {
+        try {
+          await this.reloadConfig()
+        } catch (e: unknown) {
+          this.logger.error(`Could not reload the config: ${ErrorUtils.renderError(e)}`)
+        }
+      },
     })
 
     this.config.onConfigChange.on((key, value) => this.onConfigChange(key, value))
@@ -191,6 +415,20 @@ export class IronfishNode {
       await config.load()
     }
 
+    for (const issue of config.issues) {
+      if (issue.level === 'warning') {
+        logger.warn(`${config.storage.configPath}: ${formatConfigIssue(issue)}`)
+      }
+    }
+
+    const configErrors = config.issues.filter((i) => i.level === 'error')
+    if (configErrors.length) {
+      const errors = configErrors.map((i) => `  ${formatConfigIssue(i)}`).join('\n')
+      throw new Error(
+        `Invalid config ${config.storage.configPath}, run config:lint for details:\n${errors}`,
+      )
+    }
+
     if (!internal) {
       internal = new InternalStore(files, dataDir)
       await internal.load()
@@ -199,6 +437,11 @@ export class IronfishNode {
     const hostsStore = new HostsStore(files, dataDir)
     await hostsStore.load()
 
+    const metricsHistoryStore = new MetricsHistoryStore(files, dataDir)
+    await metricsHistoryStore.load()
+
+    const memPoolStore = new MemPoolStore(files, dataDir)
+
     if (databaseName) {
       config.setOverride('databaseName', databaseName)
     }
@@ -212,20 +455,44 @@ export class IronfishNode {
         workers = Math.min(workers, maxWorkers)
       }
     }
-    const workerPool = new WorkerPool({ metrics, numWorkers: workers })
+
+    let autoscaleWorkers = config.get('nodeWorkersAutoscaleMax')
+    if (autoscaleWorkers === -1) {
+      autoscaleWorkers = os.cpus().length - 1
+    }
+
+    const workerPool = new WorkerPool({
+      metrics,
+      numWorkers: workers,
+      maxWorkers: autoscaleWorkers,
+      scaleUpLatency: config.get('nodeWorkersScaleUpLatency'),
+      jobTimeout: config.get('nodeWorkersJobTimeout'),
+      provingWorkers: config.get('nodeWorkersProving'),
+    })
 
     strategyClass = strategyClass || Strategy
     const strategy = new strategyClass(workerPool)
 
     metrics = metrics || new MetricsMonitor({ logger })
 
+    const tracer = new Tracer({
+      thresholdMs: config.get('slowOperationThresholdMs'),
+      logger,
+    })
+
+    const network = await loadNetworkDefinition(files, config.get('network'))
+
     const chain = new Blockchain({
       location: config.chainDatabasePath,
       strategy,
       logger,
       metrics,
+      tracer,
       autoSeed,
       workerPool,
+      network,
+      maxReorgDepth: config.get('maxReorgDepth'),
+      enableChainFilters: config.get('enableChainFilters'),
     })
 
     const memPool = new MemPool({ chain, metrics, logger })
@@ -241,6 +508,7 @@ export class IronfishNode {
       config,
       database: accountDB,
       workerPool,
+      tracer,
     })
 
     const minedBlocksIndexer = new MinedBlocksIndexer({
@@ -251,6 +519,14 @@ export class IronfishNode {
       logger,
     })
 
+    const viewKeyScanner = new ViewKeyScanner({
+      files,
+      location: config.scannerDatabasePath,
+      chain,
+      workerPool,
+      logger,
+    })
+
     return new IronfishNode({
       pkg,
       chain,
@@ -260,13 +536,17 @@ export class IronfishNode {
       internal,
       accounts,
       metrics,
+      tracer,
       memPool,
       workerPool,
       logger,
       webSocket,
       privateIdentity,
       hostsStore,
+      metricsHistoryStore,
+      memPoolStore,
       minedBlocksIndexer,
+      viewKeyScanner,
     })
   }
 
@@ -283,10 +563,15 @@ export class IronfishNode {
       await this.chain.open(options)
       await this.accounts.open(options)
       await this.minedBlocksIndexer.open(options)
+
+      if (this.config.get('enableViewKeyScanner')) {
+        await this.viewKeyScanner.open(options)
+      }
     } catch (e) {
       await this.chain.close()
       await this.accounts.close()
       await this.minedBlocksIndexer.close()
+      await this.viewKeyScanner.close()
       throw e
     }
   }
@@ -294,52 +579,157 @@ export class IronfishNode {
   async closeDB(): Promise<void> {
     await this.chain.close()
     await this.accounts.close()
+    await this.viewKeyScanner.close()
   }
 
   async start(): Promise<void> {
     this.shutdownPromise = new Promise((r) => (this.shutdownResolve = r))
     this.started = true
 
+    if (!this.internal.get('cleanShutdown')) {
+      await this.recover()
+    }
+
+    if (this.config.get('enableIntegrityCheck')) {
+      await this.checkIntegrity()
+    }
+
+    this.internal.set('cleanShutdown', false)
+    await this.internal.save()
+
     // Work in the worker pool happens concurrently,
     // so we should start it as soon as possible
     this.workerPool.start()
+    this.memoryBudget.start()
+    await this.diskMonitor.start()
+    this.clockMonitor.start()
 
-    if (this.config.get('enableTelemetry')) {
-      this.telemetry.start()
-    }
+    await this.updateTelemetry()
 
     if (this.config.get('enableMetrics')) {
       this.metrics.start()
+
+      if (this.config.get('enableMetricsHistory')) {
+        this.metricsHistory.start()
+      }
+    }
+
+    if (this.config.get('persistMemPool')) {
+      await this.loadMemPool()
     }
 
     await this.accounts.start()
-    this.peerNetwork.start()
+    await this.walletBackups.start()
+
+    if (this.remoteSyncer) {
+      this.remoteSyncer.start()
+    } else {
+      this.peerNetwork.start()
+    }
 
     if (this.config.get('enableRpc')) {
       await this.rpc.start()
     }
 
+    if (this.config.get('enableRosetta')) {
+      await this.rosetta.start()
+    }
+
     await this.minedBlocksIndexer.start()
+
+    if (this.config.get('enableViewKeyScanner')) {
+      await this.viewKeyScanner.start()
+      this.coldStorageMonitor.start()
+    } else if (this.config.get('coldStorageViewKeys').length) {
+      this.logger.warn('Cold storage is not monitored because enableViewKeyScanner is off')
+    }
+
     this.telemetry.submitNodeStarted()
+
+    if (this.config.get('enableConfigWatcher')) {
+      this.configWatcher.start()
+    }
   }
 
   async waitForShutdown(): Promise<void> {
     await this.shutdownPromise
   }
 
+  /**
+   * Shuts the node down in order so nothing is lost: stop accepting new work,
+   * persist peer addresses, flush the wallet and persist the mem pool, then stop
+   * everything else. Each step is abandoned if `shutdownTimeoutMs` runs out.
+   */
   async shutdown(): Promise<void> {
-    await Promise.allSettled([
-      this.accounts.stop(),
-      this.syncer.stop(),
-      this.peerNetwork.stop(),
-      this.rpc.stop(),
-      this.telemetry.stop(),
-      this.metrics.stop(),
-      this.minedBlocksIndexer.stop(),
-    ])
+    const deadline = Date.now() + this.config.get('shutdownTimeoutMs')
+
+    const step = async (description: string, fn: () => Promise<unknown>): Promise<void> => {
+      this.onShutdownStep.emit(description)
+
+      const remaining = deadline - Date.now()
+      if (remaining <= 0) {
+        this.logger.warn(`Skipped ${description}, out of time to shut down`)
+        return
+      }
+
+      let timer: ReturnType<typeof setTimeout> | undefined
+
+      const timeout = new Promise<void>((resolve) => {
+        timer = setTimeout(() => {
+          this.logger.warn(`Timed out ${description} after ${remaining}ms`)
+          resolve()
+        }, remaining)
+      })
+
+      await Promise.race([
+        fn().catch((e: unknown) => {
+          this.logger.error(`Error ${description}: ${ErrorUtils.renderError(e)}`)
+        }),
+        timeout,
+      ])
+
+      clearTimeout(timer)
+    }
+
+    await step('stopping new work', async () => {
+      this.configWatcher.stop()
+      this.devBlockProducer?.stop()
+      this.remoteSyncer?.stop()
+      await Promise.allSettled([this.rpc.stop(), this.rosetta.stop(), this.syncer.stop()])
+    })
+
+    // Stopping the network saves the peer addresses and stops new blocks and transactions
+    await step('persisting peer addresses', () => this.peerNetwork.stop())
+
+    await step('flushing wallet', () => this.accounts.stop())
+
+    // A node that never started has nothing to save over the last saved mem pool
+    if (this.started && this.config.get('persistMemPool')) {
+      await step('persisting mem pool', () => this.saveMemPool())
+    }
+
+    await step('stopping services', () =>
+      Promise.allSettled([
+        this.telemetry.stop(),
+        this.metrics.stop(),
+        this.metricsHistory.stop(),
+        this.minedBlocksIndexer.stop(),
+        this.viewKeyScanner.stop(),
+        this.coldStorageMonitor.stop(),
+        this.memoryBudget.stop(),
+        this.diskMonitor.stop(),
+        this.clockMonitor.stop(),
+        this.walletBackups.stop(),
+      ]),
+    )
 
     // Do after to avoid unhandled error from aborted jobs
-    await Promise.allSettled([this.workerPool.stop()])
+    await step('stopping workers', () => Promise.allSettled([this.workerPool.stop()]))
+
+    if (this.started) {
+      this.internal.set('cleanShutdown', true)
+      await this.internal.save()
+    }
 
     if (this.shutdownResolve) {
       this.shutdownResolve()
@@ -348,8 +738,78 @@ export class IronfishNode {
     this.started = false
   }
 
+  /**
+   * Called on start when the node did not shut down cleanly the last time it ran,
+   * like after being killed or losing power
+   */
+  async recover(): Promise<void> {
+    this.logger.warn('The node did not shut down cleanly, checking the chain database')
+
+    const fixes = await this.chain.recover()
+
+    for (const fix of fixes) {
+      this.logger.warn(`Recovered: ${fix}`)
+    }
+
+    if (!fixes.length) {
+      this.logger.info('The chain database is consistent, nothing to recover')
+    }
+  }
+
+  /**
+   * Throws if the chain database looks corrupt, instead of failing later in
+   * the sync
+   */
+  async checkIntegrity(): Promise<void> {
+    const report = await checkChainIntegrity(this.chain, {
+      depth: this.config.get('integrityCheckDepth'),
+    })
+
+    if (report.valid) {
+      this.logger.info(
+        `The chain database passed the integrity check in ${report.durationMs}ms`,
+      )
+      return
+    }
+
+    for (const problem of report.problems) {
+      this.logger.error(`Integrity check: ${problem}`)
+    }
+
+    throw new Error(
+      `The chain database at ${this.config.chainDatabasePath} is corrupt.\n` +
+        `Run "ironfish chain:repair" to rebuild it, or restore a snapshot with ` +
+        `"ironfish chain:snapshot --download <url>".`,
+    )
+  }
+
+  async loadMemPool(): Promise<void> {
+    const serialized = await this.memPoolStore.loadTransactions()
+    let added = 0
+
+    for (const buffer of serialized) {
+      try {
+        if (await this.memPool.acceptTransaction(new Transaction(buffer))) {
+          added++
+        }
+      } catch (e: unknown) {
+        this.logger.debug(`Could not load mem pool transaction: ${ErrorUtils.renderError(e)}`)
+      }
+    }
+
+    if (serialized.length) {
+      this.logger.info(`Loaded ${added} of ${serialized.length} saved mem pool transactions`)
+    }
+  }
+
+  async saveMemPool(): Promise<void> {
+    const transactions = [...this.memPool.orderedTransactions()].map((t) => t.serialize())
+    await this.memPoolStore.saveTransactions(transactions)
+  }
+
   onPeerNetworkReady(): void {
-    if (this.config.get('enableSyncing')) {
+    // Syncing starts again when the disk monitor sees space was freed
+    if (this.config.get('enableSyncing') && !this.diskMonitor.critical) {
       void this.syncer.start()
     }
   }
@@ -358,17 +818,54 @@ export class IronfishNode {
     void this.syncer.stop()
   }
 
+  /**
+   * Load the config file again. Changed options that are safe to change while
+   * running are applied immediately, the rest are reported as needing a restart.
+   */
+  async reloadConfig(): Promise<{
+    applied: Array<keyof ConfigOptions>
+    restartRequired: Array<keyof ConfigOptions>
+  }> {
+    const changed = await this.config.reload()
+
+    const applied = changed.filter((key) => RELOADABLE_CONFIG_OPTIONS.has(key))
+    const restartRequired = changed.filter((key) => !RELOADABLE_CONFIG_OPTIONS.has(key))
+
+    if (applied.length) {
+      this.logger.info(`Reloaded config options: ${applied.join(', ')}`)
+    }
+
+    if (restartRequired.length) {
+      this.logger.warn(
+        `Restart the node to apply changed config options: ${restartRequired.join(', ')}`,
+      )
+    }
+
+    return { applied, restartRequired }
+  }
+
+  /**
+   * Telemetry runs if it's either submitted to the API or exported to a file
+   */
+  private async updateTelemetry(): Promise<void> {
+    const submit = this.config.get('enableTelemetry')
+    this.telemetry.submitToApi = submit
+
+    if (submit || this.config.get('telemetryExportPath')) {
+      this.telemetry.start()
+    } else {
+      await this.telemetry.stop()
+    }
+  }
+
   async onConfigChange<Key extends keyof ConfigOptions>(
     key: Key,
     newValue: ConfigOptions[Key],
   ): Promise<void> {
     switch (key) {
-      case 'enableTelemetry': {
-        if (newValue) {
-          this.telemetry.start()
-        } else {
-          await this.telemetry.stop()
-        }
+      case 'enableTelemetry':
+      case 'telemetryExportPath': {
+        await this.updateTelemetry()
         break
       }
       case 'enableMetrics': {
@@ -379,6 +876,49 @@ export class IronfishNode {
         }
         break
       }
+      case 'enableMetricsHistory': {
+        if (newValue && this.metrics.started) {
+          this.metricsHistory.start()
+        } else {
+          await this.metricsHistory.stop()
+        }
+        break
+      }
+      case 'enablePropagationTracing': {
+        this.peerNetwork.setPropagationTracing(this.config.get('enablePropagationTracing'))
+        break
+      }
+      case 'logLevel': {
+        ConsoleReporterInstance.tagToLogLevelMap.clear()
+        setLogLevelFromConfig(this.config.get('logLevel'))
+        break
+      }
+      case 'maxPeers':
+      case 'minPeers':
+      case 'targetPeers': {
+        this.peerNetwork.setPeerLimits({
+          maxPeers: this.config.get('maxPeers'),
+          minPeers: this.config.get('minPeers'),
+          targetPeers: this.config.get('targetPeers'),
+        })
+        break
+      }
+      case 'maxReorgDepth': {
+        this.chain.maxReorgDepth = this.config.get('maxReorgDepth')
+        break
+      }
+      case 'preferredSyncPeers': {
+        this.syncer.setPreferredPeers(this.config.get('preferredSyncPeers'))
+        break
+      }
+      case 'syncStallTimeout': {
+        this.syncer.stallTimeoutMs = this.config.get('syncStallTimeout') * 1000
+        break
+      }
+      case 'slowOperationThresholdMs': {
+        this.tracer.thresholdMs = this.config.get('slowOperationThresholdMs')
+        break
+      }
       case 'enableRpc': {
         if (newValue) {
           await this.rpc.start()
@@ -0,0 +1,200 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import os from 'os'
+import path from 'path'
+import { v4 as uuid } from 'uuid'
+import { NodeFileProvider } from '../fileSystems'
+import { RpcSocketClient } from '../rpc/clients'
+import { WatchDepositsResponse } from '../rpc/routes/accounts/watchDeposits'
+import { InvoiceStore } from './invoices'
+import { PaymentProcessor, signCallback } from './processor'
+
+describe('InvoiceStore', () => {
+  const files = new NodeFileProvider()
+
+  beforeAll(async () => {
+    await files.init()
+  })
+
+  function createStore(dataDir = path.join(os.tmpdir(), uuid())): InvoiceStore {
+    return new InvoiceStore(files, dataDir)
+  }
+
+  it('returns the same invoice for the same order id', async () => {
+    const store = createStore()
+
+    const first = await store.create({ amount: BigInt(5), publicAddress: 'a', orderId: '1' })
+    const second = await store.create({ amount: BigInt(9), publicAddress: 'a', orderId: '1' })
+
+    expect(first.created).toBe(true)
+    expect(second.created).toBe(false)
+    expect(second.invoice.id).toEqual(first.invoice.id)
+    expect(store.list()).toHaveLength(1)
+  })
+
+  it('finds invoices by padded memos', async () => {
+    const store = createStore()
+    const { invoice } = await store.create({ amount: BigInt(5), publicAddress: 'a' })
+
+    const padded = invoice.memo.padEnd(32, '\u0000')
+    expect(store.getByMemo(padded)?.id).toEqual(invoice.id)
+    expect(store.getByMemo('hello')).toBeNull()
+  })
+
+  it('is paid when confirmed payments add up to the amount', async () => {
+    const store = createStore()
+    const { invoice } = await store.create({ amount: BigInt(10), publicAddress: 'a' })
+
+    const first = { transactionHash: '1', blockHash: 'b', sequence: 2, amount: '6' }
+    const second = { ...first, transactionHash: '2' }
+
+    expect(store.recordPayment(invoice, { ...first, confirmed: false })).toBe(false)
+    expect(invoice.status).toEqual('seen')
+
+    expect(store.recordPayment(invoice, { ...second, confirmed: false })).toBe(false)
+    expect(store.recordPayment(invoice, { ...first, confirmed: true })).toBe(false)
+    expect(store.recordPayment(invoice, { ...second, confirmed: true })).toBe(true)
+
+    expect(invoice.status).toEqual('paid')
+    expect(invoice.payments).toHaveLength(2)
+  })
+
+  it('goes back to pending when a payment is removed', async () => {
+    const store = createStore()
+    const { invoice } = await store.create({ amount: BigInt(10), publicAddress: 'a' })
+
+    store.recordPayment(invoice, {
+      transactionHash: '1',
+      blockHash: 'b',
+      sequence: 2,
+      amount: '10',
+      confirmed: false,
+    })
+    store.removePayment(invoice, '1', 'b')
+
+    expect(invoice.status).toEqual('pending')
+    expect(invoice.payments).toHaveLength(0)
+  })
+
+  it('expires pending invoices', async () => {
+    const store = createStore()
+    const { invoice } = await store.create({
+      amount: BigInt(10),
+      publicAddress: 'a',
+      expiresAt: 1000,
+    })
+
+    expect(store.expire(999)).toHaveLength(0)
+    expect(store.expire(1000)).toEqual([invoice])
+    expect(invoice.status).toEqual('expired')
+  })
+
+  it('saves and loads invoices', async () => {
+    const dataDir = path.join(os.tmpdir(), uuid())
+    const store = createStore(dataDir)
+    const { invoice } = await store.create({ amount: BigInt(10), publicAddress: 'a' })
+    store.head = 'abc'
+    await store.save()
+
+    const loaded = createStore(dataDir)
+    await loaded.load()
+
+    expect(loaded.get(invoice.id)).toEqual(invoice)
+    expect(loaded.head).toEqual('abc')
+  })
+})
+
+describe('PaymentProcessor', () => {
+  const files = new NodeFileProvider()
+
+  beforeAll(async () => {
+    await files.init()
+  })
+
+  function createDeposit(
+    memo: string,
+    options: Partial<WatchDepositsResponse> = {},
+  ): WatchDepositsResponse {
+    return {
+      type: 'deposit',
+      account: 'default',
+      transactionHash: 'tx',
+      amount: '10',
+      memos: [memo],
+      blockHash: 'block',
+      sequence: 2,
+      confirmations: 0,
+      head: { hash: 'head', sequence: 2 },
+      ...options,
+    }
+  }
+
+  it('calls back once an invoice is confirmed', async () => {
+    const invoices = new InvoiceStore(files, path.join(os.tmpdir(), uuid()))
+    const processor = new PaymentProcessor({
+      rpc: {} as RpcSocketClient,
+      invoices,
+      account: 'default',
+      confirmations: 2,
+      callbackSecret: 'secret',
+    })
+
+    const post = jest.spyOn(processor.http, 'post').mockResolvedValue({})
+
+    const { invoice } = await invoices.create({
+      amount: BigInt(10),
+      publicAddress: 'a',
+      callbackUrl: 'http://localhost/paid',
+    })
+
+    await processor.onDeposit(createDeposit(invoice.memo))
+    expect(invoice.status).toEqual('seen')
+    expect(post).not.toHaveBeenCalled()
+
+    // The restart point doesn't move past unconfirmed payments
+    expect(invoices.head).toBeNull()
+
+    await processor.onDeposit(
+      createDeposit(invoice.memo, { type: 'confirmed', confirmations: 2 }),
+    )
+    expect(invoice.status).toEqual('paid')
+    expect(invoice.notifiedAt).not.toBeNull()
+    expect(invoices.head).toEqual('head')
+
+    expect(post).toHaveBeenCalledTimes(1)
+    const [url, body, config] = post.mock.calls[0]
+    expect(url).toEqual('http://localhost/paid')
+    expect(JSON.parse(body as string)).toMatchObject({ event: 'invoice.paid' })
+    expect(config?.headers).toMatchObject({
+      'X-Ironfish-Signature': signCallback(body as string, 'secret'),
+    })
+  })
+
+  it('retries callbacks that failed', async () => {
+    const invoices = new InvoiceStore(files, path.join(os.tmpdir(), uuid()))
+    const processor = new PaymentProcessor({
+      rpc: {} as RpcSocketClient,
+      invoices,
+      account: 'default',
+      confirmations: 0,
+    })
+
+    const post = jest.spyOn(processor.http, 'post').mockRejectedValueOnce(new Error('down'))
+
+    const { invoice } = await invoices.create({
+      amount: BigInt(10),
+      publicAddress: 'a',
+      callbackUrl: 'http://localhost/paid',
+    })
+
+    await processor.onDeposit(createDeposit(invoice.memo, { type: 'confirmed' }))
+    expect(invoice.status).toEqual('paid')
+    expect(invoice.notifiedAt).toBeNull()
+
+    post.mockResolvedValueOnce({})
+    await processor.tick()
+    expect(invoice.notifiedAt).not.toBeNull()
+    expect(post).toHaveBeenCalledTimes(2)
+  })
+})
@@ -0,0 +1,224 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import crypto from 'crypto'
+import { FileStore } from '../fileStores/fileStore'
+import { FileSystem } from '../fileSystems'
+import { Mutex } from '../mutex'
+
+export const INVOICES_FILE_NAME = 'invoices.json'
+
+// Memos are 32 bytes, the id is short enough to leave room for a prefix
+const INVOICE_ID_BYTES = 8
+const INVOICE_MEMO_PREFIX = 'inv-'
+
+/**
+ * pending: nothing has been received yet
+ * seen: payments were received but are not confirmed or are not enough
+ * paid: confirmed payments add up to the amount
+ * expired: the invoice expired before it was paid
+ */
+export type InvoiceStatus = 'pending' | 'seen' | 'paid' | 'expired'
+
+export type InvoicePayment = {
+  transactionHash: string
+  blockHash: string
+  sequence: number
+  amount: string
+  confirmed: boolean
+}
+
+export type Invoice = {
+  id: string
+  orderId: string | null
+  memo: string
+  publicAddress: string
+  amount: string
+  status: InvoiceStatus
+  callbackUrl: string | null
+  metadata: Record<string, unknown>
+  payments: InvoicePayment[]
+  createdAt: number
+  expiresAt: number | null
+  paidAt: number | null
+  notifiedAt: number | null
+}
+
+export type CreateInvoiceOptions = {
+  amount: bigint
+  publicAddress: string
+  orderId?: string | null
+  callbackUrl?: string | null
+  expiresAt?: number | null
+  metadata?: Record<string, unknown>
+}
+
+type InvoicesFile = {
+  invoices: Invoice[]
+  head: string | null
+}
+
+/**
+ * Keeps invoices in a JSON file. Creating an invoice with an order id that
+ * already has one returns the existing invoice, and payments are recorded by
+ * transaction, so requests and chain events can be safely repeated.
+ */
+export class InvoiceStore {
+  readonly store: FileStore<InvoicesFile>
+  readonly invoices = new Map<string, Invoice>()
+
+  /**
+   * Where to start watching the chain from after a restart
+   */
+  head: string | null = null
+
+  private readonly saveMutex = new Mutex()
+
+  constructor(files: FileSystem, dataDir: string, name = INVOICES_FILE_NAME) {
+    this.store = new FileStore<InvoicesFile>(files, name, dataDir)
+  }
+
+  async load(): Promise<void> {
+    const data = await this.store.load()
+
+    this.invoices.clear()
+    for (const invoice of (data?.invoices ?? []) as Invoice[]) {
+      this.invoices.set(invoice.id, invoice)
+    }
+
+    this.head = data?.head ?? null
+  }
+
+  async save(): Promise<void> {
+    await this.saveMutex.dispatch(() =>
+      this.store.save({ invoices: [...this.invoices.values()], head: this.head }),
+    )
+  }
+
+  list(): Invoice[] {
+    return [...this.invoices.values()].sort((a, b) => b.createdAt - a.createdAt)
+  }
+
+  get(id: string): Invoice | null {
+    return this.invoices.get(id) ?? null
+  }
+
+  getByOrderId(orderId: string): Invoice | null {
+    return this.list().find((i) => i.orderId === orderId) ?? null
+  }
+
+  getByMemo(memo: string): Invoice | null {
+    // Memos are padded to 32 bytes with zeros
+    const trimmed = memo.split('\u0000').join('').trim()
+
+    if (!trimmed.startsWith(INVOICE_MEMO_PREFIX)) {
+      return null
+    }
+
+    return this.get(trimmed.slice(INVOICE_MEMO_PREFIX.length))
+  }
+
+  async create(options: CreateInvoiceOptions): Promise<{ invoice: Invoice; created: boolean }> {
+    if (options.orderId) {
+      const existing = this.getByOrderId(options.orderId)
+      if (existing) {
+        return { invoice: existing, created: false }
+      }
+    }
+
+    let id
+    do {
+      id = crypto.randomBytes(INVOICE_ID_BYTES).toString('hex')
+    } while (this.invoices.has(id))
+
+    const invoice: Invoice = {
+      id,
+      orderId: options.orderId ?? null,
+      memo: `${INVOICE_MEMO_PREFIX}${id}`,
+      publicAddress: options.publicAddress,
+      amount: options.amount.toString(),
+      status: 'pending',
+      callbackUrl: options.callbackUrl ?? null,
+      metadata: options.metadata ?? {},
+      payments: [],
+      createdAt: Date.now(),
+      expiresAt: options.expiresAt ?? null,
+      paidAt: null,
+      notifiedAt: null,
+    }
+
+    this.invoices.set(id, invoice)
+    await this.save()
+
+    return { invoice, created: true }
+  }
+
+  /**
+   * Add or update the payment in `transactionHash` and update the status
+   * @returns true if the invoice became paid
+   */
+  recordPayment(invoice: Invoice, payment: InvoicePayment): boolean {
+    invoice.payments = [
+      ...invoice.payments.filter((p) => p.transactionHash !== payment.transactionHash),
+      payment,
+    ]
+
+    return this.updateStatus(invoice)
+  }
+
+  removePayment(invoice: Invoice, transactionHash: string, blockHash: string): void {
+    invoice.payments = invoice.payments.filter(
+      (p) => p.transactionHash !== transactionHash || p.blockHash !== blockHash,
+    )
+
+    this.updateStatus(invoice)
+  }
+
+  /**
+   * @returns true if the invoice became paid
+   */
+  updateStatus(invoice: Invoice, now = Date.now()): boolean {
+    // Paid invoices stay paid, the merchant has already been told
+    if (invoice.status === 'paid') {
+      return false
+    }
+
+    const confirmed = invoice.payments
+      .filter((p) => p.confirmed)
+      .reduce((sum, p) => sum + BigInt(p.amount), BigInt(0))
+
+    if (confirmed >= BigInt(invoice.amount)) {
+      invoice.status = 'paid'
+      invoice.paidAt = now
+      return true
+    }
+
+    if (invoice.payments.length) {
+      invoice.status = 'seen'
+    } else if (invoice.expiresAt !== null && invoice.expiresAt <= now) {
+      invoice.status = 'expired'
+    } else {
+      invoice.status = 'pending'
+    }
+
+    return false
+  }
+
+  /**
+   * Mark pending invoices past their expiration as expired
+   */
+  expire(now = Date.now()): Invoice[] {
+    const expired = []
+
+    for (const invoice of this.invoices.values()) {
+      if (invoice.status === 'pending' && invoice.expiresAt !== null) {
+        this.updateStatus(invoice, now)
+        if (invoice.status === 'expired') {
+          expired.push(invoice)
+        }
+      }
+    }
+
+    return expired
+  }
+}
@@ -0,0 +1,236 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import Axios, { AxiosInstance } from 'axios'
+import crypto from 'crypto'
+import { createRootLogger, Logger } from '../logger'
+import { ERROR_CODES } from '../rpc/adapters/errors'
+import { RpcRequestError, RpcSocketClient } from '../rpc/clients'
+import { WatchDepositsResponse } from '../rpc/routes/accounts/watchDeposits'
+import { ErrorUtils, PromiseUtils, SetIntervalToken } from '../utils'
+import { Invoice, InvoiceStore } from './invoices'
+
+export const PAYMENT_SIGNATURE_HEADER = 'X-Ironfish-Signature'
+
+const RECONNECT_MS = 5000
+const RETRY_CALLBACKS_MS = 60 * 1000
+
+export type InvoiceEvent = 'invoice.paid' | 'invoice.expired'
+
+export type InvoiceCallback = {
+  event: InvoiceEvent
+  invoice: Invoice
+}
+
+/**
+ * Sign a callback body so merchants can check it came from the processor
+ */
+export function signCallback(body: string, secret: string): string {
+  return crypto.createHmac('sha256', secret).update(body).digest('hex')
+}
+
+/**
+ * Watches an account for notes with invoice memos and calls the merchant's
+ * callback URL once payments reach the number of confirmations. Callbacks are
+ * retried until the merchant responds with a 2xx status, so merchants should
+ * handle the same callback more than once.
+ */
+export class PaymentProcessor {
+  readonly rpc: RpcSocketClient
+  readonly invoices: InvoiceStore
+  readonly account: string
+  readonly confirmations: number
+  readonly callbackSecret: string | null
+  readonly logger: Logger
+  readonly http: AxiosInstance
+
+  started = false
+
+  private retryInterval: SetIntervalToken | null = null
+  private stopPromise: Promise<void> | null = null
+
+  constructor(options: {
+    rpc: RpcSocketClient
+    invoices: InvoiceStore
+    account: string
+    confirmations: number
+    callbackSecret?: string | null
+    logger?: Logger
+  }) {
+    this.rpc = options.rpc
+    this.invoices = options.invoices
+    this.account = options.account
+    this.confirmations = options.confirmations
+    this.callbackSecret = options.callbackSecret ?? null
+    this.logger = (options.logger ?? createRootLogger()).withTag('payments')
+    this.http = Axios.create({ timeout: 10000 })
+  }
+
+  start(): void {
+    if (this.started) {
+      return
+    }
+    this.started = true
+
+    this.retryInterval = setInterval(() => void this.tick(), RETRY_CALLBACKS_MS)
+    this.stopPromise = this.watch()
+    void this.tick()
+  }
+
+  async stop(): Promise<void> {
+    if (!this.started) {
+      return
+    }
+    this.started = false
+
+    if (this.retryInterval) {
+      clearInterval(this.retryInterval)
+      this.retryInterval = null
+    }
+
+    // Closing the connection ends the stream of deposits
+    this.rpc.close()
+    await this.stopPromise
+  }
+
+  private async watch(): Promise<void> {
+    while (this.started) {
+      if (!(await this.rpc.tryConnect())) {
+        await PromiseUtils.sleep(RECONNECT_MS)
+        continue
+      }
+
+      try {
+        const response = this.rpc.watchDepositsStream({
+          accounts: [this.account],
+          confirmations: this.confirmations,
+          head: this.invoices.head,
+        })
+
+        for await (const event of response.contentStream()) {
+          await this.onDeposit(event)
+        }
+      } catch (e: unknown) {
+        this.logger.error(`Error watching deposits: ${ErrorUtils.renderError(e)}`)
+
+        // The block to restart from was removed by a reorg
+        if (e instanceof RpcRequestError && e.code === ERROR_CODES.VALIDATION) {
+          this.invoices.head = null
+        }
+      }
+
+      if (this.started) {
+        this.logger.warn('Lost the connection to the node, reconnecting')
+        await PromiseUtils.sleep(RECONNECT_MS)
+      }
+    }
+  }
+
+  async onDeposit(event: WatchDepositsResponse): Promise<void> {
+    const invoices = new Set<Invoice>()
+    for (const memo of event.memos) {
+      const invoice = this.invoices.getByMemo(memo)
+      if (invoice) {
+        invoices.add(invoice)
+      }
+    }
+
+    const becamePaid = []
+
+    // A transaction is expected to pay one invoice, if it has the memos of
+    // several then each is credited with the whole amount it received
+    for (const invoice of invoices) {
+      if (event.type === 'unconfirmed') {
+        this.invoices.removePayment(invoice, event.transactionHash, event.blockHash)
+        this.logger.info(`Payment for invoice ${invoice.id} was removed by a reorg`)
+        continue
+      }
+
+      const paid = this.invoices.recordPayment(invoice, {
+        transactionHash: event.transactionHash,
+        blockHash: event.blockHash,
+        sequence: event.sequence,
+        amount: event.amount,
+        confirmed: event.type === 'confirmed',
+      })
+
+      if (paid) {
+        this.logger.info(`Invoice ${invoice.id} is paid`)
+        becamePaid.push(invoice)
+      }
+    }
+
+    this.updateHead(event)
+    await this.invoices.save()
+
+    for (const invoice of becamePaid) {
+      await this.notify('invoice.paid', invoice)
+    }
+  }
+
+  /**
+   * Only move the place to restart from past blocks with no unconfirmed
+   * payments, so they are seen again after a restart
+   */
+  private updateHead(event: WatchDepositsResponse): void {
+    const unconfirmed = this.invoices
+      .list()
+      .some((i) => i.status !== 'paid' && i.payments.some((p) => !p.confirmed))
+
+    if (!unconfirmed) {
+      this.invoices.head = event.head.hash
+    }
+  }
+
+  /**
+   * Expire invoices and retry callbacks that failed
+   */
+  async tick(): Promise<void> {
+    const expired = this.invoices.expire()
+    if (expired.length) {
+      await this.invoices.save()
+    }
+
+    for (const invoice of expired) {
+      await this.notify('invoice.expired', invoice)
+    }
+
+    for (const invoice of this.invoices.list()) {
+      if (invoice.status === 'paid' && invoice.notifiedAt === null) {
+        await this.notify('invoice.paid', invoice)
+      }
+    }
+  }
+
+  /**
+   * @returns true if the merchant received the callback
+   */
+  async notify(event: InvoiceEvent, invoice: Invoice): Promise<boolean> {
+    if (!invoice.callbackUrl) {
+      return false
+    }
+
+    const callback: InvoiceCallback = { event, invoice }
+    const body = JSON.stringify(callback)
+
+    const headers: Record<string, string> = { 'Content-Type': 'application/json' }
+    if (this.callbackSecret) {
+      headers[PAYMENT_SIGNATURE_HEADER] = signCallback(body, this.callbackSecret)
+    }
+
+    try {
+      await this.http.post(invoice.callbackUrl, body, { headers })
+    } catch (e: unknown) {
+      const error = ErrorUtils.renderError(e)
+      this.logger.warn(`Callback ${event} for invoice ${invoice.id} failed: ${error}`)
+      return false
+    }
+
+    if (event === 'invoice.paid') {
+      invoice.notifiedAt = Date.now()
+      await this.invoices.save()
+    }
+
+    return true
+  }
+}
@@ -0,0 +1,241 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { timingSafeEqual } from 'crypto'
+import http from 'http'
+import { AddressInfo } from 'net'
+import { createRootLogger, Logger } from '../logger'
+import { ErrorUtils } from '../utils'
+import { InvoiceStore } from './invoices'
+
+const MAX_REQUEST_BYTES = 64 * 1024
+
+class PaymentServerError extends Error {
+  readonly status: number
+
+  constructor(status: number, message: string) {
+    super(message)
+    this.status = status
+  }
+}
+
+/**
+ * The HTTP API merchants create invoices with
+ *
+ *   POST /invoices      { amount, orderId?, callbackUrl?, expiresInMinutes?, metadata? }
+ *   GET  /invoices
+ *   GET  /invoices/:id
+ *
+ * Amounts are strings of ore. Posting an order id that already has an invoice
+ * returns that invoice with a 200 instead of a 201.
+ */
+export class PaymentServer {
+  readonly invoices: InvoiceStore
+  readonly publicAddress: string
+  readonly host: string
+  readonly apiKey: string | null
+  readonly expiresInMinutes: number
+  readonly logger: Logger
+
+  server: http.Server | null = null
+  private requestedPort: number
+
+  constructor(options: {
+    invoices: InvoiceStore
+    publicAddress: string
+    host: string
+    port: number
+    apiKey?: string | null
+    expiresInMinutes?: number
+    logger?: Logger
+  }) {
+    this.invoices = options.invoices
+    this.publicAddress = options.publicAddress
+    this.host = options.host
+    this.requestedPort = options.port
+    this.apiKey = options.apiKey || null
+    this.expiresInMinutes = options.expiresInMinutes ?? 0
+    this.logger = (options.logger ?? createRootLogger()).withTag('payments')
+  }
+
+  get port(): number {
+    const address = this.server?.address() as AddressInfo | null | undefined
+    return address?.port ?? this.requestedPort
+  }
+
+  async start(): Promise<void> {
+    if (this.server) {
+      return
+    }
+
+    const server = http.createServer((req, res) => void this.onRequest(req, res))
+    this.server = server
+
+    await new Promise<void>((resolve, reject) => {
+      server.once('error', reject)
+      server.listen(this.requestedPort, this.host, () => {
+        server.off('error', reject)
+        resolve()
+      })
+    })
+  }
+
+  async stop(): Promise<void> {
+    const server = this.server
+    if (!server) {
+      return
+    }
+
+    this.server = null
+    await new Promise<void>((resolve) => server.close(() => resolve()))
+  }
+
+  /**
+   * @returns the HTTP status and the JSON body to respond with
+   */
+  async handle(
+    method: string,
+    path: string,
+    body: Record<string, unknown>,
+  ): Promise<{ status: number; body: unknown }> {
+    const parts = path.split('/').filter(Boolean)
+
+    if (parts[0] !== 'invoices' || parts.length > 2) {
+      throw new PaymentServerError(404, `${path} was not found`)
+    }
+
+    if (parts.length === 2) {
+      if (method !== 'GET') {
+        throw new PaymentServerError(405, `${method} is not allowed`)
+      }
+
+      const invoice = this.invoices.get(parts[1])
+      if (!invoice) {
+        throw new PaymentServerError(404, `There is no invoice ${parts[1]}`)
+      }
+      return { status: 200, body: invoice }
+    }
+
+    if (method === 'GET') {
+      return { status: 200, body: this.invoices.list() }
+    }
+
+    if (method !== 'POST') {
+      throw new PaymentServerError(405, `${method} is not allowed`)
+    }
+
+    const { invoice, created } = await this.invoices.create(this.parseInvoice(body))
+    return { status: created ? 201 : 200, body: invoice }
+  }
+
+  private parseInvoice(body: Record<string, unknown>) {
+    const { amount, orderId, callbackUrl, expiresInMinutes, metadata } = body
+
+    if (typeof amount !== 'string' || !/^[0-9]+$/.test(amount) || BigInt(amount) <= 0) {
+      throw new PaymentServerError(400, 'amount must be a string of ore more than 0')
+    }
+    if (orderId !== undefined && typeof orderId !== 'string') {
+      throw new PaymentServerError(400, 'orderId must be a string')
+    }
+    if (callbackUrl !== undefined && !this.isHttpUrl(callbackUrl)) {
+      throw new PaymentServerError(400, 'callbackUrl must be an http or https URL')
+    }
+    if (expiresInMinutes !== undefined && typeof expiresInMinutes !== 'number') {
+      throw new PaymentServerError(400, 'expiresInMinutes must be a number')
+    }
+    if (metadata !== undefined && (typeof metadata !== 'object' || metadata === null)) {
+      throw new PaymentServerError(400, 'metadata must be an object')
+    }
+
+    const minutes = expiresInMinutes ?? this.expiresInMinutes
+
+    return {
+      amount: BigInt(amount),
+      publicAddress: this.publicAddress,
+      orderId: orderId ?? null,
+      callbackUrl: callbackUrl ?? null,
+      expiresAt: minutes > 0 ? Date.now() + minutes * 60 * 1000 : null,
+      metadata: metadata as Record<string, unknown> | undefined,
+    }
+  }
+
+  private isHttpUrl(value: unknown): value is string {
+    if (typeof value !== 'string') {
+      return false
+    }
+
+    try {
+      const url = new URL(value)
+      return url.protocol === 'http:' || url.protocol === 'https:'
+    } catch {
+      return false
+    }
+  }
+
+  private isAuthorized(req: http.IncomingMessage): boolean {
+    if (!this.apiKey) {
+      return true
+    }
+
+    const given = Buffer.from(req.headers.authorization ?? '')
+    const expected = Buffer.from(`Bearer ${this.apiKey}`)
+    return given.length === expected.length && timingSafeEqual(given, expected)
+  }
+
+  private async onRequest(req: http.IncomingMessage, res: http.ServerResponse): Promise<void> {
+    const send = (status: number, body: unknown) => {
+      res.writeHead(status, { 'Content-Type': 'application/json' })
+      res.end(JSON.stringify(body))
+    }
+
+    try {
+      if (!this.isAuthorized(req)) {
+        throw new PaymentServerError(401, 'The API key is missing or wrong')
+      }
+
+      const body = req.method === 'POST' ? await this.readBody(req) : {}
+      const path = req.url?.split('?')[0] ?? ''
+      const response = await this.handle(req.method ?? '', path, body)
+      send(response.status, response.body)
+    } catch (e: unknown) {
+      if (e instanceof PaymentServerError) {
+        send(e.status, { error: e.message })
+        return
+      }
+
+      this.logger.error(`Error handling ${req.url ?? ''}: ${ErrorUtils.renderError(e, true)}`)
+      send(500, { error: ErrorUtils.renderError(e) })
+    }
+  }
+
+  private readBody(req: http.IncomingMessage): Promise<Record<string, unknown>> {
+    return new Promise((resolve, reject) => {
+      const chunks: Buffer[] = []
+      let size = 0
+
+      req.on('data', (chunk: Buffer) => {
+        size += chunk.byteLength
+        if (size > MAX_REQUEST_BYTES) {
+          reject(new PaymentServerError(413, 'The request is too large'))
+          req.destroy()
+          return
+        }
+        chunks.push(chunk)
+      })
+
+      req.on('end', () => {
+        try {
+          const body = JSON.parse(Buffer.concat(chunks).toString('utf8') || '{}') as unknown
+          if (typeof body !== 'object' || body === null || Array.isArray(body)) {
+            throw new Error()
+          }
+          resolve(body as Record<string, unknown>)
+        } catch {
+          reject(new PaymentServerError(400, 'The request must be a JSON object'))
+        }
+      })
+
+      req.on('error', reject)
+    })
+  }
+}
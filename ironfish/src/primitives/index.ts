@@ -6,4 +6,4 @@ export { Block } from './block'
 export { BlockHeader } from './blockheader'
 export { Spend } from './spend'
 export { Target } from './target'
-export { Transaction } from './transaction'
+export { getTransactionSize, Transaction } from './transaction'
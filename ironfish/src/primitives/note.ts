@@ -12,6 +12,7 @@ export class Note {
   private note: NativeNote | null = null
   private referenceCount = 0
 
+  private readonly _owner: Buffer
   private readonly _value: bigint
   private readonly _memo: Buffer
 
@@ -20,8 +21,7 @@ export class Note {
 
     const reader = bufio.read(this.noteSerialized, true)
 
-    // skip owner
-    reader.seek(43)
+    this._owner = reader.readBytes(43, true)
 
     this._value = BigInt(reader.readU64())
 
@@ -51,6 +51,13 @@ export class Note {
     }
   }
 
+  /**
+   * The public address of the owner, as hex
+   */
+  owner(): string {
+    return this._owner.toString('hex')
+  }
+
   value(): bigint {
     return this._value
   }
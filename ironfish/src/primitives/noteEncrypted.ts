@@ -83,6 +83,34 @@ export class NoteEncrypted {
     }
   }
 
+  /**
+   * The key only this note is encrypted with, to disclose it to someone
+   * without giving them a view key
+   */
+  disclosureKeyForOwner(ownerHexKey: string): Buffer | undefined {
+    const key = this.takeReference().disclosureKeyForOwner(ownerHexKey)
+    this.returnReference()
+    return key ?? undefined
+  }
+
+  disclosureKeyForSpender(spenderHexKey: string): Buffer | undefined {
+    const key = this.takeReference().disclosureKeyForSpender(spenderHexKey)
+    this.returnReference()
+    return key ?? undefined
+  }
+
+  /**
+   * Returns undefined unless `disclosureKey` decrypts this note and it is
+   * owned by `owner`
+   */
+  decryptNoteForDisclosure(owner: string, disclosureKey: Buffer): Note | undefined {
+    const note = this.takeReference().decryptNoteForDisclosure(owner, disclosureKey)
+    this.returnReference()
+    if (note) {
+      return new Note(note)
+    }
+  }
+
   merkleHash(): Buffer {
     return this._noteCommitment
   }
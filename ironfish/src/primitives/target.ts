@@ -2,7 +2,7 @@
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 
-import { TARGET_BLOCK_TIME_IN_SECONDS, TARGET_BUCKET_TIME_IN_SECONDS } from '../consensus'
+import { ConsensusParameters, DEFAULT_CONSENSUS_PARAMETERS } from '../consensus'
 import { BigIntUtils } from '../utils/bigint'
 
 /**
@@ -67,11 +67,13 @@ export class Target {
    * @param time the block's timestamp for which the target is calculated for
    * @param previousBlockTimestamp the block's previous block header's timestamp
    * @param previousBlockTarget the block's previous block header's target
+   * @param consensus the consensus parameters of the network the block is on
    */
   static calculateTarget(
     time: Date,
     previousBlockTimestamp: Date,
     previousBlockTarget: Target,
+    consensus: ConsensusParameters = DEFAULT_CONSENSUS_PARAMETERS,
   ): Target {
     const parentDifficulty = previousBlockTarget.toDifficulty()
 
@@ -79,6 +81,7 @@ export class Target {
       time,
       previousBlockTimestamp,
       parentDifficulty,
+      consensus,
     )
 
     return Target.fromDifficulty(difficulty)
@@ -104,19 +107,20 @@ export class Target {
    * @param time the block's timestamp for which the target is calcualted for
    * @param previousBlockTimestamp the block's previous block header's timestamp
    * @param previousBlockTarget the block's previous block header's target
+   * @param consensus the consensus parameters of the network the block is on
    */
   static calculateDifficulty(
     time: Date,
     previousBlockTimestamp: Date,
     previousBlockDifficulty: bigint,
+    consensus: ConsensusParameters = DEFAULT_CONSENSUS_PARAMETERS,
   ): bigint {
     const diffInSeconds = (time.getTime() - previousBlockTimestamp.getTime()) / 1000
+    const blockTime = consensus.targetBlockTimeInSeconds
+    const bucketTime = consensus.targetBucketTimeInSeconds
 
     let bucket = Math.floor(
-      (diffInSeconds -
-        TARGET_BLOCK_TIME_IN_SECONDS +
-        Math.floor(TARGET_BUCKET_TIME_IN_SECONDS / 2)) /
-        TARGET_BUCKET_TIME_IN_SECONDS,
+      (diffInSeconds - blockTime + Math.floor(bucketTime / 2)) / bucketTime,
     )
 
     // Should not change difficulty by more than 99 buckets from last block's difficulty
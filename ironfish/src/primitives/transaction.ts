@@ -12,6 +12,21 @@ export type TransactionHash = Buffer
 
 export type SerializedTransaction = Buffer
 
+// The serialized sizes of the parts of a transaction, see the constructor
+const TRANSACTION_HEADER_LENGTH = 8 + 8 + 8 + 4
+const SPEND_LENGTH = 388
+const NOTE_LENGTH = 192 + 275
+const SIGNATURE_LENGTH = 64
+
+/**
+ * The serialized size in bytes of a transaction with this many spends and notes
+ */
+export function getTransactionSize(spends: number, notes: number): number {
+  return (
+    TRANSACTION_HEADER_LENGTH + spends * SPEND_LENGTH + notes * NOTE_LENGTH + SIGNATURE_LENGTH
+  )
+}
+
 export class Transaction {
   private readonly transactionPostedSerialized: Buffer
 
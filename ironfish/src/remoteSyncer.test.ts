@@ -0,0 +1,199 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { writeBlockToBuffer } from './network/utils/block'
+import { Block } from './primitives/block'
+import { RemoteSyncer } from './remoteSyncer'
+import { RpcSocketClient } from './rpc/clients/socketClient'
+import { createNodeTest, useMinerBlockFixture } from './testUtilities'
+import { flushTimeout } from './testUtilities/helpers/tests'
+
+type TestEvent = {
+  type: 'connected' | 'disconnected'
+  block: { hash: string; serialized: string }
+}
+
+/**
+ * Streams the events it is given from followChainStream until the connection
+ * is lost
+ */
+class TestClient {
+  isConnected = false
+  connects = 0
+  events: TestEvent[] = []
+
+  private onLost: (() => void) | null = null
+
+  tryConnect(): Promise<boolean> {
+    this.connects++
+    this.isConnected = true
+    return Promise.resolve(true)
+  }
+
+  close(): void {
+    this.lose()
+  }
+
+  lose(): void {
+    this.isConnected = false
+    this.onLost?.()
+  }
+
+  followChainStream() {
+    const events = this.events.splice(0)
+    const lost = new Promise<void>((resolve) => (this.onLost = resolve))
+
+    return {
+      contentStream: async function* () {
+        for (const event of events) {
+          yield event
+        }
+
+        await lost
+      },
+    }
+  }
+}
+
+async function waitFor(condition: () => boolean): Promise<void> {
+  while (!condition()) {
+    await flushTimeout()
+  }
+}
+
+describe('RemoteSyncer', () => {
+  const nodeTest = createNodeTest()
+
+  const event = (type: TestEvent['type'], block: Block): TestEvent => ({
+    type,
+    block: {
+      hash: block.header.hash.toString('hex'),
+      serialized: writeBlockToBuffer(nodeTest.strategy.blockSerde.serialize(block)).toString(
+        'hex',
+      ),
+    },
+  })
+
+  const createSyncer = (client: TestClient): RemoteSyncer =>
+    new RemoteSyncer({
+      chain: nodeTest.chain,
+      strategy: nodeTest.strategy,
+      client: client as unknown as RpcSocketClient,
+    })
+
+  it('connects to the chain node', async () => {
+    const client = new TestClient()
+    const syncer = createSyncer(client)
+    expect(syncer.isConnected).toBe(false)
+
+    syncer.start()
+    await waitFor(() => client.connects === 1)
+    expect(syncer.isConnected).toBe(true)
+
+    syncer.stop()
+    expect(syncer.isConnected).toBe(false)
+  })
+
+  it('reconnects after the chain node disconnects', async () => {
+    const client = new TestClient()
+    const syncer = createSyncer(client)
+
+    syncer.start()
+    await waitFor(() => client.connects === 1)
+
+    client.lose()
+    expect(syncer.isConnected).toBe(false)
+
+    await waitFor(() => client.connects === 2)
+    expect(syncer.isConnected).toBe(true)
+
+    syncer.stop()
+  })
+
+  it('adds the serialized blocks from the chain node', async () => {
+    const { chain: remoteChain } = await nodeTest.createSetup()
+    const block = await useMinerBlockFixture(remoteChain, 2)
+    await expect(remoteChain).toAddBlock(block)
+
+    const client = new TestClient()
+    client.events = [event('connected', block)]
+    const syncer = createSyncer(client)
+
+    syncer.start()
+    await waitFor(() => nodeTest.chain.head.hash.equals(block.header.hash))
+    expect(nodeTest.chain.head.sequence).toBe(2)
+
+    syncer.stop()
+  })
+
+  it('removes the blocks the chain node disconnects', async () => {
+    // G -> A2 -> A3
+    //         -> B3
+    // B3 may be no heavier than A3, so only removing A3 switches to it
+    const { chain: remoteChain } = await nodeTest.createSetup()
+    const { chain: forkChain } = await nodeTest.createSetup()
+
+    const blockA2 = await useMinerBlockFixture(remoteChain, 2)
+    await expect(remoteChain).toAddBlock(blockA2)
+    await expect(forkChain).toAddBlock(blockA2)
+
+    const blockA3 = await useMinerBlockFixture(remoteChain, 3)
+    const blockB3 = await useMinerBlockFixture(forkChain, 3)
+
+    const client = new TestClient()
+    client.events = [
+      event('connected', blockA2),
+      event('connected', blockA3),
+      event('disconnected', blockA3),
+      event('connected', blockB3),
+    ]
+    const syncer = createSyncer(client)
+
+    syncer.start()
+    await waitFor(() => nodeTest.chain.head.hash.equals(blockB3.header.hash))
+
+    expect(nodeTest.chain.head.sequence).toBe(3)
+    await expect(nodeTest.chain.hasBlock(blockA3.header.hash)).resolves.toBe(false)
+
+    syncer.stop()
+  })
+
+  it('converges with the chain node after it reorganizes', async () => {
+    // G -> A2 -> A3
+    //   -> B2 -> B3 -> B4
+    const { chain: remoteChain } = await nodeTest.createSetup()
+    const { chain: forkChain } = await nodeTest.createSetup()
+
+    const blockA2 = await useMinerBlockFixture(remoteChain, 2)
+    await expect(remoteChain).toAddBlock(blockA2)
+    const blockA3 = await useMinerBlockFixture(remoteChain, 3)
+    await expect(remoteChain).toAddBlock(blockA3)
+
+    const blockB2 = await useMinerBlockFixture(forkChain, 2)
+    await expect(forkChain).toAddBlock(blockB2)
+    const blockB3 = await useMinerBlockFixture(forkChain, 3)
+    await expect(forkChain).toAddBlock(blockB3)
+    const blockB4 = await useMinerBlockFixture(forkChain, 4)
+
+    const client = new TestClient()
+    client.events = [
+      event('connected', blockA2),
+      event('connected', blockA3),
+      event('disconnected', blockA3),
+      event('disconnected', blockA2),
+      event('connected', blockB2),
+      event('connected', blockB3),
+      event('connected', blockB4),
+    ]
+    const syncer = createSyncer(client)
+
+    syncer.start()
+    await waitFor(() => nodeTest.chain.head.hash.equals(blockB4.header.hash))
+
+    expect(nodeTest.chain.head.sequence).toBe(4)
+    await expect(nodeTest.chain.hasBlock(blockA2.header.hash)).resolves.toBe(false)
+    await expect(nodeTest.chain.hasBlock(blockA3.header.hash)).resolves.toBe(false)
+
+    syncer.stop()
+  })
+})
@@ -0,0 +1,170 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { Blockchain } from './blockchain'
+import { createRootLogger, Logger } from './logger'
+import { readBlockFromBuffer } from './network/utils/block'
+import { Transaction } from './primitives/transaction'
+import { RpcSocketClient } from './rpc/clients/socketClient'
+import { Strategy } from './strategy'
+import { ErrorUtils, SetTimeoutToken } from './utils'
+
+const RECONNECT_MIN_MS = 1000
+const RECONNECT_MAX_MS = 60 * 1000
+
+/**
+ * Syncs the chain from one trusted node over RPC instead of from the peer
+ * network, and sends the wallet's transactions to that node to broadcast. This
+ * lets the wallet run in its own process with its own data dir, while only the
+ * chain node is exposed to the internet. Blocks are still fully verified.
+ */
+export class RemoteSyncer {
+  readonly chain: Blockchain
+  readonly strategy: Strategy
+  readonly client: RpcSocketClient
+  readonly logger: Logger
+
+  private started = false
+  private connectWarned = false
+  private reconnectAttempts = 0
+  private reconnectTimeout: SetTimeoutToken | null = null
+
+  constructor(options: {
+    chain: Blockchain
+    strategy: Strategy
+    client: RpcSocketClient
+    logger?: Logger
+  }) {
+    this.chain = options.chain
+    this.strategy = options.strategy
+    this.client = options.client
+    this.logger = (options.logger ?? createRootLogger()).withTag('remotesyncer')
+  }
+
+  get isConnected(): boolean {
+    return this.client.isConnected
+  }
+
+  start(): void {
+    if (this.started) {
+      return
+    }
+
+    this.started = true
+    void this.follow()
+  }
+
+  stop(): void {
+    this.started = false
+    this.client.close()
+
+    if (this.reconnectTimeout) {
+      clearTimeout(this.reconnectTimeout)
+    }
+  }
+
+  async broadcastTransaction(transaction: Transaction): Promise<void> {
+    const hash = transaction.unsignedHash().toString('hex')
+
+    try {
+      await this.client.broadcastTransaction({
+        transaction: transaction.serialize().toString('hex'),
+      })
+    } catch (e: unknown) {
+      this.logger.warn(`Could not broadcast transaction ${hash}: ${ErrorUtils.renderError(e)}`)
+    }
+  }
+
+  private async follow(): Promise<void> {
+    const connected = await this.client.tryConnect()
+
+    if (!this.started) {
+      return
+    }
+
+    if (!connected) {
+      if (!this.connectWarned) {
+        this.logger.warn('Failed to connect to the chain node, retrying...')
+        this.connectWarned = true
+      }
+
+      this.scheduleReconnect()
+      return
+    }
+
+    this.connectWarned = false
+    this.reconnectAttempts = 0
+    this.logger.info('Connected to the chain node')
+
+    try {
+      const stream = this.client.followChainStream({
+        head: this.chain.head.hash.toString('hex'),
+        serialized: true,
+      })
+
+      for await (const content of stream.contentStream()) {
+        if (!this.started) {
+          return
+        }
+
+        if (content.type === 'connected' && content.block.serialized) {
+          await this.addBlock(content.block.serialized)
+        } else if (content.type === 'disconnected') {
+          await this.removeBlock(content.block.hash)
+        }
+      }
+    } catch (e: unknown) {
+      this.logger.warn(`Lost the chain node: ${ErrorUtils.renderError(e)}`)
+    }
+
+    if (this.started) {
+      this.client.close()
+      this.scheduleReconnect()
+    }
+  }
+
+  private async addBlock(serialized: string): Promise<void> {
+    const buffer = Buffer.from(serialized, 'hex')
+    const block = this.strategy.blockSerde.deserialize(readBlockFromBuffer(buffer))
+
+    if (await this.chain.hasBlock(block.header.hash)) {
+      return
+    }
+
+    const { isAdded, reason } = await this.chain.addBlock(block)
+
+    if (!isAdded) {
+      this.logger.warn(
+        `Could not add block ${block.header.hash.toString('hex')} (${
+          block.header.sequence
+        }) from the chain node: ${String(reason)}`,
+      )
+    }
+  }
+
+  /**
+   * Remove a block the chain node disconnected if it is the local head. The
+   * fork the chain node connects next may not be heavier than the block here,
+   * so the local chain would not reorganize to it by itself.
+   */
+  private async removeBlock(hash: string): Promise<void> {
+    const buffer = Buffer.from(hash, 'hex')
+
+    if (!this.chain.head.hash.equals(buffer)) {
+      return
+    }
+
+    await this.chain.removeBlock(buffer)
+  }
+
+  /**
+   * Try to connect again after a delay that doubles with every failed attempt
+   */
+  private scheduleReconnect(): void {
+    const delay = Math.min(RECONNECT_MIN_MS * 2 ** this.reconnectAttempts, RECONNECT_MAX_MS)
+    this.reconnectAttempts++
+
+    this.logger.debug(`Reconnecting to the chain node in ${delay}ms`)
+    this.reconnectTimeout = setTimeout(() => void this.follow(), delay)
+  }
+}
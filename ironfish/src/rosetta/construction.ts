@@ -0,0 +1,293 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { IronfishNode } from '../node'
+import { Transaction } from '../primitives/transaction'
+import { ErrorUtils } from '../utils'
+import { RosettaError } from './errors'
+import { AccountIdentifier, Amount, Operation, OperationType } from './types'
+import {
+  assertNetwork,
+  findAccountByAddress,
+  fromAmount,
+  getString,
+  RosettaRequest,
+  RosettaRoute,
+  toAmount,
+  toRosettaTransaction,
+} from './utils'
+
+/**
+ * What an unsigned transaction holds. The keys of an Iron Fish account are
+ * not used to sign a hash like on other chains: the spend proofs need the
+ * spending key, so the transaction is built and signed by the sender's account
+ * in the node's wallet when it is combined.
+ */
+export type TransactionIntent = {
+  sender: string
+  receives: { publicAddress: string; amount: string; memo: string }[]
+  fee: string
+  expirationSequence: number
+}
+
+function getOperations(request: RosettaRequest): Operation[] {
+  const operations = request.operations
+  if (!Array.isArray(operations) || !operations.length) {
+    throw new RosettaError('INVALID_REQUEST', 'operations must be a list of operations')
+  }
+  return operations as Operation[]
+}
+
+/**
+ * Read the sender, the receivers, and the fee from operations like the ones
+ * /construction/parse returns: one SPEND, an OUTPUT for each receiver, and an
+ * optional FEE
+ */
+function parseOperations(operations: Operation[]): {
+  sender: string
+  receives: TransactionIntent['receives']
+  fee: bigint | null
+} {
+  const spends = operations.filter((o) => o.type === OperationType.Spend)
+  if (spends.length !== 1 || !spends[0].account?.address) {
+    throw new RosettaError('INVALID_REQUEST', 'there must be one SPEND with an account')
+  }
+
+  const receives = []
+  let fee: bigint | null = null
+
+  for (const operation of operations) {
+    if (operation.type === OperationType.Output) {
+      const amount = fromAmount(operation.amount)
+      if (!operation.account?.address || amount <= BigInt(0)) {
+        throw new RosettaError('INVALID_REQUEST', 'an OUTPUT needs an account and an amount')
+      }
+
+      const memo = operation.metadata?.memo
+      receives.push({
+        publicAddress: operation.account.address,
+        amount: amount.toString(),
+        memo: typeof memo === 'string' ? memo : '',
+      })
+    } else if (operation.type === OperationType.Fee) {
+      fee = fromAmount(operation.amount)
+    } else if (operation.type !== OperationType.Spend) {
+      throw new RosettaError('INVALID_REQUEST', `${operation.type} can't be constructed`)
+    }
+  }
+
+  if (!receives.length) {
+    throw new RosettaError('INVALID_REQUEST', 'there must be at least one OUTPUT')
+  }
+
+  return { sender: spends[0].account.address, receives, fee }
+}
+
+function intentToOperations(intent: TransactionIntent): Operation[] {
+  const operations: Operation[] = []
+  const fee = BigInt(intent.fee)
+  let sent = BigInt(0)
+
+  const add = (type: string, amount: Amount, account?: AccountIdentifier, memo?: string) => {
+    operations.push({
+      operation_identifier: { index: operations.length },
+      type,
+      account,
+      amount,
+      metadata: memo ? { memo } : undefined,
+    })
+  }
+
+  add(OperationType.Fee, toAmount(fee))
+
+  for (const receive of intent.receives) {
+    sent += BigInt(receive.amount)
+    add(
+      OperationType.Output,
+      toAmount(BigInt(receive.amount)),
+      { address: receive.publicAddress },
+      receive.memo,
+    )
+  }
+
+  add(OperationType.Spend, toAmount(-(sent + fee)), { address: intent.sender })
+  return operations
+}
+
+function encodeIntent(intent: TransactionIntent): string {
+  return Buffer.from(JSON.stringify(intent), 'utf8').toString('hex')
+}
+
+function decodeIntent(unsigned: string): TransactionIntent {
+  try {
+    return JSON.parse(Buffer.from(unsigned, 'hex').toString('utf8')) as TransactionIntent
+  } catch {
+    throw new RosettaError('INVALID_TRANSACTION', 'the unsigned transaction is not valid')
+  }
+}
+
+function decodeTransaction(node: IronfishNode, signed: string): Transaction {
+  try {
+    return node.chain.verifier.verifyNewTransaction(Buffer.from(signed, 'hex'))
+  } catch (e: unknown) {
+    throw new RosettaError('INVALID_TRANSACTION', ErrorUtils.renderError(e))
+  }
+}
+
+const derive: RosettaRoute = (node, request) => {
+  assertNetwork(node, request)
+
+  // Addresses are derived from the spending key, not from a public key
+  throw new RosettaError('UNSUPPORTED', 'create accounts with accounts:create instead')
+}
+
+const preprocess: RosettaRoute = (node, request) => {
+  assertNetwork(node, request)
+
+  const { sender, fee } = parseOperations(getOperations(request))
+
+  return Promise.resolve({
+    options: { sender, fee: fee?.toString() },
+    required_public_keys: [],
+  })
+}
+
+const metadata: RosettaRoute = async (node, request) => {
+  assertNetwork(node, request)
+
+  const options = (request.options ?? {}) as { sender?: string; fee?: string }
+  if (!options.sender) {
+    throw new RosettaError('INVALID_REQUEST', 'options must come from /construction/preprocess')
+  }
+
+  findAccountByAddress(node, options.sender)
+
+  const fee = options.fee ?? (await node.feeEstimator.estimateFees()).average.toString()
+  const expirationSequence =
+    node.chain.head.sequence + node.config.get('defaultTransactionExpirationSequenceDelta')
+
+  return {
+    metadata: { fee, expirationSequence },
+    suggested_fee: [toAmount(BigInt(fee))],
+  }
+}
+
+const payloads: RosettaRoute = (node, request) => {
+  assertNetwork(node, request)
+
+  const { sender, receives, fee } = parseOperations(getOperations(request))
+  const meta = (request.metadata ?? {}) as { fee?: string; expirationSequence?: number }
+
+  if (meta.fee === undefined || meta.expirationSequence === undefined) {
+    throw new RosettaError('INVALID_REQUEST', 'metadata must come from /construction/metadata')
+  }
+
+  findAccountByAddress(node, sender)
+
+  const intent: TransactionIntent = {
+    sender,
+    receives,
+    fee: (fee ?? BigInt(meta.fee)).toString(),
+    expirationSequence: meta.expirationSequence,
+  }
+
+  // Nothing is signed outside of the node, see TransactionIntent
+  return Promise.resolve({ unsigned_transaction: encodeIntent(intent), payloads: [] })
+}
+
+const combine: RosettaRoute = async (node, request) => {
+  assertNetwork(node, request)
+
+  const intent = decodeIntent(getString(request, 'unsigned_transaction'))
+  const account = findAccountByAddress(node, intent.sender)
+
+  const head = node.chain.head
+  if (node.chain.verifier.isExpiredSequence(intent.expirationSequence, head.sequence)) {
+    throw new RosettaError('INVALID_TRANSACTION', 'the transaction has expired')
+  }
+
+  let transaction
+  try {
+    transaction = await node.accounts.createTransaction(
+      account,
+      intent.receives.map((r) => ({ ...r, amount: BigInt(r.amount) })),
+      BigInt(intent.fee),
+      intent.expirationSequence,
+    )
+  } catch (e: unknown) {
+    throw new RosettaError('INVALID_TRANSACTION', ErrorUtils.renderError(e))
+  }
+
+  return { signed_transaction: transaction.serialize().toString('hex') }
+}
+
+const parse: RosettaRoute = (node, request) => {
+  assertNetwork(node, request)
+
+  const serialized = getString(request, 'transaction')
+
+  if (!request.signed) {
+    return Promise.resolve({
+      operations: intentToOperations(decodeIntent(serialized)),
+      account_identifier_signers: [],
+    })
+  }
+
+  const transaction = decodeTransaction(node, serialized)
+  const { operations } = toRosettaTransaction(node.accounts.listAccounts(), transaction)
+
+  return Promise.resolve({
+    operations,
+    account_identifier_signers: operations
+      .filter((o) => o.type === OperationType.Spend && o.account)
+      .map((o) => o.account),
+  })
+}
+
+const hash: RosettaRoute = (node, request) => {
+  assertNetwork(node, request)
+
+  const transaction = decodeTransaction(node, getString(request, 'signed_transaction'))
+
+  return Promise.resolve({
+    transaction_identifier: { hash: transaction.hash().toString('hex') },
+  })
+}
+
+const submit: RosettaRoute = async (node, request) => {
+  assertNetwork(node, request)
+
+  const transaction = decodeTransaction(node, getString(request, 'signed_transaction'))
+  const transactionHash = transaction.hash().toString('hex')
+
+  // Submitting the same transaction twice is not an error
+  if (!node.memPool.exists(transaction.hash())) {
+    const { valid, reason } = await node.chain.verifier.verifyTransactionAdd(transaction)
+    if (!valid) {
+      throw new RosettaError('SUBMIT_FAILED', { reason })
+    }
+
+    await node.accounts.syncTransaction(transaction, {
+      submittedSequence: node.chain.head.sequence,
+    })
+
+    if (!(await node.memPool.acceptTransaction(transaction, false))) {
+      throw new RosettaError('SUBMIT_FAILED', 'the mempool did not accept the transaction')
+    }
+
+    node.accounts.broadcastTransaction(transaction)
+  }
+
+  return { transaction_identifier: { hash: transactionHash } }
+}
+
+export const ConstructionRoutes: Record<string, RosettaRoute> = {
+  '/construction/derive': derive,
+  '/construction/preprocess': preprocess,
+  '/construction/metadata': metadata,
+  '/construction/payloads': payloads,
+  '/construction/combine': combine,
+  '/construction/parse': parse,
+  '/construction/hash': hash,
+  '/construction/submit': submit,
+}
@@ -0,0 +1,208 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { IronfishNode } from '../node'
+import { BlockHeader } from '../primitives/blockheader'
+import { ROSETTA_ERRORS, RosettaError } from './errors'
+import {
+  BlockIdentifier,
+  OPERATION_STATUS_SUCCESS,
+  OperationType,
+  PartialBlockIdentifier,
+  ROSETTA_VERSION,
+  RosettaBlock,
+} from './types'
+import {
+  assertNetwork,
+  findAccountByAddress,
+  getNetworkIdentifier,
+  getObject,
+  getString,
+  RosettaRequest,
+  RosettaRoute,
+  toAmount,
+  toBlockIdentifier,
+  toRosettaTransaction,
+} from './utils'
+
+async function getHeader(
+  node: IronfishNode,
+  identifier: PartialBlockIdentifier | undefined,
+): Promise<BlockHeader> {
+  let header: BlockHeader | null = null
+
+  if (identifier?.hash) {
+    header = await node.chain.getHeader(Buffer.from(identifier.hash, 'hex'))
+
+    // Only blocks on the main chain are returned, forks are not part of the history
+    if (header && !(await node.chain.isHeadChain(header))) {
+      header = null
+    }
+  } else if (identifier?.index !== undefined) {
+    header = await node.chain.getHeaderAtSequence(identifier.index)
+  } else {
+    header = node.chain.head
+  }
+
+  if (!header || (identifier?.index !== undefined && header.sequence !== identifier.index)) {
+    throw new RosettaError('BLOCK_NOT_FOUND', identifier)
+  }
+
+  return header
+}
+
+const networkList: RosettaRoute = (node) => {
+  return Promise.resolve({ network_identifiers: [getNetworkIdentifier(node)] })
+}
+
+const networkOptions: RosettaRoute = (node, request) => {
+  assertNetwork(node, request)
+
+  return Promise.resolve({
+    version: {
+      rosetta_version: ROSETTA_VERSION,
+      node_version: node.pkg.version,
+      metadata: { git: node.pkg.git },
+    },
+    allow: {
+      operation_statuses: [{ status: OPERATION_STATUS_SUCCESS, successful: true }],
+      operation_types: Object.values(OperationType),
+      errors: Object.values(ROSETTA_ERRORS),
+      historical_balance_lookup: false,
+      mempool_coins: false,
+    },
+  })
+}
+
+const networkStatus: RosettaRoute = (node, request) => {
+  assertNetwork(node, request)
+
+  const head = node.chain.head
+
+  return Promise.resolve({
+    current_block_identifier: toBlockIdentifier(head),
+    current_block_timestamp: head.timestamp.getTime(),
+    genesis_block_identifier: toBlockIdentifier(node.chain.genesis),
+    sync_status: {
+      current_index: head.sequence,
+      target_index: Math.max(head.sequence, node.chain.latest.sequence),
+      stage: node.syncer.state,
+      synced: node.chain.synced,
+    },
+    peers: node.peerNetwork.peerManager
+      .getConnectedPeers()
+      .map((peer) => ({ peer_id: peer.displayName })),
+  })
+}
+
+const block: RosettaRoute = async (node, request) => {
+  assertNetwork(node, request)
+
+  const header = await getHeader(node, getObject(request, 'block_identifier'))
+  const found = await node.chain.getBlock(header)
+  if (!found) {
+    throw new RosettaError('BLOCK_NOT_FOUND', toBlockIdentifier(header))
+  }
+
+  const accounts = node.accounts.listAccounts()
+
+  // The genesis block is its own parent in Rosetta
+  const parent =
+    header.sequence === node.chain.genesis.sequence
+      ? header
+      : await node.chain.getPrevious(header)
+
+  if (!parent) {
+    throw new RosettaError('BLOCK_NOT_FOUND', 'the parent of the block is missing')
+  }
+
+  const result: RosettaBlock = {
+    block_identifier: toBlockIdentifier(header),
+    parent_block_identifier: toBlockIdentifier(parent),
+    timestamp: header.timestamp.getTime(),
+    transactions: found.transactions.map((transaction) =>
+      toRosettaTransaction(accounts, transaction, OPERATION_STATUS_SUCCESS),
+    ),
+  }
+
+  return { block: result }
+}
+
+const blockTransaction: RosettaRoute = async (node, request) => {
+  assertNetwork(node, request)
+
+  const identifier = getObject<BlockIdentifier>(request, 'block_identifier')
+  const hash = getString(getObject(request, 'transaction_identifier'), 'hash')
+
+  const header = await getHeader(node, identifier)
+  const found = await node.chain.getBlock(header)
+  const transaction = found?.transactions.find((t) => t.hash().toString('hex') === hash)
+
+  if (!transaction) {
+    throw new RosettaError('TRANSACTION_NOT_FOUND', { hash })
+  }
+
+  return {
+    transaction: toRosettaTransaction(
+      node.accounts.listAccounts(),
+      transaction,
+      OPERATION_STATUS_SUCCESS,
+    ),
+  }
+}
+
+const accountBalance: RosettaRoute = async (node, request) => {
+  assertNetwork(node, request)
+
+  if (request.block_identifier) {
+    throw new RosettaError('UNSUPPORTED', 'balances are only available at the head')
+  }
+
+  const address = getString(getObject(request, 'account_identifier'), 'address')
+  const account = findAccountByAddress(node, address)
+  const head = node.chain.head
+  const balance = await node.accounts.getBalance(account)
+
+  return {
+    block_identifier: toBlockIdentifier(head),
+    balances: [toAmount(BigInt(balance.confirmed.toString()))],
+    metadata: { unconfirmed: balance.unconfirmed.toString() },
+  }
+}
+
+const mempool: RosettaRoute = (node, request) => {
+  assertNetwork(node, request)
+
+  const transactionIdentifiers = []
+  for (const transaction of node.memPool.orderedTransactions()) {
+    transactionIdentifiers.push({ hash: transaction.hash().toString('hex') })
+  }
+
+  return Promise.resolve({ transaction_identifiers: transactionIdentifiers })
+}
+
+const mempoolTransaction: RosettaRoute = (node, request: RosettaRequest) => {
+  assertNetwork(node, request)
+
+  const hash = getString(getObject(request, 'transaction_identifier'), 'hash')
+  const transaction = node.memPool.get(Buffer.from(hash, 'hex'))
+
+  if (!transaction) {
+    throw new RosettaError('TRANSACTION_NOT_FOUND', { hash })
+  }
+
+  return Promise.resolve({
+    transaction: toRosettaTransaction(node.accounts.listAccounts(), transaction),
+  })
+}
+
+export const DataRoutes: Record<string, RosettaRoute> = {
+  '/network/list': networkList,
+  '/network/options': networkOptions,
+  '/network/status': networkStatus,
+  '/block': block,
+  '/block/transaction': blockTransaction,
+  '/account/balance': accountBalance,
+  '/mempool': mempool,
+  '/mempool/transaction': mempoolTransaction,
+}
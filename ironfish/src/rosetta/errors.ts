@@ -0,0 +1,45 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { RosettaErrorResponse } from './types'
+
+/**
+ * Every error the Rosetta API can return, listed by /network/options
+ */
+export const ROSETTA_ERRORS = {
+  INVALID_REQUEST: { code: 1, message: 'Invalid request', retriable: false },
+  INVALID_NETWORK: { code: 2, message: 'Invalid network identifier', retriable: false },
+  NOT_READY: { code: 3, message: 'The node is not ready', retriable: true },
+  BLOCK_NOT_FOUND: { code: 4, message: 'Block not found', retriable: true },
+  TRANSACTION_NOT_FOUND: { code: 5, message: 'Transaction not found', retriable: true },
+  ACCOUNT_NOT_FOUND: {
+    code: 6,
+    message: 'Account not found, only accounts in the node wallet are supported',
+    retriable: false,
+  },
+  UNSUPPORTED: { code: 7, message: 'Not supported by this node', retriable: false },
+  INVALID_TRANSACTION: { code: 8, message: 'Invalid transaction', retriable: false },
+  SUBMIT_FAILED: { code: 9, message: 'The transaction was not accepted', retriable: false },
+  INTERNAL: { code: 10, message: 'Internal error', retriable: true },
+} as const
+
+export type RosettaErrorType = keyof typeof ROSETTA_ERRORS
+
+export class RosettaError extends Error {
+  readonly type: RosettaErrorType
+  readonly details: Record<string, unknown> | undefined
+
+  constructor(type: RosettaErrorType, details?: string | Record<string, unknown>) {
+    super(
+      typeof details === 'string'
+        ? `${ROSETTA_ERRORS[type].message}: ${details}`
+        : ROSETTA_ERRORS[type].message,
+    )
+    this.type = type
+    this.details = typeof details === 'string' ? { message: details } : details
+  }
+
+  toResponse(): RosettaErrorResponse {
+    return { ...ROSETTA_ERRORS[this.type], details: this.details }
+  }
+}
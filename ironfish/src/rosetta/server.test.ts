@@ -0,0 +1,152 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import '../testUtilities/matchers'
+import { createNodeTest, useAccountFixture, useMinerBlockFixture } from '../testUtilities'
+import { ROSETTA_ERRORS } from './errors'
+import { RosettaServer } from './server'
+import { OperationType, ROSETTA_BLOCKCHAIN, ROSETTA_CURRENCY } from './types'
+
+describe('RosettaServer', () => {
+  const nodeTest = createNodeTest()
+
+  function createServer(): RosettaServer {
+    return new RosettaServer({ node: nodeTest.node, host: 'localhost', port: 0 })
+  }
+
+  function network() {
+    return { blockchain: ROSETTA_BLOCKCHAIN, network: nodeTest.chain.network.id }
+  }
+
+  it('lists the network and rejects other networks', async () => {
+    const server = createServer()
+
+    const list = await server.handle('/network/list', {})
+    expect(list).toEqual({ status: 200, body: { network_identifiers: [network()] } })
+
+    const status = await server.handle('/network/status', {
+      network_identifier: { blockchain: ROSETTA_BLOCKCHAIN, network: 'other' },
+    })
+    expect(status.status).toBe(500)
+    expect(status.body).toMatchObject({ code: ROSETTA_ERRORS.INVALID_NETWORK.code })
+
+    const missing = await server.handle('/nope', {})
+    expect(missing.status).toBe(404)
+  })
+
+  it('returns blocks with the rewards of wallet accounts', async () => {
+    const { node, chain } = nodeTest
+    const server = createServer()
+    const account = await useAccountFixture(node.accounts, 'rosetta')
+
+    const block = await useMinerBlockFixture(chain, 2, account, node.accounts)
+    await expect(chain).toAddBlock(block)
+
+    const { status, body } = await server.handle('/block', {
+      network_identifier: network(),
+      block_identifier: { index: 2 },
+    })
+
+    expect(status).toBe(200)
+    expect(body).toMatchObject({
+      block: {
+        block_identifier: { index: 2, hash: block.header.hash.toString('hex') },
+        parent_block_identifier: { index: 1, hash: chain.genesis.hash.toString('hex') },
+        transactions: [
+          {
+            transaction_identifier: { hash: block.minersFee.hash().toString('hex') },
+            operations: [
+              {
+                type: OperationType.Reward,
+                account: { address: account.publicAddress },
+                amount: { value: (-block.minersFee.fee()).toString() },
+              },
+            ],
+          },
+        ],
+      },
+    })
+
+    const notFound = await server.handle('/block', {
+      network_identifier: network(),
+      block_identifier: { index: 3 },
+    })
+    expect(notFound.body).toMatchObject({ code: ROSETTA_ERRORS.BLOCK_NOT_FOUND.code })
+  })
+
+  it('returns the balance of wallet accounts only', async () => {
+    const server = createServer()
+    const account = await useAccountFixture(nodeTest.accounts, 'rosetta')
+
+    const balance = await server.handle('/account/balance', {
+      network_identifier: network(),
+      account_identifier: { address: account.publicAddress },
+    })
+
+    expect(balance.body).toMatchObject({
+      block_identifier: { index: nodeTest.chain.head.sequence },
+      balances: [{ value: '0', currency: ROSETTA_CURRENCY }],
+    })
+
+    const unknown = await server.handle('/account/balance', {
+      network_identifier: network(),
+      account_identifier: { address: 'unknown' },
+    })
+    expect(unknown.body).toMatchObject({ code: ROSETTA_ERRORS.ACCOUNT_NOT_FOUND.code })
+  })
+
+  it('parses the unsigned transactions it builds', async () => {
+    const server = createServer()
+    const sender = await useAccountFixture(nodeTest.accounts, 'sender')
+    const receiver = await useAccountFixture(nodeTest.accounts, 'receiver')
+
+    const operations = [
+      {
+        operation_identifier: { index: 0 },
+        type: OperationType.Spend,
+        account: { address: sender.publicAddress },
+      },
+      {
+        operation_identifier: { index: 1 },
+        type: OperationType.Output,
+        account: { address: receiver.publicAddress },
+        amount: { value: '10', currency: ROSETTA_CURRENCY },
+      },
+    ]
+
+    const preprocess = await server.handle('/construction/preprocess', {
+      network_identifier: network(),
+      operations,
+    })
+    expect(preprocess.body).toMatchObject({ options: { sender: sender.publicAddress } })
+
+    const payloads = await server.handle('/construction/payloads', {
+      network_identifier: network(),
+      operations,
+      metadata: { fee: '1', expirationSequence: 100 },
+    })
+    const { unsigned_transaction } = payloads.body as { unsigned_transaction: string }
+
+    const parsed = await server.handle('/construction/parse', {
+      network_identifier: network(),
+      signed: false,
+      transaction: unsigned_transaction,
+    })
+
+    expect(parsed.body).toMatchObject({
+      operations: [
+        { type: OperationType.Fee, amount: { value: '1' } },
+        {
+          type: OperationType.Output,
+          account: { address: receiver.publicAddress },
+          amount: { value: '10' },
+        },
+        {
+          type: OperationType.Spend,
+          account: { address: sender.publicAddress },
+          amount: { value: '-11' },
+        },
+      ],
+    })
+  })
+})
@@ -0,0 +1,153 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import http from 'http'
+import { createRootLogger, Logger } from '../logger'
+import { IronfishNode } from '../node'
+import { ErrorUtils } from '../utils'
+import { ConstructionRoutes } from './construction'
+import { DataRoutes } from './data'
+import { RosettaError } from './errors'
+import { RosettaRequest, RosettaRoute } from './utils'
+
+const MAX_REQUEST_BYTES = 10 * 1024 * 1024
+
+/**
+ * Serves the Rosetta Data and Construction APIs over HTTP so exchanges can
+ * integrate with the node using standard Rosetta tooling. Every endpoint is a
+ * JSON POST, see https://www.rosetta-api.org/docs/Reference.html
+ *
+ * Only accounts in the node's wallet have balances and can send transactions,
+ * because notes can't be read, or spent, without the account's keys.
+ */
+export class RosettaServer {
+  readonly node: IronfishNode
+  readonly host: string
+  readonly port: number
+  readonly logger: Logger
+  readonly routes: Record<string, RosettaRoute>
+
+  server: http.Server | null = null
+
+  constructor(options: { node: IronfishNode; host: string; port: number; logger?: Logger }) {
+    this.node = options.node
+    this.host = options.host
+    this.port = options.port
+    this.logger = (options.logger ?? createRootLogger()).withTag('rosetta')
+    this.routes = { ...DataRoutes, ...ConstructionRoutes }
+  }
+
+  get started(): boolean {
+    return this.server !== null
+  }
+
+  async start(): Promise<void> {
+    if (this.server) {
+      return
+    }
+
+    const server = http.createServer((req, res) => void this.onRequest(req, res))
+    this.server = server
+
+    await new Promise<void>((resolve, reject) => {
+      server.once('error', reject)
+      server.listen(this.port, this.host, () => {
+        server.off('error', reject)
+        resolve()
+      })
+    })
+
+    this.logger.info(`Rosetta API listening on http://${this.host}:${this.port}`)
+  }
+
+  async stop(): Promise<void> {
+    const server = this.server
+    if (!server) {
+      return
+    }
+
+    this.server = null
+    await new Promise<void>((resolve) => server.close(() => resolve()))
+  }
+
+  /**
+   * Run the route at `path` and return its response, or the Rosetta error
+   * with the HTTP status to send it with
+   */
+  async handle(
+    path: string,
+    request: RosettaRequest,
+  ): Promise<{ status: number; body: unknown }> {
+    const route = this.routes[path]
+    if (!route) {
+      return { status: 404, body: new RosettaError('UNSUPPORTED', { path }).toResponse() }
+    }
+
+    try {
+      return { status: 200, body: await route(this.node, request) }
+    } catch (e: unknown) {
+      if (e instanceof RosettaError) {
+        return { status: 500, body: e.toResponse() }
+      }
+
+      this.logger.error(`Error in Rosetta ${path}: ${ErrorUtils.renderError(e, true)}`)
+      const error = new RosettaError('INTERNAL', ErrorUtils.renderError(e))
+      return { status: 500, body: error.toResponse() }
+    }
+  }
+
+  private async onRequest(req: http.IncomingMessage, res: http.ServerResponse): Promise<void> {
+    const send = (status: number, body: unknown) => {
+      res.writeHead(status, { 'Content-Type': 'application/json' })
+      res.end(JSON.stringify(body))
+    }
+
+    if (req.method !== 'POST') {
+      send(405, new RosettaError('INVALID_REQUEST', 'requests must be POST').toResponse())
+      return
+    }
+
+    let request: RosettaRequest
+    try {
+      request = await this.readBody(req)
+    } catch (e: unknown) {
+      const error = new RosettaError('INVALID_REQUEST', ErrorUtils.renderError(e))
+      send(400, error.toResponse())
+      return
+    }
+
+    const { status, body } = await this.handle(req.url?.split('?')[0] ?? '', request)
+    send(status, body)
+  }
+
+  private readBody(req: http.IncomingMessage): Promise<RosettaRequest> {
+    return new Promise((resolve, reject) => {
+      const chunks: Buffer[] = []
+      let size = 0
+
+      req.on('data', (chunk: Buffer) => {
+        size += chunk.byteLength
+        if (size > MAX_REQUEST_BYTES) {
+          reject(new Error('the request is too large'))
+          req.destroy()
+          return
+        }
+        chunks.push(chunk)
+      })
+
+      req.on('end', () => {
+        try {
+          const body = JSON.parse(Buffer.concat(chunks).toString('utf8') || '{}') as unknown
+          if (typeof body !== 'object' || body === null || Array.isArray(body)) {
+            throw new Error('the request must be a JSON object')
+          }
+          resolve(body as RosettaRequest)
+        } catch (e: unknown) {
+          reject(e)
+        }
+      })
+
+      req.on('error', reject)
+    })
+  }
+}
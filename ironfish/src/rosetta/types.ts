@@ -0,0 +1,100 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+/**
+ * The objects of the Rosetta API used by this node, see
+ * https://www.rosetta-api.org/docs/api_objects.html
+ */
+
+export const ROSETTA_VERSION = '1.4.13'
+export const ROSETTA_BLOCKCHAIN = 'Iron Fish'
+
+export const ROSETTA_CURRENCY: Currency = { symbol: 'IRON', decimals: 8 }
+
+export enum OperationType {
+  /** The amount an account spent, including the fee */
+  Spend = 'SPEND',
+  /** A note an account received */
+  Output = 'OUTPUT',
+  /** The fee paid by a transaction, which has no account */
+  Fee = 'FEE',
+  /** The miners fee note of a block */
+  Reward = 'REWARD',
+}
+
+export const OPERATION_STATUS_SUCCESS = 'SUCCESS'
+
+export type NetworkIdentifier = {
+  blockchain: string
+  network: string
+}
+
+export type BlockIdentifier = {
+  index: number
+  hash: string
+}
+
+export type PartialBlockIdentifier = {
+  index?: number
+  hash?: string
+}
+
+export type TransactionIdentifier = {
+  hash: string
+}
+
+export type AccountIdentifier = {
+  address: string
+}
+
+export type Currency = {
+  symbol: string
+  decimals: number
+}
+
+export type Amount = {
+  value: string
+  currency: Currency
+}
+
+export type Operation = {
+  operation_identifier: { index: number }
+  type: string
+  status?: string
+  account?: AccountIdentifier
+  amount?: Amount
+  metadata?: Record<string, unknown>
+}
+
+export type RosettaTransaction = {
+  transaction_identifier: TransactionIdentifier
+  operations: Operation[]
+  metadata?: Record<string, unknown>
+}
+
+export type RosettaBlock = {
+  block_identifier: BlockIdentifier
+  parent_block_identifier: BlockIdentifier
+  timestamp: number
+  transactions: RosettaTransaction[]
+}
+
+export type SigningPayload = {
+  account_identifier: AccountIdentifier
+  hex_bytes: string
+  signature_type: string
+}
+
+export type Signature = {
+  signing_payload: SigningPayload
+  hex_bytes: string
+  signature_type: string
+}
+
+export type RosettaErrorResponse = {
+  code: number
+  message: string
+  retriable: boolean
+  details?: Record<string, unknown>
+}
@@ -0,0 +1,153 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { Account } from '../account'
+import { IronfishNode } from '../node'
+import { BlockHeader } from '../primitives/blockheader'
+import { Transaction } from '../primitives/transaction'
+import { RosettaError } from './errors'
+import {
+  Amount,
+  BlockIdentifier,
+  NetworkIdentifier,
+  Operation,
+  OperationType,
+  ROSETTA_BLOCKCHAIN,
+  ROSETTA_CURRENCY,
+  RosettaTransaction,
+} from './types'
+
+export type RosettaRequest = Record<string, unknown>
+
+export type RosettaRoute = (node: IronfishNode, request: RosettaRequest) => Promise<unknown>
+
+export function getNetworkIdentifier(node: IronfishNode): NetworkIdentifier {
+  return { blockchain: ROSETTA_BLOCKCHAIN, network: node.chain.network.id }
+}
+
+/**
+ * Throw unless the request is for the network this node is on
+ */
+export function assertNetwork(node: IronfishNode, request: RosettaRequest): void {
+  const network = request.network_identifier as Partial<NetworkIdentifier> | undefined
+  const expected = getNetworkIdentifier(node)
+
+  if (network?.blockchain !== expected.blockchain || network?.network !== expected.network) {
+    throw new RosettaError('INVALID_NETWORK', { expected })
+  }
+}
+
+export function getObject<T extends Record<string, unknown>>(
+  request: RosettaRequest,
+  field: string,
+): T {
+  const value = request[field]
+  if (typeof value !== 'object' || value === null || Array.isArray(value)) {
+    throw new RosettaError('INVALID_REQUEST', `${field} must be an object`)
+  }
+  return value as T
+}
+
+export function getString(request: RosettaRequest, field: string): string {
+  const value = request[field]
+  if (typeof value !== 'string' || !value) {
+    throw new RosettaError('INVALID_REQUEST', `${field} must be a string`)
+  }
+  return value
+}
+
+export function toAmount(ore: bigint): Amount {
+  return { value: ore.toString(), currency: ROSETTA_CURRENCY }
+}
+
+/**
+ * Parse an amount in ore, throwing if it's not an amount of IRON
+ */
+export function fromAmount(amount: Partial<Amount> | undefined): bigint {
+  if (amount?.currency?.symbol !== ROSETTA_CURRENCY.symbol) {
+    throw new RosettaError('INVALID_REQUEST', `amounts must be in ${ROSETTA_CURRENCY.symbol}`)
+  }
+
+  try {
+    return BigInt(amount.value ?? '')
+  } catch {
+    throw new RosettaError('INVALID_REQUEST', `${String(amount.value)} is not an amount`)
+  }
+}
+
+export function toBlockIdentifier(header: BlockHeader): BlockIdentifier {
+  return { index: header.sequence, hash: header.hash.toString('hex') }
+}
+
+/**
+ * Convert a transaction to Rosetta operations. Notes are encrypted, so only
+ * the notes the node's wallet accounts can decrypt become operations, and the
+ * fee is the only operation of a transaction between other accounts.
+ *
+ * A wallet account that created the transaction gets a SPEND of everything it
+ * sent plus the fee, and receives its change as an OUTPUT, so the operations
+ * add up to the change in its balance.
+ */
+export function toRosettaTransaction(
+  accounts: ReadonlyArray<Account>,
+  transaction: Transaction,
+  status?: string,
+): RosettaTransaction {
+  const operations: Operation[] = []
+  const isMinersFee = transaction.isMinersFee()
+  const fee = transaction.fee()
+
+  const add = (operation: Omit<Operation, 'operation_identifier'>) => {
+    operations.push({
+      operation_identifier: { index: operations.length },
+      status,
+      ...operation,
+    })
+  }
+
+  if (!isMinersFee) {
+    add({ type: OperationType.Fee, amount: toAmount(fee) })
+  }
+
+  for (const account of accounts) {
+    const address = { address: account.publicAddress }
+    let sent = BigInt(0)
+
+    for (const note of transaction.notes()) {
+      const received = note.decryptNoteForOwner(account.incomingViewKey)
+      if (received) {
+        const type = isMinersFee ? OperationType.Reward : OperationType.Output
+        add({ type, account: address, amount: toAmount(received.value()) })
+      }
+
+      // The miner creates the miners fee note, but it doesn't spend anything
+      if (!isMinersFee) {
+        const spent = note.decryptNoteForSpender(account.outgoingViewKey)
+        sent += spent?.value() ?? BigInt(0)
+      }
+    }
+
+    if (sent > BigInt(0)) {
+      add({ type: OperationType.Spend, account: address, amount: toAmount(-(sent + fee)) })
+    }
+  }
+
+  return {
+    transaction_identifier: { hash: transaction.hash().toString('hex') },
+    operations,
+    metadata: {
+      size: transaction.serialize().byteLength,
+      spends: transaction.spendsLength(),
+      notes: transaction.notesLength(),
+      expirationSequence: transaction.expirationSequence(),
+    },
+  }
+}
+
+export function findAccountByAddress(node: IronfishNode, address: string): Account {
+  const account = node.accounts.listAccounts().find((a) => a.publicAddress === address)
+  if (!account) {
+    throw new RosettaError('ACCOUNT_NOT_FOUND', { address })
+  }
+  return account
+}
@@ -2,13 +2,37 @@
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 
-/** All the known error codes for APIs that can be sent back from all APIs */
+/**
+ * All the known error codes for APIs that can be sent back from all APIs.
+ * Every error response has one of these as its `code`, so clients should
+ * branch on the code rather than the message, which is meant for people and
+ * may change. The values are stable and won't be renamed.
+ */
 export enum ERROR_CODES {
+  /** An account with the requested name already exists */
   ACCOUNT_EXISTS = 'account-exists',
+  /** No account has the requested name */
+  ACCOUNT_NOT_FOUND = 'account-not-found',
+  /** Any error without a more specific code */
   ERROR = 'error',
+  /** The transaction's expiration sequence has already passed */
+  EXPIRED = 'expired',
+  /** The account doesn't have enough spendable funds */
+  INSUFFICIENT_BALANCE = 'insufficient-balance',
+  /** The resource is held by another operation, like a rescan that is running */
+  LOCKED = 'locked',
+  /** The node isn't connected to the network */
+  NOT_CONNECTED = 'not-connected',
+  /** The node's chain isn't synced with the network yet */
+  NOT_SYNCED = 'not-synced',
+  /** No route is registered at the requested path */
   ROUTE_NOT_FOUND = 'route-not-found',
+  /** Too many requests were made, try again later */
+  THROTTLED = 'throttled',
+  /** The request is missing or has an invalid auth token */
+  UNAUTHENTICATED = 'unauthenticated',
+  /** The request is malformed or has invalid parameters */
   VALIDATION = 'validation',
-  INSUFFICIENT_BALANCE = 'insufficient-balance',
 }
 
 /**
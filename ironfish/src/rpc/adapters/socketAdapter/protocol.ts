@@ -18,6 +18,7 @@ export type ServerSocketRpc = {
 export type SocketRpcRequest = {
   mid: number
   type: string
+  auth?: string
   data: unknown | undefined
 }
 
@@ -45,6 +46,7 @@ export const ClientSocketRpcSchema: yup.ObjectSchema<ClientSocketRpc> = yup
       .object({
         mid: yup.number().required(),
         type: yup.string().required(),
+        auth: yup.string().optional(),
         data: yup.mixed().notRequired(),
       })
       .required(),
@@ -72,6 +74,7 @@ export const SocketRpcRequestSchema: yup.ObjectSchema<SocketRpcRequest> = yup
   .object({
     mid: yup.number().required(),
     type: yup.string().required(),
+    auth: yup.string().optional(),
     data: yup.mixed().notRequired(),
   })
   .required()
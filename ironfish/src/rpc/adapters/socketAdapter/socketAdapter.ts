@@ -1,6 +1,7 @@
 /* This Source Code Form is subject to the terms of the Mozilla Public
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { timingSafeEqual } from 'crypto'
 import net from 'net'
 import { v4 as uuid } from 'uuid'
 import { createRootLogger, Logger } from '../../../logger'
@@ -33,8 +34,16 @@ export abstract class RpcSocketAdapter implements IRpcAdapter {
   host: string
   port: number
   server: net.Server | null = null
+  rpcServer: RpcServer | null = null
   router: Router | null = null
   namespaces: ApiNamespace[]
+  // Serve only the routes in READ_ONLY_ROUTES
+  readOnly = false
+  // The most clients connected at once, and from one address, 0 for no limit
+  maxConnections = 0
+  maxConnectionsPerIp = 0
+  // Close clients without requests after this many ms without traffic, 0 to never
+  idleTimeoutMs = 0
 
   started = false
   clients = new Map<string, SocketClient>()
@@ -116,7 +125,27 @@ export abstract class RpcSocketAdapter implements IRpcAdapter {
   }
 
   attach(server: RpcServer): void {
-    this.router = server.getRouter(this.namespaces)
+    this.rpcServer = server
+    const router = server.getRouter(this.namespaces)
+    this.router = this.readOnly ? router.readOnly() : router
+  }
+
+  /**
+   * Returns true if the request was sent with the token in config "rpcAuthToken",
+   * or if no token is configured
+   */
+  isAuthenticated(auth: string | undefined): boolean {
+    const token = this.rpcServer?.node.config.get('rpcAuthToken')
+
+    if (!token) {
+      return true
+    }
+
+    if (auth === undefined || auth.length !== token.length) {
+      return false
+    }
+
+    return timingSafeEqual(Buffer.from(auth), Buffer.from(token))
   }
 
   async waitForAllToDisconnect(): Promise<void> {
@@ -132,7 +161,39 @@ export abstract class RpcSocketAdapter implements IRpcAdapter {
     })
   }
 
+  /**
+   * Returns a reason to refuse a new connection from `address`, so a client
+   * that leaks connections can't use up the file descriptors of the node
+   */
+  shouldRejectConnection(address: string | undefined): string | null {
+    if (this.maxConnections > 0 && this.clients.size >= this.maxConnections) {
+      return `the adapter has ${this.maxConnections} connections`
+    }
+
+    if (this.maxConnectionsPerIp > 0 && address !== undefined) {
+      let connections = 0
+      for (const client of this.clients.values()) {
+        if (client.socket.remoteAddress === address) {
+          connections++
+        }
+      }
+
+      if (connections >= this.maxConnectionsPerIp) {
+        return `${address} has ${this.maxConnectionsPerIp} connections`
+      }
+    }
+
+    return null
+  }
+
   onClientConnection(socket: net.Socket): void {
+    const rejectReason = this.shouldRejectConnection(socket.remoteAddress)
+    if (rejectReason) {
+      this.logger.debug(`Rejected client connection because ${rejectReason}`)
+      socket.destroy()
+      return
+    }
+
     const requests = new Map<string, RpcRequest>()
     const client = { socket, requests, id: uuid(), messageBuffer: new MessageBuffer() }
     this.clients.set(client.id, client)
@@ -150,6 +211,22 @@ export abstract class RpcSocketAdapter implements IRpcAdapter {
     socket.on('error', (error: Error) => {
       this.onClientError(client, error)
     })
+
+    if (this.idleTimeoutMs > 0) {
+      socket.setTimeout(this.idleTimeoutMs)
+
+      socket.on('timeout', () => {
+        // Streams like followChain can go quiet for a while, so only close
+        // clients that aren't waiting on a response
+        if (client.requests.size > 0) {
+          socket.setTimeout(this.idleTimeoutMs)
+          return
+        }
+
+        this.logger.debug(`Closing idle client connection: ${this.host}:${this.port}`)
+        socket.destroy()
+      })
+    }
   }
 
   onClientDisconnection(client: SocketClient): void {
@@ -182,6 +259,11 @@ export abstract class RpcSocketAdapter implements IRpcAdapter {
 
       const message = result.result.data
 
+      if (!this.isAuthenticated(message.auth)) {
+        this.emitResponse(client, this.constructUnauthenticated(message.mid))
+        continue
+      }
+
       const requestId = uuid()
       const request = new RpcRequest(
         message.data,
@@ -213,7 +295,13 @@ export abstract class RpcSocketAdapter implements IRpcAdapter {
           return
         }
 
-        throw error
+        // Clients branch on the code, so they get one even for unexpected errors
+        const response = this.constructMessage(message.mid, 500, {
+          code: ERROR_CODES.ERROR,
+          message: ErrorUtils.renderError(error),
+        })
+
+        this.emitResponse(client, response, requestId)
       }
     }
   }
@@ -290,6 +378,15 @@ export abstract class RpcSocketAdapter implements IRpcAdapter {
     }
   }
 
+  constructUnauthenticated(messageId: number): ServerSocketRpc {
+    const data: SocketRpcError = {
+      code: ERROR_CODES.UNAUTHENTICATED,
+      message: 'Missing or invalid RPC auth token',
+    }
+
+    return this.constructMessage(messageId, 401, data)
+  }
+
   constructUnmountedAdapter(): ServerSocketRpc {
     const error = new Error(`Tried to connect to unmounted adapter`)
 
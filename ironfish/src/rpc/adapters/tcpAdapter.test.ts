@@ -0,0 +1,61 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import net from 'net'
+import { RpcRequest } from '../request'
+import { RpcTcpAdapter } from './tcpAdapter'
+
+describe('RpcTcpAdapter', () => {
+  const mockSocket = (remoteAddress: string) =>
+    ({
+      remoteAddress,
+      on: jest.fn(),
+      destroy: jest.fn(),
+      setTimeout: jest.fn(),
+    } as unknown as net.Socket)
+
+  it('rejects connections over the limits', () => {
+    const adapter = new RpcTcpAdapter('localhost', 0, undefined, [])
+    adapter.maxConnections = 3
+    adapter.maxConnectionsPerIp = 2
+
+    const sockets = [
+      mockSocket('10.0.0.1'),
+      mockSocket('10.0.0.1'),
+      mockSocket('10.0.0.1'),
+      mockSocket('10.0.0.2'),
+      mockSocket('10.0.0.3'),
+    ]
+
+    for (const socket of sockets) {
+      adapter.onClientConnection(socket)
+    }
+
+    expect(adapter.clients.size).toBe(3)
+    expect(sockets.map((s) => (s.destroy as jest.Mock).mock.calls.length)).toEqual([
+      0, 0, 1, 0, 1,
+    ])
+  })
+
+  it('closes idle clients without open requests', () => {
+    const adapter = new RpcTcpAdapter('localhost', 0, undefined, [])
+    adapter.idleTimeoutMs = 1000
+
+    const socket = mockSocket('10.0.0.1')
+    adapter.onClientConnection(socket)
+    expect(socket.setTimeout).toHaveBeenCalledWith(1000)
+
+    const onTimeout = (socket.on as jest.Mock).mock.calls.find(
+      ([event]) => event === 'timeout',
+    )?.[1] as () => void
+
+    const [client] = adapter.clients.values()
+    client.requests.set('request', {} as RpcRequest)
+    onTimeout()
+    expect(socket.destroy).not.toHaveBeenCalled()
+
+    client.requests.clear()
+    onTimeout()
+    expect(socket.destroy).toHaveBeenCalled()
+  })
+})
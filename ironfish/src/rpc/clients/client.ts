@@ -4,11 +4,25 @@
 import { Logger } from '../../logger'
 import { RpcResponse, RpcResponseEnded } from '../response'
 import {
+  AddViewKeyRequest,
+  AddViewKeyResponse,
   ApiNamespace,
   BlockTemplateStreamRequest,
   BlockTemplateStreamResponse,
+  BroadcastTransactionRequest,
+  BroadcastTransactionResponse,
+  CancelWorkerJobRequest,
+  CancelWorkerJobResponse,
+  ConfirmReorgRequest,
+  ConfirmReorgResponse,
   CreateAccountRequest,
   CreateAccountResponse,
+  CreateDisclosureRequest,
+  CreateDisclosureResponse,
+  EstimateFeesRequest,
+  EstimateFeesResponse,
+  EstimateTransactionRequest,
+  EstimateTransactionResponse,
   GetAccountNotesRequest,
   GetAccountNotesResponse,
   GetAccountsRequest,
@@ -17,47 +31,112 @@ import {
   GetAccountTransactionResponse,
   GetAccountTransactionsRequest,
   GetAccountTransactionsResponse,
+  GetAliasesRequest,
+  GetAliasesResponse,
   GetBalanceRequest,
   GetBalanceResponse,
   GetBlockInfoRequest,
   GetBlockInfoResponse,
+  GetBlockRawRequest,
+  GetBlockRawResponse,
   GetBlockRequest,
   GetBlockResponse,
+  GetBlockTemplateRequest,
+  GetBlockTemplateResponse,
   GetChainInfoRequest,
   GetChainInfoResponse,
   GetConfigRequest,
   GetConfigResponse,
   GetDefaultAccountRequest,
   GetDefaultAccountResponse,
+  GetFeedRequest,
+  GetFeedResponse,
+  GetFeeHistogramRequest,
+  GetFeeHistogramResponse,
   GetFundsRequest,
   GetFundsResponse,
   GetLogStreamResponse,
+  GetMetricsHistoryRequest,
+  GetMetricsHistoryResponse,
+  GetMinersRequest,
+  GetMinersResponse,
+  GetNetworkHashrateRequest,
+  GetNetworkHashrateResponse,
   GetPeersRequest,
   GetPeersResponse,
+  GetPropagationTraceRequest,
+  GetPropagationTraceResponse,
   GetPublicKeyRequest,
   GetPublicKeyResponse,
+  GetReceiptRequest,
+  GetReceiptResponse,
+  GetScanningRequest,
+  GetScanningResponse,
+  GetSummaryRequest,
+  GetSummaryResponse,
   GetStatusRequest,
   GetStatusResponse,
+  GetSupplyRequest,
+  GetSupplyResponse,
+  GetTransactionRawRequest,
+  GetTransactionRawResponse,
   GetTransactionStreamRequest,
   GetTransactionStreamResponse,
+  GetViewKeysRequest,
+  GetViewKeysResponse,
+  GetWorkerJobsRequest,
+  GetWorkerJobsResponse,
   GetWorkersStatusRequest,
   GetWorkersStatusResponse,
+  OnHeadStreamRequest,
+  OnHeadStreamResponse,
+  ProduceBlocksRequest,
+  ProduceBlocksResponse,
+  RebuildAccountsResponse,
+  ReloadConfigResponse,
+  RemoveAliasRequest,
+  RemoveAliasResponse,
+  RemoveViewKeyRequest,
+  RemoveViewKeyResponse,
+  SendTransactionProgress,
   SendTransactionRequest,
   SendTransactionResponse,
+  SetAliasRequest,
+  SetAliasResponse,
+  SetLogLevelRequest,
+  SetLogLevelResponse,
   SetConfigRequest,
   SetConfigResponse,
+  SetScanningRequest,
+  SetScanningResponse,
   ShowChainRequest,
   ShowChainResponse,
+  SignMessageRequest,
+  SignMessageResponse,
+  SimulateTransactionRequest,
+  SimulateTransactionResponse,
   StopNodeResponse,
   SubmitBlockRequest,
   SubmitBlockResponse,
+  SubmitHashRateRequest,
+  SubmitHashRateResponse,
+  SweepAccountRequest,
+  SweepAccountResponse,
   UploadConfigRequest,
   UploadConfigResponse,
   UseAccountRequest,
   UseAccountResponse,
+  VerifyDisclosureRequest,
+  VerifyDisclosureResponse,
+  VerifyMessageRequest,
+  VerifyMessageResponse,
+  WatchDepositsRequest,
+  WatchDepositsResponse,
 } from '../routes'
 import { ExportAccountRequest, ExportAccountResponse } from '../routes/accounts/exportAccount'
+import { ExportStateRequest, ExportStateResponse } from '../routes/accounts/exportState'
 import { ImportAccountRequest, ImportAccountResponse } from '../routes/accounts/importAccount'
+import { ImportStateRequest, ImportStateResponse } from '../routes/accounts/importState'
 import { RemoveAccountRequest, RemoveAccountResponse } from '../routes/accounts/removeAccount'
 import { RescanAccountRequest, RescanAccountResponse } from '../routes/accounts/rescanAccount'
 import {
@@ -78,8 +157,24 @@ import {
   GetPeerMessagesRequest,
   GetPeerMessagesResponse,
 } from '../routes/peers/getPeerMessages'
+import { TestPeersRequest, TestPeersResponse } from '../routes/peers/testPeers'
 import { GetRpcStatusRequest, GetRpcStatusResponse } from '../routes/rpc/getStatus'
 
+export type RpcRequestOptions = {
+  timeoutMs?: number | null
+  /**
+   * Send the request again once the client reconnects instead of failing it
+   * when the connection is lost. Only set this for requests that are safe to
+   * repeat. Defaults to the reconnect policy's `retryRoute`
+   */
+  retry?: boolean
+  /**
+   * The data to send a retried stream with, so it can resume where it left off
+   * instead of starting over from the original data
+   */
+  resubscribe?: () => unknown
+}
+
 export abstract class RpcClient {
   readonly logger: Logger
 
@@ -90,7 +185,7 @@ export abstract class RpcClient {
   abstract request<TEnd = unknown, TStream = unknown>(
     route: string,
     data?: unknown,
-    options?: { timeoutMs?: number | null },
+    options?: RpcRequestOptions,
   ): RpcResponse<TEnd, TStream>
 
   async status(
@@ -116,6 +211,24 @@ export abstract class RpcClient {
     return this.request<void, GetLogStreamResponse>(`${ApiNamespace.node}/getLogStream`)
   }
 
+  async getMetricsHistory(
+    params: GetMetricsHistoryRequest = undefined,
+  ): Promise<RpcResponseEnded<GetMetricsHistoryResponse>> {
+    return this.request<GetMetricsHistoryResponse>(
+      `${ApiNamespace.node}/getMetricsHistory`,
+      params,
+    ).waitForEnd()
+  }
+
+  async setLogLevel(
+    params: SetLogLevelRequest,
+  ): Promise<RpcResponseEnded<SetLogLevelResponse>> {
+    return this.request<SetLogLevelResponse>(
+      `${ApiNamespace.node}/setLogLevel`,
+      params,
+    ).waitForEnd()
+  }
+
   async getAccounts(
     params: GetAccountsRequest = undefined,
   ): Promise<RpcResponseEnded<GetAccountsResponse>> {
@@ -150,6 +263,49 @@ export abstract class RpcClient {
     ).waitForEnd()
   }
 
+  async setAlias(params: SetAliasRequest): Promise<RpcResponseEnded<SetAliasResponse>> {
+    return await this.request<SetAliasResponse>(
+      `${ApiNamespace.account}/setAlias`,
+      params,
+    ).waitForEnd()
+  }
+
+  async removeAlias(
+    params: RemoveAliasRequest,
+  ): Promise<RpcResponseEnded<RemoveAliasResponse>> {
+    return await this.request<RemoveAliasResponse>(
+      `${ApiNamespace.account}/removeAlias`,
+      params,
+    ).waitForEnd()
+  }
+
+  async getAliases(
+    params: GetAliasesRequest = {},
+  ): Promise<RpcResponseEnded<GetAliasesResponse>> {
+    return await this.request<GetAliasesResponse>(
+      `${ApiNamespace.account}/getAliases`,
+      params,
+    ).waitForEnd()
+  }
+
+  async setScanning(
+    params: SetScanningRequest,
+  ): Promise<RpcResponseEnded<SetScanningResponse>> {
+    return await this.request<SetScanningResponse>(
+      `${ApiNamespace.account}/setScanning`,
+      params,
+    ).waitForEnd()
+  }
+
+  async getScanning(
+    params: GetScanningRequest = {},
+  ): Promise<RpcResponseEnded<GetScanningResponse>> {
+    return await this.request<GetScanningResponse>(
+      `${ApiNamespace.account}/getScanning`,
+      params,
+    ).waitForEnd()
+  }
+
   async removeAccount(
     params: RemoveAccountRequest,
   ): Promise<RpcResponseEnded<RemoveAccountResponse>> {
@@ -168,6 +324,12 @@ export abstract class RpcClient {
     ).waitForEnd()
   }
 
+  async rebuildAccounts(): Promise<RpcResponseEnded<RebuildAccountsResponse>> {
+    return await this.request<RebuildAccountsResponse>(
+      `${ApiNamespace.account}/rebuild`,
+    ).waitForEnd()
+  }
+
   rescanAccountStream(
     params: RescanAccountRequest = {},
   ): RpcResponse<void, RescanAccountResponse> {
@@ -195,6 +357,24 @@ export abstract class RpcClient {
     ).waitForEnd()
   }
 
+  async exportAccountState(
+    params: ExportStateRequest = {},
+  ): Promise<RpcResponseEnded<ExportStateResponse>> {
+    return this.request<ExportStateResponse>(
+      `${ApiNamespace.account}/exportState`,
+      params,
+    ).waitForEnd()
+  }
+
+  async importAccountState(
+    params: ImportStateRequest,
+  ): Promise<RpcResponseEnded<ImportStateResponse>> {
+    return this.request<ImportStateResponse>(
+      `${ApiNamespace.account}/importState`,
+      params,
+    ).waitForEnd()
+  }
+
   async getAccountPublicKey(
     params: GetPublicKeyRequest,
   ): Promise<RpcResponseEnded<GetPublicKeyResponse>> {
@@ -204,6 +384,56 @@ export abstract class RpcClient {
     ).waitForEnd()
   }
 
+  async signMessage(
+    params: SignMessageRequest,
+  ): Promise<RpcResponseEnded<SignMessageResponse>> {
+    return this.request<SignMessageResponse>(
+      `${ApiNamespace.account}/signMessage`,
+      params,
+    ).waitForEnd()
+  }
+
+  async verifyMessage(
+    params: VerifyMessageRequest,
+  ): Promise<RpcResponseEnded<VerifyMessageResponse>> {
+    return this.request<VerifyMessageResponse>(
+      `${ApiNamespace.account}/verifyMessage`,
+      params,
+    ).waitForEnd()
+  }
+
+  async getReceipt(params: GetReceiptRequest): Promise<RpcResponseEnded<GetReceiptResponse>> {
+    return this.request<GetReceiptResponse>(
+      `${ApiNamespace.account}/getReceipt`,
+      params,
+    ).waitForEnd()
+  }
+
+  async getSummary(params: GetSummaryRequest): Promise<RpcResponseEnded<GetSummaryResponse>> {
+    return this.request<GetSummaryResponse>(
+      `${ApiNamespace.account}/getSummary`,
+      params,
+    ).waitForEnd()
+  }
+
+  async createDisclosure(
+    params: CreateDisclosureRequest,
+  ): Promise<RpcResponseEnded<CreateDisclosureResponse>> {
+    return this.request<CreateDisclosureResponse>(
+      `${ApiNamespace.account}/createDisclosure`,
+      params,
+    ).waitForEnd()
+  }
+
+  async verifyDisclosure(
+    params: VerifyDisclosureRequest,
+  ): Promise<RpcResponseEnded<VerifyDisclosureResponse>> {
+    return this.request<VerifyDisclosureResponse>(
+      `${ApiNamespace.account}/verifyDisclosure`,
+      params,
+    ).waitForEnd()
+  }
+
   async getAccountNotes(
     params: GetAccountNotesRequest = {},
   ): Promise<RpcResponseEnded<GetAccountNotesResponse>> {
@@ -231,6 +461,15 @@ export abstract class RpcClient {
     ).waitForEnd()
   }
 
+  watchDepositsStream(
+    params: WatchDepositsRequest = {},
+  ): RpcResponse<void, WatchDepositsResponse> {
+    return this.request<void, WatchDepositsResponse>(
+      `${ApiNamespace.account}/watchDeposits`,
+      params,
+    )
+  }
+
   async getPeers(
     params: GetPeersRequest = undefined,
   ): Promise<RpcResponseEnded<GetPeersResponse>> {
@@ -273,6 +512,10 @@ export abstract class RpcClient {
     })
   }
 
+  testPeersStream(params: TestPeersRequest = {}): RpcResponse<void, TestPeersResponse> {
+    return this.request<void, TestPeersResponse>(`${ApiNamespace.peer}/testPeers`, params)
+  }
+
   async getWorkersStatus(
     params: GetWorkersStatusRequest = undefined,
   ): Promise<RpcResponseEnded<GetWorkersStatusResponse>> {
@@ -291,6 +534,24 @@ export abstract class RpcClient {
     })
   }
 
+  async getWorkerJobs(
+    params: GetWorkerJobsRequest = undefined,
+  ): Promise<RpcResponseEnded<GetWorkerJobsResponse>> {
+    return this.request<GetWorkerJobsResponse>(
+      `${ApiNamespace.worker}/getJobs`,
+      params,
+    ).waitForEnd()
+  }
+
+  async cancelWorkerJob(
+    params: CancelWorkerJobRequest,
+  ): Promise<RpcResponseEnded<CancelWorkerJobResponse>> {
+    return this.request<CancelWorkerJobResponse>(
+      `${ApiNamespace.worker}/cancelJob`,
+      params,
+    ).waitForEnd()
+  }
+
   async getRpcStatus(
     params: GetRpcStatusRequest = undefined,
   ): Promise<RpcResponseEnded<GetRpcStatusResponse>> {
@@ -313,6 +574,15 @@ export abstract class RpcClient {
     return this.request<void, OnGossipResponse>(`${ApiNamespace.event}/onGossip`, params)
   }
 
+  async estimateTransaction(
+    params: EstimateTransactionRequest,
+  ): Promise<RpcResponseEnded<EstimateTransactionResponse>> {
+    return this.request<EstimateTransactionResponse>(
+      `${ApiNamespace.transaction}/estimateTransaction`,
+      params,
+    ).waitForEnd()
+  }
+
   async sendTransaction(
     params: SendTransactionRequest,
   ): Promise<RpcResponseEnded<SendTransactionResponse>> {
@@ -322,6 +592,27 @@ export abstract class RpcClient {
     ).waitForEnd()
   }
 
+  /**
+   * Send a transaction, streaming progress as its proofs are built
+   */
+  sendTransactionStream(
+    params: SendTransactionRequest,
+  ): RpcResponse<SendTransactionResponse, SendTransactionProgress> {
+    return this.request<SendTransactionResponse, SendTransactionProgress>(
+      `${ApiNamespace.transaction}/sendTransaction`,
+      { ...params, streamProgress: true },
+    )
+  }
+
+  async sweepAccount(
+    params: SweepAccountRequest,
+  ): Promise<RpcResponseEnded<SweepAccountResponse>> {
+    return this.request<SweepAccountResponse>(
+      `${ApiNamespace.transaction}/sweepAccount`,
+      params,
+    ).waitForEnd()
+  }
+
   blockTemplateStream(
     params: BlockTemplateStreamRequest = undefined,
   ): RpcResponse<void, BlockTemplateStreamResponse> {
@@ -331,6 +622,15 @@ export abstract class RpcClient {
     )
   }
 
+  async getBlockTemplate(
+    params: GetBlockTemplateRequest = {},
+  ): Promise<RpcResponseEnded<GetBlockTemplateResponse>> {
+    return this.request<GetBlockTemplateResponse>(
+      `${ApiNamespace.miner}/getBlockTemplate`,
+      params,
+    ).waitForEnd()
+  }
+
   submitBlock(params: SubmitBlockRequest): Promise<RpcResponseEnded<SubmitBlockResponse>> {
     return this.request<SubmitBlockResponse>(
       `${ApiNamespace.miner}/submitBlock`,
@@ -338,6 +638,15 @@ export abstract class RpcClient {
     ).waitForEnd()
   }
 
+  submitHashRate(
+    params: SubmitHashRateRequest,
+  ): Promise<RpcResponseEnded<SubmitHashRateResponse>> {
+    return this.request<SubmitHashRateResponse>(
+      `${ApiNamespace.miner}/submitHashRate`,
+      params,
+    ).waitForEnd()
+  }
+
   exportMinedStream(
     params: ExportMinedStreamRequest = undefined,
   ): RpcResponse<void, ExportMinedStreamResponse> {
@@ -358,6 +667,78 @@ export abstract class RpcClient {
     return this.request<GetBlockResponse>(`${ApiNamespace.chain}/getBlock`, params).waitForEnd()
   }
 
+  async getBlockRaw(
+    params: GetBlockRawRequest,
+  ): Promise<RpcResponseEnded<GetBlockRawResponse>> {
+    return this.request<GetBlockRawResponse>(
+      `${ApiNamespace.chain}/getBlockRaw`,
+      params,
+    ).waitForEnd()
+  }
+
+  async getTransactionRaw(
+    params: GetTransactionRawRequest,
+  ): Promise<RpcResponseEnded<GetTransactionRawResponse>> {
+    return this.request<GetTransactionRawResponse>(
+      `${ApiNamespace.chain}/getTransactionRaw`,
+      params,
+    ).waitForEnd()
+  }
+
+  async estimateFees(
+    params: EstimateFeesRequest = undefined,
+  ): Promise<RpcResponseEnded<EstimateFeesResponse>> {
+    return this.request<EstimateFeesResponse>(
+      `${ApiNamespace.chain}/estimateFees`,
+      params,
+    ).waitForEnd()
+  }
+
+  async getFeeHistogram(
+    params: GetFeeHistogramRequest = undefined,
+  ): Promise<RpcResponseEnded<GetFeeHistogramResponse>> {
+    return this.request<GetFeeHistogramResponse>(
+      `${ApiNamespace.chain}/getFeeHistogram`,
+      params,
+    ).waitForEnd()
+  }
+
+  async getPropagationTrace(
+    params: GetPropagationTraceRequest,
+  ): Promise<RpcResponseEnded<GetPropagationTraceResponse>> {
+    return this.request<GetPropagationTraceResponse>(
+      `${ApiNamespace.chain}/getPropagationTrace`,
+      params,
+    ).waitForEnd()
+  }
+
+  async getSupply(
+    params: GetSupplyRequest = undefined,
+  ): Promise<RpcResponseEnded<GetSupplyResponse>> {
+    return this.request<GetSupplyResponse>(
+      `${ApiNamespace.chain}/getSupply`,
+      params,
+    ).waitForEnd()
+  }
+
+  async getMiners(
+    params: GetMinersRequest = undefined,
+  ): Promise<RpcResponseEnded<GetMinersResponse>> {
+    return this.request<GetMinersResponse>(
+      `${ApiNamespace.chain}/getMiners`,
+      params,
+    ).waitForEnd()
+  }
+
+  async getNetworkHashrate(
+    params: GetNetworkHashrateRequest = undefined,
+  ): Promise<RpcResponseEnded<GetNetworkHashrateResponse>> {
+    return this.request<GetNetworkHashrateResponse>(
+      `${ApiNamespace.chain}/getNetworkHashrate`,
+      params,
+    ).waitForEnd()
+  }
+
   async getChainInfo(
     params: GetChainInfoRequest = undefined,
   ): Promise<RpcResponseEnded<GetChainInfoResponse>> {
@@ -385,6 +766,12 @@ export abstract class RpcClient {
     )
   }
 
+  onHeadStream(
+    params: OnHeadStreamRequest = undefined,
+  ): RpcResponse<void, OnHeadStreamResponse> {
+    return this.request<void, OnHeadStreamResponse>(`${ApiNamespace.chain}/onHead`, params)
+  }
+
   async getBlockInfo(
     params: GetBlockInfoRequest,
   ): Promise<RpcResponseEnded<GetBlockInfoResponse>> {
@@ -403,6 +790,33 @@ export abstract class RpcClient {
     ).waitForEnd()
   }
 
+  async broadcastTransaction(
+    params: BroadcastTransactionRequest,
+  ): Promise<RpcResponseEnded<BroadcastTransactionResponse>> {
+    return this.request<BroadcastTransactionResponse>(
+      `${ApiNamespace.chain}/broadcastTransaction`,
+      params,
+    ).waitForEnd()
+  }
+
+  async confirmReorg(
+    params: ConfirmReorgRequest = {},
+  ): Promise<RpcResponseEnded<ConfirmReorgResponse>> {
+    return this.request<ConfirmReorgResponse>(
+      `${ApiNamespace.chain}/confirmReorg`,
+      params,
+    ).waitForEnd()
+  }
+
+  async simulateTransaction(
+    params: SimulateTransactionRequest,
+  ): Promise<RpcResponseEnded<SimulateTransactionResponse>> {
+    return this.request<SimulateTransactionResponse>(
+      `${ApiNamespace.chain}/simulateTransaction`,
+      params,
+    ).waitForEnd()
+  }
+
   getTransactionStream(
     params: GetTransactionStreamRequest,
   ): RpcResponse<void, GetTransactionStreamResponse> {
@@ -428,6 +842,21 @@ export abstract class RpcClient {
     ).waitForEnd()
   }
 
+  async produceBlocks(
+    params: ProduceBlocksRequest = {},
+  ): Promise<RpcResponseEnded<ProduceBlocksResponse>> {
+    return this.request<ProduceBlocksResponse>(
+      `${ApiNamespace.dev}/produceBlocks`,
+      params,
+    ).waitForEnd()
+  }
+
+  async reloadConfig(): Promise<RpcResponseEnded<ReloadConfigResponse>> {
+    return this.request<ReloadConfigResponse>(
+      `${ApiNamespace.config}/reloadConfig`,
+    ).waitForEnd()
+  }
+
   async uploadConfig(
     params: UploadConfigRequest,
   ): Promise<RpcResponseEnded<UploadConfigResponse>> {
@@ -436,4 +865,33 @@ export abstract class RpcClient {
       params,
     ).waitForEnd()
   }
+
+  async addViewKey(params: AddViewKeyRequest): Promise<RpcResponseEnded<AddViewKeyResponse>> {
+    return this.request<AddViewKeyResponse>(
+      `${ApiNamespace.scanner}/addViewKey`,
+      params,
+    ).waitForEnd()
+  }
+
+  async removeViewKey(
+    params: RemoveViewKeyRequest,
+  ): Promise<RpcResponseEnded<RemoveViewKeyResponse>> {
+    return this.request<RemoveViewKeyResponse>(
+      `${ApiNamespace.scanner}/removeViewKey`,
+      params,
+    ).waitForEnd()
+  }
+
+  async getViewKeys(
+    params: GetViewKeysRequest = undefined,
+  ): Promise<RpcResponseEnded<GetViewKeysResponse>> {
+    return this.request<GetViewKeysResponse>(
+      `${ApiNamespace.scanner}/getViewKeys`,
+      params,
+    ).waitForEnd()
+  }
+
+  getFeedStream(params: GetFeedRequest): RpcResponse<void, GetFeedResponse> {
+    return this.request<void, GetFeedResponse>(`${ApiNamespace.scanner}/getFeed`, params)
+  }
 }
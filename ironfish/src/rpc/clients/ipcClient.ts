@@ -7,7 +7,7 @@ import { Event } from '../../event'
 import { createRootLogger, Logger } from '../../logger'
 import { ErrorUtils } from '../../utils'
 import { IpcRequest } from '../adapters'
-import { RpcConnectionLostError, RpcConnectionRefusedError } from './errors'
+import { RpcConnectionRefusedError } from './errors'
 import { RpcClientConnectionInfo, RpcSocketClient } from './socketClient'
 
 const CONNECT_RETRY_MS = 2000
@@ -99,6 +99,8 @@ export class RpcIpcClient extends RpcSocketClient {
   }
 
   close(): void {
+    this.stopReconnect()
+
     if (this.isConnected) {
       this.ipc?.disconnect('server')
       this.ipc = null
@@ -118,6 +120,7 @@ export class RpcIpcClient extends RpcSocketClient {
 
   protected onConnect(): void {
     Assert.isNotNull(this.client)
+    this.closing = false
     this.client.on('disconnect', this.onDisconnect)
     this.client.on('message', this.onMessage)
     this.client.on('malformedRequest', this.onMalformedRequest)
@@ -135,12 +138,7 @@ export class RpcIpcClient extends RpcSocketClient {
     this.client.off('error', this.onClientError)
     this.client = null
 
-    for (const request of this.pending.values()) {
-      request.reject(new RpcConnectionLostError(request.type))
-    }
-    this.pending.clear()
-
-    this.onClose.emit()
+    this.handleClose()
   }
 
   protected onClientError = (error: unknown): void => {
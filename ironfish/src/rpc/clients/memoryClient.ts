@@ -5,7 +5,7 @@ import { Logger } from '../../logger'
 import { IronfishNode } from '../../node'
 import { MemoryResponse, RpcMemoryAdapter } from '../adapters'
 import { ALL_API_NAMESPACES, Router } from '../routes'
-import { RpcClient } from './client'
+import { RpcClient, RpcRequestOptions } from './client'
 
 export class RpcMemoryClient extends RpcClient {
   node: IronfishNode
@@ -21,9 +21,7 @@ export class RpcMemoryClient extends RpcClient {
   request<TEnd = unknown, TStream = unknown>(
     route: string,
     data?: unknown,
-    options: {
-      timeoutMs?: number | null
-    } = {},
+    options: RpcRequestOptions = {},
   ): MemoryResponse<TEnd, TStream> {
     if (options.timeoutMs) {
       throw new Error(`MemoryAdapter does not support timeoutMs`)
@@ -0,0 +1,141 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import net from 'net'
+import { createRootLogger } from '../../logger'
+import { flushTimeout } from '../../testUtilities/helpers/tests'
+import { RpcConnectionLostError, RpcConnectionRefusedError } from './errors'
+import { isIdempotentRoute, RpcClientConnectionInfo, RpcSocketClient } from './socketClient'
+
+class TestClient extends RpcSocketClient {
+  client: net.Socket | null = null
+  isConnected = false
+  connection: Partial<RpcClientConnectionInfo> = {}
+  refuse = false
+  sent: { messageId: number; route: string; data: unknown }[] = []
+
+  connect(): Promise<void> {
+    if (this.refuse) {
+      return Promise.reject(new RpcConnectionRefusedError())
+    }
+
+    this.client = {} as net.Socket
+    this.isConnected = true
+    this.closing = false
+    return Promise.resolve()
+  }
+
+  close(): void {
+    this.stopReconnect()
+
+    if (this.isConnected) {
+      this.lose()
+    }
+  }
+
+  lose(): void {
+    this.isConnected = false
+    this.handleClose()
+  }
+
+  protected send(messageId: number, route: string, data: unknown): void {
+    this.sent.push({ messageId, route, data })
+  }
+}
+
+describe('RpcSocketClient', () => {
+  const createClient = async (): Promise<TestClient> => {
+    const client = new TestClient(createRootLogger())
+    client.enableReconnect({ minDelayMs: 0, maxDelayMs: 0 })
+    await client.connect()
+    return client
+  }
+
+  it('only treats reading routes as idempotent', () => {
+    expect(isIdempotentRoute('node/getStatus')).toBe(true)
+    expect(isIdempotentRoute('chain/followChainStream')).toBe(true)
+    expect(isIdempotentRoute('transaction/estimateTransaction')).toBe(true)
+    expect(isIdempotentRoute('transaction/sendTransaction')).toBe(false)
+    expect(isIdempotentRoute('faucet/getFunds')).toBe(false)
+  })
+
+  it('fails requests that are not retried when the connection is lost', async () => {
+    const client = await createClient()
+
+    const response = client.request('transaction/sendTransaction', { fee: '1' })
+    client.lose()
+
+    await expect(response.waitForEnd()).rejects.toThrowError(RpcConnectionLostError)
+    client.close()
+  })
+
+  it('reconnects and sends retried requests again', async () => {
+    const client = await createClient()
+    const reconnected = jest.fn()
+    client.onReconnect.on(reconnected)
+
+    client.request('node/getStatus')
+    client.lose()
+    expect(client.pending.size).toBe(1)
+
+    await flushTimeout()
+
+    expect(client.isConnected).toBe(true)
+    expect(reconnected).toHaveBeenCalledTimes(1)
+    expect(client.sent.map((m) => m.route)).toEqual(['node/getStatus', 'node/getStatus'])
+  })
+
+  it('sends requests made while reconnecting once connected', async () => {
+    const client = await createClient()
+    client.refuse = true
+    client.lose()
+
+    client.request('chain/getChainInfo')
+    expect(client.sent).toHaveLength(0)
+
+    client.refuse = false
+    await flushTimeout()
+    await flushTimeout()
+
+    expect(client.sent.map((m) => m.route)).toEqual(['chain/getChainInfo'])
+  })
+
+  it('resubscribes streams with the data from resubscribe', async () => {
+    const client = await createClient()
+    let head = 'aa'
+
+    client.request('chain/followChainStream', { head }, { resubscribe: () => ({ head }) })
+    head = 'bb'
+    client.lose()
+
+    await flushTimeout()
+
+    expect(client.sent.map((m) => m.data)).toEqual([{ head: 'aa' }, { head: 'bb' }])
+  })
+
+  it('does not reconnect after close', async () => {
+    const client = await createClient()
+
+    const response = client.request('node/getStatus')
+    client.close()
+
+    await expect(response.waitForEnd()).rejects.toThrowError(RpcConnectionLostError)
+    await flushTimeout()
+    expect(client.isConnected).toBe(false)
+  })
+
+  it('gives up after the maximum attempts', async () => {
+    const client = await createClient()
+    client.enableReconnect({ minDelayMs: 0, maxDelayMs: 0, maxAttempts: 1 })
+
+    const response = client.request('node/getStatus')
+    client.refuse = true
+    client.lose()
+
+    await flushTimeout()
+    await flushTimeout()
+
+    await expect(response.waitForEnd()).rejects.toThrowError(RpcConnectionLostError)
+    expect(client.isConnected).toBe(false)
+  })
+})
@@ -5,15 +5,59 @@ import net from 'net'
 import { IpcClient } from 'node-ipc'
 import { Assert } from '../../assert'
 import { Event } from '../../event'
-import { PromiseUtils, SetTimeoutToken, YupUtils } from '../../utils'
+import { ErrorUtils, PromiseUtils, SetTimeoutToken, YupUtils } from '../../utils'
 import { IpcErrorSchema, IpcResponseSchema, IpcStreamSchema } from '../adapters'
 import { isRpcResponseError, RpcResponse } from '../response'
+import { ApiNamespace } from '../routes/router'
 import { Stream } from '../stream'
-import { RpcClient } from './client'
-import { RequestTimeoutError, RpcConnectionError, RpcRequestError } from './errors'
+import { RpcClient, RpcRequestOptions } from './client'
+import {
+  RequestTimeoutError,
+  RpcConnectionError,
+  RpcConnectionLostError,
+  RpcRequestError,
+} from './errors'
 
 const REQUEST_TIMEOUT_MS = null
 
+const IDEMPOTENT_PREFIXES = ['get', 'estimate', 'follow']
+
+/**
+ * Routes that start with get but change something, so they are not retried
+ */
+const NOT_IDEMPOTENT_ROUTES = new Set([`${ApiNamespace.faucet}/getFunds`])
+
+/**
+ * Routes that only read from the node are safe to send again after a
+ * reconnect. By convention these are the get, estimate and follow routes.
+ */
+export function isIdempotentRoute(route: string): boolean {
+  const method = route.slice(route.indexOf('/') + 1)
+
+  return (
+    IDEMPOTENT_PREFIXES.some((prefix) => method.startsWith(prefix)) &&
+    !NOT_IDEMPOTENT_ROUTES.has(route)
+  )
+}
+
+export type RpcReconnectPolicy = {
+  /** How long to wait before the first attempt to reconnect */
+  minDelayMs: number
+  /** The delay doubles after every failed attempt, up to this */
+  maxDelayMs: number
+  /** Give up after this many failed attempts in a row, or null to keep trying */
+  maxAttempts: number | null
+  /** Which requests to send again after reconnecting, unless the request sets `retry` */
+  retryRoute: (route: string) => boolean
+}
+
+export const DEFAULT_RECONNECT_POLICY: RpcReconnectPolicy = {
+  minDelayMs: 1000,
+  maxDelayMs: 60 * 1000,
+  maxAttempts: null,
+  retryRoute: isIdempotentRoute,
+}
+
 export type RpcClientConnectionInfo =
   | {
       mode: 'ipc'
@@ -37,6 +81,16 @@ export abstract class RpcSocketClient extends RpcClient {
   timeoutMs: number | null = REQUEST_TIMEOUT_MS
   messageIds = 0
 
+  /**
+   * Set with {@link enableReconnect} to reconnect when the connection is lost
+   */
+  reconnectPolicy: RpcReconnectPolicy | null = null
+  private reconnecting = false
+  private reconnectAttempts = 0
+  private reconnectTimeout: SetTimeoutToken | null = null
+  // True while the connection is being closed by close() instead of lost
+  protected closing = false
+
   pending = new Map<
     number,
     {
@@ -46,10 +100,36 @@ export abstract class RpcSocketClient extends RpcClient {
       resolve: (message: unknown) => void
       reject: (error?: unknown) => void
       type: string
+      data: unknown
+      retry: boolean
+      resubscribe: (() => unknown) | null
     }
   >()
 
   onClose = new Event<[]>()
+  onReconnect = new Event<[]>()
+
+  /**
+   * Reconnect with a backoff whenever the connection is lost, until close() is
+   * called. Requests that are retried stay pending while reconnecting and are
+   * sent again once connected, the rest fail with
+   * {@link RpcConnectionLostError} like they do without reconnecting.
+   *
+   * A retried stream keeps its response open and is sent again with the data
+   * from its `resubscribe` option, or its original data if it has none. So
+   * without `resubscribe` the stream may repeat what it already sent.
+   */
+  enableReconnect(policy: Partial<RpcReconnectPolicy> = {}): void {
+    this.reconnectPolicy = { ...DEFAULT_RECONNECT_POLICY, ...policy }
+  }
+
+  disableReconnect(): void {
+    this.reconnectPolicy = null
+
+    if (this.reconnecting) {
+      this.stopReconnect()
+    }
+  }
 
   async tryConnect(): Promise<boolean> {
     return this.connect()
@@ -65,11 +145,18 @@ export abstract class RpcSocketClient extends RpcClient {
   request<TEnd = unknown, TStream = unknown>(
     route: string,
     data?: unknown,
-    options: {
-      timeoutMs?: number | null
-    } = {},
+    options: RpcRequestOptions = {},
   ): RpcResponse<TEnd, TStream> {
-    Assert.isNotNull(this.client, 'Connect first using connect()')
+    const retry =
+      this.reconnectPolicy !== null &&
+      (options.retry ?? this.reconnectPolicy.retryRoute(route))
+
+    // Retried requests are sent once the client reconnects
+    const waitForReconnect = retry && this.reconnecting && !this.isConnected
+
+    if (!waitForReconnect) {
+      Assert.isNotNull(this.client, 'Connect first using connect()')
+    }
 
     const [promise, resolve, reject] = PromiseUtils.split<TEnd>()
     const messageId = ++this.messageIds
@@ -116,15 +203,117 @@ export abstract class RpcSocketClient extends RpcClient {
       response: response as RpcResponse<unknown>,
       stream: stream as Stream<unknown>,
       type: route,
+      data: data,
+      retry: retry,
+      resubscribe: options.resubscribe ?? null,
     }
 
     this.pending.set(messageId, pending)
 
-    this.send(messageId, route, data)
+    if (!waitForReconnect) {
+      this.send(messageId, route, data)
+    }
 
     return response
   }
 
+  /**
+   * Called by the implementations when their connection closes, to fail the
+   * pending requests and reconnect if the connection was lost
+   */
+  protected handleClose(): void {
+    const reconnect = this.reconnectPolicy !== null && !this.closing
+    this.closing = false
+
+    for (const request of this.pending.values()) {
+      if (!reconnect || !request.retry) {
+        request.reject(new RpcConnectionLostError(request.type))
+      }
+    }
+
+    this.onClose.emit()
+
+    if (reconnect) {
+      this.reconnecting = true
+      this.scheduleReconnect()
+    }
+  }
+
+  /**
+   * Called by the implementations from close() so a closed client doesn't
+   * reconnect
+   */
+  protected stopReconnect(): void {
+    this.closing = this.isConnected
+    this.reconnecting = false
+    this.reconnectAttempts = 0
+
+    if (this.reconnectTimeout) {
+      clearTimeout(this.reconnectTimeout)
+      this.reconnectTimeout = null
+    }
+
+    // Nothing will send these once the client stops reconnecting
+    if (!this.isConnected) {
+      for (const request of this.pending.values()) {
+        request.reject(new RpcConnectionLostError(request.type))
+      }
+    }
+  }
+
+  /**
+   * Try to connect again after a delay that doubles with every failed attempt
+   */
+  private scheduleReconnect(): void {
+    Assert.isNotNull(this.reconnectPolicy)
+    const { minDelayMs, maxDelayMs, maxAttempts } = this.reconnectPolicy
+
+    if (maxAttempts !== null && this.reconnectAttempts >= maxAttempts) {
+      this.logger.warn(`Could not reconnect after ${this.reconnectAttempts} attempts`)
+      this.stopReconnect()
+      return
+    }
+
+    const delay = Math.min(minDelayMs * 2 ** this.reconnectAttempts, maxDelayMs)
+    this.reconnectAttempts++
+
+    this.logger.debug(`Reconnecting in ${delay}ms`)
+    this.reconnectTimeout = setTimeout(() => void this.reconnect(), delay)
+  }
+
+  private async reconnect(): Promise<void> {
+    this.reconnectTimeout = null
+
+    const connected = await this.tryConnect().catch((e: unknown) => {
+      this.logger.debug(`Failed to reconnect: ${ErrorUtils.renderError(e)}`)
+      return false
+    })
+
+    // close() was called while connecting
+    if (!this.reconnecting) {
+      if (connected) {
+        this.close()
+      }
+      return
+    }
+
+    if (!connected) {
+      this.scheduleReconnect()
+      return
+    }
+
+    this.reconnecting = false
+    this.reconnectAttempts = 0
+    this.logger.debug('Reconnected')
+
+    for (const [messageId, request] of this.pending) {
+      const data = request.resubscribe ? request.resubscribe() : request.data
+      this.send(messageId, request.type, data)
+    }
+
+    this.onReconnect.emit()
+  }
+
   protected handleStream = async (data: unknown): Promise<void> => {
     const { result, error } = await YupUtils.tryValidate(IpcStreamSchema, data)
     if (!result) {
@@ -11,22 +11,29 @@ import {
   ServerSocketRpcSchema,
 } from '../adapters/socketAdapter/protocol'
 import { MessageBuffer } from '../messageBuffer'
-import { RpcConnectionLostError, RpcConnectionRefusedError } from './errors'
+import { RpcConnectionRefusedError } from './errors'
 import { RpcClientConnectionInfo, RpcSocketClient } from './socketClient'
 
 export class RpcTcpClient extends RpcSocketClient {
   client: net.Socket | null = null
   protected readonly host: string
   protected readonly port: number
+  protected readonly authToken: string | null
   private connectTimeout: SetTimeoutToken | null
   isConnected = false
   connection: RpcClientConnectionInfo
   private messageBuffer: MessageBuffer
 
-  constructor(host: string, port: number, logger: Logger = createRootLogger()) {
+  constructor(
+    host: string,
+    port: number,
+    logger: Logger = createRootLogger(),
+    authToken: string | null = null,
+  ) {
     super(logger.withTag('tcpclient'))
     this.host = host
     this.port = port
+    this.authToken = authToken
     this.connection = { mode: 'tcp', host: host, port: port }
     this.connectTimeout = null
     this.messageBuffer = new MessageBuffer()
@@ -62,6 +69,7 @@ export class RpcTcpClient extends RpcSocketClient {
   }
 
   close(): void {
+    this.stopReconnect()
     this.client?.end()
 
     this.messageBuffer.clear()
@@ -77,6 +85,7 @@ export class RpcTcpClient extends RpcSocketClient {
       data: {
         mid: messageId,
         type: route,
+        auth: this.authToken ?? undefined,
         data: data,
       },
     }
@@ -86,6 +95,7 @@ export class RpcTcpClient extends RpcSocketClient {
   protected onConnect(): void {
     Assert.isNotNull(this.client)
     this.isConnected = true
+    this.closing = false
     this.client.on('data', this.onClientData)
     this.client.on('close', this.onClientClose)
   }
@@ -129,12 +139,7 @@ export class RpcTcpClient extends RpcSocketClient {
     this.client?.off('data', this.onClientData)
     this.client?.off('close', this.onClientClose)
 
-    for (const request of this.pending.values()) {
-      request.reject(new RpcConnectionLostError(request.type))
-    }
-    this.pending.clear()
-
-    this.onClose.emit()
+    this.handleClose()
   }
 
   protected onMessage = (data: unknown): void => {
@@ -8,6 +8,7 @@ export class RpcRequest<TRequest = unknown, TResponse = unknown> {
   data: TRequest
   ended = false
   closed = false
+  streamed = false
   onEnd: (status: number, data?: TResponse) => void
   onStream: (data?: TResponse) => void
   onClose = new Event<[]>()
@@ -41,6 +42,7 @@ export class RpcRequest<TRequest = unknown, TResponse = unknown> {
     if (this.ended) {
       throw new Error(`Request has already ended`)
     }
+    this.streamed = true
     this.onStream(data)
   }
 
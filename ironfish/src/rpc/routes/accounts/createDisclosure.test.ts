@@ -0,0 +1,69 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import '../../../testUtilities/matchers'
+import { useAccountFixture, useMinerBlockFixture } from '../../../testUtilities/fixtures'
+import { createRouteTest } from '../../../testUtilities/routeTest'
+
+describe('Route account/createDisclosure', () => {
+  const routeTest = createRouteTest()
+
+  it('creates disclosures that verify against the chain', async () => {
+    const { node } = routeTest
+    const account = await useAccountFixture(node.accounts, 'disclose')
+
+    const block = await useMinerBlockFixture(node.chain, 2, account, node.accounts)
+    await expect(node.chain).toAddBlock(block)
+    await node.accounts.updateHead()
+
+    const transactionHash = block.minersFee.unsignedHash().toString('hex')
+
+    const response = await routeTest.client.createDisclosure({
+      account: account.name,
+      transactionHash,
+    })
+
+    expect(response.content.disclosures).toHaveLength(1)
+    const disclosure = response.content.disclosures[0]
+
+    expect(disclosure).toMatchObject({
+      transactionHash,
+      blockHash: block.header.hash.toString('hex'),
+      noteIndex: 0,
+      owner: account.publicAddress,
+      amount: (-block.minersFee.fee()).toString(),
+    })
+
+    const valid = await routeTest.client.verifyDisclosure({ disclosure })
+    expect(valid.content).toMatchObject({
+      valid: true,
+      owner: account.publicAddress,
+      amount: disclosure.amount,
+      sequence: 2,
+      confirmations: 0,
+    })
+
+    const tampered = await routeTest.client.verifyDisclosure({
+      disclosure: { ...disclosure, amount: '1' },
+    })
+    expect(tampered.content.valid).toBe(false)
+
+    const other = await useAccountFixture(node.accounts, 'disclose-other')
+    const wrongOwner = await routeTest.client.verifyDisclosure({
+      disclosure: { ...disclosure, owner: other.publicAddress },
+    })
+    expect(wrongOwner.content.valid).toBe(false)
+  })
+
+  it('fails if the account has no notes in the transaction', async () => {
+    const { node } = routeTest
+    const account = await useAccountFixture(node.accounts, 'disclose-none')
+
+    await expect(
+      routeTest.client.createDisclosure({
+        account: account.name,
+        transactionHash: Buffer.alloc(32).toString('hex'),
+      }),
+    ).rejects.toThrow('did not send or receive a note')
+  })
+})
@@ -0,0 +1,66 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { PaymentDisclosure } from '../../../account/disclosure'
+import { ValidationError } from '../../adapters/errors'
+import { ApiNamespace, router } from '../router'
+import { getAccount } from './utils'
+
+export type CreateDisclosureRequest = {
+  account?: string
+  transactionHash: string
+  noteIndex?: number
+}
+
+export type CreateDisclosureResponse = {
+  account: string
+  disclosures: PaymentDisclosure[]
+}
+
+export const PaymentDisclosureSchema: yup.ObjectSchema<PaymentDisclosure> = yup
+  .object({
+    version: yup.number().defined(),
+    transactionHash: yup.string().defined(),
+    blockHash: yup.string().nullable().defined(),
+    noteIndex: yup.number().defined(),
+    owner: yup.string().defined(),
+    amount: yup.string().defined(),
+    memo: yup.string().defined(),
+    disclosureKey: yup.string().defined(),
+  })
+  .defined()
+
+export const CreateDisclosureRequestSchema: yup.ObjectSchema<CreateDisclosureRequest> = yup
+  .object({
+    account: yup.string().strip(true),
+    transactionHash: yup.string().defined(),
+    noteIndex: yup.number().integer().min(0).optional(),
+  })
+  .defined()
+
+export const CreateDisclosureResponseSchema: yup.ObjectSchema<CreateDisclosureResponse> = yup
+  .object({
+    account: yup.string().defined(),
+    disclosures: yup.array(PaymentDisclosureSchema).defined(),
+  })
+  .defined()
+
+router.register<typeof CreateDisclosureRequestSchema, CreateDisclosureResponse>(
+  `${ApiNamespace.account}/createDisclosure`,
+  CreateDisclosureRequestSchema,
+  (request, node): void => {
+    const account = getAccount(node, request.data.account)
+    const { transactionHash, noteIndex } = request.data
+
+    const disclosures = node.accounts.getDisclosures(account, transactionHash, noteIndex)
+
+    if (!disclosures.length) {
+      throw new ValidationError(
+        `${account.name} did not send or receive a note in transaction ${transactionHash}`,
+      )
+    }
+
+    request.end({ account: account.name, disclosures })
+  },
+)
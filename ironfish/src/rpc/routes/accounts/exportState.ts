@@ -0,0 +1,80 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { WalletState } from '../../../account'
+import { ApiNamespace, router } from '../router'
+import { getAccount } from './utils'
+
+export type ExportStateRequest = { account?: string }
+export type ExportStateResponse = { state: WalletState }
+
+export const WalletStateSchema: yup.ObjectSchema<WalletState> = yup
+  .object({
+    version: yup.number().defined(),
+    createdAt: yup.string().defined(),
+    account: yup
+      .object({
+        name: yup.string().defined(),
+        spendingKey: yup.string().defined(),
+        incomingViewKey: yup.string().defined(),
+        outgoingViewKey: yup.string().defined(),
+        publicAddress: yup.string().defined(),
+        rescan: yup.number().nullable().defined(),
+      })
+      .defined(),
+    head: yup
+      .object({
+        hash: yup.string().defined(),
+        sequence: yup.number().defined(),
+      })
+      .nullable()
+      .defined(),
+    transactions: yup
+      .array(
+        yup
+          .object({
+            hash: yup.string().defined(),
+            transaction: yup.string().defined(),
+            blockHash: yup.string().nullable().defined(),
+            submittedSequence: yup.number().nullable().defined(),
+          })
+          .defined(),
+      )
+      .defined(),
+    notes: yup
+      .array(
+        yup
+          .object({
+            merkleHash: yup.string().defined(),
+            nullifierHash: yup.string().nullable().defined(),
+            noteIndex: yup.number().nullable().defined(),
+            spent: yup.boolean().defined(),
+          })
+          .defined(),
+      )
+      .defined(),
+  })
+  .defined()
+
+export const ExportStateRequestSchema: yup.ObjectSchema<ExportStateRequest> = yup
+  .object({
+    account: yup.string().strip(true),
+  })
+  .defined()
+
+export const ExportStateResponseSchema: yup.ObjectSchema<ExportStateResponse> = yup
+  .object({
+    state: WalletStateSchema,
+  })
+  .defined()
+
+router.register<typeof ExportStateRequestSchema, ExportStateResponse>(
+  `${ApiNamespace.account}/exportState`,
+  ExportStateRequestSchema,
+  async (request, node): Promise<void> => {
+    const account = getAccount(node, request.data.account)
+    const state = await node.accounts.exportState(account)
+    request.end({ state })
+  },
+)
@@ -0,0 +1,39 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { ApiNamespace, router } from '../router'
+import { getAccount } from './utils'
+
+export type GetAliasesRequest = { account?: string }
+export type GetAliasesResponse = { aliases: { alias: string; account: string }[] }
+
+export const GetAliasesRequestSchema: yup.ObjectSchema<GetAliasesRequest> = yup
+  .object({
+    account: yup.string().optional(),
+  })
+  .defined()
+
+export const GetAliasesResponseSchema: yup.ObjectSchema<GetAliasesResponse> = yup
+  .object({
+    aliases: yup
+      .array(
+        yup
+          .object({
+            alias: yup.string().defined(),
+            account: yup.string().defined(),
+          })
+          .defined(),
+      )
+      .defined(),
+  })
+  .defined()
+
+router.register<typeof GetAliasesRequestSchema, GetAliasesResponse>(
+  `${ApiNamespace.account}/getAliases`,
+  GetAliasesRequestSchema,
+  (request, node): void => {
+    const account = request.data.account ? getAccount(node, request.data.account) : undefined
+    request.end({ aliases: node.accounts.getAliases(account) })
+  },
+)
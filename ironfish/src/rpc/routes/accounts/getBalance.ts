@@ -5,12 +5,36 @@ import * as yup from 'yup'
 import { ApiNamespace, router } from '../router'
 import { getAccount } from './utils'
 
-export type GetBalanceRequest = { account?: string }
-export type GetBalanceResponse = { account: string; confirmed: string; unconfirmed: string }
+export type GetBalanceRequest = { account?: string; minConfirmations?: number }
+
+export type GetBalanceResponse = {
+  account: string
+  confirmed: string
+  unconfirmed: string
+  // Confirmed coins held by outgoing transactions that have not been mined
+  held: string
+  // What can be spent, the confirmed balance less what is held
+  available: string
+  minConfirmations: number
+  // The balance of notes with at least each number of confirmations
+  buckets: { confirmations: number; balance: string }[]
+  // Where the balance of each asset is, and why it can or cannot be spent
+  breakdown: {
+    asset: string
+    available: string
+    immature: string
+    unconfirmed: string
+    pendingChange: string
+    locked: string
+  }[]
+  // Set when recent reorgs were deep enough to reverse confirmed payments
+  warning?: string
+}
 
 export const GetBalanceRequestSchema: yup.ObjectSchema<GetBalanceRequest> = yup
   .object({
     account: yup.string().strip(true),
+    minConfirmations: yup.number().integer().min(0).optional(),
   })
   .defined()
 
@@ -19,6 +43,34 @@ export const GetBalanceResponseSchema: yup.ObjectSchema<GetBalanceResponse> = yu
     account: yup.string().defined(),
     unconfirmed: yup.string().defined(),
     confirmed: yup.string().defined(),
+    held: yup.string().defined(),
+    available: yup.string().defined(),
+    minConfirmations: yup.number().defined(),
+    buckets: yup
+      .array(
+        yup
+          .object({
+            confirmations: yup.number().defined(),
+            balance: yup.string().defined(),
+          })
+          .defined(),
+      )
+      .defined(),
+    breakdown: yup
+      .array(
+        yup
+          .object({
+            asset: yup.string().defined(),
+            available: yup.string().defined(),
+            immature: yup.string().defined(),
+            unconfirmed: yup.string().defined(),
+            pendingChange: yup.string().defined(),
+            locked: yup.string().defined(),
+          })
+          .defined(),
+      )
+      .defined(),
+    warning: yup.string().optional(),
   })
   .defined()
 
@@ -27,12 +79,43 @@ router.register<typeof GetBalanceRequestSchema, GetBalanceResponse>(
   GetBalanceRequestSchema,
   async (request, node): Promise<void> => {
     const account = getAccount(node, request.data.account)
-    const { confirmed, unconfirmed } = await node.accounts.getBalance(account)
+    const minConfirmations =
+      request.data.minConfirmations ?? node.config.get('minimumBlockConfirmations')
+
+    const { confirmed: available, unconfirmed } = await node.accounts.getBalance(account, {
+      minimumBlockConfirmations: minConfirmations,
+    })
+
+    const held = await node.accounts.getHeldBalance(account, {
+      minimumBlockConfirmations: minConfirmations,
+    })
+
+    const buckets = await node.accounts.getBalanceBuckets(account)
+
+    const breakdown = await node.accounts.getBalanceBreakdown(account, {
+      minimumBlockConfirmations: minConfirmations,
+    })
 
     request.end({
       account: account.displayName,
-      confirmed: confirmed.toString(),
+      confirmed: (available.valueOf() + held).toString(),
       unconfirmed: unconfirmed.toString(),
+      held: held.toString(),
+      available: available.toString(),
+      minConfirmations,
+      buckets: buckets.map((b) => ({
+        confirmations: b.confirmations,
+        balance: b.balance.toString(),
+      })),
+      breakdown: breakdown.map((b) => ({
+        asset: b.asset,
+        available: b.available.toString(),
+        immature: b.immature.toString(),
+        unconfirmed: b.unconfirmed.toString(),
+        pendingChange: b.pendingChange.toString(),
+        locked: b.locked.toString(),
+      })),
+      warning: node.accounts.getConfirmationWarning(minConfirmations) ?? undefined,
     })
   },
 )
@@ -5,7 +5,7 @@ import * as yup from 'yup'
 import { ApiNamespace, router } from '../router'
 import { getAccount } from './utils'
 
-export type GetAccountNotesRequest = { account?: string }
+export type GetAccountNotesRequest = { account?: string; includeDust?: boolean }
 
 export type GetAccountNotesResponse = {
   account: string
@@ -14,12 +14,14 @@ export type GetAccountNotesResponse = {
     amount: number
     memo: string
     noteTxHash: string
+    dust: boolean
   }[]
 }
 
 export const GetAccountNotesRequestSchema: yup.ObjectSchema<GetAccountNotesRequest> = yup
   .object({
     account: yup.string().strip(true),
+    includeDust: yup.boolean().optional(),
   })
   .defined()
 
@@ -34,6 +36,7 @@ export const GetAccountNotesResponseSchema: yup.ObjectSchema<GetAccountNotesResp
             amount: yup.number().defined(),
             memo: yup.string().trim().defined(),
             noteTxHash: yup.string().defined(),
+            dust: yup.boolean().defined(),
           })
           .defined(),
       )
@@ -47,6 +50,10 @@ router.register<typeof GetAccountNotesRequestSchema, GetAccountNotesResponse>(
   (request, node): void => {
     const account = getAccount(node, request.data.account)
     const { notes } = node.accounts.getNotes(account)
-    request.end({ account: account.displayName, notes })
+
+    request.end({
+      account: account.displayName,
+      notes: request.data.includeDust ? notes : notes.filter((n) => !n.dust),
+    })
   },
 )
@@ -0,0 +1,79 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import '../../../testUtilities/matchers'
+import { Account } from '../../../account'
+import { verifyReceipt } from '../../../account/receipt'
+import { useAccountFixture, useMinerBlockFixture } from '../../../testUtilities/fixtures'
+import { createRouteTest } from '../../../testUtilities/routeTest'
+
+describe('Route account/getReceipt', () => {
+  const routeTest = createRouteTest()
+
+  it('creates a signed receipt for a transaction', async () => {
+    const { node } = routeTest
+    const account = await useAccountFixture(node.accounts, 'receipt')
+
+    const blockA = await useMinerBlockFixture(node.chain, 2, account, node.accounts)
+    await expect(node.chain).toAddBlock(blockA)
+    const blockB = await useMinerBlockFixture(node.chain, 3)
+    await expect(node.chain).toAddBlock(blockB)
+    await node.accounts.updateHead()
+
+    const hash = blockA.minersFee.unsignedHash().toString('hex')
+    const amount = (-blockA.minersFee.fee()).toString()
+
+    const response = await routeTest.client.getReceipt({ account: account.name, hash })
+    const { receipt, text } = response.content
+
+    expect(receipt).toMatchObject({
+      account: account.name,
+      publicAddress: account.publicAddress,
+      transactionHash: hash,
+      status: 'completed',
+      asset: '$IRON',
+      netAmount: amount,
+      notes: [{ sent: false, owner: account.publicAddress, amount }],
+      block: {
+        hash: blockA.header.hash.toString('hex'),
+        sequence: 2,
+      },
+      confirmations: 1,
+    })
+
+    expect(text).toContain(hash)
+    expect(verifyReceipt(receipt)).toBe(true)
+    expect(verifyReceipt({ ...receipt, netAmount: '1' })).toBe(false)
+  })
+
+  it('fails if the account has no notes in the transaction', async () => {
+    const account = await useAccountFixture(routeTest.node.accounts, 'receipt-none')
+
+    await expect(
+      routeTest.client.getReceipt({
+        account: account.name,
+        hash: Buffer.alloc(32).toString('hex'),
+      }),
+    ).rejects.toThrow('did not send or receive a note')
+  })
+
+  it('refuses view only accounts', async () => {
+    const { node } = routeTest
+    const account = await useAccountFixture(node.accounts, 'receipt-view')
+
+    const block = await useMinerBlockFixture(node.chain, undefined, account, node.accounts)
+    await expect(node.chain).toAddBlock(block)
+    await node.accounts.updateHead()
+
+    // Only the view keys of the account are known
+    const viewOnly = new Account({ ...account.serialize(), spendingKey: '' })
+    node.accounts['accounts'].set(account.name, viewOnly)
+
+    await expect(
+      routeTest.client.getReceipt({
+        account: account.name,
+        hash: block.minersFee.unsignedHash().toString('hex'),
+      }),
+    ).rejects.toThrow(`${account.name} has no spending key`)
+  })
+})
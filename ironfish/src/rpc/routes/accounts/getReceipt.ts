@@ -0,0 +1,86 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { renderReceipt, TransactionReceipt } from '../../../account/receipt'
+import { ValidationError } from '../../adapters/errors'
+import { ApiNamespace, router } from '../router'
+import { getAccount } from './utils'
+
+export type GetReceiptRequest = { account?: string; hash: string }
+
+export type GetReceiptResponse = {
+  receipt: TransactionReceipt
+  // The receipt rendered as text
+  text: string
+}
+
+export const GetReceiptRequestSchema: yup.ObjectSchema<GetReceiptRequest> = yup
+  .object({
+    account: yup.string().strip(true),
+    hash: yup.string().defined(),
+  })
+  .defined()
+
+export const TransactionReceiptSchema: yup.ObjectSchema<TransactionReceipt> = yup
+  .object({
+    version: yup.number().defined(),
+    account: yup.string().defined(),
+    publicAddress: yup.string().defined(),
+    transactionHash: yup.string().defined(),
+    status: yup
+      .mixed<TransactionReceipt['status']>()
+      .oneOf(['pending', 'completed', 'forked'])
+      .defined(),
+    asset: yup.string().defined(),
+    fee: yup.string().defined(),
+    netAmount: yup.string().defined(),
+    notes: yup
+      .array(
+        yup
+          .object({
+            sent: yup.boolean().defined(),
+            owner: yup.string().defined(),
+            amount: yup.string().defined(),
+            memo: yup.string().defined(),
+          })
+          .defined(),
+      )
+      .defined(),
+    block: yup
+      .object({
+        hash: yup.string().defined(),
+        sequence: yup.number().defined(),
+        timestamp: yup.number().defined(),
+      })
+      .nullable()
+      .defined(),
+    confirmations: yup.number().defined(),
+    exportedAt: yup.number().defined(),
+    signature: yup.string().defined(),
+  })
+  .defined()
+
+export const GetReceiptResponseSchema: yup.ObjectSchema<GetReceiptResponse> = yup
+  .object({
+    receipt: TransactionReceiptSchema,
+    text: yup.string().defined(),
+  })
+  .defined()
+
+router.register<typeof GetReceiptRequestSchema, GetReceiptResponse>(
+  `${ApiNamespace.account}/getReceipt`,
+  GetReceiptRequestSchema,
+  async (request, node): Promise<void> => {
+    const account = getAccount(node, request.data.account)
+
+    const receipt = await node.accounts.getReceipt(account, request.data.hash)
+    if (!receipt) {
+      throw new ValidationError(
+        `${account.name} did not send or receive a note in transaction ${request.data.hash}`,
+      )
+    }
+
+    request.end({ receipt, text: renderReceipt(receipt) })
+  },
+)
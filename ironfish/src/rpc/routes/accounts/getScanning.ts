@@ -0,0 +1,64 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { ApiNamespace, router } from '../router'
+import { getAccount } from './utils'
+
+export type GetScanningRequest = { account?: string }
+export type GetScanningResponse = {
+  accounts: {
+    name: string
+    enabled: boolean
+    // The head sequence when scanning was disabled
+    disabledSequence: number | null
+    // The highest block scanned for the account
+    sequence: number
+    // How many blocks behind the chain head the account is scanned to
+    behind: number
+  }[]
+}
+
+export const GetScanningRequestSchema: yup.ObjectSchema<GetScanningRequest> = yup
+  .object({
+    account: yup.string().optional(),
+  })
+  .defined()
+
+export const GetScanningResponseSchema: yup.ObjectSchema<GetScanningResponse> = yup
+  .object({
+    accounts: yup
+      .array(
+        yup
+          .object({
+            name: yup.string().defined(),
+            enabled: yup.boolean().defined(),
+            disabledSequence: yup.number().nullable().defined(),
+            sequence: yup.number().defined(),
+            behind: yup.number().defined(),
+          })
+          .defined(),
+      )
+      .defined(),
+  })
+  .defined()
+
+router.register<typeof GetScanningRequestSchema, GetScanningResponse>(
+  `${ApiNamespace.account}/getScanning`,
+  GetScanningRequestSchema,
+  (request, node): void => {
+    const accounts = request.data.account
+      ? [getAccount(node, request.data.account)]
+      : node.accounts.listAccounts()
+
+    request.end({
+      accounts: accounts.map((account) => ({
+        name: account.name,
+        enabled: node.accounts.isScanningEnabled(account),
+        disabledSequence: node.accounts.getScanningDisabledSequence(account),
+        sequence: node.accounts.getScannedSequence(account),
+        behind: node.accounts.getScanLag(account),
+      })),
+    })
+  },
+)
@@ -0,0 +1,111 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { ApiNamespace, router } from '../router'
+import { getAccount } from './utils'
+
+export type GetSummaryRequest = {
+  account?: string
+  // Block timestamps in ms, the summary covers all transactions if not set
+  start?: number
+  end?: number
+  // How many of the largest payments to return
+  limit?: number
+}
+
+export type GetSummaryResponse = {
+  account: string
+  start: number | null
+  end: number | null
+  transactions: number
+  pending: number
+  assets: { asset: string; received: string; sent: string }[]
+  feesPaid: string
+  minersFees: string
+  largest: {
+    sent: boolean
+    amount: string
+    memo: string
+    counterparty: string | null
+    timestamp: number
+  }[]
+  averageConfirmationTime: number | null
+}
+
+export const GetSummaryRequestSchema: yup.ObjectSchema<GetSummaryRequest> = yup
+  .object({
+    account: yup.string().strip(true),
+    start: yup.number().min(0).optional(),
+    end: yup.number().min(0).optional(),
+    limit: yup.number().integer().min(0).optional(),
+  })
+  .defined()
+
+export const GetSummaryResponseSchema: yup.ObjectSchema<GetSummaryResponse> = yup
+  .object({
+    account: yup.string().defined(),
+    start: yup.number().nullable().defined(),
+    end: yup.number().nullable().defined(),
+    transactions: yup.number().defined(),
+    pending: yup.number().defined(),
+    assets: yup
+      .array(
+        yup
+          .object({
+            asset: yup.string().defined(),
+            received: yup.string().defined(),
+            sent: yup.string().defined(),
+          })
+          .defined(),
+      )
+      .defined(),
+    feesPaid: yup.string().defined(),
+    minersFees: yup.string().defined(),
+    largest: yup
+      .array(
+        yup
+          .object({
+            sent: yup.boolean().defined(),
+            amount: yup.string().defined(),
+            memo: yup.string().defined(),
+            counterparty: yup.string().nullable().defined(),
+            timestamp: yup.number().defined(),
+          })
+          .defined(),
+      )
+      .defined(),
+    averageConfirmationTime: yup.number().nullable().defined(),
+  })
+  .defined()
+
+router.register<typeof GetSummaryRequestSchema, GetSummaryResponse>(
+  `${ApiNamespace.account}/getSummary`,
+  GetSummaryRequestSchema,
+  async (request, node): Promise<void> => {
+    const account = getAccount(node, request.data.account)
+
+    const summary = await node.accounts.getSummary(account, {
+      start: request.data.start,
+      end: request.data.end,
+      limit: request.data.limit,
+    })
+
+    request.end({
+      account: account.name,
+      start: summary.start,
+      end: summary.end,
+      transactions: summary.transactions,
+      pending: summary.pending,
+      assets: summary.assets.map((a) => ({
+        asset: a.asset,
+        received: a.received.toString(),
+        sent: a.sent.toString(),
+      })),
+      feesPaid: summary.feesPaid.toString(),
+      minersFees: summary.minersFees.toString(),
+      largest: summary.largest.map((p) => ({ ...p, amount: p.amount.toString() })),
+      averageConfirmationTime: summary.averageConfirmationTime,
+    })
+  },
+)
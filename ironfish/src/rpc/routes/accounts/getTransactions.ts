@@ -5,7 +5,7 @@ import * as yup from 'yup'
 import { ApiNamespace, router } from '../router'
 import { getAccount } from './utils'
 
-export type GetAccountTransactionsRequest = { account?: string }
+export type GetAccountTransactionsRequest = { account?: string; includeDust?: boolean }
 
 export type GetAccountTransactionsResponse = {
   account: string
@@ -18,6 +18,7 @@ export type GetAccountTransactionsResponse = {
     notes: number
     spends: number
     expiration: number
+    dust: boolean
   }[]
 }
 
@@ -25,6 +26,7 @@ export const GetAccountTransactionsRequestSchema: yup.ObjectSchema<GetAccountTra
   yup
     .object({
       account: yup.string().strip(true),
+      includeDust: yup.boolean().optional(),
     })
     .defined()
 
@@ -44,6 +46,7 @@ export const GetAccountTransactionsResponseSchema: yup.ObjectSchema<GetAccountTr
               notes: yup.number().defined(),
               spends: yup.number().defined(),
               expiration: yup.number().defined(),
+              dust: yup.boolean().defined(),
             })
             .defined(),
         )
@@ -57,6 +60,12 @@ router.register<typeof GetAccountTransactionsRequestSchema, GetAccountTransactio
   async (request, node): Promise<void> => {
     const account = getAccount(node, request.data.account)
     const transactions = await node.accounts.getTransactions(account)
-    request.end({ account: account.displayName, transactions })
+
+    request.end({
+      account: account.displayName,
+      transactions: request.data.includeDust
+        ? transactions
+        : transactions.filter((t) => !t.dust),
+    })
   },
 )
@@ -0,0 +1,51 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { WalletState, WalletStateImport } from '../../../account'
+import { ApiNamespace, router } from '../router'
+import { WalletStateSchema } from './exportState'
+
+export type ImportStateRequest = { state: WalletState }
+
+export type ImportStateResponse = WalletStateImport & {
+  name: string
+  isDefaultAccount: boolean
+}
+
+export const ImportStateRequestSchema: yup.ObjectSchema<ImportStateRequest> = yup
+  .object({
+    state: WalletStateSchema,
+  })
+  .defined()
+
+export const ImportStateResponseSchema: yup.ObjectSchema<ImportStateResponse> = yup
+  .object({
+    name: yup.string().defined(),
+    isDefaultAccount: yup.boolean().defined(),
+    transactions: yup.number().defined(),
+    notes: yup.number().defined(),
+    scanned: yup.number().defined(),
+    rescan: yup.boolean().defined(),
+  })
+  .defined()
+
+router.register<typeof ImportStateRequestSchema, ImportStateResponse>(
+  `${ApiNamespace.account}/importState`,
+  ImportStateRequestSchema,
+  async (request, node): Promise<void> => {
+    const { account, result } = await node.accounts.importState(request.data.state)
+
+    let isDefaultAccount = false
+    if (!node.accounts.hasDefaultAccount) {
+      await node.accounts.setDefaultAccount(account.name)
+      isDefaultAccount = true
+    }
+
+    request.end({
+      name: account.name,
+      isDefaultAccount,
+      ...result,
+    })
+  },
+)
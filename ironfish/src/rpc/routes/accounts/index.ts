@@ -3,15 +3,30 @@
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 
 export * from './create'
+export * from './createDisclosure'
 export * from './exportAccount'
+export * from './exportState'
 export * from './getAccounts'
+export * from './getAliases'
 export * from './getDefaultAccount'
 export * from './getNotes'
 export * from './getBalance'
 export * from './getPublicKey'
+export * from './getReceipt'
+export * from './getScanning'
+export * from './getSummary'
 export * from './getTransaction'
 export * from './getTransactions'
 export * from './importAccount'
+export * from './importState'
+export * from './rebuildAccounts'
 export * from './removeAccount'
+export * from './removeAlias'
 export * from './rescanAccount'
+export * from './setAlias'
+export * from './setScanning'
+export * from './signMessage'
 export * from './useAccount'
+export * from './verifyDisclosure'
+export * from './verifyMessage'
+export * from './watchDeposits'
@@ -0,0 +1,43 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { ERROR_CODES, ValidationError } from '../../adapters'
+import { ApiNamespace, router } from '../router'
+
+export type RebuildAccountsRequest = Record<string, never> | undefined
+export type RebuildAccountsResponse = {
+  // The number of wallet events that were replayed
+  events: number
+  transactions: number
+  notes: number
+}
+
+export const RebuildAccountsRequestSchema: yup.MixedSchema<RebuildAccountsRequest> = yup
+  .mixed()
+  .oneOf([undefined] as const)
+
+export const RebuildAccountsResponseSchema: yup.ObjectSchema<RebuildAccountsResponse> = yup
+  .object({
+    events: yup.number().defined(),
+    transactions: yup.number().defined(),
+    notes: yup.number().defined(),
+  })
+  .defined()
+
+router.register<typeof RebuildAccountsRequestSchema, RebuildAccountsResponse>(
+  `${ApiNamespace.account}/rebuild`,
+  RebuildAccountsRequestSchema,
+  async (request, node): Promise<void> => {
+    if (node.accounts.scan) {
+      throw new ValidationError(
+        `Cannot rebuild the wallet while a rescan is running`,
+        undefined,
+        ERROR_CODES.LOCKED,
+      )
+    }
+
+    const result = await node.accounts.rebuild()
+    request.end(result)
+  },
+)
@@ -0,0 +1,33 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { ValidationError } from '../../adapters'
+import { ApiNamespace, router } from '../router'
+
+export type RemoveAliasRequest = { alias: string }
+export type RemoveAliasResponse = undefined
+
+export const RemoveAliasRequestSchema: yup.ObjectSchema<RemoveAliasRequest> = yup
+  .object({
+    alias: yup.string().trim().defined(),
+  })
+  .defined()
+
+export const RemoveAliasResponseSchema: yup.MixedSchema<RemoveAliasResponse> = yup
+  .mixed()
+  .oneOf([undefined] as const)
+
+router.register<typeof RemoveAliasRequestSchema, RemoveAliasResponse>(
+  `${ApiNamespace.account}/removeAlias`,
+  RemoveAliasRequestSchema,
+  async (request, node): Promise<void> => {
+    const removed = await node.accounts.removeAlias(request.data.alias)
+
+    if (!removed) {
+      throw new ValidationError(`No alias named ${request.data.alias}`)
+    }
+
+    request.end()
+  },
+)
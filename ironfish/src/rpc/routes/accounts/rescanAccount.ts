@@ -2,7 +2,7 @@
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 import * as yup from 'yup'
-import { ValidationError } from '../../adapters/errors'
+import { ERROR_CODES, ValidationError } from '../../adapters/errors'
 import { ApiNamespace, router } from '../router'
 
 export type RescanAccountRequest = { follow?: boolean; reset?: boolean }
@@ -30,7 +30,11 @@ router.register<typeof RescanAccountRequestSchema, RescanAccountResponse>(
     let scan = node.accounts.scan
 
     if (scan && !request.data.follow) {
-      throw new ValidationError(`A transaction rescan is already running`)
+      throw new ValidationError(
+        `A transaction rescan is already running`,
+        undefined,
+        ERROR_CODES.LOCKED,
+      )
     }
 
     if (!scan) {
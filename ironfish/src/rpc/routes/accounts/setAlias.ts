@@ -0,0 +1,44 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { ValidationError } from '../../adapters'
+import { ApiNamespace, router } from '../router'
+import { getAccount } from './utils'
+
+export type SetAliasRequest = { alias: string; account: string }
+export type SetAliasResponse = { alias: string; account: string }
+
+export const SetAliasRequestSchema: yup.ObjectSchema<SetAliasRequest> = yup
+  .object({
+    alias: yup
+      .string()
+      .trim()
+      .matches(/^\S+$/, 'The alias cannot contain spaces')
+      .defined(),
+    account: yup.string().defined(),
+  })
+  .defined()
+
+export const SetAliasResponseSchema: yup.ObjectSchema<SetAliasResponse> = yup
+  .object({
+    alias: yup.string().defined(),
+    account: yup.string().defined(),
+  })
+  .defined()
+
+router.register<typeof SetAliasRequestSchema, SetAliasResponse>(
+  `${ApiNamespace.account}/setAlias`,
+  SetAliasRequestSchema,
+  async (request, node): Promise<void> => {
+    const { alias } = request.data
+    const account = getAccount(node, request.data.account)
+
+    if (node.accounts.accountExists(alias)) {
+      throw new ValidationError(`There is already an account with the name ${alias}`)
+    }
+
+    await node.accounts.setAlias(alias, account)
+    request.end({ alias, account: account.name })
+  },
+)
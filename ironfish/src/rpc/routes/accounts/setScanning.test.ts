@@ -0,0 +1,44 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { useAccountFixture } from '../../../testUtilities'
+import { createRouteTest } from '../../../testUtilities/routeTest'
+
+describe('Route account/setScanning', () => {
+  const routeTest = createRouteTest(true)
+
+  it('disables and enables scanning for an account', async () => {
+    const account = await useAccountFixture(routeTest.node.accounts, 'setScanning')
+
+    const disabled = await routeTest.client.setScanning({
+      account: account.name,
+      enabled: false,
+    })
+    expect(disabled.content).toEqual({ account: account.name, enabled: false })
+
+    const status = await routeTest.client.getScanning({ account: account.name })
+    expect(status.content.accounts).toEqual([
+      {
+        name: account.name,
+        enabled: false,
+        disabledSequence: routeTest.chain.head.sequence,
+        sequence: routeTest.chain.head.sequence,
+        behind: 0,
+      },
+    ])
+
+    const enabled = await routeTest.client.setScanning({
+      account: account.name,
+      enabled: true,
+    })
+    expect(enabled.content).toEqual({ account: account.name, enabled: true })
+    expect(routeTest.node.accounts.isScanningEnabled(account)).toBe(true)
+    expect(account.rescan).not.toBeNull()
+  })
+
+  it('throws if the account does not exist', async () => {
+    await expect(
+      routeTest.client.setScanning({ account: 'AccountDoesNotExist', enabled: false }),
+    ).rejects.toThrowError('No account with name AccountDoesNotExist')
+  })
+})
@@ -0,0 +1,38 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { ApiNamespace, router } from '../router'
+import { getAccount } from './utils'
+
+export type SetScanningRequest = { account: string; enabled: boolean }
+export type SetScanningResponse = { account: string; enabled: boolean }
+
+export const SetScanningRequestSchema: yup.ObjectSchema<SetScanningRequest> = yup
+  .object({
+    account: yup.string().defined(),
+    enabled: yup.boolean().defined(),
+  })
+  .defined()
+
+export const SetScanningResponseSchema: yup.ObjectSchema<SetScanningResponse> = yup
+  .object({
+    account: yup.string().defined(),
+    enabled: yup.boolean().defined(),
+  })
+  .defined()
+
+router.register<typeof SetScanningRequestSchema, SetScanningResponse>(
+  `${ApiNamespace.account}/setScanning`,
+  SetScanningRequestSchema,
+  async (request, node): Promise<void> => {
+    const account = getAccount(node, request.data.account)
+
+    await node.accounts.setScanningEnabled(account, request.data.enabled)
+
+    request.end({
+      account: account.name,
+      enabled: node.accounts.isScanningEnabled(account),
+    })
+  },
+)
@@ -0,0 +1,63 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { v4 as uuid } from 'uuid'
+import { Account } from '../../../account'
+import { createRouteTest } from '../../../testUtilities/routeTest'
+
+describe('Route account/signMessage', () => {
+  const routeTest = createRouteTest(true)
+
+  it('signs messages that only verify for the address and message', async () => {
+    const account = await routeTest.node.accounts.createAccount(uuid())
+    const other = await routeTest.node.accounts.createAccount(uuid())
+
+    const signed = await routeTest.client.signMessage({
+      account: account.name,
+      message: 'hello',
+    })
+
+    expect(signed.content).toMatchObject({
+      account: account.name,
+      publicAddress: account.publicAddress,
+      message: 'hello',
+    })
+
+    const verify = async (publicAddress: string, message: string) => {
+      const response = await routeTest.client.verifyMessage({
+        publicAddress,
+        message,
+        signature: signed.content.signature,
+      })
+      return response.content.valid
+    }
+
+    await expect(verify(account.publicAddress, 'hello')).resolves.toBe(true)
+    await expect(verify(account.publicAddress, 'goodbye')).resolves.toBe(false)
+    await expect(verify(other.publicAddress, 'hello')).resolves.toBe(false)
+  })
+
+  it('refuses view only accounts', async () => {
+    const account = await routeTest.node.accounts.createAccount(uuid())
+
+    // Only the view keys of the account are known
+    const viewOnly = new Account({ ...account.serialize(), spendingKey: '' })
+    routeTest.node.accounts['accounts'].set(account.name, viewOnly)
+
+    await expect(
+      routeTest.client.signMessage({ account: account.name, message: 'hello' }),
+    ).rejects.toThrow(`${account.name} has no spending key`)
+  })
+
+  it('rejects signatures that are not hex', async () => {
+    const account = await routeTest.node.accounts.createAccount(uuid())
+
+    await expect(
+      routeTest.client.verifyMessage({
+        publicAddress: account.publicAddress,
+        message: 'hello',
+        signature: 'not hex',
+      }),
+    ).rejects.toThrow('The signature must be hex')
+  })
+})
@@ -0,0 +1,62 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { signMessage } from '@ironfish/rust-nodejs'
+import * as yup from 'yup'
+import { ValidationError } from '../../adapters/errors'
+import { ApiNamespace, router } from '../router'
+import { getAccount } from './utils'
+
+export type SignMessageRequest = { account?: string; message: string }
+
+export type SignMessageResponse = {
+  account: string
+  publicAddress: string
+  message: string
+  signature: string
+}
+
+export const SignMessageRequestSchema: yup.ObjectSchema<SignMessageRequest> = yup
+  .object({
+    account: yup.string().strip(true),
+    message: yup.string().defined(),
+  })
+  .defined()
+
+export const SignMessageResponseSchema: yup.ObjectSchema<SignMessageResponse> = yup
+  .object({
+    account: yup.string().defined(),
+    publicAddress: yup.string().defined(),
+    message: yup.string().defined(),
+    signature: yup.string().defined(),
+  })
+  .defined()
+
+router.register<typeof SignMessageRequestSchema, SignMessageResponse>(
+  `${ApiNamespace.account}/signMessage`,
+  SignMessageRequestSchema,
+  (request, node): void => {
+    const account = getAccount(node, request.data.account)
+
+    // Signed with the spend authorizing key like spends, so the view keys
+    // alone can't sign for an address
+    if (!account.spendingKey) {
+      throw new ValidationError(
+        `${account.name} has no spending key, so it can't sign messages`,
+      )
+    }
+
+    const signature = signMessage(
+      account.spendingKey,
+      account.publicAddress,
+      Buffer.from(request.data.message, 'utf8'),
+    )
+
+    request.end({
+      account: account.name,
+      publicAddress: account.publicAddress,
+      message: request.data.message,
+      signature: signature.toString('hex'),
+    })
+  },
+)
@@ -2,7 +2,7 @@
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 import * as yup from 'yup'
-import { ValidationError } from '../../adapters'
+import { ERROR_CODES, ValidationError } from '../../adapters'
 import { ApiNamespace, router } from '../router'
 
 export type UseAccountRequest = { name: string }
@@ -23,7 +23,7 @@ router.register<typeof UseAccountRequestSchema, UseAccountResponse>(
   UseAccountRequestSchema,
   async (request, node): Promise<void> => {
     const name = request.data.name
-    const account = node.accounts.getAccountByName(name)
+    const account = node.accounts.resolveAccount(name)
 
     if (!account) {
       throw new ValidationError(
@@ -32,6 +32,8 @@ router.register<typeof UseAccountRequestSchema, UseAccountResponse>(
             .listAccounts()
             .map((a) => a.name)
             .join('\n'),
+        undefined,
+        ERROR_CODES.ACCOUNT_NOT_FOUND,
       )
     }
 
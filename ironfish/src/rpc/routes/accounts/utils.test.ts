@@ -3,6 +3,7 @@
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 
 import { createRouteTest } from '../../../testUtilities/routeTest'
+import { ERROR_CODES } from '../../adapters'
 import { getAccount } from './utils'
 
 describe('Accounts utils', () => {
@@ -20,6 +21,13 @@ describe('Accounts utils', () => {
       expect(() => {
         getAccount(routeTest.node, 'badAccount')
       }).toThrow('No account with name')
+
+      try {
+        expect.assertions(2)
+        getAccount(routeTest.node, 'badAccount')
+      } catch (e: unknown) {
+        expect(e).toMatchObject({ code: ERROR_CODES.ACCOUNT_NOT_FOUND })
+      }
     })
 
     it('should pass if account is found with name', () => {
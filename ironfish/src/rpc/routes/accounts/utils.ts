@@ -3,15 +3,19 @@
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 import { Account } from '../../../account'
 import { IronfishNode } from '../../../node'
-import { ValidationError } from '../../adapters'
+import { ERROR_CODES, ValidationError } from '../../adapters'
 
 export function getAccount(node: IronfishNode, name?: string): Account {
   if (name) {
-    const account = node.accounts.getAccountByName(name)
+    const account = node.accounts.resolveAccount(name)
     if (account) {
       return account
     }
-    throw new ValidationError(`No account with name ${name}`)
+    throw new ValidationError(
+      `No account with name ${name}`,
+      undefined,
+      ERROR_CODES.ACCOUNT_NOT_FOUND,
+    )
   }
 
   const defaultAccount = node.accounts.getDefaultAccount()
@@ -22,5 +26,7 @@ export function getAccount(node: IronfishNode, name?: string): Account {
   throw new ValidationError(
     `No account is currently active.\n\n` +
       `Use ironfish accounts:create <name> to first create an account`,
+    undefined,
+    ERROR_CODES.ACCOUNT_NOT_FOUND,
   )
 }
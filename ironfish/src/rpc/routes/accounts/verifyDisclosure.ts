@@ -0,0 +1,84 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { PaymentDisclosure, verifyDisclosure } from '../../../account/disclosure'
+import { ApiNamespace, router } from '../router'
+import { PaymentDisclosureSchema } from './createDisclosure'
+
+export type VerifyDisclosureRequest = { disclosure: PaymentDisclosure }
+
+export type VerifyDisclosureResponse = {
+  valid: boolean
+  // Why the disclosure is not valid
+  reason?: string
+  owner?: string
+  amount?: string
+  memo?: string
+  sequence?: number
+  confirmations?: number
+}
+
+export const VerifyDisclosureRequestSchema: yup.ObjectSchema<VerifyDisclosureRequest> = yup
+  .object({
+    disclosure: PaymentDisclosureSchema,
+  })
+  .defined()
+
+export const VerifyDisclosureResponseSchema: yup.ObjectSchema<VerifyDisclosureResponse> = yup
+  .object({
+    valid: yup.boolean().defined(),
+    reason: yup.string().optional(),
+    owner: yup.string().optional(),
+    amount: yup.string().optional(),
+    memo: yup.string().optional(),
+    sequence: yup.number().optional(),
+    confirmations: yup.number().optional(),
+  })
+  .defined()
+
+router.register<typeof VerifyDisclosureRequestSchema, VerifyDisclosureResponse>(
+  `${ApiNamespace.account}/verifyDisclosure`,
+  VerifyDisclosureRequestSchema,
+  async (request, node): Promise<void> => {
+    const { disclosure } = request.data
+
+    if (!disclosure.blockHash) {
+      request.end({ valid: false, reason: 'The transaction was not on the chain yet' })
+      return
+    }
+
+    const header = await node.chain.getHeader(Buffer.from(disclosure.blockHash, 'hex'))
+    const block = header ? await node.chain.getBlock(header) : null
+
+    if (!header || !block || !(await node.chain.isHeadChain(header))) {
+      request.end({ valid: false, reason: 'The block is not on the main chain' })
+      return
+    }
+
+    const transaction = block.transactions.find(
+      (t) => t.unsignedHash().toString('hex') === disclosure.transactionHash,
+    )
+
+    if (!transaction) {
+      request.end({ valid: false, reason: 'The transaction is not in the block' })
+      return
+    }
+
+    const result = verifyDisclosure(transaction, disclosure)
+
+    if (!result.valid) {
+      request.end({ valid: false, reason: result.reason })
+      return
+    }
+
+    request.end({
+      valid: true,
+      owner: result.owner,
+      amount: result.amount,
+      memo: result.memo,
+      sequence: header.sequence,
+      confirmations: node.chain.head.sequence - header.sequence,
+    })
+  },
+)
@@ -0,0 +1,54 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { verifyMessage } from '@ironfish/rust-nodejs'
+import * as yup from 'yup'
+import { isValidPublicAddress } from '../../../account/validator'
+import { ValidationError } from '../../adapters/errors'
+import { ApiNamespace, router } from '../router'
+
+export type VerifyMessageRequest = {
+  publicAddress: string
+  message: string
+  signature: string
+}
+
+export type VerifyMessageResponse = { valid: boolean }
+
+export const VerifyMessageRequestSchema: yup.ObjectSchema<VerifyMessageRequest> = yup
+  .object({
+    publicAddress: yup.string().defined(),
+    message: yup.string().defined(),
+    signature: yup.string().defined(),
+  })
+  .defined()
+
+export const VerifyMessageResponseSchema: yup.ObjectSchema<VerifyMessageResponse> = yup
+  .object({
+    valid: yup.boolean().defined(),
+  })
+  .defined()
+
+router.register<typeof VerifyMessageRequestSchema, VerifyMessageResponse>(
+  `${ApiNamespace.account}/verifyMessage`,
+  VerifyMessageRequestSchema,
+  (request): void => {
+    const { publicAddress, message, signature } = request.data
+
+    if (!isValidPublicAddress(publicAddress)) {
+      throw new ValidationError(`${publicAddress} is not a valid public address`)
+    }
+
+    if (!/^([0-9a-f]{2})+$/i.test(signature)) {
+      throw new ValidationError('The signature must be hex')
+    }
+
+    const valid = verifyMessage(
+      publicAddress,
+      Buffer.from(message, 'utf8'),
+      Buffer.from(signature, 'hex'),
+    )
+
+    request.end({ valid })
+  },
+)
@@ -0,0 +1,60 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import '../../../testUtilities/matchers'
+import { Assert } from '../../../assert'
+import { useAccountFixture, useMinerBlockFixture } from '../../../testUtilities/fixtures'
+import { createRouteTest } from '../../../testUtilities/routeTest'
+import { WatchDepositsResponse } from './watchDeposits'
+
+describe('Route account/watchDeposits', () => {
+  const routeTest = createRouteTest()
+
+  it('should fail if the account does not exist', async () => {
+    await expect(
+      routeTest.client.watchDepositsStream({ accounts: ['doesnotexist'] }).waitForEnd(),
+    ).rejects.toThrow('No account with name doesnotexist')
+  })
+
+  it('streams deposits until they are confirmed', async () => {
+    const { node } = routeTest
+    const account = await useAccountFixture(node.accounts, 'deposits')
+
+    const blockA = await useMinerBlockFixture(node.chain, 2, account, node.accounts)
+    await expect(node.chain).toAddBlock(blockA)
+
+    const response = routeTest.client.request<void, WatchDepositsResponse>(
+      'account/watchDeposits',
+      { accounts: [account.name], confirmations: 1 },
+    )
+    const stream = response.contentStream()
+
+    const deposit = (await stream.next()).value
+    expect(deposit).toMatchObject({
+      type: 'deposit',
+      account: account.name,
+      transactionHash: blockA.minersFee.hash().toString('hex'),
+      amount: (-blockA.minersFee.fee()).toString(),
+      blockHash: blockA.header.hash.toString('hex'),
+      sequence: 2,
+      confirmations: 0,
+    })
+
+    const blockB = await useMinerBlockFixture(node.chain, 3)
+    await expect(node.chain).toAddBlock(blockB)
+
+    const confirmed = (await stream.next()).value
+    expect(confirmed).toMatchObject({
+      type: 'confirmed',
+      blockHash: blockA.header.hash.toString('hex'),
+      confirmations: 1,
+      head: {
+        hash: blockB.header.hash.toString('hex'),
+        sequence: 3,
+      },
+    })
+
+    Assert.isNotNull(response.request)
+    response.request.close()
+  })
+})
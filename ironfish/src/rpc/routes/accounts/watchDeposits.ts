@@ -0,0 +1,225 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { Account } from '../../../account'
+import { Assert } from '../../../assert'
+import { ChainProcessor } from '../../../chainProcessor'
+import { GENESIS_BLOCK_SEQUENCE } from '../../../consensus'
+import { BlockHeader } from '../../../primitives/blockheader'
+import { PromiseUtils } from '../../../utils/promise'
+import { ValidationError } from '../../adapters'
+import { ApiNamespace, router } from '../router'
+import { getAccount } from './utils'
+
+type DepositEventType = 'deposit' | 'confirmation' | 'confirmed' | 'unconfirmed'
+
+export type WatchDepositsRequest = {
+  accounts?: string[]
+  confirmations?: number
+  head?: string | null
+}
+
+export type WatchDepositsResponse = {
+  type: DepositEventType
+  account: string
+  transactionHash: string
+  amount: string
+  memos: string[]
+  blockHash: string
+  sequence: number
+  confirmations: number
+  head: {
+    hash: string
+    sequence: number
+  }
+}
+
+export const WatchDepositsRequestSchema: yup.ObjectSchema<WatchDepositsRequest> = yup
+  .object({
+    accounts: yup.array(yup.string().defined()).optional(),
+    confirmations: yup.number().integer().min(0).optional(),
+    head: yup.string().nullable().optional(),
+  })
+  .defined()
+
+export const WatchDepositsResponseSchema: yup.ObjectSchema<WatchDepositsResponse> = yup
+  .object({
+    type: yup.string().oneOf(['deposit', 'confirmation', 'confirmed', 'unconfirmed']).defined(),
+    account: yup.string().defined(),
+    transactionHash: yup.string().defined(),
+    amount: yup.string().defined(),
+    memos: yup.array(yup.string().defined()).defined(),
+    blockHash: yup.string().defined(),
+    sequence: yup.number().defined(),
+    confirmations: yup.number().defined(),
+    head: yup
+      .object({
+        hash: yup.string().defined(),
+        sequence: yup.number().defined(),
+      })
+      .defined(),
+  })
+  .defined()
+
+type Deposit = {
+  account: Account
+  transactionHash: string
+  amount: bigint
+  memos: string[]
+  blockHash: string
+  sequence: number
+  confirmations: number
+}
+
+/**
+ * Streams notes received by the watched accounts as they are added to the
+ * chain, then streams their confirmation count on every new block until they
+ * reach the requested depth. If a block containing a deposit is removed by a
+ * reorg before then, an `unconfirmed` event is streamed and the deposit is
+ * reported again if it's included in the new chain.
+ */
+router.register<typeof WatchDepositsRequestSchema, WatchDepositsResponse>(
+  `${ApiNamespace.account}/watchDeposits`,
+  WatchDepositsRequestSchema,
+  async (request, node): Promise<void> => {
+    const accounts = request.data.accounts?.length
+      ? request.data.accounts.map((name) => getAccount(node, name))
+      : [getAccount(node)]
+
+    const target = request.data.confirmations ?? node.config.get('minimumBlockConfirmations')
+
+    let head: Buffer | null = null
+
+    if (request.data.head) {
+      head = Buffer.from(request.data.head, 'hex')
+
+      if (!(await node.chain.hasBlock(head))) {
+        throw new ValidationError(
+          `Block with hash ${request.data.head} was not found in the chain`,
+        )
+      }
+    } else {
+      // Start far enough back to report deposits that are not confirmed yet
+      const start = node.chain.head.sequence - target - 1
+      head = start >= GENESIS_BLOCK_SEQUENCE ? await node.chain.getHashAtSequence(start) : null
+    }
+
+    const processor = new ChainProcessor({
+      chain: node.chain,
+      logger: node.logger,
+      head: head,
+    })
+
+    // Deposits that have not reached the target depth, keyed by block hash
+    const pending = new Map<string, Deposit[]>()
+
+    const stream = (type: DepositEventType, deposit: Deposit, tip: BlockHeader): void => {
+      request.stream({
+        type,
+        account: deposit.account.name,
+        transactionHash: deposit.transactionHash,
+        amount: deposit.amount.toString(),
+        memos: deposit.memos,
+        blockHash: deposit.blockHash,
+        sequence: deposit.sequence,
+        confirmations: deposit.confirmations,
+        head: {
+          hash: tip.hash.toString('hex'),
+          sequence: tip.sequence,
+        },
+      })
+    }
+
+    const onAdd = async (header: BlockHeader) => {
+      const block = await node.chain.getBlock(header)
+      Assert.isNotNull(block)
+
+      const deposits: Deposit[] = []
+
+      for (const transaction of block.transactions) {
+        for (const account of accounts) {
+          let amount = BigInt(0)
+          const memos: string[] = []
+
+          for (const note of transaction.notes()) {
+            const decryptedNote = note.decryptNoteForOwner(account.incomingViewKey)
+
+            if (decryptedNote) {
+              amount += decryptedNote.value()
+              memos.push(decryptedNote.memo())
+            }
+          }
+
+          if (memos.length) {
+            deposits.push({
+              account,
+              transactionHash: transaction.hash().toString('hex'),
+              amount,
+              memos,
+              blockHash: header.hash.toString('hex'),
+              sequence: header.sequence,
+              confirmations: 0,
+            })
+          }
+        }
+      }
+
+      for (const [blockHash, blockDeposits] of pending) {
+        for (const deposit of blockDeposits) {
+          deposit.confirmations = header.sequence - deposit.sequence
+          const type = deposit.confirmations >= target ? 'confirmed' : 'confirmation'
+          stream(type, deposit, header)
+        }
+
+        if (blockDeposits[0].confirmations >= target) {
+          pending.delete(blockHash)
+        }
+      }
+
+      for (const deposit of deposits) {
+        stream(target === 0 ? 'confirmed' : 'deposit', deposit, header)
+      }
+
+      if (deposits.length && target > 0) {
+        pending.set(header.hash.toString('hex'), deposits)
+      }
+    }
+
+    const onRemove = async (header: BlockHeader) => {
+      const blockHash = header.hash.toString('hex')
+      const deposits = pending.get(blockHash)
+
+      if (!deposits) {
+        return
+      }
+
+      const previous = await node.chain.getHeader(header.previousBlockHash)
+      Assert.isNotNull(previous)
+
+      for (const deposit of deposits) {
+        stream('unconfirmed', deposit, previous)
+      }
+
+      pending.delete(blockHash)
+    }
+
+    const abortController = new AbortController()
+
+    processor.onAdd.on(onAdd)
+    processor.onRemove.on(onRemove)
+
+    request.onClose.on(() => {
+      abortController.abort()
+      processor.onAdd.off(onAdd)
+      processor.onRemove.off(onRemove)
+    })
+
+    while (!request.closed) {
+      await processor.update({ signal: abortController.signal })
+      await PromiseUtils.sleep(1000)
+    }
+
+    request.end()
+  },
+)
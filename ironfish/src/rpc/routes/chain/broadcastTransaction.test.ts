@@ -0,0 +1,39 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { useTxSpendsFixture } from '../../../testUtilities/fixtures'
+import { createRouteTest } from '../../../testUtilities/routeTest'
+
+describe('Route chain/broadcastTransaction', () => {
+  const routeTest = createRouteTest()
+
+  it('should add the transaction to the mem pool and broadcast it', async () => {
+    const { transaction } = await useTxSpendsFixture(routeTest.node)
+    const broadcastSpy = jest.spyOn(routeTest.node.accounts, 'broadcastTransaction')
+
+    const response = await routeTest.client.broadcastTransaction({
+      transaction: transaction.serialize().toString('hex'),
+    })
+
+    expect(response.content).toEqual({
+      hash: transaction.unsignedHash().toString('hex'),
+      accepted: true,
+    })
+    expect(routeTest.node.memPool.exists(transaction.hash())).toBe(true)
+    expect(broadcastSpy).toHaveBeenCalledTimes(1)
+
+    // The mem pool already has it, so it is not broadcast again
+    const duplicate = await routeTest.client.broadcastTransaction({
+      transaction: transaction.serialize().toString('hex'),
+    })
+
+    expect(duplicate.content.accepted).toBe(false)
+    expect(broadcastSpy).toHaveBeenCalledTimes(1)
+  })
+
+  it('should fail if the transaction is invalid', async () => {
+    await expect(
+      routeTest.client.broadcastTransaction({ transaction: 'deadbeef' }),
+    ).rejects.toThrow('Invalid transaction')
+  })
+})
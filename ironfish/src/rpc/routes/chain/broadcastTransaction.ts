@@ -0,0 +1,74 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { Transaction } from '../../../primitives/transaction'
+import { ErrorUtils } from '../../../utils'
+import { ERROR_CODES, ValidationError } from '../../adapters'
+import { ApiNamespace, router } from '../router'
+
+export type BroadcastTransactionRequest = {
+  transaction: string
+}
+
+export type BroadcastTransactionResponse = {
+  hash: string
+  /**
+   * False if the mem pool already had the transaction or rejected it
+   */
+  accepted: boolean
+}
+
+export const BroadcastTransactionRequestSchema: yup.ObjectSchema<BroadcastTransactionRequest> =
+  yup
+    .object({
+      transaction: yup.string().defined(),
+    })
+    .defined()
+
+export const BroadcastTransactionResponseSchema: yup.ObjectSchema<BroadcastTransactionResponse> =
+  yup
+    .object({
+      hash: yup.string().defined(),
+      accepted: yup.boolean().defined(),
+    })
+    .defined()
+
+/**
+ * Adds a raw transaction to the mem pool and gossips it to peers, for wallets
+ * that run separately from the node and have no peers of their own
+ */
+router.register<typeof BroadcastTransactionRequestSchema, BroadcastTransactionResponse>(
+  `${ApiNamespace.chain}/broadcastTransaction`,
+  BroadcastTransactionRequestSchema,
+  async (request, node): Promise<void> => {
+    let transaction: Transaction
+    try {
+      const serialized = Buffer.from(request.data.transaction, 'hex')
+      transaction = node.chain.verifier.verifyNewTransaction(serialized)
+    } catch (e: unknown) {
+      throw new ValidationError(`Invalid transaction: ${ErrorUtils.renderError(e)}`)
+    }
+
+    const expirationSequence = transaction.expirationSequence()
+    if (node.chain.verifier.isExpiredSequence(expirationSequence, node.chain.head.sequence)) {
+      throw new ValidationError(
+        `Transaction expired at sequence ${expirationSequence}`,
+        undefined,
+        ERROR_CODES.EXPIRED,
+      )
+    }
+
+    const accepted = await node.memPool.acceptTransaction(transaction)
+
+    // The peer network gossips the transactions the wallet broadcasts
+    if (accepted) {
+      node.accounts.broadcastTransaction(transaction)
+    }
+
+    request.end({
+      hash: transaction.unsignedHash().toString('hex'),
+      accepted,
+    })
+  },
+)
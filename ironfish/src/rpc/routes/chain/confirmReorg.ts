@@ -0,0 +1,60 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { ValidationError } from '../../adapters'
+import { ApiNamespace, router } from '../router'
+
+export type ConfirmReorgRequest = {
+  /**
+   * The head of the fork to reorganize to, defaults to the refused fork
+   */
+  hash?: string
+}
+
+export type ConfirmReorgResponse = {
+  head: string
+  sequence: number
+}
+
+export const ConfirmReorgRequestSchema: yup.ObjectSchema<ConfirmReorgRequest> = yup
+  .object({
+    hash: yup.string().optional(),
+  })
+  .defined()
+
+export const ConfirmReorgResponseSchema: yup.ObjectSchema<ConfirmReorgResponse> = yup
+  .object({
+    head: yup.string().defined(),
+    sequence: yup.number().defined(),
+  })
+  .defined()
+
+/**
+ * Reorganizes to a fork that was refused for being deeper than maxReorgDepth
+ */
+router.register<typeof ConfirmReorgRequestSchema, ConfirmReorgResponse>(
+  `${ApiNamespace.chain}/confirmReorg`,
+  ConfirmReorgRequestSchema,
+  async (request, node): Promise<void> => {
+    const hash = request.data.hash
+      ? Buffer.from(request.data.hash, 'hex')
+      : node.chain.refusedReorg?.hash
+
+    if (!hash) {
+      throw new ValidationError('No reorganization was refused')
+    }
+
+    const confirmed = await node.chain.confirmReorganization(hash)
+    if (!confirmed) {
+      throw new ValidationError(
+        `Block ${hash.toString('hex')} is not known or not heavier than the head`,
+      )
+    }
+
+    request.end({
+      head: node.chain.head.hash.toString('hex'),
+      sequence: node.chain.head.sequence,
+    })
+  },
+)
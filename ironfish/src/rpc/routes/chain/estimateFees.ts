@@ -0,0 +1,42 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { ApiNamespace, router } from '../router'
+
+export type EstimateFeesRequest = Record<string, never> | undefined
+
+/**
+ * The estimated fee in ore for each fee tier
+ */
+export type EstimateFeesResponse = {
+  slow: string
+  average: string
+  fast: string
+}
+
+export const EstimateFeesRequestSchema: yup.MixedSchema<EstimateFeesRequest> = yup
+  .mixed()
+  .oneOf([undefined] as const)
+
+export const EstimateFeesResponseSchema: yup.ObjectSchema<EstimateFeesResponse> = yup
+  .object({
+    slow: yup.string().defined(),
+    average: yup.string().defined(),
+    fast: yup.string().defined(),
+  })
+  .defined()
+
+router.register<typeof EstimateFeesRequestSchema, EstimateFeesResponse>(
+  `${ApiNamespace.chain}/estimateFees`,
+  EstimateFeesRequestSchema,
+  async (request, node): Promise<void> => {
+    const estimates = await node.feeEstimator.estimateFees()
+
+    request.end({
+      slow: estimates.slow.toString(),
+      average: estimates.average.toString(),
+      fast: estimates.fast.toString(),
+    })
+  },
+)
@@ -2,8 +2,10 @@
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 import { Assert } from '../../../assert'
+import { readBlockFromBuffer } from '../../../network/utils/block'
 import { makeBlockAfter } from '../../../testUtilities/helpers/blockchain'
 import { createRouteTest } from '../../../testUtilities/routeTest'
+import { AsyncUtils } from '../../../utils/async'
 
 describe('Route chain/exportChainStream', () => {
   const routeTest = createRouteTest()
@@ -47,4 +49,26 @@ describe('Route chain/exportChainStream', () => {
       },
     })
   })
+
+  it('includes serialized blocks that can be read back', async () => {
+    const { chain, strategy } = routeTest
+    await chain.open()
+    strategy.disableMiningReward()
+
+    const genesis = await chain.getBlock(chain.genesis)
+    Assert.isNotNull(genesis)
+
+    const blockA1 = await makeBlockAfter(chain, genesis)
+    await expect(chain).toAddBlock(blockA1)
+
+    const response = routeTest.client.exportChainStream({ start: 2, stop: 2, serialized: true })
+    const results = await AsyncUtils.materialize(response.contentStream())
+
+    const serialized = results[1].block?.serialized
+    Assert.isNotUndefined(serialized)
+
+    const data = readBlockFromBuffer(Buffer.from(serialized, 'hex'))
+    const block = strategy.blockSerde.deserialize(data)
+    expect(block.header.hash).toEqualHash(blockA1.header.hash)
+  })
 })
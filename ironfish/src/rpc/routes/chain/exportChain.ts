@@ -3,6 +3,7 @@
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 import * as yup from 'yup'
 import { Assert } from '../../../assert'
+import { writeBlockToBuffer } from '../../../network/utils/block'
 import { BlockchainUtils } from '../../../utils/blockchain'
 import { ApiNamespace, router } from '../router'
 
@@ -10,6 +11,10 @@ export type ExportChainStreamRequest =
   | {
       start?: number | null
       stop?: number | null
+      /**
+       * Include each block serialized as hex so it can be re-applied with chain:replay
+       */
+      serialized?: boolean
     }
   | undefined
 
@@ -27,6 +32,7 @@ export type ExportChainStreamResponse = {
     difficulty: string
     head: boolean
     latest: boolean
+    serialized?: string
   }
 }
 
@@ -34,6 +40,7 @@ export const ExportChainStreamRequestSchema: yup.ObjectSchema<ExportChainStreamR
   .object({
     start: yup.number().nullable().optional(),
     stop: yup.number().nullable().optional(),
+    serialized: yup.boolean().optional(),
   })
   .optional()
 
@@ -53,6 +60,7 @@ export const ExportChainStreamResponseSchema: yup.ObjectSchema<ExportChainStream
         difficulty: yup.string().defined(),
         head: yup.boolean().defined(),
         latest: yup.boolean().defined(),
+        serialized: yup.string().optional(),
       })
       .optional(),
   })
@@ -78,6 +86,15 @@ router.register<typeof ExportChainStreamRequestSchema, ExportChainStreamResponse
       for (const block of blocks) {
         const isMain = await node.chain.isHeadChain(block)
 
+        let serialized: string | undefined
+        if (request.data?.serialized) {
+          const full = await node.chain.getBlock(block)
+          Assert.isNotNull(full, `Missing block body for ${block.hash.toString('hex')}`)
+
+          const data = node.strategy.blockSerde.serialize(full)
+          serialized = writeBlockToBuffer(data).toString('hex')
+        }
+
         const result = {
           main: isMain,
           hash: block.hash.toString('hex'),
@@ -89,6 +106,7 @@ router.register<typeof ExportChainStreamRequestSchema, ExportChainStreamResponse
           difficulty: block.target.toDifficulty().toString(),
           head: block.hash.equals(node.chain.head.hash),
           latest: block.hash.equals(node.chain.latest.hash),
+          serialized,
         }
 
         request.stream({ start, stop, block: result })
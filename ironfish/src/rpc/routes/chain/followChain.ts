@@ -4,6 +4,7 @@
 import * as yup from 'yup'
 import { Assert } from '../../../assert'
 import { ChainProcessor } from '../../../chainProcessor'
+import { writeBlockToBuffer } from '../../../network/utils/block'
 import { Block, BlockHeader } from '../../../primitives'
 import { BlockHashSerdeInstance } from '../../../serde'
 import { GraffitiUtils, PromiseUtils } from '../../../utils'
@@ -12,6 +13,10 @@ import { ApiNamespace, router } from '../router'
 export type FollowChainStreamRequest =
   | {
       head?: string | null
+      /**
+       * Include each block serialized as hex, to add it to another chain
+       */
+      serialized?: boolean
     }
   | undefined
 
@@ -30,6 +35,7 @@ export type FollowChainStreamResponse = {
     timestamp: number
     work: string
     main: boolean
+    serialized?: string
     transactions: Array<{
       hash: string
       size: number
@@ -43,6 +49,7 @@ export type FollowChainStreamResponse = {
 export const FollowChainStreamRequestSchema: yup.ObjectSchema<FollowChainStreamRequest> = yup
   .object({
     head: yup.string().nullable().optional(),
+    serialized: yup.boolean().optional(),
   })
   .optional()
 
@@ -65,6 +72,7 @@ export const FollowChainStreamResponseSchema: yup.ObjectSchema<FollowChainStream
         work: yup.string().defined(),
         main: yup.boolean().defined(),
         difficulty: yup.string().defined(),
+        serialized: yup.string().optional(),
         transactions: yup
           .array(
             yup
@@ -128,6 +136,8 @@ router.register<typeof FollowChainStreamRequestSchema, FollowChainStreamResponse
         })
       })
 
+      const serialized = node.strategy.blockSerde.serialize(block)
+
       request.stream({
         type: type,
         head: {
@@ -138,13 +148,15 @@ router.register<typeof FollowChainStreamRequestSchema, FollowChainStreamResponse
           sequence: block.header.sequence,
           previous: block.header.previousBlockHash.toString('hex'),
           graffiti: GraffitiUtils.toHuman(block.header.graffiti),
-          size: Buffer.from(JSON.stringify(node.strategy.blockSerde.serialize(block)))
-            .byteLength,
+          size: Buffer.from(JSON.stringify(serialized)).byteLength,
           work: block.header.work.toString(),
           main: type === 'connected',
           timestamp: block.header.timestamp.valueOf(),
           difficulty: block.header.target.toDifficulty().toString(),
           transactions,
+          serialized: request.data?.serialized
+            ? writeBlockToBuffer(serialized).toString('hex')
+            : undefined,
         },
       })
     }
@@ -0,0 +1,42 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import '../../../testUtilities/matchers'
+import { readBlockFromBuffer } from '../../../network/utils/block'
+import { useMinerBlockFixture } from '../../../testUtilities/fixtures'
+import { createRouteTest } from '../../../testUtilities/routeTest'
+
+describe('Route chain/getBlockRaw', () => {
+  const routeTest = createRouteTest()
+
+  it('should fail if no sequence or hash provided', async () => {
+    await expect(routeTest.client.getBlockRaw({})).rejects.toThrow('Missing hash or sequence')
+  })
+
+  it('returns the serialized block', async () => {
+    const { chain, strategy } = routeTest
+    const block = await useMinerBlockFixture(chain, 2)
+    await expect(chain).toAddBlock(block)
+
+    const response = await routeTest.client.getBlockRaw({ sequence: 2 })
+    expect(response.content).toMatchObject({
+      hash: block.header.hash.toString('hex'),
+      sequence: 2,
+      encoding: 'hex',
+    })
+
+    const decoded = strategy.blockSerde.deserialize(
+      readBlockFromBuffer(Buffer.from(response.content.block, 'hex')),
+    )
+    expect(decoded.header.recomputeHash().equals(block.header.hash)).toBe(true)
+    expect(decoded.transactions[0].equals(block.transactions[0])).toBe(true)
+
+    const base64 = await routeTest.client.getBlockRaw({
+      hash: block.header.hash.toString('hex'),
+      encoding: 'base64',
+    })
+    expect(Buffer.from(base64.content.block, 'base64').toString('hex')).toEqual(
+      response.content.block,
+    )
+  })
+})
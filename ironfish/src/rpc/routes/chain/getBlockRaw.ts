@@ -0,0 +1,78 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { writeBlockToBuffer } from '../../../network/utils/block'
+import { BlockHeader } from '../../../primitives'
+import { ValidationError } from '../../adapters'
+import { ApiNamespace, router } from '../router'
+
+export type RawEncoding = 'hex' | 'base64'
+
+export const RAW_ENCODINGS: RawEncoding[] = ['hex', 'base64']
+
+export type GetBlockRawRequest = {
+  hash?: string
+  sequence?: number
+  encoding?: RawEncoding
+}
+
+export type GetBlockRawResponse = {
+  hash: string
+  sequence: number
+  encoding: RawEncoding
+  // The block as it is serialized on the network
+  block: string
+}
+
+export const GetBlockRawRequestSchema: yup.ObjectSchema<GetBlockRawRequest> = yup
+  .object({
+    hash: yup.string(),
+    sequence: yup.number(),
+    encoding: yup.string().oneOf(RAW_ENCODINGS),
+  })
+  .defined()
+
+export const GetBlockRawResponseSchema: yup.ObjectSchema<GetBlockRawResponse> = yup
+  .object({
+    hash: yup.string().defined(),
+    sequence: yup.number().defined(),
+    encoding: yup.string().oneOf(RAW_ENCODINGS).defined(),
+    block: yup.string().defined(),
+  })
+  .defined()
+
+router.register<typeof GetBlockRawRequestSchema, GetBlockRawResponse>(
+  `${ApiNamespace.chain}/getBlockRaw`,
+  GetBlockRawRequestSchema,
+  async (request, node): Promise<void> => {
+    const encoding = request.data.encoding ?? 'hex'
+    let header: BlockHeader | null = null
+
+    if (request.data.hash) {
+      header = await node.chain.getHeader(Buffer.from(request.data.hash, 'hex'))
+    } else if (request.data.sequence) {
+      header = await node.chain.getHeaderAtSequence(request.data.sequence)
+    } else {
+      throw new ValidationError(`Missing hash or sequence`)
+    }
+
+    if (!header) {
+      throw new ValidationError(`No block found`)
+    }
+
+    const block = await node.chain.getBlock(header)
+    if (!block) {
+      throw new ValidationError(`No block with header ${header.hash.toString('hex')}`)
+    }
+
+    const serialized = writeBlockToBuffer(node.strategy.blockSerde.serialize(block))
+
+    request.end({
+      hash: header.hash.toString('hex'),
+      sequence: header.sequence,
+      encoding,
+      block: serialized.toString(encoding),
+    })
+  },
+)
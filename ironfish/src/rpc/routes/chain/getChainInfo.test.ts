@@ -25,5 +25,6 @@ describe('Route chain.getChainInfo', () => {
     expect(response.content.currentBlockTimestamp).toEqual(
       Number(routeTest.chain.latest.timestamp),
     )
+    expect(response.content.finalizedSequence).toBeNull()
   }, 10000)
 })
@@ -14,6 +14,11 @@ export interface ChainInfo {
   genesisBlockIdentifier: BlockIdentifier
   oldestBlockIdentifier: BlockIdentifier
   currentBlockTimestamp: number
+  /**
+   * The last sequence a reorganization can change without the operator
+   * confirming it, or null if the node has no maxReorgDepth
+   */
+  finalizedSequence: number | null
 }
 
 export type GetChainInfoRequest = Record<string, never> | undefined
@@ -35,6 +40,7 @@ export const GetChainInfoResponseSchema: yup.ObjectSchema<GetChainInfoResponse>
       .object({ index: yup.string().defined(), hash: yup.string().defined() })
       .defined(),
     currentBlockTimestamp: yup.number().defined(),
+    finalizedSequence: yup.number().nullable().defined(),
   })
   .defined()
 
@@ -73,6 +79,7 @@ router.register<typeof GetChainInfoRequestSchema, GetChainInfoResponse>(
       oldestBlockIdentifier,
       genesisBlockIdentifier,
       currentBlockTimestamp,
+      finalizedSequence: node.chain.finalizedSequence,
     })
   },
 )
@@ -0,0 +1,123 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { FeeRateBucket, FeeTier } from '../../../memPool'
+import { ApiNamespace, router } from '../router'
+
+export type GetFeeHistogramRequest =
+  | {
+      /**
+       * How many blocks back from the head to include, defaults to 10
+       */
+      blocks?: number
+    }
+  | undefined
+
+/**
+ * Fee rates are in ore per kilobyte and returned as strings
+ */
+export type FeeRateBucketResponse = {
+  min: string
+  max: string
+  count: number
+}
+
+export type GetFeeHistogramResponse = {
+  blocks: {
+    sequence: number
+    hash: string
+    transactions: number
+    minFeeRate: string | null
+    histogram: FeeRateBucketResponse[]
+  }[]
+  memPool: {
+    transactions: number
+    histogram: FeeRateBucketResponse[]
+  }
+  tiers: {
+    tier: FeeTier
+    feeRate: string
+    inclusionProbability: number
+  }[]
+}
+
+export const GetFeeHistogramRequestSchema: yup.ObjectSchema<GetFeeHistogramRequest> = yup
+  .object({
+    blocks: yup.number().integer().min(1).max(1000).optional(),
+  })
+  .optional()
+  .default({})
+
+const FeeRateBucketSchema = yup
+  .object({
+    min: yup.string().defined(),
+    max: yup.string().defined(),
+    count: yup.number().defined(),
+  })
+  .defined()
+
+export const GetFeeHistogramResponseSchema: yup.ObjectSchema<GetFeeHistogramResponse> = yup
+  .object({
+    blocks: yup
+      .array(
+        yup
+          .object({
+            sequence: yup.number().defined(),
+            hash: yup.string().defined(),
+            transactions: yup.number().defined(),
+            minFeeRate: yup.string().nullable().defined(),
+            histogram: yup.array(FeeRateBucketSchema).defined(),
+          })
+          .defined(),
+      )
+      .defined(),
+    memPool: yup
+      .object({
+        transactions: yup.number().defined(),
+        histogram: yup.array(FeeRateBucketSchema).defined(),
+      })
+      .defined(),
+    tiers: yup
+      .array(
+        yup
+          .object({
+            tier: yup.string<FeeTier>().oneOf(['slow', 'average', 'fast']).defined(),
+            feeRate: yup.string().defined(),
+            inclusionProbability: yup.number().defined(),
+          })
+          .defined(),
+      )
+      .defined(),
+  })
+  .defined()
+
+router.register<typeof GetFeeHistogramRequestSchema, GetFeeHistogramResponse>(
+  `${ApiNamespace.chain}/getFeeHistogram`,
+  GetFeeHistogramRequestSchema,
+  async (request, node): Promise<void> => {
+    const histogram = await node.feeEstimator.getFeeHistogram(request.data?.blocks)
+
+    const renderBuckets = (buckets: FeeRateBucket[]): FeeRateBucketResponse[] =>
+      buckets.map((b) => ({ min: b.min.toString(), max: b.max.toString(), count: b.count }))
+
+    request.end({
+      blocks: histogram.blocks.map((block) => ({
+        sequence: block.sequence,
+        hash: block.hash.toString('hex'),
+        transactions: block.transactions,
+        minFeeRate: block.minFeeRate !== null ? block.minFeeRate.toString() : null,
+        histogram: renderBuckets(block.histogram),
+      })),
+      memPool: {
+        transactions: histogram.memPool.transactions,
+        histogram: renderBuckets(histogram.memPool.histogram),
+      },
+      tiers: histogram.tiers.map((tier) => ({
+        tier: tier.tier,
+        feeRate: tier.feeRate.toString(),
+        inclusionProbability: tier.inclusionProbability,
+      })),
+    })
+  },
+)
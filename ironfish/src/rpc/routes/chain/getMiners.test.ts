@@ -0,0 +1,32 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+import { createRouteTest } from '../../../testUtilities/routeTest'
+import { GraffitiUtils } from '../../../utils'
+import { GetMinersResponse } from './getMiners'
+
+describe('Route chain.getMiners', () => {
+  const routeTest = createRouteTest()
+
+  it('groups the blocks by graffiti', async () => {
+    const response = await routeTest.client
+      .request<GetMinersResponse>('chain/getMiners', {})
+      .waitForEnd()
+
+    const genesis = routeTest.chain.genesis
+
+    expect(response.content).toEqual({
+      start: genesis.sequence,
+      stop: genesis.sequence,
+      blocks: 1,
+      miners: [
+        {
+          graffiti: GraffitiUtils.toHuman(genesis.graffiti),
+          blocks: 1,
+          lastSequence: genesis.sequence,
+        },
+      ],
+    })
+  })
+})
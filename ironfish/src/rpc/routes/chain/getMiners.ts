@@ -0,0 +1,99 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { GraffitiUtils } from '../../../utils'
+import { BlockchainUtils } from '../../../utils/blockchain'
+import { ApiNamespace, router } from '../router'
+
+export type GetMinersRequest =
+  | {
+      /**
+       * The first block to count, negative numbers count back from the head
+       */
+      start?: number | null
+      stop?: number | null
+    }
+  | undefined
+
+export type GetMinersResponse = {
+  start: number
+  stop: number
+  blocks: number
+  /**
+   * The blocks on the main chain grouped by their graffiti, most blocks
+   * first. The miner reward note is encrypted so the graffiti is the only
+   * public sign of who mined a block.
+   */
+  miners: {
+    graffiti: string
+    blocks: number
+    lastSequence: number
+  }[]
+}
+
+export const GetMinersRequestSchema: yup.ObjectSchema<GetMinersRequest> = yup
+  .object({
+    start: yup.number().nullable().optional(),
+    stop: yup.number().nullable().optional(),
+  })
+  .optional()
+
+export const GetMinersResponseSchema: yup.ObjectSchema<GetMinersResponse> = yup
+  .object({
+    start: yup.number().defined(),
+    stop: yup.number().defined(),
+    blocks: yup.number().defined(),
+    miners: yup
+      .array(
+        yup
+          .object({
+            graffiti: yup.string().defined(),
+            blocks: yup.number().defined(),
+            lastSequence: yup.number().defined(),
+          })
+          .defined(),
+      )
+      .defined(),
+  })
+  .defined()
+
+router.register<typeof GetMinersRequestSchema, GetMinersResponse>(
+  `${ApiNamespace.chain}/getMiners`,
+  GetMinersRequestSchema,
+  async (request, node): Promise<void> => {
+    const range = BlockchainUtils.getBlockRange(node.chain, {
+      start: request.data?.start,
+      stop: request.data?.stop,
+    })
+
+    const start = Math.min(range.start, node.chain.head.sequence)
+    const stop = Math.min(range.stop, node.chain.head.sequence)
+
+    const miners = new Map<string, GetMinersResponse['miners'][number]>()
+
+    for (let sequence = start; sequence <= stop; sequence++) {
+      const header = await node.chain.getHeaderAtSequence(sequence)
+      if (!header) {
+        break
+      }
+
+      const graffiti = GraffitiUtils.toHuman(header.graffiti)
+      const miner = miners.get(graffiti)
+
+      if (miner) {
+        miner.blocks++
+        miner.lastSequence = sequence
+      } else {
+        miners.set(graffiti, { graffiti, blocks: 1, lastSequence: sequence })
+      }
+    }
+
+    request.end({
+      start,
+      stop,
+      blocks: stop - start + 1,
+      miners: [...miners.values()].sort((a, b) => b.blocks - a.blocks),
+    })
+  },
+)
@@ -0,0 +1,31 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+import { createRouteTest } from '../../../testUtilities/routeTest'
+import { GetNetworkHashrateResponse } from './getNetworkHashrate'
+
+describe('Route chain.getNetworkHashrate', () => {
+  const routeTest = createRouteTest()
+
+  it('returns no hashrate at the genesis block', async () => {
+    const response = await routeTest.client
+      .request<GetNetworkHashrateResponse>('chain/getNetworkHashrate', { blocks: 10 })
+      .waitForEnd()
+
+    expect(response.content).toEqual({
+      sequence: routeTest.chain.head.sequence,
+      blocks: 0,
+      hashesPerSecond: 0,
+      difficulty: routeTest.chain.head.target.toDifficulty().toString(),
+      averageBlockTime: 0,
+      blockReward: routeTest.node.strategy.miningReward(2).toString(),
+    })
+  })
+
+  it('fails for a block that does not exist', async () => {
+    await expect(
+      routeTest.client.request('chain/getNetworkHashrate', { sequence: 1000 }).waitForEnd(),
+    ).rejects.toThrow('No block found at sequence 1000')
+  })
+})
@@ -0,0 +1,94 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { Assert } from '../../../assert'
+import { ValidationError } from '../../adapters'
+import { ApiNamespace, router } from '../router'
+
+export type GetNetworkHashrateRequest =
+  | undefined
+  | {
+      /**
+       * How many blocks to average over, defaults to 120
+       */
+      blocks?: number
+      /**
+       * The block to estimate at, defaults to the head
+       */
+      sequence?: number
+    }
+
+export type GetNetworkHashrateResponse = {
+  sequence: number
+  /**
+   * How many blocks were averaged over, fewer than requested near the genesis block
+   */
+  blocks: number
+  hashesPerSecond: number
+  difficulty: string
+  /**
+   * The average time between the blocks in milliseconds
+   */
+  averageBlockTime: number
+  /**
+   * The mining reward in ore of the next block
+   */
+  blockReward: string
+}
+
+export const GetNetworkHashrateRequestSchema: yup.ObjectSchema<GetNetworkHashrateRequest> = yup
+  .object({
+    blocks: yup.number().integer().min(1).optional(),
+    sequence: yup.number().integer().min(1).optional(),
+  })
+  .optional()
+  .default({})
+
+export const GetNetworkHashrateResponseSchema: yup.ObjectSchema<GetNetworkHashrateResponse> =
+  yup
+    .object({
+      sequence: yup.number().defined(),
+      blocks: yup.number().defined(),
+      hashesPerSecond: yup.number().defined(),
+      difficulty: yup.string().defined(),
+      averageBlockTime: yup.number().defined(),
+      blockReward: yup.string().defined(),
+    })
+    .defined()
+
+router.register<typeof GetNetworkHashrateRequestSchema, GetNetworkHashrateResponse>(
+  `${ApiNamespace.chain}/getNetworkHashrate`,
+  GetNetworkHashrateRequestSchema,
+  async (request, node): Promise<void> => {
+    const chain = node.chain
+    const sequence = request.data?.sequence ?? chain.head.sequence
+
+    const end = await chain.getHeaderAtSequence(sequence)
+    if (!end) {
+      throw new ValidationError(`No block found at sequence ${sequence}`)
+    }
+
+    const startSequence = Math.max(sequence - (request.data?.blocks ?? 120), 1)
+    const start = await chain.getHeaderAtSequence(startSequence)
+    Assert.isNotNull(start)
+
+    // The work of a block is the expected number of hashes to mine it, so the
+    // work added over the window divided by its duration is the hashrate
+    const blocks = end.sequence - start.sequence
+    const elapsed = end.timestamp.valueOf() - start.timestamp.valueOf()
+    const work = end.work - start.work
+
+    const hashesPerSecond = elapsed > 0 ? (Number(work) / elapsed) * 1000 : 0
+    const averageBlockTime = blocks > 0 ? elapsed / blocks : 0
+
+    request.end({
+      sequence: end.sequence,
+      blocks,
+      hashesPerSecond: Math.round(hashesPerSecond),
+      difficulty: end.target.toDifficulty().toString(),
+      averageBlockTime: Math.round(averageBlockTime),
+      blockReward: node.strategy.miningReward(end.sequence + 1).toString(),
+    })
+  },
+)
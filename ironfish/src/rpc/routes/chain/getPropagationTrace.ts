@@ -0,0 +1,92 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { PropagationTrace } from '../../../network/propagationTracer'
+import { ApiNamespace, router } from '../router'
+
+export type GetPropagationTraceRequest = { hash: string }
+
+export type PropagationPeerResponse = {
+  identity: string
+  name: string | null
+  elapsedMs: number
+}
+
+export type GetPropagationTraceResponse = {
+  // False if the node is not recording traces
+  enabled: boolean
+  trace: {
+    type: PropagationTrace['type']
+    hash: string
+    firstSeenAt: number
+    firstSeenFrom: PropagationPeerResponse | null
+    received: PropagationPeerResponse[]
+    forwarded: PropagationPeerResponse[]
+  } | null
+}
+
+export const GetPropagationTraceRequestSchema: yup.ObjectSchema<GetPropagationTraceRequest> =
+  yup
+    .object({
+      hash: yup.string().defined(),
+    })
+    .defined()
+
+const PropagationPeerSchema = yup
+  .object({
+    identity: yup.string().defined(),
+    name: yup.string().nullable().defined(),
+    elapsedMs: yup.number().defined(),
+  })
+  .defined()
+
+export const GetPropagationTraceResponseSchema: yup.ObjectSchema<GetPropagationTraceResponse> =
+  yup
+    .object({
+      enabled: yup.boolean().defined(),
+      trace: yup
+        .object({
+          type: yup.string().oneOf(['block', 'transaction']).defined(),
+          hash: yup.string().defined(),
+          firstSeenAt: yup.number().defined(),
+          firstSeenFrom: PropagationPeerSchema.nullable().defined(),
+          received: yup.array(PropagationPeerSchema).defined(),
+          forwarded: yup.array(PropagationPeerSchema).defined(),
+        })
+        .nullable()
+        .defined(),
+    })
+    .defined()
+
+router.register<typeof GetPropagationTraceRequestSchema, GetPropagationTraceResponse>(
+  `${ApiNamespace.chain}/getPropagationTrace`,
+  GetPropagationTraceRequestSchema,
+  (request, node): void => {
+    const tracer = node.peerNetwork.propagation
+    const trace = tracer?.get(request.data.hash) ?? null
+
+    if (!trace || trace.hash === null) {
+      request.end({ enabled: !!tracer, trace: null })
+      return
+    }
+
+    const renderPeer = (identity: string, elapsedMs: number): PropagationPeerResponse => ({
+      identity,
+      name: node.peerNetwork.peerManager.getPeer(identity)?.name ?? null,
+      elapsedMs,
+    })
+
+    request.end({
+      enabled: true,
+      trace: {
+        type: trace.type,
+        hash: trace.hash,
+        firstSeenAt: trace.firstSeenAt,
+        firstSeenFrom: trace.firstSeenFrom && renderPeer(trace.firstSeenFrom, 0),
+        received: trace.received.map((e) => renderPeer(e.identity, e.elapsedMs)),
+        forwarded: trace.forwarded.map((e) => renderPeer(e.identity, e.elapsedMs)),
+      },
+    })
+  },
+)
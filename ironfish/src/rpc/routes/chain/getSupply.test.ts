@@ -0,0 +1,42 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+import { IRON_FISH_YEAR_IN_BLOCKS } from '../../../consensus'
+import { createRouteTest } from '../../../testUtilities/routeTest'
+import { GetSupplyResponse } from './getSupply'
+
+describe('Route chain.getSupply', () => {
+  const routeTest = createRouteTest()
+
+  it('returns the supply and emission schedule', async () => {
+    const response = await routeTest.client
+      .request<GetSupplyResponse>('chain/getSupply', { years: 2 })
+      .waitForEnd()
+
+    const genesis = BigInt(response.content.genesis)
+    expect(genesis).toBeGreaterThan(BigInt(0))
+
+    expect(response.content.sequence).toEqual(routeTest.chain.head.sequence)
+    expect(response.content.mined).toEqual('0')
+    expect(response.content.burned).toEqual('0')
+    expect(response.content.circulating).toEqual(genesis.toString())
+
+    const firstYear = BigInt(IRON_FISH_YEAR_IN_BLOCKS - 2) * BigInt(20 * 10 ** 8)
+    const secondYear = BigInt(IRON_FISH_YEAR_IN_BLOCKS) * BigInt(19 * 10 ** 8)
+    expect(response.content.emission).toEqual([
+      {
+        year: 0,
+        startSequence: 1,
+        blockReward: (20 * 10 ** 8).toString(),
+        supply: (genesis + firstYear).toString(),
+      },
+      {
+        year: 1,
+        startSequence: IRON_FISH_YEAR_IN_BLOCKS,
+        blockReward: (19 * 10 ** 8).toString(),
+        supply: (genesis + firstYear + secondYear).toString(),
+      },
+    ])
+  })
+})
@@ -0,0 +1,120 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { Assert } from '../../../assert'
+import { IRON_FISH_YEAR_IN_BLOCKS } from '../../../consensus'
+import { ApiNamespace, router } from '../router'
+
+export type GetSupplyRequest =
+  | undefined
+  | {
+      /**
+       * How many years of the emission schedule to project, defaults to 10
+       */
+      years?: number
+    }
+
+/**
+ * Amounts are in ore and returned as strings
+ */
+export type GetSupplyResponse = {
+  sequence: number
+  /**
+   * Ore allocated by the genesis block
+   */
+  genesis: string
+  /**
+   * Ore issued by mining rewards after the genesis block
+   */
+  mined: string
+  /**
+   * Ore removed from circulation. Fees are paid to the miner of the block so
+   * none are burned yet, it's returned so clients don't need to change if they are.
+   */
+  burned: string
+  circulating: string
+  emission: {
+    year: number
+    startSequence: number
+    blockReward: string
+    /**
+     * The circulating supply at the end of the year
+     */
+    supply: string
+  }[]
+}
+
+export const GetSupplyRequestSchema: yup.ObjectSchema<GetSupplyRequest> = yup
+  .object({
+    years: yup.number().integer().min(0).max(100).optional(),
+  })
+  .optional()
+  .default({})
+
+export const GetSupplyResponseSchema: yup.ObjectSchema<GetSupplyResponse> = yup
+  .object({
+    sequence: yup.number().defined(),
+    genesis: yup.string().defined(),
+    mined: yup.string().defined(),
+    burned: yup.string().defined(),
+    circulating: yup.string().defined(),
+    emission: yup
+      .array(
+        yup
+          .object({
+            year: yup.number().defined(),
+            startSequence: yup.number().defined(),
+            blockReward: yup.string().defined(),
+            supply: yup.string().defined(),
+          })
+          .defined(),
+      )
+      .defined(),
+  })
+  .defined()
+
+router.register<typeof GetSupplyRequestSchema, GetSupplyResponse>(
+  `${ApiNamespace.chain}/getSupply`,
+  GetSupplyRequestSchema,
+  async (request, node): Promise<void> => {
+    const genesisBlock = await node.chain.getBlock(node.chain.genesis)
+    Assert.isNotNull(genesisBlock, 'no genesis')
+
+    // The genesis transactions have negative fees for the coins they create
+    let genesis = BigInt(0)
+    for (const transaction of genesisBlock.transactions) {
+      genesis -= transaction.fee()
+    }
+
+    const sequence = node.chain.head.sequence
+    const mined = node.strategy.totalMiningReward(sequence)
+    const burned = BigInt(0)
+
+    const years = request.data?.years ?? 10
+    const currentYear = Math.floor(sequence / IRON_FISH_YEAR_IN_BLOCKS)
+    const emission = []
+
+    for (let year = currentYear; year < currentYear + years; year++) {
+      const startSequence = Math.max(year * IRON_FISH_YEAR_IN_BLOCKS, 1)
+      const endSequence = (year + 1) * IRON_FISH_YEAR_IN_BLOCKS - 1
+      const supply = genesis + node.strategy.totalMiningReward(endSequence) - burned
+
+      emission.push({
+        year,
+        startSequence,
+        blockReward: node.strategy.miningReward(startSequence).toString(),
+        supply: supply.toString(),
+      })
+    }
+
+    request.end({
+      sequence,
+      genesis: genesis.toString(),
+      mined: mined.toString(),
+      burned: burned.toString(),
+      circulating: (genesis + mined - burned).toString(),
+      emission,
+    })
+  },
+)
@@ -0,0 +1,38 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import '../../../testUtilities/matchers'
+import { useMinerBlockFixture } from '../../../testUtilities/fixtures'
+import { createRouteTest } from '../../../testUtilities/routeTest'
+
+describe('Route chain/getTransactionRaw', () => {
+  const routeTest = createRouteTest()
+
+  it('returns the serialized transaction', async () => {
+    const { chain } = routeTest
+    const block = await useMinerBlockFixture(chain, 2)
+    await expect(chain).toAddBlock(block)
+
+    const transaction = block.transactions[0]
+
+    const response = await routeTest.client.getTransactionRaw({
+      hash: transaction.unsignedHash().toString('hex'),
+      blockHash: block.header.hash.toString('hex'),
+      encoding: 'base64',
+    })
+
+    expect(response.content.encoding).toEqual('base64')
+    expect(Buffer.from(response.content.transaction, 'base64')).toEqual(
+      transaction.serialize(),
+    )
+  })
+
+  it('should fail if the transaction is not in the block', async () => {
+    await expect(
+      routeTest.client.getTransactionRaw({
+        hash: Buffer.alloc(32).toString('hex'),
+        blockHash: routeTest.chain.genesis.hash.toString('hex'),
+      }),
+    ).rejects.toThrow('No transaction')
+  })
+})
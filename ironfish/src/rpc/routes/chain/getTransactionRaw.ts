@@ -0,0 +1,74 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { ValidationError } from '../../adapters'
+import { ApiNamespace, router } from '../router'
+import { RAW_ENCODINGS, RawEncoding } from './getBlockRaw'
+
+export type GetTransactionRawRequest = {
+  hash: string
+  // The chain is not indexed by transaction, so the block is needed to find it
+  blockHash: string
+  encoding?: RawEncoding
+}
+
+export type GetTransactionRawResponse = {
+  hash: string
+  blockHash: string
+  encoding: RawEncoding
+  // The posted transaction as it is serialized in blocks
+  transaction: string
+}
+
+export const GetTransactionRawRequestSchema: yup.ObjectSchema<GetTransactionRawRequest> = yup
+  .object({
+    hash: yup.string().defined(),
+    blockHash: yup.string().defined(),
+    encoding: yup.string().oneOf(RAW_ENCODINGS),
+  })
+  .defined()
+
+export const GetTransactionRawResponseSchema: yup.ObjectSchema<GetTransactionRawResponse> =
+  yup
+    .object({
+      hash: yup.string().defined(),
+      blockHash: yup.string().defined(),
+      encoding: yup.string().oneOf(RAW_ENCODINGS).defined(),
+      transaction: yup.string().defined(),
+    })
+    .defined()
+
+router.register<typeof GetTransactionRawRequestSchema, GetTransactionRawResponse>(
+  `${ApiNamespace.chain}/getTransactionRaw`,
+  GetTransactionRawRequestSchema,
+  async (request, node): Promise<void> => {
+    const encoding = request.data.encoding ?? 'hex'
+
+    const header = await node.chain.getHeader(Buffer.from(request.data.blockHash, 'hex'))
+    if (!header) {
+      throw new ValidationError(`No block found with hash ${request.data.blockHash}`)
+    }
+
+    const block = await node.chain.getBlock(header)
+    if (!block) {
+      throw new ValidationError(`No block with header ${header.hash.toString('hex')}`)
+    }
+
+    const hash = Buffer.from(request.data.hash, 'hex')
+    const transaction = block.transactions.find((t) => t.unsignedHash().equals(hash))
+
+    if (!transaction) {
+      throw new ValidationError(
+        `No transaction ${request.data.hash} in block ${request.data.blockHash}`,
+      )
+    }
+
+    request.end({
+      hash: transaction.unsignedHash().toString('hex'),
+      blockHash: header.hash.toString('hex'),
+      encoding,
+      transaction: transaction.serialize().toString(encoding),
+    })
+  },
+)
@@ -2,10 +2,22 @@
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 
+export * from './broadcastTransaction'
+export * from './confirmReorg'
+export * from './estimateFees'
 export * from './exportChain'
 export * from './followChain'
 export * from './getBlock'
 export * from './getBlockInfo'
+export * from './getBlockRaw'
 export * from './getChainInfo'
+export * from './getFeeHistogram'
+export * from './getMiners'
+export * from './getNetworkHashrate'
+export * from './getPropagationTrace'
+export * from './getSupply'
+export * from './getTransactionRaw'
 export * from './getTransactionStream'
+export * from './onHead'
 export * from './showChain'
+export * from './simulateTransaction'
@@ -0,0 +1,58 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import '../../../testUtilities/matchers'
+import { Assert } from '../../../assert'
+import { makeBlockAfter } from '../../../testUtilities/helpers/blockchain'
+import { createRouteTest } from '../../../testUtilities/routeTest'
+import { OnHeadStreamResponse } from './onHead'
+
+describe('Route chain/onHead', () => {
+  const routeTest = createRouteTest()
+
+  it('streams the head and marks reorganizations', async () => {
+    const { chain, strategy } = routeTest
+    await chain.open()
+    strategy.disableMiningReward()
+
+    const response = routeTest.client.onHeadStream()
+    const stream = response.contentStream()
+
+    const first = (await stream.next()).value as OnHeadStreamResponse
+    expect(first).toMatchObject({
+      type: 'head',
+      hash: chain.genesis.hash.toString('hex'),
+      sequence: chain.genesis.sequence,
+      fork: null,
+    })
+
+    const blockA1 = await makeBlockAfter(chain, chain.genesis)
+    await expect(chain).toAddBlock(blockA1)
+
+    expect((await stream.next()).value).toMatchObject({
+      type: 'head',
+      hash: blockA1.header.hash.toString('hex'),
+      previous: chain.genesis.hash.toString('hex'),
+      work: blockA1.header.work.toString(),
+    })
+
+    // A heavier fork from the genesis block
+    const blockB1 = await makeBlockAfter(chain, chain.genesis)
+    const blockB2 = await makeBlockAfter(chain, blockB1)
+    await expect(chain).toAddBlock(blockB1)
+    await expect(chain).toAddBlock(blockB2)
+
+    expect((await stream.next()).value).toMatchObject({
+      type: 'reorganized',
+      hash: blockB2.header.hash.toString('hex'),
+      sequence: 3,
+      fork: {
+        hash: chain.genesis.hash.toString('hex'),
+        sequence: chain.genesis.sequence,
+      },
+    })
+
+    Assert.isNotNull(response.request)
+    response.request.close()
+  })
+})
@@ -0,0 +1,105 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { BlockHeader } from '../../../primitives'
+import { ApiNamespace, router } from '../router'
+
+// eslint-disable-next-line @typescript-eslint/ban-types
+export type OnHeadStreamRequest = {} | undefined
+
+export type OnHeadStreamResponse = {
+  // The current head is sent first, then every new head. A reorganized head
+  // is on another chain than the head before it.
+  type: 'head' | 'reorganized'
+  hash: string
+  sequence: number
+  previous: string
+  timestamp: number
+  work: string
+  // For reorganizations, the last block the old and the new chain share
+  fork: { hash: string; sequence: number } | null
+}
+
+export const OnHeadStreamRequestSchema: yup.ObjectSchema<OnHeadStreamRequest> = yup
+  .object({})
+  .notRequired()
+  .default({})
+
+export const OnHeadStreamResponseSchema: yup.ObjectSchema<OnHeadStreamResponse> = yup
+  .object({
+    type: yup.string().oneOf(['head', 'reorganized']).defined(),
+    hash: yup.string().defined(),
+    sequence: yup.number().defined(),
+    previous: yup.string().defined(),
+    timestamp: yup.number().defined(),
+    work: yup.string().defined(),
+    fork: yup
+      .object({
+        hash: yup.string().defined(),
+        sequence: yup.number().defined(),
+      })
+      .nullable()
+      .defined(),
+  })
+  .defined()
+
+router.register<typeof OnHeadStreamRequestSchema, OnHeadStreamResponse>(
+  `${ApiNamespace.chain}/onHead`,
+  OnHeadStreamRequestSchema,
+  (request, node): void => {
+    const chain = node.chain
+
+    // The fork of the reorganization that is being committed, if any
+    let fork: BlockHeader | null = null
+
+    const send = (
+      header: BlockHeader,
+      type: OnHeadStreamResponse['type'],
+      forkHeader: BlockHeader | null,
+    ) => {
+      request.stream({
+        type,
+        hash: header.hash.toString('hex'),
+        sequence: header.sequence,
+        previous: header.previousBlockHash.toString('hex'),
+        timestamp: header.timestamp.valueOf(),
+        work: header.work.toString(),
+        fork: forkHeader && {
+          hash: forkHeader.hash.toString('hex'),
+          sequence: forkHeader.sequence,
+        },
+      })
+    }
+
+    const onReorganize = (
+      _oldHead: BlockHeader,
+      _newHead: BlockHeader,
+      forkHeader: BlockHeader,
+    ) => {
+      fork = forkHeader
+    }
+
+    // Sent once the blocks are committed, so a reorganization is one message
+    // rather than every block it disconnects and connects
+    const onHeadChange = (head: BlockHeader, previous: BlockHeader | null) => {
+      if (!previous || head.previousBlockHash.equals(previous.hash)) {
+        send(head, 'head', null)
+      } else {
+        send(head, 'reorganized', fork)
+      }
+
+      fork = null
+    }
+
+    chain.onReorganize.on(onReorganize)
+    chain.onHeadChange.on(onHeadChange)
+
+    request.onClose.on(() => {
+      chain.onReorganize.off(onReorganize)
+      chain.onHeadChange.off(onHeadChange)
+    })
+
+    send(chain.head, 'head', null)
+  },
+)
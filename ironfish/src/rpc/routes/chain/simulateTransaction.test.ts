@@ -0,0 +1,44 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+import { VerificationResultReason } from '../../../consensus'
+import { useTxSpendsFixture } from '../../../testUtilities/fixtures'
+import { createRouteTest } from '../../../testUtilities/routeTest'
+
+describe('Route chain.simulateTransaction', () => {
+  const routeTest = createRouteTest()
+
+  it('should fail if the transaction cannot be deserialized', async () => {
+    await expect(
+      routeTest.client.simulateTransaction({ transaction: 'deadbeef' }),
+    ).rejects.toThrow()
+  })
+
+  it('should report a valid transaction without adding it to the mem pool', async () => {
+    const { transaction } = await useTxSpendsFixture(routeTest.node)
+
+    const response = await routeTest.client.simulateTransaction({
+      transaction: transaction.serialize().toString('hex'),
+    })
+
+    expect(response.content).toMatchObject({
+      valid: true,
+      hash: transaction.hash().toString('hex'),
+      fee: transaction.fee().toString(),
+      reasons: [],
+      conflicts: [],
+    })
+    expect(routeTest.node.memPool.exists(transaction.hash())).toBe(false)
+
+    await routeTest.node.memPool.acceptTransaction(transaction)
+
+    const duplicate = await routeTest.client.simulateTransaction({
+      transaction: transaction.serialize().toString('hex'),
+    })
+
+    expect(duplicate.content.valid).toBe(false)
+    expect(duplicate.content.reasons).toContain(VerificationResultReason.DUPLICATE)
+    expect(duplicate.content.conflicts).toEqual([])
+  })
+})
@@ -0,0 +1,110 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { VerificationResultReason } from '../../../consensus'
+import { Transaction } from '../../../primitives/transaction'
+import { ErrorUtils } from '../../../utils'
+import { ValidationError } from '../../adapters'
+import { ApiNamespace, router } from '../router'
+
+export type SimulateTransactionRequest = {
+  transaction: string
+}
+
+export type SimulateTransactionResponse = {
+  valid: boolean
+  hash: string
+  fee: string
+  expirationSequence: number
+  /**
+   * The sequence of the block the transaction was simulated against
+   */
+  sequence: number
+  reasons: string[]
+  conflicts: string[]
+}
+
+export const SimulateTransactionRequestSchema: yup.ObjectSchema<SimulateTransactionRequest> =
+  yup
+    .object({
+      transaction: yup.string().defined(),
+    })
+    .defined()
+
+export const SimulateTransactionResponseSchema: yup.ObjectSchema<SimulateTransactionResponse> =
+  yup
+    .object({
+      valid: yup.boolean().defined(),
+      hash: yup.string().defined(),
+      fee: yup.string().defined(),
+      expirationSequence: yup.number().defined(),
+      sequence: yup.number().defined(),
+      reasons: yup.array(yup.string().defined()).defined(),
+      conflicts: yup.array(yup.string().defined()).defined(),
+    })
+    .defined()
+
+/**
+ * Checks a raw transaction the same way it would be checked for the mem pool
+ * and the next block, without broadcasting it. Every failed check is reported
+ * instead of stopping at the first one.
+ */
+router.register<typeof SimulateTransactionRequestSchema, SimulateTransactionResponse>(
+  `${ApiNamespace.chain}/simulateTransaction`,
+  SimulateTransactionRequestSchema,
+  async (request, node): Promise<void> => {
+    let transaction: Transaction
+    try {
+      const serialized = Buffer.from(request.data.transaction, 'hex')
+      transaction = node.chain.verifier.verifyNewTransaction(serialized)
+    } catch (e: unknown) {
+      throw new ValidationError(ErrorUtils.renderError(e))
+    }
+
+    const { verifier } = node.chain
+    const reasons: string[] = []
+    const sequence = node.chain.head.sequence + 1
+
+    // Miner's fee transactions have a negative fee and are rejected here
+    if (transaction.fee() < BigInt(0)) {
+      reasons.push(VerificationResultReason.INVALID_TRANSACTION_FEE)
+    }
+
+    if (verifier.isExpiredSequence(transaction.expirationSequence(), sequence)) {
+      reasons.push(VerificationResultReason.TRANSACTION_EXPIRED)
+    }
+
+    const proof = await verifier.verifyTransactionNoncontextual(transaction)
+    if (!proof.valid && proof.reason) {
+      reasons.push(proof.reason)
+    }
+
+    const spends = await verifier.verifyTransactionSpends(transaction)
+    if (!spends.valid && spends.reason) {
+      reasons.push(spends.reason)
+    }
+
+    if (node.memPool.exists(transaction.hash())) {
+      reasons.push(VerificationResultReason.DUPLICATE)
+    }
+
+    const conflicts = node.memPool.getConflicts(transaction)
+    for (const conflict of conflicts) {
+      if (conflict.fee() >= transaction.fee()) {
+        const hash = conflict.hash().toString('hex')
+        reasons.push(`Double spends mem pool transaction ${hash} with an equal or higher fee`)
+      }
+    }
+
+    request.end({
+      valid: reasons.length === 0,
+      hash: transaction.hash().toString('hex'),
+      fee: transaction.fee().toString(),
+      expirationSequence: transaction.expirationSequence(),
+      sequence,
+      reasons,
+      conflicts: conflicts.map((c) => c.hash().toString('hex')),
+    })
+  },
+)
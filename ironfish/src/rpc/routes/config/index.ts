@@ -3,5 +3,6 @@
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 
 export * from './getConfig'
+export * from './reloadConfig'
 export * from './setConfig'
 export * from './uploadConfig'
@@ -0,0 +1,34 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { createRouteTest } from '../../../testUtilities/routeTest'
+
+describe('Route config/reloadConfig', () => {
+  const routeTest = createRouteTest()
+
+  it('applies reloadable options and reports the rest', async () => {
+    const config = routeTest.node.config
+
+    await config.storage.save({
+      ...config.loaded,
+      maxPeers: 20,
+      nodeName: 'reloaded',
+    })
+
+    const response = await routeTest.client.reloadConfig()
+
+    expect(response.content.applied).toEqual(['maxPeers'])
+    expect(response.content.restartRequired).toEqual(['nodeName'])
+    expect(routeTest.node.peerNetwork.peerManager.maxPeers).toEqual(20)
+  })
+
+  it('does not change anything if the config has errors', async () => {
+    const config = routeTest.node.config
+    const maxPeers = config.get('maxPeers')
+
+    await config.storage.save({ ...config.loaded, maxPeers: 'lots' as unknown as number })
+
+    await expect(routeTest.client.reloadConfig()).rejects.toThrow('maxPeers')
+    expect(config.get('maxPeers')).toEqual(maxPeers)
+  })
+})
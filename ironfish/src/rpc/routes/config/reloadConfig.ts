@@ -0,0 +1,40 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { ErrorUtils } from '../../../utils'
+import { ValidationError } from '../../adapters/errors'
+import { ApiNamespace, router } from '../router'
+
+export type ReloadConfigRequest = Record<string, never> | undefined
+export type ReloadConfigResponse = {
+  applied: string[]
+  restartRequired: string[]
+}
+
+export const ReloadConfigRequestSchema: yup.MixedSchema<ReloadConfigRequest> = yup
+  .mixed()
+  .oneOf([undefined] as const)
+
+export const ReloadConfigResponseSchema: yup.ObjectSchema<ReloadConfigResponse> = yup
+  .object({
+    applied: yup.array(yup.string().defined()).defined(),
+    restartRequired: yup.array(yup.string().defined()).defined(),
+  })
+  .defined()
+
+router.register<typeof ReloadConfigRequestSchema, ReloadConfigResponse>(
+  `${ApiNamespace.config}/reloadConfig`,
+  ReloadConfigRequestSchema,
+  async (request, node): Promise<void> => {
+    let result
+
+    try {
+      result = await node.reloadConfig()
+    } catch (e: unknown) {
+      throw new ValidationError(ErrorUtils.renderError(e))
+    }
+
+    request.end(result)
+  },
+)
@@ -0,0 +1,67 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { ERROR_CODES, ValidationError } from '../../adapters/errors'
+import { ApiNamespace, router } from '../router'
+
+export type ProduceBlocksRequest = { count?: number; account?: string }
+export type ProduceBlocksResponse = {
+  blocks: { hash: string; sequence: number; transactions: number }[]
+}
+
+export const ProduceBlocksRequestSchema: yup.ObjectSchema<ProduceBlocksRequest> = yup
+  .object({
+    count: yup.number().integer().min(1).max(1000).optional(),
+    account: yup.string().optional(),
+  })
+  .defined()
+
+export const ProduceBlocksResponseSchema: yup.ObjectSchema<ProduceBlocksResponse> = yup
+  .object({
+    blocks: yup
+      .array(
+        yup
+          .object({
+            hash: yup.string().defined(),
+            sequence: yup.number().defined(),
+            transactions: yup.number().defined(),
+          })
+          .defined(),
+      )
+      .defined(),
+  })
+  .defined()
+
+router.register<typeof ProduceBlocksRequestSchema, ProduceBlocksResponse>(
+  `${ApiNamespace.dev}/produceBlocks`,
+  ProduceBlocksRequestSchema,
+  async (request, node): Promise<void> => {
+    if (!node.devBlockProducer) {
+      throw new ValidationError('Blocks can only be produced by a node started with dev:start')
+    }
+
+    let account = undefined
+    if (request.data.account) {
+      account = node.accounts.resolveAccount(request.data.account)
+
+      if (!account) {
+        throw new ValidationError(
+          `No account with name ${request.data.account}`,
+          undefined,
+          ERROR_CODES.ACCOUNT_NOT_FOUND,
+        )
+      }
+    }
+
+    const blocks = await node.devBlockProducer.produceBlocks(request.data.count ?? 1, account)
+
+    request.end({
+      blocks: blocks.map((block) => ({
+        hash: block.header.hash.toString('hex'),
+        sequence: block.header.sequence,
+        transactions: block.transactions.length,
+      })),
+    })
+  },
+)
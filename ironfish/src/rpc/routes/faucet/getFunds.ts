@@ -27,9 +27,13 @@ router.register<typeof GetFundsRequestSchema, GetFundsResponse>(
   `${ApiNamespace.faucet}/getFunds`,
   GetFundsRequestSchema,
   async (request, node): Promise<void> => {
-    const account = node.accounts.getAccountByName(request.data.accountName)
+    const account = node.accounts.resolveAccount(request.data.accountName)
     if (!account) {
-      throw new ValidationError(`Account ${request.data.accountName} could not be found`)
+      throw new ValidationError(
+        `Account ${request.data.accountName} could not be found`,
+        undefined,
+        ERROR_CODES.ACCOUNT_NOT_FOUND,
+      )
     }
 
     const api = new WebApi({
@@ -50,6 +54,12 @@ router.register<typeof GetFundsRequestSchema, GetFundsResponse>(
               ERROR_CODES.VALIDATION,
               status,
             )
+          } else if (status === 429) {
+            throw new ResponseError(
+              data.message ?? 'Too many faucet requests, try again later.',
+              ERROR_CODES.THROTTLED,
+              status,
+            )
           } else if (data.message) {
             throw new ResponseError(data.message, ERROR_CODES.ERROR, status)
           }
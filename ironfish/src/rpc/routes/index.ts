@@ -4,11 +4,13 @@
 export * from './accounts'
 export * from './config'
 export * from './chain'
+export * from './dev'
 export * from './events'
 export * from './node'
 export * from './peers'
 export * from './router'
 export * from './rpc'
+export * from './scanner'
 export * from './mining'
 export * from './transactions'
 export * from './faucet'
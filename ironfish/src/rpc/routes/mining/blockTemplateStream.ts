@@ -111,6 +111,9 @@ router.register<typeof BlockTemplateStreamRequestSchema, BlockTemplateStreamResp
     // If the listener stops listening, we no longer need to generate new block templates
     request.onClose.once(() => {
       node.miningManager.minersConnected--
+      if (node.miningManager.minersConnected === 0) {
+        node.miningManager.poolHashRate = 0
+      }
       node.chain.onConnectBlock.off(timeoutWrappedListener)
     })
   },
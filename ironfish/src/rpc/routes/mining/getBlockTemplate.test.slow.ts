@@ -0,0 +1,47 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+import { useMinerBlockFixture } from '../../../testUtilities'
+import { createRouteTest } from '../../../testUtilities/routeTest'
+
+describe('Route miner/getBlockTemplate', () => {
+  const routeTest = createRouteTest()
+
+  beforeAll(async () => {
+    await routeTest.node.accounts.createAccount('testAccount', true)
+  })
+
+  it('fails if the node is not connected to the network', async () => {
+    routeTest.node.config.set('miningForce', false)
+
+    await expect(routeTest.client.getBlockTemplate()).rejects.toThrow('Cannot mine')
+  })
+
+  it('returns a template on top of the chain head', async () => {
+    routeTest.node.config.set('miningForce', true)
+
+    const response = await routeTest.client.getBlockTemplate()
+
+    expect(response.content.header.sequence).toBe(routeTest.chain.head.sequence + 1)
+    expect(response.content.header.previousBlockHash).toEqual(
+      routeTest.chain.head.hash.toString('hex'),
+    )
+  })
+
+  it('waits for a new chain head when given the current head', async () => {
+    const { chain, node } = routeTest
+    node.config.set('miningForce', true)
+
+    const response = routeTest.client.getBlockTemplate({
+      previousBlockHash: chain.head.hash.toString('hex'),
+      timeoutMs: 60000,
+    })
+
+    const block = await useMinerBlockFixture(chain, chain.head.sequence + 1)
+    await expect(chain).toAddBlock(block)
+
+    const { content } = await response
+    expect(content.header.previousBlockHash).toEqual(block.header.hash.toString('hex'))
+  }, 20000)
+})
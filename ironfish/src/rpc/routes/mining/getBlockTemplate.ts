@@ -0,0 +1,99 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { SerializedBlockTemplate } from '../../../serde/BlockTemplateSerde'
+import { ErrorUtils } from '../../../utils'
+import { ERROR_CODES, ValidationError } from '../../adapters'
+import { ApiNamespace, router } from '../router'
+import { BlockTemplateStreamResponseSchema } from './blockTemplateStream'
+
+const DEFAULT_LONG_POLL_MS = 30 * 1000
+const MAX_LONG_POLL_MS = 5 * 60 * 1000
+
+export type GetBlockTemplateRequest = {
+  /**
+   * If this is the hash of the current chain head, wait until a new block is
+   * connected or `timeoutMs` passes before returning a template
+   */
+  previousBlockHash?: string
+  timeoutMs?: number
+}
+
+export type GetBlockTemplateResponse = SerializedBlockTemplate
+
+export const GetBlockTemplateRequestSchema: yup.ObjectSchema<GetBlockTemplateRequest> = yup
+  .object({
+    previousBlockHash: yup.string().optional(),
+    timeoutMs: yup.number().integer().min(0).max(MAX_LONG_POLL_MS).optional(),
+  })
+  .defined()
+
+export const GetBlockTemplateResponseSchema: yup.ObjectSchema<GetBlockTemplateResponse> =
+  BlockTemplateStreamResponseSchema
+
+router.register<typeof GetBlockTemplateRequestSchema, GetBlockTemplateResponse>(
+  `${ApiNamespace.miner}/getBlockTemplate`,
+  GetBlockTemplateRequestSchema,
+  async (request, node): Promise<void> => {
+    if (!node.config.get('miningForce')) {
+      if (!node.chain.synced) {
+        throw new ValidationError(
+          'Cannot mine while the node is syncing',
+          undefined,
+          ERROR_CODES.NOT_SYNCED,
+        )
+      }
+
+      if (!node.peerNetwork.isReady) {
+        throw new ValidationError(
+          'Cannot mine while the node is not connected to the network',
+          undefined,
+          ERROR_CODES.NOT_CONNECTED,
+        )
+      }
+    }
+
+    const previousBlockHash = request.data.previousBlockHash
+
+    if (previousBlockHash && previousBlockHash === node.chain.head.hash.toString('hex')) {
+      const timeoutMs = request.data.timeoutMs ?? DEFAULT_LONG_POLL_MS
+
+      await new Promise<void>((resolve) => {
+        const done = () => {
+          clearTimeout(timeout)
+          node.chain.onConnectBlock.off(onConnectBlock)
+          request.onClose.off(done)
+          resolve()
+        }
+
+        // Wrap the listener to avoid a deadlock from chain.newBlock()
+        const onConnectBlock = () => {
+          setTimeout(done)
+        }
+
+        const timeout = setTimeout(done, timeoutMs)
+        node.chain.onConnectBlock.on(onConnectBlock)
+        request.onClose.on(done)
+      })
+
+      if (request.closed) {
+        return
+      }
+    }
+
+    const head = await node.chain.getBlock(node.chain.head)
+    if (!head) {
+      throw new ValidationError('Could not find the chain head')
+    }
+
+    let template: SerializedBlockTemplate
+    try {
+      template = await node.miningManager.createNewBlockTemplate(head)
+    } catch (e: unknown) {
+      throw new ValidationError(`Failed to create block template: ${ErrorUtils.renderError(e)}`)
+    }
+
+    request.end(template)
+  },
+)
@@ -4,4 +4,6 @@
 
 export * from './blockTemplateStream'
 export * from './exportMined'
+export * from './getBlockTemplate'
 export * from './submitBlock'
+export * from './submitHashRate'
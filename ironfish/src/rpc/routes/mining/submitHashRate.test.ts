@@ -0,0 +1,22 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { createRouteTest } from '../../../testUtilities/routeTest'
+
+describe('Route miner/submitHashRate', () => {
+  const routeTest = createRouteTest()
+
+  it('should record the pool hashrate in the metrics history', async () => {
+    const response = await routeTest.client.submitHashRate({ hashRate: 1500 })
+
+    expect(response.status).toBe(200)
+    expect(routeTest.node.miningManager.poolHashRate).toBe(1500)
+    expect(routeTest.node.metricsHistory.sample()).toMatchObject({ hashRate: 1500 })
+  })
+
+  it('should reject a negative hashrate', async () => {
+    await expect(routeTest.client.submitHashRate({ hashRate: -1 })).rejects.toThrow(
+      'hashRate must be greater than or equal to 0',
+    )
+  })
+})
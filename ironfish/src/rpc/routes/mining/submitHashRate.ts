@@ -0,0 +1,27 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { ApiNamespace, router } from '../router'
+
+export type SubmitHashRateRequest = { hashRate: number }
+export type SubmitHashRateResponse = undefined
+
+export const SubmitHashRateRequestSchema: yup.ObjectSchema<SubmitHashRateRequest> = yup
+  .object({
+    hashRate: yup.number().min(0).defined(),
+  })
+  .defined()
+
+export const SubmitHashRateResponseSchema: yup.MixedSchema<SubmitHashRateResponse> = yup
+  .mixed()
+  .oneOf([undefined] as const)
+
+router.register<typeof SubmitHashRateRequestSchema, SubmitHashRateResponse>(
+  `${ApiNamespace.miner}/submitHashRate`,
+  SubmitHashRateRequestSchema,
+  (request, node): void => {
+    node.miningManager.poolHashRate = request.data.hashRate
+    request.end()
+  },
+)
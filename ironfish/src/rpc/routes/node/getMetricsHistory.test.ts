@@ -0,0 +1,30 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { createRouteTest } from '../../../testUtilities/routeTest'
+
+describe('Route node/getMetricsHistory', () => {
+  const routeTest = createRouteTest()
+
+  it('should return samples since the requested time', async () => {
+    const history = routeTest.node.metricsHistory
+    history.sample(1000)
+    history.sample(Date.now())
+
+    const response = await routeTest.client
+      .request('node/getMetricsHistory', { since: 2000 })
+      .waitForEnd()
+
+    expect(response.status).toBe(200)
+    expect(response.content).toMatchObject({
+      enabled: true,
+      samples: [
+        {
+          headSequence: routeTest.chain.head.sequence,
+          peers: expect.any(Number),
+          memPoolSize: expect.any(Number),
+        },
+      ],
+    })
+  })
+})
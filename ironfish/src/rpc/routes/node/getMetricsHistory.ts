@@ -0,0 +1,59 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { MetricsHistorySample } from '../../../fileStores/metricsHistory'
+import { ApiNamespace, router } from '../router'
+
+export type GetMetricsHistoryRequest =
+  | undefined
+  | {
+      /**
+       * Only return samples recorded at or after this unix timestamp in milliseconds
+       */
+      since?: number
+    }
+
+export type GetMetricsHistoryResponse = {
+  enabled: boolean
+  samples: MetricsHistorySample[]
+}
+
+export const GetMetricsHistoryRequestSchema: yup.ObjectSchema<GetMetricsHistoryRequest> = yup
+  .object({
+    since: yup.number().optional(),
+  })
+  .optional()
+  .default({})
+
+export const GetMetricsHistoryResponseSchema: yup.ObjectSchema<GetMetricsHistoryResponse> = yup
+  .object({
+    enabled: yup.boolean().defined(),
+    samples: yup
+      .array(
+        yup
+          .object({
+            timestamp: yup.number().defined(),
+            blockAddTime: yup.number().defined(),
+            headSequence: yup.number().defined(),
+            peers: yup.number().defined(),
+            memPoolSize: yup.number().defined(),
+            heapUsed: yup.number().defined(),
+            hashRate: yup.number().defined(),
+          })
+          .defined(),
+      )
+      .defined(),
+  })
+  .defined()
+
+router.register<typeof GetMetricsHistoryRequestSchema, GetMetricsHistoryResponse>(
+  `${ApiNamespace.node}/getMetricsHistory`,
+  GetMetricsHistoryRequestSchema,
+  (request, node): void => {
+    const enabled = node.config.get('enableMetrics') && node.config.get('enableMetricsHistory')
+    const samples = node.metricsHistory.getSamples(request.data?.since)
+
+    request.end({ enabled, samples })
+  },
+)
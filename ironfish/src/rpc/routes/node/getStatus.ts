@@ -2,8 +2,11 @@
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 import * as yup from 'yup'
+import { DiskStatus } from '../../../diskMonitor'
+import { MemoryConsumer, MemoryPressure } from '../../../memoryBudget'
 import { IronfishNode } from '../../../node'
 import { MathUtils, PromiseUtils } from '../../../utils'
+import { WorkerMessageType } from '../../../workerPool/tasks/workerMessage'
 import { ApiNamespace, router } from '../router'
 
 export type GetStatusRequest =
@@ -25,6 +28,19 @@ export type GetStatusResponse = {
     rss: number
     memFree: number
     memTotal: number
+    pressure: MemoryPressure
+    budget: number
+    consumers: MemoryConsumer[]
+  }
+  clock: {
+    offsetMs: number | null
+    skewed: boolean
+    compensationMs: number
+  }
+  disk: {
+    status: DiskStatus
+    free: number | null
+    dataDir: string
   }
   miningDirector: {
     status: 'started'
@@ -37,9 +53,23 @@ export type GetStatusResponse = {
   blockchain: {
     synced: boolean
     head: string
+    // Null unless maxReorgDepth is set
+    finalizedSequence: number | null
+    // A fork deeper than maxReorgDepth that waits to be confirmed
+    refusedReorg: string | null
+    caches: {
+      name: string
+      hitRate: number
+      items: number
+      sizeBytes: number
+      maxBytes: number
+    }[]
   }
   blockSyncer: {
     status: 'stopped' | 'idle' | 'stopping' | 'syncing'
+    // True if the last peer we synced from stalled and we have no blocks since
+    stalled?: boolean
+    stalls?: number
     syncing?: {
       blockSpeed: number
       speed: number
@@ -52,11 +82,24 @@ export type GetStatusResponse = {
     inboundTraffic: number
     outboundTraffic: number
   }
+  blockPropagation: {
+    forwardTime: number
+    forwardTimeLast: number
+    minedAckTime: number
+    minedAckTimeLast: number
+  }
   telemetry: {
     status: 'started' | 'stopped'
     pending: number
     submitted: number
   }
+  accounts: {
+    scanning: boolean
+    sequence: number
+    endSequence: number
+    // The highest block scanned for each account and how far behind the chain head it is
+    scanned: { name: string; sequence: number; behind: number; enabled: boolean }[]
+  }
   workers: {
     started: boolean
     workers: number
@@ -65,6 +108,9 @@ export type GetStatusResponse = {
     executing: number
     change: number
     speed: number
+    maxWorkers: number
+    queueLatency: number
+    queues: Array<{ name: string; queued: number }>
   }
 }
 
@@ -92,6 +138,35 @@ export const GetStatusResponseSchema: yup.ObjectSchema<GetStatusResponse> = yup
         rss: yup.number().defined(),
         memFree: yup.number().defined(),
         memTotal: yup.number().defined(),
+        pressure: yup
+          .string<MemoryPressure>()
+          .oneOf(['normal', 'elevated', 'critical'])
+          .defined(),
+        budget: yup.number().defined(),
+        consumers: yup
+          .array(
+            yup
+              .object({
+                name: yup.string().defined(),
+                bytes: yup.number().defined(),
+              })
+              .defined(),
+          )
+          .defined(),
+      })
+      .defined(),
+    clock: yup
+      .object({
+        offsetMs: yup.number().nullable().defined(),
+        skewed: yup.boolean().defined(),
+        compensationMs: yup.number().defined(),
+      })
+      .defined(),
+    disk: yup
+      .object({
+        status: yup.string<DiskStatus>().oneOf(['ok', 'low', 'critical']).defined(),
+        free: yup.number().nullable().defined(),
+        dataDir: yup.string().defined(),
       })
       .defined(),
     miningDirector: yup
@@ -110,6 +185,21 @@ export const GetStatusResponseSchema: yup.ObjectSchema<GetStatusResponse> = yup
       .object({
         synced: yup.boolean().defined(),
         head: yup.string().defined(),
+        finalizedSequence: yup.number().nullable().defined(),
+        refusedReorg: yup.string().nullable().defined(),
+        caches: yup
+          .array(
+            yup
+              .object({
+                name: yup.string().defined(),
+                hitRate: yup.number().defined(),
+                items: yup.number().defined(),
+                sizeBytes: yup.number().defined(),
+                maxBytes: yup.number().defined(),
+              })
+              .defined(),
+          )
+          .defined(),
       })
       .defined(),
     peerNetwork: yup
@@ -120,10 +210,20 @@ export const GetStatusResponseSchema: yup.ObjectSchema<GetStatusResponse> = yup
         outboundTraffic: yup.number().defined(),
       })
       .defined(),
+    blockPropagation: yup
+      .object({
+        forwardTime: yup.number().defined(),
+        forwardTimeLast: yup.number().defined(),
+        minedAckTime: yup.number().defined(),
+        minedAckTimeLast: yup.number().defined(),
+      })
+      .defined(),
     blockSyncer: yup
       .object({
         status: yup.string().oneOf(['stopped', 'idle', 'stopping', 'syncing']).defined(),
         error: yup.string().optional(),
+        stalled: yup.boolean().optional(),
+        stalls: yup.number().optional(),
         syncing: yup
           .object({
             blockSpeed: yup.number().defined(),
@@ -140,6 +240,25 @@ export const GetStatusResponseSchema: yup.ObjectSchema<GetStatusResponse> = yup
         submitted: yup.number().defined(),
       })
       .defined(),
+    accounts: yup
+      .object({
+        scanning: yup.boolean().defined(),
+        sequence: yup.number().defined(),
+        endSequence: yup.number().defined(),
+        scanned: yup
+          .array(
+            yup
+              .object({
+                name: yup.string().defined(),
+                sequence: yup.number().defined(),
+                behind: yup.number().defined(),
+                enabled: yup.boolean().defined(),
+              })
+              .defined(),
+          )
+          .defined(),
+      })
+      .defined(),
     workers: yup
       .object({
         started: yup.boolean().defined(),
@@ -149,6 +268,18 @@ export const GetStatusResponseSchema: yup.ObjectSchema<GetStatusResponse> = yup
         executing: yup.number().defined(),
         change: yup.number().defined(),
         speed: yup.number().defined(),
+        maxWorkers: yup.number().defined(),
+        queueLatency: yup.number().defined(),
+        queues: yup
+          .array(
+            yup
+              .object({
+                name: yup.string().defined(),
+                queued: yup.number().defined(),
+              })
+              .defined(),
+          )
+          .defined(),
       })
       .defined(),
   })
@@ -181,6 +312,8 @@ router.register<typeof GetStatusRequestSchema, GetStatusResponse>(
 )
 
 function getStatus(node: IronfishNode): GetStatusResponse {
+  const refusedReorg = node.chain.refusedReorg
+
   const status: GetStatusResponse = {
     peerNetwork: {
       peers: node.metrics.p2p_PeersCount.value,
@@ -188,11 +321,31 @@ function getStatus(node: IronfishNode): GetStatusResponse {
       inboundTraffic: Math.max(node.metrics.p2p_InboundTraffic.rate1s, 0),
       outboundTraffic: Math.max(node.metrics.p2p_OutboundTraffic.rate1s, 0),
     },
+    blockPropagation: {
+      forwardTime: MathUtils.round(node.metrics.p2p_BlockPropagation.average, 2),
+      forwardTimeLast: MathUtils.round(node.metrics.p2p_BlockPropagation.history(0), 2),
+      minedAckTime: MathUtils.round(node.metrics.p2p_MinedBlockAcknowledgement.average, 2),
+      minedAckTimeLast: MathUtils.round(
+        node.metrics.p2p_MinedBlockAcknowledgement.history(0),
+        2,
+      ),
+    },
     blockchain: {
       synced: node.chain.synced,
       head: `${node.chain.head.hash.toString('hex') || ''} (${
         node.chain.head.sequence.toString() || ''
       })`,
+      finalizedSequence: node.chain.finalizedSequence,
+      refusedReorg: refusedReorg
+        ? `${refusedReorg.hash.toString('hex')} (${refusedReorg.sequence})`
+        : null,
+      caches: node.chain.getCacheStats().map((c) => ({
+        name: c.name,
+        hitRate: MathUtils.round(c.hitRate, 4),
+        items: c.items,
+        sizeBytes: c.sizeBytes,
+        maxBytes: c.maxBytes,
+      })),
     },
     node: {
       status: node.started ? 'started' : 'stopped',
@@ -206,6 +359,19 @@ function getStatus(node: IronfishNode): GetStatusResponse {
       rss: node.metrics.rss.value,
       memFree: node.metrics.memFree.value,
       memTotal: node.metrics.memTotal,
+      pressure: node.memoryBudget.pressure,
+      budget: node.memoryBudget.budget,
+      consumers: node.memoryBudget.getConsumers(),
+    },
+    clock: {
+      offsetMs: node.clockMonitor.offsetMs,
+      skewed: node.clockMonitor.skewed,
+      compensationMs: node.chain.verifier.clockOffsetMs,
+    },
+    disk: {
+      status: node.diskMonitor.status,
+      free: node.diskMonitor.free,
+      dataDir: node.diskMonitor.dataDir,
     },
     miningDirector: {
       status: 'started',
@@ -217,6 +383,8 @@ function getStatus(node: IronfishNode): GetStatusResponse {
     },
     blockSyncer: {
       status: node.syncer.state,
+      stalled: node.syncer.stalled,
+      stalls: node.syncer.stalls,
       syncing: {
         blockSpeed: MathUtils.round(node.chain.addSpeed.avg, 2),
         speed: MathUtils.round(node.syncer.speed.rate1m, 2),
@@ -228,6 +396,17 @@ function getStatus(node: IronfishNode): GetStatusResponse {
       pending: node.telemetry.pending,
       submitted: node.telemetry.submitted,
     },
+    accounts: {
+      scanning: node.accounts.scan !== null,
+      sequence: node.accounts.scan?.sequence ?? 0,
+      endSequence: node.accounts.scan?.endSequence ?? 0,
+      scanned: node.accounts.listAccounts().map((account) => ({
+        name: account.name,
+        sequence: node.accounts.getScannedSequence(account),
+        behind: node.accounts.getScanLag(account),
+        enabled: node.accounts.isScanningEnabled(account),
+      })),
+    },
     workers: {
       started: node.workerPool.started,
       workers: node.workerPool.workers.length,
@@ -236,8 +415,24 @@ function getStatus(node: IronfishNode): GetStatusResponse {
       capacity: node.workerPool.capacity,
       change: MathUtils.round(node.workerPool.change?.rate5s ?? 0, 2),
       speed: MathUtils.round(node.workerPool.speed?.rate5s ?? 0, 2),
+      maxWorkers: node.workerPool.maxWorkers,
+      queueLatency: MathUtils.round(node.workerPool.queueLatency.average, 2),
+      queues: getWorkerQueues(node),
     },
   }
 
   return status
 }
+
+function getWorkerQueues(node: IronfishNode): GetStatusResponse['workers']['queues'] {
+  const queues = []
+
+  for (const type of node.workerPool.stats.keys()) {
+    const queued = node.workerPool.queue.lengthOf(type)
+    if (queued > 0) {
+      queues.push({ name: WorkerMessageType[type], queued })
+    }
+  }
+
+  return queues
+}
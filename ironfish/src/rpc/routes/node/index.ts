@@ -3,5 +3,7 @@
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 
 export * from './getLogStream'
+export * from './getMetricsHistory'
 export * from './getStatus'
+export * from './setLogLevel'
 export * from './stopNode'
@@ -0,0 +1,32 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { LogLevel } from 'consola'
+import { ConsoleReporterInstance } from '../../../logger'
+import { createRouteTest } from '../../../testUtilities/routeTest'
+
+describe('Route node/setLogLevel', () => {
+  const routeTest = createRouteTest()
+
+  afterEach(() => {
+    ConsoleReporterInstance.tagToLogLevelMap.delete('syncer')
+  })
+
+  it('should set the log level for a module', async () => {
+    const response = await routeTest.client
+      .request('node/setLogLevel', { module: 'Syncer', level: 'debug' })
+      .waitForEnd()
+
+    expect(response.status).toBe(200)
+    expect(response.content).toEqual({ module: 'syncer', level: 'debug' })
+    expect(ConsoleReporterInstance.tagToLogLevelMap.get('syncer')).toBe(LogLevel.Debug)
+  })
+
+  it('should error on an invalid log level', async () => {
+    await expect(
+      routeTest.client
+        .request('node/setLogLevel', { module: 'syncer', level: 'loud' })
+        .waitForEnd(),
+    ).rejects.toThrow('Log level loud should be one of the following')
+  })
+})
@@ -0,0 +1,43 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { setLogLevelForTag } from '../../../logger'
+import { ErrorUtils } from '../../../utils'
+import { ValidationError } from '../../adapters/errors'
+import { ApiNamespace, router } from '../router'
+
+export type SetLogLevelRequest = { module: string; level: string }
+export type SetLogLevelResponse = { module: string; level: string }
+
+export const SetLogLevelRequestSchema: yup.ObjectSchema<SetLogLevelRequest> = yup
+  .object({
+    module: yup.string().trim().defined(),
+    level: yup.string().trim().defined(),
+  })
+  .defined()
+
+export const SetLogLevelResponseSchema: yup.ObjectSchema<SetLogLevelResponse> = yup
+  .object({
+    module: yup.string().defined(),
+    level: yup.string().defined(),
+  })
+  .defined()
+
+router.register<typeof SetLogLevelRequestSchema, SetLogLevelResponse>(
+  `${ApiNamespace.node}/setLogLevel`,
+  SetLogLevelRequestSchema,
+  (request, node): void => {
+    const module = request.data.module.toLowerCase()
+    const level = request.data.level.toLowerCase()
+
+    try {
+      setLogLevelForTag(module, level)
+    } catch (e: unknown) {
+      throw new ValidationError(ErrorUtils.renderError(e))
+    }
+
+    node.logger.withTag('logger').info(`Set log level for ${module} to ${level}`)
+    request.end({ module, level })
+  },
+)
@@ -31,6 +31,7 @@ export const GetPeerResponseSchema: yup.ObjectSchema<GetPeerResponse> = yup
         state: yup.string().defined(),
         address: yup.string().nullable().defined(),
         port: yup.number().nullable().defined(),
+        latency: yup.number().nullable().defined(),
         identity: yup.string().nullable().defined(),
         name: yup.string().nullable().defined(),
         head: yup.string().nullable().defined(),
@@ -112,6 +113,7 @@ function getPeer(network: PeerNetwork, identity: string): PeerResponse | null {
         state: peer.state.type,
         address: peer.address,
         port: peer.port,
+        latency: peer.latencyMs,
         identity: peer.state.identity,
         name: peer.name,
         version: peer.version,
@@ -18,6 +18,8 @@ export type PeerResponse = {
   name: string | null
   address: string | null
   port: number | null
+  // The smoothed round trip time to the peer in ms
+  latency: number | null
   error: string | null
   connections: number
   connectionWebSocket: ConnectionState
@@ -52,6 +54,7 @@ export const GetPeersResponseSchema: yup.ObjectSchema<GetPeersResponse> = yup
             state: yup.string().defined(),
             address: yup.string().nullable().defined(),
             port: yup.number().nullable().defined(),
+            latency: yup.number().nullable().defined(),
             identity: yup.string().nullable().defined(),
             name: yup.string().nullable().defined(),
             head: yup.string().nullable().defined(),
@@ -136,6 +139,7 @@ function getPeers(network: PeerNetwork): PeerResponse[] {
       state: peer.state.type,
       address: peer.address,
       port: peer.port,
+      latency: peer.latencyMs,
       identity: peer.state.identity,
       name: peer.name,
       version: peer.version,
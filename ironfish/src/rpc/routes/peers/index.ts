@@ -5,3 +5,4 @@
 export * from './getPeers'
 export * from './getPeer'
 export * from './getPeerMessages'
+export * from './testPeers'
@@ -0,0 +1,134 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import net from 'net'
+import { getConnectedPeer } from '../../../network/testUtilities'
+import { createRouteTest } from '../../../testUtilities/routeTest'
+import { AsyncUtils } from '../../../utils'
+
+describe('Route peer/testPeers', () => {
+  const routeTest = createRouteTest()
+
+  beforeEach(() => {
+    routeTest.peerNetwork.bootstrapNodes.splice(0)
+  })
+
+  it('measures connected peers', async () => {
+    const { peerNetwork } = routeTest
+    const { peer } = getConnectedPeer(peerNetwork.peerManager)
+
+    const getBlockHashes = jest.spyOn(peerNetwork, 'getBlockHashes').mockResolvedValue([])
+    const getBlocks = jest.spyOn(peerNetwork, 'getBlocks').mockResolvedValue([])
+
+    const response = routeTest.client.testPeersStream({ blocks: 2 })
+    const results = await AsyncUtils.materialize(response.contentStream())
+
+    expect(getBlockHashes).toHaveBeenCalledWith(peer, 1, 1)
+    expect(getBlocks).toHaveBeenCalledWith(peer, routeTest.chain.genesis.hash, 2)
+
+    expect(results).toHaveLength(1)
+    expect(results[0]).toMatchObject({
+      type: 'peer',
+      identity: peer.state.identity,
+      latencyMs: expect.any(Number),
+      throughput: expect.any(Number),
+      blocks: 0,
+      error: null,
+      underperforming: false,
+      hints: [],
+    })
+  })
+
+  it('skips the block download when no blocks are requested', async () => {
+    const { peerNetwork } = routeTest
+    getConnectedPeer(peerNetwork.peerManager)
+
+    jest.spyOn(peerNetwork, 'getBlockHashes').mockResolvedValue([])
+    const getBlocks = jest.spyOn(peerNetwork, 'getBlocks')
+
+    const response = routeTest.client.testPeersStream({ blocks: 0 })
+    const results = await AsyncUtils.materialize(response.contentStream())
+
+    expect(getBlocks).not.toHaveBeenCalled()
+    expect(results[0]).toMatchObject({ throughput: null, underperforming: false })
+  })
+
+  it('reports peers that do not answer', async () => {
+    const { peerNetwork } = routeTest
+    getConnectedPeer(peerNetwork.peerManager)
+
+    jest.spyOn(peerNetwork, 'getBlockHashes').mockRejectedValue(new Error('Request timed out'))
+    const getBlocks = jest.spyOn(peerNetwork, 'getBlocks')
+
+    const response = routeTest.client.testPeersStream()
+    const results = await AsyncUtils.materialize(response.contentStream())
+
+    expect(getBlocks).not.toHaveBeenCalled()
+    expect(results[0]).toMatchObject({
+      latencyMs: null,
+      error: expect.stringContaining('Request timed out'),
+      underperforming: true,
+      hints: [expect.stringContaining('did not answer')],
+    })
+  })
+
+  it('reports peers that fail to send blocks', async () => {
+    const { peerNetwork } = routeTest
+    getConnectedPeer(peerNetwork.peerManager)
+
+    jest.spyOn(peerNetwork, 'getBlockHashes').mockResolvedValue([])
+    jest.spyOn(peerNetwork, 'getBlocks').mockRejectedValue(new Error('Request timed out'))
+
+    const response = routeTest.client.testPeersStream()
+    const results = await AsyncUtils.materialize(response.contentStream())
+
+    expect(results[0]).toMatchObject({
+      latencyMs: expect.any(Number),
+      throughput: null,
+      underperforming: true,
+      hints: [expect.stringContaining('MTU')],
+    })
+  })
+
+  it('reports bootstrap nodes that refuse connections', async () => {
+    // Find a port that nothing is listening on
+    const server = net.createServer()
+    await new Promise<void>((resolve) => server.listen(0, '127.0.0.1', resolve))
+    const port = (server.address() as net.AddressInfo).port
+    await new Promise((resolve) => server.close(resolve))
+
+    routeTest.peerNetwork.bootstrapNodes.push(`127.0.0.1:${port}`)
+
+    const response = routeTest.client.testPeersStream()
+    const results = await AsyncUtils.materialize(response.contentStream())
+
+    expect(results).toHaveLength(1)
+    expect(results[0]).toMatchObject({
+      type: 'bootstrap',
+      address: `127.0.0.1:${port}`,
+      handshakeMs: null,
+      error: expect.any(String),
+      underperforming: true,
+      hints: [expect.stringContaining('refused')],
+    })
+  })
+
+  it('measures the handshake with bootstrap nodes', async () => {
+    const server = net.createServer((socket) => socket.destroy())
+    await new Promise<void>((resolve) => server.listen(0, '127.0.0.1', resolve))
+    const port = (server.address() as net.AddressInfo).port
+
+    routeTest.peerNetwork.bootstrapNodes.push(`127.0.0.1:${port}`)
+
+    const response = routeTest.client.testPeersStream()
+    const results = await AsyncUtils.materialize(response.contentStream())
+    await new Promise((resolve) => server.close(resolve))
+
+    expect(results[0]).toMatchObject({
+      type: 'bootstrap',
+      handshakeMs: expect.any(Number),
+      error: null,
+      underperforming: false,
+    })
+  })
+})
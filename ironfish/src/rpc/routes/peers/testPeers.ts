@@ -0,0 +1,252 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import net from 'net'
+import * as yup from 'yup'
+import { GENESIS_BLOCK_SEQUENCE } from '../../../consensus'
+import { DEFAULT_WEBSOCKET_PORT } from '../../../fileStores/config'
+import { Peer, PeerNetwork } from '../../../network'
+import { GetBlocksResponse } from '../../../network/messages/getBlocks'
+import { parseBootstrapNode, parseUrl } from '../../../network/utils'
+import { ErrorUtils } from '../../../utils'
+import { ValidationError } from '../../adapters'
+import { ApiNamespace, router } from '../router'
+
+const HANDSHAKE_TIMEOUT_MS = 5000
+const DEFAULT_SAMPLE_BLOCKS = 10
+
+// Connections slower than these are reported as underperforming
+const SLOW_HANDSHAKE_MS = 1000
+const SLOW_LATENCY_MS = 1000
+const SLOW_THROUGHPUT_BYTES_PER_SECOND = 50 * 1024
+
+export type TestPeersRequest = {
+  /**
+   * How many blocks to download from each connected peer to measure throughput
+   */
+  blocks?: number
+}
+
+export type TestPeersResponse = {
+  type: 'bootstrap' | 'peer'
+  address: string | null
+  identity: string | null
+  name: string | null
+  /**
+   * How long it took to open a TCP connection, bootstrap nodes only
+   */
+  handshakeMs: number | null
+  /**
+   * The round trip time of a small request to the peer
+   */
+  latencyMs: number | null
+  /**
+   * The rate the sample of blocks was downloaded at
+   */
+  throughput: number | null
+  blocks: number
+  error: string | null
+  underperforming: boolean
+  hints: string[]
+}
+
+export const TestPeersRequestSchema: yup.ObjectSchema<TestPeersRequest> = yup
+  .object({
+    blocks: yup.number().integer().min(0).max(100).optional(),
+  })
+  .defined()
+
+export const TestPeersResponseSchema: yup.ObjectSchema<TestPeersResponse> = yup
+  .object({
+    type: yup.string<'bootstrap' | 'peer'>().oneOf(['bootstrap', 'peer']).defined(),
+    address: yup.string().nullable().defined(),
+    identity: yup.string().nullable().defined(),
+    name: yup.string().nullable().defined(),
+    handshakeMs: yup.number().nullable().defined(),
+    latencyMs: yup.number().nullable().defined(),
+    throughput: yup.number().nullable().defined(),
+    blocks: yup.number().defined(),
+    error: yup.string().nullable().defined(),
+    underperforming: yup.boolean().defined(),
+    hints: yup.array(yup.string().defined()).defined(),
+  })
+  .defined()
+
+router.register<typeof TestPeersRequestSchema, TestPeersResponse>(
+  `${ApiNamespace.peer}/testPeers`,
+  TestPeersRequestSchema,
+  async (request, node): Promise<void> => {
+    const peerNetwork = node.peerNetwork
+
+    if (!peerNetwork) {
+      throw new ValidationError(`The node is not connected to the network`)
+    }
+
+    const sampleBlocks = request.data.blocks ?? DEFAULT_SAMPLE_BLOCKS
+
+    for (const bootstrapNode of peerNetwork.bootstrapNodes) {
+      if (request.closed) {
+        return
+      }
+
+      request.stream(await testBootstrapNode(bootstrapNode))
+    }
+
+    const start = Math.max(node.chain.head.sequence - sampleBlocks + 1, GENESIS_BLOCK_SEQUENCE)
+    const startHash = await node.chain.getHashAtSequence(start)
+
+    for (const peer of peerNetwork.peerManager.getConnectedPeers()) {
+      if (request.closed) {
+        return
+      }
+
+      request.stream(await testPeer(peerNetwork, peer, startHash, sampleBlocks))
+    }
+
+    request.end()
+  },
+)
+
+async function testBootstrapNode(bootstrapNode: string): Promise<TestPeersResponse> {
+  // Bootstrap nodes can be pinned to an identity with identity@address
+  const { address, identity } = parseBootstrapNode(bootstrapNode)
+  const url = parseUrl(address)
+  const host = url.hostname ?? ''
+  const port = url.port ?? DEFAULT_WEBSOCKET_PORT
+
+  const result: TestPeersResponse = {
+    type: 'bootstrap',
+    address: `${host}:${port}`,
+    identity,
+    name: null,
+    handshakeMs: null,
+    latencyMs: null,
+    throughput: null,
+    blocks: 0,
+    error: null,
+    underperforming: false,
+    hints: [],
+  }
+
+  try {
+    result.handshakeMs = await measureHandshake(host, port)
+  } catch (e: unknown) {
+    result.error = ErrorUtils.renderError(e)
+    result.underperforming = true
+    result.hints.push(getConnectionHint(e))
+    return result
+  }
+
+  if (result.handshakeMs > SLOW_HANDSHAKE_MS) {
+    result.underperforming = true
+    result.hints.push('Connecting is slow, the node may be far from your region or congested')
+  }
+
+  return result
+}
+
+async function testPeer(
+  peerNetwork: PeerNetwork,
+  peer: Peer,
+  startHash: Buffer | null,
+  sampleBlocks: number,
+): Promise<TestPeersResponse> {
+  const result: TestPeersResponse = {
+    type: 'peer',
+    address: peer.address ? peer.getWebSocketAddress(false) : null,
+    identity: peer.state.identity,
+    name: peer.name,
+    handshakeMs: null,
+    latencyMs: null,
+    throughput: null,
+    blocks: 0,
+    error: null,
+    underperforming: false,
+    hints: [],
+  }
+
+  try {
+    const latencyStart = Date.now()
+    await peerNetwork.getBlockHashes(peer, GENESIS_BLOCK_SEQUENCE, 1)
+    result.latencyMs = Date.now() - latencyStart
+  } catch (e: unknown) {
+    result.error = ErrorUtils.renderError(e)
+    result.underperforming = true
+    result.hints.push('The peer did not answer a small request, it may be overloaded')
+    return result
+  }
+
+  if (result.latencyMs > SLOW_LATENCY_MS) {
+    result.underperforming = true
+    result.hints.push('High latency, the peer may be far from your region')
+  }
+
+  if (!startHash || sampleBlocks === 0) {
+    return result
+  }
+
+  try {
+    const downloadStart = Date.now()
+    const blocks = await peerNetwork.getBlocks(peer, startHash, sampleBlocks)
+    const elapsedMs = Math.max(Date.now() - downloadStart, 1)
+
+    const bytes = new GetBlocksResponse(blocks, 0).getSize()
+    result.blocks = blocks.length
+    result.throughput = Math.round((bytes / elapsedMs) * 1000)
+  } catch (e: unknown) {
+    // Small requests worked, so a failed large one usually means packets are being dropped
+    result.error = ErrorUtils.renderError(e)
+    result.underperforming = true
+    result.hints.push('Downloading blocks failed, check for an MTU mismatch or VPN issues')
+    return result
+  }
+
+  if (result.blocks > 1 && result.throughput < SLOW_THROUGHPUT_BYTES_PER_SECOND) {
+    result.underperforming = true
+    result.hints.push('Downloading blocks is slow, your bandwidth to this peer is limited')
+  }
+
+  return result
+}
+
+function measureHandshake(host: string, port: number): Promise<number> {
+  return new Promise((resolve, reject) => {
+    const start = Date.now()
+    const socket = net.connect({ host, port })
+
+    socket.setTimeout(HANDSHAKE_TIMEOUT_MS)
+
+    socket.once('connect', () => {
+      socket.destroy()
+      resolve(Date.now() - start)
+    })
+
+    socket.once('timeout', () => {
+      socket.destroy()
+      reject(new Error(`Timed out after ${HANDSHAKE_TIMEOUT_MS}ms`))
+    })
+
+    socket.once('error', (error) => {
+      socket.destroy()
+      reject(error)
+    })
+  })
+}
+
+function getConnectionHint(error: unknown): string {
+  const code = ErrorUtils.isNodeError(error) ? error.code : null
+
+  switch (code) {
+    case 'ENOTFOUND':
+    case 'EAI_AGAIN':
+      return 'The hostname could not be resolved, check your DNS settings'
+    case 'ECONNREFUSED':
+      return 'The connection was refused, the bootstrap node may be down'
+    case 'ECONNRESET':
+    case 'EHOSTUNREACH':
+    case 'ENETUNREACH':
+      return 'The host is unreachable, check your firewall and network connection'
+    default:
+      return 'The connection failed, a firewall may be blocking outbound connections'
+  }
+}
@@ -0,0 +1,24 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { Router } from './router'
+
+describe('Router', () => {
+  it('keeps only the read only routes', () => {
+    const router = new Router()
+    const handler = () => undefined
+
+    router.register('chain/getBlock', yup.object({}), handler)
+    router.register('chain/broadcastTransaction', yup.object({}), handler)
+    router.register('node/getStatus', yup.object({}), handler)
+    router.register('node/stopNode', yup.object({}), handler)
+    router.register('account/create', yup.object({}), handler)
+
+    const readOnly = router.readOnly()
+
+    expect([...readOnly.routes.keys()]).toEqual(['chain', 'node'])
+    expect([...(readOnly.routes.get('chain')?.keys() ?? [])]).toEqual(['getBlock'])
+    expect([...(readOnly.routes.get('node')?.keys() ?? [])]).toEqual(['getStatus'])
+  })
+})
@@ -14,11 +14,13 @@ export enum ApiNamespace {
   account = 'account',
   chain = 'chain',
   config = 'config',
+  dev = 'dev',
   event = 'event',
   faucet = 'faucet',
   miner = 'miner',
   node = 'node',
   peer = 'peer',
+  scanner = 'scanner',
   transaction = 'transaction',
   telemetry = 'telemetry',
   worker = 'worker',
@@ -27,6 +29,33 @@ export enum ApiNamespace {
 
 export const ALL_API_NAMESPACES = StrEnumUtils.getValues(ApiNamespace)
 
+/**
+ * The routes that only read from the node, which read only adapters serve.
+ * Routes are left out until they are added here, so a new route that changes
+ * the node is never served by accident.
+ */
+export const READ_ONLY_ROUTES: ReadonlySet<string> = new Set([
+  `${ApiNamespace.chain}/estimateFees`,
+  `${ApiNamespace.chain}/exportChainStream`,
+  `${ApiNamespace.chain}/followChainStream`,
+  `${ApiNamespace.chain}/getBlock`,
+  `${ApiNamespace.chain}/getBlockInfo`,
+  `${ApiNamespace.chain}/getBlockRaw`,
+  `${ApiNamespace.chain}/getChainInfo`,
+  `${ApiNamespace.chain}/getFeeHistogram`,
+  `${ApiNamespace.chain}/getMiners`,
+  `${ApiNamespace.chain}/getNetworkHashrate`,
+  `${ApiNamespace.chain}/getSupply`,
+  `${ApiNamespace.chain}/getTransactionRaw`,
+  `${ApiNamespace.chain}/getTransactionStream`,
+  `${ApiNamespace.chain}/onHead`,
+  `${ApiNamespace.chain}/showChain`,
+  `${ApiNamespace.chain}/simulateTransaction`,
+  `${ApiNamespace.event}/onGossip`,
+  `${ApiNamespace.node}/getStatus`,
+  `${ApiNamespace.rpc}/getStatus`,
+])
+
 export type RouteHandler<TRequest = unknown, TResponse = unknown> = (
   request: RpcRequest<TRequest, TResponse>,
   node: IronfishNode,
@@ -98,6 +127,8 @@ export class Router {
 
     Assert.isNotNull(this.server)
 
+    const span = this.server.node.tracer.start('rpc.handler', { route })
+
     try {
       await handler(request, this.server.node)
     } catch (e: unknown) {
@@ -108,7 +139,38 @@ export class Router {
         throw new ResponseError(e)
       }
       throw e
+    } finally {
+      // Streaming routes stay open for as long as the client is listening
+      if (!request.streamed) {
+        span.end()
+      }
+    }
+  }
+
+  /**
+   * A copy of this router with only the routes in READ_ONLY_ROUTES
+   */
+  readOnly(): Router {
+    const copy = new Router()
+    copy.server = this.server
+
+    for (const [namespace, routes] of this.routes) {
+      for (const [method, route] of routes) {
+        if (!READ_ONLY_ROUTES.has(`${namespace}/${method}`)) {
+          continue
+        }
+
+        let namespaceRoutes = copy.routes.get(namespace)
+        if (!namespaceRoutes) {
+          namespaceRoutes = new Map<string, { handler: RouteHandler; schema: YupSchema }>()
+          copy.routes.set(namespace, namespaceRoutes)
+        }
+
+        namespaceRoutes.set(method, route)
+      }
     }
+
+    return copy
   }
 
   filter(namespaces: string[]): Router {
@@ -0,0 +1,69 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { ValidationError } from '../../adapters'
+import { ApiNamespace, router } from '../router'
+import { assertScannerEnabled, RpcViewKey, serializeRpcViewKey } from './utils'
+
+export type AddViewKeyRequest = {
+  /**
+   * Any unique id the caller uses to tell keys apart, like a user id
+   */
+  id: string
+  incomingViewKey: string
+  outgoingViewKey: string
+  /**
+   * Scan from this sequence instead of the scanner head
+   */
+  startSequence?: number
+}
+
+export type AddViewKeyResponse = RpcViewKey
+
+export const AddViewKeyRequestSchema: yup.ObjectSchema<AddViewKeyRequest> = yup
+  .object({
+    id: yup.string().trim().min(1).max(256).defined(),
+    incomingViewKey: yup
+      .string()
+      .matches(/^[0-9a-fA-F]{64}$/, 'incomingViewKey must be 32 bytes of hex')
+      .defined(),
+    outgoingViewKey: yup
+      .string()
+      .matches(/^[0-9a-fA-F]{64}$/, 'outgoingViewKey must be 32 bytes of hex')
+      .defined(),
+    startSequence: yup.number().integer().min(1).optional(),
+  })
+  .defined()
+
+export const AddViewKeyResponseSchema: yup.ObjectSchema<AddViewKeyResponse> = yup
+  .object({
+    id: yup.string().defined(),
+    createdAt: yup.number().defined(),
+    feedLength: yup.number().defined(),
+    catchUpSequence: yup.number().nullable().defined(),
+  })
+  .defined()
+
+router.register<typeof AddViewKeyRequestSchema, AddViewKeyResponse>(
+  `${ApiNamespace.scanner}/addViewKey`,
+  AddViewKeyRequestSchema,
+  async (request, node): Promise<void> => {
+    assertScannerEnabled(node)
+
+    const { id } = request.data
+
+    if (node.viewKeyScanner.getViewKey(id)) {
+      throw new ValidationError(`A view key is already registered with the id ${id}`)
+    }
+
+    const key = await node.viewKeyScanner.addViewKey(
+      id,
+      request.data.incomingViewKey.toLowerCase(),
+      request.data.outgoingViewKey.toLowerCase(),
+      request.data.startSequence,
+    )
+
+    request.end(serializeRpcViewKey(key))
+  },
+)
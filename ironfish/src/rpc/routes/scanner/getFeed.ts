@@ -0,0 +1,112 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { FeedEntry } from '../../../indexers/viewKeyScanner'
+import { ValidationError } from '../../adapters'
+import { ApiNamespace, router } from '../router'
+import { assertScannerEnabled, RpcFeedEntry, serializeRpcFeedEntry } from './utils'
+
+export type GetFeedRequest = {
+  id: string
+  /**
+   * The index of the first entry to return, pass the index after the last
+   * entry you processed to resume
+   */
+  start?: number
+  limit?: number
+  /**
+   * Keep the request open and stream new entries as blocks are scanned
+   */
+  stream?: boolean
+}
+
+export type GetFeedResponse = RpcFeedEntry
+
+export const GetFeedRequestSchema: yup.ObjectSchema<GetFeedRequest> = yup
+  .object({
+    id: yup.string().defined(),
+    start: yup.number().integer().min(0).optional(),
+    limit: yup.number().integer().min(1).optional(),
+    stream: yup.boolean().optional(),
+  })
+  .defined()
+
+export const GetFeedResponseSchema: yup.ObjectSchema<GetFeedResponse> = yup
+  .object({
+    index: yup.number().defined(),
+    type: yup.string().oneOf(['connected', 'disconnected']).defined(),
+    sequence: yup.number().defined(),
+    blockHash: yup.string().defined(),
+    transactionHash: yup.string().defined(),
+    notes: yup
+      .array(
+        yup
+          .object({
+            sent: yup.boolean().defined(),
+            value: yup.string().defined(),
+            memo: yup.string().defined(),
+          })
+          .defined(),
+      )
+      .defined(),
+  })
+  .defined()
+
+router.register<typeof GetFeedRequestSchema, GetFeedResponse>(
+  `${ApiNamespace.scanner}/getFeed`,
+  GetFeedRequestSchema,
+  async (request, node): Promise<void> => {
+    assertScannerEnabled(node)
+
+    const { id, limit, stream } = request.data
+
+    if (!node.viewKeyScanner.getViewKey(id)) {
+      throw new ValidationError(`No view key is registered with the id ${id}`)
+    }
+
+    let next = request.data.start ?? 0
+    let sent = 0
+
+    // Buffer entries scanned while the stored feed is being sent so none are missed
+    const pending: FeedEntry[] = []
+    const onFeedEntry = (entry: FeedEntry) => {
+      if (entry.id === id) {
+        pending.push(entry)
+      }
+    }
+
+    if (stream) {
+      node.viewKeyScanner.onFeedEntry.on(onFeedEntry)
+      request.onClose.on(() => node.viewKeyScanner.onFeedEntry.off(onFeedEntry))
+    }
+
+    for await (const entry of node.viewKeyScanner.getFeed(id, next, limit)) {
+      if (request.closed) {
+        break
+      }
+
+      request.stream(serializeRpcFeedEntry(entry))
+      next = entry.index + 1
+      sent++
+    }
+
+    if (!stream || request.closed || (limit !== undefined && sent >= limit)) {
+      node.viewKeyScanner.onFeedEntry.off(onFeedEntry)
+      request.end()
+      return
+    }
+
+    const send = (entry: FeedEntry) => {
+      if (entry.index >= next) {
+        request.stream(serializeRpcFeedEntry(entry))
+        next = entry.index + 1
+      }
+    }
+
+    pending.forEach(send)
+    node.viewKeyScanner.onFeedEntry.off(onFeedEntry)
+    node.viewKeyScanner.onFeedEntry.on(send)
+    request.onClose.on(() => node.viewKeyScanner.onFeedEntry.off(send))
+  },
+)
@@ -0,0 +1,53 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { ApiNamespace, router } from '../router'
+import { assertScannerEnabled, RpcViewKey, serializeRpcViewKey } from './utils'
+
+export type GetViewKeysRequest = Record<string, never> | undefined
+
+export type GetViewKeysResponse = {
+  /**
+   * The hash of the last block the scanner has scanned
+   */
+  head: string | null
+  viewKeys: RpcViewKey[]
+}
+
+export const GetViewKeysRequestSchema: yup.MixedSchema<GetViewKeysRequest> = yup
+  .mixed()
+  .oneOf([undefined] as const)
+
+export const GetViewKeysResponseSchema: yup.ObjectSchema<GetViewKeysResponse> = yup
+  .object({
+    head: yup.string().nullable().defined(),
+    viewKeys: yup
+      .array(
+        yup
+          .object({
+            id: yup.string().defined(),
+            createdAt: yup.number().defined(),
+            feedLength: yup.number().defined(),
+            catchUpSequence: yup.number().nullable().defined(),
+          })
+          .defined(),
+      )
+      .defined(),
+  })
+  .defined()
+
+router.register<typeof GetViewKeysRequestSchema, GetViewKeysResponse>(
+  `${ApiNamespace.scanner}/getViewKeys`,
+  GetViewKeysRequestSchema,
+  (request, node): void => {
+    assertScannerEnabled(node)
+
+    const head = node.viewKeyScanner.head
+
+    request.end({
+      head: head ? head.toString('hex') : null,
+      viewKeys: node.viewKeyScanner.listViewKeys().map(serializeRpcViewKey),
+    })
+  },
+)
@@ -0,0 +1,35 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { ValidationError } from '../../adapters'
+import { ApiNamespace, router } from '../router'
+import { assertScannerEnabled } from './utils'
+
+export type RemoveViewKeyRequest = { id: string }
+export type RemoveViewKeyResponse = undefined
+
+export const RemoveViewKeyRequestSchema: yup.ObjectSchema<RemoveViewKeyRequest> = yup
+  .object({
+    id: yup.string().defined(),
+  })
+  .defined()
+
+export const RemoveViewKeyResponseSchema: yup.MixedSchema<RemoveViewKeyResponse> = yup
+  .mixed()
+  .oneOf([undefined] as const)
+
+router.register<typeof RemoveViewKeyRequestSchema, RemoveViewKeyResponse>(
+  `${ApiNamespace.scanner}/removeViewKey`,
+  RemoveViewKeyRequestSchema,
+  async (request, node): Promise<void> => {
+    assertScannerEnabled(node)
+
+    if (!node.viewKeyScanner.getViewKey(request.data.id)) {
+      throw new ValidationError(`No view key is registered with the id ${request.data.id}`)
+    }
+
+    await node.viewKeyScanner.removeViewKey(request.data.id)
+    request.end()
+  },
+)
@@ -0,0 +1,55 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { FeedEntry, ViewKey } from '../../../indexers/viewKeyScanner'
+import { IronfishNode } from '../../../node'
+import { ValidationError } from '../../adapters'
+
+/**
+ * View keys themselves are never returned, only what the scanner knows about them
+ */
+export type RpcViewKey = {
+  id: string
+  createdAt: number
+  feedLength: number
+  catchUpSequence: number | null
+}
+
+export type RpcFeedEntry = {
+  index: number
+  type: 'connected' | 'disconnected'
+  sequence: number
+  blockHash: string
+  transactionHash: string
+  notes: Array<{ sent: boolean; value: string; memo: string }>
+}
+
+export function assertScannerEnabled(node: IronfishNode): void {
+  if (!node.config.get('enableViewKeyScanner')) {
+    throw new ValidationError('The view key scanner is not enabled, set enableViewKeyScanner')
+  }
+}
+
+export function serializeRpcViewKey(key: ViewKey): RpcViewKey {
+  return {
+    id: key.id,
+    createdAt: key.createdAt,
+    feedLength: key.feedLength,
+    catchUpSequence: key.catchUpSequence,
+  }
+}
+
+export function serializeRpcFeedEntry(entry: FeedEntry): RpcFeedEntry {
+  return {
+    index: entry.index,
+    type: entry.connected ? 'connected' : 'disconnected',
+    sequence: entry.sequence,
+    blockHash: entry.blockHash.toString('hex'),
+    transactionHash: entry.transactionHash.toString('hex'),
+    notes: entry.notes.map((note) => ({
+      sent: note.sent,
+      value: note.value.toString(),
+      memo: note.memo,
+    })),
+  }
+}
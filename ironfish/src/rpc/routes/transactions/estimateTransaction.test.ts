@@ -0,0 +1,75 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+import { getTransactionSize } from '../../../primitives'
+import { createRouteTest } from '../../../testUtilities/routeTest'
+import { ERROR_CODES } from '../../adapters'
+
+const TEST_PARAMS = {
+  fromAccountName: 'existingAccount',
+  receives: [{ publicAddress: 'test2', amount: BigInt(10).toString() }],
+  fee: BigInt(1).toString(),
+}
+
+describe('Transactions estimateTransaction', () => {
+  const routeTest = createRouteTest(true)
+
+  beforeAll(async () => {
+    await routeTest.node.accounts.createAccount('existingAccount', true)
+  })
+
+  it('throws if account does not exist', async () => {
+    await expect(
+      routeTest.client.estimateTransaction({
+        ...TEST_PARAMS,
+        fromAccountName: 'AccountDoesNotExist',
+      }),
+    ).rejects.toMatchObject({
+      code: ERROR_CODES.ACCOUNT_NOT_FOUND,
+      codeMessage: 'No account found with name AccountDoesNotExist',
+    })
+  })
+
+  it('throws if not enough funds', async () => {
+    await expect(routeTest.client.estimateTransaction(TEST_PARAMS)).rejects.toMatchObject({
+      code: ERROR_CODES.INSUFFICIENT_BALANCE,
+      codeMessage: 'Your balance is too low. Add funds to your account first',
+    })
+  })
+
+  it('returns the estimate with fees for its size', async () => {
+    const size = getTransactionSize(900, 2)
+
+    jest.spyOn(routeTest.node.accounts, 'estimateTransaction').mockResolvedValueOnce({
+      spends: 900,
+      notes: 2,
+      size,
+      proofTimeMs: 902000,
+    })
+
+    jest.spyOn(routeTest.node.feeEstimator, 'getFeeHistogram').mockResolvedValueOnce({
+      blocks: [],
+      memPool: { transactions: 0, histogram: [] },
+      tiers: [
+        { tier: 'slow', feeRate: BigInt(0), inclusionProbability: 1 },
+        { tier: 'average', feeRate: BigInt(1000), inclusionProbability: 1 },
+        { tier: 'fast', feeRate: BigInt(2000), inclusionProbability: 1 },
+      ],
+    })
+
+    const response = await routeTest.client.estimateTransaction(TEST_PARAMS)
+
+    expect(response.content).toEqual({
+      spends: 900,
+      notes: 2,
+      size,
+      proofTimeMs: 902000,
+      fees: {
+        slow: routeTest.node.feeEstimator.minimumFee.toString(),
+        average: size.toString(),
+        fast: (size * 2).toString(),
+      },
+    })
+  })
+})
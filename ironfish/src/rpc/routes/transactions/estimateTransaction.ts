@@ -0,0 +1,123 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { NotEnoughFundsError } from '../../../account/errors'
+import { ERROR_CODES, ValidationError } from '../../adapters/errors'
+import { ApiNamespace, router } from '../router'
+
+export type EstimateTransactionRequest = {
+  fromAccountName: string
+  receives: {
+    publicAddress: string
+    amount: string
+  }[]
+  // Defaults to the average fee estimate
+  fee?: string | null
+  minConfirmations?: number | null
+}
+
+export type EstimateTransactionResponse = {
+  spends: number
+  notes: number
+  // The serialized size of the transaction in bytes
+  size: number
+  // How long building the proofs is expected to take
+  proofTimeMs: number
+  // The fee in ore for each fee tier, for the size of this transaction
+  fees: {
+    slow: string
+    average: string
+    fast: string
+  }
+}
+
+export const EstimateTransactionRequestSchema: yup.ObjectSchema<EstimateTransactionRequest> =
+  yup
+    .object({
+      fromAccountName: yup.string().defined(),
+      receives: yup
+        .array(
+          yup
+            .object({
+              publicAddress: yup.string().defined(),
+              amount: yup.string().defined(),
+            })
+            .defined(),
+        )
+        .defined(),
+      fee: yup.string().nullable().optional(),
+      minConfirmations: yup.number().integer().min(0).nullable().optional(),
+    })
+    .defined()
+
+export const EstimateTransactionResponseSchema: yup.ObjectSchema<EstimateTransactionResponse> =
+  yup
+    .object({
+      spends: yup.number().defined(),
+      notes: yup.number().defined(),
+      size: yup.number().defined(),
+      proofTimeMs: yup.number().defined(),
+      fees: yup
+        .object({
+          slow: yup.string().defined(),
+          average: yup.string().defined(),
+          fast: yup.string().defined(),
+        })
+        .defined(),
+    })
+    .defined()
+
+router.register<typeof EstimateTransactionRequestSchema, EstimateTransactionResponse>(
+  `${ApiNamespace.transaction}/estimateTransaction`,
+  EstimateTransactionRequestSchema,
+  async (request, node): Promise<void> => {
+    const account = node.accounts.resolveAccount(request.data.fromAccountName)
+
+    if (!account) {
+      throw new ValidationError(
+        `No account found with name ${request.data.fromAccountName}`,
+        undefined,
+        ERROR_CODES.ACCOUNT_NOT_FOUND,
+      )
+    }
+
+    const fee = request.data.fee
+      ? BigInt(request.data.fee)
+      : (await node.feeEstimator.estimateFees()).average
+
+    const receives = request.data.receives.map((receive) => ({
+      publicAddress: receive.publicAddress,
+      amount: BigInt(receive.amount),
+      memo: '',
+    }))
+
+    let estimate
+    try {
+      estimate = await node.accounts.estimateTransaction(account, receives, fee, {
+        minimumBlockConfirmations: request.data.minConfirmations ?? undefined,
+      })
+    } catch (e: unknown) {
+      if (e instanceof NotEnoughFundsError) {
+        throw new ValidationError(
+          `Your balance is too low. Add funds to your account first`,
+          undefined,
+          ERROR_CODES.INSUFFICIENT_BALANCE,
+        )
+      }
+      throw e
+    }
+
+    // The tiers are fee rates in ore per kilobyte
+    const histogram = await node.feeEstimator.getFeeHistogram()
+    const fees = { slow: '', average: '', fast: '' }
+
+    for (const { tier, feeRate } of histogram.tiers) {
+      const tierFee = (feeRate * BigInt(estimate.size)) / BigInt(1000)
+      const minimumFee = node.feeEstimator.minimumFee
+      fees[tier] = (tierFee > minimumFee ? tierFee : minimumFee).toString()
+    }
+
+    request.end({ ...estimate, fees })
+  },
+)
@@ -2,8 +2,16 @@
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 
-import { useAccountFixture, useMinersTxFixture } from '../../../testUtilities/fixtures'
+import { Account } from '../../../account'
+import { Assert } from '../../../assert'
+import {
+  useAccountFixture,
+  useMinerBlockFixture,
+  useMinersTxFixture,
+} from '../../../testUtilities/fixtures'
 import { createRouteTest } from '../../../testUtilities/routeTest'
+import { AsyncUtils } from '../../../utils'
+import { ERROR_CODES } from '../../adapters'
 
 const TEST_PARAMS = {
   fromAccountName: 'existingAccount',
@@ -41,30 +49,40 @@ describe('Transactions sendTransaction', () => {
     await routeTest.node.accounts.createAccount('existingAccount', true)
   })
 
+  afterEach(() => {
+    jest.restoreAllMocks()
+  })
+
   it('throws if account does not exist', async () => {
     await expect(
       routeTest.client.sendTransaction({
         ...TEST_PARAMS,
         fromAccountName: 'AccountDoesNotExist',
       }),
-    ).rejects.toThrowError('No account found with name AccountDoesNotExist')
+    ).rejects.toMatchObject({
+      code: ERROR_CODES.ACCOUNT_NOT_FOUND,
+      codeMessage: 'No account found with name AccountDoesNotExist',
+    })
   })
 
   it('throws if not connected to network', async () => {
     routeTest.node.peerNetwork['_isReady'] = false
 
-    await expect(routeTest.client.sendTransaction(TEST_PARAMS)).rejects.toThrowError(
-      'Your node must be connected to the Iron Fish network to send a transaction',
-    )
+    await expect(routeTest.client.sendTransaction(TEST_PARAMS)).rejects.toMatchObject({
+      code: ERROR_CODES.NOT_CONNECTED,
+      codeMessage: 'Your node must be connected to the Iron Fish network to send a transaction',
+    })
   })
 
   it('throws if the chain is outdated', async () => {
     routeTest.node.peerNetwork['_isReady'] = true
     routeTest.chain.synced = false
 
-    await expect(routeTest.client.sendTransaction(TEST_PARAMS)).rejects.toThrowError(
-      'Your node must be synced with the Iron Fish network to send a transaction. Please try again later',
-    )
+    await expect(routeTest.client.sendTransaction(TEST_PARAMS)).rejects.toMatchObject({
+      code: ERROR_CODES.NOT_SYNCED,
+      codeMessage:
+        'Your node must be synced with the Iron Fish network to send a transaction. Please try again later',
+    })
   })
 
   it('throws if not enough funds', async () => {
@@ -160,6 +178,7 @@ describe('Transactions sendTransaction', () => {
       expect.anything(),
       routeTest.node.config.get('defaultTransactionExpirationSequenceDelta'),
       undefined,
+      expect.anything(),
     )
 
     await routeTest.client.sendTransaction({
@@ -175,6 +194,116 @@ describe('Transactions sendTransaction', () => {
       expect.anything(),
       12345,
       1234,
+      expect.anything(),
     )
   }, 30000)
+
+  it('lets another account pay the fee', async () => {
+    const { node, chain } = routeTest
+    const sender = await useAccountFixture(node.accounts, 'sponsoredSender')
+    const feePayer = await useAccountFixture(node.accounts, 'feePayer')
+    const recipient = await useAccountFixture(node.accounts, 'sponsoredRecipient')
+
+    await expect(chain).toAddBlock(await useMinerBlockFixture(chain, 2, sender))
+    await expect(chain).toAddBlock(await useMinerBlockFixture(chain, 3, feePayer))
+    await node.accounts.updateHead()
+
+    node.peerNetwork['_isReady'] = true
+    chain.synced = true
+
+    const result = await routeTest.client.sendTransaction({
+      fromAccountName: sender.name,
+      feePayerAccountName: feePayer.name,
+      receives: [{ publicAddress: recipient.publicAddress, amount: '10', memo: '' }],
+      fee: '5',
+      minConfirmations: 0,
+    })
+
+    expect(result.content.feePayerAccountName).toEqual(feePayer.name)
+
+    const transaction = node.accounts['transactionMap'].get(
+      Buffer.from(result.content.hash, 'hex'),
+    )?.transaction
+    Assert.isNotUndefined(transaction)
+    expect(transaction.fee()).toEqual(BigInt(5))
+    expect(transaction.spendsLength()).toBe(2)
+
+    // Each account spent its miner's fee note and got the rest back as change
+    const received = (account: Account) =>
+      node.accounts
+        .getNotes(account)
+        .notes.filter((n) => n.noteTxHash === result.content.hash && !n.spender)
+        .map((n) => n.amount)
+
+    expect(received(recipient)).toEqual([10])
+    expect(received(sender)).toEqual([2000000000 - 10])
+    expect(received(feePayer)).toEqual([2000000000 - 5])
+  }, 60000)
+
+  it('streams progress as the proofs are built', async () => {
+    const account = await useAccountFixture(routeTest.node.accounts, 'progress')
+    const tx = await useMinersTxFixture(routeTest.node.accounts, account)
+
+    routeTest.node.peerNetwork['_isReady'] = true
+    routeTest.chain.synced = true
+
+    jest.spyOn(routeTest.node.accounts, 'getBalance').mockResolvedValue({
+      unconfirmed: BigInt(11),
+      confirmed: BigInt(11),
+    })
+
+    jest
+      .spyOn(routeTest.node.accounts, 'pay')
+      .mockImplementation((_memPool, _sender, _receives, _fee, _delta, _sequence, options) => {
+        options?.onProgress?.(1, 2)
+        options?.onProgress?.(2, 2)
+        return Promise.resolve(tx)
+      })
+
+    const response = routeTest.client.sendTransactionStream(TEST_PARAMS)
+    const progress = await AsyncUtils.materialize(response.contentStream())
+    const result = await response.waitForEnd()
+
+    expect(progress).toEqual([
+      { done: 1, total: 2 },
+      { done: 2, total: 2 },
+    ])
+    expect(result.content.hash).toEqual(tx.unsignedHash().toString('hex'))
+  }, 30000)
+
+  it('does not report progress unless asked to', async () => {
+    const account = await useAccountFixture(routeTest.node.accounts, 'noProgress')
+    const tx = await useMinersTxFixture(routeTest.node.accounts, account)
+
+    routeTest.node.peerNetwork['_isReady'] = true
+    routeTest.chain.synced = true
+
+    jest.spyOn(routeTest.node.accounts, 'getBalance').mockResolvedValue({
+      unconfirmed: BigInt(11),
+      confirmed: BigInt(11),
+    })
+
+    const paySpy = jest.spyOn(routeTest.node.accounts, 'pay').mockResolvedValue(tx)
+
+    await routeTest.client.sendTransaction(TEST_PARAMS)
+
+    expect(paySpy).toHaveBeenLastCalledWith(
+      expect.anything(),
+      expect.anything(),
+      expect.anything(),
+      expect.anything(),
+      expect.anything(),
+      undefined,
+      expect.objectContaining({ onProgress: undefined }),
+    )
+  }, 30000)
+
+  it('throws if the fee payer does not exist', async () => {
+    await expect(
+      routeTest.client.sendTransaction({
+        ...TEST_PARAMS,
+        feePayerAccountName: 'AccountDoesNotExist',
+      }),
+    ).rejects.toThrowError('No account found with name AccountDoesNotExist')
+  })
 })
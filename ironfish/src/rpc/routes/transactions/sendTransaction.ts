@@ -2,6 +2,8 @@
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 import * as yup from 'yup'
+import { Account } from '../../../account'
+import { IronfishNode } from '../../../node'
 import { ERROR_CODES, ValidationError } from '../../adapters/errors'
 import { ApiNamespace, router } from '../router'
 
@@ -15,6 +17,12 @@ export type SendTransactionRequest = {
   fee: string
   expirationSequence?: number | null
   expirationSequenceDelta?: number | null
+  // Only spend notes with this many confirmations, defaults to minimumBlockConfirmations
+  minConfirmations?: number | null
+  // Another account that pays the fee, so the sender only needs the amount
+  feePayerAccountName?: string | null
+  // Stream a SendTransactionProgress as each proof is built
+  streamProgress?: boolean
 }
 
 export type SendTransactionResponse = {
@@ -24,9 +32,15 @@ export type SendTransactionResponse = {
     memo: string
   }[]
   fromAccountName: string
+  feePayerAccountName: string | null
   hash: string
 }
 
+export type SendTransactionProgress = {
+  done: number
+  total: number
+}
+
 export const SendTransactionRequestSchema: yup.ObjectSchema<SendTransactionRequest> = yup
   .object({
     fromAccountName: yup.string().defined(),
@@ -44,6 +58,9 @@ export const SendTransactionRequestSchema: yup.ObjectSchema<SendTransactionReque
     fee: yup.string().defined(),
     expirationSequence: yup.number().nullable().optional(),
     expirationSequenceDelta: yup.number().nullable().optional(),
+    minConfirmations: yup.number().integer().min(0).nullable().optional(),
+    feePayerAccountName: yup.string().nullable().optional(),
+    streamProgress: yup.boolean().optional(),
   })
   .defined()
 
@@ -61,55 +78,86 @@ export const SendTransactionResponseSchema: yup.ObjectSchema<SendTransactionResp
       )
       .defined(),
     fromAccountName: yup.string().defined(),
+    feePayerAccountName: yup.string().nullable().defined(),
     hash: yup.string().defined(),
   })
   .defined()
 
-router.register<typeof SendTransactionRequestSchema, SendTransactionResponse>(
+export const SendTransactionProgressSchema: yup.ObjectSchema<SendTransactionProgress> = yup
+  .object({
+    done: yup.number().defined(),
+    total: yup.number().defined(),
+  })
+  .defined()
+
+router.register<
+  typeof SendTransactionRequestSchema,
+  SendTransactionResponse | SendTransactionProgress
+>(
   `${ApiNamespace.transaction}/sendTransaction`,
   SendTransactionRequestSchema,
   async (request, node): Promise<void> => {
     const transaction = request.data
 
-    const account = node.accounts.getAccountByName(transaction.fromAccountName)
+    const account = node.accounts.resolveAccount(transaction.fromAccountName)
 
     if (!account) {
-      throw new ValidationError(`No account found with name ${transaction.fromAccountName}`)
+      throw new ValidationError(
+        `No account found with name ${transaction.fromAccountName}`,
+        undefined,
+        ERROR_CODES.ACCOUNT_NOT_FOUND,
+      )
+    }
+
+    let feePayer: Account | null = null
+    if (transaction.feePayerAccountName) {
+      feePayer = node.accounts.resolveAccount(transaction.feePayerAccountName)
+
+      if (!feePayer) {
+        throw new ValidationError(
+          `No account found with name ${transaction.feePayerAccountName}`,
+          undefined,
+          ERROR_CODES.ACCOUNT_NOT_FOUND,
+        )
+      }
+
+      // Paying your own fee is an ordinary transaction
+      if (feePayer.spendingKey === account.spendingKey) {
+        feePayer = null
+      }
     }
 
-    // The node must be connected to the network first
-    if (!node.peerNetwork.isReady) {
+    // The node must be connected to the network first, or to the chain node it syncs from
+    if (!node.peerNetwork.isReady && !node.remoteSyncer?.isConnected) {
       throw new ValidationError(
         `Your node must be connected to the Iron Fish network to send a transaction`,
+        undefined,
+        ERROR_CODES.NOT_CONNECTED,
       )
     }
 
     if (!node.chain.synced) {
       throw new ValidationError(
         `Your node must be synced with the Iron Fish network to send a transaction. Please try again later`,
+        undefined,
+        ERROR_CODES.NOT_SYNCED,
       )
     }
 
-    // Check that the node account is updated
-    const balance = await node.accounts.getBalance(account)
-    const sum =
-      transaction.receives.reduce((acc, receive) => acc + BigInt(receive.amount), BigInt(0)) +
-      BigInt(transaction.fee)
+    const minimumBlockConfirmations = transaction.minConfirmations ?? undefined
 
-    if (balance.confirmed < sum && balance.unconfirmed < sum) {
-      throw new ValidationError(
-        `Your balance is too low. Add funds to your account first`,
-        undefined,
-        ERROR_CODES.INSUFFICIENT_BALANCE,
-      )
-    }
+    const amount = transaction.receives.reduce(
+      (acc, receive) => acc + BigInt(receive.amount),
+      BigInt(0),
+    )
+    const fee = BigInt(transaction.fee)
 
-    if (balance.confirmed < sum) {
-      throw new ValidationError(
-        `Please wait a few seconds for your balance to update and try again`,
-        undefined,
-        ERROR_CODES.INSUFFICIENT_BALANCE,
-      )
+    // Check that the node accounts are updated
+    if (feePayer) {
+      await checkBalance(node, account, amount, minimumBlockConfirmations)
+      await checkBalance(node, feePayer, fee, minimumBlockConfirmations)
+    } else {
+      await checkBalance(node, account, amount + fee, minimumBlockConfirmations)
     }
 
     const receives = transaction.receives.map((receive) => {
@@ -128,12 +176,45 @@ router.register<typeof SendTransactionRequestSchema, SendTransactionResponse>(
       transaction.expirationSequenceDelta ??
         node.config.get('defaultTransactionExpirationSequenceDelta'),
       transaction.expirationSequence,
+      {
+        minimumBlockConfirmations,
+        feePayer: feePayer ?? undefined,
+        onProgress: transaction.streamProgress
+          ? (done, total) => request.stream({ done, total })
+          : undefined,
+      },
     )
 
     request.end({
       receives: transaction.receives,
       fromAccountName: account.name,
+      feePayerAccountName: feePayer?.name ?? null,
       hash: transactionPosted.unsignedHash().toString('hex'),
     })
   },
 )
+
+async function checkBalance(
+  node: IronfishNode,
+  account: Account,
+  sum: bigint,
+  minimumBlockConfirmations: number | undefined,
+): Promise<void> {
+  const balance = await node.accounts.getBalance(account, { minimumBlockConfirmations })
+
+  if (balance.confirmed < sum && balance.unconfirmed < sum) {
+    throw new ValidationError(
+      `Your balance is too low. Add funds to your account first`,
+      undefined,
+      ERROR_CODES.INSUFFICIENT_BALANCE,
+    )
+  }
+
+  if (balance.confirmed < sum) {
+    throw new ValidationError(
+      `Please wait a few seconds for your balance to update and try again`,
+      undefined,
+      ERROR_CODES.INSUFFICIENT_BALANCE,
+    )
+  }
+}
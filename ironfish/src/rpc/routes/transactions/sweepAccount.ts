@@ -0,0 +1,111 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { isValidPublicAddress } from '../../../account/validator'
+import { ERROR_CODES, ValidationError } from '../../adapters/errors'
+import { ApiNamespace, router } from '../router'
+
+const DEFAULT_MAX_NOTES = 10
+
+export type SweepAccountRequest = {
+  fromAccountName: string
+  toPublicAddress: string
+  fee: string
+  // The most notes to spend in the transaction
+  maxNotes?: number
+  memo?: string
+  expirationSequenceDelta?: number | null
+  minConfirmations?: number | null
+}
+
+export type SweepAccountResponse = {
+  // Null if there was nothing worth sending
+  hash: string | null
+  amount: string
+  notes: number
+  // The balance left in the account, including notes still being confirmed
+  remaining: string
+}
+
+export const SweepAccountRequestSchema: yup.ObjectSchema<SweepAccountRequest> = yup
+  .object({
+    fromAccountName: yup.string().defined(),
+    toPublicAddress: yup.string().defined(),
+    fee: yup.string().defined(),
+    maxNotes: yup.number().integer().min(1).optional(),
+    memo: yup.string().optional(),
+    expirationSequenceDelta: yup.number().nullable().optional(),
+    minConfirmations: yup.number().integer().min(0).nullable().optional(),
+  })
+  .defined()
+
+export const SweepAccountResponseSchema: yup.ObjectSchema<SweepAccountResponse> = yup
+  .object({
+    hash: yup.string().nullable().defined(),
+    amount: yup.string().defined(),
+    notes: yup.number().defined(),
+    remaining: yup.string().defined(),
+  })
+  .defined()
+
+router.register<typeof SweepAccountRequestSchema, SweepAccountResponse>(
+  `${ApiNamespace.transaction}/sweepAccount`,
+  SweepAccountRequestSchema,
+  async (request, node): Promise<void> => {
+    const account = node.accounts.resolveAccount(request.data.fromAccountName)
+
+    if (!account) {
+      throw new ValidationError(
+        `No account found with name ${request.data.fromAccountName}`,
+        undefined,
+        ERROR_CODES.ACCOUNT_NOT_FOUND,
+      )
+    }
+
+    if (!isValidPublicAddress(request.data.toPublicAddress)) {
+      throw new ValidationError(`${request.data.toPublicAddress} is not a valid public address`)
+    }
+
+    if (!node.peerNetwork.isReady && !node.remoteSyncer?.isConnected) {
+      throw new ValidationError(
+        `Your node must be connected to the Iron Fish network to send a transaction`,
+        undefined,
+        ERROR_CODES.NOT_CONNECTED,
+      )
+    }
+
+    if (!node.chain.synced) {
+      throw new ValidationError(
+        `Your node must be synced with the Iron Fish network to send a transaction. Please try again later`,
+        undefined,
+        ERROR_CODES.NOT_SYNCED,
+      )
+    }
+
+    const minimumBlockConfirmations = request.data.minConfirmations ?? undefined
+
+    const result = await node.accounts.sweep(
+      node.memPool,
+      account,
+      request.data.toPublicAddress,
+      BigInt(request.data.fee),
+      request.data.expirationSequenceDelta ??
+        node.config.get('defaultTransactionExpirationSequenceDelta'),
+      {
+        maxNotes: request.data.maxNotes ?? DEFAULT_MAX_NOTES,
+        memo: request.data.memo,
+        minimumBlockConfirmations,
+      },
+    )
+
+    const balance = await node.accounts.getBalance(account, { minimumBlockConfirmations })
+
+    request.end({
+      hash: result ? result.transaction.unsignedHash().toString('hex') : null,
+      amount: result ? result.amount.toString() : '0',
+      notes: result ? result.notes : 0,
+      remaining: balance.unconfirmed.toString(),
+    })
+  },
+)
@@ -0,0 +1,34 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import '../../../testUtilities/matchers'
+import { createRouteTest } from '../../../testUtilities/routeTest'
+import { JobAbortedError } from '../../../workerPool/tasks/jobAbort'
+
+describe('Route worker/cancelJob', () => {
+  const routeTest = createRouteTest()
+
+  it('should list and cancel a job', async () => {
+    const job = routeTest.node.workerPool.sleep(60000)
+
+    const jobs = await routeTest.client.getWorkerJobs()
+    expect(jobs.content.jobs).toContainEqual(
+      expect.objectContaining({ id: job.id, name: 'Sleep', status: 'executing' }),
+    )
+
+    const response = await routeTest.client.cancelWorkerJob({ id: job.id })
+    expect(response.status).toBe(200)
+
+    await expect(job.result()).toRejectErrorInstance(JobAbortedError)
+    expect(job.status).toBe('aborted')
+
+    const after = await routeTest.client.getWorkerJobs()
+    expect(after.content.jobs.find((j) => j.id === job.id)).toBeUndefined()
+  })
+
+  it('should error if the job does not exist', async () => {
+    await expect(routeTest.client.cancelWorkerJob({ id: 123456789 })).rejects.toThrow(
+      'No queued or executing job found with id 123456789',
+    )
+  })
+})
@@ -0,0 +1,31 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { ValidationError } from '../../adapters/errors'
+import { ApiNamespace, router } from '../router'
+
+export type CancelWorkerJobRequest = { id: number }
+export type CancelWorkerJobResponse = Record<string, never> | undefined
+
+export const CancelWorkerJobRequestSchema: yup.ObjectSchema<CancelWorkerJobRequest> = yup
+  .object({
+    id: yup.number().defined(),
+  })
+  .defined()
+
+export const CancelWorkerJobResponseSchema: yup.MixedSchema<CancelWorkerJobResponse> = yup
+  .mixed()
+  .oneOf([undefined] as const)
+
+router.register<typeof CancelWorkerJobRequestSchema, CancelWorkerJobResponse>(
+  `${ApiNamespace.worker}/cancelJob`,
+  CancelWorkerJobRequestSchema,
+  (request, node): void => {
+    if (!node.workerPool.cancelJob(request.data.id)) {
+      throw new ValidationError(`No queued or executing job found with id ${request.data.id}`)
+    }
+
+    request.end()
+  },
+)
@@ -0,0 +1,59 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import * as yup from 'yup'
+import { WorkerMessageType } from '../../../workerPool/tasks/workerMessage'
+import { ApiNamespace, router } from '../router'
+
+export type GetWorkerJobsRequest = Record<string, never> | undefined
+
+export type GetWorkerJobsResponse = {
+  jobs: Array<{
+    id: number
+    name: string
+    status: string
+    queuedAt: number
+    startedAt: number | null
+    timeout: number | null
+  }>
+}
+
+export const GetWorkerJobsRequestSchema: yup.MixedSchema<GetWorkerJobsRequest> = yup
+  .mixed()
+  .oneOf([undefined] as const)
+
+export const GetWorkerJobsResponseSchema: yup.ObjectSchema<GetWorkerJobsResponse> = yup
+  .object({
+    jobs: yup
+      .array(
+        yup
+          .object({
+            id: yup.number().defined(),
+            name: yup.string().defined(),
+            status: yup.string().defined(),
+            queuedAt: yup.number().defined(),
+            startedAt: yup.number().nullable().defined(),
+            timeout: yup.number().nullable().defined(),
+          })
+          .defined(),
+      )
+      .defined(),
+  })
+  .defined()
+
+router.register<typeof GetWorkerJobsRequestSchema, GetWorkerJobsResponse>(
+  `${ApiNamespace.worker}/getJobs`,
+  GetWorkerJobsRequestSchema,
+  (request, node): void => {
+    const jobs = node.workerPool.getJobs().map((job) => ({
+      id: job.id,
+      name: WorkerMessageType[job.request.type],
+      status: job.status,
+      queuedAt: job.queuedAt,
+      startedAt: job.startedAt,
+      timeout: job.timeout,
+    }))
+
+    request.end({ jobs })
+  },
+)
@@ -0,0 +1,399 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import axios, { Method } from 'axios'
+import crypto from 'crypto'
+import fsAsync from 'fs/promises'
+
+const DEFAULT_REGION = 'us-east-1'
+const EMPTY_HASH = sha256('')
+
+/**
+ * S3 allows at most 10000 parts with every part but the last at least 5MB
+ */
+const MAX_PARTS = 10000
+export const DEFAULT_PART_SIZE = 64 * 1024 * 1024
+
+export type S3Credentials = {
+  accessKeyId: string
+  secretAccessKey: string
+  sessionToken?: string
+}
+
+export type S3TransferOptions = {
+  partSize?: number
+  onProgress?: (transferred: number, total: number) => void
+}
+
+export type S3Object = {
+  key: string
+  size: number
+  lastModified: Date
+}
+
+function sha256(data: string | Buffer): string {
+  return crypto.createHash('sha256').update(data).digest('hex')
+}
+
+function hmac(key: string | Buffer, data: string): Buffer {
+  return crypto.createHmac('sha256', key).update(data).digest()
+}
+
+/**
+ * Percent encode like RFC 3986, which S3 signatures require
+ */
+function encodeRfc3986(value: string): string {
+  return encodeURIComponent(value).replace(
+    /[!'()*]/g,
+    (c) => `%${c.charCodeAt(0).toString(16).toUpperCase()}`,
+  )
+}
+
+function decodeXml(value: string): string {
+  return value
+    .replace(/&lt;/g, '<')
+    .replace(/&gt;/g, '>')
+    .replace(/&quot;/g, '"')
+    .replace(/&apos;/g, "'")
+    .replace(/&amp;/g, '&')
+}
+
+function readXmlTag(xml: string, tag: string): string | null {
+  const match = new RegExp(`<${tag}>([\\s\\S]*?)</${tag}>`).exec(xml)
+  return match ? decodeXml(match[1]) : null
+}
+
+/**
+ * A minimal client for S3 compatible object storage that signs requests with
+ * AWS signature version 4. Requests use path style URLs so it works with
+ * endpoints other than AWS, like MinIO or R2.
+ */
+export class S3Client {
+  readonly region: string
+  readonly endpoint: string
+  readonly credentials: S3Credentials | null
+
+  constructor(options: {
+    region?: string
+    endpoint?: string
+    credentials?: S3Credentials | null
+  }) {
+    this.region = options.region || DEFAULT_REGION
+    this.endpoint = (options.endpoint || `https://s3.${this.region}.amazonaws.com`).replace(
+      /\/+$/,
+      '',
+    )
+    this.credentials = options.credentials ?? null
+  }
+
+  /**
+   * Create a client from the standard AWS environment variables. Requests are
+   * sent unsigned if no credentials are set, which works for public buckets.
+   */
+  static fromEnvironment(env: NodeJS.ProcessEnv = process.env): S3Client {
+    const accessKeyId = env.AWS_ACCESS_KEY_ID
+    const secretAccessKey = env.AWS_SECRET_ACCESS_KEY
+
+    return new S3Client({
+      region: env.AWS_REGION || env.AWS_DEFAULT_REGION,
+      endpoint: env.AWS_ENDPOINT_URL_S3 || env.AWS_ENDPOINT_URL,
+      credentials:
+        accessKeyId && secretAccessKey
+          ? { accessKeyId, secretAccessKey, sessionToken: env.AWS_SESSION_TOKEN }
+          : null,
+    })
+  }
+
+  static isS3Url(url: string): boolean {
+    return url.startsWith('s3://')
+  }
+
+  /**
+   * Split an `s3://bucket/key` URL into its bucket and key
+   */
+  static parseUrl(url: string): { bucket: string; key: string } {
+    if (!S3Client.isS3Url(url)) {
+      throw new Error(`${url} is not an s3:// URL`)
+    }
+
+    const path = url.slice('s3://'.length)
+    const slash = path.indexOf('/')
+    const bucket = slash === -1 ? path : path.slice(0, slash)
+    const key = slash === -1 ? '' : path.slice(slash + 1)
+
+    if (!bucket) {
+      throw new Error(`${url} does not have a bucket`)
+    }
+
+    return { bucket, key }
+  }
+
+  async putObject(bucket: string, key: string, body: Buffer): Promise<void> {
+    await this.send('PUT', bucket, key, {}, body)
+  }
+
+  async getObject(bucket: string, key: string): Promise<Buffer> {
+    return this.send('GET', bucket, key)
+  }
+
+  async deleteObject(bucket: string, key: string): Promise<void> {
+    await this.send('DELETE', bucket, key)
+  }
+
+  /**
+   * List every object in `bucket` whose key starts with `prefix`
+   */
+  async listObjects(bucket: string, prefix = ''): Promise<S3Object[]> {
+    const objects: S3Object[] = []
+    let continuationToken: string | null = null
+
+    do {
+      const query: Record<string, string> = { 'list-type': '2', prefix }
+      if (continuationToken) {
+        query['continuation-token'] = continuationToken
+      }
+
+      const xml = (await this.send('GET', bucket, '', query)).toString('utf8')
+
+      for (const match of xml.matchAll(/<Contents>([\s\S]*?)<\/Contents>/g)) {
+        objects.push({
+          key: readXmlTag(match[1], 'Key') ?? '',
+          size: Number(readXmlTag(match[1], 'Size') ?? 0),
+          lastModified: new Date(readXmlTag(match[1], 'LastModified') ?? 0),
+        })
+      }
+
+      const truncated = readXmlTag(xml, 'IsTruncated') === 'true'
+      continuationToken = truncated ? readXmlTag(xml, 'NextContinuationToken') : null
+    } while (continuationToken)
+
+    return objects
+  }
+
+  async headObject(bucket: string, key: string): Promise<{ size: number }> {
+    const { headers } = await this.request('HEAD', bucket, key)
+    return { size: Number(headers['content-length'] ?? 0) }
+  }
+
+  /**
+   * Upload a file in parts so files larger than a single PUT allows can be
+   * uploaded. Each part is sent with its MD5 so S3 rejects corrupted parts.
+   */
+  async uploadFile(
+    bucket: string,
+    key: string,
+    filePath: string,
+    options: S3TransferOptions = {},
+  ): Promise<void> {
+    const { size } = await fsAsync.stat(filePath)
+    const partSize = Math.max(
+      options.partSize ?? DEFAULT_PART_SIZE,
+      Math.ceil(size / MAX_PARTS),
+    )
+
+    const created = await this.send('POST', bucket, key, { uploads: '' })
+    const uploadId = readXmlTag(created.toString('utf8'), 'UploadId')
+    if (!uploadId) {
+      throw new Error(`S3 did not start a multipart upload for s3://${bucket}/${key}`)
+    }
+
+    const handle = await fsAsync.open(filePath, 'r')
+    const etags: string[] = []
+
+    try {
+      for (let offset = 0, part = 1; offset < size || part === 1; offset += partSize, part++) {
+        const length = Math.min(partSize, size - offset)
+        const body = Buffer.alloc(length)
+        await handle.read(body, 0, length, offset)
+
+        const md5 = crypto.createHash('md5').update(body).digest('base64')
+        const { headers } = await this.request(
+          'PUT',
+          bucket,
+          key,
+          { partNumber: String(part), uploadId },
+          body,
+          { 'Content-MD5': md5 },
+        )
+
+        etags.push(headers['etag'] ?? '')
+        options.onProgress?.(offset + length, size)
+      }
+
+      const parts = etags
+        .map((etag, i) => `<Part><PartNumber>${i + 1}</PartNumber><ETag>${etag}</ETag></Part>`)
+        .join('')
+      const body = Buffer.from(`<CompleteMultipartUpload>${parts}</CompleteMultipartUpload>`)
+
+      // Completing can fail after S3 has already responded with 200
+      const completed = await this.send('POST', bucket, key, { uploadId }, body)
+      const error = readXmlTag(completed.toString('utf8'), 'Code')
+      if (error) {
+        throw new Error(`S3 could not complete the upload of s3://${bucket}/${key}: ${error}`)
+      }
+    } catch (e) {
+      await this.send('DELETE', bucket, key, { uploadId }).catch(() => undefined)
+      throw e
+    } finally {
+      await handle.close()
+    }
+  }
+
+  /**
+   * Download an object to a file in ranges of `partSize` bytes
+   */
+  async downloadFile(
+    bucket: string,
+    key: string,
+    filePath: string,
+    options: S3TransferOptions = {},
+  ): Promise<void> {
+    const { size } = await this.headObject(bucket, key)
+    const partSize = options.partSize ?? DEFAULT_PART_SIZE
+    const handle = await fsAsync.open(filePath, 'w')
+
+    try {
+      for (let offset = 0; offset < size; offset += partSize) {
+        const end = Math.min(offset + partSize, size) - 1
+        const { data } = await this.request('GET', bucket, key, {}, undefined, {
+          Range: `bytes=${offset}-${end}`,
+        })
+
+        if (data.length !== end - offset + 1) {
+          throw new Error(`S3 returned ${data.length} bytes for bytes ${offset}-${end}`)
+        }
+
+        await handle.write(data, 0, data.length, offset)
+        options.onProgress?.(end + 1, size)
+      }
+    } finally {
+      await handle.close()
+    }
+  }
+
+  async send(
+    method: Method,
+    bucket: string,
+    key: string,
+    query: Record<string, string> = {},
+    body?: Buffer,
+    headers: Record<string, string> = {},
+  ): Promise<Buffer> {
+    const { data } = await this.request(method, bucket, key, query, body, headers)
+    return data
+  }
+
+  private async request(
+    method: Method,
+    bucket: string,
+    key: string,
+    query: Record<string, string> = {},
+    body?: Buffer,
+    headers: Record<string, string> = {},
+  ): Promise<{ data: Buffer; headers: Record<string, string> }> {
+    const path = [bucket, ...key.split('/')].filter((p) => p.length).map(encodeRfc3986)
+    const url = new URL(`${this.endpoint}/${path.join('/')}`)
+
+    for (const [name, value] of Object.entries(query)) {
+      url.searchParams.set(name, value)
+    }
+
+    const signed = this.signRequest(method, url, headers, body ? sha256(body) : EMPTY_HASH)
+
+    const response = await axios.request<ArrayBuffer>({
+      method,
+      url: url.toString(),
+      headers: signed,
+      data: body,
+      responseType: 'arraybuffer',
+      maxBodyLength: Infinity,
+      maxContentLength: Infinity,
+      validateStatus: () => true,
+    })
+
+    const data = response.data ? Buffer.from(response.data) : Buffer.alloc(0)
+
+    if (response.status < 200 || response.status >= 300) {
+      const code = readXmlTag(data.toString('utf8'), 'Code') ?? 'UnknownError'
+      throw new Error(
+        `S3 ${method} s3://${bucket}/${key} failed with ${response.status} ${code}`,
+      )
+    }
+
+    return { data, headers: response.headers as Record<string, string> }
+  }
+
+  /**
+   * Add the AWS signature version 4 headers to a request
+   * @returns the headers to send, including the given headers
+   */
+  signRequest(
+    method: string,
+    url: URL,
+    headers: Record<string, string>,
+    payloadHash: string,
+    date = new Date(),
+  ): Record<string, string> {
+    const amzDate = date.toISOString().replace(/[:-]|\.\d{3}/g, '')
+    const day = amzDate.slice(0, 8)
+
+    const all: Record<string, string> = {
+      ...headers,
+      host: url.host,
+      'x-amz-content-sha256': payloadHash,
+      'x-amz-date': amzDate,
+    }
+
+    if (!this.credentials) {
+      return all
+    }
+
+    if (this.credentials.sessionToken) {
+      all['x-amz-security-token'] = this.credentials.sessionToken
+    }
+
+    const names = Object.keys(all)
+      .map((n) => n.toLowerCase())
+      .sort()
+    const lower = Object.fromEntries(Object.entries(all).map(([n, v]) => [n.toLowerCase(), v]))
+
+    const canonicalHeaders = names.map((n) => `${n}:${lower[n].trim()}\n`).join('')
+    const signedHeaders = names.join(';')
+
+    const canonicalQuery = [...url.searchParams.entries()]
+      .map(([k, v]) => [encodeRfc3986(k), encodeRfc3986(v)])
+      .sort(([a], [b]) => (a < b ? -1 : a > b ? 1 : 0))
+      .map(([k, v]) => `${k}=${v}`)
+      .join('&')
+
+    const canonicalRequest = [
+      method.toUpperCase(),
+      url.pathname || '/',
+      canonicalQuery,
+      canonicalHeaders,
+      signedHeaders,
+      payloadHash,
+    ].join('\n')
+
+    const scope = `${day}/${this.region}/s3/aws4_request`
+    const stringToSign = [
+      'AWS4-HMAC-SHA256',
+      amzDate,
+      scope,
+      sha256(canonicalRequest),
+    ].join('\n')
+
+    let signingKey = hmac(`AWS4${this.credentials.secretAccessKey}`, day)
+    signingKey = hmac(signingKey, this.region)
+    signingKey = hmac(signingKey, 's3')
+    signingKey = hmac(signingKey, 'aws4_request')
+
+    const signature = crypto.createHmac('sha256', signingKey).update(stringToSign).digest('hex')
+
+    all['Authorization'] =
+      `AWS4-HMAC-SHA256 Credential=${this.credentials.accessKeyId}/${scope},` +
+      `SignedHeaders=${signedHeaders},Signature=${signature}`
+
+    return all
+  }
+}
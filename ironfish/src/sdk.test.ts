@@ -1,6 +1,7 @@
 /* This Source Code Form is subject to the terms of the Mozilla Public
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import net from 'net'
 import os from 'os'
 import { Accounts } from './account'
 import { Config, DEFAULT_DATA_DIR } from './fileStores'
@@ -8,6 +9,7 @@ import { NodeFileProvider } from './fileSystems'
 import { IronfishNode } from './node'
 import { Platform } from './platform'
 import { RpcClient, RpcMemoryClient } from './rpc'
+import { RpcTcpClient } from './rpc/clients/tcpClient'
 import { IronfishSdk } from './sdk'
 
 describe('IronfishSdk', () => {
@@ -105,4 +107,71 @@ describe('IronfishSdk', () => {
       })
     })
   })
+
+  describe('follower', () => {
+    const getFreePort = (): Promise<number> =>
+      new Promise((resolve, reject) => {
+        const server = net.createServer()
+        server.on('error', reject)
+        server.listen(0, 'localhost', () => {
+          const address = server.address() as net.AddressInfo
+          server.close(() => resolve(address.port))
+        })
+      })
+
+    const init = async () => {
+      const sdk = await IronfishSdk.init({
+        configName: 'foo.config.json',
+        dataDir: os.tmpdir(),
+      })
+
+      sdk.config.setOverride('enableRpcIpc', false)
+      sdk.config.setOverride('follower', true)
+      return sdk
+    }
+
+    it('needs a chain node to sync from', async () => {
+      const sdk = await init()
+
+      await expect(sdk.node({ databaseName: 'follower' })).rejects.toThrowError(
+        'A follower needs a chainNode to sync from',
+      )
+    })
+
+    it('only serves read only routes over TCP', async () => {
+      const port = await getFreePort()
+      const sdk = await init()
+      sdk.config.setOverride('enableRpcTcp', true)
+      sdk.config.setOverride('enableRpcTls', false)
+      sdk.config.setOverride('rpcTcpPort', port)
+      sdk.config.setOverride('rpcTcpSecure', true)
+      sdk.config.setOverride('chainNode', 'localhost:1')
+      sdk.config.setOverride('chainNodeTls', false)
+
+      const node = await sdk.node({ databaseName: 'follower' })
+      expect(node.remoteSyncer).not.toBeNull()
+
+      await node.openDB()
+      await node.rpc.start()
+
+      const client = new RpcTcpClient('localhost', port)
+      await client.connect()
+
+      try {
+        await expect(client.getChainInfo()).resolves.toBeTruthy()
+
+        await expect(
+          client.broadcastTransaction({ transaction: '00' }),
+        ).rejects.toMatchObject({ status: 404 })
+
+        await expect(
+          client.request('account/create', { name: 'follower' }).waitForEnd(),
+        ).rejects.toMatchObject({ status: 404 })
+      } finally {
+        client.close()
+        await node.rpc.stop()
+        await node.closeDB()
+      }
+    })
+  })
 })
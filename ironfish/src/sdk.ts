@@ -2,7 +2,13 @@
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 import { BoxKeyPair } from 'tweetnacl'
-import { Config, ConfigOptions, DEFAULT_DATA_DIR, InternalStore } from './fileStores'
+import {
+  Config,
+  ConfigOptions,
+  DEFAULT_DATA_DIR,
+  InternalStore,
+  Profiles,
+} from './fileStores'
 import { FileSystem, NodeFileProvider } from './fileSystems'
 import {
   createRootLogger,
@@ -71,6 +77,7 @@ export class IronfishSdk {
     configOverrides,
     fileSystem,
     dataDir,
+    profile,
     logger = createRootLogger(),
     metrics,
     strategyClass,
@@ -80,6 +87,7 @@ export class IronfishSdk {
     configOverrides?: Partial<ConfigOptions>
     fileSystem?: FileSystem
     dataDir?: string
+    profile?: string
     logger?: Logger
     metrics?: MetricsMonitor
     strategyClass?: typeof Strategy
@@ -98,6 +106,10 @@ export class IronfishSdk {
     logger = logger.withTag('ironfishsdk')
     dataDir = dataDir || DEFAULT_DATA_DIR
 
+    if (profile) {
+      dataDir = Profiles.getDataDir(fileSystem, dataDir, profile)
+    }
+
     const config = new Config(fileSystem, dataDir, configName)
     await config.load()
 
@@ -136,10 +148,14 @@ export class IronfishSdk {
 
     let client: RpcSocketClient
     if (config.get('enableRpcTcp')) {
+      const host = config.get('rpcTcpHost')
+      const port = config.get('rpcTcpPort')
+      const authToken = config.get('rpcAuthToken') || null
+
       if (config.get('enableRpcTls')) {
-        client = new RpcTlsClient(config.get('rpcTcpHost'), config.get('rpcTcpPort'), logger)
+        client = new RpcTlsClient(host, port, logger, authToken)
       } else {
-        client = new RpcTcpClient(config.get('rpcTcpHost'), config.get('rpcTcpPort'), logger)
+        client = new RpcTcpClient(host, port, logger, authToken)
       }
     } else {
       client = new RpcIpcClient(
@@ -195,11 +211,13 @@ export class IronfishSdk {
         ApiNamespace.account,
         ApiNamespace.chain,
         ApiNamespace.config,
+        ApiNamespace.dev,
         ApiNamespace.event,
         ApiNamespace.faucet,
         ApiNamespace.miner,
         ApiNamespace.node,
         ApiNamespace.peer,
+        ApiNamespace.scanner,
         ApiNamespace.transaction,
         ApiNamespace.telemetry,
         ApiNamespace.worker,
@@ -221,6 +239,7 @@ export class IronfishSdk {
     if (this.config.get('enableRpcTcp')) {
       const namespaces = [
         ApiNamespace.chain,
+        ApiNamespace.dev,
         ApiNamespace.event,
         ApiNamespace.faucet,
         ApiNamespace.miner,
@@ -236,9 +255,13 @@ export class IronfishSdk {
         namespaces.push(ApiNamespace.account, ApiNamespace.config)
       }
 
-      if (this.config.get('enableRpcTls')) {
-        await node.rpc.mount(
-          new RpcTlsAdapter(
+      // View keys reveal every transaction of the users they belong to
+      if (this.config.get('rpcTcpSecure') || this.config.get('rpcAuthToken')) {
+        namespaces.push(ApiNamespace.scanner)
+      }
+
+      const adapter = this.config.get('enableRpcTls')
+        ? new RpcTlsAdapter(
             this.config.get('rpcTcpHost'),
             this.config.get('rpcTcpPort'),
             this.fileSystem,
@@ -246,18 +269,19 @@ export class IronfishSdk {
             this.config.get('tlsCertPath'),
             this.logger,
             namespaces,
-          ),
-        )
-      } else {
-        await node.rpc.mount(
-          new RpcTcpAdapter(
+          )
+        : new RpcTcpAdapter(
             this.config.get('rpcTcpHost'),
             this.config.get('rpcTcpPort'),
             this.logger,
             namespaces,
-          ),
-        )
-      }
+          )
+
+      adapter.readOnly = this.config.get('rpcTcpReadOnly') || this.config.get('follower')
+      adapter.maxConnections = this.config.get('rpcTcpMaxConnections')
+      adapter.maxConnectionsPerIp = this.config.get('rpcTcpMaxConnectionsPerIp')
+      adapter.idleTimeoutMs = this.config.get('rpcTcpIdleTimeoutMs')
+      await node.rpc.mount(adapter)
     }
 
     return node
@@ -4,12 +4,13 @@
 
 import leveldown from 'leveldown'
 import { IJsonSerializable } from '../serde'
-import { PromiseUtils } from '../utils'
+import { AsyncUtils, PromiseUtils } from '../utils'
 import {
   ArrayEncoding,
   BufferEncoding,
   DatabaseSchema,
   DuplicateKeyError,
+  IDatabaseTransaction,
   JsonEncoding,
   StringEncoding,
 } from './database'
@@ -98,6 +99,16 @@ describe('Database', () => {
     expect(await db.metaStore.get('version')).toBe(1)
 
     await expect(db.upgrade(3)).rejects.toThrowError('You are running a newer')
+    await expect(db.upgrade(0)).rejects.toThrowError('You are running an older')
+  })
+
+  it('should set the version', async () => {
+    await db.open()
+    await db.upgrade(1)
+
+    await db.setVersion(2)
+    expect(await db.getVersion()).toBe(2)
+    await expect(db.upgrade(2)).resolves.toBeUndefined()
   })
 
   it('should store and get values', async () => {
@@ -550,6 +561,28 @@ describe('Database', () => {
       expect(keys).toContain('d')
     })
 
+    it('should iterate in reverse with a limit', async () => {
+      await db.open()
+      await db.metaStore.clear()
+      await db.metaStore.put('a', 1000)
+      await db.metaStore.put('b', 1001)
+      await db.metaStore.put('c', 1002)
+
+      const options = { reverse: true, limit: 2 }
+      const keys = (tx?: IDatabaseTransaction) =>
+        AsyncUtils.materialize(db.metaStore.getAllKeysIter(tx, options))
+
+      expect(await keys()).toEqual(['c', 'b'])
+
+      await db.transaction(async (tx) => {
+        await db.metaStore.put('d', 1003, tx)
+        await db.metaStore.del('c', tx)
+
+        expect(await keys(tx)).toEqual(['d', 'b'])
+        expect(await db.metaStore.getAllKeys(tx)).toEqual(['a', 'b', 'd'])
+      })
+    })
+
     it('should encode and decode keys', async () => {
       await db.open()
       await bazStore.clear()
@@ -40,6 +40,16 @@ export interface IDatabase {
    */
   upgrade(version: number): Promise<void>
 
+  /**
+   * The schema version stored by [[`IDatabase.upgrade`]], or null if there is none
+   */
+  getVersion(): Promise<number | null>
+
+  /**
+   * Store the schema version, used by migrations after they change the schema
+   */
+  setVersion(version: number, transaction?: IDatabaseTransaction): Promise<void>
+
   /**
    * Add an {@link IDatabaseStore} to the database
    *
@@ -130,6 +140,8 @@ export abstract class Database implements IDatabase {
   abstract open(options?: DatabaseOptions): Promise<void>
   abstract close(): Promise<void>
   abstract upgrade(version: number): Promise<void>
+  abstract getVersion(): Promise<number | null>
+  abstract setVersion(version: number, transaction?: IDatabaseTransaction): Promise<void>
 
   abstract transaction(): IDatabaseTransaction
 
@@ -31,6 +31,21 @@ export class U32Encoding implements IDatabaseEncoding<number> {
   }
 }
 
+/**
+ * Like {@link U32Encoding} but big endian, so keys iterate in numerical order
+ */
+export class U32BEEncoding implements IDatabaseEncoding<number> {
+  serialize(value: number): Buffer {
+    const buffer = Buffer.alloc(4)
+    buffer.writeUInt32BE(value)
+    return buffer
+  }
+
+  deserialize(buffer: Buffer): number {
+    return buffer.readUInt32BE()
+  }
+}
+
 export class BufferEncoding implements IDatabaseEncoding<Buffer> {
   serialize = (value: Buffer): Buffer => value
   deserialize = (buffer: Buffer): Buffer => buffer
@@ -69,3 +84,4 @@ export default class BufferToStringEncoding implements Serde<Buffer, string> {
 export const BUFFER_TO_STRING_ENCODING = new BufferToStringEncoding()
 export const BUFFER_ENCODING = new BufferEncoding()
 export const U32_ENCODING = new U32Encoding()
+export const U32_BE_ENCODING = new U32BEEncoding()
@@ -16,3 +16,14 @@ export class DatabaseOpenError extends Error {
 export class DatabaseIsOpenError extends DatabaseOpenError {}
 export class DatabaseIsLockedError extends DatabaseOpenError {}
 export class DatabaseIsCorruptError extends DatabaseOpenError {}
+
+export class DatabaseVersionError extends Error {
+  readonly version: number
+  readonly expected: number
+
+  constructor(version: number, expected: number, message?: string) {
+    super(message ?? `The database version is ${version}, expected ${expected}`)
+    this.version = version
+    this.expected = expected
+  }
+}
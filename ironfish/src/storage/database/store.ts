@@ -5,6 +5,13 @@
 import { IDatabaseTransaction } from './transaction'
 import { DatabaseSchema, IDatabaseEncoding, SchemaKey, SchemaValue } from './types'
 
+export type IDatabaseIteratorOptions = {
+  /** Iterate from the last key to the first */
+  reverse?: boolean
+  /** The most entries to yield */
+  limit?: number
+}
+
 export type IDatabaseStoreOptions<Schema extends DatabaseSchema> = {
   /** The unique name of the store inside of the database */
   name: string
@@ -40,9 +47,11 @@ export interface IDatabaseStore<Schema extends DatabaseSchema> {
    */
   encode(key: SchemaKey<Schema>, value: SchemaValue<Schema>): [Buffer, Buffer]
 
-  /* Get an [[`AsyncGenerator`]] that yields all of the key/value pairs in the IDatastore */
+  /* Get an [[`AsyncGenerator`]] that yields all of the key/value pairs in the IDatastore, in
+   the order of their encoded keys */
   getAllIter(
     transaction?: IDatabaseTransaction,
+    options?: IDatabaseIteratorOptions,
   ): AsyncGenerator<[SchemaKey<Schema>, SchemaValue<Schema>]>
 
   /* Get an [[`AsyncGenerator`]] that yields all of the values in the IDatastore */
@@ -51,7 +60,10 @@ export interface IDatabaseStore<Schema extends DatabaseSchema> {
   getAllValues(transaction?: IDatabaseTransaction): Promise<Array<SchemaValue<Schema>>>
 
   /* Get an [[`AsyncGenerator`]] that yields all of the keys in the IDatastore */
-  getAllKeysIter(transaction?: IDatabaseTransaction): AsyncGenerator<SchemaKey<Schema>>
+  getAllKeysIter(
+    transaction?: IDatabaseTransaction,
+    options?: IDatabaseIteratorOptions,
+  ): AsyncGenerator<SchemaKey<Schema>>
   /* Get all of the keys in the IDatastore */
   getAllKeys(transaction?: IDatabaseTransaction): Promise<Array<SchemaKey<Schema>>>
 
@@ -152,6 +164,7 @@ export abstract class DatabaseStore<Schema extends DatabaseSchema>
 
   abstract getAllIter(
     transaction?: IDatabaseTransaction,
+    options?: IDatabaseIteratorOptions,
   ): AsyncGenerator<[SchemaKey<Schema>, SchemaValue<Schema>]>
 
   abstract getAllValuesIter(
@@ -159,7 +172,10 @@ export abstract class DatabaseStore<Schema extends DatabaseSchema>
   ): AsyncGenerator<SchemaValue<Schema>>
   abstract getAllValues(transaction?: IDatabaseTransaction): Promise<Array<SchemaValue<Schema>>>
 
-  abstract getAllKeysIter(transaction?: IDatabaseTransaction): AsyncGenerator<SchemaKey<Schema>>
+  abstract getAllKeysIter(
+    transaction?: IDatabaseTransaction,
+    options?: IDatabaseIteratorOptions,
+  ): AsyncGenerator<SchemaKey<Schema>>
   abstract getAllKeys(transaction?: IDatabaseTransaction): Promise<Array<SchemaKey<Schema>>>
 
   abstract clear(): Promise<void>
@@ -23,6 +23,7 @@ import {
   DatabaseIsCorruptError,
   DatabaseIsLockedError,
   DatabaseIsOpenError,
+  DatabaseVersionError,
 } from '../database/errors'
 import { LevelupBatch } from './batch'
 import { LevelupStore } from './store'
@@ -112,12 +113,34 @@ export class LevelupDatabase extends Database {
       throw new Error(`Corrupted database version ${typeof current}: ${String(current)}`)
     }
 
-    if (current !== version) {
-      throw new Error(
+    if (current < version) {
+      throw new DatabaseVersionError(
+        current,
+        version,
         `You are running a newer version of ironfish on an older database.\n` +
-          `Run "ironfish reset" to reset your database.\n`,
+          `Run "ironfish migrations:run" to migrate your database.\n`,
       )
     }
+
+    if (current > version) {
+      throw new DatabaseVersionError(
+        current,
+        version,
+        `You are running an older version of ironfish on a newer database.\n` +
+          `Upgrade ironfish, or run "ironfish migrations:rollback" with the newer version.\n`,
+      )
+    }
+  }
+
+  async getVersion(): Promise<number | null> {
+    Assert.isTrue(this.isOpen, 'Database needs to be open')
+
+    const version = await this.metaStore.get('version')
+    return typeof version === 'number' ? version : null
+  }
+
+  async setVersion(version: number, transaction?: IDatabaseTransaction): Promise<void> {
+    await this.metaStore.put('version', version, transaction)
   }
 
   transaction<TResult>(
@@ -8,6 +8,7 @@ import {
   DatabaseSchema,
   DatabaseStore,
   DuplicateKeyError,
+  IDatabaseIteratorOptions,
   IDatabaseStoreOptions,
   IDatabaseTransaction,
   SchemaKey,
@@ -92,8 +93,12 @@ export class LevelupStore<Schema extends DatabaseSchema> extends DatabaseStore<S
 
   async *getAllIter(
     transaction?: IDatabaseTransaction,
+    options?: IDatabaseIteratorOptions,
   ): AsyncGenerator<[SchemaKey<Schema>, SchemaValue<Schema>]> {
+    const reverse = options?.reverse ?? false
+    let remaining = options?.limit ?? Infinity
     const seen = new Set<string>()
+    const pending: [Buffer, SchemaValue<Schema> | undefined][] = []
 
     if (ENABLE_TRANSACTIONS && transaction instanceof LevelupTransaction) {
       await transaction.acquireLock()
@@ -106,22 +111,55 @@ export class LevelupStore<Schema extends DatabaseSchema> extends DatabaseStore<S
           .equals(this.prefixBuffer)
 
         if (isFromStore) {
-          if (value !== undefined) {
-            yield [this.decodeKey(keyBuffer), value as SchemaValue<Schema>]
-          }
+          pending.push([keyBuffer, value as SchemaValue<Schema> | undefined])
           seen.add(key)
         }
       }
     }
 
-    const stream = this.db.levelup.createReadStream(this.allKeysRange)
+    // The entries of the transaction are merged into the entries of the database in key order
+    const compare = (a: Buffer, b: Buffer) => (reverse ? b.compare(a) : a.compare(b))
+    pending.sort(([a], [b]) => compare(a, b))
+    let next = 0
+
+    const stream = this.db.levelup.createReadStream({
+      ...this.allKeysRange,
+      reverse,
+      // Entries of the transaction replace entries of the database, so the database may need
+      // to yield more entries than the limit
+      limit: seen.size === 0 && remaining !== Infinity ? remaining : -1,
+    })
 
     for await (const pair of stream) {
       const { key, value } = pair as unknown as { key: Buffer; value: Buffer }
+
+      for (; next < pending.length && compare(pending[next][0], key) <= 0; next++) {
+        const [pendingKey, pendingValue] = pending[next]
+        if (pendingValue !== undefined) {
+          if (remaining-- <= 0) {
+            return
+          }
+          yield [this.decodeKey(pendingKey), pendingValue]
+        }
+      }
+
       if (!seen.has(BUFFER_TO_STRING_ENCODING.serialize(key))) {
+        if (remaining-- <= 0) {
+          return
+        }
         yield [this.decodeKey(key), this.valueEncoding.deserialize(value)]
       }
     }
+
+    for (; next < pending.length; next++) {
+      const [pendingKey, pendingValue] = pending[next]
+      if (pendingValue !== undefined) {
+        if (remaining-- <= 0) {
+          return
+        }
+        yield [this.decodeKey(pendingKey), pendingValue]
+      }
+    }
   }
 
   async getAll(
@@ -142,8 +180,11 @@ export class LevelupStore<Schema extends DatabaseSchema> extends DatabaseStore<S
     return AsyncUtils.materialize(this.getAllValuesIter(transaction))
   }
 
-  async *getAllKeysIter(transaction?: IDatabaseTransaction): AsyncGenerator<SchemaKey<Schema>> {
-    for await (const [key] of this.getAllIter(transaction)) {
+  async *getAllKeysIter(
+    transaction?: IDatabaseTransaction,
+    options?: IDatabaseIteratorOptions,
+  ): AsyncGenerator<SchemaKey<Schema>> {
+    for await (const [key] of this.getAllIter(transaction, options)) {
       yield key
     }
   }
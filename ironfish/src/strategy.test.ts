@@ -30,4 +30,15 @@ describe('Miners reward', () => {
     const minersReward = strategy.miningReward(IRON_FISH_YEAR_IN_BLOCKS + 1)
     expect(minersReward).toBe(19 * 10 ** 8)
   })
+  it('sums the mining rewards of every block after genesis', () => {
+    expect(strategy.totalMiningReward(1)).toEqual(BigInt(0))
+    expect(strategy.totalMiningReward(3)).toEqual(BigInt(2 * 20 * 10 ** 8))
+
+    // Every block in the first year after genesis, then one from the second year
+    const firstYear = BigInt(IRON_FISH_YEAR_IN_BLOCKS - 2) * BigInt(20 * 10 ** 8)
+    expect(strategy.totalMiningReward(IRON_FISH_YEAR_IN_BLOCKS - 1)).toEqual(firstYear)
+    expect(strategy.totalMiningReward(IRON_FISH_YEAR_IN_BLOCKS)).toEqual(
+      firstYear + BigInt(19 * 10 ** 8),
+    )
+  })
 })
@@ -2,7 +2,11 @@
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 
-import { GENESIS_SUPPLY_IN_IRON, IRON_FISH_YEAR_IN_BLOCKS } from './consensus'
+import {
+  GENESIS_BLOCK_SEQUENCE,
+  GENESIS_SUPPLY_IN_IRON,
+  IRON_FISH_YEAR_IN_BLOCKS,
+} from './consensus'
 import { NoteHasher } from './merkletree/hasher'
 import { BlockSerde } from './primitives/block'
 import { BlockHash, BlockHeaderSerde, hashBlockHeader } from './primitives/blockheader'
@@ -84,6 +88,30 @@ export class Strategy {
     return reward
   }
 
+  /**
+   * Sum the mining rewards of every block after the genesis block up to and
+   * including `sequence`. The reward only changes once a year so this adds
+   * up whole years at a time.
+   *
+   * @param sequence Block sequence
+   * @returns total mining reward (in ORE) issued by the emission schedule
+   */
+  totalMiningReward(sequence: number): bigint {
+    let total = BigInt(0)
+    let start = GENESIS_BLOCK_SEQUENCE + 1
+
+    while (start <= sequence) {
+      const yearsAfterLaunch = Math.floor(start / IRON_FISH_YEAR_IN_BLOCKS)
+      const yearEnd = (yearsAfterLaunch + 1) * IRON_FISH_YEAR_IN_BLOCKS - 1
+      const end = Math.min(yearEnd, sequence)
+
+      total += BigInt(this.miningReward(start)) * BigInt(end - start + 1)
+      start = end + 1
+    }
+
+    return total
+  }
+
   convertIronToOre(iron: number): number {
     return Math.round(iron * 10 ** 8)
   }
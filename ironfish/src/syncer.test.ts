@@ -46,6 +46,116 @@ describe('Syncer', () => {
     expect(startSyncSpy).toHaveBeenCalledWith(peer)
   }, 10000)
 
+  it('should load from a preferred peer first', () => {
+    const { chain, peerNetwork, syncer } = nodeTest
+    Assert.isNotNull(chain.head)
+
+    const startSyncSpy = jest.spyOn(syncer, 'startSync').mockImplementation()
+
+    const { peer: other } = getConnectedPeer(peerNetwork.peerManager)
+    const { peer: preferred } = getConnectedPeer(peerNetwork.peerManager)
+    other.work = chain.head.work + BigInt(1)
+    preferred.work = chain.head.work + BigInt(1)
+
+    Assert.isNotNull(preferred.state.identity)
+    syncer.setPreferredPeers([preferred.state.identity])
+
+    for (let i = 0; i < 10; i++) {
+      syncer.findPeer()
+    }
+
+    expect(startSyncSpy).toHaveBeenCalledTimes(10)
+    for (const [peer] of startSyncSpy.mock.calls) {
+      expect(peer).toBe(preferred)
+    }
+  })
+
+  it('should fall back when a preferred peer stalls', () => {
+    const { peerNetwork, syncer } = nodeTest
+
+    const { peer } = getConnectedPeer(peerNetwork.peerManager)
+    peer.work = BigInt(1)
+    peer.sequence = 1
+    peer.head = Buffer.from('')
+
+    Assert.isNotNull(peer.state.identity)
+    syncer.setPreferredPeers([peer.state.identity])
+
+    const [promise] = PromiseUtils.split<void>()
+    jest.spyOn(syncer, 'syncFrom').mockReturnValue(promise)
+    syncer.startSync(peer)
+
+    // Still making progress
+    syncer.checkStalled(peer)
+    expect(syncer.loader).toBe(peer)
+
+    syncer.lastProgress = Date.now() - 2 * 60 * 1000
+    syncer.checkStalled(peer)
+
+    expect(syncer.loader).toBe(null)
+    expect(syncer.state).toEqual('idle')
+    expect(syncer.isPreferred(peer)).toBe(false)
+    expect(peer.state.type).toEqual('CONNECTED')
+  })
+
+  it('should rotate away from a stalled peer', () => {
+    const { chain, peerNetwork, syncer } = nodeTest
+    Assert.isNotNull(chain.head)
+
+    const { peer } = getConnectedPeer(peerNetwork.peerManager)
+    const { peer: other } = getConnectedPeer(peerNetwork.peerManager)
+    peer.work = chain.head.work + BigInt(1)
+    peer.sequence = 1
+    peer.head = Buffer.from('')
+    other.work = chain.head.work + BigInt(1)
+
+    const [promise] = PromiseUtils.split<void>()
+    jest.spyOn(syncer, 'syncFrom').mockReturnValue(promise)
+    syncer.startSync(peer)
+
+    syncer.lastProgress = Date.now() - 2 * 60 * 1000
+    syncer.checkStalled(peer)
+
+    expect(syncer.loader).toBe(null)
+    expect(syncer.stalled).toBe(true)
+    expect(syncer.isBackedOff(peer)).toBe(true)
+    expect(peer.state.type).toEqual('DISCONNECTED')
+
+    const startSyncSpy = jest.spyOn(syncer, 'startSync').mockImplementation()
+    syncer.findPeer()
+    expect(startSyncSpy).toHaveBeenCalledWith(other)
+  })
+
+  it('should back off longer for every stall in a row', () => {
+    const { peerNetwork, syncer } = nodeTest
+
+    const { peer } = getConnectedPeer(peerNetwork.peerManager)
+    peer.work = BigInt(1)
+    peer.sequence = 1
+    peer.head = Buffer.from('')
+
+    Assert.isNotNull(peer.state.identity)
+    syncer.setPreferredPeers([peer.state.identity])
+
+    const [promise] = PromiseUtils.split<void>()
+    jest.spyOn(syncer, 'syncFrom').mockReturnValue(promise)
+
+    const backoffs: number[] = []
+    for (let i = 0; i < 3; i++) {
+      syncer.startSync(peer)
+      syncer.lastProgress = Date.now() - 2 * 60 * 1000
+      syncer.checkStalled(peer)
+
+      const stalled = syncer.stalledPeers.get(peer.state.identity)
+      Assert.isNotUndefined(stalled)
+      backoffs.push(stalled.until - Date.now())
+    }
+
+    expect(backoffs[1]).toBeGreaterThan(backoffs[0])
+    expect(backoffs[2]).toBeGreaterThan(backoffs[1])
+    expect(syncer.stalls).toBe(3)
+  })
+
   it('should sync and then finish from peer', async () => {
     const { peerNetwork, syncer } = nodeTest
 
@@ -7,6 +7,7 @@ import { Blockchain } from './blockchain'
 import { GENESIS_BLOCK_SEQUENCE, VerificationResultReason } from './consensus'
 import { Event } from './event'
 import { createRootLogger, Logger } from './logger'
+import { MemoryBudget } from './memoryBudget'
 import { Meter, MetricsMonitor } from './metrics'
 import { Peer, PeerNetwork } from './network'
 import { BAN_SCORE, KnownBlockHashesValue, PeerState } from './network/peers/peer'
@@ -20,6 +21,10 @@ import { ArrayUtils } from './utils/array'
 const SYNCER_TICK_MS = 10 * 1000
 const LINEAR_ANCESTOR_SEARCH = 3
 const REQUEST_BLOCKS_PER_MESSAGE = 20
+const SYNC_STALL_TIMEOUT_MS = 60 * 1000
+// How long we skip a peer after it stalls, doubled for every stall in a row
+const STALL_BACKOFF_MS = 60 * 1000
+const STALL_BACKOFF_MAX_MS = 60 * 60 * 1000
 
 class AbortSyncingError extends Error {}
 
@@ -31,12 +36,22 @@ export class Syncer {
   readonly telemetry: Telemetry
   readonly logger: Logger
   readonly speed: Meter
+  readonly memoryBudget: MemoryBudget | null
 
   state: 'stopped' | 'idle' | 'stopping' | 'syncing'
   stopping: Promise<void> | null
   eventLoopTimeout: SetTimeoutToken | null
   loader: Peer | null = null
   blocksPerMessage: number
+  preferredPeers: Set<string>
+  // How long we can go without blocks from the loader before rotating peers
+  stallTimeoutMs: number
+  // Peers that stalled while we synced from them, by identity
+  stalledPeers = new Map<string, { until: number; count: number }>()
+  lastProgress = 0
+  stalls = 0
+  // When the last sync stalled, until we get blocks again
+  stalledAt: number | null = null
 
   onGossip = new Event<[Block]>()
 
@@ -48,6 +63,9 @@ export class Syncer {
     metrics?: MetricsMonitor
     logger?: Logger
     blocksPerMessage?: number
+    memoryBudget?: MemoryBudget
+    preferredPeers?: string[]
+    stallTimeoutMs?: number
   }) {
     const logger = options.logger || createRootLogger()
 
@@ -56,6 +74,7 @@ export class Syncer {
     this.strategy = options.strategy
     this.logger = logger.withTag('syncer')
     this.telemetry = options.telemetry
+    this.memoryBudget = options.memoryBudget ?? null
 
     this.metrics = options.metrics || new MetricsMonitor({ logger: this.logger })
 
@@ -65,6 +84,41 @@ export class Syncer {
     this.eventLoopTimeout = null
 
     this.blocksPerMessage = options.blocksPerMessage ?? REQUEST_BLOCKS_PER_MESSAGE
+    this.preferredPeers = new Set(options.preferredPeers)
+    this.stallTimeoutMs = options.stallTimeoutMs ?? SYNC_STALL_TIMEOUT_MS
+  }
+
+  get stalled(): boolean {
+    return this.stalledAt !== null
+  }
+
+  setPreferredPeers(identities: string[]): void {
+    this.preferredPeers = new Set(identities)
+  }
+
+  /**
+   * If the peer stalled recently enough that we should sync from others
+   */
+  isBackedOff(peer: Peer): boolean {
+    const identity = peer.state.identity
+    const stalled = identity !== null ? this.stalledPeers.get(identity) : undefined
+    return stalled !== undefined && stalled.until > Date.now()
+  }
+
+  /**
+   * Preferred peers are the configured ones and the ones on the local network
+   */
+  isPreferred(peer: Peer): boolean {
+    const identity = peer.state.identity
+    if (identity === null) {
+      return false
+    }
+
+    if (!this.preferredPeers.has(identity) && !this.peerNetwork.isLocalPeer(peer)) {
+      return false
+    }
+
+    return !this.isBackedOff(peer)
   }
 
   async start(): Promise<void> {
@@ -106,6 +160,10 @@ export class Syncer {
       return
     }
 
+    if (this.state === 'syncing' && this.loader) {
+      this.checkStalled(this.loader)
+    }
+
     if (this.state === 'idle') {
       this.findPeer()
     }
@@ -121,10 +179,21 @@ export class Syncer {
     }
 
     // Find all allowed peers that have more work than we have
-    const peers = this.peerNetwork.peerManager
+    const ahead = this.peerNetwork.peerManager
       .getConnectedPeers()
       .filter((peer) => peer.work && peer.work > head.work)
 
+    // Skip peers that stalled recently, unless they are the only ones ahead of us
+    const available = ahead.filter((peer) => !this.isBackedOff(peer))
+    const peers = available.length > 0 ? available : ahead
+
+    // Sync from a preferred peer if one can help us
+    const preferred = peers.filter((peer) => this.isPreferred(peer))
+    if (preferred.length > 0) {
+      this.startSync(ArrayUtils.sampleOrThrow(preferred))
+      return
+    }
+
     // Get a random peer with higher work. We do this to encourage
     // peer diversity so the highest work peer isn't overwhelmed
     // as well as helping to make sure we don't get stuck with unstable peers
@@ -134,6 +203,38 @@ export class Syncer {
     }
   }
 
+  /**
+   * Stop syncing from a peer that has not sent us blocks in a while and skip
+   * it for a time, so the next tick syncs from another peer
+   */
+  checkStalled(peer: Peer): void {
+    if (this.stallTimeoutMs <= 0 || Date.now() - this.lastProgress < this.stallTimeoutMs) {
+      return
+    }
+
+    Assert.isNotNull(peer.state.identity)
+
+    const preferred = this.isPreferred(peer)
+    const count = (this.stalledPeers.get(peer.state.identity)?.count ?? 0) + 1
+    const backoff = Math.min(STALL_BACKOFF_MS * 2 ** (count - 1), STALL_BACKOFF_MAX_MS)
+
+    this.logger.warn(
+      `Sync stalled, no blocks from ${peer.displayName} in ${
+        this.stallTimeoutMs / 1000
+      }s, rotating peers`,
+    )
+
+    this.stalledPeers.set(peer.state.identity, { until: Date.now() + backoff, count })
+    this.stalls++
+    this.stalledAt = Date.now()
+    this.stopSync(peer)
+
+    // Keep preferred peers connected since they were chosen by the operator
+    if (!preferred) {
+      peer.close(new Error('Stalled while syncing'))
+    }
+  }
+
   startSync(peer: Peer): void {
     if (this.loader) {
       return
@@ -151,6 +252,7 @@ export class Syncer {
 
     this.state = 'syncing'
     this.loader = peer
+    this.lastProgress = Date.now()
 
     peer.onStateChanged.on(this.onPeerStateChanged)
 
@@ -356,6 +458,16 @@ export class Syncer {
     let skipped = 0
 
     while (head) {
+      const pressure = this.memoryBudget?.pressure ?? 'normal'
+      if (this.memoryBudget && pressure !== 'normal') {
+        this.logger.info(
+          `Memory pressure is ${pressure}, pausing sync from ${peer.displayName}`,
+        )
+
+        await this.memoryBudget.waitForCapacity()
+        this.abort(peer)
+      }
+
       this.logger.info(
         `Requesting ${this.blocksPerMessage} blocks starting at ${HashUtils.renderHash(
           head,
@@ -373,6 +485,11 @@ export class Syncer {
       }
 
       this.abort(peer)
+      this.lastProgress = Date.now()
+      this.stalledAt = null
+      if (peer.state.identity !== null) {
+        this.stalledPeers.delete(peer.state.identity)
+      }
 
       for (const addBlock of blocks) {
         sequence += 1
@@ -1,7 +1,9 @@
 /* This Source Code Form is subject to the terms of the Mozilla Public
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+export { TELEMETRY_CATEGORIES, TelemetryCategory } from './interfaces/category'
 export { Field } from './interfaces/field'
 export { Metric } from './interfaces/metric'
+export { TelemetrySubmission } from './interfaces/submission'
 export { Tag } from './interfaces/tag'
 export { Telemetry } from './telemetry'
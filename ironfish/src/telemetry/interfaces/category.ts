@@ -0,0 +1,16 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { StrEnumUtils } from '../../utils/enums'
+
+/**
+ * Groups of metrics that users can opt in to submitting separately
+ * using the `telemetryCategories` config option.
+ */
+export enum TelemetryCategory {
+  performance = 'performance',
+  network = 'network',
+  mining = 'mining',
+}
+
+export const TELEMETRY_CATEGORIES = StrEnumUtils.getValues(TelemetryCategory)
@@ -1,6 +1,7 @@
 /* This Source Code Form is subject to the terms of the Mozilla Public
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { TelemetryCategory } from './category'
 import { Field } from './field'
 import { Tag } from './tag'
 
@@ -14,6 +15,13 @@ export interface Metric {
    */
   measurement: string
 
+  /**
+   * The category this metric belongs to. Metrics in a category are only
+   * collected if the category is enabled. Metrics without a category are
+   * always collected.
+   */
+  category?: TelemetryCategory
+
   /**
    * The exact time at which the metric was recorded.
    * JS gives us millisecond accuracy here.
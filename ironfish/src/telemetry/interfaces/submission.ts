@@ -0,0 +1,20 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { Metric } from './metric'
+
+/**
+ * The JSON body a node POSTs to the `telemetryApi` endpoint. Timestamps are
+ * serialized as ISO 8601 strings.
+ */
+export interface TelemetrySubmission {
+  /**
+   * Up to 1000 points, oldest first
+   */
+  points: Metric[]
+
+  /**
+   * The `blockGraffiti` of the node, which may be empty
+   */
+  graffiti: string
+}
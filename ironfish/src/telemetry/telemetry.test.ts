@@ -2,8 +2,9 @@
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 import { v4 as uuid } from 'uuid'
-import { mockChain, mockConfig, mockWorkerPool } from '../testUtilities/mocks'
+import { mockChain, mockConfig, mockFileSystem, mockWorkerPool } from '../testUtilities/mocks'
 import { GraffitiUtils } from '../utils/graffiti'
+import { TelemetryCategory } from './interfaces/category'
 import { Metric } from './interfaces/metric'
 import { Telemetry } from './telemetry'
 
@@ -30,7 +31,11 @@ describe('Telemetry', () => {
     telemetry = new Telemetry({
       chain: mockChain(),
       workerPool: mockWorkerPool(),
-      config: mockConfig({ blockGraffiti: mockGraffiti }),
+      config: mockConfig({
+        blockGraffiti: mockGraffiti,
+        telemetryApi: 'https://telemetry.example.com/points',
+        telemetryApiKey: 'key',
+      }),
       localPeerIdentity: uuid(),
     })
 
@@ -78,6 +83,45 @@ describe('Telemetry', () => {
       })
     })
 
+    describe('when the category is disabled', () => {
+      it('does nothing', () => {
+        const categoryTelemetry = new Telemetry({
+          chain: mockChain(),
+          workerPool: mockWorkerPool(),
+          config: mockConfig({ telemetryCategories: ['performance'] }),
+          localPeerIdentity: uuid(),
+        })
+        categoryTelemetry.start()
+
+        categoryTelemetry.submit({ ...mockMetric, category: TelemetryCategory.mining })
+        expect(categoryTelemetry['points']).toHaveLength(0)
+
+        categoryTelemetry.submit({ ...mockMetric, category: TelemetryCategory.performance })
+        expect(categoryTelemetry['points']).toHaveLength(1)
+
+        void categoryTelemetry.stop()
+      })
+    })
+
+    describe('when not submitting to the API', () => {
+      it('only queues the metric for export', () => {
+        const exportTelemetry = new Telemetry({
+          chain: mockChain(),
+          workerPool: mockWorkerPool(),
+          config: mockConfig({ telemetryExportPath: '/tmp/telemetry.jsonl' }),
+          localPeerIdentity: uuid(),
+        })
+        exportTelemetry.submitToApi = false
+        exportTelemetry.start()
+
+        exportTelemetry.submit(mockMetric)
+        expect(exportTelemetry['points']).toHaveLength(0)
+        expect(exportTelemetry['exportPoints']).toHaveLength(1)
+
+        void exportTelemetry.stop()
+      })
+    })
+
     it('stores the metric', () => {
       const currentPointsLength = telemetry['points'].length
       telemetry.submit(mockMetric)
@@ -126,6 +170,27 @@ describe('Telemetry', () => {
       })
     })
 
+    it('appends exported points to the export file', async () => {
+      const writeFile = jest.fn()
+      const exportTelemetry = new Telemetry({
+        chain: mockChain(),
+        workerPool: mockWorkerPool(),
+        config: mockConfig({ telemetryExportPath: '/tmp/telemetry.jsonl' }),
+        files: mockFileSystem({ writeFile }),
+        localPeerIdentity: uuid(),
+      })
+      exportTelemetry['exportPoints'] = [mockMetric, mockMetric]
+
+      await exportTelemetry.flush()
+
+      expect(writeFile).toHaveBeenCalledWith(
+        '/tmp/telemetry.jsonl',
+        `${JSON.stringify(mockMetric)}\n${JSON.stringify(mockMetric)}\n`,
+        { flag: 'a' },
+      )
+      expect(exportTelemetry['exportPoints']).toHaveLength(0)
+    })
+
     it('submits a slice of telemetry points to the pool', async () => {
       const submitTelemetry = jest.spyOn(telemetry['workerPool'], 'submitTelemetry')
       const points = Array(telemetry['MAX_POINTS_TO_SUBMIT'] + 1).fill(mockMetric)
@@ -136,6 +201,8 @@ describe('Telemetry', () => {
       expect(submitTelemetry).toHaveBeenCalledWith(
         points.slice(0, telemetry['MAX_POINTS_TO_SUBMIT']),
         GraffitiUtils.fromString(mockGraffiti),
+        'https://telemetry.example.com/points',
+        'key',
       )
       expect(telemetry['points']).toEqual(points.slice(telemetry['MAX_POINTS_TO_SUBMIT']))
       expect(telemetry['points']).toHaveLength(
@@ -4,6 +4,7 @@
 import { Assert } from '../assert'
 import { Blockchain } from '../blockchain'
 import { Config } from '../fileStores/config'
+import { FileSystem } from '../fileSystems'
 import { createRootLogger, Logger } from '../logger'
 import { MetricsMonitor } from '../metrics'
 import { Identity } from '../network'
@@ -13,6 +14,7 @@ import { Block } from '../primitives/block'
 import { TransactionHash } from '../primitives/transaction'
 import { GraffitiUtils, renderError, SetIntervalToken } from '../utils'
 import { WorkerPool } from '../workerPool'
+import { TelemetryCategory } from './interfaces/category'
 import { Field } from './interfaces/field'
 import { Metric } from './interfaces/metric'
 import { Tag } from './interfaces/tag'
@@ -25,6 +27,7 @@ export class Telemetry {
 
   private readonly chain: Blockchain
   private readonly config: Config
+  private readonly files: FileSystem | null
   private readonly defaultTags: Tag[]
   private readonly defaultFields: Field[]
   private readonly logger: Logger
@@ -36,13 +39,21 @@ export class Telemetry {
   private flushInterval: SetIntervalToken | null
   private metricsInterval: SetIntervalToken | null
   private points: Metric[]
+  private exportPoints: Metric[]
   private retries: number
   private _submitted: number
 
+  /**
+   * If false, points are only written to `telemetryExportPath` and are never
+   * submitted to the telemetry API
+   */
+  submitToApi = true
+
   constructor(options: {
     chain: Blockchain
     workerPool: WorkerPool
     config: Config
+    files?: FileSystem
     logger?: Logger
     metrics?: MetricsMonitor
     localPeerIdentity: Identity
@@ -52,6 +63,7 @@ export class Telemetry {
     this.chain = options.chain
     this.workerPool = options.workerPool
     this.config = options.config
+    this.files = options.files ?? null
     this.logger = options.logger ?? createRootLogger()
     this.metrics = options.metrics ?? null
     this.defaultTags = options.defaultTags ?? []
@@ -61,6 +73,7 @@ export class Telemetry {
     this.flushInterval = null
     this.metricsInterval = null
     this.points = []
+    this.exportPoints = []
     this.retries = 0
     this._submitted = 0
     this.started = false
@@ -126,6 +139,7 @@ export class Telemetry {
     for (const [id, meter] of this.metrics.p2p_OutboundMessagesByPeer) {
       this.submit({
         measurement: 'peer_messages',
+        category: TelemetryCategory.network,
         timestamp: new Date(),
         fields: [
           {
@@ -183,6 +197,66 @@ export class Telemetry {
         type: 'integer',
         value: this.chain.head.sequence,
       },
+      {
+        name: 'block_propagation',
+        type: 'float',
+        value: this.metrics.p2p_BlockPropagation.average,
+      },
+      {
+        name: 'mined_block_acknowledgement',
+        type: 'float',
+        value: this.metrics.p2p_MinedBlockAcknowledgement.average,
+      },
+      {
+        name: 'gossip_cache_hits',
+        type: 'float',
+        value: this.metrics.p2p_GossipCacheHits.rate5m,
+      },
+      {
+        name: 'gossip_cache_misses',
+        type: 'float',
+        value: this.metrics.p2p_GossipCacheMisses.rate5m,
+      },
+      {
+        name: 'gossip_cache_size',
+        type: 'integer',
+        value: this.metrics.p2p_GossipCacheSize.value,
+      },
+      {
+        name: 'header_cache_hits',
+        type: 'float',
+        value: this.metrics.chain_HeaderCacheHits.rate5m,
+      },
+      {
+        name: 'header_cache_misses',
+        type: 'float',
+        value: this.metrics.chain_HeaderCacheMisses.rate5m,
+      },
+      {
+        name: 'block_cache_hits',
+        type: 'float',
+        value: this.metrics.chain_BlockCacheHits.rate5m,
+      },
+      {
+        name: 'block_cache_misses',
+        type: 'float',
+        value: this.metrics.chain_BlockCacheMisses.rate5m,
+      },
+      {
+        name: 'tree_node_cache_hits',
+        type: 'float',
+        value: this.metrics.chain_TreeNodeCacheHits.rate5m,
+      },
+      {
+        name: 'tree_node_cache_misses',
+        type: 'float',
+        value: this.metrics.chain_TreeNodeCacheMisses.rate5m,
+      },
+      {
+        name: 'chain_cache_size',
+        type: 'integer',
+        value: this.chain.cacheSizeBytes,
+      },
     ]
 
     for (const [messageType, meter] of this.metrics.p2p_InboundTrafficByMessage) {
@@ -203,6 +277,7 @@ export class Telemetry {
 
     this.submit({
       measurement: 'node_stats',
+      category: TelemetryCategory.performance,
       timestamp: new Date(),
       tags: [
         {
@@ -223,6 +298,10 @@ export class Telemetry {
       return
     }
 
+    if (metric.category && !this.isCategoryEnabled(metric.category)) {
+      return
+    }
+
     if (metric.fields.length === 0) {
       throw new Error('Cannot submit metrics without fields')
     }
@@ -243,15 +322,35 @@ export class Telemetry {
       }
     }
 
-    this.points.push({
+    const point = {
       ...metric,
       timestamp: metric.timestamp,
       tags,
       fields,
-    })
+    }
+
+    if (this.submitToApi) {
+      this.points.push(point)
+    }
+
+    if (this.config.get('telemetryExportPath')) {
+      this.exportPoints.push(point)
+    }
+  }
+
+  isCategoryEnabled(category: TelemetryCategory): boolean {
+    const categories: string[] | string | undefined = this.config.get('telemetryCategories')
+    if (categories === undefined) {
+      return true
+    }
+
+    const enabled = Array.isArray(categories) ? categories : categories.split(',')
+    return enabled.map((c) => c.trim()).includes(category)
   }
 
   async flush(): Promise<void> {
+    await this.flushExport()
+
     const points = this.points.slice(0, this.MAX_POINTS_TO_SUBMIT)
     this.points = this.points.slice(this.MAX_POINTS_TO_SUBMIT)
 
@@ -261,7 +360,12 @@ export class Telemetry {
 
     try {
       const graffiti = GraffitiUtils.fromString(this.config.get('blockGraffiti'))
-      await this.workerPool.submitTelemetry(points, graffiti)
+      await this.workerPool.submitTelemetry(
+        points,
+        graffiti,
+        this.config.get('telemetryApi'),
+        this.config.get('telemetryApiKey'),
+      )
       this.logger.debug(`Submitted ${points.length} telemetry points`)
       this.retries = 0
       this._submitted += points.length
@@ -280,6 +384,30 @@ export class Telemetry {
     }
   }
 
+  /**
+   * Append queued points as JSON lines to `telemetryExportPath`
+   */
+  private async flushExport(): Promise<void> {
+    const points = this.exportPoints
+    this.exportPoints = []
+
+    const exportPath = this.config.get('telemetryExportPath')
+    if (points.length === 0 || !exportPath || !this.files) {
+      return
+    }
+
+    const lines = points.map((p) => JSON.stringify(p)).join('\n') + '\n'
+
+    try {
+      const path = this.files.resolve(exportPath)
+      await this.files.mkdir(this.files.dirname(path), { recursive: true })
+      await this.files.writeFile(path, lines, { flag: 'a' })
+      this.logger.debug(`Exported ${points.length} telemetry points to ${path}`)
+    } catch (error: unknown) {
+      this.logger.error(`Error exporting telemetry to ${exportPath}: ${renderError(error)}`)
+    }
+  }
+
   submitNodeStarted(): void {
     this.submit({
       measurement: 'node_started',
@@ -296,9 +424,30 @@ export class Telemetry {
     })
   }
 
+  submitClockSkew(offsetMs: number): void {
+    this.submit({
+      measurement: 'clock_skew',
+      fields: [{ name: 'offset_ms', type: 'integer', value: offsetMs }],
+      timestamp: new Date(),
+    })
+  }
+
+  /**
+   * The view key and hashes are left out so the telemetry can't be used to find
+   * the cold storage transaction
+   */
+  submitColdStorageSpend(sequence: number): void {
+    this.submit({
+      measurement: 'cold_storage_spend',
+      fields: [{ name: 'sequence', type: 'integer', value: sequence }],
+      timestamp: new Date(),
+    })
+  }
+
   submitBlockMined(block: Block): void {
     this.submit({
       measurement: 'block_mined',
+      category: TelemetryCategory.mining,
       fields: [
         {
           name: 'difficulty',
@@ -318,6 +467,7 @@ export class Telemetry {
   submitNewBlockSeen(block: Block, seenAt: Date): void {
     this.submit({
       measurement: 'block_propagation',
+      category: TelemetryCategory.network,
       timestamp: seenAt,
       tags: [
         {
@@ -340,6 +490,63 @@ export class Telemetry {
     })
   }
 
+  submitBlockPropagated(block: Block, elapsedMs: number): void {
+    this.submit({
+      measurement: 'block_forwarded',
+      category: TelemetryCategory.network,
+      timestamp: new Date(),
+      tags: [
+        {
+          name: 'hash',
+          value: block.header.hash.toString('hex'),
+        },
+      ],
+      fields: [
+        {
+          name: 'sequence',
+          type: 'integer',
+          value: block.header.sequence,
+        },
+        {
+          name: 'elapsed',
+          type: 'float',
+          value: elapsedMs,
+        },
+      ],
+    })
+  }
+
+  submitMinedBlockAcknowledged(block: Block, elapsedMs: number, peerIdentity: string): void {
+    this.submit({
+      measurement: 'block_mined_acknowledged',
+      category: TelemetryCategory.mining,
+      timestamp: new Date(),
+      tags: [
+        {
+          name: 'hash',
+          value: block.header.hash.toString('hex'),
+        },
+      ],
+      fields: [
+        {
+          name: 'sequence',
+          type: 'integer',
+          value: block.header.sequence,
+        },
+        {
+          name: 'elapsed',
+          type: 'float',
+          value: elapsedMs,
+        },
+        {
+          name: 'peer',
+          type: 'string',
+          value: peerIdentity,
+        },
+      ],
+    })
+  }
+
   submitNewTransactionSeen(transaction: Transaction, seenAt: Date): void {
     const hash = transaction.hash()
 
@@ -349,6 +556,7 @@ export class Telemetry {
 
     this.submit({
       measurement: 'transaction_propagation',
+      category: TelemetryCategory.network,
       timestamp: seenAt,
       tags: [
         {
@@ -4,16 +4,13 @@
 
 import bufio from 'bufio'
 import { MerkleTree } from '../../merkletree'
-import { NodeValue } from '../../merkletree/database/nodes'
 import { StructureHasher } from '../../merkletree/hasher'
-import { Side } from '../../merkletree/merkletree'
 import { IDatabase, IDatabaseEncoding, StringEncoding } from '../../storage'
 import { createDB } from '../helpers/storage'
 
 type StructureLeafValue = {
   element: string
   merkleHash: string
-  parentIndex: number
 }
 
 class StructureLeafEncoding implements IDatabaseEncoding<StructureLeafValue> {
@@ -22,7 +19,6 @@ class StructureLeafEncoding implements IDatabaseEncoding<StructureLeafValue> {
 
     bw.writeVarString(value.element)
     bw.writeVarString(value.merkleHash)
-    bw.writeU32(value.parentIndex)
 
     return bw.render()
   }
@@ -32,61 +28,14 @@ class StructureLeafEncoding implements IDatabaseEncoding<StructureLeafValue> {
 
     const element = bw.readVarString()
     const merkleHash = bw.readVarString()
-    const parentIndex = bw.readU32()
 
     return {
       element,
       merkleHash,
-      parentIndex,
     }
   }
 }
 
-class StructureNodeEncoding implements IDatabaseEncoding<NodeValue<string>> {
-  serialize(value: NodeValue<string>): Buffer {
-    const bw = bufio.write()
-
-    bw.writeVarString(value.hashOfSibling)
-
-    if (value.side === Side.Left) {
-      bw.writeU8(0)
-      bw.writeU32(value.parentIndex)
-    } else {
-      bw.writeU8(1)
-      bw.writeU32(value.leftIndex)
-    }
-
-    return bw.render()
-  }
-
-  deserialize(buffer: Buffer): NodeValue<string> {
-    const reader = bufio.read(buffer, true)
-
-    const hashOfSibling = reader.readVarString()
-
-    const sideNumber = reader.readU8()
-    const side = sideNumber === 0 ? Side.Left : Side.Right
-
-    const otherIndex = reader.readU32()
-
-    if (side === Side.Left) {
-      const leftNode = {
-        side,
-        hashOfSibling,
-        parentIndex: otherIndex,
-      } as const
-      return leftNode
-    }
-
-    const rightNode = {
-      side,
-      hashOfSibling,
-      leftIndex: otherIndex,
-    } as const
-    return rightNode
-  }
-}
-
 export async function makeTree({
   name,
   db,
@@ -106,7 +55,7 @@ export async function makeTree({
     hasher: new StructureHasher(),
     leafIndexKeyEncoding: new StringEncoding(),
     leafEncoding: new StructureLeafEncoding(),
-    nodeEncoding: new StructureNodeEncoding(),
+    nodeEncoding: new StringEncoding(),
     db: db,
     name: name,
     depth: depth,
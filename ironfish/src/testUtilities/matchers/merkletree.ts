@@ -4,14 +4,14 @@
 
 import diff from 'jest-diff'
 import { MerkleTree, Witness, WitnessSide } from '../../merkletree'
-import { NodeValue } from '../../merkletree/schema'
+import { completeNodeCount } from '../../merkletree/utils'
 import { makeError } from './utils'
 
 declare global {
   namespace jest {
     interface Matchers<R> {
-      toHaveLeaves(characters: string, parents: number[]): Promise<R>
-      toHaveNodes(nodeSpecs: [number, WitnessSide, number, string][]): Promise<R>
+      toHaveLeaves(characters: string): Promise<R>
+      toHaveNodes(hashes: string[]): Promise<R>
       toMatchTree(other: MerkleTree<string, string, string, string>): Promise<R>
       toMatchWitness(treeSize: number, rootHash: string, authPath: [WitnessSide, string][]): R
     }
@@ -22,14 +22,11 @@ expect.extend({
   async toHaveLeaves(
     tree: MerkleTree<string, string, string, string>,
     characters: string,
-    parents: number[],
   ): Promise<jest.CustomMatcherResult> {
     let error: string | null = null
     const treeSize = await tree.size()
 
-    if (characters.length !== parents.length) {
-      error = `expected characters to have same length as parents`
-    } else if (treeSize !== characters.length) {
+    if (treeSize !== characters.length) {
       error = `expected tree size ${treeSize} to be ${characters.length}`
     }
 
@@ -44,58 +41,29 @@ expect.extend({
         error = `expected element ${i} to be ${characters[i]}, but it is ${leaf.element}`
       } else if (leaf.merkleHash !== characters[i]) {
         error = `expected element ${i} to have hash ${characters[i]}, but it is ${leaf.merkleHash}`
-      } else if (leaf.parentIndex !== parents[i]) {
-        error = `expected element ${i} to have parent ${parents[i]}, but it is ${leaf.parentIndex}`
       }
     }
-    return makeError(
-      error,
-      `expected tree not to match ${characters} and ${parents.toString()}`,
-    )
+    return makeError(error, `expected tree not to match ${characters}`)
   },
   async toHaveNodes(
     tree: MerkleTree<string, string, string, string>,
-    nodeSpecs: [number, WitnessSide, number, string][],
+    hashes: string[],
   ): Promise<jest.CustomMatcherResult> {
     let error: string | null = null
 
-    const treeNodes = await tree.nodes.getAllValues()
-    const treeNodeCount = treeNodes.length
-    const nodeCounter = await tree.getCount('Nodes')
+    const nodeCount = completeNodeCount(await tree.size())
 
-    if (treeNodeCount !== nodeSpecs.length) {
-      error = `expected tree to have ${nodeSpecs.length} nodes, got ${treeNodeCount}`
-    } else if (nodeCounter !== nodeSpecs.length + 1) {
-      error = `expected Node counter to be ${nodeSpecs.length + 1} but it is ${nodeCounter}`
+    if (nodeCount !== hashes.length) {
+      error = `expected tree to have ${hashes.length} nodes, got ${nodeCount}`
     }
 
-    for (const nodeSpec of nodeSpecs) {
-      const [index, side, otherIndex, hashOfSibling] = nodeSpec
-
+    for (let index = 0; index < hashes.length; index++) {
       if (error !== null) {
         break
       }
 
-      // Sorry; this is a bit convoluted; I'm trying to make the tests as readable as possible.
-      // it's just building a list of elements
-      const nodeValue: NodeValue<string> = {
-        side,
-        hashOfSibling,
-        leftIndex: otherIndex,
-        parentIndex: otherIndex,
-      }
-
-      let expected
-      if (side === WitnessSide.Left) {
-        const { leftIndex: _leftIndex, ...expectedValue } = nodeValue
-        expected = expectedValue
-      } else {
-        const { parentIndex: _parentIndex, ...expectedValue } = nodeValue
-        expected = expectedValue
-      }
-
-      const node = await tree.getNode(index)
-      const diffString = diff(expected, node)
+      const node = await tree.getNodeOrNull(index)
+      const diffString = diff(hashes[index], node)
 
       if (diffString && diffString.includes('Expected')) {
         error = `node ${index} didn't match: \n\nDifference:\n\n${diffString}`
@@ -110,14 +78,10 @@ expect.extend({
   ): Promise<jest.CustomMatcherResult> {
     let error: string | null = null
     const treeLeafCount = await tree.getCount('Leaves')
-    const treeNodeCount = await tree.getCount('Nodes')
     const otherLeafCount = await other.getCount('Leaves')
-    const otherNodeCount = await other.getCount('Nodes')
 
     if (treeLeafCount !== otherLeafCount) {
       error = `tree ${tree.name} has ${treeLeafCount} leaves, but expected ${otherLeafCount}`
-    } else if (treeNodeCount !== otherNodeCount) {
-      error = `tree ${tree.name} has ${treeNodeCount} nodes, but expected ${otherNodeCount}`
     }
 
     for (let index = 0; index < treeLeafCount; index++) {
@@ -134,12 +98,15 @@ expect.extend({
       }
     }
 
-    for (let index = 1; index < treeNodeCount; index++) {
+    // Nodes past the complete nodes are left over from before a truncate
+    const nodeCount = completeNodeCount(treeLeafCount)
+
+    for (let index = 0; index < nodeCount; index++) {
       if (error !== null) {
         break
       }
-      const { ...expectedNode } = await tree.getNode(index)
-      const { ...actualNode } = await other.getNode(index)
+      const expectedNode = await other.getNode(index)
+      const actualNode = await tree.getNode(index)
 
       const diffString = diff(actualNode, expectedNode)
       if (diffString && diffString.includes('Expected')) {
@@ -53,6 +53,22 @@ export function mockNode(): any {
     syncer: mockSyncer(),
     workerPool: mockWorkerPool(),
     chain: mockChain(),
+    memoryBudget: mockMemoryBudget(),
+    diskMonitor: mockDiskMonitor(),
+  }
+}
+
+export function mockDiskMonitor(): any {
+  return {
+    status: 'ok',
+    critical: false,
+  }
+}
+
+export function mockMemoryBudget(): any {
+  return {
+    pressure: 'normal',
+    waitForCapacity: jest.fn(),
   }
 }
 
@@ -104,3 +120,13 @@ export function mockConfig(values: Record<string, any>): any {
     get: jest.fn((x) => values[x]),
   }
 }
+
+export function mockFileSystem(overrides: Record<string, any> = {}): any {
+  return {
+    resolve: jest.fn((path: string) => path),
+    dirname: jest.fn((path: string) => path.split('/').slice(0, -1).join('/')),
+    mkdir: jest.fn(),
+    writeFile: jest.fn(),
+    ...overrides,
+  }
+}
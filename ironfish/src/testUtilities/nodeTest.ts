@@ -79,6 +79,7 @@ export class NodeTest {
     sdk.config.setOverride('enableListenP2P', false)
     sdk.config.setOverride('enableTelemetry', false)
     sdk.config.setOverride('minimumBlockConfirmations', 0)
+    sdk.config.setOverride('ntpServer', '')
 
     // Allow tests to override default settings
     if (options?.config) {
@@ -2,7 +2,7 @@
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 
-type HRTime = [seconds: number, nanoseconds: number]
+export type HRTime = [seconds: number, nanoseconds: number]
 
 function start(): HRTime {
   return process.hrtime()
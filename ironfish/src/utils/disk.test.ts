@@ -0,0 +1,20 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { DiskUtils } from './disk'
+
+describe('DiskUtils', () => {
+  it('parses the available space from df', () => {
+    const output = [
+      'Filesystem     1024-blocks      Used Available Capacity Mounted on',
+      '/dev/sda1        102400000  51200000  51200000      50% /',
+    ].join('\n')
+
+    expect(DiskUtils.parseDfOutput(output)).toEqual(51200000 * 1024)
+  })
+
+  it('returns null for output it does not understand', () => {
+    expect(DiskUtils.parseDfOutput('')).toBeNull()
+    expect(DiskUtils.parseDfOutput('header\n/dev/sda1 a b c')).toBeNull()
+  })
+})
@@ -0,0 +1,45 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { execFile } from 'child_process'
+import fs from 'fs'
+import path from 'path'
+
+/**
+ * Parse the available bytes out of the output of `df -Pk`
+ */
+function parseDfOutput(output: string): number | null {
+  const lines = output.trim().split('\n')
+  if (lines.length < 2) {
+    return null
+  }
+
+  const columns = lines[lines.length - 1].trim().split(/\s+/)
+  const availableKb = Number(columns[3])
+
+  return Number.isNaN(availableKb) ? null : availableKb * 1024
+}
+
+/**
+ * Get the bytes available on the disk that holds `target`. Since the target
+ * may not have been created yet, the closest existing parent is checked.
+ * @returns null if the free space could not be determined, for example on Windows
+ */
+async function getFreeSpace(target: string): Promise<number | null> {
+  if (process.platform === 'win32') {
+    return null
+  }
+
+  let dir = path.resolve(target)
+  while (!fs.existsSync(dir) && path.dirname(dir) !== dir) {
+    dir = path.dirname(dir)
+  }
+
+  return new Promise((resolve) => {
+    execFile('df', ['-Pk', dir], (error, stdout) => {
+      resolve(error ? null : parseDfOutput(stdout))
+    })
+  })
+}
+
+export const DiskUtils = { getFreeSpace, parseDfOutput }
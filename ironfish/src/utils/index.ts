@@ -7,6 +7,7 @@ export * from './bench'
 export * from './bigint'
 export * from './blockchain'
 export * from './currency'
+export * from './disk'
 export * from './enums'
 export * from './error'
 export * from './file'
@@ -33,4 +33,25 @@ const getByteLength = (value: string, encoding?: BufferEncoding): number => {
   return Buffer.from(value, encoding).byteLength
 }
 
-export const StringUtils = { hash, hashToNumber, getByteLength }
+/**
+ * The Levenshtein edit distance between two strings, useful for suggesting the
+ * closest match when a user mistypes a name
+ */
+function distance(a: string, b: string): number {
+  let previous = Array.from({ length: b.length + 1 }, (_, i) => i)
+
+  for (let i = 1; i <= a.length; i++) {
+    const current = [i]
+
+    for (let j = 1; j <= b.length; j++) {
+      const cost = a[i - 1] === b[j - 1] ? 0 : 1
+      current[j] = Math.min(previous[j] + 1, current[j - 1] + 1, previous[j - 1] + cost)
+    }
+
+    previous = current
+  }
+
+  return previous[b.length]
+}
+
+export const StringUtils = { hash, hashToNumber, getByteLength, distance }
@@ -13,4 +13,28 @@ describe('TimeUtils', () => {
       expect(TimeUtils.renderEstimate(10, 10000, 1)).toEqual('2h 46m 30s')
     })
   })
+
+  describe('renderSpan', () => {
+    it('should render spans of milliseconds', () => {
+      expect(TimeUtils.renderSpan(500)).toEqual('0s')
+      expect(TimeUtils.renderSpan(90 * 1000)).toEqual('1m 30s')
+      expect(TimeUtils.renderSpan(61 * 60 * 1000)).toEqual('1h 1m 0s')
+    })
+  })
+
+  describe('parseDuration', () => {
+    it('should parse durations into milliseconds', () => {
+      expect(TimeUtils.parseDuration('30s')).toEqual(30 * 1000)
+      expect(TimeUtils.parseDuration('15m')).toEqual(15 * 60 * 1000)
+      expect(TimeUtils.parseDuration('24h')).toEqual(24 * 60 * 60 * 1000)
+      expect(TimeUtils.parseDuration('7D')).toEqual(7 * 24 * 60 * 60 * 1000)
+      expect(TimeUtils.parseDuration('1.5h')).toEqual(90 * 60 * 1000)
+    })
+
+    it('should throw on invalid durations', () => {
+      expect(() => TimeUtils.parseDuration('24')).toThrowError('Invalid duration')
+      expect(() => TimeUtils.parseDuration('h')).toThrowError('Invalid duration')
+      expect(() => TimeUtils.parseDuration('-1h')).toThrowError('Invalid duration')
+    })
+  })
 })
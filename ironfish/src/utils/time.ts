@@ -6,6 +6,32 @@ const MS_PER_SEC = 1000.0
 const MS_PER_MIN = 60.0 * 1000.0
 const MS_PER_HOUR = 60.0 * 60.0 * 1000.0
 
+/**
+ * Renders a span of time like `1h 2m 3s`
+ * @param spanMs the span of time in milliseconds
+ */
+const renderSpan = (spanMs: number): string => {
+  if (spanMs < MS_PER_MIN) {
+    const seconds = Math.floor(spanMs / MS_PER_SEC)
+    return `${seconds.toFixed(0)}s`
+  }
+
+  if (spanMs < MS_PER_HOUR) {
+    const minutes = Math.floor(spanMs / MS_PER_MIN)
+    spanMs -= minutes * MS_PER_MIN
+    const seconds = spanMs / MS_PER_SEC
+    return `${minutes.toFixed(0)}m ${seconds.toFixed(0)}s`
+  }
+
+  const hours = Math.floor(spanMs / MS_PER_HOUR)
+  spanMs -= hours * MS_PER_HOUR
+  const minutes = Math.floor(spanMs / MS_PER_MIN)
+  spanMs -= minutes * MS_PER_MIN
+  const seconds = Math.floor(spanMs / MS_PER_SEC)
+
+  return `${hours.toFixed(0)}h ${minutes.toFixed(0)}m ${seconds.toFixed(0)}s`
+}
+
 /**
  *
  * @param done how many items have been completed
@@ -14,35 +40,41 @@ const MS_PER_HOUR = 60.0 * 60.0 * 1000.0
  */
 const renderEstimate = (done: number, total: number, speed: number): string => {
   const remaining = total - done
-  let estimateSec = (remaining / speed) * 1000
+  const estimateMs = (remaining / speed) * 1000
 
   if (speed <= 0) {
     return 'N/A'
   }
 
-  if (estimateSec < 1000) {
+  if (estimateMs < 1000) {
     return 'soon'
   }
 
-  if (estimateSec < MS_PER_MIN) {
-    const seconds = Math.floor(estimateSec / MS_PER_SEC)
-    return `${seconds.toFixed(0)}s`
-  }
+  return renderSpan(estimateMs)
+}
 
-  if (estimateSec < MS_PER_HOUR) {
-    const minutes = Math.floor(estimateSec / MS_PER_MIN)
-    estimateSec -= minutes * MS_PER_MIN
-    const seconds = estimateSec / MS_PER_SEC
-    return `${minutes.toFixed(0)}m ${seconds.toFixed(0)}s`
-  }
+const DURATION_UNITS_MS: Record<string, number> = {
+  s: MS_PER_SEC,
+  m: MS_PER_MIN,
+  h: MS_PER_HOUR,
+  d: 24 * MS_PER_HOUR,
+  w: 7 * 24 * MS_PER_HOUR,
+}
 
-  const hours = Math.floor(estimateSec / MS_PER_HOUR)
-  estimateSec -= hours * MS_PER_HOUR
-  const minutes = Math.floor(estimateSec / MS_PER_MIN)
-  estimateSec -= minutes * MS_PER_MIN
-  const seconds = Math.floor(estimateSec / MS_PER_SEC)
+/**
+ * Parses a short duration string like `30m`, `24h`, or `7d` into milliseconds
+ * @throws if the duration is not a positive number followed by s, m, h, d, or w
+ */
+const parseDuration = (duration: string): number => {
+  const match = /^(\d+(?:\.\d+)?)\s*([smhdw])$/.exec(duration.trim().toLowerCase())
 
-  return `${hours.toFixed(0)}h ${minutes.toFixed(0)}m ${seconds.toFixed(0)}s`
+  if (!match) {
+    throw new Error(
+      `Invalid duration ${duration}, expected a number followed by one of s, m, h, d, w`,
+    )
+  }
+
+  return Number(match[1]) * DURATION_UNITS_MS[match[2]]
 }
 
-export const TimeUtils = { renderEstimate }
+export const TimeUtils = { renderEstimate, renderSpan, parseDuration }
@@ -4,7 +4,7 @@
 
 import axios, { AxiosError, AxiosRequestConfig } from 'axios'
 import { FollowChainStreamResponse } from './rpc/routes/chain/followChain'
-import { Metric } from './telemetry'
+import { TelemetrySubmission } from './telemetry'
 import { UnwrapPromise } from './utils/types'
 
 type FaucetTransaction = {
@@ -39,6 +39,37 @@ type ApiUser = {
   rank: number
 }
 
+/**
+ * Which releases a node is told about. Beta and nightly include pre-releases
+ * for testers, stable only includes full releases.
+ */
+export type ReleaseChannel = 'stable' | 'beta' | 'nightly'
+
+export const RELEASE_CHANNELS: ReleaseChannel[] = ['stable', 'beta', 'nightly']
+
+export type ApiVersionAsset = {
+  platform: string
+  arch: string
+  url: string
+  sha256: string
+  /**
+   * The ed25519 signature of the asset in hex
+   */
+  signature: string
+}
+
+export type ApiVersion = {
+  version: string
+  channel: ReleaseChannel
+  published_at: string
+  /**
+   * The percent of nodes, 0 to 100, that should update to this version
+   * automatically so a bad release only reaches some of them
+   */
+  rollout: number
+  assets: ApiVersionAsset[]
+}
+
 /**
  *  The API should be compatible with the Ironfish API here
  *  used to host our Facuet, BlockExplorer, and other things.
@@ -105,6 +136,15 @@ export class WebApi {
     await axios.post(`${this.host}/blocks`, { blocks: serialized }, options)
   }
 
+  async getLatestVersion(channel: ReleaseChannel = 'stable'): Promise<ApiVersion | null> {
+    const options = this.options()
+    options.params = { channel }
+    return await axios
+      .get<ApiVersion>(`${this.host}/versions/latest`, options)
+      .then((r) => r.data)
+      .catch(() => null)
+  }
+
   async getDepositAddress(): Promise<string> {
     const response = await axios.get<{ address: string }>(`${this.host}/deposits/address`)
     return response.data.address
@@ -196,8 +236,18 @@ export class WebApi {
     return response.data
   }
 
-  async submitTelemetry(payload: { points: Metric[]; graffiti: string }): Promise<void> {
-    await axios.post(`${this.host}/telemetry`, payload)
+  /**
+   * Submit telemetry to `endpoint`, which defaults to the telemetry endpoint
+   * of this API. The API key is sent as a bearer token if given.
+   */
+  async submitTelemetry(
+    payload: TelemetrySubmission,
+    options?: { endpoint?: string; apiKey?: string },
+  ): Promise<void> {
+    const endpoint = options?.endpoint || `${this.host}/telemetry`
+    const headers = options?.apiKey ? { Authorization: `Bearer ${options.apiKey}` } : {}
+
+    await axios.post(endpoint, payload, { headers })
   }
 
   options(headers: Record<string, string> = {}): AxiosRequestConfig {
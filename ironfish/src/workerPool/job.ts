@@ -5,7 +5,7 @@
 import { Event } from '../event'
 import { PromiseReject, PromiseResolve, PromiseUtils } from '../utils'
 import { handleRequest } from './tasks/handlers'
-import { JobAbortedError, JobAbortedMessage } from './tasks/jobAbort'
+import { JobAbortedError, JobAbortedMessage, JobTimeoutError } from './tasks/jobAbort'
 import { WorkerMessage } from './tasks/workerMessage'
 import { Worker } from './worker'
 
@@ -14,12 +14,29 @@ export class Job {
   request: WorkerMessage
   worker: Worker | null
   status: 'init' | 'queued' | 'executing' | 'success' | 'error' | 'aborted'
+  queuedAt: number
+  startedAt: number | null = null
   promise: Promise<WorkerMessage>
   resolve: PromiseResolve<WorkerMessage>
   reject: PromiseReject
 
   onEnded = new Event<[Job]>()
   onChange = new Event<[Job, Job['status']]>()
+  onTimeout = new Event<[Job]>()
+  onProgress = new Event<[done: number, total: number]>()
+
+  /**
+   * How many milliseconds the job may execute for before it's aborted with a
+   * JobTimeoutError. Null means the job never times out.
+   */
+  timeout: number | null = null
+
+  /**
+   * Aborted when the job is aborted, so tasks can cooperatively stop work
+   */
+  readonly abortController = new AbortController()
+
+  private timeoutTimer: ReturnType<typeof setTimeout> | null = null
 
   // This determines if JobAbortedError is fed into the response if the job is
   // aborted. The code base hasn't been upgraded to handle these so it should be
@@ -32,6 +49,7 @@ export class Job {
     this.request = request
     this.worker = null
     this.status = 'queued'
+    this.queuedAt = Date.now()
 
     const [promise, resolve, reject] = PromiseUtils.split<WorkerMessage>()
     this.promise = promise
@@ -44,13 +62,24 @@ export class Job {
     })
   }
 
-  abort(): void {
+  get signal(): AbortSignal {
+    return this.abortController.signal
+  }
+
+  /**
+   * Abort the job if it's queued or executing. If an error is given, the job
+   * result is always rejected with it so callers don't wait forever.
+   */
+  abort(error?: JobAbortedError): void {
     if (this.status !== 'queued' && this.status !== 'executing') {
       return
     }
 
+    this.clearTimeoutTimer()
+
     const prevStatus = this.status
     this.status = 'aborted'
+    this.abortController.abort()
     this.onChange.emit(this, prevStatus)
     this.onEnded.emit(this)
 
@@ -59,17 +88,47 @@ export class Job {
       this.worker.jobs.delete(this.id)
     }
 
-    if (this.reject && this.enableJobAbortedError) {
-      this.reject(new JobAbortedError())
+    if (this.reject && (error || this.enableJobAbortedError)) {
+      this.reject(error ?? new JobAbortedError())
     }
   }
 
+  /**
+   * Puts an executing job back into the queued state, used when the worker
+   * executing the job has to be replaced
+   */
+  requeue(): void {
+    if (this.status !== 'executing') {
+      return
+    }
+
+    this.clearTimeoutTimer()
+
+    const prevStatus = this.status
+    this.status = 'queued'
+    this.worker = null
+    this.startedAt = null
+    this.onChange.emit(this, prevStatus)
+  }
+
   execute(worker: Worker | null = null): Job {
     const prevStatus = this.status
     this.status = 'executing'
     this.worker = worker
+    this.startedAt = Date.now()
     this.onChange.emit(this, prevStatus)
 
+    if (this.timeout !== null) {
+      const timeout = this.timeout
+
+      this.timeoutTimer = setTimeout(() => {
+        this.abort(new JobTimeoutError(timeout))
+        this.onTimeout.emit(this)
+      }, timeout)
+
+      this.onEnded.once(() => this.clearTimeoutTimer())
+    }
+
     if (worker) {
       worker.send(this.request)
       return this
@@ -98,7 +157,22 @@ export class Job {
     return this
   }
 
+  /**
+   * Called by tasks to report how many of their steps are done. In a worker
+   * thread it is sent on to the job in the main thread.
+   */
+  progress(done: number, total: number): void {
+    this.onProgress.emit(done, total)
+  }
+
   result(): Promise<WorkerMessage> {
     return this.promise
   }
+
+  private clearTimeoutTimer(): void {
+    if (this.timeoutTimer) {
+      clearTimeout(this.timeoutTimer)
+      this.timeoutTimer = null
+    }
+  }
 }
@@ -3,9 +3,12 @@
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
 
 import '../testUtilities/matchers'
+import { Config } from '../fileStores'
+import { NodeFileProvider } from '../fileSystems'
 import { WorkerPool } from './pool'
-import { JobAbortedError } from './tasks/jobAbort'
+import { JobAbortedError, JobTimeoutError } from './tasks/jobAbort'
 import { JobError } from './tasks/jobError'
+import { JobProgressMessage } from './tasks/jobProgress'
 
 describe('Worker Pool', () => {
   let pool: WorkerPool
@@ -54,6 +57,26 @@ describe('Worker Pool', () => {
     expect(pool.workers.length).toBe(1)
   }, 10000)
 
+  it('reports the progress a worker sends while the job executes', async () => {
+    pool = new WorkerPool({ numWorkers: 1 })
+    pool.start()
+
+    const worker = pool.workers[0]
+    const job = pool.sleep()
+    const onProgress = jest.fn()
+    job.onProgress.on(onProgress)
+
+    const progress = new JobProgressMessage(1, 3, job.id)
+    worker['onMessageFromWorker'](progress.serializeWithMetadata())
+
+    expect(onProgress).toHaveBeenCalledWith(1, 3)
+    expect(job.status).toBe('executing')
+    expect(worker.jobs.has(job.id)).toBe(true)
+
+    job.abort()
+    await expect(job.result()).toRejectErrorInstance(JobAbortedError)
+  }, 10000)
+
   it('aborts job in worker', async () => {
     pool = new WorkerPool({ numWorkers: 1 })
     pool.start()
@@ -138,6 +161,119 @@ describe('Worker Pool', () => {
     expect(job.status).toBe('success')
   }, 10000)
 
+  it('scales up workers when jobs wait in the queue', async () => {
+    pool = new WorkerPool({ numWorkers: 1, maxWorkers: 2, scaleUpLatency: 0 })
+    pool.start()
+
+    const job1 = pool.sleep(Number.MAX_SAFE_INTEGER)
+    const job2 = pool.sleep(Number.MAX_SAFE_INTEGER)
+    job1.result().catch(() => {})
+    job2.result().catch(() => {})
+
+    expect(pool.workers.length).toBe(1)
+    expect(job2.status).toBe('queued')
+
+    pool['autoscale']()
+
+    expect(pool.workers.length).toBe(2)
+    expect(pool.queued).toBe(0)
+    expect(job2.status).toBe('executing')
+
+    // Already at maxWorkers
+    pool.sleep(Number.MAX_SAFE_INTEGER).result().catch(() => {})
+    pool['autoscale']()
+    expect(pool.workers.length).toBe(2)
+
+    await pool.stop()
+  }, 10000)
+
+  it('cancels jobs', async () => {
+    pool = new WorkerPool({ numWorkers: 1 })
+    pool.start()
+
+    const job = pool.sleep(60000)
+    expect(pool.getJobs()).toEqual([job])
+
+    expect(pool.cancelJob(job.id)).toBe(true)
+    await expect(job.result()).toRejectErrorInstance(JobAbortedError)
+
+    expect(job.status).toBe('aborted')
+    expect(pool.getJobs()).toEqual([])
+    expect(pool.cancelJob(job.id)).toBe(false)
+  }, 10000)
+
+  it('times out jobs and replaces the worker', async () => {
+    pool = new WorkerPool({ numWorkers: 1, jobTimeout: 10 })
+    pool.start()
+
+    const worker = pool.workers[0]
+    const job = pool.sleep(60000)
+
+    await expect(job.result()).toRejectErrorInstance(JobTimeoutError)
+
+    expect(job.status).toBe('aborted')
+    expect(pool.workers.length).toBe(1)
+    expect(pool.workers[0]).not.toBe(worker)
+    expect(pool.executing).toBe(0)
+  }, 10000)
+
+  it('does not time out transactions under the default config', async () => {
+    const files = await new NodeFileProvider().init()
+    const { nodeWorkersJobTimeout } = Config.GetDefaults(files, '/tmp')
+
+    pool = new WorkerPool({ numWorkers: 1, jobTimeout: nodeWorkersJobTimeout })
+    pool.start()
+
+    jest.spyOn(pool.workers[0], 'send').mockImplementation(() => undefined)
+    jest.useFakeTimers()
+
+    try {
+      pool.createTransaction('', BigInt(0), [], [], 0).catch(() => {})
+      const [job] = pool.getJobs()
+
+      jest.advanceTimersByTime(24 * 60 * 60 * 1000)
+
+      expect(job.timeout).toBeNull()
+      expect(job.status).toBe('executing')
+    } finally {
+      jest.useRealTimers()
+    }
+  }, 10000)
+
+  it('does not time out proving jobs', async () => {
+    pool = new WorkerPool({ numWorkers: 1, jobTimeout: 10 })
+    pool.start()
+
+    jest.spyOn(pool.workers[0], 'send').mockImplementation(() => undefined)
+
+    pool.createTransaction('', BigInt(0), [], [], 0).catch(() => {})
+    const [job] = pool.getJobs()
+
+    await new Promise((resolve) => setTimeout(resolve, 50))
+
+    expect(job.timeout).toBeNull()
+    expect(job.status).toBe('executing')
+  }, 10000)
+
+  it('runs proving jobs on the proving workers', async () => {
+    pool = new WorkerPool({ numWorkers: 0, provingWorkers: 1 })
+    pool.start()
+
+    expect(pool.workers.length).toBe(0)
+    expect(pool.provingWorkers.length).toBe(1)
+
+    const executeSpy = jest
+      .spyOn(pool.provingWorkers[0], 'execute')
+      .mockImplementation(() => undefined)
+
+    pool.createMinersFee('', BigInt(0), '').catch(() => {})
+    expect(executeSpy).toHaveBeenCalledTimes(1)
+
+    // Other jobs still execute in process
+    await pool.sleep().result()
+    expect(executeSpy).toHaveBeenCalledTimes(1)
+  }, 10000)
+
   it('should queue up job', () => {
     pool = new WorkerPool({ numWorkers: 1, maxJobs: 0 })
     pool.start()
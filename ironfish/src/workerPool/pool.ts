@@ -7,10 +7,12 @@ import _ from 'lodash'
 import { VerificationResult, VerificationResultReason } from '../consensus'
 import { createRootLogger, Logger } from '../logger'
 import { Meter, MetricsMonitor } from '../metrics'
+import { RollingAverage } from '../metrics/rollingAverage'
 import { Identity, PrivateIdentity } from '../network'
 import { Note } from '../primitives/note'
 import { Transaction } from '../primitives/transaction'
 import { Metric } from '../telemetry/interfaces/metric'
+import { SetIntervalToken } from '../utils'
 import { WorkerMessageStats } from './interfaces/workerMessageStats'
 import { Job } from './job'
 import { RoundRobinQueue } from './roundrobinqueue'
@@ -24,6 +26,7 @@ import {
   DecryptNotesResponse,
 } from './tasks/decryptNotes'
 import { GetUnspentNotesRequest, GetUnspentNotesResponse } from './tasks/getUnspentNotes'
+import { JobAbortedError } from './tasks/jobAbort'
 import { SleepRequest } from './tasks/sleep'
 import { SubmitTelemetryRequest } from './tasks/submitTelemetry'
 import { UnboxMessageRequest, UnboxMessageResponse } from './tasks/unboxMessage'
@@ -32,9 +35,22 @@ import {
   VerifyTransactionRequest,
   VerifyTransactionResponse,
 } from './tasks/verifyTransaction'
+import {
+  VerifyTransactionsRequest,
+  VerifyTransactionsResponse,
+} from './tasks/verifyTransactions'
 import { WorkerMessage, WorkerMessageType } from './tasks/workerMessage'
 import { getWorkerPath, Worker } from './worker'
 
+const AUTOSCALE_INTERVAL_MS = 1000
+const SCALE_DOWN_IDLE_MS = 60 * 1000
+
+// Jobs that build proofs, which run on the proving workers if there are any
+const PROVING_JOBS = new Set([
+  WorkerMessageType.CreateMinersFee,
+  WorkerMessageType.CreateTransaction,
+])
+
 /**
  * Manages the creation of worker threads and distribution of jobs to them.
  */
@@ -42,15 +58,30 @@ export class WorkerPool {
   readonly maxJobs: number
   readonly maxQueue: number
   readonly numWorkers: number
+  readonly numProvingWorkers: number
+  readonly maxWorkers: number
+  readonly scaleUpLatency: number
+  readonly jobTimeout: number
   readonly logger: Logger
 
   queue = new RoundRobinQueue()
   workers: Array<Worker> = []
+  provingWorkers: Array<Worker> = []
+  provingQueue = new RoundRobinQueue()
+  jobs = new Map<number, Job>()
   started = false
   completed = 0
   change: Meter | null
   speed: Meter | null
 
+  /**
+   * The average number of milliseconds recent jobs waited in the queue
+   */
+  readonly queueLatency = new RollingAverage(100)
+
+  private autoscaleInterval: SetIntervalToken | null = null
+  private lastQueuedAt = 0
+
   readonly stats = new Map<WorkerMessageType, WorkerMessageStats>([
     [WorkerMessageType.BoxMessage, { complete: 0, error: 0, queue: 0, execute: 0 }],
     [WorkerMessageType.CreateMinersFee, { complete: 0, error: 0, queue: 0, execute: 0 }],
@@ -62,6 +93,7 @@ export class WorkerPool {
     [WorkerMessageType.SubmitTelemetry, { complete: 0, error: 0, queue: 0, execute: 0 }],
     [WorkerMessageType.UnboxMessage, { complete: 0, error: 0, queue: 0, execute: 0 }],
     [WorkerMessageType.VerifyTransaction, { complete: 0, error: 0, queue: 0, execute: 0 }],
+    [WorkerMessageType.VerifyTransactions, { complete: 0, error: 0, queue: 0, execute: 0 }],
   ])
 
   get saturated(): boolean {
@@ -69,25 +101,45 @@ export class WorkerPool {
   }
 
   get executing(): number {
-    return _.sumBy(this.workers, (w) => w.jobs.size)
+    return (
+      _.sumBy(this.workers, (w) => w.jobs.size) +
+      _.sumBy(this.provingWorkers, (w) => w.jobs.size)
+    )
   }
 
   get queued(): number {
-    return this.queue.length
+    return this.queue.length + this.provingQueue.length
   }
 
   get capacity(): number {
     return this.workers.length * this.maxJobs
   }
 
+  /**
+   * @param options.numWorkers The number of workers to start with, and to scale back down to
+   * @param options.maxWorkers The number of workers to scale up to as jobs wait in the queue
+   * @param options.scaleUpLatency How long in ms the oldest job may wait before scaling up
+   * @param options.jobTimeout How long in ms a job may execute before it's aborted, 0 disables.
+   * Proving jobs can't be cancelled and can take long on slow machines, so they never time out.
+   * @param options.provingWorkers Workers that only build proofs. They start with the pool, so
+   * their proving keys are loaded before the first transaction, and are never scaled down.
+   */
   constructor(options?: {
     metrics?: MetricsMonitor
     numWorkers?: number
+    maxWorkers?: number
+    scaleUpLatency?: number
+    jobTimeout?: number
+    provingWorkers?: number
     maxQueue?: number
     maxJobs?: number
     logger?: Logger
   }) {
     this.numWorkers = options?.numWorkers ?? 1
+    this.maxWorkers = Math.max(this.numWorkers, options?.maxWorkers ?? this.numWorkers)
+    this.scaleUpLatency = options?.scaleUpLatency ?? 500
+    this.jobTimeout = options?.jobTimeout ?? 0
+    this.numProvingWorkers = options?.provingWorkers ?? 0
     this.maxJobs = options?.maxJobs ?? 1
     this.maxQueue = options?.maxQueue ?? 500
     this.change = options?.metrics?.addMeter() ?? null
@@ -105,11 +157,21 @@ export class WorkerPool {
     const path = getWorkerPath()
 
     for (let i = 0; i < this.numWorkers; i++) {
-      const worker = new Worker({ path, maxJobs: this.maxJobs })
-      this.workers.push(worker)
+      this.addWorker()
+    }
+
+    for (let i = 0; i < this.numProvingWorkers; i++) {
+      this.addWorker(this.provingWorkers)
+    }
+
+    // A pool without workers executes jobs in process, so there is nothing to scale
+    if (this.numWorkers > 0 && this.maxWorkers > this.numWorkers) {
+      this.autoscaleInterval = setInterval(() => this.autoscale(), AUTOSCALE_INTERVAL_MS)
     }
 
-    this.logger.debug(`Started worker pool with ${this.numWorkers} workers using ${path}`)
+    this.logger.debug(
+      `Started worker pool with ${this.numWorkers} workers and ${this.numProvingWorkers} proving workers using ${path}`,
+    )
   }
 
   async stop(): Promise<void> {
@@ -119,12 +181,19 @@ export class WorkerPool {
 
     this.started = false
 
-    const workers = this.workers
+    if (this.autoscaleInterval) {
+      clearInterval(this.autoscaleInterval)
+      this.autoscaleInterval = null
+    }
+
+    const workers = [...this.workers, ...this.provingWorkers]
     const queue = this.queue
 
     this.workers = []
+    this.provingWorkers = []
 
     queue.abortAll()
+    this.provingQueue.abortAll()
 
     await Promise.all(workers.map((w) => w.stop()))
   }
@@ -152,9 +221,11 @@ export class WorkerPool {
         side: Side
         hashOfSibling: Buffer
       }[]
+      spendKey?: string
     }[],
     receives: { publicAddress: string; amount: bigint; memo: string }[],
     expirationSequence: number,
+    onProgress?: (done: number, total: number) => void,
   ): Promise<Transaction> {
     const spendsWithSerializedNotes = spends.map((s) => ({
       ...s,
@@ -168,7 +239,7 @@ export class WorkerPool {
       receives,
     )
 
-    const response = await this.execute(request).result()
+    const response = await this.execute(request, { onProgress }).result()
 
     if (!(response instanceof CreateTransactionResponse)) {
       throw new Error('Invalid response')
@@ -196,6 +267,23 @@ export class WorkerPool {
       : { valid: false, reason: VerificationResultReason.ERROR }
   }
 
+  /**
+   * Verify a group of transactions with their proofs checked in a single batch.
+   * An invalid result means at least one of the transactions is invalid.
+   */
+  async verifyTransactions(transactions: Transaction[]): Promise<VerificationResult> {
+    const request = new VerifyTransactionsRequest(transactions.map((t) => t.serialize()))
+
+    const response = await this.execute(request).result()
+    if (!(response instanceof VerifyTransactionsResponse)) {
+      throw new Error('Invalid response')
+    }
+
+    return response.verified
+      ? { valid: true }
+      : { valid: false, reason: VerificationResultReason.ERROR }
+  }
+
   async boxMessage(
     plainTextMessage: string,
     sender: PrivateIdentity,
@@ -281,17 +369,59 @@ export class WorkerPool {
     return job
   }
 
-  async submitTelemetry(points: Metric[], graffiti: Buffer): Promise<void> {
-    const request = new SubmitTelemetryRequest(points, graffiti)
+  async submitTelemetry(
+    points: Metric[],
+    graffiti: Buffer,
+    endpoint: string,
+    apiKey: string,
+  ): Promise<void> {
+    const request = new SubmitTelemetryRequest(points, graffiti, endpoint, apiKey)
 
     await this.execute(request).result()
   }
 
-  private execute(request: Readonly<WorkerMessage>): Job {
+  /**
+   * Returns the jobs that are queued or executing
+   */
+  getJobs(): Job[] {
+    return Array.from(this.jobs.values())
+  }
+
+  /**
+   * Aborts a queued or executing job, rejecting its result with a JobAbortedError
+   * @returns false if there is no queued or executing job with the id
+   */
+  cancelJob(id: number): boolean {
+    const job = this.jobs.get(id)
+    if (!job) {
+      return false
+    }
+
+    job.abort(new JobAbortedError('Job was cancelled'))
+    return true
+  }
+
+  private execute(
+    request: Readonly<WorkerMessage>,
+    options?: { timeout?: number; onProgress?: (done: number, total: number) => void },
+  ): Job {
     const job = new Job(request)
+    const jobTimeout = PROVING_JOBS.has(request.type) ? 0 : this.jobTimeout
+    job.timeout = options?.timeout || jobTimeout || null
+    if (options?.onProgress) {
+      job.onProgress.on(options.onProgress)
+    }
     job.onEnded.once(this.jobEnded)
     job.onChange.on(this.jobChange)
+    job.onTimeout.once(this.jobTimedOut)
     job.onChange.emit(job, 'init')
+    this.jobs.set(job.id, job)
+
+    if (this.provingWorkers.length > 0 && PROVING_JOBS.has(request.type)) {
+      this.change?.add(1)
+      this.dispatch(job, this.provingWorkers, this.provingQueue)
+      return job
+    }
 
     // If there are no workers, execute in process
     if (this.workers.length === 0) {
@@ -300,47 +430,140 @@ export class WorkerPool {
     }
 
     this.change?.add(1)
+    this.dispatch(job, this.workers, this.queue)
+    return job
+  }
 
+  private dispatch(job: Job, workers: Worker[], queue: RoundRobinQueue): void {
     // If we already have queue, put it at the end of the queue
-    if (this.queue.length > 0) {
-      this.queue.enqueue(request.type, job)
-      return job
+    if (queue.length > 0) {
+      queue.enqueue(job.request.type, job)
+      return
     }
 
-    const worker = this.workers.find((w) => w.canTakeJobs)
+    const worker = workers.find((w) => w.canTakeJobs)
 
     if (!worker) {
-      this.queue.enqueue(request.type, job)
-      return job
+      queue.enqueue(job.request.type, job)
+      return
     }
 
+    this.queueLatency.add(0)
     worker.execute(job)
-    return job
   }
 
-  private executeQueue(): void {
-    if (this.queue.length === 0) {
+  private addWorker(workers = this.workers): Worker {
+    const worker = new Worker({ path: getWorkerPath(), maxJobs: this.maxJobs })
+    workers.push(worker)
+    return worker
+  }
+
+  /**
+   * Adds a worker when the oldest queued job has waited longer than `scaleUpLatency`, and
+   * removes idle workers above `numWorkers` once nothing has been queued for a while.
+   */
+  private autoscale(): void {
+    const now = Date.now()
+    const oldestQueuedAt = this.queue.oldestQueuedAt()
+
+    if (oldestQueuedAt !== null) {
+      this.lastQueuedAt = now
+
+      const waiting = now - oldestQueuedAt
+
+      if (waiting >= this.scaleUpLatency && this.workers.length < this.maxWorkers) {
+        this.addWorker()
+        this.logger.debug(`Scaled worker pool up to ${this.workers.length} workers`)
+
+        for (let i = 0; i < this.maxJobs; i++) {
+          this.executeQueue()
+        }
+      }
+
+      return
+    }
+
+    const idle = now - this.lastQueuedAt
+
+    if (this.workers.length > this.numWorkers && idle >= SCALE_DOWN_IDLE_MS) {
+      const index = this.workers.findIndex((w) => !w.executing)
+      if (index === -1) {
+        return
+      }
+
+      const [worker] = this.workers.splice(index, 1)
+      void worker.stop()
+
+      // Wait for another idle period before removing the next worker
+      this.lastQueuedAt = now
+      this.logger.debug(`Scaled worker pool down to ${this.workers.length} workers`)
+    }
+  }
+
+  private executeQueue(workers = this.workers, queue = this.queue): void {
+    if (queue.length === 0) {
       return
     }
 
-    const worker = this.workers.find((w) => w.canTakeJobs)
+    const worker = workers.find((w) => w.canTakeJobs)
     if (!worker) {
       return
     }
 
-    const job = this.queue.nextJob()
+    const job = queue.nextJob()
     if (!job) {
       return
     }
 
+    this.queueLatency.add(Date.now() - job.queuedAt)
     worker.execute(job)
   }
 
-  private jobEnded = (): void => {
+  private jobEnded = (job: Job): void => {
+    this.jobs.delete(job.id)
     this.change?.add(-1)
     this.speed?.add(1)
     this.completed++
     this.executeQueue()
+    this.executeQueue(this.provingWorkers, this.provingQueue)
+  }
+
+  /**
+   * A worker thread that doesn't finish a job in time is probably stuck on it and can't
+   * process the abort, so it's replaced and any other jobs it was executing are requeued
+   */
+  private jobTimedOut = (job: Job): void => {
+    this.logger.warn(
+      `Worker job ${job.id} (${WorkerMessageType[job.request.type]}) timed out after ${
+        job.timeout ?? 0
+      }ms`,
+    )
+
+    const worker = job.worker
+    const proving = worker !== null && this.provingWorkers.includes(worker)
+    const workers = proving ? this.provingWorkers : this.workers
+    const queue = proving ? this.provingQueue : this.queue
+
+    const index = worker ? workers.indexOf(worker) : -1
+    if (!worker || index === -1) {
+      return
+    }
+
+    const requeue = Array.from(worker.jobs.values())
+    worker.jobs.clear()
+    void worker.stop()
+
+    workers.splice(index, 1)
+    this.addWorker(workers)
+
+    for (const other of requeue) {
+      other.requeue()
+      queue.enqueue(other.request.type, other)
+    }
+
+    for (let i = 0; i < this.maxJobs; i++) {
+      this.executeQueue(workers, queue)
+    }
   }
 
   private jobChange = (job: Job, prevStatus: Job['status']): void => {
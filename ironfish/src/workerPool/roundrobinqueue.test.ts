@@ -0,0 +1,68 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+
+import { Job } from './job'
+import { RoundRobinQueue } from './roundrobinqueue'
+import { SleepRequest } from './tasks/sleep'
+import { SubmitTelemetryRequest } from './tasks/submitTelemetry'
+import { WorkerMessageType } from './tasks/workerMessage'
+
+describe('RoundRobinQueue', () => {
+  const sleepJob = () => new Job(new SleepRequest(0, ''))
+  const telemetryJob = () => new Job(new SubmitTelemetryRequest([], Buffer.alloc(0), '', ''))
+
+  it('takes jobs round robin by type', () => {
+    const queue = new RoundRobinQueue({})
+
+    const sleep1 = sleepJob()
+    const sleep2 = sleepJob()
+    const telemetry = telemetryJob()
+
+    queue.enqueue(WorkerMessageType.Sleep, sleep1)
+    queue.enqueue(WorkerMessageType.Sleep, sleep2)
+    queue.enqueue(WorkerMessageType.SubmitTelemetry, telemetry)
+
+    expect(queue.length).toBe(3)
+    expect(queue.lengthOf(WorkerMessageType.Sleep)).toBe(2)
+
+    expect(queue.nextJob()).toBe(sleep1)
+    expect(queue.nextJob()).toBe(telemetry)
+    expect(queue.nextJob()).toBe(sleep2)
+    expect(queue.nextJob()).toBeUndefined()
+  })
+
+  it('takes higher priority jobs first', () => {
+    const queue = new RoundRobinQueue({
+      [WorkerMessageType.SubmitTelemetry]: 0,
+      [WorkerMessageType.Sleep]: 2,
+    })
+
+    const telemetry = telemetryJob()
+    const sleep1 = sleepJob()
+    const sleep2 = sleepJob()
+
+    queue.enqueue(WorkerMessageType.SubmitTelemetry, telemetry)
+    queue.enqueue(WorkerMessageType.Sleep, sleep1)
+    queue.enqueue(WorkerMessageType.Sleep, sleep2)
+
+    expect(queue.nextJob()).toBe(sleep1)
+    expect(queue.nextJob()).toBe(sleep2)
+    expect(queue.nextJob()).toBe(telemetry)
+  })
+
+  it('returns when the oldest job was queued', () => {
+    const queue = new RoundRobinQueue()
+    expect(queue.oldestQueuedAt()).toBeNull()
+
+    const sleep = sleepJob()
+    sleep.queuedAt = 100
+    const telemetry = telemetryJob()
+    telemetry.queuedAt = 50
+
+    queue.enqueue(WorkerMessageType.Sleep, sleep)
+    queue.enqueue(WorkerMessageType.SubmitTelemetry, telemetry)
+
+    expect(queue.oldestQueuedAt()).toBe(50)
+  })
+})
@@ -5,9 +5,29 @@
 import { Job } from './job'
 import { WorkerMessageType } from './tasks/workerMessage'
 
+/**
+ * Jobs with a higher priority are always taken from the queue before jobs with a
+ * lower priority. Jobs of the same priority are taken round robin by type.
+ */
+export const WORKER_JOB_PRIORITIES: Readonly<Partial<Record<WorkerMessageType, number>>> = {
+  [WorkerMessageType.CreateMinersFee]: 2,
+  [WorkerMessageType.CreateTransaction]: 2,
+  [WorkerMessageType.BoxMessage]: 1,
+  [WorkerMessageType.DecryptNotes]: 1,
+  [WorkerMessageType.GetUnspentNotes]: 1,
+  [WorkerMessageType.UnboxMessage]: 1,
+  [WorkerMessageType.VerifyTransaction]: 1,
+  [WorkerMessageType.VerifyTransactions]: 1,
+  [WorkerMessageType.SubmitTelemetry]: 0,
+  [WorkerMessageType.Sleep]: 0,
+}
+
+const DEFAULT_PRIORITY = 1
+
 export class RoundRobinQueue {
   private queueMap: Map<WorkerMessageType, Array<Job>>
-  private lastMapIndex = 0
+  // Job types grouped by priority, highest priority first
+  private priorityGroups: Array<{ types: WorkerMessageType[]; lastIndex: number }>
 
   /**
    * Returns the total length of all queues.
@@ -27,8 +47,11 @@ export class RoundRobinQueue {
    * will be guaranteed not to be backed up by one type queueing many jobs. This does not
    * solve the issue of long-running jobs, however. Luckily, this is not something we
    * have had to worry about yet.
+   *
+   * Types with a higher priority in `priorities` are always served first, so a burst
+   * of background jobs can't delay jobs a user is waiting on.
    */
-  constructor() {
+  constructor(priorities: Partial<Record<WorkerMessageType, number>> = WORKER_JOB_PRIORITIES) {
     this.queueMap = new Map()
 
     // Numerical enums return all keys, then all values as strings when
@@ -37,9 +60,42 @@ export class RoundRobinQueue {
       .map((v) => Number(v))
       .filter((v) => !isNaN(v))
 
+    const groups = new Map<number, WorkerMessageType[]>()
+
     for (const key of enumKeys) {
       this.queueMap.set(key, [])
+
+      const priority = priorities[key as WorkerMessageType] ?? DEFAULT_PRIORITY
+      const group = groups.get(priority) ?? []
+      group.push(key)
+      groups.set(priority, group)
+    }
+
+    this.priorityGroups = Array.from(groups.entries())
+      .sort(([a], [b]) => b - a)
+      .map(([, types]) => ({ types, lastIndex: 0 }))
+  }
+
+  /**
+   * Returns the length of the queue for a single type
+   */
+  lengthOf(type: WorkerMessageType): number {
+    return this.queueMap.get(type)?.length ?? 0
+  }
+
+  /**
+   * Returns the time the longest waiting job was queued, or null if the queue is empty
+   */
+  oldestQueuedAt(): number | null {
+    let oldest: number | null = null
+
+    for (const queue of this.queueMap.values()) {
+      if (queue.length && (oldest === null || queue[0].queuedAt < oldest)) {
+        oldest = queue[0].queuedAt
+      }
     }
+
+    return oldest
   }
 
   /**
@@ -56,27 +112,28 @@ export class RoundRobinQueue {
   }
 
   /**
-   * Get the next job across all queues. Will iterate over each type
-   * starting from the type after the last executed job's type.
+   * Get the next job across all queues. Takes from the highest priority group with
+   * queued jobs, iterating over each type in that group starting from the type
+   * after the last executed job's type.
    */
   nextJob(): Job | undefined {
-    const queueEntries = Array.from(this.queueMap.values())
-
-    for (let i = 1; i <= queueEntries.length; i++) {
-      const index = (this.lastMapIndex + i) % queueEntries.length
-
-      const queue = queueEntries[index]
-      if (!queue.length) {
-        continue
+    for (const group of this.priorityGroups) {
+      for (let i = 1; i <= group.types.length; i++) {
+        const index = (group.lastIndex + i) % group.types.length
+
+        const queue = this.queueMap.get(group.types[index])
+        if (!queue || !queue.length) {
+          continue
+        }
+
+        const nextJob = queue.shift()
+        if (!nextJob) {
+          continue
+        }
+
+        group.lastIndex = index
+        return nextJob
       }
-
-      const nextJob = queue.shift()
-      if (!nextJob) {
-        continue
-      }
-
-      this.lastMapIndex = index
-      return nextJob
     }
   }
 
@@ -8,6 +8,7 @@ import { Witness } from '../../merkletree'
 import { NoteHasher } from '../../merkletree/hasher'
 import { Side } from '../../merkletree/merkletree'
 import { BigIntUtils } from '../../utils/bigint'
+import type { Job } from '../job'
 import { WorkerMessage, WorkerMessageType } from './workerMessage'
 import { WorkerTask } from './workerTask'
 
@@ -18,6 +19,7 @@ export class CreateTransactionRequest extends WorkerMessage {
   readonly spendKey: string
   readonly transactionFee: bigint
   readonly expirationSequence: number
+  // Spends with a spendKey are for notes of another account, like a fee payer
   readonly spends: {
     note: Buffer
     treeSize: number
@@ -26,6 +28,7 @@ export class CreateTransactionRequest extends WorkerMessage {
       side: Side
       hashOfSibling: Buffer
     }[]
+    spendKey?: string
   }[]
   readonly receives: { publicAddress: string; amount: bigint; memo: string }[]
 
@@ -38,6 +41,7 @@ export class CreateTransactionRequest extends WorkerMessage {
       treeSize: number
       rootHash: Buffer
       authPath: { side: Side; hashOfSibling: Buffer }[]
+      spendKey?: string
     }[],
     receives: { publicAddress: string; amount: bigint; memo: string }[],
     jobId?: number,
@@ -74,6 +78,8 @@ export class CreateTransactionRequest extends WorkerMessage {
         }
         bw.writeVarBytes(step.hashOfSibling)
       }
+
+      bw.writeVarString(spend.spendKey ?? '')
     }
 
     bw.writeU64(this.receives.length)
@@ -107,7 +113,9 @@ export class CreateTransactionRequest extends WorkerMessage {
         authPath.push({ side, hashOfSibling })
       }
 
-      spends.push({ note, treeSize, rootHash, authPath })
+      const spendKey = reader.readVarString() || undefined
+
+      spends.push({ note, treeSize, rootHash, authPath, spendKey })
     }
 
     const receivesLength = reader.readU64()
@@ -143,7 +151,8 @@ export class CreateTransactionRequest extends WorkerMessage {
         8 + // treeSize
         bufio.sizeVarBytes(spend.rootHash) +
         8 + // authPath length
-        authPathSize
+        authPathSize +
+        bufio.sizeVarString(spend.spendKey ?? '')
     }
 
     let receivesSize = 0
@@ -202,32 +211,43 @@ export class CreateTransactionTask extends WorkerTask {
     return CreateTransactionTask.instance
   }
 
-  execute({
-    jobId,
-    transactionFee,
-    spendKey,
-    spends,
-    receives,
-    expirationSequence,
-  }: CreateTransactionRequest): CreateTransactionResponse {
+  execute(
+    {
+      jobId,
+      transactionFee,
+      spendKey,
+      spends,
+      receives,
+      expirationSequence,
+    }: CreateTransactionRequest,
+    job?: Job,
+  ): CreateTransactionResponse {
     const transaction = new Transaction()
     transaction.setExpirationSequence(expirationSequence)
 
+    // Each spend and receive builds a proof, then posting builds the change
+    // and signs the transaction
+    const total = spends.length + receives.length + 1
+    let done = 0
+
     for (const spend of spends) {
       const note = Note.deserialize(spend.note)
       transaction.spend(
-        spendKey,
+        spend.spendKey ?? spendKey,
         note,
         new Witness(spend.treeSize, spend.rootHash, spend.authPath, noteHasher),
       )
+      job?.progress(++done, total)
     }
 
     for (const { publicAddress, amount, memo } of receives) {
       const note = new Note(publicAddress, amount, memo)
       transaction.receive(spendKey, note)
+      job?.progress(++done, total)
     }
 
     const serializedTransactionPosted = transaction.post(spendKey, undefined, transactionFee)
+    job?.progress(++done, total)
 
     return new CreateTransactionResponse(serializedTransactionPosted, jobId)
   }
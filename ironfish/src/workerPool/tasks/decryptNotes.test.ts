@@ -81,5 +81,30 @@ describe('DecryptNotesTask', () => {
         ],
       })
     })
+
+    it('decrypts notes for many accounts in one batch', async () => {
+      const account = await useAccountFixture(nodeTest.accounts, 'a')
+      const other = await useAccountFixture(nodeTest.accounts, 'b')
+      const transaction = await useMinersTxFixture(nodeTest.accounts, account)
+      const serializedNote = transaction.getNote(0).serialize()
+
+      const task = new DecryptNotesTask()
+      const request = new DecryptNotesRequest(
+        [other, account, other].map((a) => ({
+          serializedNote,
+          incomingViewKey: a.incomingViewKey,
+          outgoingViewKey: a.outgoingViewKey,
+          spendingKey: a.spendingKey,
+          currentNoteIndex: null,
+        })),
+      )
+      const response = task.execute(request)
+
+      expect(response.notes).toEqual([
+        null,
+        expect.objectContaining({ forSpender: false, index: null, nullifier: null }),
+        null,
+      ])
+    })
   })
 })
@@ -1,9 +1,11 @@
 /* This Source Code Form is subject to the terms of the Mozilla Public
  * License, v. 2.0. If a copy of the MPL was not distributed with this
  * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { decryptNotes } from '@ironfish/rust-nodejs'
+import { BufferMap } from 'buffer-map'
 import bufio from 'bufio'
 import { ACCOUNT_KEY_LENGTH } from '../../account'
-import { NOTE_LENGTH } from '../../primitives/note'
+import { Note, NOTE_LENGTH } from '../../primitives/note'
 import { ENCRYPTED_NOTE_LENGTH, NoteEncrypted } from '../../primitives/noteEncrypted'
 import { WorkerMessage, WorkerMessageType } from './workerMessage'
 import { WorkerTask } from './workerTask'
@@ -211,19 +213,53 @@ export class DecryptNotesTask extends WorkerTask {
   }
 
   execute({ payloads, jobId }: DecryptNotesRequest): DecryptNotesResponse {
+    // Trial decrypt every unique note with every unique account in one
+    // native call, rather than crossing into native code for each payload
+    const noteIndexes = new BufferMap<number>()
+    const keyIndexes = new Map<string, number>()
+    const serializedNotes: Buffer[] = []
+    const incomingViewKeys: string[] = []
+    const outgoingViewKeys: string[] = []
+
+    const indexes = payloads.map(({ serializedNote, incomingViewKey, outgoingViewKey }) => {
+      let noteIndex = noteIndexes.get(serializedNote)
+      if (noteIndex === undefined) {
+        noteIndex = serializedNotes.push(serializedNote) - 1
+        noteIndexes.set(serializedNote, noteIndex)
+      }
+
+      const keys = `${incomingViewKey}:${outgoingViewKey}`
+      let keyIndex = keyIndexes.get(keys)
+      if (keyIndex === undefined) {
+        keyIndex = incomingViewKeys.push(incomingViewKey) - 1
+        outgoingViewKeys.push(outgoingViewKey)
+        keyIndexes.set(keys, keyIndex)
+      }
+
+      return { noteIndex, keyIndex }
+    })
+
+    const matches = new Map<string, { forSpender: boolean; note: Buffer }>()
+    for (const match of decryptNotes(serializedNotes, incomingViewKeys, outgoingViewKeys)) {
+      matches.set(`${match.noteIndex}:${match.keyIndex}`, match)
+    }
+
     const decryptedNotes = []
 
-    for (const {
-      serializedNote,
-      incomingViewKey,
-      outgoingViewKey,
-      spendingKey,
-      currentNoteIndex,
-    } of payloads) {
+    for (const [i, payload] of payloads.entries()) {
+      const { serializedNote, outgoingViewKey, spendingKey, currentNoteIndex } = payload
+      const { noteIndex, keyIndex } = indexes[i]
+      const match = matches.get(`${noteIndex}:${keyIndex}`)
+
+      if (!match) {
+        decryptedNotes.push(null)
+        continue
+      }
+
       const note = new NoteEncrypted(serializedNote)
 
       // Try decrypting the note as the owner
-      const receivedNote = note.decryptNoteForOwner(incomingViewKey)
+      const receivedNote = match.forSpender ? null : new Note(match.note)
       if (receivedNote && receivedNote.value() !== BigInt(0)) {
         decryptedNotes.push({
           index: currentNoteIndex,
@@ -238,8 +274,11 @@ export class DecryptNotesTask extends WorkerTask {
         continue
       }
 
-      // Try decrypting the note as the spender
-      const spentNote = note.decryptNoteForSpender(outgoingViewKey)
+      // Try decrypting the note as the spender. The batch only tries this
+      // when decrypting as the owner fails, so retry a zero value note here
+      const spentNote = match.forSpender
+        ? new Note(match.note)
+        : note.decryptNoteForSpender(outgoingViewKey)
       if (spentNote && spentNote.value() !== BigInt(0)) {
         decryptedNotes.push({
           index: currentNoteIndex,
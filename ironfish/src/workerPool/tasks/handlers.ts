@@ -11,6 +11,7 @@ import { SleepTask } from './sleep'
 import { SubmitTelemetryTask } from './submitTelemetry'
 import { UnboxMessageTask } from './unboxMessage'
 import { VerifyTransactionTask } from './verifyTransaction'
+import { VerifyTransactionsTask } from './verifyTransactions'
 import { WorkerMessage, WorkerMessageType } from './workerMessage'
 import { WorkerTask } from './workerTask'
 
@@ -22,10 +23,12 @@ export const handlers: Record<WorkerMessageType, WorkerTask | undefined> = {
   [WorkerMessageType.GetUnspentNotes]: GetUnspentNotesTask.getInstance(),
   [WorkerMessageType.JobAborted]: undefined,
   [WorkerMessageType.JobError]: undefined,
+  [WorkerMessageType.JobProgress]: undefined,
   [WorkerMessageType.Sleep]: SleepTask.getInstance(),
   [WorkerMessageType.SubmitTelemetry]: SubmitTelemetryTask.getInstance(),
   [WorkerMessageType.UnboxMessage]: UnboxMessageTask.getInstance(),
   [WorkerMessageType.VerifyTransaction]: VerifyTransactionTask.getInstance(),
+  [WorkerMessageType.VerifyTransactions]: VerifyTransactionsTask.getInstance(),
 }
 
 export async function handleRequest(request: WorkerMessage, job: Job): Promise<WorkerMessage> {
@@ -24,8 +24,17 @@ export class JobAbortedMessage extends WorkerMessage {
 export class JobAbortedError extends Error {
   type = 'JobAbortedError'
 
-  constructor() {
-    super()
+  constructor(message?: string) {
+    super(message)
     this.name = 'JobAbortedError'
   }
 }
+
+export class JobTimeoutError extends JobAbortedError {
+  type = 'JobTimeoutError'
+
+  constructor(timeout: number) {
+    super(`Job timed out after ${timeout}ms`)
+    this.name = 'JobTimeoutError'
+  }
+}
@@ -0,0 +1,13 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { JobProgressMessage } from './jobProgress'
+
+describe('JobProgressMessage', () => {
+  it('serializes the object to a buffer and deserializes to the original object', () => {
+    const message = new JobProgressMessage(42, 118, 7)
+    const buffer = message.serialize()
+    const deserializedMessage = JobProgressMessage.deserialize(message.jobId, buffer)
+    expect(deserializedMessage).toEqual(message)
+  })
+})
@@ -0,0 +1,38 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import bufio from 'bufio'
+import { WorkerMessage, WorkerMessageType } from './workerMessage'
+
+/**
+ * Sent from a worker while a job executes to report how many of its steps
+ * are done
+ */
+export class JobProgressMessage extends WorkerMessage {
+  readonly done: number
+  readonly total: number
+
+  constructor(done: number, total: number, jobId?: number) {
+    super(WorkerMessageType.JobProgress, jobId)
+    this.done = done
+    this.total = total
+  }
+
+  serialize(): Buffer {
+    const bw = bufio.write(this.getSize())
+    bw.writeU32(this.done)
+    bw.writeU32(this.total)
+    return bw.render()
+  }
+
+  static deserialize(jobId: number, buffer: Buffer): JobProgressMessage {
+    const reader = bufio.read(buffer, true)
+    const done = reader.readU32()
+    const total = reader.readU32()
+    return new JobProgressMessage(done, total, jobId)
+  }
+
+  getSize(): number {
+    return 4 + 4
+  }
+}
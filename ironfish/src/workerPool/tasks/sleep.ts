@@ -4,7 +4,6 @@
 
 import type { Job } from '../job'
 import bufio from 'bufio'
-import { PromiseUtils } from '../../utils'
 import { WorkerMessage, WorkerMessageType } from './workerMessage'
 import { WorkerTask } from './workerTask'
 
@@ -73,13 +72,26 @@ export class SleepTask extends WorkerTask {
   }
 
   async execute({ jobId, sleep, error }: SleepRequest, job: Job): Promise<SleepResponse> {
-    await PromiseUtils.sleep(sleep)
+    // Stop sleeping as soon as the job is aborted
+    await new Promise<void>((resolve) => {
+      const onAbort = () => {
+        clearTimeout(timeout)
+        resolve()
+      }
+
+      const timeout = setTimeout(() => {
+        job.signal.removeEventListener('abort', onAbort)
+        resolve()
+      }, sleep)
+
+      job.signal.addEventListener('abort', onAbort, { once: true })
+    })
 
     if (error) {
       throw new Error(error)
     }
 
-    if (job.status === 'aborted') {
+    if (job.signal.aborted) {
       return new SleepResponse(true, jobId)
     }
 
@@ -42,7 +42,12 @@ describe('SubmitTelemetryRequest', () => {
       timestamp: new Date(),
     }
 
-    const request = new SubmitTelemetryRequest([mockMetric], GraffitiUtils.fromString(''))
+    const request = new SubmitTelemetryRequest(
+      [mockMetric],
+      GraffitiUtils.fromString(''),
+      'https://telemetry.example.com/points',
+      'key',
+    )
     const buffer = request.serialize()
     const deserializedRequest = SubmitTelemetryRequest.deserialize(request.jobId, buffer)
     expect(deserializedRequest).toEqual(request)
@@ -78,10 +83,18 @@ describe('SubmitTelemetryTask', () => {
       const graffitiBuffer = GraffitiUtils.fromString('testgraffiti')
       const graffiti = GraffitiUtils.toHuman(graffitiBuffer)
       const task = new SubmitTelemetryTask()
-      const request = new SubmitTelemetryRequest(points, graffitiBuffer)
+      const request = new SubmitTelemetryRequest(
+        points,
+        graffitiBuffer,
+        'https://telemetry.example.com/points',
+        'key',
+      )
 
       await task.execute(request)
-      expect(submitTelemetryPointsToApi).toHaveBeenCalledWith({ points, graffiti })
+      expect(submitTelemetryPointsToApi).toHaveBeenCalledWith(
+        { points, graffiti },
+        { endpoint: 'https://telemetry.example.com/points', apiKey: 'key' },
+      )
     })
   })
 })
@@ -12,16 +12,28 @@ import { WorkerTask } from './workerTask'
 export class SubmitTelemetryRequest extends WorkerMessage {
   readonly points: Metric[]
   readonly graffiti: Buffer
-
-  constructor(points: Metric[], graffiti: Buffer, jobId?: number) {
+  readonly endpoint: string
+  readonly apiKey: string
+
+  constructor(
+    points: Metric[],
+    graffiti: Buffer,
+    endpoint: string,
+    apiKey: string,
+    jobId?: number,
+  ) {
     super(WorkerMessageType.SubmitTelemetry, jobId)
     this.points = points
     this.graffiti = graffiti
+    this.endpoint = endpoint
+    this.apiKey = apiKey
   }
 
   serialize(): Buffer {
     const bw = bufio.write(this.getSize())
     bw.writeVarBytes(this.graffiti)
+    bw.writeVarString(this.endpoint, 'utf8')
+    bw.writeVarString(this.apiKey, 'utf8')
     bw.writeU64(this.points.length)
 
     for (const point of this.points) {
@@ -64,6 +76,8 @@ export class SubmitTelemetryRequest extends WorkerMessage {
   static deserialize(jobId: number, buffer: Buffer): SubmitTelemetryRequest {
     const reader = bufio.read(buffer, true)
     const graffiti = reader.readVarBytes()
+    const endpoint = reader.readVarString('utf8')
+    const apiKey = reader.readVarString('utf8')
     const pointsLength = reader.readU64()
     const points = []
     for (let i = 0; i < pointsLength; i++) {
@@ -114,11 +128,13 @@ export class SubmitTelemetryRequest extends WorkerMessage {
 
       points.push({ measurement, tags, timestamp, fields })
     }
-    return new SubmitTelemetryRequest(points, graffiti, jobId)
+    return new SubmitTelemetryRequest(points, graffiti, endpoint, apiKey, jobId)
   }
 
   getSize(): number {
     let size = 8 + bufio.sizeVarBytes(this.graffiti)
+    size += bufio.sizeVarString(this.endpoint, 'utf8')
+    size += bufio.sizeVarString(this.apiKey, 'utf8')
     for (const point of this.points) {
       size += bufio.sizeVarString(point.measurement)
       size += bufio.sizeVarString(point.timestamp.toISOString())
@@ -186,9 +202,14 @@ export class SubmitTelemetryTask extends WorkerTask {
     jobId,
     points,
     graffiti,
+    endpoint,
+    apiKey,
   }: SubmitTelemetryRequest): Promise<SubmitTelemetryResponse> {
     const api = new WebApi()
-    await api.submitTelemetry({ points, graffiti: GraffitiUtils.toHuman(graffiti) })
+    await api.submitTelemetry(
+      { points, graffiti: GraffitiUtils.toHuman(graffiti) },
+      { endpoint, apiKey },
+    )
     return new SubmitTelemetryResponse(jobId)
   }
 }
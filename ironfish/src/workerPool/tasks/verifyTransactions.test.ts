@@ -0,0 +1,52 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { createNodeTest, useAccountFixture, useMinersTxFixture } from '../../testUtilities'
+import {
+  VerifyTransactionsRequest,
+  VerifyTransactionsResponse,
+  VerifyTransactionsTask,
+} from './verifyTransactions'
+
+describe('VerifyTransactionsRequest', () => {
+  it('serializes the object to a buffer and deserializes to the original object', () => {
+    const request = new VerifyTransactionsRequest([Buffer.from('a'), Buffer.from('bcd')])
+    const buffer = request.serialize()
+    const deserializedRequest = VerifyTransactionsRequest.deserialize(request.jobId, buffer)
+    expect(deserializedRequest).toEqual(request)
+  })
+})
+
+describe('VerifyTransactionsResponse', () => {
+  it('serializes the object to a buffer and deserializes to the original object', () => {
+    const response = new VerifyTransactionsResponse(true, 0)
+    const buffer = response.serialize()
+    const deserializedResponse = VerifyTransactionsResponse.deserialize(response.jobId, buffer)
+    expect(deserializedResponse).toEqual(response)
+  })
+})
+
+describe('VerifyTransactionsTask', () => {
+  const nodeTest = createNodeTest()
+
+  describe('execute', () => {
+    it('verifies the transactions', async () => {
+      const account = await useAccountFixture(nodeTest.accounts)
+      const transaction = await useMinersTxFixture(nodeTest.accounts, account)
+
+      const task = new VerifyTransactionsTask()
+      const request = new VerifyTransactionsRequest([transaction.serialize()])
+
+      const response = task.execute(request)
+      expect(response).toEqual(new VerifyTransactionsResponse(true, request.jobId))
+    })
+
+    it('returns false for an invalid transaction', () => {
+      const task = new VerifyTransactionsTask()
+      const request = new VerifyTransactionsRequest([Buffer.from('invalid')])
+
+      const response = task.execute(request)
+      expect(response).toEqual(new VerifyTransactionsResponse(false, request.jobId))
+    })
+  })
+})
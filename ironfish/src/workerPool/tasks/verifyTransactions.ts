@@ -0,0 +1,98 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at https://mozilla.org/MPL/2.0/. */
+import { verifyTransactions } from '@ironfish/rust-nodejs'
+import bufio from 'bufio'
+import { WorkerMessage, WorkerMessageType } from './workerMessage'
+import { WorkerTask } from './workerTask'
+
+export class VerifyTransactionsRequest extends WorkerMessage {
+  readonly transactionsPosted: Buffer[]
+
+  constructor(transactionsPosted: Buffer[], jobId?: number) {
+    super(WorkerMessageType.VerifyTransactions, jobId)
+    this.transactionsPosted = transactionsPosted
+  }
+
+  serialize(): Buffer {
+    const bw = bufio.write(this.getSize())
+    bw.writeU64(this.transactionsPosted.length)
+    for (const transactionPosted of this.transactionsPosted) {
+      bw.writeVarBytes(transactionPosted)
+    }
+    return bw.render()
+  }
+
+  static deserialize(jobId: number, buffer: Buffer): VerifyTransactionsRequest {
+    const reader = bufio.read(buffer, true)
+    const transactionsPosted = []
+    const length = reader.readU64()
+    for (let i = 0; i < length; i++) {
+      transactionsPosted.push(reader.readVarBytes())
+    }
+    return new VerifyTransactionsRequest(transactionsPosted, jobId)
+  }
+
+  getSize(): number {
+    let size = 8
+    for (const transactionPosted of this.transactionsPosted) {
+      size += bufio.sizeVarBytes(transactionPosted)
+    }
+    return size
+  }
+}
+
+export class VerifyTransactionsResponse extends WorkerMessage {
+  readonly verified: boolean
+
+  constructor(verified: boolean, jobId: number) {
+    super(WorkerMessageType.VerifyTransactions, jobId)
+    this.verified = verified
+  }
+
+  serialize(): Buffer {
+    const bw = bufio.write(this.getSize())
+    bw.writeU8(Number(this.verified))
+    return bw.render()
+  }
+
+  static deserialize(jobId: number, buffer: Buffer): VerifyTransactionsResponse {
+    const reader = bufio.read(buffer, true)
+    const verified = Boolean(reader.readU8())
+    return new VerifyTransactionsResponse(verified, jobId)
+  }
+
+  getSize(): number {
+    return 1
+  }
+}
+
+/**
+ * Verifies a group of transactions with their proofs checked in one batch,
+ * which is much cheaper than verifying each transaction separately
+ */
+export class VerifyTransactionsTask extends WorkerTask {
+  private static instance: VerifyTransactionsTask | undefined
+
+  static getInstance(): VerifyTransactionsTask {
+    if (!VerifyTransactionsTask.instance) {
+      VerifyTransactionsTask.instance = new VerifyTransactionsTask()
+    }
+    return VerifyTransactionsTask.instance
+  }
+
+  execute({
+    jobId,
+    transactionsPosted,
+  }: VerifyTransactionsRequest): VerifyTransactionsResponse {
+    let verified = false
+
+    try {
+      verified = verifyTransactions(transactionsPosted)
+    } catch {
+      verified = false
+    }
+
+    return new VerifyTransactionsResponse(verified, jobId)
+  }
+}
@@ -17,6 +17,8 @@ export enum WorkerMessageType {
   SubmitTelemetry = 8,
   UnboxMessage = 9,
   VerifyTransaction = 10,
+  VerifyTransactions = 11,
+  JobProgress = 12,
 }
 
 export abstract class WorkerMessage implements Serializable {
@@ -16,10 +16,15 @@ import { DecryptNotesRequest, DecryptNotesResponse } from './tasks/decryptNotes'
 import { GetUnspentNotesRequest, GetUnspentNotesResponse } from './tasks/getUnspentNotes'
 import { JobAbortedError, JobAbortedMessage } from './tasks/jobAbort'
 import { JobError, JobErrorMessage } from './tasks/jobError'
+import { JobProgressMessage } from './tasks/jobProgress'
 import { SleepRequest, SleepResponse } from './tasks/sleep'
 import { SubmitTelemetryRequest, SubmitTelemetryResponse } from './tasks/submitTelemetry'
 import { UnboxMessageRequest, UnboxMessageResponse } from './tasks/unboxMessage'
 import { VerifyTransactionRequest, VerifyTransactionResponse } from './tasks/verifyTransaction'
+import {
+  VerifyTransactionsRequest,
+  VerifyTransactionsResponse,
+} from './tasks/verifyTransactions'
 import { WorkerMessage, WorkerMessageType } from './tasks/workerMessage'
 
 export class Worker {
@@ -156,6 +161,10 @@ export class Worker {
     const job = new Job(requestBody)
     this.jobs.set(job.id, job)
 
+    job.onProgress.on((done, total) => {
+      this.send(new JobProgressMessage(done, total, job.id))
+    })
+
     job
       .execute()
       .result()
@@ -183,12 +192,20 @@ export class Worker {
 
     const { body, jobId, type } = header
     const job = this.jobs.get(jobId)
-    this.jobs.delete(jobId)
 
     if (!job) {
       return
     }
 
+    // Progress is sent while the job is still executing
+    if (type === WorkerMessageType.JobProgress) {
+      const progress = JobProgressMessage.deserialize(jobId, body)
+      job.progress(progress.done, progress.total)
+      return
+    }
+
+    this.jobs.delete(jobId)
+
     const prevStatus = job.status
     job.status = 'success'
     job.onChange.emit(job, prevStatus)
@@ -246,6 +263,8 @@ export class Worker {
         throw new Error('JobAbort should not be sent as a request')
       case WorkerMessageType.JobError:
         throw new Error('JobError should not be sent as a request')
+      case WorkerMessageType.JobProgress:
+        throw new Error('JobProgress should not be sent as a request')
       case WorkerMessageType.Sleep:
         return SleepRequest.deserialize(jobId, request)
       case WorkerMessageType.SubmitTelemetry:
@@ -254,6 +273,8 @@ export class Worker {
         return UnboxMessageRequest.deserialize(jobId, request)
       case WorkerMessageType.VerifyTransaction:
         return VerifyTransactionRequest.deserialize(jobId, request)
+      case WorkerMessageType.VerifyTransactions:
+        return VerifyTransactionsRequest.deserialize(jobId, request)
     }
   }
 
@@ -277,6 +298,8 @@ export class Worker {
         return JobAbortedMessage.deserialize()
       case WorkerMessageType.JobError:
         return JobErrorMessage.deserialize(jobId, response)
+      case WorkerMessageType.JobProgress:
+        return JobProgressMessage.deserialize(jobId, response)
       case WorkerMessageType.Sleep:
         return SleepResponse.deserialize(jobId, response)
       case WorkerMessageType.SubmitTelemetry:
@@ -285,6 +308,8 @@ export class Worker {
         return UnboxMessageResponse.deserialize(jobId, response)
       case WorkerMessageType.VerifyTransaction:
         return VerifyTransactionResponse.deserialize(jobId, response)
+      case WorkerMessageType.VerifyTransactions:
+        return VerifyTransactionsResponse.deserialize(jobId, response)
     }
   }
 }